@@ -1,8 +1,157 @@
 package config
 
+import (
+	"encoding/json"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// Config describes a cache so deployments can retarget it without code
+// changes. Fields left empty keep the defaults of the cache package. Every
+// field can be set from the environment variable in its env tag.
 type Config struct {
+	// DSN, if set, selects the backend directly, e.g. redis://host:6379/0,
+	// and takes precedence over Backend and Addr
+	DSN string `yaml:"dsn" json:"dsn" env:"CACHE_DSN"`
+	// Backend is the driver name: redis, memory, memcached, bolt, badger or
+	// null, defaulting to redis
+	Backend string `yaml:"backend" json:"backend" env:"CACHE_BACKEND"`
+	// Addr is host:port for Redis, a comma separated list of servers for
+	// memcached and a path for bolt and badger
+	Addr     string `yaml:"addr" json:"addr" env:"CACHE_ADDR"`
+	Username string `yaml:"username" json:"username" env:"CACHE_USERNAME"`
+	Password string `yaml:"password" json:"password" env:"CACHE_PASSWORD"`
+	DB       int    `yaml:"db" json:"db" env:"CACHE_DB"`
+	PoolSize int    `yaml:"pool_size" json:"pool_size" env:"CACHE_POOL_SIZE"`
+
+	TLS     bool   `yaml:"tls" json:"tls" env:"CACHE_TLS"`
+	TLSCert string `yaml:"tls_cert" json:"tls_cert" env:"CACHE_TLS_CERT"`
+	TLSKey  string `yaml:"tls_key" json:"tls_key" env:"CACHE_TLS_KEY"`
+	TLSCA   string `yaml:"tls_ca" json:"tls_ca" env:"CACHE_TLS_CA"`
+
+	Prefix string `yaml:"prefix" json:"prefix" env:"CACHE_PREFIX"`
+	// DefaultTTL is used by Set and Wrap, 0 stores keys forever. Without it
+	// they need an explicit ttl.
+	DefaultTTL *Duration `yaml:"default_ttl" json:"default_ttl" env:"CACHE_DEFAULT_TTL"`
+	Timeout    Duration  `yaml:"timeout" json:"timeout" env:"CACHE_TIMEOUT"`
+	// LocalTTL puts an in-process tier keeping entries that long in front of
+	// the backend
+	LocalTTL Duration `yaml:"local_ttl" json:"local_ttl" env:"CACHE_LOCAL_TTL"`
+	// MaxEntries bounds the memory backend
+	MaxEntries int  `yaml:"max_entries" json:"max_entries" env:"CACHE_MAX_ENTRIES"`
+	Disabled   bool `yaml:"disabled" json:"disabled" env:"CACHE_DISABLED"`
 }
 
 func NewConfig() *Config {
 	return &Config{}
 }
+
+// Duration is a time.Duration written as a string like "10m" in files and
+// environment variables
+type Duration time.Duration
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// FromEnv returns the configuration in the CACHE_* environment variables
+func FromEnv() (Config, error) {
+	var c Config
+	err := c.ApplyEnv()
+	return c, err
+}
+
+// FromFile reads the configuration from a YAML file, or a JSON one if path
+// ends in .json
+func FromFile(path string) (Config, error) {
+	var c Config
+	err := readFile(path, &c)
+	return c, err
+}
+
+// Manager configures several named caches, one of which is the default
+type Manager struct {
+	Default string            `yaml:"default" json:"default"`
+	Stores  map[string]Config `yaml:"stores" json:"stores"`
+}
+
+// ManagerFromFile reads the stores of a Manager like FromFile
+func ManagerFromFile(path string) (Manager, error) {
+	var m Manager
+	err := readFile(path, &m)
+	return m, err
+}
+
+func readFile(path string, v interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if filepath.Ext(path) == ".json" {
+		err = json.Unmarshal(data, v)
+	} else {
+		err = yaml.Unmarshal(data, v)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// ApplyEnv overrides the fields whose environment variable is set, e.g. to
+// adjust a file per environment
+func (c *Config) ApplyEnv() error {
+	v := reflect.ValueOf(c).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		name := v.Type().Field(i).Tag.Get("env")
+		value, ok := os.LookupEnv(name)
+		if name == "" || !ok {
+			continue
+		}
+		if err := setField(v.Field(i), value); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func setField(field reflect.Value, value string) error {
+	if field.Kind() == reflect.Pointer {
+		field.Set(reflect.New(field.Type().Elem()))
+		field = field.Elem()
+	}
+	if d, ok := field.Addr().Interface().(*Duration); ok {
+		return d.UnmarshalText([]byte(value))
+	}
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(n))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	}
+	return nil
+}
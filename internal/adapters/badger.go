@@ -0,0 +1,159 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"github.com/dgraph-io/badger/v4"
+	"sync"
+	"time"
+)
+
+// DefaultBadgerGCInterval is how often OpenBadger runs value log garbage
+// collection
+const DefaultBadgerGCInterval = 5 * time.Minute
+
+// badgerGCRatio is the share of a value log file that must be garbage for
+// badger to rewrite it
+const badgerGCRatio = 0.5
+
+// BadgerStore is a Store over a badger database. Entries with an expiration
+// are written with a badger TTL, so badger drops them without a janitor.
+type BadgerStore struct {
+	DB *badger.DB
+
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewBadgerStore creates a Store over db that runs value log garbage
+// collection every gcInterval, if positive, until it is closed
+func NewBadgerStore(db *badger.DB, gcInterval time.Duration) *BadgerStore {
+	b := &BadgerStore{DB: db, stop: make(chan struct{}), done: make(chan struct{})}
+	if gcInterval > 0 {
+		go b.collectGarbage(gcInterval)
+	} else {
+		close(b.done)
+	}
+	return b
+}
+
+// OpenBadger opens or creates the badger database in dir and returns a
+// CacheServer persisting its entries there
+func OpenBadger(dir string, opts ...MemoryOption) (*Memory, error) {
+	db, err := badger.Open(badger.DefaultOptions(dir).WithLogger(nil))
+	if err != nil {
+		return nil, err
+	}
+	// Badger expires entries itself, the janitor is not needed
+	opts = append([]MemoryOption{WithCleanupInterval(0)}, opts...)
+	return NewStoreServer(NewBadgerStore(db, DefaultBadgerGCInterval), opts...), nil
+}
+
+func (b *BadgerStore) collectGarbage(interval time.Duration) {
+	defer close(b.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			// Each run rewrites at most one file, keep going while it helps
+			for b.DB.RunValueLogGC(badgerGCRatio) == nil {
+			}
+		case <-b.stop:
+			return
+		}
+	}
+}
+
+func (b *BadgerStore) Name() string {
+	return "badger"
+}
+
+// Update reads and writes key in one transaction, running fn again after a
+// backoff when the transaction conflicts with a concurrent one, up to
+// storeConflicts.MaxAttempts times before failing with ErrStoreConflict
+func (b *BadgerStore) Update(ctx context.Context, key string, fn func(current []byte) ([]byte, bool)) error {
+	return retryConflicts(ctx, func() error {
+		return b.DB.Update(func(txn *badger.Txn) error {
+			var current []byte
+			item, err := txn.Get([]byte(key))
+			switch {
+			case err == nil:
+				if current, err = item.ValueCopy(nil); err != nil {
+					return err
+				}
+			case !errors.Is(err, badger.ErrKeyNotFound):
+				return err
+			}
+
+			next, write := fn(current)
+			switch {
+			case !write:
+				return nil
+			case next == nil:
+				return txn.Delete([]byte(key))
+			}
+			return txn.SetEntry(badgerEntry(key, next))
+		})
+	}, func(err error) bool {
+		return errors.Is(err, badger.ErrConflict)
+	})
+}
+
+// PutMany writes entries with a badger write batch
+func (b *BadgerStore) PutMany(ctx context.Context, entries map[string][]byte) error {
+	batch := b.DB.NewWriteBatch()
+	defer batch.Cancel()
+	for key, data := range entries {
+		if err := batch.SetEntry(badgerEntry(key, data)); err != nil {
+			return err
+		}
+	}
+	return batch.Flush()
+}
+
+func (b *BadgerStore) Range(ctx context.Context, fn func(key string, data []byte) bool) error {
+	return b.DB.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			item := it.Item()
+			keep := true
+			err := item.Value(func(data []byte) error {
+				keep = fn(string(item.Key()), data)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			if !keep {
+				return nil
+			}
+		}
+		return nil
+	})
+}
+
+// Close stops the garbage collection and closes the database
+func (b *BadgerStore) Close() error {
+	b.closeOnce.Do(func() {
+		close(b.stop)
+	})
+	<-b.done
+	return b.DB.Close()
+}
+
+// badgerEntry creates the entry for an encoded value, expiring it when the
+// value does
+func badgerEntry(key string, data []byte) *badger.Entry {
+	entry := badger.NewEntry([]byte(key), data)
+	if expiresAt := entryExpiresAt(data); !expiresAt.IsZero() {
+		// Badger TTLs have second precision, round up so it never expires early
+		entry = entry.WithTTL(time.Until(expiresAt) + time.Second)
+	}
+	return entry
+}
@@ -0,0 +1,179 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"math/bits"
+	"strings"
+)
+
+// ErrBitOp is returned by BitOp for an operation other than AND, OR, XOR and
+// NOT, or NOT over more than one key
+var ErrBitOp = errors.New("unknown bit operation")
+
+// SetBit sets the bit at offset of the string at key to value, 0 or 1, and
+// returns its previous value. The string grows as needed.
+func (r *RedisClient) SetBit(ctx context.Context, key string, offset int64, value int) (int64, error) {
+	return r.Client.SetBit(ctx, key, offset, value).Result()
+}
+
+// GetBit returns the bit at offset of the string at key, 0 past its end
+func (r *RedisClient) GetBit(ctx context.Context, key string, offset int64) (int64, error) {
+	return r.Client.GetBit(ctx, key, offset).Result()
+}
+
+// BitCount returns the number of bits set in the string at key
+func (r *RedisClient) BitCount(ctx context.Context, key string) (int64, error) {
+	return r.Client.BitCount(ctx, key, nil).Result()
+}
+
+// BitOp stores the bitwise AND, OR, XOR or NOT of the strings at keys in dest
+// and returns its length in bytes
+func (r *RedisClient) BitOp(ctx context.Context, op, dest string, keys ...string) (int64, error) {
+	switch strings.ToUpper(op) {
+	case "AND":
+		return r.Client.BitOpAnd(ctx, dest, keys...).Result()
+	case "OR":
+		return r.Client.BitOpOr(ctx, dest, keys...).Result()
+	case "XOR":
+		return r.Client.BitOpXor(ctx, dest, keys...).Result()
+	case "NOT":
+		if len(keys) != 1 {
+			return 0, ErrBitOp
+		}
+		return r.Client.BitOpNot(ctx, dest, keys[0]).Result()
+	}
+	return 0, ErrBitOp
+}
+
+// SetBit sets the bit at offset of the string at key to value, 0 or 1, and
+// returns its previous value. The string grows as needed.
+func (m *Memory) SetBit(ctx context.Context, key string, offset int64, value int) (int64, error) {
+	if offset < 0 || value&^1 != 0 {
+		return 0, ErrNotInteger
+	}
+	var previous int64
+	err := m.update(ctx, key, func(s *memoryShard, e *memoryEntry) error {
+		if e == nil {
+			e = &memoryEntry{kind: memoryString}
+			s.items[key] = e
+		}
+		if e.kind != memoryString {
+			return ErrWrongType
+		}
+		data := []byte(e.value)
+		if index := int(offset / 8); index >= len(data) {
+			data = append(data, make([]byte, index-len(data)+1)...)
+		}
+		previous = bitAt(data, offset)
+		mask := byte(1) << (7 - offset%8)
+		if value == 1 {
+			data[offset/8] |= mask
+		} else {
+			data[offset/8] &^= mask
+		}
+		e.value = string(data)
+		return nil
+	})
+	return previous, err
+}
+
+// GetBit returns the bit at offset of the string at key, 0 past its end
+func (m *Memory) GetBit(ctx context.Context, key string, offset int64) (int64, error) {
+	var bit int64
+	err := m.bitmap(ctx, key, func(data string) {
+		bit = bitAt([]byte(data), offset)
+	})
+	return bit, err
+}
+
+// BitCount returns the number of bits set in the string at key
+func (m *Memory) BitCount(ctx context.Context, key string) (int64, error) {
+	var count int64
+	err := m.bitmap(ctx, key, func(data string) {
+		count = 0
+		for i := 0; i < len(data); i++ {
+			count += int64(bits.OnesCount8(data[i]))
+		}
+	})
+	return count, err
+}
+
+// BitOp stores the bitwise AND, OR, XOR or NOT of the strings at keys in dest
+// and returns its length in bytes. Shorter strings are padded with zeros and
+// dest is deleted when the result is empty. The sources are read one at a
+// time, not in one atomic step.
+func (m *Memory) BitOp(ctx context.Context, op, dest string, keys ...string) (int64, error) {
+	op = strings.ToUpper(op)
+	if op != "AND" && op != "OR" && op != "XOR" && op != "NOT" || op == "NOT" && len(keys) != 1 {
+		return 0, ErrBitOp
+	}
+	sources := make([][]byte, len(keys))
+	size := 0
+	for i, key := range keys {
+		if err := m.bitmap(ctx, key, func(data string) {
+			sources[i] = []byte(data)
+		}); err != nil {
+			return 0, err
+		}
+		size = max(size, len(sources[i]))
+	}
+
+	result := make([]byte, size)
+	for i := range result {
+		byteAt := func(source []byte) byte {
+			if i < len(source) {
+				return source[i]
+			}
+			return 0
+		}
+		result[i] = byteAt(sources[0])
+		for _, source := range sources[1:] {
+			switch op {
+			case "AND":
+				result[i] &= byteAt(source)
+			case "OR":
+				result[i] |= byteAt(source)
+			case "XOR":
+				result[i] ^= byteAt(source)
+			}
+		}
+		if op == "NOT" {
+			result[i] = ^result[i]
+		}
+	}
+
+	err := m.update(ctx, dest, func(s *memoryShard, e *memoryEntry) error {
+		if size == 0 {
+			delete(s.items, dest)
+			return nil
+		}
+		s.items[dest] = &memoryEntry{kind: memoryString, value: string(result)}
+		return nil
+	})
+	return int64(size), err
+}
+
+// bitmap runs fn on the string at key, which is empty when it does not exist
+func (m *Memory) bitmap(ctx context.Context, key string, fn func(data string)) error {
+	return m.update(ctx, key, func(s *memoryShard, e *memoryEntry) error {
+		if e == nil {
+			fn("")
+			return nil
+		}
+		if e.kind != memoryString {
+			return ErrWrongType
+		}
+		fn(e.value)
+		return nil
+	})
+}
+
+// bitAt returns the bit at offset of data, counting from the most significant
+// bit of the first byte like Redis
+func bitAt(data []byte, offset int64) int64 {
+	if offset < 0 || offset/8 >= int64(len(data)) {
+		return 0
+	}
+	return int64(data[offset/8]>>(7-offset%8)) & 1
+}
@@ -0,0 +1,127 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"strings"
+)
+
+// SetBits sets the bits at offsets of the string at key to 1 in one round
+// trip and returns how many of them were 0
+func (r *RedisClient) SetBits(ctx context.Context, key string, offsets ...int64) (int64, error) {
+	cmds, err := r.Client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, offset := range offsets {
+			pipe.SetBit(ctx, key, offset, 1)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	var set int64
+	for _, cmd := range cmds {
+		if cmd.(*redis.IntCmd).Val() == 0 {
+			set++
+		}
+	}
+	return set, nil
+}
+
+// TestBits reports whether every bit at offsets of the string at key is 1,
+// in one round trip
+func (r *RedisClient) TestBits(ctx context.Context, key string, offsets ...int64) (bool, error) {
+	cmds, err := r.Client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, offset := range offsets {
+			pipe.GetBit(ctx, key, offset)
+		}
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	for _, cmd := range cmds {
+		if cmd.(*redis.IntCmd).Val() == 0 {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// BloomReserve creates the RedisBloom filter at key for capacity items with
+// errorRate false positives, leaving an existing filter alone. supported is
+// false when the server does not have the RedisBloom module.
+func (r *RedisClient) BloomReserve(ctx context.Context, key string, errorRate float64, capacity int64) (supported bool, err error) {
+	err = r.Client.Do(ctx, "BF.RESERVE", key, errorRate, capacity).Err()
+	var replyErr redis.Error
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.As(err, &replyErr) && strings.Contains(strings.ToLower(err.Error()), "unknown command"):
+		return false, nil
+	case errors.As(err, &replyErr) && strings.Contains(strings.ToLower(err.Error()), "exists"):
+		return true, nil
+	}
+	return false, err
+}
+
+// BloomAdd adds items to the RedisBloom filter at key
+func (r *RedisClient) BloomAdd(ctx context.Context, key string, items ...string) error {
+	args := make([]interface{}, 0, len(items)+2)
+	args = append(args, "BF.MADD", key)
+	for _, item := range items {
+		args = append(args, item)
+	}
+	return r.Client.Do(ctx, args...).Err()
+}
+
+// BloomExists reports whether item may have been added to the RedisBloom
+// filter at key
+func (r *RedisClient) BloomExists(ctx context.Context, key, item string) (bool, error) {
+	return r.Client.Do(ctx, "BF.EXISTS", key, item).Bool()
+}
+
+// SetBits sets the bits at offsets of the string at key to 1 at once and
+// returns how many of them were 0
+func (m *Memory) SetBits(ctx context.Context, key string, offsets ...int64) (int64, error) {
+	var set int64
+	err := m.update(ctx, key, func(s *memoryShard, e *memoryEntry) error {
+		if e == nil {
+			e = &memoryEntry{kind: memoryString}
+			s.items[key] = e
+		}
+		if e.kind != memoryString {
+			return ErrWrongType
+		}
+		data := []byte(e.value)
+		for _, offset := range offsets {
+			if offset < 0 {
+				return ErrNotInteger
+			}
+			if index := int(offset / 8); index >= len(data) {
+				data = append(data, make([]byte, index-len(data)+1)...)
+			}
+			if bitAt(data, offset) == 0 {
+				set++
+				data[offset/8] |= byte(1) << (7 - offset%8)
+			}
+		}
+		e.value = string(data)
+		return nil
+	})
+	return set, err
+}
+
+// TestBits reports whether every bit at offsets of the string at key is 1
+func (m *Memory) TestBits(ctx context.Context, key string, offsets ...int64) (bool, error) {
+	all := false
+	err := m.bitmap(ctx, key, func(data string) {
+		for _, offset := range offsets {
+			if offset < 0 || offset/8 >= int64(len(data)) || data[offset/8]>>(7-offset%8)&1 == 0 {
+				return
+			}
+		}
+		all = true
+	})
+	return all, err
+}
@@ -0,0 +1,84 @@
+package adapters
+
+import (
+	"context"
+	"go.etcd.io/bbolt"
+	"time"
+)
+
+// DefaultBoltBucket is the bucket OpenBolt keeps entries in
+const DefaultBoltBucket = "cacher"
+
+// BoltStore is a Store over a bucket of a bbolt database file
+type BoltStore struct {
+	DB     *bbolt.DB
+	Bucket []byte
+}
+
+// NewBoltStore creates a Store over bucket of db, creating the bucket if
+// needed
+func NewBoltStore(db *bbolt.DB, bucket string) (*BoltStore, error) {
+	err := db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists([]byte(bucket))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &BoltStore{DB: db, Bucket: []byte(bucket)}, nil
+}
+
+// OpenBolt opens or creates the bbolt database at path and returns a
+// CacheServer persisting its entries there. Expired entries are removed by a
+// janitor, see NewStoreServer.
+func OpenBolt(path string, opts ...MemoryOption) (*Memory, error) {
+	db, err := bbolt.Open(path, 0o600, &bbolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+	store, err := NewBoltStore(db, DefaultBoltBucket)
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return NewStoreServer(store, opts...), nil
+}
+
+func (b *BoltStore) Name() string {
+	return "bbolt"
+}
+
+// Update reads and writes key in one read-write transaction. bbolt runs a
+// single writer at a time, so fn is never retried.
+func (b *BoltStore) Update(ctx context.Context, key string, fn func(current []byte) ([]byte, bool)) error {
+	return b.DB.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(b.Bucket)
+		next, write := fn(bucket.Get([]byte(key)))
+		switch {
+		case !write:
+			return nil
+		case next == nil:
+			return bucket.Delete([]byte(key))
+		}
+		return bucket.Put([]byte(key), next)
+	})
+}
+
+func (b *BoltStore) Range(ctx context.Context, fn func(key string, data []byte) bool) error {
+	return b.DB.View(func(tx *bbolt.Tx) error {
+		c := tx.Bucket(b.Bucket).Cursor()
+		for key, data := c.First(); key != nil; key, data = c.Next() {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if !fn(string(key), data) {
+				return nil
+			}
+		}
+		return nil
+	})
+}
+
+func (b *BoltStore) Close() error {
+	return b.DB.Close()
+}
@@ -0,0 +1,296 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned while the breaker is open and no fallback is set
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+type BreakerState int
+
+const (
+	BreakerClosed BreakerState = iota
+	BreakerOpen
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	}
+	return "closed"
+}
+
+// Breaker is a Cache that stops calling a failing backend. After Threshold
+// consecutive errors it opens and serves requests from Fallback, or fails them
+// with ErrCircuitOpen, until Cooldown elapses. Then a single probe request is
+// let through: it closes the breaker on success and reopens it on failure.
+//
+// Keys written or deleted while open are deleted from the backend once it
+// recovers, so it never serves values that changed during the outage.
+type Breaker struct {
+	Cache     Cache
+	Fallback  CacheServer
+	Threshold int
+	Cooldown  time.Duration
+
+	mutex    sync.Mutex
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	probing  bool
+	dirty    map[string]struct{}
+	trips    uint64
+	rejected uint64
+}
+
+// NewBreaker wraps cache with a circuit breaker. fallback may be nil, in which
+// case requests fail while the breaker is open.
+func NewBreaker(cache Cache, fallback CacheServer, threshold int, cooldown time.Duration) *Breaker {
+	return &Breaker{
+		Cache:     cache,
+		Fallback:  fallback,
+		Threshold: threshold,
+		Cooldown:  cooldown,
+		dirty:     make(map[string]struct{}),
+	}
+}
+
+// State returns the current state of the breaker
+func (b *Breaker) State() BreakerState {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.state
+}
+
+// allow reports whether a request may reach the backend
+func (b *Breaker) allow() bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if time.Since(b.openedAt) < b.Cooldown {
+			b.rejected++
+			return false
+		}
+		b.state = BreakerHalfOpen
+		b.probing = true
+		return true
+	case BreakerHalfOpen:
+		if b.probing {
+			b.rejected++
+			return false
+		}
+		b.probing = true
+		return true
+	}
+	return true
+}
+
+// record updates the breaker with the outcome of a backend request
+func (b *Breaker) record(ctx context.Context, err error) {
+	b.mutex.Lock()
+	probe := b.state == BreakerHalfOpen
+	b.probing = false
+
+	if err != nil && !errors.Is(err, redis.Nil) {
+		b.failures++
+		if probe || b.failures >= b.Threshold {
+			if b.state != BreakerOpen {
+				b.trips++
+			}
+			b.state = BreakerOpen
+			b.openedAt = time.Now()
+		}
+		b.mutex.Unlock()
+		return
+	}
+
+	b.failures = 0
+	b.state = BreakerClosed
+	var dirty []string
+	if probe {
+		for key := range b.dirty {
+			dirty = append(dirty, key)
+		}
+		b.dirty = make(map[string]struct{})
+	}
+	b.mutex.Unlock()
+
+	if probe {
+		b.recover(ctx, dirty)
+	}
+}
+
+// recover drops values that changed during the outage from the backend and
+// empties the fallback, which is only valid while the breaker is open
+func (b *Breaker) recover(ctx context.Context, dirty []string) {
+	if len(dirty) > 0 {
+		if err := b.Cache.Delete(ctx, dirty...); err != nil {
+			b.markDirty(dirty...)
+		}
+	}
+	if b.Fallback != nil {
+		_, _ = DeleteMatching(ctx, b.Fallback, "*")
+	}
+}
+
+func (b *Breaker) markDirty(keys ...string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	for _, key := range keys {
+		b.dirty[key] = struct{}{}
+	}
+}
+
+// clean forgets keys that were written to the backend again
+func (b *Breaker) clean(keys ...string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	for _, key := range keys {
+		delete(b.dirty, key)
+	}
+}
+
+// isDirty reports whether key changed while the backend missed it
+func (b *Breaker) isDirty(key string) bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	_, dirty := b.dirty[key]
+	return dirty
+}
+
+func (b *Breaker) Get(ctx context.Context, key string) (interface{}, error) {
+	if !b.allow() {
+		if b.Fallback == nil {
+			return nil, ErrCircuitOpen
+		}
+		value, err := b.Fallback.Get(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		return value, nil
+	}
+
+	value, err := b.Cache.Get(ctx, key)
+	b.record(ctx, err)
+	return value, err
+}
+
+func (b *Breaker) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	if !b.allow() {
+		b.markDirty(key)
+		if b.Fallback == nil {
+			return ErrCircuitOpen
+		}
+		return b.Fallback.Set(ctx, key, value, expiration)
+	}
+
+	err := b.Cache.Set(ctx, key, value, expiration)
+	b.record(ctx, err)
+	return err
+}
+
+func (b *Breaker) Delete(ctx context.Context, keys ...string) error {
+	if !b.allow() {
+		b.markDirty(keys...)
+		if b.Fallback == nil {
+			return ErrCircuitOpen
+		}
+		_, err := b.Fallback.Delete(ctx, keys...)
+		return err
+	}
+
+	err := b.Cache.Delete(ctx, keys...)
+	b.record(ctx, err)
+	return err
+}
+
+func (b *Breaker) GetMany(ctx context.Context, keys ...string) (map[string]interface{}, error) {
+	if !b.allow() {
+		if b.Fallback == nil {
+			return nil, ErrCircuitOpen
+		}
+		values, err := b.Fallback.MGet(ctx, keys...)
+		if err != nil {
+			return nil, err
+		}
+		return zipValues(keys, values), nil
+	}
+
+	values, err := b.Cache.GetMany(ctx, keys...)
+	b.record(ctx, err)
+	return values, err
+}
+
+func (b *Breaker) SetMany(ctx context.Context, values map[string]interface{}, expiration time.Duration) error {
+	if !b.allow() {
+		for key := range values {
+			b.markDirty(key)
+		}
+		if b.Fallback == nil {
+			return ErrCircuitOpen
+		}
+		return b.Fallback.MSet(ctx, values, expiration)
+	}
+
+	err := b.Cache.SetMany(ctx, values, expiration)
+	b.record(ctx, err)
+	return err
+}
+
+func (b *Breaker) Close() error {
+	if b.Fallback != nil {
+		return errors.Join(b.Fallback.Close(), b.Cache.Close())
+	}
+	return b.Cache.Close()
+}
+
+// BreakerStatistics returns the state and counters of the breaker
+func (b *Breaker) BreakerStatistics() map[string]uint64 {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	stats := map[string]uint64{
+		"open":      0,
+		"half_open": 0,
+		"failures":  uint64(b.failures),
+		"trips":     b.trips,
+		"rejected":  b.rejected,
+	}
+	switch b.state {
+	case BreakerOpen:
+		stats["open"] = 1
+	case BreakerHalfOpen:
+		stats["half_open"] = 1
+	}
+	return stats
+}
+
+// FindBreaker returns the circuit breaker wrapped in c, if any
+func FindBreaker(c Cache) (*Breaker, bool) {
+	switch driver := c.(type) {
+	case *Breaker:
+		return driver, true
+	case *Tiered:
+		return FindBreaker(driver.Remote)
+	case *Compressing:
+		return FindBreaker(driver.Cache)
+	case *Encrypting:
+		return FindBreaker(driver.Cache)
+	case *Migrating:
+		return FindBreaker(driver.New)
+	case *FallbackChain:
+		return FindBreaker(driver.Backends[0])
+	}
+	return nil, false
+}
@@ -1,10 +1,20 @@
 package adapters
 
-import "context"
+import (
+	"context"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/sync/singleflight"
+	"time"
+)
 
 type Cache interface {
 	Get(context context.Context, key string) (interface{}, error)
-	Set(context context.Context, key string, value interface{}) error
+	Set(context context.Context, key string, value interface{}, expiration time.Duration) error
+	Delete(context context.Context, keys ...string) error
+	GetMany(context context.Context, keys ...string) (map[string]interface{}, error)
+	SetMany(context context.Context, values map[string]interface{}, expiration time.Duration) error
+	Close() error
 }
 
 type cacheDriver struct {
@@ -18,9 +28,170 @@ func NewCache(server CacheServer) Cache {
 }
 
 func (c *cacheDriver) Get(context context.Context, key string) (interface{}, error) {
-	return c.Server.Get(context, key)
+	value, err := c.Server.Get(context, key)
+	if err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+func (c *cacheDriver) Set(context context.Context, key string, value interface{}, expiration time.Duration) error {
+	return c.Server.Set(context, key, value, expiration)
+}
+
+func (c *cacheDriver) Delete(context context.Context, keys ...string) error {
+	_, err := c.Server.Delete(context, keys...)
+	return err
+}
+
+// GetMany returns the values of the keys that exist, keyed by key
+func (c *cacheDriver) GetMany(context context.Context, keys ...string) (map[string]interface{}, error) {
+	values, err := c.Server.MGet(context, keys...)
+	if err != nil {
+		return nil, err
+	}
+	return zipValues(keys, values), nil
+}
+
+func (c *cacheDriver) SetMany(context context.Context, values map[string]interface{}, expiration time.Duration) error {
+	return c.Server.MSet(context, values, expiration)
+}
+
+func (c *cacheDriver) Close() error {
+	return c.Server.Close()
+}
+
+// remember implements Remember on top of Get and Set, running value at most
+// once per key at a time. The computed value is returned even when storing it
+// fails, together with the error.
+func remember(ctx context.Context, server CacheServer, loads *singleflight.Group, key string, expiration time.Duration, value func() interface{}) (interface{}, error) {
+	result, getErr := server.Get(ctx, key)
+	if getErr == nil {
+		return result, nil
+	}
+
+	temp, err, _ := loads.Do(key, func() (interface{}, error) {
+		temp := value()
+		if !errors.Is(getErr, redis.Nil) {
+			return temp, getErr
+		}
+		return temp, server.Set(ctx, key, temp, expiration)
+	})
+	return temp, err
 }
 
-func (c *cacheDriver) Set(context context.Context, key string, value interface{}) error {
-	return c.Server.Set(context, key, value, -1)
+// Backend returns the CacheServer holding the data of c. For a tiered cache
+// this is the server behind the remote tier.
+func Backend(c Cache) (CacheServer, bool) {
+	switch driver := c.(type) {
+	case *Tiered:
+		return Backend(driver.Remote)
+	case *Breaker:
+		return Backend(driver.Cache)
+	case *Retrying:
+		return Backend(driver.Cache)
+	case *Compressing:
+		return Backend(driver.Cache)
+	case *Encrypting:
+		return Backend(driver.Cache)
+	case *Migrating:
+		return Backend(driver.New)
+	case *FallbackChain:
+		return Backend(driver.Backends[0])
+	case *cacheDriver:
+		return driver.Server, true
+	}
+	return nil, false
+}
+
+// BackendName returns a short name of the backend behind c, used to label
+// traces and statistics
+func BackendName(c Cache) string {
+	switch driver := c.(type) {
+	case *Tiered:
+		return "tiered"
+	case *Breaker:
+		return BackendName(driver.Cache)
+	case *Retrying:
+		return BackendName(driver.Cache)
+	case *Compressing:
+		return BackendName(driver.Cache)
+	case *Encrypting:
+		return BackendName(driver.Cache)
+	case *Migrating:
+		return "migrating"
+	case *FallbackChain:
+		return "fallback"
+	case *cacheDriver:
+		return serverName(driver.Server)
+	case *Memcached:
+		return "memcached"
+	}
+	return "custom"
+}
+
+func serverName(server CacheServer) string {
+	switch s := server.(type) {
+	case *Prefixed:
+		return serverName(s.Server)
+	case *RedisClient:
+		return "redis"
+	case *Sharded:
+		return "sharded"
+	case *Replicated:
+		return serverName(s.Primary)
+	case *Memory:
+		if s.store != nil {
+			return s.store.Name()
+		}
+		return "memory"
+	case *Null:
+		return "null"
+	}
+	return "custom"
+}
+
+// unlinker is a CacheServer that can delete keys without blocking while
+// their memory is freed, which DeleteMatching prefers to Delete
+type unlinker interface {
+	Unlink(ctx context.Context, keys ...string) (int64, error)
+}
+
+// DeleteMatching deletes every key of server matching a glob pattern, one
+// SCAN page at a time, and returns how many keys were removed
+func DeleteMatching(ctx context.Context, server CacheServer, match string) (int64, error) {
+	del := server.Delete
+	if u, ok := server.(unlinker); ok {
+		del = u.Unlink
+	}
+	var deleted int64
+	var cursor uint64
+	for {
+		keys, next, err := server.Scan(ctx, cursor, match, 100)
+		if err != nil {
+			return deleted, err
+		}
+		if len(keys) > 0 {
+			n, err := del(ctx, keys...)
+			deleted += n
+			if err != nil {
+				return deleted, err
+			}
+		}
+		if next == 0 {
+			return deleted, nil
+		}
+		cursor = next
+	}
+}
+
+// zipValues pairs MGet results with their keys, dropping missing keys
+func zipValues(keys []string, values []interface{}) map[string]interface{} {
+	found := make(map[string]interface{}, len(keys))
+	for i, value := range values {
+		if value != nil {
+			found[keys[i]] = value
+		}
+	}
+	return found
 }
@@ -0,0 +1,115 @@
+package adapters
+
+import (
+	"context"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+var compareAndDeleteScript = DefaultScripts.Register("compare_and_delete", `
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+var compareAndExpireScript = DefaultScripts.Register("compare_and_expire", `
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// compareAndSwapScript sets the key when it holds ARGV[1]. ARGV[3] is the
+// expiration in milliseconds, 0 for none and -1 to keep the current one.
+var compareAndSwapScript = DefaultScripts.Register("compare_and_swap", `
+if redis.call('GET', KEYS[1]) ~= ARGV[1] then
+	return 0
+end
+local expiration = tonumber(ARGV[3])
+if expiration > 0 then
+	redis.call('SET', KEYS[1], ARGV[2], 'PX', expiration)
+elseif expiration < 0 then
+	redis.call('SET', KEYS[1], ARGV[2], 'KEEPTTL')
+else
+	redis.call('SET', KEYS[1], ARGV[2])
+end
+return 1
+`)
+
+// CompareAndDelete deletes key only if it holds expected
+func (r *RedisClient) CompareAndDelete(ctx context.Context, key string, expected string) (bool, error) {
+	deleted, err := compareAndDeleteScript.Run(ctx, r.Client, []string{key}, expected).Int64()
+	return deleted == 1, err
+}
+
+// CompareAndExpire sets the expiration of key only if it holds expected
+func (r *RedisClient) CompareAndExpire(ctx context.Context, key string, expected string, expiration time.Duration) (bool, error) {
+	updated, err := compareAndExpireScript.Run(ctx, r.Client, []string{key}, expected, expiration.Milliseconds()).Int64()
+	return updated == 1, err
+}
+
+// CompareAndSwap stores value at key only if it holds expected. Like Set, a
+// zero expiration never expires and redis.KeepTTL keeps the current one.
+func (r *RedisClient) CompareAndSwap(ctx context.Context, key string, expected string, value interface{}, expiration time.Duration) (bool, error) {
+	milliseconds := expiration.Milliseconds()
+	if expiration == redis.KeepTTL {
+		milliseconds = -1
+	}
+	swapped, err := compareAndSwapScript.Run(ctx, r.Client, []string{key}, expected, value, milliseconds).Int64()
+	return swapped == 1, err
+}
+
+// CompareAndDelete deletes key only if it holds expected
+func (m *Memory) CompareAndDelete(ctx context.Context, key string, expected string) (bool, error) {
+	deleted := false
+	err := m.update(ctx, key, func(s *memoryShard, e *memoryEntry) error {
+		deleted = false
+		if e != nil && e.kind == memoryString && e.value == expected {
+			delete(s.items, key)
+			deleted = true
+		}
+		return nil
+	})
+	return deleted, err
+}
+
+// CompareAndExpire sets the expiration of key only if it holds expected
+func (m *Memory) CompareAndExpire(ctx context.Context, key string, expected string, expiration time.Duration) (bool, error) {
+	updated := false
+	err := m.update(ctx, key, func(s *memoryShard, e *memoryEntry) error {
+		updated = false
+		if e != nil && e.kind == memoryString && e.value == expected {
+			e.expiresAt = time.Now().Add(expiration)
+			updated = true
+		}
+		return nil
+	})
+	return updated, err
+}
+
+// CompareAndSwap stores value at key only if it holds expected. Like Set, a
+// zero expiration never expires and redis.KeepTTL keeps the current one.
+func (m *Memory) CompareAndSwap(ctx context.Context, key string, expected string, value interface{}, expiration time.Duration) (bool, error) {
+	str, err := formatValue(value)
+	if err != nil {
+		return false, err
+	}
+	swapped := false
+	err = m.update(ctx, key, func(s *memoryShard, e *memoryEntry) error {
+		swapped = false
+		if e == nil || e.kind != memoryString || e.value != expected {
+			return nil
+		}
+		next := &memoryEntry{kind: memoryString, value: str}
+		if expiration == redis.KeepTTL {
+			next.expiresAt = e.expiresAt
+		} else if expiration > 0 {
+			next.expiresAt = time.Now().Add(expiration)
+		}
+		s.items[key] = next
+		swapped = true
+		return nil
+	})
+	return swapped, err
+}
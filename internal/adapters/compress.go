@@ -0,0 +1,203 @@
+package adapters
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/snappy"
+	"github.com/klauspost/compress/zstd"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Compression names the algorithm Compressing stores large values with
+type Compression byte
+
+const (
+	NoCompression Compression = iota
+	Gzip
+	Snappy
+	Zstd
+)
+
+func (c Compression) String() string {
+	switch c {
+	case Gzip:
+		return "gzip"
+	case Snappy:
+		return "snappy"
+	case Zstd:
+		return "zstd"
+	}
+	return "none"
+}
+
+// compressionMagic starts every compressed value, followed by one byte with
+// the Compression used, so values are readable whatever is configured now
+const compressionMagic = "\x00cz"
+
+var (
+	zstdOnce    sync.Once
+	zstdEncoder *zstd.Encoder
+	zstdDecoder *zstd.Decoder
+)
+
+func zstdCodec() (*zstd.Encoder, *zstd.Decoder) {
+	zstdOnce.Do(func() {
+		zstdEncoder, _ = zstd.NewWriter(nil)
+		zstdDecoder, _ = zstd.NewReader(nil)
+	})
+	return zstdEncoder, zstdDecoder
+}
+
+// Compress returns value compressed with compression when it is a string or
+// []byte longer than threshold bytes and compressing makes it smaller. Other
+// values are returned unchanged.
+func Compress(value interface{}, compression Compression, threshold int) (interface{}, error) {
+	var raw []byte
+	switch v := value.(type) {
+	case string:
+		raw = []byte(v)
+	case []byte:
+		raw = v
+	default:
+		return value, nil
+	}
+	if compression == NoCompression || len(raw) <= threshold {
+		return value, nil
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(compressionMagic)
+	buf.WriteByte(byte(compression))
+	switch compression {
+	case Gzip:
+		w := gzip.NewWriter(&buf)
+		if _, err := w.Write(raw); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+	case Snappy:
+		buf.Write(snappy.Encode(nil, raw))
+	case Zstd:
+		encoder, _ := zstdCodec()
+		buf.Write(encoder.EncodeAll(raw, nil))
+	default:
+		return nil, fmt.Errorf("unknown compression %d", compression)
+	}
+
+	if buf.Len() >= len(raw) {
+		return value, nil
+	}
+	return buf.String(), nil
+}
+
+// Decompress reverses Compress. Values without the compression header are
+// returned unchanged.
+func Decompress(value string) (string, error) {
+	if len(value) <= len(compressionMagic) || !strings.HasPrefix(value, compressionMagic) {
+		return value, nil
+	}
+
+	data := []byte(value[len(compressionMagic)+1:])
+	switch Compression(value[len(compressionMagic)]) {
+	case Gzip:
+		r, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return "", fmt.Errorf("decompress: %w", err)
+		}
+		raw, err := io.ReadAll(r)
+		if err != nil {
+			return "", fmt.Errorf("decompress: %w", err)
+		}
+		return string(raw), nil
+	case Snappy:
+		raw, err := snappy.Decode(nil, data)
+		if err != nil {
+			return "", fmt.Errorf("decompress: %w", err)
+		}
+		return string(raw), nil
+	case Zstd:
+		_, decoder := zstdCodec()
+		raw, err := decoder.DecodeAll(data, nil)
+		if err != nil {
+			return "", fmt.Errorf("decompress: %w", err)
+		}
+		return string(raw), nil
+	}
+	return "", fmt.Errorf("decompress: unknown compression %d", value[len(compressionMagic)])
+}
+
+// Compressing is a Cache that compresses values longer than Threshold bytes
+// before they are written and decompresses them when they are read back
+type Compressing struct {
+	Cache       Cache
+	Compression Compression
+	Threshold   int
+}
+
+// NewCompressing wraps cache so values longer than threshold bytes are stored
+// compressed with compression
+func NewCompressing(cache Cache, compression Compression, threshold int) *Compressing {
+	return &Compressing{Cache: cache, Compression: compression, Threshold: threshold}
+}
+
+func (c *Compressing) Get(ctx context.Context, key string) (interface{}, error) {
+	value, err := c.Cache.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return decompressValue(value)
+}
+
+func (c *Compressing) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	compressed, err := Compress(value, c.Compression, c.Threshold)
+	if err != nil {
+		return err
+	}
+	return c.Cache.Set(ctx, key, compressed, expiration)
+}
+
+func (c *Compressing) Delete(ctx context.Context, keys ...string) error {
+	return c.Cache.Delete(ctx, keys...)
+}
+
+func (c *Compressing) GetMany(ctx context.Context, keys ...string) (map[string]interface{}, error) {
+	values, err := c.Cache.GetMany(ctx, keys...)
+	if err != nil {
+		return values, err
+	}
+	for key, value := range values {
+		if values[key], err = decompressValue(value); err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
+
+func (c *Compressing) SetMany(ctx context.Context, values map[string]interface{}, expiration time.Duration) error {
+	compressed := make(map[string]interface{}, len(values))
+	for key, value := range values {
+		var err error
+		if compressed[key], err = Compress(value, c.Compression, c.Threshold); err != nil {
+			return err
+		}
+	}
+	return c.Cache.SetMany(ctx, compressed, expiration)
+}
+
+func (c *Compressing) Close() error {
+	return c.Cache.Close()
+}
+
+func decompressValue(value interface{}) (interface{}, error) {
+	if str, ok := value.(string); ok {
+		return Decompress(str)
+	}
+	return value, nil
+}
@@ -0,0 +1,80 @@
+package adapters
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"time"
+)
+
+var incrByExpireScript = DefaultScripts.Register("incr_by_expire", `
+local created = redis.call('EXISTS', KEYS[1]) == 0
+local value = redis.call('INCRBY', KEYS[1], ARGV[1])
+if created then
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return value
+`)
+
+var incrByFloatExpireScript = DefaultScripts.Register("incr_by_float_expire", `
+local created = redis.call('EXISTS', KEYS[1]) == 0
+local value = redis.call('INCRBYFLOAT', KEYS[1], ARGV[1])
+if created then
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return value
+`)
+
+// IncrBy adds delta to the integer stored at key, creating it with 0 first if
+// it does not exist. A key created by the call expires after expiration, if
+// positive; the expiration of existing keys is left unchanged.
+func (r *RedisClient) IncrBy(ctx context.Context, key string, delta int64, expiration time.Duration) (int64, error) {
+	if expiration <= 0 {
+		return r.Client.IncrBy(ctx, key, delta).Result()
+	}
+	return incrByExpireScript.Run(ctx, r.Client, []string{key}, delta, expiration.Milliseconds()).Int64()
+}
+
+// IncrByFloat adds delta to the number stored at key like IncrBy
+func (r *RedisClient) IncrByFloat(ctx context.Context, key string, delta float64, expiration time.Duration) (float64, error) {
+	if expiration <= 0 {
+		return r.Client.IncrByFloat(ctx, key, delta).Result()
+	}
+	return incrByFloatExpireScript.Run(ctx, r.Client, []string{key}, delta, expiration.Milliseconds()).Float64()
+}
+
+// IncrBy adds delta to the integer stored at key, creating it with 0 first if
+// it does not exist. A key created by the call expires after expiration, if
+// positive; the expiration of existing keys is left unchanged.
+func (m *Memory) IncrBy(ctx context.Context, key string, delta int64, expiration time.Duration) (int64, error) {
+	return m.incrBy(ctx, key, delta, expiration)
+}
+
+// IncrByFloat adds delta to the number stored at key like IncrBy
+func (m *Memory) IncrByFloat(ctx context.Context, key string, delta float64, expiration time.Duration) (float64, error) {
+	var result float64
+	err := m.update(ctx, key, func(s *memoryShard, e *memoryEntry) error {
+		if e == nil {
+			e = &memoryEntry{kind: memoryString, value: "0"}
+			if expiration > 0 {
+				e.expiresAt = time.Now().Add(expiration)
+			}
+			s.items[key] = e
+		}
+		if e.kind != memoryString {
+			return ErrWrongType
+		}
+		current, err := strconv.ParseFloat(e.value, 64)
+		if err != nil || math.IsNaN(current) || math.IsInf(current, 0) {
+			return ErrNotFloat
+		}
+		next := current + delta
+		if math.IsNaN(next) || math.IsInf(next, 0) {
+			return ErrNotFloat
+		}
+		result = next
+		e.value = strconv.FormatFloat(result, 'f', -1, 64)
+		return nil
+	})
+	return result, err
+}
@@ -0,0 +1,274 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"strconv"
+	"time"
+)
+
+// DynamoDBClient is the part of *dynamodb.Client a DynamoStore uses
+type DynamoDBClient interface {
+	GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error)
+	PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
+	DeleteItem(ctx context.Context, params *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error)
+	UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error)
+	Scan(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error)
+}
+
+// DynamoDB item attributes. The table needs "key" as its string partition
+// key, and TTL enabled on "expires_at" to have DynamoDB delete expired items.
+// Counters keep a number in "count" instead of an entry in "value", and
+// "expires_ms" holds the exact expiration the TTL attribute rounds up.
+const (
+	dynamoKey         = "key"
+	dynamoValue       = "value"
+	dynamoCount       = "count"
+	dynamoVersion     = "version"
+	dynamoExpiresAt   = "expires_at"
+	dynamoExpiresAtMs = "expires_ms"
+)
+
+// DynamoStore is a Store over a DynamoDB table. Every item carries a version
+// and writes are conditional on it, so concurrent updates of a key, SetNX
+// included, never overwrite each other. Counters are added to atomically
+// with UpdateItem. Expiring items get a TTL attribute in epoch seconds.
+type DynamoStore struct {
+	Client DynamoDBClient
+	Table  string
+}
+
+// NewDynamoDB returns a CacheServer keeping its entries in table. DynamoDB's
+// TTL deletes expired items, so no janitor runs; they are hidden until then.
+func NewDynamoDB(client DynamoDBClient, table string, opts ...MemoryOption) *Memory {
+	opts = append([]MemoryOption{WithCleanupInterval(0)}, opts...)
+	return NewStoreServer(&DynamoStore{Client: client, Table: table}, opts...)
+}
+
+func (d *DynamoStore) Name() string {
+	return "dynamodb"
+}
+
+// Update reads key consistently and writes it back on the condition that its
+// version did not change, running fn again after a backoff when it did, up
+// to storeConflicts.MaxAttempts times before failing with ErrStoreConflict
+func (d *DynamoStore) Update(ctx context.Context, key string, fn func(current []byte) ([]byte, bool)) error {
+	return retryConflicts(ctx, func() error {
+		return d.updateOnce(ctx, key, fn)
+	}, dynamoConflict)
+}
+
+func (d *DynamoStore) updateOnce(ctx context.Context, key string, fn func(current []byte) ([]byte, bool)) error {
+	itemKey := dynamoItemKey(key)
+	out, err := d.Client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(d.Table),
+		Key:            itemKey,
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return err
+	}
+
+	current := dynamoEntry(out.Item)
+	version, versioned := dynamoInt(out.Item, dynamoVersion)
+	next, write := fn(current)
+	if !write {
+		return nil
+	}
+
+	condition := aws.String("attribute_not_exists(#key)")
+	names := map[string]string{"#key": dynamoKey}
+	var values map[string]types.AttributeValue
+	switch {
+	case versioned:
+		condition = aws.String("#version = :version")
+		names = map[string]string{"#version": dynamoVersion}
+		values = map[string]types.AttributeValue{":version": dynamoNumber(version)}
+	case len(out.Item) > 0:
+		// An item written by something else, it gets a version now
+		condition = aws.String("attribute_exists(#key) AND attribute_not_exists(#version)")
+		names = map[string]string{"#key": dynamoKey, "#version": dynamoVersion}
+	}
+
+	if next == nil {
+		_, err = d.Client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+			TableName:                 aws.String(d.Table),
+			Key:                       itemKey,
+			ConditionExpression:       condition,
+			ExpressionAttributeNames:  names,
+			ExpressionAttributeValues: values,
+		})
+		return err
+	}
+
+	item := map[string]types.AttributeValue{
+		dynamoKey:     &types.AttributeValueMemberS{Value: key},
+		dynamoValue:   &types.AttributeValueMemberB{Value: next},
+		dynamoVersion: dynamoNumber(version + 1),
+	}
+	if expiresAt := entryExpiresAt(next); !expiresAt.IsZero() {
+		// DynamoDB TTLs are in seconds, round up so items never go early
+		item[dynamoExpiresAt] = dynamoNumber(expiresAt.Unix() + 1)
+		item[dynamoExpiresAtMs] = dynamoNumber(expiresAt.UnixMilli())
+	}
+	_, err = d.Client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:                 aws.String(d.Table),
+		Item:                      item,
+		ConditionExpression:       condition,
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+	})
+	return err
+}
+
+func (d *DynamoStore) Range(ctx context.Context, fn func(key string, data []byte) bool) error {
+	var start map[string]types.AttributeValue
+	for {
+		out, err := d.Client.Scan(ctx, &dynamodb.ScanInput{
+			TableName:         aws.String(d.Table),
+			ConsistentRead:    aws.Bool(true),
+			ExclusiveStartKey: start,
+		})
+		if err != nil {
+			return err
+		}
+		for _, item := range out.Items {
+			key, _ := item[dynamoKey].(*types.AttributeValueMemberS)
+			data := dynamoEntry(item)
+			if key == nil || data == nil {
+				continue
+			}
+			if !fn(key.Value, data) {
+				return nil
+			}
+		}
+		if len(out.LastEvaluatedKey) == 0 {
+			return nil
+		}
+		start = out.LastEvaluatedKey
+	}
+}
+
+// Add adds delta to the counter at key with one UpdateItem. A missing or
+// expired key is replaced by a counter holding delta; keys holding another
+// value fail with ErrNotCounter.
+func (d *DynamoStore) Add(ctx context.Context, key string, delta int64, expiresAt time.Time) (int64, error) {
+	var result int64
+	err := retryConflicts(ctx, func() error {
+		now := dynamoNumber(time.Now().UnixMilli())
+		out, err := d.Client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+			TableName:                           aws.String(d.Table),
+			Key:                                 dynamoItemKey(key),
+			UpdateExpression:                    aws.String("ADD #count :delta, #version :one"),
+			ConditionExpression:                 aws.String("attribute_exists(#count) AND (attribute_not_exists(#expires_ms) OR #expires_ms > :now)"),
+			ExpressionAttributeNames:            map[string]string{"#count": dynamoCount, "#version": dynamoVersion, "#expires_ms": dynamoExpiresAtMs},
+			ExpressionAttributeValues:           map[string]types.AttributeValue{":delta": dynamoNumber(delta), ":one": dynamoNumber(1), ":now": now},
+			ReturnValues:                        types.ReturnValueUpdatedNew,
+			ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailureAllOld,
+		})
+		var conflict *types.ConditionalCheckFailedException
+		switch {
+		case err == nil:
+			result, _ = dynamoInt(out.Attributes, dynamoCount)
+			return nil
+		case !errors.As(err, &conflict):
+			return err
+		case len(conflict.Item) > 0 && !dynamoExpired(conflict.Item):
+			if _, ok := conflict.Item[dynamoCount]; !ok {
+				return ErrNotCounter
+			}
+			// A counter created since, the next attempt adds to it
+			return conflict
+		}
+		result = delta
+		return d.createCounter(ctx, key, delta, expiresAt)
+	}, dynamoConflict)
+	return result, err
+}
+
+// createCounter replaces a missing or expired item at key by a counter
+// holding delta, failing with a conflict when another writer got there first
+func (d *DynamoStore) createCounter(ctx context.Context, key string, delta int64, expiresAt time.Time) error {
+	update := "SET #count = :delta REMOVE #value, #expires_at, #expires_ms ADD #version :one"
+	values := map[string]types.AttributeValue{
+		":delta": dynamoNumber(delta),
+		":one":   dynamoNumber(1),
+		":now":   dynamoNumber(time.Now().UnixMilli()),
+	}
+	if !expiresAt.IsZero() {
+		update = "SET #count = :delta, #expires_at = :expires_at, #expires_ms = :expires_ms REMOVE #value ADD #version :one"
+		values[":expires_at"] = dynamoNumber(expiresAt.Unix() + 1)
+		values[":expires_ms"] = dynamoNumber(expiresAt.UnixMilli())
+	}
+	_, err := d.Client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:           aws.String(d.Table),
+		Key:                 dynamoItemKey(key),
+		UpdateExpression:    aws.String(update),
+		ConditionExpression: aws.String("attribute_not_exists(#key) OR #expires_ms <= :now"),
+		ExpressionAttributeNames: map[string]string{
+			"#key":        dynamoKey,
+			"#count":      dynamoCount,
+			"#value":      dynamoValue,
+			"#version":    dynamoVersion,
+			"#expires_at": dynamoExpiresAt,
+			"#expires_ms": dynamoExpiresAtMs,
+		},
+		ExpressionAttributeValues: values,
+	})
+	return err
+}
+
+// Close does nothing, the client is owned by the caller
+func (d *DynamoStore) Close() error {
+	return nil
+}
+
+func dynamoNumber(n int64) *types.AttributeValueMemberN {
+	return &types.AttributeValueMemberN{Value: strconv.FormatInt(n, 10)}
+}
+
+func dynamoItemKey(key string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{dynamoKey: &types.AttributeValueMemberS{Value: key}}
+}
+
+// dynamoInt returns the number attribute name of item
+func dynamoInt(item map[string]types.AttributeValue, name string) (int64, bool) {
+	n, ok := item[name].(*types.AttributeValueMemberN)
+	if !ok {
+		return 0, false
+	}
+	value, err := strconv.ParseInt(n.Value, 10, 64)
+	return value, err == nil
+}
+
+// dynamoExpired reports whether item has an exact expiration that passed
+func dynamoExpired(item map[string]types.AttributeValue) bool {
+	expiresAt, ok := dynamoInt(item, dynamoExpiresAtMs)
+	return ok && expiresAt <= time.Now().UnixMilli()
+}
+
+// dynamoEntry returns the entry item holds, encoding the number of a counter
+// as a string entry, or nil without one
+func dynamoEntry(item map[string]types.AttributeValue) []byte {
+	if value, ok := item[dynamoValue].(*types.AttributeValueMemberB); ok {
+		return value.Value
+	}
+	count, ok := item[dynamoCount].(*types.AttributeValueMemberN)
+	if !ok {
+		return nil
+	}
+	e := &memoryEntry{kind: memoryString, value: count.Value}
+	if expiresAt, ok := dynamoInt(item, dynamoExpiresAtMs); ok {
+		e.expiresAt = time.UnixMilli(expiresAt)
+	}
+	return encodeEntry(e)
+}
+
+// dynamoConflict reports whether err is a failed write condition
+func dynamoConflict(err error) bool {
+	var conflict *types.ConditionalCheckFailedException
+	return errors.As(err, &conflict)
+}
@@ -0,0 +1,181 @@
+package adapters
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"strings"
+	"time"
+)
+
+var (
+	ErrUnknownKey   = errors.New("value was encrypted with an unknown key")
+	ErrDecrypt      = errors.New("cannot decrypt cached value")
+	ErrNotEncrypted = errors.New("cached value is not encrypted")
+)
+
+// encryptionMagic starts every encrypted value. It is followed by the id of
+// the key, the nonce and the AES-GCM sealed value.
+const encryptionMagic = "\x00ce"
+
+const keyIDSize = 4
+
+// Keyring encrypts values with its current key and decrypts values sealed
+// with any of its keys, so keys can be rotated without losing cached data.
+// Keys are identified by a fingerprint stored alongside every value.
+type Keyring struct {
+	// AllowPlaintext makes Decrypt return values that were not encrypted
+	// unchanged instead of failing, so a cache can start encrypting without
+	// a flush. Whoever can write to the backend can then forge values.
+	AllowPlaintext bool
+	current        string
+	aeads          map[string]cipher.AEAD
+}
+
+// NewKeyring creates a Keyring encrypting with key and also decrypting with
+// the previous keys. Keys must be 16, 24 or 32 bytes long to select AES-128,
+// AES-192 or AES-256.
+func NewKeyring(key []byte, previous ...[]byte) (*Keyring, error) {
+	k := &Keyring{aeads: make(map[string]cipher.AEAD)}
+	for i, material := range append([][]byte{key}, previous...) {
+		block, err := aes.NewCipher(material)
+		if err != nil {
+			return nil, err
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+
+		sum := sha256.Sum256(material)
+		id := string(sum[:keyIDSize])
+		if i == 0 {
+			k.current = id
+		}
+		k.aeads[id] = aead
+	}
+	return k, nil
+}
+
+// Encrypt seals value with the current key. The name of the cache key is
+// authenticated too, so a value copied to another key fails to decrypt.
+func (k *Keyring) Encrypt(key string, value interface{}) (string, error) {
+	plaintext, err := formatValue(value)
+	if err != nil {
+		return "", err
+	}
+
+	aead := k.aeads[k.current]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	envelope := make([]byte, 0, len(encryptionMagic)+keyIDSize+len(nonce)+len(plaintext)+aead.Overhead())
+	envelope = append(envelope, encryptionMagic...)
+	envelope = append(envelope, k.current...)
+	envelope = append(envelope, nonce...)
+	return string(aead.Seal(envelope, nonce, []byte(plaintext), []byte(key))), nil
+}
+
+// Decrypt opens a value sealed by Encrypt. Values that were not encrypted
+// fail with ErrNotEncrypted, unless AllowPlaintext is set.
+func (k *Keyring) Decrypt(key, value string) (string, error) {
+	if !strings.HasPrefix(value, encryptionMagic) {
+		if k.AllowPlaintext {
+			return value, nil
+		}
+		return "", ErrNotEncrypted
+	}
+
+	data := value[len(encryptionMagic):]
+	if len(data) < keyIDSize {
+		return "", ErrDecrypt
+	}
+	aead, ok := k.aeads[data[:keyIDSize]]
+	if !ok {
+		return "", ErrUnknownKey
+	}
+	data = data[keyIDSize:]
+	if len(data) < aead.NonceSize() {
+		return "", ErrDecrypt
+	}
+
+	nonce, sealed := data[:aead.NonceSize()], data[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, []byte(nonce), []byte(sealed), []byte(key))
+	if err != nil {
+		return "", ErrDecrypt
+	}
+	return string(plaintext), nil
+}
+
+// decrypt opens value if it is a string, other values are returned unchanged
+func (k *Keyring) decrypt(key string, value interface{}) (interface{}, error) {
+	if str, ok := value.(string); ok {
+		return k.Decrypt(key, str)
+	}
+	return value, nil
+}
+
+// Encrypting is a Cache that encrypts every value before it is written and
+// decrypts it when it is read back
+type Encrypting struct {
+	Cache Cache
+	Keys  *Keyring
+}
+
+// NewEncrypting wraps cache so values are stored encrypted with keys
+func NewEncrypting(cache Cache, keys *Keyring) *Encrypting {
+	return &Encrypting{Cache: cache, Keys: keys}
+}
+
+func (e *Encrypting) Get(ctx context.Context, key string) (interface{}, error) {
+	value, err := e.Cache.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return e.Keys.decrypt(key, value)
+}
+
+func (e *Encrypting) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	sealed, err := e.Keys.Encrypt(key, value)
+	if err != nil {
+		return err
+	}
+	return e.Cache.Set(ctx, key, sealed, expiration)
+}
+
+func (e *Encrypting) Delete(ctx context.Context, keys ...string) error {
+	return e.Cache.Delete(ctx, keys...)
+}
+
+func (e *Encrypting) GetMany(ctx context.Context, keys ...string) (map[string]interface{}, error) {
+	values, err := e.Cache.GetMany(ctx, keys...)
+	if err != nil {
+		return values, err
+	}
+	for key, value := range values {
+		if values[key], err = e.Keys.decrypt(key, value); err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
+
+func (e *Encrypting) SetMany(ctx context.Context, values map[string]interface{}, expiration time.Duration) error {
+	sealed := make(map[string]interface{}, len(values))
+	for key, value := range values {
+		var err error
+		if sealed[key], err = e.Keys.Encrypt(key, value); err != nil {
+			return err
+		}
+	}
+	return e.Cache.SetMany(ctx, sealed, expiration)
+}
+
+func (e *Encrypting) Close() error {
+	return e.Cache.Close()
+}
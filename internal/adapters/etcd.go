@@ -0,0 +1,153 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"math"
+	"time"
+)
+
+// DefaultEtcdPrefix is the prefix NewEtcd keeps keys under
+const DefaultEtcdPrefix = "cacher/"
+
+// etcdPageSize is how many keys Range reads per request
+const etcdPageSize = 500
+
+// errEtcdConflict means the key changed since it was read
+var errEtcdConflict = errors.New("key updated concurrently")
+
+// EtcdStore is a Store over the keys of an etcd cluster under Prefix. Writes
+// are transactions conditional on the revision of the key, and expiring keys
+// are attached to a lease so etcd deletes them. etcd rounds lease TTLs up to
+// whole seconds and a server side minimum, expired keys are hidden until then.
+type EtcdStore struct {
+	Client *clientv3.Client
+	Prefix string
+}
+
+// NewEtcd returns a CacheServer keeping its entries in etcd under prefix.
+// Leases expire the keys, so no janitor runs.
+func NewEtcd(client *clientv3.Client, prefix string, opts ...MemoryOption) *Memory {
+	opts = append([]MemoryOption{WithCleanupInterval(0)}, opts...)
+	return NewStoreServer(&EtcdStore{Client: client, Prefix: prefix}, opts...)
+}
+
+func (e *EtcdStore) Name() string {
+	return "etcd"
+}
+
+// Update writes key if its revision is still the one fn saw, a missing key
+// has revision 0, and runs fn again after a backoff otherwise, up to
+// storeConflicts.MaxAttempts times before failing with ErrStoreConflict
+func (e *EtcdStore) Update(ctx context.Context, key string, fn func(current []byte) ([]byte, bool)) error {
+	return retryConflicts(ctx, func() error {
+		return e.updateOnce(ctx, e.Prefix+key, fn)
+	}, func(err error) bool {
+		return errors.Is(err, errEtcdConflict)
+	})
+}
+
+func (e *EtcdStore) updateOnce(ctx context.Context, name string, fn func(current []byte) ([]byte, bool)) error {
+	resp, err := e.Client.Get(ctx, name)
+	if err != nil {
+		return err
+	}
+	var current []byte
+	var revision int64
+	var lease clientv3.LeaseID
+	if len(resp.Kvs) > 0 {
+		current = resp.Kvs[0].Value
+		revision = resp.Kvs[0].ModRevision
+		lease = clientv3.LeaseID(resp.Kvs[0].Lease)
+	}
+
+	next, write := fn(current)
+	if !write {
+		return nil
+	}
+	op, granted, err := e.write(ctx, name, current, next, lease)
+	if err != nil {
+		return err
+	}
+
+	txn, err := e.Client.Txn(ctx).
+		If(clientv3.Compare(clientv3.ModRevision(name), "=", revision)).
+		Then(op).
+		Commit()
+	if err != nil || !txn.Succeeded {
+		if granted != lease {
+			e.revoke(granted)
+		}
+		if err != nil {
+			return err
+		}
+		return errEtcdConflict
+	}
+	// The key no longer uses its previous lease, which held only this key
+	if lease != clientv3.NoLease && lease != granted {
+		e.revoke(lease)
+	}
+	return nil
+}
+
+// write returns the operation storing next at name and the lease it uses,
+// keeping the current lease when the expiration did not change
+func (e *EtcdStore) write(ctx context.Context, name string, current, next []byte, lease clientv3.LeaseID) (clientv3.Op, clientv3.LeaseID, error) {
+	if next == nil {
+		return clientv3.OpDelete(name), clientv3.NoLease, nil
+	}
+	expiresAt := entryExpiresAt(next)
+	if expiresAt.IsZero() {
+		return clientv3.OpPut(name, string(next)), clientv3.NoLease, nil
+	}
+	if lease != clientv3.NoLease && entryExpiresAt(current).Equal(expiresAt) {
+		return clientv3.OpPut(name, string(next), clientv3.WithLease(lease)), lease, nil
+	}
+
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		return clientv3.OpDelete(name), clientv3.NoLease, nil
+	}
+	grant, err := e.Client.Grant(ctx, int64(math.Ceil(ttl.Seconds())))
+	if err != nil {
+		return clientv3.Op{}, clientv3.NoLease, err
+	}
+	return clientv3.OpPut(name, string(next), clientv3.WithLease(grant.ID)), grant.ID, nil
+}
+
+// revoke releases a lease that is no longer attached to any key. Failures
+// only leave the lease to expire on its own.
+func (e *EtcdStore) revoke(lease clientv3.LeaseID) {
+	if lease == clientv3.NoLease {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, _ = e.Client.Revoke(ctx, lease)
+}
+
+func (e *EtcdStore) Range(ctx context.Context, fn func(key string, data []byte) bool) error {
+	end := clientv3.GetPrefixRangeEnd(e.Prefix)
+	from := e.Prefix
+	for {
+		resp, err := e.Client.Get(ctx, from, clientv3.WithRange(end), clientv3.WithLimit(etcdPageSize))
+		if err != nil {
+			return err
+		}
+		for _, kv := range resp.Kvs {
+			if !fn(string(kv.Key[len(e.Prefix):]), kv.Value) {
+				return nil
+			}
+		}
+		if !resp.More || len(resp.Kvs) == 0 {
+			return nil
+		}
+		from = string(resp.Kvs[len(resp.Kvs)-1].Key) + "\x00"
+	}
+}
+
+// Close does nothing, the client is owned by the caller
+func (e *EtcdStore) Close() error {
+	return nil
+}
@@ -0,0 +1,237 @@
+package adapters
+
+import (
+	"container/list"
+	"hash/maphash"
+	"time"
+)
+
+// EvictionPolicy decides which keys a bounded Memory evicts
+type EvictionPolicy int
+
+const (
+	// LRU evicts the least recently used keys
+	LRU EvictionPolicy = iota
+	// TinyLFU is W-TinyLFU: new keys enter a window of 1% of the capacity and
+	// leaving it, take the place of the least recently used key only if they
+	// were used more often, as estimated by a frequency sketch. Keys read
+	// once then do not push out hot ones, as they do with LRU.
+	TinyLFU
+)
+
+func (p EvictionPolicy) String() string {
+	if p == TinyLFU {
+		return "tinylfu"
+	}
+	return "lru"
+}
+
+// memoryUse is the position of a key in the recency list, or the window, of
+// its shard
+type memoryUse struct {
+	key    string
+	size   int64
+	hash   uint64
+	window bool
+}
+
+// bounded reports whether the memory evicts keys, see WithMaxEntries and
+// WithMaxBytes
+func (m *Memory) bounded() bool {
+	return m.store == nil && (m.maxEntries > 0 || m.maxBytes > 0)
+}
+
+// limits returns the share of WithMaxEntries and WithMaxBytes of each shard
+func (m *Memory) limits() (int, int64) {
+	return (m.maxEntries + memoryShardCount - 1) / memoryShardCount, (m.maxBytes + memoryShardCount - 1) / memoryShardCount
+}
+
+// resetUses forgets the uses of every key of s
+func (m *Memory) resetUses(s *memoryShard) {
+	s.recency = list.New()
+	s.window = list.New()
+	s.uses = make(map[string]*list.Element)
+	s.bytes, s.windowBytes = 0, 0
+	if m.policy == TinyLFU {
+		maxEntries, maxBytes := m.limits()
+		if maxEntries == 0 {
+			// Assume small values to size the sketch
+			maxEntries = int(maxBytes / 64)
+		}
+		// Wide enough for the keys passing through, not only those held
+		s.sketch = newFrequencySketch(8 * maxEntries)
+	}
+}
+
+// used marks key as the most recently used key of s, or forgets it when it no
+// longer exists, and evicts other keys until s is within its share of the
+// limits. s must be locked.
+func (m *Memory) used(s *memoryShard, key string) {
+	element, tracked := s.uses[key]
+	e, exists := s.items[key]
+	if !exists {
+		if tracked {
+			s.forget(element)
+		}
+		return
+	}
+
+	if !tracked {
+		use := &memoryUse{key: key}
+		if s.sketch != nil {
+			use.hash = maphash.String(m.seed, key)
+			use.window = true
+		}
+		element = s.list(use).PushFront(use)
+		s.uses[key] = element
+	}
+	use := element.Value.(*memoryUse)
+	if m.maxBytes > 0 {
+		s.resize(use, e.size(key))
+	}
+	s.list(use).MoveToFront(element)
+	if s.sketch != nil {
+		s.sketch.increment(use.hash)
+		m.admit(s)
+	}
+	m.evict(s)
+}
+
+// admit moves the keys beyond the share of the window of s into the main
+// space, each evicting the least recently used key there if it was used more
+// often than that one and being evicted itself otherwise
+func (m *Memory) admit(s *memoryShard) {
+	maxEntries, maxBytes := m.limits()
+	windowEntries, windowBytes := max(maxEntries/100, 1), max(maxBytes/100, 1)
+	for s.window.Len() > 1 && (maxEntries > 0 && s.window.Len() > windowEntries || maxBytes > 0 && s.windowBytes > windowBytes) {
+		candidate := s.window.Back()
+		use := candidate.Value.(*memoryUse)
+		s.window.Remove(candidate)
+		s.windowBytes -= use.size
+		use.window = false
+		candidate = s.recency.PushFront(use)
+		s.uses[use.key] = candidate
+
+		victim := s.recency.Back()
+		if victim == candidate || !m.over(s) {
+			continue
+		}
+		if s.sketch.estimate(use.hash) > s.sketch.estimate(victim.Value.(*memoryUse).hash) {
+			m.drop(s, victim)
+		} else {
+			m.drop(s, candidate)
+		}
+	}
+}
+
+// over reports whether s holds more than its share of the limits
+func (m *Memory) over(s *memoryShard) bool {
+	maxEntries, maxBytes := m.limits()
+	return maxEntries > 0 && s.recency.Len()+s.window.Len() > maxEntries || maxBytes > 0 && s.bytes > maxBytes
+}
+
+// evict removes the least recently used keys of s while it holds more than
+// its share of the limits, keeping the most recently used one even if it is
+// larger than that on its own
+func (m *Memory) evict(s *memoryShard) {
+	for s.recency.Len()+s.window.Len() > 1 && m.over(s) {
+		victim := s.recency.Back()
+		if victim == nil {
+			victim = s.window.Back()
+		}
+		m.drop(s, victim)
+	}
+}
+
+// drop evicts the key at element from s
+func (m *Memory) drop(s *memoryShard, element *list.Element) {
+	use := element.Value.(*memoryUse)
+	e := s.items[use.key]
+	s.forget(element)
+	delete(s.items, use.key)
+
+	if e.expired(time.Now()) {
+		m.emit(KeyExpired, use.key)
+		return
+	}
+	m.evictions.Add(1)
+	m.evictedBytes.Add(uint64(e.size(use.key)))
+	if m.onEvict != nil {
+		m.onEvict(use.key)
+	}
+}
+
+// list returns the list of s holding use
+func (s *memoryShard) list(use *memoryUse) *list.List {
+	if use.window {
+		return s.window
+	}
+	return s.recency
+}
+
+// resize records that the key of use now takes size bytes
+func (s *memoryShard) resize(use *memoryUse, size int64) {
+	s.bytes += size - use.size
+	if use.window {
+		s.windowBytes += size - use.size
+	}
+	use.size = size
+}
+
+// forget removes the key at element from the lists of s
+func (s *memoryShard) forget(element *list.Element) {
+	use := element.Value.(*memoryUse)
+	s.resize(use, 0)
+	s.list(use).Remove(element)
+	delete(s.uses, use.key)
+}
+
+// EvictionStatistics returns how many keys were evicted to respect
+// WithMaxEntries or WithMaxBytes as "evictions", together with their
+// "evicted_bytes", and the "entries" and approximate "bytes" held now, or nil
+// when the memory is not bounded. Bytes are only tracked WithMaxBytes.
+func (m *Memory) EvictionStatistics() map[string]uint64 {
+	if !m.bounded() {
+		return nil
+	}
+	var entries, bytes int64
+	for _, shard := range m.shards {
+		shard.mutex.Lock()
+		entries += int64(shard.recency.Len() + shard.window.Len())
+		bytes += shard.bytes
+		shard.mutex.Unlock()
+	}
+	return map[string]uint64{
+		"evictions":     m.evictions.Load(),
+		"evicted_bytes": m.evictedBytes.Load(),
+		"entries":       uint64(entries),
+		"bytes":         uint64(bytes),
+	}
+}
+
+// size approximates the memory held by the entry at key by the lengths of the
+// key and of everything it stores
+func (e *memoryEntry) size(key string) int64 {
+	size := len(key) + len(e.value)
+	for _, value := range e.list {
+		size += len(value)
+	}
+	for member := range e.set {
+		size += len(member)
+	}
+	for member := range e.zset {
+		size += len(member) + 8
+	}
+	for field, value := range e.hash {
+		size += len(field) + len(value)
+	}
+	if e.stream != nil {
+		for _, entry := range e.stream.entries {
+			size += 16
+			for field, value := range entry.fields {
+				size += len(field) + len(value)
+			}
+		}
+	}
+	return int64(size)
+}
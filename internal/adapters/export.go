@@ -0,0 +1,151 @@
+package adapters
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"io"
+	"time"
+)
+
+// exportHeader starts every export, followed by one record per key: the key,
+// its remaining time to live in milliseconds, -1 for none, and its value,
+// the key and the value length prefixed
+var exportHeader = []byte("cacher-export\x01")
+
+// importBatch is how many keys Import sets per round trip
+const importBatch = 100
+
+// ErrCorruptExport is returned when a stream given to Import cannot be
+// decoded
+var ErrCorruptExport = errors.New("corrupt cache export")
+
+// Export writes the string keys of server matching the glob pattern match to
+// w, with their values and remaining time to live, for Import to load them
+// into any CacheServer. Other kinds of keys are skipped. Keys are read one
+// SCAN page at a time, so writes made while it runs may or may not be
+// included. It returns how many keys were written.
+func Export(ctx context.Context, server CacheServer, w io.Writer, match string) (int64, error) {
+	buffered := bufio.NewWriter(w)
+	if _, err := buffered.Write(exportHeader); err != nil {
+		return 0, err
+	}
+	var exported int64
+	var record []byte
+	var cursor uint64
+	for {
+		keys, next, err := server.Scan(ctx, cursor, match, importBatch)
+		if err != nil {
+			return exported, err
+		}
+		for _, key := range keys {
+			value, ttl, err := GetWithTTL(ctx, server, key)
+			if errors.Is(err, redis.Nil) || err != nil && isWrongType(err) {
+				continue
+			}
+			if err != nil {
+				return exported, err
+			}
+			millis := int64(-1)
+			if ttl != NoExpiration {
+				// Round up so a key about to expire is not imported without one
+				millis = max(int64((ttl+time.Millisecond-1)/time.Millisecond), 1)
+			}
+			record = binary.AppendUvarint(record[:0], uint64(len(key)))
+			record = append(record, key...)
+			record = binary.AppendVarint(record, millis)
+			record = binary.AppendUvarint(record, uint64(len(value)))
+			record = append(record, value...)
+			if _, err := buffered.Write(record); err != nil {
+				return exported, err
+			}
+			exported++
+		}
+		if next == 0 {
+			return exported, buffered.Flush()
+		}
+		cursor = next
+	}
+}
+
+// Import sets the keys of a stream written by Export on server, replacing
+// keys of the same name. Their time to live starts over from what was left
+// at the export. Keys read before a corrupt part of the stream are kept. It
+// returns how many keys were set.
+func Import(ctx context.Context, server CacheServer, r io.Reader) (int64, error) {
+	buffered := bufio.NewReader(r)
+	header := make([]byte, len(exportHeader))
+	if _, err := io.ReadFull(buffered, header); err != nil || !bytes.Equal(header, exportHeader) {
+		return 0, ErrCorruptExport
+	}
+
+	var imported int64
+	ops := make([]*PipelineOp, 0, importBatch)
+	flush := func() error {
+		if err := server.Pipeline(ctx, ops); err != nil {
+			return err
+		}
+		for _, op := range ops {
+			if op.Err != nil {
+				return op.Err
+			}
+			imported++
+		}
+		ops = ops[:0]
+		return nil
+	}
+	// fail keeps the keys read before a corrupt record
+	fail := func(err error) (int64, error) {
+		if flushErr := flush(); flushErr != nil {
+			return imported, flushErr
+		}
+		return imported, err
+	}
+	for {
+		key, err := readExportRecord(buffered)
+		if errors.Is(err, io.EOF) {
+			return imported, flush()
+		}
+		if err != nil {
+			return fail(err)
+		}
+		millis, err := binary.ReadVarint(buffered)
+		if err != nil || millis == 0 || millis < -1 {
+			return fail(ErrCorruptExport)
+		}
+		value, err := readExportRecord(buffered)
+		if err != nil {
+			return fail(ErrCorruptExport)
+		}
+		var ttl time.Duration
+		if millis > 0 {
+			ttl = time.Duration(millis) * time.Millisecond
+		}
+		ops = append(ops, &PipelineOp{Command: PipelineSet, Keys: []string{string(key)}, Value: string(value), Expiration: ttl})
+		if len(ops) == importBatch {
+			if err := flush(); err != nil {
+				return imported, err
+			}
+		}
+	}
+}
+
+// readExportRecord reads one length prefixed part of a record, returning
+// io.EOF at the end of the stream
+func readExportRecord(r *bufio.Reader) ([]byte, error) {
+	size, err := binary.ReadUvarint(r)
+	if errors.Is(err, io.EOF) {
+		return nil, io.EOF
+	}
+	if err != nil || size > maxSnapshotRecord {
+		return nil, ErrCorruptExport
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, ErrCorruptExport
+	}
+	return data, nil
+}
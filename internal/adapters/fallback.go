@@ -0,0 +1,221 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultFallbackThreshold is how many consecutive errors take a backend
+	// of a FallbackChain out of the chain
+	DefaultFallbackThreshold = 3
+	// DefaultFallbackCooldown is how long a failing backend of a FallbackChain
+	// is skipped before it is tried again
+	DefaultFallbackCooldown = 5 * time.Second
+)
+
+// FallbackChain is a Cache over several backends in order of preference, e.g.
+// Redis first and a cache on local disk second. Reads try one backend after
+// the other until one has the key, and writes go to every backend, succeeding
+// when one of them took the write.
+//
+// Each backend is tracked by a Breaker: after Threshold consecutive errors it
+// is skipped for Cooldown, and keys written while it was skipped are deleted
+// from it once it answers again. Everything using the backend server
+// directly, such as counters, locks and pipelines, only uses the first
+// backend.
+type FallbackChain struct {
+	Backends []*Breaker
+
+	stats []fallbackStats
+}
+
+type fallbackStats struct {
+	hits    atomic.Uint64
+	misses  atomic.Uint64
+	skipped atomic.Uint64
+}
+
+// NewFallbackChain chains backends, at least one, in the given order.
+// threshold and cooldown configure the health tracking of every backend and
+// default to DefaultFallbackThreshold and DefaultFallbackCooldown when 0.
+func NewFallbackChain(threshold int, cooldown time.Duration, backends ...Cache) *FallbackChain {
+	if threshold <= 0 {
+		threshold = DefaultFallbackThreshold
+	}
+	if cooldown <= 0 {
+		cooldown = DefaultFallbackCooldown
+	}
+	chain := &FallbackChain{stats: make([]fallbackStats, len(backends))}
+	for _, backend := range backends {
+		chain.Backends = append(chain.Backends, NewBreaker(backend, nil, threshold, cooldown))
+	}
+	return chain
+}
+
+// Get returns the value of the first backend having key. It misses when the
+// backends that answered do not have it and fails with the errors of every
+// backend when none answered.
+func (f *FallbackChain) Get(ctx context.Context, key string) (interface{}, error) {
+	var errs []error
+	answered := false
+	for i, backend := range f.Backends {
+		// A backend that missed the last write of key has it stale, if at all
+		if backend.isDirty(key) {
+			f.stats[i].skipped.Add(1)
+			continue
+		}
+		value, err := backend.Get(ctx, key)
+		switch {
+		case err == nil:
+			f.stats[i].hits.Add(1)
+			return value, nil
+		case errors.Is(err, redis.Nil):
+			f.stats[i].misses.Add(1)
+			answered = true
+		default:
+			f.stats[i].skipped.Add(1)
+			errs = append(errs, err)
+		}
+	}
+	if answered {
+		return nil, redis.Nil
+	}
+	return nil, errors.Join(errs...)
+}
+
+// GetMany looks up the keys one backend missed in the next one
+func (f *FallbackChain) GetMany(ctx context.Context, keys ...string) (map[string]interface{}, error) {
+	found := make(map[string]interface{}, len(keys))
+	var errs []error
+	answered := false
+	for i, backend := range f.Backends {
+		values, err := backend.GetMany(ctx, keys...)
+		if err != nil && !errors.Is(err, redis.Nil) {
+			f.stats[i].skipped.Add(1)
+			errs = append(errs, err)
+			continue
+		}
+		answered = true
+		var missing []string
+		for _, key := range keys {
+			if value, ok := values[key]; ok && !backend.isDirty(key) {
+				found[key] = value
+			} else {
+				missing = append(missing, key)
+			}
+		}
+		f.stats[i].hits.Add(uint64(len(keys) - len(missing)))
+		f.stats[i].misses.Add(uint64(len(missing)))
+		if keys = missing; len(keys) == 0 {
+			break
+		}
+	}
+	if !answered {
+		return nil, errors.Join(errs...)
+	}
+	return found, nil
+}
+
+func (f *FallbackChain) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	return f.write(ctx, []string{key}, func(backend *Breaker) error {
+		return backend.Set(ctx, key, value, expiration)
+	})
+}
+
+func (f *FallbackChain) SetMany(ctx context.Context, values map[string]interface{}, expiration time.Duration) error {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	return f.write(ctx, keys, func(backend *Breaker) error {
+		return backend.SetMany(ctx, values, expiration)
+	})
+}
+
+func (f *FallbackChain) Delete(ctx context.Context, keys ...string) error {
+	return f.write(ctx, keys, func(backend *Breaker) error {
+		return backend.Delete(ctx, keys...)
+	})
+}
+
+// write runs fn on every backend and succeeds when one of them did. Keys a
+// backend failed to write are deleted from it when it recovers, so it never
+// serves values older than those of the other backends.
+func (f *FallbackChain) write(ctx context.Context, keys []string, fn func(backend *Breaker) error) error {
+	var errs []error
+	written := false
+	for _, backend := range f.Backends {
+		err := fn(backend)
+		if err == nil {
+			backend.clean(keys...)
+			written = true
+			continue
+		}
+		errs = append(errs, err)
+		if !errors.Is(err, ErrCircuitOpen) {
+			backend.markDirty(keys...)
+		}
+	}
+	if written {
+		return nil
+	}
+	return errors.Join(errs...)
+}
+
+// Ping succeeds when one of the backends answers
+func (f *FallbackChain) Ping(ctx context.Context) error {
+	var errs []error
+	for _, backend := range f.Backends {
+		err := Ping(ctx, backend.Cache)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// FallbackStatistics returns the "hits", "misses" and "skipped" reads of every
+// backend together with the state of its Breaker, keyed by the position of
+// the backend and its name, e.g. "0:redis"
+func (f *FallbackChain) FallbackStatistics() map[string]map[string]uint64 {
+	stats := make(map[string]map[string]uint64, len(f.Backends))
+	for i, backend := range f.Backends {
+		backendStats := backend.BreakerStatistics()
+		backendStats["hits"] = f.stats[i].hits.Load()
+		backendStats["misses"] = f.stats[i].misses.Load()
+		backendStats["skipped"] = f.stats[i].skipped.Load()
+		stats[strconv.Itoa(i)+":"+BackendName(backend.Cache)] = backendStats
+	}
+	return stats
+}
+
+// FindFallbackChain returns the FallbackChain in c, if any
+func FindFallbackChain(c Cache) (*FallbackChain, bool) {
+	switch driver := c.(type) {
+	case *FallbackChain:
+		return driver, true
+	case *Tiered:
+		return FindFallbackChain(driver.Remote)
+	case *Compressing:
+		return FindFallbackChain(driver.Cache)
+	case *Encrypting:
+		return FindFallbackChain(driver.Cache)
+	case *Migrating:
+		return FindFallbackChain(driver.New)
+	}
+	return nil, false
+}
+
+func (f *FallbackChain) Close() error {
+	var errs []error
+	for _, backend := range f.Backends {
+		errs = append(errs, backend.Close())
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,158 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"math"
+	"sort"
+)
+
+var (
+	ErrInvalidCoordinates = errors.New("invalid longitude or latitude")
+	ErrGeoUnit            = errors.New("unsupported unit, use m, km, mi or ft")
+)
+
+// The area Redis can index, the latitudes of the Web Mercator projection
+const (
+	geoLatitudeLimit  = 85.05112878
+	geoLongitudeLimit = 180
+	geoStep           = 26
+	// geoEarthRadius is the radius in meters Redis uses for distances
+	geoEarthRadius = 6372797.560856
+)
+
+// geoUnits are the meters in the units of Redis distances
+var geoUnits = map[string]float64{"m": 1, "km": 1000, "mi": 1609.34, "ft": 0.3048}
+
+// GeoAdd adds locations to the geospatial index at key, a sorted set, updating
+// the positions of existing members, and returns how many were added
+func (r *RedisClient) GeoAdd(ctx context.Context, key string, locations ...*redis.GeoLocation) (int64, error) {
+	return r.Client.GeoAdd(ctx, key, locations...).Result()
+}
+
+// GeoPos returns the positions of members, nil for those not in the index
+func (r *RedisClient) GeoPos(ctx context.Context, key string, members ...string) ([]*redis.GeoPos, error) {
+	return r.Client.GeoPos(ctx, key, members...).Result()
+}
+
+// GeoSearch returns up to count members, all when count is not positive,
+// within radius of a position, nearest first with their position and their
+// distance in unit: m, km, mi or ft
+func (r *RedisClient) GeoSearch(ctx context.Context, key string, longitude, latitude, radius float64, unit string, count int) ([]redis.GeoLocation, error) {
+	return r.Client.GeoSearchLocation(ctx, key, &redis.GeoSearchLocationQuery{
+		GeoSearchQuery: redis.GeoSearchQuery{
+			Longitude:  longitude,
+			Latitude:   latitude,
+			Radius:     radius,
+			RadiusUnit: unit,
+			Sort:       "ASC",
+			Count:      count,
+		},
+		WithCoord: true,
+		WithDist:  true,
+	}).Result()
+}
+
+// GeoAdd adds locations to the geospatial index at key, a sorted set scored by
+// geohash like in Redis, and returns how many were added
+func (m *Memory) GeoAdd(ctx context.Context, key string, locations ...*redis.GeoLocation) (int64, error) {
+	members := make([]redis.Z, len(locations))
+	for i, location := range locations {
+		if !validCoordinates(location.Longitude, location.Latitude) {
+			return 0, ErrInvalidCoordinates
+		}
+		members[i] = redis.Z{Member: location.Name, Score: float64(geoEncode(location.Longitude, location.Latitude))}
+	}
+	return m.ZAdd(ctx, key, members...)
+}
+
+// GeoPos returns the positions of members, nil for those not in the index
+func (m *Memory) GeoPos(ctx context.Context, key string, members ...string) ([]*redis.GeoPos, error) {
+	var positions []*redis.GeoPos
+	err := m.zset(ctx, key, func(zset map[string]float64) error {
+		positions = make([]*redis.GeoPos, len(members))
+		for i, member := range members {
+			if score, ok := zset[member]; ok {
+				longitude, latitude := geoDecode(uint64(score))
+				positions[i] = &redis.GeoPos{Longitude: longitude, Latitude: latitude}
+			}
+		}
+		return nil
+	})
+	return positions, err
+}
+
+// GeoSearch returns up to count members, all when count is not positive,
+// within radius of a position, nearest first with their position and their
+// distance in unit: m, km, mi or ft
+func (m *Memory) GeoSearch(ctx context.Context, key string, longitude, latitude, radius float64, unit string, count int) ([]redis.GeoLocation, error) {
+	meters, ok := geoUnits[unit]
+	if !ok {
+		return nil, ErrGeoUnit
+	}
+	if !validCoordinates(longitude, latitude) {
+		return nil, ErrInvalidCoordinates
+	}
+	var found []redis.GeoLocation
+	err := m.zset(ctx, key, func(zset map[string]float64) error {
+		found = []redis.GeoLocation{}
+		for member, score := range zset {
+			lon, lat := geoDecode(uint64(score))
+			distance := geoDistance(longitude, latitude, lon, lat) / meters
+			if distance <= radius {
+				found = append(found, redis.GeoLocation{Name: member, Longitude: lon, Latitude: lat, Dist: distance})
+			}
+		}
+		return nil
+	})
+	sort.Slice(found, func(i, j int) bool {
+		if found[i].Dist != found[j].Dist {
+			return found[i].Dist < found[j].Dist
+		}
+		return found[i].Name < found[j].Name
+	})
+	if count > 0 && len(found) > count {
+		found = found[:count]
+	}
+	return found, err
+}
+
+func validCoordinates(longitude, latitude float64) bool {
+	return math.Abs(longitude) <= geoLongitudeLimit && math.Abs(latitude) <= geoLatitudeLimit
+}
+
+// geoEncode returns the 52 bit geohash Redis scores a position with, the bits
+// of the latitude and longitude cells interleaved
+func geoEncode(longitude, latitude float64) uint64 {
+	lat := uint64((latitude + geoLatitudeLimit) / (2 * geoLatitudeLimit) * (1 << geoStep))
+	lon := uint64((longitude + geoLongitudeLimit) / (2 * geoLongitudeLimit) * (1 << geoStep))
+	lat, lon = min(lat, 1<<geoStep-1), min(lon, 1<<geoStep-1)
+	var hash uint64
+	for bit := 0; bit < geoStep; bit++ {
+		hash |= (lat >> bit & 1) << (2 * bit)
+		hash |= (lon >> bit & 1) << (2*bit + 1)
+	}
+	return hash
+}
+
+// geoDecode returns the center of the cell of a geohash
+func geoDecode(hash uint64) (longitude, latitude float64) {
+	var lat, lon uint64
+	for bit := 0; bit < geoStep; bit++ {
+		lat |= (hash >> (2 * bit) & 1) << bit
+		lon |= (hash >> (2*bit + 1) & 1) << bit
+	}
+	latitude = -geoLatitudeLimit + (float64(lat)+0.5)/(1<<geoStep)*(2*geoLatitudeLimit)
+	longitude = -geoLongitudeLimit + (float64(lon)+0.5)/(1<<geoStep)*(2*geoLongitudeLimit)
+	return longitude, latitude
+}
+
+// geoDistance returns the haversine distance in meters between two positions
+func geoDistance(lon1, lat1, lon2, lat2 float64) float64 {
+	toRadians := math.Pi / 180
+	u := math.Sin((lat2 - lat1) * toRadians / 2)
+	v := math.Sin((lon2 - lon1) * toRadians / 2)
+	a := u*u + math.Cos(lat1*toRadians)*math.Cos(lat2*toRadians)*v*v
+	return 2 * geoEarthRadius * math.Asin(math.Sqrt(a))
+}
@@ -0,0 +1,72 @@
+package adapters
+
+import (
+	"context"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+// GetDel returns the value of key and deletes it atomically
+func (r *RedisClient) GetDel(ctx context.Context, key string) (string, error) {
+	return r.Client.GetDel(ctx, key).Result()
+}
+
+// GetSet stores value at key and returns the previous value atomically, or
+// redis.Nil if there was none. The expiration follows the rules of Set.
+func (r *RedisClient) GetSet(ctx context.Context, key string, value interface{}, expiration time.Duration) (string, error) {
+	args := redis.SetArgs{Get: true}
+	if expiration == redis.KeepTTL {
+		args.KeepTTL = true
+	} else if expiration > 0 {
+		args.TTL = expiration
+	}
+	return r.Client.SetArgs(ctx, key, value, args).Result()
+}
+
+// GetDel returns the value of key and deletes it atomically
+func (m *Memory) GetDel(ctx context.Context, key string) (string, error) {
+	var result string
+	err := m.update(ctx, key, func(s *memoryShard, e *memoryEntry) error {
+		if e == nil {
+			return redis.Nil
+		}
+		if e.kind != memoryString {
+			return ErrWrongType
+		}
+		result = e.value
+		delete(s.items, key)
+		return nil
+	})
+	return result, err
+}
+
+// GetSet stores value at key and returns the previous value atomically, or
+// redis.Nil if there was none. The expiration follows the rules of Set.
+func (m *Memory) GetSet(ctx context.Context, key string, value interface{}, expiration time.Duration) (string, error) {
+	str, err := formatValue(value)
+	if err != nil {
+		return "", err
+	}
+
+	var previous string
+	err = m.update(ctx, key, func(s *memoryShard, e *memoryEntry) error {
+		if e != nil && e.kind != memoryString {
+			return ErrWrongType
+		}
+
+		next := &memoryEntry{kind: memoryString, value: str}
+		if expiration == redis.KeepTTL && e != nil {
+			next.expiresAt = e.expiresAt
+		} else if expiration > 0 {
+			next.expiresAt = time.Now().Add(expiration)
+		}
+		s.items[key] = next
+
+		if e == nil {
+			return redis.Nil
+		}
+		previous = e.value
+		return nil
+	})
+	return previous, err
+}
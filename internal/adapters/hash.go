@@ -0,0 +1,204 @@
+package adapters
+
+import (
+	"context"
+	"github.com/redis/go-redis/v9"
+	"strconv"
+)
+
+// HIncrByMany increments the fields of the hash at key by the given amounts,
+// sending every HINCRBY in one pipeline
+func (r *RedisClient) HIncrByMany(ctx context.Context, key string, increments map[string]int64) error {
+	if len(increments) == 0 {
+		return nil
+	}
+	_, err := r.Client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for field, increment := range increments {
+			pipe.HIncrBy(ctx, key, field, increment)
+		}
+		return nil
+	})
+	return err
+}
+
+// HGetAll returns every field of the hash at key
+func (r *RedisClient) HGetAll(ctx context.Context, key string) (map[string]string, error) {
+	return r.Client.HGetAll(ctx, key).Result()
+}
+
+// HSet sets fields of the hash at key and returns how many were added
+func (r *RedisClient) HSet(ctx context.Context, key string, values map[string]interface{}) (int64, error) {
+	return r.Client.HSet(ctx, key, values).Result()
+}
+
+// HGet returns a field of the hash at key, redis.Nil if it is missing
+func (r *RedisClient) HGet(ctx context.Context, key, field string) (string, error) {
+	return r.Client.HGet(ctx, key, field).Result()
+}
+
+// HDel removes fields from the hash at key and returns how many existed
+func (r *RedisClient) HDel(ctx context.Context, key string, fields ...string) (int64, error) {
+	return r.Client.HDel(ctx, key, fields...).Result()
+}
+
+// HIncrBy adds delta to the integer in a field of the hash at key
+func (r *RedisClient) HIncrBy(ctx context.Context, key, field string, delta int64) (int64, error) {
+	return r.Client.HIncrBy(ctx, key, field, delta).Result()
+}
+
+// HIncrByMany increments the fields of the hash at key by the given amounts
+func (m *Memory) HIncrByMany(ctx context.Context, key string, increments map[string]int64) error {
+	if len(increments) == 0 {
+		return nil
+	}
+	return m.update(ctx, key, func(s *memoryShard, e *memoryEntry) error {
+		if e == nil {
+			e = &memoryEntry{kind: memoryHash, hash: make(map[string]string)}
+			s.items[key] = e
+		}
+		if e.kind != memoryHash {
+			return ErrWrongType
+		}
+
+		// Validate every field first so a failure leaves the hash untouched
+		next := make(map[string]string, len(increments))
+		for field, increment := range increments {
+			current := int64(0)
+			if value, exists := e.hash[field]; exists {
+				parsed, err := strconv.ParseInt(value, 10, 64)
+				if err != nil {
+					return ErrNotInteger
+				}
+				current = parsed
+			}
+			next[field] = strconv.FormatInt(current+increment, 10)
+		}
+		for field, value := range next {
+			e.hash[field] = value
+		}
+		return nil
+	})
+}
+
+// HGetAll returns every field of the hash at key
+func (m *Memory) HGetAll(ctx context.Context, key string) (map[string]string, error) {
+	var result map[string]string
+	err := m.update(ctx, key, func(s *memoryShard, e *memoryEntry) error {
+		result = map[string]string{}
+		if e == nil {
+			return nil
+		}
+		if e.kind != memoryHash {
+			return ErrWrongType
+		}
+		for field, value := range e.hash {
+			result[field] = value
+		}
+		return nil
+	})
+	return result, err
+}
+
+// HSet sets fields of the hash at key and returns how many were added
+func (m *Memory) HSet(ctx context.Context, key string, values map[string]interface{}) (int64, error) {
+	formatted := make(map[string]string, len(values))
+	for field, value := range values {
+		str, err := formatValue(value)
+		if err != nil {
+			return 0, err
+		}
+		formatted[field] = str
+	}
+
+	var added int64
+	err := m.update(ctx, key, func(s *memoryShard, e *memoryEntry) error {
+		added = 0
+		if e == nil {
+			e = &memoryEntry{kind: memoryHash, hash: make(map[string]string)}
+			s.items[key] = e
+		}
+		if e.kind != memoryHash {
+			return ErrWrongType
+		}
+		for field, value := range formatted {
+			if _, exists := e.hash[field]; !exists {
+				added++
+			}
+			e.hash[field] = value
+		}
+		return nil
+	})
+	return added, err
+}
+
+// HGet returns a field of the hash at key, redis.Nil if it is missing
+func (m *Memory) HGet(ctx context.Context, key, field string) (string, error) {
+	var result string
+	err := m.update(ctx, key, func(s *memoryShard, e *memoryEntry) error {
+		if e == nil {
+			return redis.Nil
+		}
+		if e.kind != memoryHash {
+			return ErrWrongType
+		}
+		value, exists := e.hash[field]
+		if !exists {
+			return redis.Nil
+		}
+		result = value
+		return nil
+	})
+	return result, err
+}
+
+// HDel removes fields from the hash at key and returns how many existed. Like
+// Redis, a hash left without fields is deleted.
+func (m *Memory) HDel(ctx context.Context, key string, fields ...string) (int64, error) {
+	var removed int64
+	err := m.update(ctx, key, func(s *memoryShard, e *memoryEntry) error {
+		removed = 0
+		if e == nil {
+			return nil
+		}
+		if e.kind != memoryHash {
+			return ErrWrongType
+		}
+		for _, field := range fields {
+			if _, exists := e.hash[field]; exists {
+				delete(e.hash, field)
+				removed++
+			}
+		}
+		if len(e.hash) == 0 {
+			delete(s.items, key)
+		}
+		return nil
+	})
+	return removed, err
+}
+
+// HIncrBy adds delta to the integer in a field of the hash at key
+func (m *Memory) HIncrBy(ctx context.Context, key, field string, delta int64) (int64, error) {
+	var result int64
+	err := m.update(ctx, key, func(s *memoryShard, e *memoryEntry) error {
+		if e == nil {
+			e = &memoryEntry{kind: memoryHash, hash: make(map[string]string)}
+			s.items[key] = e
+		}
+		if e.kind != memoryHash {
+			return ErrWrongType
+		}
+		current := int64(0)
+		if value, exists := e.hash[field]; exists {
+			parsed, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return ErrNotInteger
+			}
+			current = parsed
+		}
+		result = current + delta
+		e.hash[field] = strconv.FormatInt(result, 10)
+		return nil
+	})
+	return result, err
+}
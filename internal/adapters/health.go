@@ -0,0 +1,60 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"github.com/redis/go-redis/v9"
+)
+
+// Pinger is implemented by backends that can check their connection cheaply
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// Ping sends PING to Redis
+func (r *RedisClient) Ping(ctx context.Context) error {
+	return r.Client.Ping(ctx).Err()
+}
+
+// Ping always succeeds, memory is always reachable
+func (m *Memory) Ping(ctx context.Context) error {
+	return nil
+}
+
+func (p *Prefixed) Ping(ctx context.Context) error {
+	return Ping(ctx, NewCache(p.Server))
+}
+
+// pingKey is read by Ping for adapters without a Pinger
+const pingKey = "cacher:ping"
+
+// Ping checks that the backend behind c answers, bypassing any circuit
+// breaker, retries and local tier. Adapters that are not a Pinger are probed
+// by reading a key, where a miss counts as success.
+func Ping(ctx context.Context, c Cache) error {
+	switch driver := c.(type) {
+	case Pinger:
+		return driver.Ping(ctx)
+	case *Tiered:
+		return Ping(ctx, driver.Remote)
+	case *Breaker:
+		return Ping(ctx, driver.Cache)
+	case *Retrying:
+		return Ping(ctx, driver.Cache)
+	case *Compressing:
+		return Ping(ctx, driver.Cache)
+	case *Encrypting:
+		return Ping(ctx, driver.Cache)
+	case *Migrating:
+		return errors.Join(Ping(ctx, driver.Old), Ping(ctx, driver.New))
+	case *cacheDriver:
+		if pinger, ok := driver.Server.(Pinger); ok {
+			return pinger.Ping(ctx)
+		}
+	}
+
+	if _, err := c.Get(ctx, pingKey); err != nil && !errors.Is(err, redis.Nil) {
+		return err
+	}
+	return nil
+}
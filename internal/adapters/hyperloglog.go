@@ -0,0 +1,187 @@
+package adapters
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"math/bits"
+	"strings"
+)
+
+// PFAdd adds elements to the HyperLogLog at key and reports whether its
+// estimate changed
+func (r *RedisClient) PFAdd(ctx context.Context, key string, elements ...interface{}) (bool, error) {
+	changed, err := r.Client.PFAdd(ctx, key, elements...).Result()
+	return changed == 1, err
+}
+
+// PFCount estimates the number of distinct elements added to the union of the
+// HyperLogLogs at keys
+func (r *RedisClient) PFCount(ctx context.Context, keys ...string) (int64, error) {
+	return r.Client.PFCount(ctx, keys...).Result()
+}
+
+// PFMerge stores the union of the HyperLogLogs at keys, and at dest, in dest
+func (r *RedisClient) PFMerge(ctx context.Context, dest string, keys ...string) error {
+	return r.Client.PFMerge(ctx, dest, keys...).Err()
+}
+
+// The memory HyperLogLog has 2^14 one byte registers like Redis, for a
+// standard error of 0.81%. It is stored as a string value.
+const (
+	hllPrecision = 14
+	hllRegisters = 1 << hllPrecision
+	hllMagic     = "\x00hll"
+)
+
+func parseHLL(value string) ([]byte, error) {
+	if len(value) != len(hllMagic)+hllRegisters || !strings.HasPrefix(value, hllMagic) {
+		return nil, ErrWrongType
+	}
+	return []byte(value[len(hllMagic):]), nil
+}
+
+// hllAdd sets the register of element, returning whether it grew
+func hllAdd(registers []byte, element string) bool {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(element))
+	// FNV mixes its low bits poorly, so the hash is finalized like splitmix64
+	hash := h.Sum64()
+	hash ^= hash >> 30
+	hash *= 0xbf58476d1ce4e5b9
+	hash ^= hash >> 27
+	hash *= 0x94d049bb133111eb
+	hash ^= hash >> 31
+
+	index := hash & (hllRegisters - 1)
+	rank := byte(bits.TrailingZeros64(hash>>hllPrecision|1<<(64-hllPrecision)) + 1)
+	if rank <= registers[index] {
+		return false
+	}
+	registers[index] = rank
+	return true
+}
+
+// hllEstimate returns the cardinality estimated from the registers, using
+// linear counting for small cardinalities
+func hllEstimate(registers []byte) int64 {
+	const m = float64(hllRegisters)
+	alpha := 0.7213 / (1 + 1.079/m)
+	sum, zeros := 0.0, 0
+	for _, register := range registers {
+		sum += math.Ldexp(1, -int(register))
+		if register == 0 {
+			zeros++
+		}
+	}
+	estimate := alpha * m * m / sum
+	if estimate <= 2.5*m && zeros > 0 {
+		estimate = m * math.Log(m/float64(zeros))
+	}
+	return int64(math.Round(estimate))
+}
+
+// PFAdd adds elements to the HyperLogLog at key and reports whether its
+// estimate changed
+func (m *Memory) PFAdd(ctx context.Context, key string, elements ...interface{}) (bool, error) {
+	formatted := make([]string, len(elements))
+	for i, element := range elements {
+		str, err := formatValue(element)
+		if err != nil {
+			return false, err
+		}
+		formatted[i] = str
+	}
+
+	changed := false
+	err := m.update(ctx, key, func(s *memoryShard, e *memoryEntry) error {
+		changed = false
+		registers := make([]byte, hllRegisters)
+		if e == nil {
+			e = &memoryEntry{kind: memoryString}
+			s.items[key] = e
+			changed = true
+		} else if e.kind != memoryString {
+			return ErrWrongType
+		} else {
+			var err error
+			if registers, err = parseHLL(e.value); err != nil {
+				return err
+			}
+		}
+		for _, element := range formatted {
+			if hllAdd(registers, element) {
+				changed = true
+			}
+		}
+		e.value = hllMagic + string(registers)
+		return nil
+	})
+	return changed, err
+}
+
+// PFCount estimates the number of distinct elements added to the union of the
+// HyperLogLogs at keys
+func (m *Memory) PFCount(ctx context.Context, keys ...string) (int64, error) {
+	union, err := m.hllUnion(ctx, keys)
+	if err != nil {
+		return 0, err
+	}
+	return hllEstimate(union), nil
+}
+
+// PFMerge stores the union of the HyperLogLogs at keys, and at dest, in dest.
+// The sources are read one at a time, not in one atomic step.
+func (m *Memory) PFMerge(ctx context.Context, dest string, keys ...string) error {
+	union, err := m.hllUnion(ctx, keys)
+	if err != nil {
+		return err
+	}
+	return m.update(ctx, dest, func(s *memoryShard, e *memoryEntry) error {
+		merged := append([]byte(nil), union...)
+		if e == nil {
+			e = &memoryEntry{kind: memoryString}
+			s.items[dest] = e
+		} else if e.kind != memoryString {
+			return ErrWrongType
+		} else {
+			registers, err := parseHLL(e.value)
+			if err != nil {
+				return err
+			}
+			for i, register := range registers {
+				merged[i] = max(merged[i], register)
+			}
+		}
+		e.value = hllMagic + string(merged)
+		return nil
+	})
+}
+
+// hllUnion returns the registers of the union of the HyperLogLogs at keys,
+// treating missing keys as empty
+func (m *Memory) hllUnion(ctx context.Context, keys []string) ([]byte, error) {
+	union := make([]byte, hllRegisters)
+	for _, key := range keys {
+		err := m.update(ctx, key, func(s *memoryShard, e *memoryEntry) error {
+			if e == nil {
+				return nil
+			}
+			if e.kind != memoryString {
+				return ErrWrongType
+			}
+			registers, err := parseHLL(e.value)
+			if err != nil {
+				return err
+			}
+			for i, register := range registers {
+				union[i] = max(union[i], register)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return union, nil
+}
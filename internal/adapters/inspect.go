@@ -0,0 +1,150 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+// NoExpiration is the TTL of a key that never expires
+const NoExpiration time.Duration = -1
+
+// Exists reports whether key exists
+func (r *RedisClient) Exists(ctx context.Context, key string) (bool, error) {
+	n, err := r.Client.Exists(ctx, key).Result()
+	return n > 0, err
+}
+
+// TTL returns the remaining lifetime of key, NoExpiration if it never expires
+// and redis.Nil if it does not exist
+func (r *RedisClient) TTL(ctx context.Context, key string) (time.Duration, error) {
+	ttl, err := r.Client.PTTL(ctx, key).Result()
+	if err != nil {
+		return 0, err
+	}
+	switch ttl {
+	case -2:
+		return 0, redis.Nil
+	case -1:
+		return NoExpiration, nil
+	}
+	return ttl, nil
+}
+
+// Exists reports whether key exists
+func (m *Memory) Exists(ctx context.Context, key string) (bool, error) {
+	found := false
+	err := m.update(ctx, key, func(s *memoryShard, e *memoryEntry) error {
+		found = e != nil
+		return nil
+	})
+	return found, err
+}
+
+// TTL returns the remaining lifetime of key, NoExpiration if it never expires
+// and redis.Nil if it does not exist
+func (m *Memory) TTL(ctx context.Context, key string) (time.Duration, error) {
+	var ttl time.Duration
+	err := m.update(ctx, key, func(s *memoryShard, e *memoryEntry) error {
+		switch {
+		case e == nil:
+			return redis.Nil
+		case e.expiresAt.IsZero():
+			ttl = NoExpiration
+		default:
+			ttl = time.Until(e.expiresAt)
+		}
+		return nil
+	})
+	return ttl, err
+}
+
+// ttlGetter is a CacheServer that reads a value together with its TTL in one
+// round trip, see GetWithTTL
+type ttlGetter interface {
+	GetWithTTL(ctx context.Context, key string) (string, time.Duration, error)
+}
+
+// GetWithTTL returns the string at key and its remaining lifetime, or
+// redis.Nil if it does not exist. Servers that cannot do it at once are
+// asked with Get and TTL.
+func GetWithTTL(ctx context.Context, server CacheServer, key string) (string, time.Duration, error) {
+	if getter, ok := server.(ttlGetter); ok {
+		return getter.GetWithTTL(ctx, key)
+	}
+	value, err := server.Get(ctx, key)
+	if err != nil {
+		return "", 0, err
+	}
+	ttl, err := server.TTL(ctx, key)
+	return value, ttl, err
+}
+
+// GetWithTTL sends GET and PTTL in one pipeline
+func (r *RedisClient) GetWithTTL(ctx context.Context, key string) (string, time.Duration, error) {
+	var get *redis.StringCmd
+	var pttl *redis.DurationCmd
+	_, err := r.Client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		get = pipe.Get(ctx, key)
+		pttl = pipe.PTTL(ctx, key)
+		return nil
+	})
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return "", 0, err
+	}
+	value, err := get.Result()
+	if err != nil {
+		return "", 0, err
+	}
+	switch ttl := pttl.Val(); ttl {
+	case -2:
+		// Expired between the two commands
+		return "", 0, redis.Nil
+	case -1:
+		return value, NoExpiration, nil
+	default:
+		return value, ttl, nil
+	}
+}
+
+// GetWithTTL returns the string at key and its remaining lifetime at once
+func (m *Memory) GetWithTTL(ctx context.Context, key string) (string, time.Duration, error) {
+	var value string
+	var ttl time.Duration
+	err := m.update(ctx, key, func(s *memoryShard, e *memoryEntry) error {
+		switch {
+		case e == nil:
+			return redis.Nil
+		case e.kind != memoryString:
+			return ErrWrongType
+		case e.expiresAt.IsZero():
+			ttl = NoExpiration
+		default:
+			ttl = time.Until(e.expiresAt)
+		}
+		value = e.value
+		return nil
+	})
+	return value, ttl, err
+}
+
+func (p *Prefixed) GetWithTTL(ctx context.Context, key string) (string, time.Duration, error) {
+	return GetWithTTL(ctx, p.Server, p.key(key))
+}
+
+func (s *Sharded) GetWithTTL(ctx context.Context, key string) (string, time.Duration, error) {
+	return GetWithTTL(ctx, s.shard(key), key)
+}
+
+func (r *Replicated) GetWithTTL(ctx context.Context, key string) (string, time.Duration, error) {
+	type valueWithTTL struct {
+		value string
+		ttl   time.Duration
+	}
+	result, err := replicaRead(r, func(server CacheServer) (valueWithTTL, error) {
+		value, ttl, err := GetWithTTL(ctx, server, key)
+		return valueWithTTL{value, ttl}, err
+	})
+	return result.value, result.ttl, err
+}
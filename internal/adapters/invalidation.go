@@ -0,0 +1,82 @@
+package adapters
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/redis/go-redis/v9"
+)
+
+// InvalidationBus broadcasts keys that changed so every node can drop its
+// local copy of them
+type InvalidationBus interface {
+	Publish(ctx context.Context, keys ...string) error
+	Subscribe(handler func(keys []string)) error
+	Close() error
+}
+
+type invalidationMessage struct {
+	Origin string   `json:"origin"`
+	Keys   []string `json:"keys"`
+}
+
+// RedisInvalidationBus is an InvalidationBus over a Redis Pub/Sub channel.
+// Messages published by a bus are not delivered back to its own handler.
+type RedisInvalidationBus struct {
+	client  redis.UniversalClient
+	channel string
+	origin  string
+	pubsub  *redis.PubSub
+}
+
+// NewRedisInvalidationBus creates a bus publishing on channel
+func NewRedisInvalidationBus(client redis.UniversalClient, channel string) *RedisInvalidationBus {
+	id := make([]byte, 8)
+	_, _ = rand.Read(id)
+	return &RedisInvalidationBus{
+		client:  client,
+		channel: channel,
+		origin:  hex.EncodeToString(id),
+	}
+}
+
+// Publish announces that keys changed
+func (b *RedisInvalidationBus) Publish(ctx context.Context, keys ...string) error {
+	payload, err := json.Marshal(invalidationMessage{Origin: b.origin, Keys: keys})
+	if err != nil {
+		return err
+	}
+	return b.client.Publish(ctx, b.channel, payload).Err()
+}
+
+// Subscribe calls handler for every change announced by other nodes
+func (b *RedisInvalidationBus) Subscribe(handler func(keys []string)) error {
+	b.pubsub = b.client.Subscribe(context.Background(), b.channel)
+	// Wait for the subscription to be confirmed so no message is missed
+	if _, err := b.pubsub.Receive(context.Background()); err != nil {
+		_ = b.pubsub.Close()
+		return err
+	}
+
+	go func() {
+		for msg := range b.pubsub.Channel() {
+			var message invalidationMessage
+			if err := json.Unmarshal([]byte(msg.Payload), &message); err != nil {
+				continue
+			}
+			if message.Origin != b.origin {
+				handler(message.Keys)
+			}
+		}
+	}()
+	return nil
+}
+
+// Close stops the subscription
+func (b *RedisInvalidationBus) Close() error {
+	if b.pubsub == nil {
+		return nil
+	}
+	return b.pubsub.Close()
+}
@@ -0,0 +1,157 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"io"
+	"strings"
+	"sync"
+)
+
+// The key events, named like the keyspace notifications of Redis
+const (
+	KeyExpired = "expired"
+	KeyDeleted = "del"
+)
+
+// KeyEvent tells that Key expired or was deleted
+type KeyEvent struct {
+	Type string
+	Key  string
+}
+
+// keyEventChannels are the keyspace notification channels of the events of
+// every database
+var keyEventChannels = []string{"__keyevent@*__:" + KeyExpired, "__keyevent@*__:" + KeyDeleted}
+
+// memoryEventBuffer is how many events a subscription of Memory holds for a
+// slow handler before it drops new ones
+const memoryEventBuffer = 1024
+
+// SubscribeKeyEvents calls handler for every key that expires or is deleted
+// until the returned Closer is closed. Redis only publishes them when
+// notify-keyspace-events contains E, g and x, see EnableKeyEvents. On a
+// cluster every master is subscribed to, as each publishes its own keys.
+func (r *RedisClient) SubscribeKeyEvents(ctx context.Context, handler func(KeyEvent)) (io.Closer, error) {
+	cluster, ok := r.Client.(*redis.ClusterClient)
+	if !ok {
+		return subscribeKeyEvents(ctx, r.Client, handler)
+	}
+	var mutex sync.Mutex
+	var subscriptions closers
+	err := cluster.ForEachMaster(ctx, func(ctx context.Context, node *redis.Client) error {
+		pubsub, err := subscribeKeyEvents(ctx, node, handler)
+		if err != nil {
+			return err
+		}
+		mutex.Lock()
+		subscriptions = append(subscriptions, pubsub)
+		mutex.Unlock()
+		return nil
+	})
+	if err != nil {
+		_ = subscriptions.Close()
+		return nil, err
+	}
+	return subscriptions, nil
+}
+
+func subscribeKeyEvents(ctx context.Context, client redis.UniversalClient, handler func(KeyEvent)) (*redis.PubSub, error) {
+	pubsub := client.PSubscribe(ctx, keyEventChannels...)
+	// Wait for every pattern to be confirmed so no event is missed
+	for range keyEventChannels {
+		if _, err := pubsub.Receive(ctx); err != nil {
+			_ = pubsub.Close()
+			return nil, err
+		}
+	}
+	go func() {
+		for msg := range pubsub.Channel() {
+			event := msg.Channel[strings.LastIndexByte(msg.Channel, ':')+1:]
+			handler(KeyEvent{Type: event, Key: msg.Payload})
+		}
+	}()
+	return pubsub, nil
+}
+
+// EnableKeyEvents adds the expired and generic events to the
+// notify-keyspace-events of the server, keeping the events it already
+// publishes. Managed services that disable CONFIG need it set by other means.
+func (r *RedisClient) EnableKeyEvents(ctx context.Context) error {
+	config, err := r.Client.ConfigGet(ctx, "notify-keyspace-events").Result()
+	if err != nil {
+		return err
+	}
+	flags := config["notify-keyspace-events"]
+	for _, flag := range "Egx" {
+		// A includes g and x
+		if strings.ContainsRune(flags, flag) || flag != 'E' && strings.ContainsRune(flags, 'A') {
+			continue
+		}
+		flags += string(flag)
+	}
+	return r.Client.ConfigSet(ctx, "notify-keyspace-events", flags).Err()
+}
+
+// closers closes several subscriptions at once
+type closers []io.Closer
+
+func (c closers) Close() error {
+	var errs []error
+	for _, closer := range c {
+		errs = append(errs, closer.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// memorySubscription delivers the events of Memory to one handler
+type memorySubscription struct {
+	m      *Memory
+	events chan KeyEvent
+	once   sync.Once
+}
+
+// SubscribeKeyEvents calls handler for every key that is deleted with Delete
+// or found expired, when it is accessed or by the janitor, until the returned
+// Closer is closed. Entries a Store expires by itself are not reported.
+// Handlers run on their own goroutine; events are dropped while one is more
+// than memoryEventBuffer events behind.
+func (m *Memory) SubscribeKeyEvents(ctx context.Context, handler func(KeyEvent)) (io.Closer, error) {
+	subscription := &memorySubscription{m: m, events: make(chan KeyEvent, memoryEventBuffer)}
+	m.subscriptionMutex.Lock()
+	if m.subscriptions == nil {
+		m.subscriptions = make(map[*memorySubscription]struct{})
+	}
+	m.subscriptions[subscription] = struct{}{}
+	m.subscriptionMutex.Unlock()
+
+	go func() {
+		for event := range subscription.events {
+			handler(event)
+		}
+	}()
+	return subscription, nil
+}
+
+func (s *memorySubscription) Close() error {
+	s.once.Do(func() {
+		s.m.subscriptionMutex.Lock()
+		delete(s.m.subscriptions, s)
+		s.m.subscriptionMutex.Unlock()
+		close(s.events)
+	})
+	return nil
+}
+
+// emit sends an event to every subscription without waiting for handlers
+func (m *Memory) emit(event, key string) {
+	m.subscriptionMutex.RLock()
+	defer m.subscriptionMutex.RUnlock()
+	for subscription := range m.subscriptions {
+		select {
+		case subscription.events <- KeyEvent{Type: event, Key: key}:
+		default:
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package adapters
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// hashedKeyReadable is how much of a hashed key is kept readable in front of
+// its hash
+const hashedKeyReadable = 32
+
+// SafeKey returns key unchanged if it is at most maxLength bytes of printable
+// ASCII without spaces, which every backend accepts. Other keys are replaced
+// by their SHA-256, led by the start of the key with unsafe bytes replaced by
+// _ so they stay recognizable, e.g. "search:query_with_spaces#3f2a...".
+func SafeKey(key string, maxLength int) string {
+	if len(key) <= maxLength && !hasUnsafeByte(key) {
+		return key
+	}
+	sum := sha256.Sum256([]byte(key))
+	hash := hex.EncodeToString(sum[:])
+	if maxLength <= len(hash) {
+		return hash[:max(maxLength, 0)]
+	}
+
+	readable := []byte(key[:min(len(key), hashedKeyReadable, maxLength-len(hash)-1)])
+	for i, b := range readable {
+		if unsafeByte(b) {
+			readable[i] = '_'
+		}
+	}
+	return string(readable) + "#" + hash
+}
+
+func unsafeByte(b byte) bool {
+	return b <= ' ' || b >= 0x7f
+}
+
+func hasUnsafeByte(key string) bool {
+	for i := 0; i < len(key); i++ {
+		if unsafeByte(key[i]) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,236 @@
+package adapters
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+)
+
+// tokenBucketScript refills the bucket stored at KEYS[1] based on the server
+// clock and takes one token from it, all in a single atomic step
+var tokenBucketScript = DefaultScripts.Register("token_bucket", `
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local clock = redis.call('TIME')
+local now = tonumber(clock[1]) + tonumber(clock[2]) / 1000000
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1])
+local ts = tonumber(state[2])
+if tokens == nil or ts == nil then
+	tokens = burst
+	ts = now
+end
+
+tokens = math.min(burst, tokens + math.max(0, now - ts) * rate)
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', tostring(now))
+redis.call('EXPIRE', KEYS[1], math.ceil(burst / rate) + 1)
+return {allowed, math.floor(tokens)}
+`)
+
+// TokenBucket takes one token from the bucket at key, which holds up to burst
+// tokens and refills at rate tokens per second. It reports whether a token was
+// available and how many whole tokens remain.
+func (r *RedisClient) TokenBucket(ctx context.Context, key string, rate float64, burst int64) (bool, int64, error) {
+	result, err := tokenBucketScript.Run(ctx, r.Client, []string{key}, rate, burst).Int64Slice()
+	if err != nil {
+		return false, 0, err
+	}
+	return result[0] == 1, result[1], nil
+}
+
+// TokenBucket takes one token from the bucket at key, which holds up to burst
+// tokens and refills at rate tokens per second. It reports whether a token was
+// available and how many whole tokens remain.
+func (m *Memory) TokenBucket(ctx context.Context, key string, rate float64, burst int64) (bool, int64, error) {
+	var allowed bool
+	var remaining float64
+	err := m.update(ctx, key, func(s *memoryShard, e *memoryEntry) error {
+		now := time.Now()
+		tokens, last := float64(burst), now
+		if e != nil {
+			if e.kind != memoryString {
+				return ErrWrongType
+			}
+			var lastNano int64
+			if _, err := fmt.Sscanf(e.value, "%g %d", &tokens, &lastNano); err != nil {
+				return ErrWrongType
+			}
+			last = time.Unix(0, lastNano)
+		}
+
+		remaining, allowed = takeToken(tokens, last, now, rate, burst)
+		s.items[key] = &memoryEntry{
+			kind:      memoryString,
+			value:     fmt.Sprintf("%g %d", remaining, now.UnixNano()),
+			expiresAt: now.Add(bucketLifetime(rate, burst)),
+		}
+		return nil
+	})
+	return allowed, int64(math.Floor(remaining)), err
+}
+
+// takeToken refills a bucket holding tokens at last up to now and takes one
+// token from it if available
+func takeToken(tokens float64, last, now time.Time, rate float64, burst int64) (float64, bool) {
+	if elapsed := now.Sub(last).Seconds(); elapsed > 0 {
+		tokens += elapsed * rate
+	}
+	tokens = math.Min(float64(burst), tokens)
+	if tokens < 1 {
+		return tokens, false
+	}
+	return tokens - 1, true
+}
+
+// bucketLifetime is how long an untouched bucket takes to refill completely,
+// after which its state is no longer needed
+func bucketLifetime(rate float64, burst int64) time.Duration {
+	return time.Duration(math.Ceil(float64(burst)/rate)+1) * time.Second
+}
+
+// GCRAResult is the outcome of a GCRA rate limit check, mirroring the reply
+// of redis-cell's CL.THROTTLE
+type GCRAResult struct {
+	Allowed    bool
+	Limit      int64         // Maximum number of requests in a burst
+	Remaining  int64         // Requests left before being limited
+	RetryAfter time.Duration // When a limited request may be retried, -1 if allowed
+	ResetAfter time.Duration // When the limit is back to its maximum
+}
+
+// gcraScript applies the generic cell rate algorithm to the theoretical arrival
+// time stored at KEYS[1]. Times are in microseconds from the server clock.
+var gcraScript = DefaultScripts.Register("gcra", `
+local burst = tonumber(ARGV[1])
+local interval = tonumber(ARGV[2])
+local quantity = tonumber(ARGV[3])
+local clock = redis.call('TIME')
+local now = tonumber(clock[1]) * 1000000 + tonumber(clock[2])
+
+local tolerance = interval * (burst + 1)
+local increment = interval * quantity
+local tat = tonumber(redis.call('GET', KEYS[1]))
+if tat == nil then
+	tat = now
+end
+
+local newTat = math.max(tat, now) + increment
+local diff = now - (newTat - tolerance)
+local limited, retry, ttl
+if diff < 0 then
+	limited = 1
+	retry = -1
+	if increment <= tolerance then
+		retry = -diff
+	end
+	ttl = math.max(0, tat - now)
+else
+	limited = 0
+	retry = -1
+	ttl = newTat - now
+	-- A quantity of 0 only checks the limit, leaving the state as it is
+	if increment > 0 then
+		redis.call('SET', KEYS[1], string.format('%d', newTat), 'PX', math.ceil(ttl / 1000))
+	end
+end
+
+local remaining = 0
+local next = tolerance - ttl
+if next > -interval then
+	remaining = math.floor(next / interval)
+end
+return {limited, burst + 1, remaining, math.ceil(retry), math.ceil(ttl)}
+`)
+
+// GCRA checks whether quantity requests at key fit in a limit of count
+// requests per period with bursts of up to maxBurst extra requests
+func (r *RedisClient) GCRA(ctx context.Context, key string, maxBurst, count int64, period time.Duration, quantity int64) (GCRAResult, error) {
+	interval := float64(period.Microseconds()) / float64(count)
+	result, err := gcraScript.Run(ctx, r.Client, []string{key}, maxBurst, interval, quantity).Int64Slice()
+	if err != nil {
+		return GCRAResult{}, err
+	}
+
+	retryAfter := time.Duration(-1)
+	if result[3] >= 0 {
+		retryAfter = time.Duration(result[3]) * time.Microsecond
+	}
+	return GCRAResult{
+		Allowed:    result[0] == 0,
+		Limit:      result[1],
+		Remaining:  result[2],
+		RetryAfter: retryAfter,
+		ResetAfter: time.Duration(result[4]) * time.Microsecond,
+	}, nil
+}
+
+// GCRA checks whether quantity requests at key fit in a limit of count
+// requests per period with bursts of up to maxBurst extra requests
+func (m *Memory) GCRA(ctx context.Context, key string, maxBurst, count int64, period time.Duration, quantity int64) (GCRAResult, error) {
+	var result GCRAResult
+	err := m.update(ctx, key, func(s *memoryShard, e *memoryEntry) error {
+		now := time.Now()
+		tat := now
+		if e != nil {
+			if e.kind != memoryString {
+				return ErrWrongType
+			}
+			var tatNano int64
+			if _, err := fmt.Sscanf(e.value, "%d", &tatNano); err != nil {
+				return ErrWrongType
+			}
+			tat = time.Unix(0, tatNano)
+		}
+
+		var newTat time.Time
+		result, newTat = gcra(tat, now, maxBurst, period/time.Duration(count), quantity)
+		if result.Allowed && quantity > 0 {
+			s.items[key] = &memoryEntry{
+				kind:      memoryString,
+				value:     fmt.Sprintf("%d", newTat.UnixNano()),
+				expiresAt: newTat,
+			}
+		}
+		return nil
+	})
+	return result, err
+}
+
+// gcra applies the generic cell rate algorithm to the theoretical arrival time
+// tat and returns the new one to store when the request is allowed
+func gcra(tat, now time.Time, maxBurst int64, interval time.Duration, quantity int64) (GCRAResult, time.Time) {
+	tolerance := interval * time.Duration(maxBurst+1)
+	increment := interval * time.Duration(quantity)
+
+	newTat := tat
+	if now.After(newTat) {
+		newTat = now
+	}
+	newTat = newTat.Add(increment)
+
+	result := GCRAResult{Limit: maxBurst + 1, RetryAfter: -1}
+	var ttl time.Duration
+	if diff := now.Sub(newTat.Add(-tolerance)); diff < 0 {
+		if increment <= tolerance {
+			result.RetryAfter = -diff
+		}
+		ttl = max(0, tat.Sub(now))
+	} else {
+		result.Allowed = true
+		ttl = newTat.Sub(now)
+	}
+
+	if next := tolerance - ttl; next > -interval {
+		result.Remaining = int64(next / interval)
+	}
+	result.ResetAfter = ttl
+	return result, newTat
+}
@@ -0,0 +1,240 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"math"
+	"strings"
+	"time"
+)
+
+// ErrListDirection is returned by LMove for a direction other than LEFT and
+// RIGHT
+var ErrListDirection = errors.New("list direction must be LEFT or RIGHT")
+
+// BLPop pops the first element of the first non-empty list of keys, waiting
+// up to timeout for one, forever when it is 0. It returns the key and the
+// element, redis.Nil if the wait timed out.
+func (r *RedisClient) BLPop(ctx context.Context, timeout time.Duration, keys ...string) ([]string, error) {
+	return r.Client.BLPop(ctx, timeout, keys...).Result()
+}
+
+// BRPop pops the last element of a list like BLPop
+func (r *RedisClient) BRPop(ctx context.Context, timeout time.Duration, keys ...string) ([]string, error) {
+	return r.Client.BRPop(ctx, timeout, keys...).Result()
+}
+
+// LMove pops an element from the from end, LEFT or RIGHT, of source and pushes
+// it to the to end of destination in one step. It returns the element,
+// redis.Nil if source is empty.
+func (r *RedisClient) LMove(ctx context.Context, source, destination, from, to string) (string, error) {
+	return r.Client.LMove(ctx, source, destination, from, to).Result()
+}
+
+// BLMove moves an element like LMove, waiting up to timeout for source to have
+// one, forever when it is 0
+func (r *RedisClient) BLMove(ctx context.Context, source, destination, from, to string, timeout time.Duration) (string, error) {
+	return r.Client.BLMove(ctx, source, destination, from, to, timeout).Result()
+}
+
+// LRem removes the first count occurrences of value from the list at key, the
+// last ones for a negative count and all of them for 0, and returns how many
+// were removed
+func (r *RedisClient) LRem(ctx context.Context, key string, count int64, value interface{}) (int64, error) {
+	return r.Client.LRem(ctx, key, count, value).Result()
+}
+
+// LLen returns the length of the list at key
+func (r *RedisClient) LLen(ctx context.Context, key string) (int64, error) {
+	return r.Client.LLen(ctx, key).Result()
+}
+
+// BLPop pops the first element of the first non-empty list of keys, waiting
+// up to timeout for one, forever when it is 0. It returns the key and the
+// element, redis.Nil if the wait timed out.
+func (m *Memory) BLPop(ctx context.Context, timeout time.Duration, keys ...string) ([]string, error) {
+	return m.blockingPop(ctx, timeout, keys, "LEFT")
+}
+
+// BRPop pops the last element of a list like BLPop
+func (m *Memory) BRPop(ctx context.Context, timeout time.Duration, keys ...string) ([]string, error) {
+	return m.blockingPop(ctx, timeout, keys, "RIGHT")
+}
+
+func (m *Memory) blockingPop(ctx context.Context, timeout time.Duration, keys []string, end string) ([]string, error) {
+	var popped []string
+	err := m.block(ctx, blockTimeout(timeout), func() (bool, error) {
+		for _, key := range keys {
+			element, err := m.popEnd(ctx, key, end)
+			if err == nil {
+				popped = []string{key, element}
+				return true, nil
+			}
+			if !errors.Is(err, redis.Nil) {
+				return false, err
+			}
+		}
+		return false, nil
+	})
+	if err == nil && popped == nil {
+		err = redis.Nil
+	}
+	return popped, err
+}
+
+// LMove pops an element from the from end, LEFT or RIGHT, of source and pushes
+// it to the to end of destination. It returns the element, redis.Nil if source
+// is empty. Unlike Redis, lists in different shards are not updated in one
+// step.
+func (m *Memory) LMove(ctx context.Context, source, destination, from, to string) (string, error) {
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+	if from != "LEFT" && from != "RIGHT" || to != "LEFT" && to != "RIGHT" {
+		return "", ErrListDirection
+	}
+	// Check destination first so a wrong type does not lose the element
+	if err := m.update(ctx, destination, func(s *memoryShard, e *memoryEntry) error {
+		if e != nil && e.kind != memoryList {
+			return ErrWrongType
+		}
+		return nil
+	}); err != nil {
+		return "", err
+	}
+
+	element, err := m.popEnd(ctx, source, from)
+	if err != nil {
+		return "", err
+	}
+	return element, m.update(ctx, destination, func(s *memoryShard, e *memoryEntry) error {
+		if e == nil {
+			e = &memoryEntry{kind: memoryList}
+			s.items[destination] = e
+		}
+		if e.kind != memoryList {
+			return ErrWrongType
+		}
+		if to == "LEFT" {
+			e.list = append([]string{element}, e.list...)
+		} else {
+			e.list = append(e.list[:len(e.list):len(e.list)], element)
+		}
+		return nil
+	})
+}
+
+// BLMove moves an element like LMove, waiting up to timeout for source to have
+// one, forever when it is 0
+func (m *Memory) BLMove(ctx context.Context, source, destination, from, to string, timeout time.Duration) (string, error) {
+	var element string
+	moved := false
+	err := m.block(ctx, blockTimeout(timeout), func() (bool, error) {
+		var err error
+		element, err = m.LMove(ctx, source, destination, from, to)
+		if errors.Is(err, redis.Nil) {
+			return false, nil
+		}
+		moved = err == nil
+		return moved, err
+	})
+	if err == nil && !moved {
+		err = redis.Nil
+	}
+	return element, err
+}
+
+// LRem removes the first count occurrences of value from the list at key, the
+// last ones for a negative count and all of them for 0, and returns how many
+// were removed
+func (m *Memory) LRem(ctx context.Context, key string, count int64, value interface{}) (int64, error) {
+	str, err := formatValue(value)
+	if err != nil {
+		return 0, err
+	}
+	var removed int64
+	err = m.update(ctx, key, func(s *memoryShard, e *memoryEntry) error {
+		removed = 0
+		if e == nil {
+			return nil
+		}
+		if e.kind != memoryList {
+			return ErrWrongType
+		}
+		limit := count
+		if limit < 0 {
+			limit = -limit
+		}
+		keep := make([]bool, len(e.list))
+		for n := range e.list {
+			i := n
+			if count < 0 {
+				i = len(e.list) - 1 - n
+			}
+			keep[i] = e.list[i] != str || limit > 0 && removed == limit
+			if !keep[i] {
+				removed++
+			}
+		}
+		list := make([]string, 0, len(e.list)-int(removed))
+		for i, element := range e.list {
+			if keep[i] {
+				list = append(list, element)
+			}
+		}
+		e.list = list
+		if len(e.list) == 0 {
+			delete(s.items, key)
+		}
+		return nil
+	})
+	return removed, err
+}
+
+// LLen returns the length of the list at key
+func (m *Memory) LLen(ctx context.Context, key string) (int64, error) {
+	var n int64
+	err := m.update(ctx, key, func(s *memoryShard, e *memoryEntry) error {
+		n = 0
+		if e == nil {
+			return nil
+		}
+		if e.kind != memoryList {
+			return ErrWrongType
+		}
+		n = int64(len(e.list))
+		return nil
+	})
+	return n, err
+}
+
+// popEnd pops the element at the LEFT or RIGHT end of the list at key
+func (m *Memory) popEnd(ctx context.Context, key, end string) (string, error) {
+	var element string
+	err := m.update(ctx, key, func(s *memoryShard, e *memoryEntry) error {
+		if e == nil {
+			return redis.Nil
+		}
+		if e.kind != memoryList {
+			return ErrWrongType
+		}
+		if end == "LEFT" {
+			element, e.list = e.list[0], e.list[1:]
+		} else {
+			element, e.list = e.list[len(e.list)-1], e.list[:len(e.list)-1]
+		}
+		if len(e.list) == 0 {
+			delete(s.items, key)
+		}
+		return nil
+	})
+	return element, err
+}
+
+// blockTimeout turns the 0 timeout of blocking Redis commands, which wait
+// forever, into one for block
+func blockTimeout(timeout time.Duration) time.Duration {
+	if timeout == 0 {
+		return math.MaxInt64
+	}
+	return timeout
+}
@@ -0,0 +1,36 @@
+package adapters
+
+// Logger receives log records made of a message and alternating key value
+// pairs. *slog.Logger implements it.
+type Logger interface {
+	Debug(msg string, args ...any)
+	Info(msg string, args ...any)
+	Warn(msg string, args ...any)
+	Error(msg string, args ...any)
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(msg string, args ...any) {}
+func (nopLogger) Info(msg string, args ...any)  {}
+func (nopLogger) Warn(msg string, args ...any)  {}
+func (nopLogger) Error(msg string, args ...any) {}
+
+// NopLogger discards every record
+func NopLogger() Logger {
+	return nopLogger{}
+}
+
+// loggerOf returns the logger configured on server, discarding records when
+// there is none
+func loggerOf(server CacheServer) Logger {
+	switch s := server.(type) {
+	case *RedisClient:
+		if s.Logger != nil {
+			return s.Logger
+		}
+	case *Prefixed:
+		return loggerOf(s.Server)
+	}
+	return nopLogger{}
+}
@@ -0,0 +1,117 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"github.com/bradfitz/gomemcache/memcache"
+	"github.com/redis/go-redis/v9"
+	"math"
+	"time"
+)
+
+// memcachedMaxRelative is the longest expiration memcached takes in seconds,
+// longer ones must be given as a unix time
+const memcachedMaxRelative = 30 * 24 * time.Hour
+
+// memcachedMaxKey is the longest key memcached accepts
+const memcachedMaxKey = 250
+
+// Memcached is a Cache over memcached servers. Memcached only stores plain
+// values, so operations that need a CacheServer, like tags and counters, are
+// not available on it. Keys are stored with Prefix in front, and through
+// SafeKey as memcached rejects long keys and keys with spaces.
+type Memcached struct {
+	Client *memcache.Client
+	Prefix string
+}
+
+// NewMemcached creates a Cache over client
+func NewMemcached(client *memcache.Client, prefix string) *Memcached {
+	return &Memcached{Client: client, Prefix: prefix}
+}
+
+func (m *Memcached) Get(ctx context.Context, key string) (interface{}, error) {
+	item, err := m.Client.Get(m.key(key))
+	if errors.Is(err, memcache.ErrCacheMiss) {
+		return nil, redis.Nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return string(item.Value), nil
+}
+
+// Set stores value at key. Memcached cannot keep the expiration of an existing
+// key, so redis.KeepTTL stores the key without one.
+func (m *Memcached) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	str, err := formatValue(value)
+	if err != nil {
+		return err
+	}
+	return m.Client.Set(&memcache.Item{
+		Key:        m.key(key),
+		Value:      []byte(str),
+		Expiration: memcachedExpiration(expiration),
+	})
+}
+
+func (m *Memcached) Delete(ctx context.Context, keys ...string) error {
+	for _, key := range keys {
+		if err := m.Client.Delete(m.key(key)); err != nil && !errors.Is(err, memcache.ErrCacheMiss) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *Memcached) GetMany(ctx context.Context, keys ...string) (map[string]interface{}, error) {
+	names := make([]string, len(keys))
+	for i, key := range keys {
+		names[i] = m.key(key)
+	}
+	items, err := m.Client.GetMulti(names)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]interface{}, len(items))
+	for i, name := range names {
+		if item, ok := items[name]; ok {
+			values[keys[i]] = string(item.Value)
+		}
+	}
+	return values, nil
+}
+
+func (m *Memcached) SetMany(ctx context.Context, values map[string]interface{}, expiration time.Duration) error {
+	for key, value := range values {
+		if err := m.Set(ctx, key, value, expiration); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *Memcached) key(key string) string {
+	return SafeKey(m.Prefix+key, memcachedMaxKey)
+}
+
+func (m *Memcached) Close() error {
+	return m.Client.Close()
+}
+
+// Ping asks every server for its version
+func (m *Memcached) Ping(ctx context.Context) error {
+	return m.Client.Ping()
+}
+
+// memcachedExpiration converts expiration to the seconds memcached expects,
+// rounding up so keys never expire early
+func memcachedExpiration(expiration time.Duration) int32 {
+	switch {
+	case expiration <= 0:
+		return 0
+	case expiration > memcachedMaxRelative:
+		return int32(time.Now().Add(expiration).Unix())
+	}
+	return int32(math.Ceil(expiration.Seconds()))
+}
@@ -0,0 +1,748 @@
+package adapters
+
+import (
+	"container/list"
+	"context"
+	"encoding"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/sync/singleflight"
+	"hash/fnv"
+	"hash/maphash"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const memoryShardCount = 32
+
+// memoryBlockPoll is how often a blocked read of the in-memory server checks
+// for new data
+const memoryBlockPoll = 10 * time.Millisecond
+
+var (
+	ErrNotInteger = errors.New("value is not an integer or out of range")
+	ErrNotFloat   = errors.New("value is not a valid float")
+	ErrWrongType  = errors.New("operation against a key holding the wrong kind of value")
+)
+
+type memoryKind uint8
+
+const (
+	memoryString memoryKind = iota
+	memoryList
+	memorySet
+	memoryZSet
+	memoryHash
+	memoryStream
+)
+
+type memoryEntry struct {
+	kind      memoryKind
+	value     string
+	list      []string
+	set       map[string]struct{}
+	zset      map[string]float64
+	hash      map[string]string
+	stream    *streamLog
+	expiresAt time.Time
+}
+
+func (e *memoryEntry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && !now.Before(e.expiresAt)
+}
+
+type memoryShard struct {
+	items map[string]*memoryEntry
+	mutex sync.Mutex
+
+	// recency orders the keys of a bounded memory from the most to the least
+	// recently used, see WithMaxEntries and WithMaxBytes. With TinyLFU new
+	// keys wait in window before they compete for a place in recency.
+	recency     *list.List
+	window      *list.List
+	uses        map[string]*list.Element
+	bytes       int64
+	windowBytes int64
+	sketch      *frequencySketch
+}
+
+// Memory is an in-process CacheServer backed by a sharded map. It mirrors the
+// semantics of the Redis adapter so it can be swapped in where Redis is not
+// available, e.g. in unit tests. Created with NewStoreServer it keeps its
+// entries in a persistent Store instead.
+type Memory struct {
+	shards          [memoryShardCount]*memoryShard
+	store           Store
+	cleanupInterval time.Duration
+	maxEntries      int
+	maxBytes        int64
+	onEvict         func(key string)
+	policy          EvictionPolicy
+	seed            maphash.Seed
+	evictions       atomic.Uint64
+	evictedBytes    atomic.Uint64
+	stop            chan struct{}
+	closeOnce       sync.Once
+	loads           singleflight.Group
+
+	subscriptionMutex sync.RWMutex
+	subscriptions     map[*memorySubscription]struct{}
+}
+
+var _ CacheServer = (*Memory)(nil)
+
+type MemoryOption func(*Memory)
+
+// WithCleanupInterval starts a janitor that removes expired entries every
+// interval. Without it expired entries are only removed when accessed.
+func WithCleanupInterval(interval time.Duration) MemoryOption {
+	return func(m *Memory) {
+		m.cleanupInterval = interval
+	}
+}
+
+// WithMaxEntries bounds the number of keys to about max. Adding a key to a
+// full memory evicts the least recently used ones. It does not apply to
+// NewStoreServer.
+func WithMaxEntries(max int) MemoryOption {
+	return func(m *Memory) {
+		m.maxEntries = max
+	}
+}
+
+// WithMaxBytes bounds the size of the keys and their encoded values to about
+// max bytes, evicting the least recently used keys once it is exceeded. Sizes
+// are approximate: they count lengths, not the overhead of the maps holding
+// them. It does not apply to NewStoreServer.
+func WithMaxBytes(max int64) MemoryOption {
+	return func(m *Memory) {
+		m.maxBytes = max
+	}
+}
+
+// WithEvictionPolicy chooses the keys evicted to respect WithMaxEntries and
+// WithMaxBytes, LRU by default
+func WithEvictionPolicy(policy EvictionPolicy) MemoryOption {
+	return func(m *Memory) {
+		m.policy = policy
+	}
+}
+
+// WithOnEvict calls fn with every key evicted to respect WithMaxEntries or
+// WithMaxBytes, not for keys that expired or were deleted. fn runs with part
+// of the memory locked and must not use it.
+func WithOnEvict(fn func(key string)) MemoryOption {
+	return func(m *Memory) {
+		m.onEvict = fn
+	}
+}
+
+// NewMemory creates an empty in-memory cache server
+func NewMemory(opts ...MemoryOption) *Memory {
+	m := &Memory{stop: make(chan struct{})}
+	for i := range m.shards {
+		m.shards[i] = &memoryShard{items: make(map[string]*memoryEntry)}
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	if m.bounded() {
+		m.seed = maphash.MakeSeed()
+		for _, shard := range m.shards {
+			m.resetUses(shard)
+		}
+	}
+
+	if m.cleanupInterval > 0 {
+		go m.janitor()
+	}
+	return m
+}
+
+// Close stops the janitor goroutine, if any, and closes the store
+func (m *Memory) Close() error {
+	var err error
+	m.closeOnce.Do(func() {
+		close(m.stop)
+		if m.store != nil {
+			err = m.store.Close()
+		}
+	})
+	return err
+}
+
+// Flush deletes every key and returns how many there were. The maps of the
+// shards are replaced rather than emptied key by key; a memory over a Store
+// deletes its entries one SCAN page at a time.
+func (m *Memory) Flush(ctx context.Context) (int64, error) {
+	if m.store != nil {
+		return DeleteMatching(ctx, m, "*")
+	}
+	var deleted int64
+	for _, shard := range m.shards {
+		shard.mutex.Lock()
+		deleted += int64(len(shard.items))
+		shard.items = make(map[string]*memoryEntry)
+		if m.bounded() {
+			m.resetUses(shard)
+		}
+		shard.mutex.Unlock()
+	}
+	return deleted, nil
+}
+
+func (m *Memory) janitor() {
+	ticker := time.NewTicker(m.cleanupInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			m.deleteExpired()
+		case <-m.stop:
+			return
+		}
+	}
+}
+
+func (m *Memory) deleteExpired() {
+	now := time.Now()
+	if m.store != nil {
+		m.deleteExpiredFromStore(now)
+		return
+	}
+	for _, shard := range m.shards {
+		shard.mutex.Lock()
+		for key, e := range shard.items {
+			if e.expired(now) {
+				delete(shard.items, key)
+				m.used(shard, key)
+				m.emit(KeyExpired, key)
+			}
+		}
+		shard.mutex.Unlock()
+	}
+}
+
+func (m *Memory) shard(key string) *memoryShard {
+	return m.shards[m.shardIndex(key)]
+}
+
+func (m *Memory) shardIndex(key string) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	return int(h.Sum32() % memoryShardCount)
+}
+
+// update runs fn with the shard of key locked, unless a transaction running
+// in ctx holds it already. The entry passed to fn is nil when the key does not
+// exist or has expired. Stores may run fn more than once when transactions
+// conflict, so it must not accumulate into variables declared outside of it.
+func (m *Memory) update(ctx context.Context, key string, fn func(s *memoryShard, e *memoryEntry) error) error {
+	s := m.shard(key)
+	if held, _ := ctx.Value(memoryHeldKey{}).(map[*memoryShard]bool); !held[s] {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+	}
+	if m.store != nil {
+		return m.updateStore(ctx, key, fn)
+	}
+
+	e, exists := s.items[key]
+	if exists && e.expired(time.Now()) {
+		delete(s.items, key)
+		m.emit(KeyExpired, key)
+		e = nil
+	}
+	err := fn(s, e)
+	if m.bounded() {
+		m.used(s, key)
+	}
+	return err
+}
+
+// block runs try until it reports done, or once when timeout is not positive,
+// polling every memoryBlockPoll for at most timeout
+func (m *Memory) block(ctx context.Context, timeout time.Duration, try func() (bool, error)) error {
+	done, err := try()
+	if done || err != nil || timeout <= 0 {
+		return err
+	}
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	poll := time.NewTicker(memoryBlockPoll)
+	defer poll.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-deadline.C:
+			return nil
+		case <-poll.C:
+			if done, err := try(); done || err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Incr increments the value of a key
+func (m *Memory) Incr(ctx context.Context, key string) (int64, error) {
+	return m.incrBy(ctx, key, 1, 0)
+}
+
+// Decr decrements the value of a key
+func (m *Memory) Decr(ctx context.Context, key string) (int64, error) {
+	return m.incrBy(ctx, key, -1, 0)
+}
+
+// DecrBy decrements the value of a key by a specified decrement
+func (m *Memory) DecrBy(ctx context.Context, key string, decrement int64) (int64, error) {
+	return m.incrBy(ctx, key, -decrement, 0)
+}
+
+// incrBy adds delta to the integer at key. A key created by the call expires
+// after expiration, if positive.
+func (m *Memory) incrBy(ctx context.Context, key string, delta int64, expiration time.Duration) (int64, error) {
+	if counters, ok := m.store.(CounterStore); ok {
+		var expiresAt time.Time
+		if expiration > 0 {
+			expiresAt = time.Now().Add(expiration)
+		}
+		result, err := counters.Add(ctx, key, delta, expiresAt)
+		if !errors.Is(err, ErrNotCounter) {
+			return result, err
+		}
+	}
+	var result int64
+	err := m.update(ctx, key, func(s *memoryShard, e *memoryEntry) error {
+		if e == nil {
+			e = &memoryEntry{kind: memoryString, value: "0"}
+			if expiration > 0 {
+				e.expiresAt = time.Now().Add(expiration)
+			}
+			s.items[key] = e
+		}
+		if e.kind != memoryString {
+			return ErrWrongType
+		}
+		current, err := strconv.ParseInt(e.value, 10, 64)
+		if err != nil {
+			return ErrNotInteger
+		}
+		result = current + delta
+		e.value = strconv.FormatInt(result, 10)
+		return nil
+	})
+	return result, err
+}
+
+// Set sets a value for a given key with an expiration time. Like Redis, a zero
+// expiration means the key never expires and redis.KeepTTL retains the
+// current expiration.
+func (m *Memory) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	str, err := formatValue(value)
+	if err != nil {
+		return err
+	}
+	return m.update(ctx, key, func(s *memoryShard, e *memoryEntry) error {
+		next := &memoryEntry{kind: memoryString, value: str}
+		if expiration == redis.KeepTTL && e != nil {
+			next.expiresAt = e.expiresAt
+		} else if expiration > 0 {
+			next.expiresAt = time.Now().Add(expiration)
+		}
+		s.items[key] = next
+		return nil
+	})
+}
+
+// Remember returns the value stored at key, or computes it with value and stores
+// it with the given expiration on a miss
+func (m *Memory) Remember(ctx context.Context, key string, expiration time.Duration, value func() interface{}) (interface{}, error) {
+	return remember(ctx, m, &m.loads, key, expiration, value)
+}
+
+// Get retrieves the value for a given key, returning redis.Nil when it is missing
+func (m *Memory) Get(ctx context.Context, key string) (string, error) {
+	var result string
+	err := m.update(ctx, key, func(s *memoryShard, e *memoryEntry) error {
+		if e == nil {
+			return redis.Nil
+		}
+		if e.kind != memoryString {
+			return ErrWrongType
+		}
+		result = e.value
+		return nil
+	})
+	return result, err
+}
+
+// Pop pops a value from the head of a list
+func (m *Memory) Pop(ctx context.Context, key string) (string, error) {
+	var result string
+	err := m.update(ctx, key, func(s *memoryShard, e *memoryEntry) error {
+		if e == nil {
+			return redis.Nil
+		}
+		if e.kind != memoryList {
+			return ErrWrongType
+		}
+		result = e.list[0]
+		e.list = e.list[1:]
+		if len(e.list) == 0 {
+			delete(s.items, key)
+		}
+		return nil
+	})
+	return result, err
+}
+
+// Push pushes values to the head of a list, in the same order as LPUSH
+func (m *Memory) Push(ctx context.Context, key string, values ...interface{}) error {
+	formatted := make([]string, len(values))
+	for i, value := range values {
+		str, err := formatValue(value)
+		if err != nil {
+			return err
+		}
+		formatted[len(values)-1-i] = str
+	}
+	return m.update(ctx, key, func(s *memoryShard, e *memoryEntry) error {
+		if e == nil {
+			e = &memoryEntry{kind: memoryList}
+			s.items[key] = e
+		}
+		if e.kind != memoryList {
+			return ErrWrongType
+		}
+		e.list = append(formatted, e.list...)
+		return nil
+	})
+}
+
+// List retrieves all the elements of a list
+func (m *Memory) List(ctx context.Context, key string) ([]string, error) {
+	var result []string
+	err := m.update(ctx, key, func(s *memoryShard, e *memoryEntry) error {
+		result = []string{}
+		if e == nil {
+			return nil
+		}
+		if e.kind != memoryList {
+			return ErrWrongType
+		}
+		result = append(result, e.list...)
+		return nil
+	})
+	return result, err
+}
+
+// SetNX sets a value to a key only if the key does not exist
+func (m *Memory) SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) (bool, error) {
+	str, err := formatValue(value)
+	if err != nil {
+		return false, err
+	}
+	set := false
+	err = m.update(ctx, key, func(s *memoryShard, e *memoryEntry) error {
+		set = false
+		if e != nil {
+			return nil
+		}
+		next := &memoryEntry{kind: memoryString, value: str}
+		if expiration > 0 {
+			next.expiresAt = time.Now().Add(expiration)
+		}
+		s.items[key] = next
+		set = true
+		return nil
+	})
+	return set, err
+}
+
+// Expire sets an expiration time for a given key. A non-positive expiration
+// deletes the key, as it does in Redis.
+func (m *Memory) Expire(ctx context.Context, key string, expiration time.Duration) (bool, error) {
+	found := false
+	err := m.update(ctx, key, func(s *memoryShard, e *memoryEntry) error {
+		found = false
+		if e == nil {
+			return nil
+		}
+		found = true
+		if expiration <= 0 {
+			delete(s.items, key)
+			return nil
+		}
+		e.expiresAt = time.Now().Add(expiration)
+		return nil
+	})
+	return found, err
+}
+
+// Persist removes the expiration of a key, reporting whether it had one
+func (m *Memory) Persist(ctx context.Context, key string) (bool, error) {
+	persisted := false
+	err := m.update(ctx, key, func(s *memoryShard, e *memoryEntry) error {
+		persisted = e != nil && !e.expiresAt.IsZero()
+		if e != nil {
+			e.expiresAt = time.Time{}
+		}
+		return nil
+	})
+	return persisted, err
+}
+
+// ExpireXX sets the expiration of a key only if it already has one
+func (m *Memory) ExpireXX(ctx context.Context, key string, expiration time.Duration) (bool, error) {
+	updated := false
+	err := m.update(ctx, key, func(s *memoryShard, e *memoryEntry) error {
+		updated = e != nil && !e.expiresAt.IsZero()
+		if !updated {
+			return nil
+		}
+		if expiration <= 0 {
+			delete(s.items, key)
+			return nil
+		}
+		e.expiresAt = time.Now().Add(expiration)
+		return nil
+	})
+	return updated, err
+}
+
+// Delete removes the given keys and returns how many existed
+func (m *Memory) Delete(ctx context.Context, keys ...string) (int64, error) {
+	var deleted int64
+	for _, key := range keys {
+		existed := false
+		err := m.update(ctx, key, func(s *memoryShard, e *memoryEntry) error {
+			existed = e != nil
+			delete(s.items, key)
+			return nil
+		})
+		if err != nil {
+			return deleted, err
+		}
+		if existed {
+			m.emit(KeyDeleted, key)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// SAdd adds members to the set stored at key
+func (m *Memory) SAdd(ctx context.Context, key string, members ...interface{}) (int64, error) {
+	formatted := make([]string, len(members))
+	for i, member := range members {
+		str, err := formatValue(member)
+		if err != nil {
+			return 0, err
+		}
+		formatted[i] = str
+	}
+
+	var added int64
+	err := m.update(ctx, key, func(s *memoryShard, e *memoryEntry) error {
+		added = 0
+		if e == nil {
+			e = &memoryEntry{kind: memorySet, set: make(map[string]struct{})}
+			s.items[key] = e
+		}
+		if e.kind != memorySet {
+			return ErrWrongType
+		}
+		for _, member := range formatted {
+			if _, exists := e.set[member]; !exists {
+				e.set[member] = struct{}{}
+				added++
+			}
+		}
+		return nil
+	})
+	return added, err
+}
+
+// SMembers returns all members of the set stored at key
+func (m *Memory) SMembers(ctx context.Context, key string) ([]string, error) {
+	var result []string
+	err := m.update(ctx, key, func(s *memoryShard, e *memoryEntry) error {
+		result = []string{}
+		if e == nil {
+			return nil
+		}
+		if e.kind != memorySet {
+			return ErrWrongType
+		}
+		for member := range e.set {
+			result = append(result, member)
+		}
+		return nil
+	})
+	return result, err
+}
+
+// Scan iterates over the keys matching a glob pattern, one shard per call. The
+// cursor is the index of the next shard and count is ignored. Stores are
+// scanned in a single call.
+func (m *Memory) Scan(ctx context.Context, cursor uint64, match string, count int64) ([]string, uint64, error) {
+	if m.store != nil {
+		return m.scanStore(ctx, match)
+	}
+	if cursor >= memoryShardCount {
+		return []string{}, 0, nil
+	}
+
+	now := time.Now()
+	keys := []string{}
+	shard := m.shards[cursor]
+	shard.mutex.Lock()
+	for key, e := range shard.items {
+		if !e.expired(now) && (match == "" || MatchPattern(match, key)) {
+			keys = append(keys, key)
+		}
+	}
+	shard.mutex.Unlock()
+
+	next := cursor + 1
+	if next == memoryShardCount {
+		next = 0
+	}
+	return keys, next, nil
+}
+
+// MGet retrieves the values of several keys. Missing keys and keys that do not
+// hold a string have a nil value at their position.
+func (m *Memory) MGet(ctx context.Context, keys ...string) ([]interface{}, error) {
+	values := make([]interface{}, len(keys))
+	for i, key := range keys {
+		if value, err := m.Get(ctx, key); err == nil {
+			values[i] = value
+		}
+	}
+	return values, nil
+}
+
+// MSet sets several keys with a shared expiration
+func (m *Memory) MSet(ctx context.Context, values map[string]interface{}, expiration time.Duration) error {
+	if batch, ok := m.store.(BatchStore); ok && expiration != redis.KeepTTL {
+		keys := make([]string, 0, len(values))
+		for key := range values {
+			keys = append(keys, key)
+		}
+		ctx, unlock := m.lockShards(ctx, keys)
+		defer unlock()
+		return m.putMany(ctx, batch, values, expiration)
+	}
+	for key, value := range values {
+		if err := m.Set(ctx, key, value, expiration); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RateLimiter limits the rate of a specific action by decrementing a counter
+func (m *Memory) RateLimiter(ctx context.Context, key string, value int, expiration time.Duration) (int64, error) {
+	if _, err := m.SetNX(ctx, key, value, expiration); err != nil {
+		return 0, err
+	}
+	return m.Decr(ctx, key)
+}
+
+// CountRateLimiter decrements a counter and ensures it does not go below 0
+func (m *Memory) CountRateLimiter(ctx context.Context, key string, value int, decrement int, expiration time.Duration) (int64, error) {
+	var newValue int64
+	err := m.update(ctx, key, func(s *memoryShard, e *memoryEntry) error {
+		// Initialize the key if not exists
+		if e == nil {
+			e = &memoryEntry{kind: memoryString, value: strconv.Itoa(value)}
+			if expiration > 0 {
+				e.expiresAt = time.Now().Add(expiration)
+			}
+			s.items[key] = e
+		}
+		if e.kind != memoryString {
+			return ErrWrongType
+		}
+
+		currentVal, err := strconv.ParseInt(e.value, 10, 64)
+		if err != nil {
+			return ErrNotInteger
+		}
+
+		newValue = currentVal - int64(decrement)
+		if newValue < 0 {
+			return nil
+		}
+		e.value = strconv.FormatInt(newValue, 10)
+		return nil
+	})
+	return newValue, err
+}
+
+// FormatValue converts a value to the string every backend stores for it
+func FormatValue(value interface{}) (string, error) {
+	return formatValue(value)
+}
+
+// formatValue converts a value to the string Redis would store for it
+func formatValue(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	case int:
+		return strconv.FormatInt(int64(v), 10), nil
+	case int8:
+		return strconv.FormatInt(int64(v), 10), nil
+	case int16:
+		return strconv.FormatInt(int64(v), 10), nil
+	case int32:
+		return strconv.FormatInt(int64(v), 10), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case uint:
+		return strconv.FormatUint(uint64(v), 10), nil
+	case uint8:
+		return strconv.FormatUint(uint64(v), 10), nil
+	case uint16:
+		return strconv.FormatUint(uint64(v), 10), nil
+	case uint32:
+		return strconv.FormatUint(uint64(v), 10), nil
+	case uint64:
+		return strconv.FormatUint(v, 10), nil
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case bool:
+		if v {
+			return "1", nil
+		}
+		return "0", nil
+	case time.Time:
+		return v.Format(time.RFC3339Nano), nil
+	case time.Duration:
+		return strconv.FormatInt(v.Nanoseconds(), 10), nil
+	case net.IP:
+		return string(v), nil
+	case encoding.BinaryMarshaler:
+		b, err := v.MarshalBinary()
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	default:
+		return "", fmt.Errorf("can't marshal %T (implement encoding.BinaryMarshaler)", value)
+	}
+}
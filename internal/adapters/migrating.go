@@ -0,0 +1,205 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"math/rand/v2"
+	"sync/atomic"
+	"time"
+)
+
+// Migrating is a Cache moving a cache from Old to New without downtime, e.g.
+// from Memcached to Redis. Reads are served by Old, the source of truth, and
+// writes go to both, so New fills up with everything written while both run.
+// A CompareRate fraction of reads is repeated on New and compared to what Old
+// returned, and entries New lacks are copied to it with BackfillTTL, if set.
+//
+// Once New holds everything, e.g. after the entries written before the
+// migration expired, Cutover makes New serve the reads. Writes still go to
+// both, so Rollback can switch back until Old is dropped. Everything using the
+// backend server directly, such as counters, locks and pipelines, only uses
+// New.
+type Migrating struct {
+	Old         Cache
+	New         Cache
+	CompareRate float64
+	BackfillTTL time.Duration
+
+	cutover     atomic.Bool
+	compared    uint64
+	matches     uint64
+	missing     uint64
+	mismatches  uint64
+	backfilled  uint64
+	otherErrors uint64
+}
+
+// NewMigrating reads from old and writes to old and new, comparing a
+// compareRate fraction of the reads between them
+func NewMigrating(old, new Cache, compareRate float64) *Migrating {
+	return &Migrating{Old: old, New: new, CompareRate: compareRate}
+}
+
+// Cutover serves reads from New
+func (m *Migrating) Cutover() {
+	m.cutover.Store(true)
+}
+
+// Rollback serves reads from Old again
+func (m *Migrating) Rollback() {
+	m.cutover.Store(false)
+}
+
+// ReadsNew reports whether New serves the reads
+func (m *Migrating) ReadsNew() bool {
+	return m.cutover.Load()
+}
+
+// source returns the backend serving reads and the one they are compared to
+func (m *Migrating) source() (Cache, Cache) {
+	if m.cutover.Load() {
+		return m.New, m.Old
+	}
+	return m.Old, m.New
+}
+
+func (m *Migrating) Get(ctx context.Context, key string) (interface{}, error) {
+	source, other := m.source()
+	value, err := source.Get(ctx, key)
+	if err == nil && m.sampled() {
+		m.compare(ctx, other, map[string]interface{}{key: value})
+	}
+	return value, err
+}
+
+func (m *Migrating) GetMany(ctx context.Context, keys ...string) (map[string]interface{}, error) {
+	source, other := m.source()
+	values, err := source.GetMany(ctx, keys...)
+	if err == nil && len(values) > 0 && m.sampled() {
+		m.compare(ctx, other, values)
+	}
+	return values, err
+}
+
+func (m *Migrating) sampled() bool {
+	return m.CompareRate > 0 && rand.Float64() < m.CompareRate
+}
+
+// compare counts how many of values other has too, and copies the missing
+// ones to New while Old serves the reads
+func (m *Migrating) compare(ctx context.Context, other Cache, values map[string]interface{}) {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	found, err := other.GetMany(ctx, keys...)
+	if err != nil && !errors.Is(err, redis.Nil) {
+		atomic.AddUint64(&m.otherErrors, 1)
+		return
+	}
+
+	missing := make(map[string]interface{})
+	for key, value := range values {
+		atomic.AddUint64(&m.compared, 1)
+		switch otherValue, ok := found[key]; {
+		case !ok:
+			atomic.AddUint64(&m.missing, 1)
+			missing[key] = value
+		case sameValue(value, otherValue):
+			atomic.AddUint64(&m.matches, 1)
+		default:
+			atomic.AddUint64(&m.mismatches, 1)
+		}
+	}
+	if len(missing) == 0 || m.BackfillTTL <= 0 || other != m.New {
+		return
+	}
+	if err := m.New.SetMany(ctx, missing, m.BackfillTTL); err != nil {
+		atomic.AddUint64(&m.otherErrors, 1)
+		return
+	}
+	atomic.AddUint64(&m.backfilled, uint64(len(missing)))
+}
+
+// sameValue compares values read from different adapters, which return
+// strings or bytes for the same stored value
+func sameValue(a, b interface{}) bool {
+	if bytes, ok := a.([]byte); ok {
+		a = string(bytes)
+	}
+	if bytes, ok := b.([]byte); ok {
+		b = string(bytes)
+	}
+	return a == b
+}
+
+// Set writes to the backend serving reads and then to the other one. Only
+// errors of the first are returned, those of the other are counted.
+func (m *Migrating) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	return m.write(func(c Cache) error {
+		return c.Set(ctx, key, value, expiration)
+	})
+}
+
+func (m *Migrating) SetMany(ctx context.Context, values map[string]interface{}, expiration time.Duration) error {
+	return m.write(func(c Cache) error {
+		return c.SetMany(ctx, values, expiration)
+	})
+}
+
+func (m *Migrating) Delete(ctx context.Context, keys ...string) error {
+	return m.write(func(c Cache) error {
+		return c.Delete(ctx, keys...)
+	})
+}
+
+func (m *Migrating) write(fn func(c Cache) error) error {
+	source, other := m.source()
+	if err := fn(source); err != nil {
+		return err
+	}
+	if err := fn(other); err != nil {
+		atomic.AddUint64(&m.otherErrors, 1)
+	}
+	return nil
+}
+
+// MigrationStatistics returns how the compared reads went: "compared" reads,
+// "matches", entries "missing" from or "mismatches" with the other backend,
+// entries "backfilled" to New, failed "other_errors" writes and compares, and
+// "cutover" 1 once New serves the reads
+func (m *Migrating) MigrationStatistics() map[string]uint64 {
+	cutover := uint64(0)
+	if m.cutover.Load() {
+		cutover = 1
+	}
+	return map[string]uint64{
+		"compared":     atomic.LoadUint64(&m.compared),
+		"matches":      atomic.LoadUint64(&m.matches),
+		"missing":      atomic.LoadUint64(&m.missing),
+		"mismatches":   atomic.LoadUint64(&m.mismatches),
+		"backfilled":   atomic.LoadUint64(&m.backfilled),
+		"other_errors": atomic.LoadUint64(&m.otherErrors),
+		"cutover":      cutover,
+	}
+}
+
+// FindMigrating returns the Migrating adapter in c, if any
+func FindMigrating(c Cache) (*Migrating, bool) {
+	switch driver := c.(type) {
+	case *Migrating:
+		return driver, true
+	case *Tiered:
+		return FindMigrating(driver.Remote)
+	case *Compressing:
+		return FindMigrating(driver.Cache)
+	case *Encrypting:
+		return FindMigrating(driver.Cache)
+	}
+	return nil, false
+}
+
+func (m *Migrating) Close() error {
+	return errors.Join(m.Old.Close(), m.New.Close())
+}
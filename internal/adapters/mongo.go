@@ -0,0 +1,143 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+	"time"
+)
+
+// mongoEntry is the document a MongoStore keeps per key
+type mongoEntry struct {
+	Key       string     `bson:"_id"`
+	Value     []byte     `bson:"value"`
+	Version   int64      `bson:"version"`
+	ExpiresAt *time.Time `bson:"expires_at,omitempty"`
+}
+
+// errMongoConflict means the document changed since it was read
+var errMongoConflict = errors.New("document updated concurrently")
+
+// MongoStore is a Store over a MongoDB collection with a document per key.
+// Every document carries a version, writes are conditional on it and insert
+// the key when it is missing, so SetNX and counters stay atomic. A TTL index
+// on expires_at has MongoDB delete expired documents, about once a minute.
+type MongoStore struct {
+	Collection *mongo.Collection
+}
+
+// NewMongoStore creates a Store over collection, creating its TTL index if
+// needed
+func NewMongoStore(ctx context.Context, collection *mongo.Collection) (*MongoStore, error) {
+	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &MongoStore{Collection: collection}, nil
+}
+
+// OpenMongo returns a CacheServer keeping its entries in collection. The TTL
+// index expires the documents, so no janitor runs.
+func OpenMongo(ctx context.Context, collection *mongo.Collection, opts ...MemoryOption) (*Memory, error) {
+	store, err := NewMongoStore(ctx, collection)
+	if err != nil {
+		return nil, err
+	}
+	opts = append([]MemoryOption{WithCleanupInterval(0)}, opts...)
+	return NewStoreServer(store, opts...), nil
+}
+
+func (m *MongoStore) Name() string {
+	return "mongodb"
+}
+
+// Update runs fn again after a backoff whenever the document of key changed
+// in the meantime, up to storeConflicts.MaxAttempts times before failing with
+// ErrStoreConflict
+func (m *MongoStore) Update(ctx context.Context, key string, fn func(current []byte) ([]byte, bool)) error {
+	return retryConflicts(ctx, func() error {
+		return m.updateOnce(ctx, key, fn)
+	}, func(err error) bool {
+		return errors.Is(err, errMongoConflict)
+	})
+}
+
+func (m *MongoStore) updateOnce(ctx context.Context, key string, fn func(current []byte) ([]byte, bool)) error {
+	var doc mongoEntry
+	err := m.Collection.FindOne(ctx, bson.D{{Key: "_id", Value: key}}).Decode(&doc)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return err
+	}
+	var current []byte
+	if err == nil {
+		current = doc.Value
+	}
+
+	next, write := fn(current)
+	if !write {
+		return nil
+	}
+	// A missing document has version 0, which no stored one has
+	filter := bson.D{{Key: "_id", Value: key}, {Key: "version", Value: doc.Version}}
+
+	if next == nil {
+		result, err := m.Collection.DeleteOne(ctx, filter)
+		if err != nil {
+			return err
+		}
+		if result.DeletedCount == 0 {
+			return errMongoConflict
+		}
+		return nil
+	}
+
+	update := bson.D{
+		{Key: "$set", Value: bson.D{{Key: "value", Value: next}}},
+		{Key: "$inc", Value: bson.D{{Key: "version", Value: 1}}},
+	}
+	if expiresAt := entryExpiresAt(next); expiresAt.IsZero() {
+		update = append(update, bson.E{Key: "$unset", Value: bson.D{{Key: "expires_at", Value: ""}}})
+	} else {
+		update[0].Value = append(update[0].Value.(bson.D), bson.E{Key: "expires_at", Value: expiresAt})
+	}
+
+	// The upsert inserts a missing key. When the version does not match it
+	// tries to insert the key again instead, which fails as a duplicate.
+	err = m.Collection.FindOneAndUpdate(ctx, filter, update, options.FindOneAndUpdate().SetUpsert(true)).Err()
+	switch {
+	case mongo.IsDuplicateKeyError(err):
+		return errMongoConflict
+	case errors.Is(err, mongo.ErrNoDocuments):
+		// Returned for the document the upsert inserted
+		return nil
+	}
+	return err
+}
+
+func (m *MongoStore) Range(ctx context.Context, fn func(key string, data []byte) bool) error {
+	cursor, err := m.Collection.Find(ctx, bson.D{}, options.Find().SetProjection(bson.D{{Key: "value", Value: 1}}))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+	for cursor.Next(ctx) {
+		var doc mongoEntry
+		if err := cursor.Decode(&doc); err != nil {
+			return err
+		}
+		if !fn(doc.Key, doc.Value) {
+			return nil
+		}
+	}
+	return cursor.Err()
+}
+
+// Close does nothing, the client is owned by the caller
+func (m *MongoStore) Close() error {
+	return nil
+}
@@ -0,0 +1,353 @@
+package adapters
+
+import (
+	"context"
+	"github.com/redis/go-redis/v9"
+	"io"
+	"time"
+)
+
+// Null is a CacheServer that stores nothing. Every call behaves as if it were
+// the first on an empty server that forgets writes at once: reads miss, writes
+// succeed, counters start from zero and limiters allow every request.
+type Null struct{}
+
+// NewNull creates a CacheServer that disables caching
+func NewNull() *Null {
+	return &Null{}
+}
+
+func (n *Null) Incr(ctx context.Context, key string) (int64, error) {
+	return 1, nil
+}
+
+func (n *Null) Decr(ctx context.Context, key string) (int64, error) {
+	return -1, nil
+}
+
+func (n *Null) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	return nil
+}
+
+// Remember always computes the value
+func (n *Null) Remember(ctx context.Context, key string, expiration time.Duration, value func() interface{}) (interface{}, error) {
+	return value(), nil
+}
+
+func (n *Null) Get(ctx context.Context, key string) (string, error) {
+	return "", redis.Nil
+}
+
+func (n *Null) GetDel(ctx context.Context, key string) (string, error) {
+	return "", redis.Nil
+}
+
+func (n *Null) GetSet(ctx context.Context, key string, value interface{}, expiration time.Duration) (string, error) {
+	return "", redis.Nil
+}
+
+func (n *Null) Pop(ctx context.Context, key string) (string, error) {
+	return "", redis.Nil
+}
+
+func (n *Null) Push(ctx context.Context, key string, values ...interface{}) error {
+	return nil
+}
+
+func (n *Null) List(ctx context.Context, key string) ([]string, error) {
+	return []string{}, nil
+}
+
+func (n *Null) SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) (bool, error) {
+	return true, nil
+}
+
+func (n *Null) DecrBy(ctx context.Context, key string, decrement int64) (int64, error) {
+	return -decrement, nil
+}
+
+func (n *Null) IncrBy(ctx context.Context, key string, delta int64, expiration time.Duration) (int64, error) {
+	return delta, nil
+}
+
+func (n *Null) IncrByFloat(ctx context.Context, key string, delta float64, expiration time.Duration) (float64, error) {
+	return delta, nil
+}
+
+func (n *Null) Expire(ctx context.Context, key string, expiration time.Duration) (bool, error) {
+	return false, nil
+}
+
+func (n *Null) Persist(ctx context.Context, key string) (bool, error) {
+	return false, nil
+}
+
+func (n *Null) ExpireXX(ctx context.Context, key string, expiration time.Duration) (bool, error) {
+	return false, nil
+}
+
+func (n *Null) Exists(ctx context.Context, key string) (bool, error) {
+	return false, nil
+}
+
+func (n *Null) TTL(ctx context.Context, key string) (time.Duration, error) {
+	return 0, redis.Nil
+}
+
+func (n *Null) Delete(ctx context.Context, keys ...string) (int64, error) {
+	return 0, nil
+}
+
+func (n *Null) SAdd(ctx context.Context, key string, members ...interface{}) (int64, error) {
+	return int64(len(members)), nil
+}
+
+func (n *Null) SMembers(ctx context.Context, key string) ([]string, error) {
+	return []string{}, nil
+}
+
+func (n *Null) SRem(ctx context.Context, key string, members ...interface{}) (int64, error) {
+	return 0, nil
+}
+
+func (n *Null) SIsMember(ctx context.Context, key string, member interface{}) (bool, error) {
+	return false, nil
+}
+
+func (n *Null) SCard(ctx context.Context, key string) (int64, error) {
+	return 0, nil
+}
+
+func (n *Null) ZRem(ctx context.Context, key string, members ...interface{}) (int64, error) {
+	return 0, nil
+}
+
+func (n *Null) Scan(ctx context.Context, cursor uint64, match string, count int64) ([]string, uint64, error) {
+	return []string{}, 0, nil
+}
+
+func (n *Null) MGet(ctx context.Context, keys ...string) ([]interface{}, error) {
+	return make([]interface{}, len(keys)), nil
+}
+
+func (n *Null) MSet(ctx context.Context, values map[string]interface{}, expiration time.Duration) error {
+	return nil
+}
+
+func (n *Null) CompareAndDelete(ctx context.Context, key string, expected string) (bool, error) {
+	return false, nil
+}
+
+func (n *Null) CompareAndExpire(ctx context.Context, key string, expected string, expiration time.Duration) (bool, error) {
+	return false, nil
+}
+
+func (n *Null) CompareAndSwap(ctx context.Context, key string, expected string, value interface{}, expiration time.Duration) (bool, error) {
+	return false, nil
+}
+
+func (n *Null) Close() error {
+	return nil
+}
+
+func (n *Null) RateLimiter(ctx context.Context, key string, value int, expiration time.Duration) (int64, error) {
+	return int64(value) - 1, nil
+}
+
+func (n *Null) CountRateLimiter(ctx context.Context, key string, value int, decrement int, expiration time.Duration) (int64, error) {
+	return int64(value - decrement), nil
+}
+
+func (n *Null) TokenBucket(ctx context.Context, key string, rate float64, burst int64) (bool, int64, error) {
+	return burst > 0, max(burst-1, 0), nil
+}
+
+func (n *Null) GCRA(ctx context.Context, key string, maxBurst, count int64, period time.Duration, quantity int64) (GCRAResult, error) {
+	now := time.Now()
+	result, _ := gcra(now, now, maxBurst, period/time.Duration(count), quantity)
+	return result, nil
+}
+
+func (n *Null) AcquireSemaphore(ctx context.Context, key string, holder string, limit int64, ttl time.Duration) (bool, error) {
+	return limit > 0, nil
+}
+
+func (n *Null) HIncrByMany(ctx context.Context, key string, increments map[string]int64) error {
+	return nil
+}
+
+func (n *Null) HGetAll(ctx context.Context, key string) (map[string]string, error) {
+	return map[string]string{}, nil
+}
+
+func (n *Null) HSet(ctx context.Context, key string, values map[string]interface{}) (int64, error) {
+	return int64(len(values)), nil
+}
+
+func (n *Null) HGet(ctx context.Context, key, field string) (string, error) {
+	return "", redis.Nil
+}
+
+func (n *Null) HDel(ctx context.Context, key string, fields ...string) (int64, error) {
+	return 0, nil
+}
+
+func (n *Null) HIncrBy(ctx context.Context, key, field string, delta int64) (int64, error) {
+	return delta, nil
+}
+
+func (n *Null) ZAdd(ctx context.Context, key string, members ...redis.Z) (int64, error) {
+	return int64(len(members)), nil
+}
+
+func (n *Null) ZIncrBy(ctx context.Context, key string, increment float64, member string) (float64, error) {
+	return increment, nil
+}
+
+func (n *Null) ZScore(ctx context.Context, key, member string) (float64, error) {
+	return 0, redis.Nil
+}
+
+func (n *Null) ZRank(ctx context.Context, key, member string) (int64, error) {
+	return 0, redis.Nil
+}
+
+func (n *Null) ZRevRank(ctx context.Context, key, member string) (int64, error) {
+	return 0, redis.Nil
+}
+
+func (n *Null) ZRangeWithScores(ctx context.Context, key string, start, stop int64) ([]redis.Z, error) {
+	return []redis.Z{}, nil
+}
+
+func (n *Null) ZRevRangeWithScores(ctx context.Context, key string, start, stop int64) ([]redis.Z, error) {
+	return []redis.Z{}, nil
+}
+
+func (n *Null) PFAdd(ctx context.Context, key string, elements ...interface{}) (bool, error) {
+	return true, nil
+}
+
+func (n *Null) PFCount(ctx context.Context, keys ...string) (int64, error) {
+	return 0, nil
+}
+
+func (n *Null) PFMerge(ctx context.Context, dest string, keys ...string) error {
+	return nil
+}
+
+func (n *Null) SetBit(ctx context.Context, key string, offset int64, value int) (int64, error) {
+	return 0, nil
+}
+
+func (n *Null) GetBit(ctx context.Context, key string, offset int64) (int64, error) {
+	return 0, nil
+}
+
+func (n *Null) BitCount(ctx context.Context, key string) (int64, error) {
+	return 0, nil
+}
+
+func (n *Null) BitOp(ctx context.Context, op, dest string, keys ...string) (int64, error) {
+	return 0, nil
+}
+
+func (n *Null) XAdd(ctx context.Context, stream string, maxLen int64, values map[string]interface{}) (string, error) {
+	return "", nil
+}
+
+func (n *Null) XLen(ctx context.Context, stream string) (int64, error) {
+	return 0, nil
+}
+
+func (n *Null) XGroupCreate(ctx context.Context, stream, group, start string) error {
+	return nil
+}
+
+func (n *Null) XReadGroup(ctx context.Context, stream, group, consumer string, count int64, block time.Duration) ([]redis.XMessage, error) {
+	return nil, nil
+}
+
+func (n *Null) XAck(ctx context.Context, stream, group string, ids ...string) (int64, error) {
+	return 0, nil
+}
+
+func (n *Null) XAutoClaim(ctx context.Context, stream, group, consumer string, minIdle time.Duration, start string, count int64) ([]redis.XMessage, string, error) {
+	return nil, "0-0", nil
+}
+
+func (n *Null) GeoAdd(ctx context.Context, key string, locations ...*redis.GeoLocation) (int64, error) {
+	return 0, nil
+}
+
+func (n *Null) GeoPos(ctx context.Context, key string, members ...string) ([]*redis.GeoPos, error) {
+	return make([]*redis.GeoPos, len(members)), nil
+}
+
+func (n *Null) GeoSearch(ctx context.Context, key string, longitude, latitude, radius float64, unit string, count int) ([]redis.GeoLocation, error) {
+	return nil, nil
+}
+
+// BLPop returns redis.Nil at once, waiting would never produce an element
+func (n *Null) BLPop(ctx context.Context, timeout time.Duration, keys ...string) ([]string, error) {
+	return nil, redis.Nil
+}
+
+func (n *Null) BRPop(ctx context.Context, timeout time.Duration, keys ...string) ([]string, error) {
+	return nil, redis.Nil
+}
+
+func (n *Null) LMove(ctx context.Context, source, destination, from, to string) (string, error) {
+	return "", redis.Nil
+}
+
+func (n *Null) BLMove(ctx context.Context, source, destination, from, to string, timeout time.Duration) (string, error) {
+	return "", redis.Nil
+}
+
+func (n *Null) LRem(ctx context.Context, key string, count int64, value interface{}) (int64, error) {
+	return 0, nil
+}
+
+func (n *Null) LLen(ctx context.Context, key string) (int64, error) {
+	return 0, nil
+}
+
+func (n *Null) ZMoveToList(ctx context.Context, source, destination string, max float64, count int64) (int64, error) {
+	return 0, nil
+}
+
+func (n *Null) Pipeline(ctx context.Context, ops []*PipelineOp) error {
+	RunPipeline(ctx, n, ops)
+	return nil
+}
+
+// Watch runs fn and applies the writes it returns, nothing ever changes
+func (n *Null) Watch(ctx context.Context, fn TxFunc, keys ...string) error {
+	ops, err := fn(n)
+	if err != nil {
+		return err
+	}
+	RunPipeline(ctx, n, ops)
+	return nil
+}
+
+// SubscribeKeyEvents never calls handler, no key expires
+func (n *Null) SubscribeKeyEvents(ctx context.Context, handler func(KeyEvent)) (io.Closer, error) {
+	return closers(nil), nil
+}
+
+func (n *Null) SetBits(ctx context.Context, key string, offsets ...int64) (int64, error) {
+	return 0, nil
+}
+
+// TestBits reports every bit set, as nothing is known not to exist in a
+// backend that never stores anything
+func (n *Null) TestBits(ctx context.Context, key string, offsets ...int64) (bool, error) {
+	return true, nil
+}
+
+// Ping always succeeds, there is nothing to reach
+func (n *Null) Ping(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,106 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+// PipelineCommand is the kind of a PipelineOp
+type PipelineCommand int
+
+const (
+	PipelineGet PipelineCommand = iota
+	PipelineSet
+	PipelineDelete
+	PipelineIncr
+)
+
+// PipelineOp is one command of a pipeline. Pipeline fills in Result and Err
+// once it ran: the value of a Get, redis.Nil for a missing key, the number of
+// keys a Delete removed and the new value of an Incr.
+type PipelineOp struct {
+	Command    PipelineCommand
+	Keys       []string
+	Value      interface{}
+	Expiration time.Duration
+	Delta      int64
+	Result     interface{}
+	Err        error
+}
+
+// Pipeline sends every op to the server in one round trip. The error is the
+// one of the round trip itself, those of single commands are in their Err.
+func (r *RedisClient) Pipeline(ctx context.Context, ops []*PipelineOp) error {
+	if len(ops) == 0 {
+		return nil
+	}
+	var cmds []redis.Cmder
+	_, err := r.Client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		cmds = queueOps(ctx, pipe, ops)
+		return nil
+	})
+	return opResults(ops, cmds, err)
+}
+
+// queueOps queues ops on pipe and returns their commands
+func queueOps(ctx context.Context, pipe redis.Pipeliner, ops []*PipelineOp) []redis.Cmder {
+	cmds := make([]redis.Cmder, len(ops))
+	for i, op := range ops {
+		switch op.Command {
+		case PipelineGet:
+			cmds[i] = pipe.Get(ctx, op.Keys[0])
+		case PipelineSet:
+			cmds[i] = pipe.Set(ctx, op.Keys[0], op.Value, op.Expiration)
+		case PipelineDelete:
+			cmds[i] = pipe.Del(ctx, op.Keys...)
+		case PipelineIncr:
+			cmds[i] = pipe.IncrBy(ctx, op.Keys[0], op.Delta)
+		}
+	}
+	return cmds
+}
+
+// opResults fills in ops from the commands that ran them and returns err
+// unless it is only the first error reply, which go-redis also returns
+func opResults(ops []*PipelineOp, cmds []redis.Cmder, err error) error {
+	for i, op := range ops {
+		switch cmd := cmds[i].(type) {
+		case *redis.StringCmd:
+			op.Result, op.Err = cmd.Result()
+		case *redis.StatusCmd:
+			op.Err = cmd.Err()
+		case *redis.IntCmd:
+			op.Result, op.Err = cmd.Result()
+		}
+	}
+	var reply redis.Error
+	if errors.As(err, &reply) {
+		return nil
+	}
+	return err
+}
+
+// Pipeline runs every op in turn, there is no round trip to save
+func (m *Memory) Pipeline(ctx context.Context, ops []*PipelineOp) error {
+	RunPipeline(ctx, m, ops)
+	return nil
+}
+
+// RunPipeline runs every op against server one after the other, for servers
+// without a way to batch commands
+func RunPipeline(ctx context.Context, server CacheServer, ops []*PipelineOp) {
+	for _, op := range ops {
+		switch op.Command {
+		case PipelineGet:
+			op.Result, op.Err = server.Get(ctx, op.Keys[0])
+		case PipelineSet:
+			op.Err = server.Set(ctx, op.Keys[0], op.Value, op.Expiration)
+		case PipelineDelete:
+			op.Result, op.Err = server.Delete(ctx, op.Keys...)
+		case PipelineIncr:
+			op.Result, op.Err = server.IncrBy(ctx, op.Keys[0], op.Delta, 0)
+		}
+	}
+}
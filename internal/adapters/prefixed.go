@@ -0,0 +1,446 @@
+package adapters
+
+import (
+	"context"
+	"github.com/redis/go-redis/v9"
+	"io"
+	"strings"
+	"time"
+)
+
+// Prefixed is a CacheServer that transparently namespaces every key of the
+// wrapped server with Prefix, so several applications can share one backend.
+// With a MaxKeyLength the prefixed keys are also passed through SafeKey.
+type Prefixed struct {
+	Server       CacheServer
+	Prefix       string
+	MaxKeyLength int
+}
+
+var _ CacheServer = (*Prefixed)(nil)
+
+// NewPrefixed namespaces the keys of server with prefix
+func NewPrefixed(server CacheServer, prefix string) *Prefixed {
+	return &Prefixed{Server: server, Prefix: prefix}
+}
+
+func (p *Prefixed) key(key string) string {
+	if p.MaxKeyLength > 0 {
+		return SafeKey(p.Prefix+key, p.MaxKeyLength)
+	}
+	return p.Prefix + key
+}
+
+// Key returns the key of the wrapped server that key is stored at
+func (p *Prefixed) Key(key string) string {
+	return p.key(key)
+}
+
+func (p *Prefixed) keys(keys []string) []string {
+	prefixed := make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = p.key(key)
+	}
+	return prefixed
+}
+
+func (p *Prefixed) Incr(ctx context.Context, key string) (int64, error) {
+	return p.Server.Incr(ctx, p.key(key))
+}
+
+func (p *Prefixed) Decr(ctx context.Context, key string) (int64, error) {
+	return p.Server.Decr(ctx, p.key(key))
+}
+
+func (p *Prefixed) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	return p.Server.Set(ctx, p.key(key), value, expiration)
+}
+
+func (p *Prefixed) Remember(ctx context.Context, key string, expiration time.Duration, value func() interface{}) (interface{}, error) {
+	return p.Server.Remember(ctx, p.key(key), expiration, value)
+}
+
+func (p *Prefixed) Get(ctx context.Context, key string) (string, error) {
+	return p.Server.Get(ctx, p.key(key))
+}
+
+func (p *Prefixed) Pop(ctx context.Context, key string) (string, error) {
+	return p.Server.Pop(ctx, p.key(key))
+}
+
+func (p *Prefixed) Push(ctx context.Context, key string, values ...interface{}) error {
+	return p.Server.Push(ctx, p.key(key), values...)
+}
+
+func (p *Prefixed) List(ctx context.Context, key string) ([]string, error) {
+	return p.Server.List(ctx, p.key(key))
+}
+
+func (p *Prefixed) SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) (bool, error) {
+	return p.Server.SetNX(ctx, p.key(key), value, expiration)
+}
+
+func (p *Prefixed) DecrBy(ctx context.Context, key string, decrement int64) (int64, error) {
+	return p.Server.DecrBy(ctx, p.key(key), decrement)
+}
+
+func (p *Prefixed) IncrBy(ctx context.Context, key string, delta int64, expiration time.Duration) (int64, error) {
+	return p.Server.IncrBy(ctx, p.key(key), delta, expiration)
+}
+
+func (p *Prefixed) IncrByFloat(ctx context.Context, key string, delta float64, expiration time.Duration) (float64, error) {
+	return p.Server.IncrByFloat(ctx, p.key(key), delta, expiration)
+}
+
+func (p *Prefixed) Expire(ctx context.Context, key string, expiration time.Duration) (bool, error) {
+	return p.Server.Expire(ctx, p.key(key), expiration)
+}
+
+func (p *Prefixed) Persist(ctx context.Context, key string) (bool, error) {
+	return p.Server.Persist(ctx, p.key(key))
+}
+
+func (p *Prefixed) ExpireXX(ctx context.Context, key string, expiration time.Duration) (bool, error) {
+	return p.Server.ExpireXX(ctx, p.key(key), expiration)
+}
+
+func (p *Prefixed) Delete(ctx context.Context, keys ...string) (int64, error) {
+	return p.Server.Delete(ctx, p.keys(keys)...)
+}
+
+// Unlink deletes keys with the Unlink of the wrapped server, or its Delete
+func (p *Prefixed) Unlink(ctx context.Context, keys ...string) (int64, error) {
+	if u, ok := p.Server.(unlinker); ok {
+		return u.Unlink(ctx, p.keys(keys)...)
+	}
+	return p.Server.Delete(ctx, p.keys(keys)...)
+}
+
+func (p *Prefixed) SAdd(ctx context.Context, key string, members ...interface{}) (int64, error) {
+	return p.Server.SAdd(ctx, p.key(key), members...)
+}
+
+func (p *Prefixed) SMembers(ctx context.Context, key string) ([]string, error) {
+	return p.Server.SMembers(ctx, p.key(key))
+}
+
+func (p *Prefixed) SRem(ctx context.Context, key string, members ...interface{}) (int64, error) {
+	return p.Server.SRem(ctx, p.key(key), members...)
+}
+
+func (p *Prefixed) SIsMember(ctx context.Context, key string, member interface{}) (bool, error) {
+	return p.Server.SIsMember(ctx, p.key(key), member)
+}
+
+func (p *Prefixed) SCard(ctx context.Context, key string) (int64, error) {
+	return p.Server.SCard(ctx, p.key(key))
+}
+
+func (p *Prefixed) GetDel(ctx context.Context, key string) (string, error) {
+	return p.Server.GetDel(ctx, p.key(key))
+}
+
+func (p *Prefixed) GetSet(ctx context.Context, key string, value interface{}, expiration time.Duration) (string, error) {
+	return p.Server.GetSet(ctx, p.key(key), value, expiration)
+}
+
+func (p *Prefixed) Exists(ctx context.Context, key string) (bool, error) {
+	return p.Server.Exists(ctx, p.key(key))
+}
+
+func (p *Prefixed) TTL(ctx context.Context, key string) (time.Duration, error) {
+	return p.Server.TTL(ctx, p.key(key))
+}
+
+func (p *Prefixed) HIncrByMany(ctx context.Context, key string, increments map[string]int64) error {
+	return p.Server.HIncrByMany(ctx, p.key(key), increments)
+}
+
+func (p *Prefixed) HGetAll(ctx context.Context, key string) (map[string]string, error) {
+	return p.Server.HGetAll(ctx, p.key(key))
+}
+
+func (p *Prefixed) HSet(ctx context.Context, key string, values map[string]interface{}) (int64, error) {
+	return p.Server.HSet(ctx, p.key(key), values)
+}
+
+func (p *Prefixed) HGet(ctx context.Context, key, field string) (string, error) {
+	return p.Server.HGet(ctx, p.key(key), field)
+}
+
+func (p *Prefixed) HDel(ctx context.Context, key string, fields ...string) (int64, error) {
+	return p.Server.HDel(ctx, p.key(key), fields...)
+}
+
+func (p *Prefixed) HIncrBy(ctx context.Context, key, field string, delta int64) (int64, error) {
+	return p.Server.HIncrBy(ctx, p.key(key), field, delta)
+}
+
+func (p *Prefixed) ZAdd(ctx context.Context, key string, members ...redis.Z) (int64, error) {
+	return p.Server.ZAdd(ctx, p.key(key), members...)
+}
+
+func (p *Prefixed) ZIncrBy(ctx context.Context, key string, increment float64, member string) (float64, error) {
+	return p.Server.ZIncrBy(ctx, p.key(key), increment, member)
+}
+
+func (p *Prefixed) ZScore(ctx context.Context, key, member string) (float64, error) {
+	return p.Server.ZScore(ctx, p.key(key), member)
+}
+
+func (p *Prefixed) ZRank(ctx context.Context, key, member string) (int64, error) {
+	return p.Server.ZRank(ctx, p.key(key), member)
+}
+
+func (p *Prefixed) ZRevRank(ctx context.Context, key, member string) (int64, error) {
+	return p.Server.ZRevRank(ctx, p.key(key), member)
+}
+
+func (p *Prefixed) ZRangeWithScores(ctx context.Context, key string, start, stop int64) ([]redis.Z, error) {
+	return p.Server.ZRangeWithScores(ctx, p.key(key), start, stop)
+}
+
+func (p *Prefixed) ZRevRangeWithScores(ctx context.Context, key string, start, stop int64) ([]redis.Z, error) {
+	return p.Server.ZRevRangeWithScores(ctx, p.key(key), start, stop)
+}
+
+func (p *Prefixed) PFAdd(ctx context.Context, key string, elements ...interface{}) (bool, error) {
+	return p.Server.PFAdd(ctx, p.key(key), elements...)
+}
+
+func (p *Prefixed) PFCount(ctx context.Context, keys ...string) (int64, error) {
+	return p.Server.PFCount(ctx, p.keys(keys)...)
+}
+
+func (p *Prefixed) PFMerge(ctx context.Context, dest string, keys ...string) error {
+	return p.Server.PFMerge(ctx, p.key(dest), p.keys(keys)...)
+}
+
+func (p *Prefixed) SetBit(ctx context.Context, key string, offset int64, value int) (int64, error) {
+	return p.Server.SetBit(ctx, p.key(key), offset, value)
+}
+
+func (p *Prefixed) GetBit(ctx context.Context, key string, offset int64) (int64, error) {
+	return p.Server.GetBit(ctx, p.key(key), offset)
+}
+
+func (p *Prefixed) BitCount(ctx context.Context, key string) (int64, error) {
+	return p.Server.BitCount(ctx, p.key(key))
+}
+
+func (p *Prefixed) BitOp(ctx context.Context, op, dest string, keys ...string) (int64, error) {
+	return p.Server.BitOp(ctx, op, p.key(dest), p.keys(keys)...)
+}
+
+func (p *Prefixed) XAdd(ctx context.Context, stream string, maxLen int64, values map[string]interface{}) (string, error) {
+	return p.Server.XAdd(ctx, p.key(stream), maxLen, values)
+}
+
+func (p *Prefixed) XLen(ctx context.Context, stream string) (int64, error) {
+	return p.Server.XLen(ctx, p.key(stream))
+}
+
+func (p *Prefixed) XGroupCreate(ctx context.Context, stream, group, start string) error {
+	return p.Server.XGroupCreate(ctx, p.key(stream), group, start)
+}
+
+func (p *Prefixed) XReadGroup(ctx context.Context, stream, group, consumer string, count int64, block time.Duration) ([]redis.XMessage, error) {
+	return p.Server.XReadGroup(ctx, p.key(stream), group, consumer, count, block)
+}
+
+func (p *Prefixed) XAck(ctx context.Context, stream, group string, ids ...string) (int64, error) {
+	return p.Server.XAck(ctx, p.key(stream), group, ids...)
+}
+
+func (p *Prefixed) XAutoClaim(ctx context.Context, stream, group, consumer string, minIdle time.Duration, start string, count int64) ([]redis.XMessage, string, error) {
+	return p.Server.XAutoClaim(ctx, p.key(stream), group, consumer, minIdle, start, count)
+}
+
+func (p *Prefixed) GeoAdd(ctx context.Context, key string, locations ...*redis.GeoLocation) (int64, error) {
+	return p.Server.GeoAdd(ctx, p.key(key), locations...)
+}
+
+func (p *Prefixed) GeoPos(ctx context.Context, key string, members ...string) ([]*redis.GeoPos, error) {
+	return p.Server.GeoPos(ctx, p.key(key), members...)
+}
+
+func (p *Prefixed) GeoSearch(ctx context.Context, key string, longitude, latitude, radius float64, unit string, count int) ([]redis.GeoLocation, error) {
+	return p.Server.GeoSearch(ctx, p.key(key), longitude, latitude, radius, unit, count)
+}
+
+func (p *Prefixed) BLPop(ctx context.Context, timeout time.Duration, keys ...string) ([]string, error) {
+	popped, err := p.Server.BLPop(ctx, timeout, p.keys(keys)...)
+	return p.unprefixPopped(keys, popped, err)
+}
+
+func (p *Prefixed) BRPop(ctx context.Context, timeout time.Duration, keys ...string) ([]string, error) {
+	popped, err := p.Server.BRPop(ctx, timeout, p.keys(keys)...)
+	return p.unprefixPopped(keys, popped, err)
+}
+
+// unprefixPopped replaces the key a blocking pop returns with the one of keys
+// it was made from
+func (p *Prefixed) unprefixPopped(keys, popped []string, err error) ([]string, error) {
+	if err != nil || len(popped) != 2 {
+		return popped, err
+	}
+	for _, key := range keys {
+		if p.key(key) == popped[0] {
+			popped[0] = key
+			break
+		}
+	}
+	return popped, nil
+}
+
+func (p *Prefixed) LMove(ctx context.Context, source, destination, from, to string) (string, error) {
+	return p.Server.LMove(ctx, p.key(source), p.key(destination), from, to)
+}
+
+func (p *Prefixed) BLMove(ctx context.Context, source, destination, from, to string, timeout time.Duration) (string, error) {
+	return p.Server.BLMove(ctx, p.key(source), p.key(destination), from, to, timeout)
+}
+
+func (p *Prefixed) LRem(ctx context.Context, key string, count int64, value interface{}) (int64, error) {
+	return p.Server.LRem(ctx, p.key(key), count, value)
+}
+
+func (p *Prefixed) LLen(ctx context.Context, key string) (int64, error) {
+	return p.Server.LLen(ctx, p.key(key))
+}
+
+func (p *Prefixed) ZMoveToList(ctx context.Context, source, destination string, max float64, count int64) (int64, error) {
+	return p.Server.ZMoveToList(ctx, p.key(source), p.key(destination), max, count)
+}
+
+// Pipeline forwards ops with prefixed keys, filling in the results of ops
+func (p *Prefixed) Pipeline(ctx context.Context, ops []*PipelineOp) error {
+	prefixed := make([]*PipelineOp, len(ops))
+	for i, op := range ops {
+		forwarded := *op
+		forwarded.Keys = p.keys(op.Keys)
+		prefixed[i] = &forwarded
+	}
+	err := p.Server.Pipeline(ctx, prefixed)
+	for i, op := range ops {
+		op.Result, op.Err = prefixed[i].Result, prefixed[i].Err
+	}
+	return err
+}
+
+// Watch watches the prefixed keys and commits the writes of fn with prefixed
+// keys, filling in the results of the writes fn returned
+func (p *Prefixed) Watch(ctx context.Context, fn TxFunc, keys ...string) error {
+	var ops, prefixed []*PipelineOp
+	err := p.Server.Watch(ctx, func(tx TxReader) ([]*PipelineOp, error) {
+		var err error
+		ops, err = fn(prefixedReader{p, tx})
+		prefixed = make([]*PipelineOp, len(ops))
+		for i, op := range ops {
+			forwarded := *op
+			forwarded.Keys = p.keys(op.Keys)
+			prefixed[i] = &forwarded
+		}
+		return prefixed, err
+	}, p.keys(keys)...)
+	for i, op := range ops {
+		op.Result, op.Err = prefixed[i].Result, prefixed[i].Err
+	}
+	return err
+}
+
+type prefixedReader struct {
+	p  *Prefixed
+	tx TxReader
+}
+
+func (r prefixedReader) Get(ctx context.Context, key string) (string, error) {
+	return r.tx.Get(ctx, r.p.key(key))
+}
+
+// SubscribeKeyEvents only reports keys under the prefix, without it
+func (p *Prefixed) SubscribeKeyEvents(ctx context.Context, handler func(KeyEvent)) (io.Closer, error) {
+	return p.Server.SubscribeKeyEvents(ctx, func(event KeyEvent) {
+		if key, ok := strings.CutPrefix(event.Key, p.Prefix); ok {
+			event.Key = key
+			handler(event)
+		}
+	})
+}
+
+func (p *Prefixed) SetBits(ctx context.Context, key string, offsets ...int64) (int64, error) {
+	return p.Server.SetBits(ctx, p.key(key), offsets...)
+}
+
+func (p *Prefixed) TestBits(ctx context.Context, key string, offsets ...int64) (bool, error) {
+	return p.Server.TestBits(ctx, p.key(key), offsets...)
+}
+
+func (p *Prefixed) ZRem(ctx context.Context, key string, members ...interface{}) (int64, error) {
+	return p.Server.ZRem(ctx, p.key(key), members...)
+}
+
+// Scan only visits keys under the prefix and returns them without it
+func (p *Prefixed) Scan(ctx context.Context, cursor uint64, match string, count int64) ([]string, uint64, error) {
+	if match == "" {
+		match = "*"
+	}
+	keys, next, err := p.Server.Scan(ctx, cursor, escapePattern(p.Prefix)+match, count)
+	for i, key := range keys {
+		keys[i] = strings.TrimPrefix(key, p.Prefix)
+	}
+	return keys, next, err
+}
+
+func (p *Prefixed) MGet(ctx context.Context, keys ...string) ([]interface{}, error) {
+	return p.Server.MGet(ctx, p.keys(keys)...)
+}
+
+func (p *Prefixed) MSet(ctx context.Context, values map[string]interface{}, expiration time.Duration) error {
+	prefixed := make(map[string]interface{}, len(values))
+	for key, value := range values {
+		prefixed[p.key(key)] = value
+	}
+	return p.Server.MSet(ctx, prefixed, expiration)
+}
+
+func (p *Prefixed) CompareAndDelete(ctx context.Context, key string, expected string) (bool, error) {
+	return p.Server.CompareAndDelete(ctx, p.key(key), expected)
+}
+
+func (p *Prefixed) CompareAndExpire(ctx context.Context, key string, expected string, expiration time.Duration) (bool, error) {
+	return p.Server.CompareAndExpire(ctx, p.key(key), expected, expiration)
+}
+
+func (p *Prefixed) CompareAndSwap(ctx context.Context, key string, expected string, value interface{}, expiration time.Duration) (bool, error) {
+	return p.Server.CompareAndSwap(ctx, p.key(key), expected, value, expiration)
+}
+
+func (p *Prefixed) Close() error {
+	return p.Server.Close()
+}
+
+func (p *Prefixed) RateLimiter(ctx context.Context, key string, value int, expiration time.Duration) (int64, error) {
+	return p.Server.RateLimiter(ctx, p.key(key), value, expiration)
+}
+
+func (p *Prefixed) CountRateLimiter(ctx context.Context, key string, value int, decrement int, expiration time.Duration) (int64, error) {
+	return p.Server.CountRateLimiter(ctx, p.key(key), value, decrement, expiration)
+}
+
+func (p *Prefixed) TokenBucket(ctx context.Context, key string, rate float64, burst int64) (bool, int64, error) {
+	return p.Server.TokenBucket(ctx, p.key(key), rate, burst)
+}
+
+func (p *Prefixed) GCRA(ctx context.Context, key string, maxBurst, count int64, period time.Duration, quantity int64) (GCRAResult, error) {
+	return p.Server.GCRA(ctx, p.key(key), maxBurst, count, period, quantity)
+}
+
+func (p *Prefixed) AcquireSemaphore(ctx context.Context, key string, holder string, limit int64, ttl time.Duration) (bool, error) {
+	return p.Server.AcquireSemaphore(ctx, p.key(key), holder, limit, ttl)
+}
+
+// Flush deletes every key under the prefix
+func (p *Prefixed) Flush(ctx context.Context) (int64, error) {
+	return DeleteMatching(ctx, p, "*")
+}
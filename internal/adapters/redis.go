@@ -1,11 +1,12 @@
 package adapters
 
 import (
+	"cacher/codec"
 	"context"
-	"encoding/json"
-	"fmt"
+	"errors"
 	"github.com/redis/go-redis/v9"
-	"strconv"
+	"golang.org/x/sync/singleflight"
+	"io"
 	"sync"
 	"time"
 )
@@ -14,21 +15,91 @@ type CacheServer interface {
 	Incr(ctx context.Context, key string) (int64, error)
 	Decr(ctx context.Context, key string) (int64, error)
 	Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error
-	Remember(ctx context.Context, key string, value func() interface{}) interface{}
+	Remember(ctx context.Context, key string, expiration time.Duration, value func() interface{}) (interface{}, error)
 	Get(ctx context.Context, key string) (string, error)
+	GetDel(ctx context.Context, key string) (string, error)
+	GetSet(ctx context.Context, key string, value interface{}, expiration time.Duration) (string, error)
 	Pop(ctx context.Context, key string) (string, error)
 	Push(ctx context.Context, key string, values ...interface{}) error
 	List(ctx context.Context, key string) ([]string, error)
 	SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) (bool, error)
 	DecrBy(ctx context.Context, key string, decrement int64) (int64, error)
+	IncrBy(ctx context.Context, key string, delta int64, expiration time.Duration) (int64, error)
+	IncrByFloat(ctx context.Context, key string, delta float64, expiration time.Duration) (float64, error)
 	Expire(ctx context.Context, key string, expiration time.Duration) (bool, error)
+	Persist(ctx context.Context, key string) (bool, error)
+	ExpireXX(ctx context.Context, key string, expiration time.Duration) (bool, error)
+	Exists(ctx context.Context, key string) (bool, error)
+	TTL(ctx context.Context, key string) (time.Duration, error)
+	Delete(ctx context.Context, keys ...string) (int64, error)
+	SAdd(ctx context.Context, key string, members ...interface{}) (int64, error)
+	SMembers(ctx context.Context, key string) ([]string, error)
+	SRem(ctx context.Context, key string, members ...interface{}) (int64, error)
+	SIsMember(ctx context.Context, key string, member interface{}) (bool, error)
+	SCard(ctx context.Context, key string) (int64, error)
+	ZRem(ctx context.Context, key string, members ...interface{}) (int64, error)
+	Scan(ctx context.Context, cursor uint64, match string, count int64) ([]string, uint64, error)
+	MGet(ctx context.Context, keys ...string) ([]interface{}, error)
+	MSet(ctx context.Context, values map[string]interface{}, expiration time.Duration) error
+	CompareAndDelete(ctx context.Context, key string, expected string) (bool, error)
+	CompareAndExpire(ctx context.Context, key string, expected string, expiration time.Duration) (bool, error)
+	CompareAndSwap(ctx context.Context, key string, expected string, value interface{}, expiration time.Duration) (bool, error)
+	Close() error
 	RateLimiter(ctx context.Context, key string, value int, expiration time.Duration) (int64, error)
 	CountRateLimiter(ctx context.Context, key string, value int, decrement int, expiration time.Duration) (int64, error)
+	TokenBucket(ctx context.Context, key string, rate float64, burst int64) (bool, int64, error)
+	GCRA(ctx context.Context, key string, maxBurst, count int64, period time.Duration, quantity int64) (GCRAResult, error)
+	AcquireSemaphore(ctx context.Context, key string, holder string, limit int64, ttl time.Duration) (bool, error)
+	HIncrByMany(ctx context.Context, key string, increments map[string]int64) error
+	HGetAll(ctx context.Context, key string) (map[string]string, error)
+	HSet(ctx context.Context, key string, values map[string]interface{}) (int64, error)
+	HGet(ctx context.Context, key, field string) (string, error)
+	HDel(ctx context.Context, key string, fields ...string) (int64, error)
+	HIncrBy(ctx context.Context, key, field string, delta int64) (int64, error)
+	ZAdd(ctx context.Context, key string, members ...redis.Z) (int64, error)
+	ZIncrBy(ctx context.Context, key string, increment float64, member string) (float64, error)
+	ZScore(ctx context.Context, key, member string) (float64, error)
+	ZRank(ctx context.Context, key, member string) (int64, error)
+	ZRevRank(ctx context.Context, key, member string) (int64, error)
+	ZRangeWithScores(ctx context.Context, key string, start, stop int64) ([]redis.Z, error)
+	ZRevRangeWithScores(ctx context.Context, key string, start, stop int64) ([]redis.Z, error)
+	ZMoveToList(ctx context.Context, source, destination string, max float64, count int64) (int64, error)
+	PFAdd(ctx context.Context, key string, elements ...interface{}) (bool, error)
+	PFCount(ctx context.Context, keys ...string) (int64, error)
+	PFMerge(ctx context.Context, dest string, keys ...string) error
+	SetBit(ctx context.Context, key string, offset int64, value int) (int64, error)
+	GetBit(ctx context.Context, key string, offset int64) (int64, error)
+	BitCount(ctx context.Context, key string) (int64, error)
+	BitOp(ctx context.Context, op, dest string, keys ...string) (int64, error)
+	XAdd(ctx context.Context, stream string, maxLen int64, values map[string]interface{}) (string, error)
+	XLen(ctx context.Context, stream string) (int64, error)
+	XGroupCreate(ctx context.Context, stream, group, start string) error
+	XReadGroup(ctx context.Context, stream, group, consumer string, count int64, block time.Duration) ([]redis.XMessage, error)
+	XAck(ctx context.Context, stream, group string, ids ...string) (int64, error)
+	XAutoClaim(ctx context.Context, stream, group, consumer string, minIdle time.Duration, start string, count int64) ([]redis.XMessage, string, error)
+	GeoAdd(ctx context.Context, key string, locations ...*redis.GeoLocation) (int64, error)
+	GeoPos(ctx context.Context, key string, members ...string) ([]*redis.GeoPos, error)
+	GeoSearch(ctx context.Context, key string, longitude, latitude, radius float64, unit string, count int) ([]redis.GeoLocation, error)
+	BLPop(ctx context.Context, timeout time.Duration, keys ...string) ([]string, error)
+	BRPop(ctx context.Context, timeout time.Duration, keys ...string) ([]string, error)
+	LMove(ctx context.Context, source, destination, from, to string) (string, error)
+	BLMove(ctx context.Context, source, destination, from, to string, timeout time.Duration) (string, error)
+	LRem(ctx context.Context, key string, count int64, value interface{}) (int64, error)
+	LLen(ctx context.Context, key string) (int64, error)
+	Pipeline(ctx context.Context, ops []*PipelineOp) error
+	Watch(ctx context.Context, fn TxFunc, keys ...string) error
+	SubscribeKeyEvents(ctx context.Context, handler func(KeyEvent)) (io.Closer, error)
+	SetBits(ctx context.Context, key string, offsets ...int64) (int64, error)
+	TestBits(ctx context.Context, key string, offsets ...int64) (bool, error)
 }
 
+// RedisClient is a CacheServer over any go-redis client: a single node
+// *redis.Client, a *redis.ClusterClient or a failover client. Logger, if set,
+// receives debug records from RememberWithType.
 type RedisClient struct {
-	Client    *redis.Client
-	Available bool
+	Client redis.UniversalClient
+	Logger Logger
+	loads  singleflight.Group
 }
 
 var (
@@ -36,11 +107,40 @@ var (
 	once                sync.Once
 )
 
-// Redis returns a singleton Redis client, initializing it only once
+// NewRedisClient creates a Redis client connected with the given options. Every
+// call returns an independent instance.
+func NewRedisClient(opts *redis.Options) *RedisClient {
+	return &RedisClient{Client: redis.NewClient(opts)}
+}
+
+// NewRedisClusterClient creates a client for a Redis Cluster, e.g. ElastiCache
+// in cluster mode
+func NewRedisClusterClient(opts *redis.ClusterOptions) *RedisClient {
+	return &RedisClient{Client: redis.NewClusterClient(opts)}
+}
+
+// NewRedisFailoverClient creates a client that discovers the current master
+// through Redis Sentinel and reconnects to the new master after a failover
+func NewRedisFailoverClient(opts *redis.FailoverOptions) *RedisClient {
+	return &RedisClient{Client: redis.NewFailoverClient(opts)}
+}
+
+// NewRedisUniversalClient creates a single node, cluster or failover client
+// depending on the options, see redis.NewUniversalClient
+func NewRedisUniversalClient(opts *redis.UniversalOptions) *RedisClient {
+	return &RedisClient{Client: redis.NewUniversalClient(opts)}
+}
+
+// Redis returns a singleton Redis client, initializing it only once. Any client
+// passed after the first call is ignored.
+//
+// Deprecated: use NewRedisClient, which supports multiple independent clients.
 func Redis(client *RedisClient) *RedisClient {
 	once.Do(func() {
 		redisClientInstance = client
-		fmt.Println("Reinit")
+		if client != nil {
+			loggerOf(client).Debug("redis client initialized")
+		}
 	})
 	return redisClientInstance
 }
@@ -60,13 +160,10 @@ func (r *RedisClient) Set(ctx context.Context, key string, value interface{}, ex
 	return r.Client.Set(ctx, key, value, expiration).Err()
 }
 
-func (r *RedisClient) Remember(ctx context.Context, key string, value func() interface{}) interface{} {
-	result, err := r.Get(ctx, key)
-	if err != nil {
-		temp := value()
-		return temp
-	}
-	return result
+// Remember returns the value stored at key, or computes it with value and stores
+// it with the given expiration on a miss
+func (r *RedisClient) Remember(ctx context.Context, key string, expiration time.Duration, value func() interface{}) (interface{}, error) {
+	return remember(ctx, r, &r.loads, key, expiration, value)
 }
 
 // Get retrieves the value for a given key
@@ -104,58 +201,182 @@ func (r *RedisClient) Expire(ctx context.Context, key string, expiration time.Du
 	return r.Client.Expire(ctx, key, expiration).Result()
 }
 
-// RateLimiter limits the rate of a specific action by decrementing a counter
-func (r *RedisClient) RateLimiter(ctx context.Context, key string, value int, expiration time.Duration) (int64, error) {
-	_, err := r.Client.Pipelined(ctx, func(p redis.Pipeliner) error {
-		if err := p.SetNX(ctx, key, value, expiration).Err(); err != nil {
-			return err
+// Persist removes the expiration of a key, reporting whether it had one
+func (r *RedisClient) Persist(ctx context.Context, key string) (bool, error) {
+	return r.Client.Persist(ctx, key).Result()
+}
+
+// expireXXScript is EXPIRE XX for servers older than Redis 7
+var expireXXScript = DefaultScripts.Register("expire_xx", `
+if redis.call('PTTL', KEYS[1]) > 0 then
+	return redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end
+return 0
+`)
+
+// ExpireXX sets the expiration of a key only if it already has one
+func (r *RedisClient) ExpireXX(ctx context.Context, key string, expiration time.Duration) (bool, error) {
+	updated, err := expireXXScript.Run(ctx, r.Client, []string{key}, expiration.Milliseconds()).Int64()
+	return updated == 1, err
+}
+
+// Delete removes the given keys and returns how many existed
+func (r *RedisClient) Delete(ctx context.Context, keys ...string) (int64, error) {
+	return r.delete(ctx, keys, redis.Cmdable.Del)
+}
+
+// Unlink removes the given keys like Delete, but Redis frees their memory in
+// the background, so deleting large values does not block it
+func (r *RedisClient) Unlink(ctx context.Context, keys ...string) (int64, error) {
+	return r.delete(ctx, keys, redis.Cmdable.Unlink)
+}
+
+func (r *RedisClient) delete(ctx context.Context, keys []string, del func(redis.Cmdable, context.Context, ...string) *redis.IntCmd) (int64, error) {
+	if !r.isCluster() || len(keys) < 2 {
+		return del(r.Client, ctx, keys...).Result()
+	}
+
+	// Keys of a multi key DEL must share a slot on a cluster, so they are
+	// deleted one by one in a pipeline instead
+	cmds, err := r.Client.Pipelined(ctx, func(p redis.Pipeliner) error {
+		for _, key := range keys {
+			del(p, ctx, key)
 		}
-		p.Decr(ctx, key)
 		return nil
 	})
 	if err != nil {
 		return 0, err
 	}
+	var deleted int64
+	for _, cmd := range cmds {
+		deleted += cmd.(*redis.IntCmd).Val()
+	}
+	return deleted, nil
+}
 
-	// Fetch the current value
-	return r.Client.Get(ctx, key).Int64()
+// SAdd adds members to the set stored at key
+func (r *RedisClient) SAdd(ctx context.Context, key string, members ...interface{}) (int64, error) {
+	return r.Client.SAdd(ctx, key, members...).Result()
 }
 
-// CountRateLimiter decrements a counter and ensures it does not go below 0
-func (r *RedisClient) CountRateLimiter(ctx context.Context, key string, value int, decrement int, expiration time.Duration) (int64, error) {
-	// Initialize the key if not exists
-	_, err := r.Client.SetNX(ctx, key, value, expiration).Result()
-	if err != nil {
-		return 0, err
-	}
+// SMembers returns all members of the set stored at key
+func (r *RedisClient) SMembers(ctx context.Context, key string) ([]string, error) {
+	return r.Client.SMembers(ctx, key).Result()
+}
 
-	// Get current value
-	currentValStr, err := r.Client.Get(ctx, key).Result()
-	if err != nil {
-		return 0, err
+// Scan iterates over the keys matching a glob pattern. Iteration starts and
+// ends with a zero cursor.
+func (r *RedisClient) Scan(ctx context.Context, cursor uint64, match string, count int64) ([]string, uint64, error) {
+	cluster, ok := r.Client.(*redis.ClusterClient)
+	if !ok {
+		return r.Client.Scan(ctx, cursor, match, count).Result()
 	}
 
-	currentVal, err := strconv.Atoi(currentValStr)
-	if err != nil {
-		return 0, err
-	}
+	// A cursor is only valid on the node that returned it, so on a cluster
+	// every master is scanned completely and all keys come back in one page
+	var mutex sync.Mutex
+	keys := []string{}
+	err := cluster.ForEachMaster(ctx, func(ctx context.Context, node *redis.Client) error {
+		iter := node.Scan(ctx, 0, match, count).Iterator()
+		for iter.Next(ctx) {
+			mutex.Lock()
+			keys = append(keys, iter.Val())
+			mutex.Unlock()
+		}
+		return iter.Err()
+	})
+	return keys, 0, err
+}
 
-	// Calculate new value
-	newValue := currentVal - decrement
-	if newValue < 0 {
-		return int64(newValue), nil
+// MGet retrieves the values of several keys in one round trip. Missing keys
+// have a nil value at their position.
+func (r *RedisClient) MGet(ctx context.Context, keys ...string) ([]interface{}, error) {
+	if !r.isCluster() {
+		return r.Client.MGet(ctx, keys...).Result()
 	}
 
-	// Decrement the key
-	_, err = r.Client.DecrBy(ctx, key, int64(decrement)).Result()
-	if err != nil {
-		return 0, err
+	// MGET cannot span slots on a cluster, so a pipeline of GETs is used
+	cmds, err := r.Client.Pipelined(ctx, func(p redis.Pipeliner) error {
+		for _, key := range keys {
+			p.Get(ctx, key)
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, err
 	}
+	values := make([]interface{}, len(keys))
+	for i, cmd := range cmds {
+		if value, err := cmd.(*redis.StringCmd).Result(); err == nil {
+			values[i] = value
+		}
+	}
+	return values, nil
+}
 
-	return int64(newValue), nil
+// MSet sets several keys with a shared expiration in one pipelined round trip
+func (r *RedisClient) MSet(ctx context.Context, values map[string]interface{}, expiration time.Duration) error {
+	_, err := r.Client.Pipelined(ctx, func(p redis.Pipeliner) error {
+		for key, value := range values {
+			p.Set(ctx, key, value, expiration)
+		}
+		return nil
+	})
+	return err
+}
+
+func (r *RedisClient) isCluster() bool {
+	_, ok := r.Client.(*redis.ClusterClient)
+	return ok
+}
+
+// Close closes the connection to Redis
+func (r *RedisClient) Close() error {
+	return r.Client.Close()
+}
+
+// rateLimiterScript creates the counter at KEYS[1] with ARGV[1], expiring
+// after ARGV[2] milliseconds if positive, and decrements it by ARGV[3]. With
+// ARGV[4] set the decrement is skipped when it would go below 0, and the value
+// it would have reached is returned instead.
+var rateLimiterScript = DefaultScripts.Register("rate_limiter", `
+if tonumber(ARGV[2]) > 0 then
+	redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[2], 'NX')
+else
+	redis.call('SETNX', KEYS[1], ARGV[1])
+end
+local current = tonumber(redis.call('GET', KEYS[1]))
+if current == nil then
+	return redis.error_reply('ERR value is not an integer or out of range')
+end
+local next = current - tonumber(ARGV[3])
+if ARGV[4] == '1' and next < 0 then
+	return next
+end
+return redis.call('DECRBY', KEYS[1], ARGV[3])
+`)
+
+// RateLimiter limits the rate of a specific action by decrementing a counter.
+// The counter starts at value and expires after expiration; creating and
+// decrementing it is one atomic step.
+func (r *RedisClient) RateLimiter(ctx context.Context, key string, value int, expiration time.Duration) (int64, error) {
+	return rateLimiterScript.Run(ctx, r.Client, []string{key}, value, expiration.Milliseconds(), 1, 0).Int64()
+}
+
+// CountRateLimiter decrements a counter and ensures it does not go below 0. A
+// decrement that would is not applied, and the negative value it would have
+// reached is returned. The check and the decrement are one atomic step.
+func (r *RedisClient) CountRateLimiter(ctx context.Context, key string, value int, decrement int, expiration time.Duration) (int64, error) {
+	return rateLimiterScript.Run(ctx, r.Client, []string{key}, value, expiration.Milliseconds(), decrement, 1).Int64()
 }
 
-func RememberWithType[T any](r *RedisClient, ctx context.Context, key string, value func() T) (T, error) {
+// RememberWithType is a typed Remember that serializes values with the given
+// codec, defaulting to JSON when it is nil
+func RememberWithType[T any](r CacheServer, valueCodec codec.Codec, ctx context.Context, key string, value func() T) (T, error) {
+	if valueCodec == nil {
+		valueCodec = codec.JSON
+	}
+
 	// Try to retrieve the value from Redis
 	result, err := r.Get(ctx, key)
 	if err != nil || result == "" {
@@ -163,7 +384,7 @@ func RememberWithType[T any](r *RedisClient, ctx context.Context, key string, va
 		temp := value()
 
 		// Marshal the value to store it in Redis
-		data, marshalErr := json.Marshal(temp)
+		data, marshalErr := valueCodec.Marshal(temp)
 		if marshalErr != nil {
 			return temp, marshalErr
 		}
@@ -173,14 +394,14 @@ func RememberWithType[T any](r *RedisClient, ctx context.Context, key string, va
 			return temp, setErr
 		}
 
-		fmt.Println("cache miss")
+		loggerOf(r).Debug("cache miss", "key", key)
 		return temp, nil
 	}
 
-	fmt.Println("cache hit")
+	loggerOf(r).Debug("cache hit", "key", key)
 	// Unmarshal the result into the generic type T
 	var parsed T
-	unmarshalErr := json.Unmarshal([]byte(result), &parsed)
+	unmarshalErr := valueCodec.Unmarshal([]byte(result), &parsed)
 	if unmarshalErr != nil {
 		var zero T
 		return zero, unmarshalErr
@@ -0,0 +1,578 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"io"
+	"math"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ReplicaPolicy decides which replica of a Replicated serves a read
+type ReplicaPolicy int
+
+const (
+	// RoundRobin spreads reads evenly over the healthy replicas
+	RoundRobin ReplicaPolicy = iota
+	// LowestLatency sends reads to the healthy replica answering fastest
+	LowestLatency
+)
+
+func (p ReplicaPolicy) String() string {
+	if p == LowestLatency {
+		return "lowest-latency"
+	}
+	return "round-robin"
+}
+
+// DefaultReplicaCheckInterval is how often a Replicated measures its replicas
+const DefaultReplicaCheckInterval = time.Second
+
+// heartbeatKey holds the time the primary last wrote it, read from the
+// replicas to measure how far behind they are. It goes after the prefix of
+// the cache, so apps sharing a server each measure their own heartbeat.
+const heartbeatKey = "cacher:heartbeat"
+
+// Replicated is a CacheServer sending writes to Primary and reads to one of
+// its replicas. Every check interval it writes a heartbeat to the primary and
+// reads it back from each replica, to measure the replica's latency and how
+// far its copy lags behind. Replicas lagging more than the maximum lag, and
+// replicas failing a read, get no reads until the next check finds them well,
+// and reads fall back to the primary when no replica is left.
+//
+// Commands that both read and write, blocking commands, Scan and transactions
+// always use the primary.
+type Replicated struct {
+	Primary CacheServer
+
+	replicas  []*replica
+	policy    ReplicaPolicy
+	maxLag    time.Duration
+	interval  time.Duration
+	key       string
+	heartbeat int64
+	next      atomic.Uint64
+	stop      chan struct{}
+	stopped   sync.WaitGroup
+	once      sync.Once
+}
+
+var _ CacheServer = (*Replicated)(nil)
+
+type replica struct {
+	server  CacheServer
+	healthy atomic.Bool
+	latency atomic.Int64
+	lag     atomic.Int64
+}
+
+// ReplicaStatus is what the last check found out about a replica
+type ReplicaStatus struct {
+	Healthy bool
+	Latency time.Duration // Moving average of the heartbeat reads
+	Lag     time.Duration // Age of its heartbeat, in steps of the check interval
+}
+
+// NewReplicated reads from replicas chosen by policy and writes to primary.
+// A maxLag of 0 disables the lag guard; checkInterval defaults to
+// DefaultReplicaCheckInterval. The heartbeat is written under prefix. Close
+// stops the checks.
+func NewReplicated(primary CacheServer, replicas []CacheServer, policy ReplicaPolicy, maxLag, checkInterval time.Duration, prefix string) *Replicated {
+	if checkInterval <= 0 {
+		checkInterval = DefaultReplicaCheckInterval
+	}
+	r := &Replicated{
+		Primary:  primary,
+		policy:   policy,
+		maxLag:   maxLag,
+		interval: checkInterval,
+		key:      prefix + heartbeatKey,
+		stop:     make(chan struct{}),
+	}
+	for _, server := range replicas {
+		replica := &replica{server: server}
+		replica.healthy.Store(true)
+		r.replicas = append(r.replicas, replica)
+	}
+	r.stopped.Add(1)
+	go r.run()
+	return r
+}
+
+// Replicas returns the status of every replica, in the order they were given
+func (r *Replicated) Replicas() []ReplicaStatus {
+	statuses := make([]ReplicaStatus, len(r.replicas))
+	for i, replica := range r.replicas {
+		statuses[i] = ReplicaStatus{
+			Healthy: replica.healthy.Load(),
+			Latency: time.Duration(replica.latency.Load()),
+			Lag:     time.Duration(replica.lag.Load()),
+		}
+	}
+	return statuses
+}
+
+func (r *Replicated) run() {
+	defer r.stopped.Done()
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), r.interval)
+			r.check(ctx)
+			cancel()
+		}
+	}
+}
+
+// check reads the heartbeat from every replica and then writes the next one
+func (r *Replicated) check(ctx context.Context) {
+	for _, replica := range r.replicas {
+		start := time.Now()
+		value, err := replica.server.Get(ctx, r.key)
+		if err != nil && !errors.Is(err, redis.Nil) {
+			replica.healthy.Store(false)
+			continue
+		}
+		replica.observe(time.Since(start))
+
+		// A replica is as far behind as the heartbeat it has is older than
+		// the last one written
+		seen, _ := strconv.ParseInt(value, 10, 64)
+		lag := max(r.heartbeat-seen, 0)
+		replica.lag.Store(lag)
+		replica.healthy.Store(r.maxLag <= 0 || time.Duration(lag) <= r.maxLag)
+	}
+
+	now := time.Now().UnixNano()
+	if err := r.Primary.Set(ctx, r.key, now, max(10*r.interval, time.Minute)); err == nil {
+		r.heartbeat = now
+	}
+}
+
+// observe adds latency to the moving average of the replica
+func (r *replica) observe(latency time.Duration) {
+	previous := r.latency.Load()
+	if previous == 0 {
+		r.latency.Store(int64(latency))
+		return
+	}
+	r.latency.Store(previous + (int64(latency)-previous)/5)
+}
+
+// pick returns the replica the next read goes to, nil when none is healthy
+func (r *Replicated) pick() *replica {
+	if r.policy == LowestLatency {
+		var fastest *replica
+		lowest := int64(math.MaxInt64)
+		for _, replica := range r.replicas {
+			if latency := replica.latency.Load(); replica.healthy.Load() && latency < lowest {
+				fastest, lowest = replica, latency
+			}
+		}
+		return fastest
+	}
+	start := r.next.Add(1)
+	for i := range r.replicas {
+		if replica := r.replicas[(start+uint64(i))%uint64(len(r.replicas))]; replica.healthy.Load() {
+			return replica
+		}
+	}
+	return nil
+}
+
+// replicaRead runs read on a replica, and on the primary when there is no
+// healthy replica or the replica fails. A miss is not a failure.
+func replicaRead[T any](r *Replicated, read func(server CacheServer) (T, error)) (T, error) {
+	if replica := r.pick(); replica != nil {
+		result, err := read(replica.server)
+		if err == nil || errors.Is(err, redis.Nil) {
+			return result, err
+		}
+		replica.healthy.Store(false)
+	}
+	return read(r.Primary)
+}
+
+// Ping checks the primary, reads fall back to it when replicas are down
+func (r *Replicated) Ping(ctx context.Context) error {
+	return Ping(ctx, NewCache(r.Primary))
+}
+
+// Close stops the checks and closes the primary and every replica
+func (r *Replicated) Close() error {
+	r.once.Do(func() {
+		close(r.stop)
+	})
+	r.stopped.Wait()
+	errs := []error{r.Primary.Close()}
+	for _, replica := range r.replicas {
+		errs = append(errs, replica.server.Close())
+	}
+	return errors.Join(errs...)
+}
+
+func (r *Replicated) Get(ctx context.Context, key string) (string, error) {
+	return replicaRead(r, func(server CacheServer) (string, error) {
+		return server.Get(ctx, key)
+	})
+}
+
+func (r *Replicated) List(ctx context.Context, key string) ([]string, error) {
+	return replicaRead(r, func(server CacheServer) ([]string, error) {
+		return server.List(ctx, key)
+	})
+}
+
+func (r *Replicated) Exists(ctx context.Context, key string) (bool, error) {
+	return replicaRead(r, func(server CacheServer) (bool, error) {
+		return server.Exists(ctx, key)
+	})
+}
+
+func (r *Replicated) TTL(ctx context.Context, key string) (time.Duration, error) {
+	return replicaRead(r, func(server CacheServer) (time.Duration, error) {
+		return server.TTL(ctx, key)
+	})
+}
+
+func (r *Replicated) SMembers(ctx context.Context, key string) ([]string, error) {
+	return replicaRead(r, func(server CacheServer) ([]string, error) {
+		return server.SMembers(ctx, key)
+	})
+}
+
+func (r *Replicated) SIsMember(ctx context.Context, key string, member interface{}) (bool, error) {
+	return replicaRead(r, func(server CacheServer) (bool, error) {
+		return server.SIsMember(ctx, key, member)
+	})
+}
+
+func (r *Replicated) SCard(ctx context.Context, key string) (int64, error) {
+	return replicaRead(r, func(server CacheServer) (int64, error) {
+		return server.SCard(ctx, key)
+	})
+}
+
+func (r *Replicated) MGet(ctx context.Context, keys ...string) ([]interface{}, error) {
+	return replicaRead(r, func(server CacheServer) ([]interface{}, error) {
+		return server.MGet(ctx, keys...)
+	})
+}
+
+func (r *Replicated) HGetAll(ctx context.Context, key string) (map[string]string, error) {
+	return replicaRead(r, func(server CacheServer) (map[string]string, error) {
+		return server.HGetAll(ctx, key)
+	})
+}
+
+func (r *Replicated) HGet(ctx context.Context, key, field string) (string, error) {
+	return replicaRead(r, func(server CacheServer) (string, error) {
+		return server.HGet(ctx, key, field)
+	})
+}
+
+func (r *Replicated) ZScore(ctx context.Context, key, member string) (float64, error) {
+	return replicaRead(r, func(server CacheServer) (float64, error) {
+		return server.ZScore(ctx, key, member)
+	})
+}
+
+func (r *Replicated) ZRank(ctx context.Context, key, member string) (int64, error) {
+	return replicaRead(r, func(server CacheServer) (int64, error) {
+		return server.ZRank(ctx, key, member)
+	})
+}
+
+func (r *Replicated) ZRevRank(ctx context.Context, key, member string) (int64, error) {
+	return replicaRead(r, func(server CacheServer) (int64, error) {
+		return server.ZRevRank(ctx, key, member)
+	})
+}
+
+func (r *Replicated) ZRangeWithScores(ctx context.Context, key string, start, stop int64) ([]redis.Z, error) {
+	return replicaRead(r, func(server CacheServer) ([]redis.Z, error) {
+		return server.ZRangeWithScores(ctx, key, start, stop)
+	})
+}
+
+func (r *Replicated) ZRevRangeWithScores(ctx context.Context, key string, start, stop int64) ([]redis.Z, error) {
+	return replicaRead(r, func(server CacheServer) ([]redis.Z, error) {
+		return server.ZRevRangeWithScores(ctx, key, start, stop)
+	})
+}
+
+func (r *Replicated) PFCount(ctx context.Context, keys ...string) (int64, error) {
+	return replicaRead(r, func(server CacheServer) (int64, error) {
+		return server.PFCount(ctx, keys...)
+	})
+}
+
+func (r *Replicated) GetBit(ctx context.Context, key string, offset int64) (int64, error) {
+	return replicaRead(r, func(server CacheServer) (int64, error) {
+		return server.GetBit(ctx, key, offset)
+	})
+}
+
+func (r *Replicated) BitCount(ctx context.Context, key string) (int64, error) {
+	return replicaRead(r, func(server CacheServer) (int64, error) {
+		return server.BitCount(ctx, key)
+	})
+}
+
+func (r *Replicated) XLen(ctx context.Context, stream string) (int64, error) {
+	return replicaRead(r, func(server CacheServer) (int64, error) {
+		return server.XLen(ctx, stream)
+	})
+}
+
+func (r *Replicated) GeoPos(ctx context.Context, key string, members ...string) ([]*redis.GeoPos, error) {
+	return replicaRead(r, func(server CacheServer) ([]*redis.GeoPos, error) {
+		return server.GeoPos(ctx, key, members...)
+	})
+}
+
+func (r *Replicated) GeoSearch(ctx context.Context, key string, longitude, latitude, radius float64, unit string, count int) ([]redis.GeoLocation, error) {
+	return replicaRead(r, func(server CacheServer) ([]redis.GeoLocation, error) {
+		return server.GeoSearch(ctx, key, longitude, latitude, radius, unit, count)
+	})
+}
+
+func (r *Replicated) LLen(ctx context.Context, key string) (int64, error) {
+	return replicaRead(r, func(server CacheServer) (int64, error) {
+		return server.LLen(ctx, key)
+	})
+}
+
+func (r *Replicated) TestBits(ctx context.Context, key string, offsets ...int64) (bool, error) {
+	return replicaRead(r, func(server CacheServer) (bool, error) {
+		return server.TestBits(ctx, key, offsets...)
+	})
+}
+
+func (r *Replicated) Incr(ctx context.Context, key string) (int64, error) {
+	return r.Primary.Incr(ctx, key)
+}
+
+func (r *Replicated) Decr(ctx context.Context, key string) (int64, error) {
+	return r.Primary.Decr(ctx, key)
+}
+
+func (r *Replicated) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	return r.Primary.Set(ctx, key, value, expiration)
+}
+
+func (r *Replicated) Remember(ctx context.Context, key string, expiration time.Duration, value func() interface{}) (interface{}, error) {
+	return r.Primary.Remember(ctx, key, expiration, value)
+}
+
+func (r *Replicated) GetDel(ctx context.Context, key string) (string, error) {
+	return r.Primary.GetDel(ctx, key)
+}
+
+func (r *Replicated) GetSet(ctx context.Context, key string, value interface{}, expiration time.Duration) (string, error) {
+	return r.Primary.GetSet(ctx, key, value, expiration)
+}
+
+func (r *Replicated) Pop(ctx context.Context, key string) (string, error) {
+	return r.Primary.Pop(ctx, key)
+}
+
+func (r *Replicated) Push(ctx context.Context, key string, values ...interface{}) error {
+	return r.Primary.Push(ctx, key, values...)
+}
+
+func (r *Replicated) SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) (bool, error) {
+	return r.Primary.SetNX(ctx, key, value, expiration)
+}
+
+func (r *Replicated) DecrBy(ctx context.Context, key string, decrement int64) (int64, error) {
+	return r.Primary.DecrBy(ctx, key, decrement)
+}
+
+func (r *Replicated) IncrBy(ctx context.Context, key string, delta int64, expiration time.Duration) (int64, error) {
+	return r.Primary.IncrBy(ctx, key, delta, expiration)
+}
+
+func (r *Replicated) IncrByFloat(ctx context.Context, key string, delta float64, expiration time.Duration) (float64, error) {
+	return r.Primary.IncrByFloat(ctx, key, delta, expiration)
+}
+
+func (r *Replicated) Expire(ctx context.Context, key string, expiration time.Duration) (bool, error) {
+	return r.Primary.Expire(ctx, key, expiration)
+}
+
+func (r *Replicated) Persist(ctx context.Context, key string) (bool, error) {
+	return r.Primary.Persist(ctx, key)
+}
+
+func (r *Replicated) ExpireXX(ctx context.Context, key string, expiration time.Duration) (bool, error) {
+	return r.Primary.ExpireXX(ctx, key, expiration)
+}
+
+func (r *Replicated) Delete(ctx context.Context, keys ...string) (int64, error) {
+	return r.Primary.Delete(ctx, keys...)
+}
+
+func (r *Replicated) SAdd(ctx context.Context, key string, members ...interface{}) (int64, error) {
+	return r.Primary.SAdd(ctx, key, members...)
+}
+
+func (r *Replicated) SRem(ctx context.Context, key string, members ...interface{}) (int64, error) {
+	return r.Primary.SRem(ctx, key, members...)
+}
+
+func (r *Replicated) ZRem(ctx context.Context, key string, members ...interface{}) (int64, error) {
+	return r.Primary.ZRem(ctx, key, members...)
+}
+
+func (r *Replicated) Scan(ctx context.Context, cursor uint64, match string, count int64) ([]string, uint64, error) {
+	return r.Primary.Scan(ctx, cursor, match, count)
+}
+
+func (r *Replicated) MSet(ctx context.Context, values map[string]interface{}, expiration time.Duration) error {
+	return r.Primary.MSet(ctx, values, expiration)
+}
+
+func (r *Replicated) CompareAndDelete(ctx context.Context, key string, expected string) (bool, error) {
+	return r.Primary.CompareAndDelete(ctx, key, expected)
+}
+
+func (r *Replicated) CompareAndExpire(ctx context.Context, key string, expected string, expiration time.Duration) (bool, error) {
+	return r.Primary.CompareAndExpire(ctx, key, expected, expiration)
+}
+
+func (r *Replicated) CompareAndSwap(ctx context.Context, key string, expected string, value interface{}, expiration time.Duration) (bool, error) {
+	return r.Primary.CompareAndSwap(ctx, key, expected, value, expiration)
+}
+
+func (r *Replicated) RateLimiter(ctx context.Context, key string, value int, expiration time.Duration) (int64, error) {
+	return r.Primary.RateLimiter(ctx, key, value, expiration)
+}
+
+func (r *Replicated) CountRateLimiter(ctx context.Context, key string, value int, decrement int, expiration time.Duration) (int64, error) {
+	return r.Primary.CountRateLimiter(ctx, key, value, decrement, expiration)
+}
+
+func (r *Replicated) TokenBucket(ctx context.Context, key string, rate float64, burst int64) (bool, int64, error) {
+	return r.Primary.TokenBucket(ctx, key, rate, burst)
+}
+
+func (r *Replicated) GCRA(ctx context.Context, key string, maxBurst, count int64, period time.Duration, quantity int64) (GCRAResult, error) {
+	return r.Primary.GCRA(ctx, key, maxBurst, count, period, quantity)
+}
+
+func (r *Replicated) AcquireSemaphore(ctx context.Context, key string, holder string, limit int64, ttl time.Duration) (bool, error) {
+	return r.Primary.AcquireSemaphore(ctx, key, holder, limit, ttl)
+}
+
+func (r *Replicated) HIncrByMany(ctx context.Context, key string, increments map[string]int64) error {
+	return r.Primary.HIncrByMany(ctx, key, increments)
+}
+
+func (r *Replicated) HSet(ctx context.Context, key string, values map[string]interface{}) (int64, error) {
+	return r.Primary.HSet(ctx, key, values)
+}
+
+func (r *Replicated) HDel(ctx context.Context, key string, fields ...string) (int64, error) {
+	return r.Primary.HDel(ctx, key, fields...)
+}
+
+func (r *Replicated) HIncrBy(ctx context.Context, key, field string, delta int64) (int64, error) {
+	return r.Primary.HIncrBy(ctx, key, field, delta)
+}
+
+func (r *Replicated) ZAdd(ctx context.Context, key string, members ...redis.Z) (int64, error) {
+	return r.Primary.ZAdd(ctx, key, members...)
+}
+
+func (r *Replicated) ZIncrBy(ctx context.Context, key string, increment float64, member string) (float64, error) {
+	return r.Primary.ZIncrBy(ctx, key, increment, member)
+}
+
+func (r *Replicated) ZMoveToList(ctx context.Context, source, destination string, max float64, count int64) (int64, error) {
+	return r.Primary.ZMoveToList(ctx, source, destination, max, count)
+}
+
+func (r *Replicated) PFAdd(ctx context.Context, key string, elements ...interface{}) (bool, error) {
+	return r.Primary.PFAdd(ctx, key, elements...)
+}
+
+func (r *Replicated) PFMerge(ctx context.Context, dest string, keys ...string) error {
+	return r.Primary.PFMerge(ctx, dest, keys...)
+}
+
+func (r *Replicated) SetBit(ctx context.Context, key string, offset int64, value int) (int64, error) {
+	return r.Primary.SetBit(ctx, key, offset, value)
+}
+
+func (r *Replicated) BitOp(ctx context.Context, op, dest string, keys ...string) (int64, error) {
+	return r.Primary.BitOp(ctx, op, dest, keys...)
+}
+
+func (r *Replicated) XAdd(ctx context.Context, stream string, maxLen int64, values map[string]interface{}) (string, error) {
+	return r.Primary.XAdd(ctx, stream, maxLen, values)
+}
+
+func (r *Replicated) XGroupCreate(ctx context.Context, stream, group, start string) error {
+	return r.Primary.XGroupCreate(ctx, stream, group, start)
+}
+
+func (r *Replicated) XReadGroup(ctx context.Context, stream, group, consumer string, count int64, block time.Duration) ([]redis.XMessage, error) {
+	return r.Primary.XReadGroup(ctx, stream, group, consumer, count, block)
+}
+
+func (r *Replicated) XAck(ctx context.Context, stream, group string, ids ...string) (int64, error) {
+	return r.Primary.XAck(ctx, stream, group, ids...)
+}
+
+func (r *Replicated) XAutoClaim(ctx context.Context, stream, group, consumer string, minIdle time.Duration, start string, count int64) ([]redis.XMessage, string, error) {
+	return r.Primary.XAutoClaim(ctx, stream, group, consumer, minIdle, start, count)
+}
+
+func (r *Replicated) GeoAdd(ctx context.Context, key string, locations ...*redis.GeoLocation) (int64, error) {
+	return r.Primary.GeoAdd(ctx, key, locations...)
+}
+
+func (r *Replicated) BLPop(ctx context.Context, timeout time.Duration, keys ...string) ([]string, error) {
+	return r.Primary.BLPop(ctx, timeout, keys...)
+}
+
+func (r *Replicated) BRPop(ctx context.Context, timeout time.Duration, keys ...string) ([]string, error) {
+	return r.Primary.BRPop(ctx, timeout, keys...)
+}
+
+func (r *Replicated) LMove(ctx context.Context, source, destination, from, to string) (string, error) {
+	return r.Primary.LMove(ctx, source, destination, from, to)
+}
+
+func (r *Replicated) BLMove(ctx context.Context, source, destination, from, to string, timeout time.Duration) (string, error) {
+	return r.Primary.BLMove(ctx, source, destination, from, to, timeout)
+}
+
+func (r *Replicated) LRem(ctx context.Context, key string, count int64, value interface{}) (int64, error) {
+	return r.Primary.LRem(ctx, key, count, value)
+}
+
+func (r *Replicated) Pipeline(ctx context.Context, ops []*PipelineOp) error {
+	return r.Primary.Pipeline(ctx, ops)
+}
+
+func (r *Replicated) Watch(ctx context.Context, fn TxFunc, keys ...string) error {
+	return r.Primary.Watch(ctx, fn, keys...)
+}
+
+func (r *Replicated) SubscribeKeyEvents(ctx context.Context, handler func(KeyEvent)) (io.Closer, error) {
+	return r.Primary.SubscribeKeyEvents(ctx, handler)
+}
+
+func (r *Replicated) SetBits(ctx context.Context, key string, offsets ...int64) (int64, error) {
+	return r.Primary.SetBits(ctx, key, offsets...)
+}
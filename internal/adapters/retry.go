@@ -0,0 +1,151 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"strings"
+	"time"
+)
+
+// RetryPolicy controls how often and how fast failed operations are retried.
+// The delay before attempt n is chosen at random between zero and
+// BaseDelay*2^n, capped at MaxDelay, so retrying clients do not synchronize.
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// backoff returns the delay before the given retry, counting from zero
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	limit := p.BaseDelay << min(retry, 30)
+	if p.MaxDelay > 0 && (limit <= 0 || limit > p.MaxDelay) {
+		limit = p.MaxDelay
+	}
+	if limit <= 0 {
+		return 0
+	}
+	return rand.N(limit)
+}
+
+// Retrying is a Cache that bounds every backend call by Timeout and retries
+// calls failing with a transient error according to Policy
+type Retrying struct {
+	Cache   Cache
+	Timeout time.Duration
+	Policy  RetryPolicy
+}
+
+// NewRetrying wraps cache with per operation timeouts and retries. A zero
+// timeout leaves calls unbounded and a policy with at most one attempt never
+// retries.
+func NewRetrying(cache Cache, timeout time.Duration, policy RetryPolicy) *Retrying {
+	return &Retrying{Cache: cache, Timeout: timeout, Policy: policy}
+}
+
+// do runs operation until it succeeds, fails permanently, runs out of
+// attempts or ctx is done
+func (r *Retrying) do(ctx context.Context, operation func(ctx context.Context) error) error {
+	attempts := max(r.Policy.MaxAttempts, 1)
+	var err error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			timer := time.NewTimer(r.Policy.backoff(attempt - 1))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return err
+			case <-timer.C:
+			}
+		}
+
+		err = r.attempt(ctx, operation)
+		if err == nil || !IsTransient(err) || ctx.Err() != nil {
+			return err
+		}
+	}
+	return err
+}
+
+func (r *Retrying) attempt(ctx context.Context, operation func(ctx context.Context) error) error {
+	if r.Timeout <= 0 {
+		return operation(ctx)
+	}
+	ctx, cancel := context.WithTimeout(ctx, r.Timeout)
+	defer cancel()
+	return operation(ctx)
+}
+
+func (r *Retrying) Get(ctx context.Context, key string) (interface{}, error) {
+	var value interface{}
+	err := r.do(ctx, func(ctx context.Context) error {
+		var err error
+		value, err = r.Cache.Get(ctx, key)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+func (r *Retrying) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	return r.do(ctx, func(ctx context.Context) error {
+		return r.Cache.Set(ctx, key, value, expiration)
+	})
+}
+
+func (r *Retrying) Delete(ctx context.Context, keys ...string) error {
+	return r.do(ctx, func(ctx context.Context) error {
+		return r.Cache.Delete(ctx, keys...)
+	})
+}
+
+func (r *Retrying) GetMany(ctx context.Context, keys ...string) (map[string]interface{}, error) {
+	var values map[string]interface{}
+	err := r.do(ctx, func(ctx context.Context) error {
+		var err error
+		values, err = r.Cache.GetMany(ctx, keys...)
+		return err
+	})
+	return values, err
+}
+
+func (r *Retrying) SetMany(ctx context.Context, values map[string]interface{}, expiration time.Duration) error {
+	return r.do(ctx, func(ctx context.Context) error {
+		return r.Cache.SetMany(ctx, values, expiration)
+	})
+}
+
+func (r *Retrying) Close() error {
+	return r.Cache.Close()
+}
+
+// transientPrefixes are Redis error replies that go away on their own, e.g.
+// while a replica loads its dataset or a cluster slot migrates
+var transientPrefixes = []string{"LOADING", "READONLY", "MASTERDOWN", "TRYAGAIN", "CLUSTERDOWN"}
+
+// IsTransient reports whether err is worth retrying: timeouts, dropped
+// connections and Redis replies that signal a temporary condition. Misses and
+// wrong type errors are permanent.
+func IsTransient(err error) bool {
+	if err == nil || errors.Is(err, ErrCircuitOpen) {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	for _, prefix := range transientPrefixes {
+		if strings.HasPrefix(err.Error(), prefix) {
+			return true
+		}
+	}
+	return false
+}
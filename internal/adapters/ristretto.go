@@ -0,0 +1,144 @@
+package adapters
+
+import (
+	"context"
+	"github.com/dgraph-io/ristretto/v2"
+	"hash/maphash"
+	"sync"
+	"time"
+)
+
+// ristrettoItem is what a RistrettoStore keeps in ristretto, the key is stored
+// too so evictions can be removed from the key index
+type ristrettoItem struct {
+	key  string
+	data []byte
+}
+
+// RistrettoStore is a Store over a ristretto cache, which admits new keys by
+// their estimated frequency (TinyLFU) and evicts by cost once maxCost is
+// reached. The cost of an entry is its serialized size, so maxCost is a bound
+// in bytes. Like any full cache it may drop keys that were just written.
+type RistrettoStore struct {
+	cache *ristretto.Cache[string, *ristrettoItem]
+	seed  maphash.Seed
+	locks [64]sync.Mutex
+
+	// keys indexes the stored items, as ristretto cannot be iterated
+	mutex sync.Mutex
+	keys  map[string]*ristrettoItem
+}
+
+// NewRistrettoStore creates a RistrettoStore holding up to maxCost bytes. The
+// admission counters are sized for entries of about 100 bytes.
+func NewRistrettoStore(maxCost int64) (*RistrettoStore, error) {
+	r := &RistrettoStore{seed: maphash.MakeSeed(), keys: make(map[string]*ristrettoItem)}
+	cache, err := ristretto.NewCache(&ristretto.Config[string, *ristrettoItem]{
+		NumCounters:        max(maxCost/10, 1000),
+		MaxCost:            maxCost,
+		BufferItems:        64,
+		IgnoreInternalCost: true,
+		OnEvict:            func(item *ristretto.Item[*ristrettoItem]) { r.forget(item.Value) },
+		OnReject:           func(item *ristretto.Item[*ristrettoItem]) { r.forget(item.Value) },
+	})
+	if err != nil {
+		return nil, err
+	}
+	r.cache = cache
+	return r, nil
+}
+
+// NewRistretto returns an in-process CacheServer bounded to maxCost bytes of
+// serialized entries. ristretto expires keys itself, so no janitor runs.
+func NewRistretto(maxCost int64, opts ...MemoryOption) (*Memory, error) {
+	store, err := NewRistrettoStore(maxCost)
+	if err != nil {
+		return nil, err
+	}
+	opts = append([]MemoryOption{WithCleanupInterval(0)}, opts...)
+	return NewStoreServer(store, opts...), nil
+}
+
+func (r *RistrettoStore) Name() string {
+	return "ristretto"
+}
+
+// Update serializes the updates of a key with a striped lock
+func (r *RistrettoStore) Update(ctx context.Context, key string, fn func(current []byte) ([]byte, bool)) error {
+	lock := &r.locks[maphash.String(r.seed, key)%uint64(len(r.locks))]
+	lock.Lock()
+	defer lock.Unlock()
+
+	var current []byte
+	if item, ok := r.cache.Get(key); ok {
+		current = item.data
+	}
+	next, write := fn(current)
+	if !write {
+		return nil
+	}
+	var ttl time.Duration
+	expired := false
+	if expiresAt := entryExpiresAt(next); !expiresAt.IsZero() {
+		ttl = time.Until(expiresAt)
+		expired = ttl <= 0
+	}
+	if next == nil || expired {
+		r.cache.Del(key)
+		r.mutex.Lock()
+		delete(r.keys, key)
+		r.mutex.Unlock()
+		return nil
+	}
+	item := &ristrettoItem{key: key, data: next}
+	r.mutex.Lock()
+	r.keys[key] = item
+	r.mutex.Unlock()
+	r.cache.SetWithTTL(key, item, int64(len(key)+len(next)), ttl)
+	if current == nil {
+		// Updates are visible at once but new keys only once ristretto has
+		// admitted them, wait so the next read of key sees it
+		r.cache.Wait()
+	}
+	return nil
+}
+
+// forget removes an evicted or rejected item from the index, unless key was
+// written again in the meantime
+func (r *RistrettoStore) forget(item *ristrettoItem) {
+	if item == nil {
+		return
+	}
+	r.mutex.Lock()
+	if r.keys[item.key] == item {
+		delete(r.keys, item.key)
+	}
+	r.mutex.Unlock()
+}
+
+// Range visits the indexed keys still in the cache, dropping the others
+func (r *RistrettoStore) Range(ctx context.Context, fn func(key string, data []byte) bool) error {
+	r.mutex.Lock()
+	indexed := make([]*ristrettoItem, 0, len(r.keys))
+	for _, item := range r.keys {
+		indexed = append(indexed, item)
+	}
+	r.mutex.Unlock()
+
+	for _, item := range indexed {
+		stored, ok := r.cache.Get(item.key)
+		if !ok {
+			r.forget(item)
+			continue
+		}
+		if !fn(item.key, stored.data) {
+			return nil
+		}
+	}
+	return nil
+}
+
+func (r *RistrettoStore) Close() error {
+	r.cache.Close()
+	return nil
+}
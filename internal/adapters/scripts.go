@@ -0,0 +1,155 @@
+package adapters
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrUnknownScript is returned when running a script that was not registered
+var ErrUnknownScript = errors.New("unknown script")
+
+// Script is a Lua script run on Redis by its SHA1 digest. It is loaded with
+// SCRIPT LOAD the first time it runs, and again whenever the server answers
+// NOSCRIPT because its script cache was flushed or it failed over to a replica
+// that never saw the script, so the source is only sent when needed.
+type Script struct {
+	name   string
+	source string
+	hash   string
+	loaded atomic.Bool
+}
+
+// NewScript creates a Script that is not part of any registry
+func NewScript(name, source string) *Script {
+	sum := sha1.Sum([]byte(source))
+	return &Script{name: name, source: source, hash: hex.EncodeToString(sum[:])}
+}
+
+func (s *Script) Name() string {
+	return s.name
+}
+
+// Hash returns the SHA1 digest Redis knows the script by
+func (s *Script) Hash() string {
+	return s.hash
+}
+
+// Load sends the script to the server, or to every master of a cluster
+func (s *Script) Load(ctx context.Context, client redis.Scripter) error {
+	if err := client.ScriptLoad(ctx, s.source).Err(); err != nil {
+		return err
+	}
+	s.loaded.Store(true)
+	return nil
+}
+
+// Run runs the script with keys and args, loading it first if needed
+func (s *Script) Run(ctx context.Context, client redis.Scripter, keys []string, args ...interface{}) *redis.Cmd {
+	if !s.loaded.Load() {
+		if err := s.Load(ctx, client); err != nil {
+			return failedCmd(ctx, err)
+		}
+	}
+	cmd := client.EvalSha(ctx, s.hash, keys, args...)
+	if !isNoScript(cmd.Err()) {
+		return cmd
+	}
+	s.loaded.Store(false)
+	if err := s.Load(ctx, client); err != nil {
+		return failedCmd(ctx, err)
+	}
+	return client.EvalSha(ctx, s.hash, keys, args...)
+}
+
+func isNoScript(err error) bool {
+	return err != nil && strings.HasPrefix(err.Error(), "NOSCRIPT")
+}
+
+func failedCmd(ctx context.Context, err error) *redis.Cmd {
+	cmd := redis.NewCmd(ctx)
+	cmd.SetErr(err)
+	return cmd
+}
+
+// Scripts is a registry of named Lua scripts, so a script is defined once and
+// run by name wherever it is needed
+type Scripts struct {
+	mutex   sync.RWMutex
+	scripts map[string]*Script
+}
+
+// DefaultScripts holds the scripts of the Redis adapter, and those registered
+// by applications with Register
+var DefaultScripts = NewScripts()
+
+// NewScripts creates an empty registry
+func NewScripts() *Scripts {
+	return &Scripts{scripts: make(map[string]*Script)}
+}
+
+// Register adds the script called name and returns it. Registering a name
+// twice with different sources panics, as both callers expect their own.
+func (s *Scripts) Register(name, source string) *Script {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if existing, ok := s.scripts[name]; ok {
+		if existing.source != source {
+			panic(fmt.Sprintf("cacher: script %q registered twice", name))
+		}
+		return existing
+	}
+	script := NewScript(name, source)
+	s.scripts[name] = script
+	return script
+}
+
+// Get returns the script called name
+func (s *Scripts) Get(name string) (*Script, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	script, ok := s.scripts[name]
+	return script, ok
+}
+
+// Run runs the script called name, ErrUnknownScript if there is none
+func (s *Scripts) Run(ctx context.Context, client redis.Scripter, name string, keys []string, args ...interface{}) *redis.Cmd {
+	script, ok := s.Get(name)
+	if !ok {
+		return failedCmd(ctx, fmt.Errorf("%w: %s", ErrUnknownScript, name))
+	}
+	return script.Run(ctx, client, keys, args...)
+}
+
+// Load sends every script to the server, e.g. at startup so none is loaded
+// while serving
+func (s *Scripts) Load(ctx context.Context, client redis.Scripter) error {
+	s.mutex.RLock()
+	scripts := make([]*Script, 0, len(s.scripts))
+	for _, script := range s.scripts {
+		scripts = append(scripts, script)
+	}
+	s.mutex.RUnlock()
+	for _, script := range scripts {
+		if err := script.Load(ctx, client); err != nil {
+			return fmt.Errorf("loading script %s: %w", script.name, err)
+		}
+	}
+	return nil
+}
+
+// LoadScripts sends every script of DefaultScripts to the server
+func (r *RedisClient) LoadScripts(ctx context.Context) error {
+	return DefaultScripts.Load(ctx, r.Client)
+}
+
+// RunScript runs the script of DefaultScripts called name
+func (r *RedisClient) RunScript(ctx context.Context, name string, keys []string, args ...interface{}) (interface{}, error) {
+	return DefaultScripts.Run(ctx, r.Client, name, keys, args...).Result()
+}
@@ -0,0 +1,86 @@
+package adapters
+
+import "context"
+
+// SRem removes members from the set stored at key and returns how many existed
+func (r *RedisClient) SRem(ctx context.Context, key string, members ...interface{}) (int64, error) {
+	return r.Client.SRem(ctx, key, members...).Result()
+}
+
+// SIsMember reports whether member is in the set stored at key
+func (r *RedisClient) SIsMember(ctx context.Context, key string, member interface{}) (bool, error) {
+	return r.Client.SIsMember(ctx, key, member).Result()
+}
+
+// SCard returns the number of members of the set stored at key
+func (r *RedisClient) SCard(ctx context.Context, key string) (int64, error) {
+	return r.Client.SCard(ctx, key).Result()
+}
+
+// SRem removes members from the set stored at key and returns how many
+// existed. Like Redis, a set left without members is deleted.
+func (m *Memory) SRem(ctx context.Context, key string, members ...interface{}) (int64, error) {
+	var removed int64
+	err := m.update(ctx, key, func(s *memoryShard, e *memoryEntry) error {
+		removed = 0
+		if e == nil {
+			return nil
+		}
+		if e.kind != memorySet {
+			return ErrWrongType
+		}
+		for _, member := range members {
+			str, err := formatValue(member)
+			if err != nil {
+				return err
+			}
+			if _, exists := e.set[str]; exists {
+				delete(e.set, str)
+				removed++
+			}
+		}
+		if len(e.set) == 0 {
+			delete(s.items, key)
+		}
+		return nil
+	})
+	return removed, err
+}
+
+// SIsMember reports whether member is in the set stored at key
+func (m *Memory) SIsMember(ctx context.Context, key string, member interface{}) (bool, error) {
+	str, err := formatValue(member)
+	if err != nil {
+		return false, err
+	}
+	found := false
+	err = m.update(ctx, key, func(s *memoryShard, e *memoryEntry) error {
+		found = false
+		if e == nil {
+			return nil
+		}
+		if e.kind != memorySet {
+			return ErrWrongType
+		}
+		_, found = e.set[str]
+		return nil
+	})
+	return found, err
+}
+
+// SCard returns the number of members of the set stored at key
+func (m *Memory) SCard(ctx context.Context, key string) (int64, error) {
+	var count int64
+	err := m.update(ctx, key, func(s *memoryShard, e *memoryEntry) error {
+		count = 0
+		if e == nil {
+			return nil
+		}
+		if e.kind != memorySet {
+			return ErrWrongType
+		}
+		count = int64(len(e.set))
+		return nil
+	})
+	return count, err
+}
@@ -0,0 +1,899 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"hash/fnv"
+	"io"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultVirtualNodes is how many points each shard of a Sharded gets on the
+// hash ring, enough to spread keys within a few percent of evenly
+const DefaultVirtualNodes = 160
+
+// ErrCrossShard is returned by Sharded for an operation on several keys that
+// are stored on different shards. Keys sharing a hash tag, the part between
+// the first { and the following }, are always stored together.
+var ErrCrossShard = errors.New("keys of the operation are on different shards")
+
+// shardCursorBits is how much of a Sharded Scan cursor is the cursor of the
+// shard being scanned, the rest is the position of that shard
+const shardCursorBits = 48
+
+// Sharded is a CacheServer spreading keys over independent servers, e.g.
+// several Redis instances, with a consistent hash ring so that adding or
+// removing a shard only moves the keys that belong to it. Keys are hashed
+// like Redis Cluster does, by their hash tag if they have one.
+//
+// After AddShard or RemoveShard, Get still finds string values where the
+// previous ring put them and moves them to their new shard, until Rebalance
+// moved every key and forgets the previous rings.
+type Sharded struct {
+	mutex    sync.RWMutex
+	nodes    int
+	shards   map[string]CacheServer
+	ring     hashRing
+	previous []hashRing
+	retired  map[string]CacheServer
+}
+
+var _ CacheServer = (*Sharded)(nil)
+
+// NewSharded spreads keys over shards by their name, which must stay the same
+// across restarts and instances for keys to be found again, so use e.g. the
+// address of each server rather than its position in a list. virtualNodes is
+// the number of points of each shard on the ring, DefaultVirtualNodes when 0.
+// At least one shard is needed.
+func NewSharded(virtualNodes int, shards map[string]CacheServer) *Sharded {
+	if virtualNodes <= 0 {
+		virtualNodes = DefaultVirtualNodes
+	}
+	s := &Sharded{
+		nodes:   virtualNodes,
+		shards:  make(map[string]CacheServer, len(shards)),
+		retired: make(map[string]CacheServer),
+	}
+	for name, server := range shards {
+		s.shards[name] = server
+	}
+	s.ring = newHashRing(s.shards, s.nodes)
+	return s
+}
+
+// hashRing maps hashes to the shard owning the next point clockwise
+type hashRing []ringPoint
+
+type ringPoint struct {
+	hash  uint64
+	shard string
+}
+
+func newHashRing(shards map[string]CacheServer, nodes int) hashRing {
+	ring := make(hashRing, 0, len(shards)*nodes)
+	for name := range shards {
+		for i := range nodes {
+			ring = append(ring, ringPoint{hash: hashKey(name + "#" + strconv.Itoa(i)), shard: name})
+		}
+	}
+	sort.Slice(ring, func(i, j int) bool {
+		if ring[i].hash == ring[j].hash {
+			return ring[i].shard < ring[j].shard
+		}
+		return ring[i].hash < ring[j].hash
+	})
+	return ring
+}
+
+// locate returns the name of the shard owning key
+func (r hashRing) locate(key string) string {
+	if len(r) == 0 {
+		return ""
+	}
+	hash := hashKey(hashTag(key))
+	i := sort.Search(len(r), func(i int) bool { return r[i].hash >= hash })
+	if i == len(r) {
+		i = 0
+	}
+	return r[i].shard
+}
+
+func hashKey(key string) uint64 {
+	hash := fnv.New64a()
+	_, _ = hash.Write([]byte(key))
+	// FNV barely mixes the last bytes, which are all that differs between
+	// the virtual nodes of a shard
+	h := hash.Sum64()
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	return h
+}
+
+// hashTag returns the part of key that decides its shard: the text between
+// the first { and the next } when it is not empty, and the whole key otherwise
+func hashTag(key string) string {
+	if start := strings.IndexByte(key, '{'); start >= 0 {
+		if end := strings.IndexByte(key[start+1:], '}'); end > 0 {
+			return key[start+1 : start+1+end]
+		}
+	}
+	return key
+}
+
+// Locate returns the name of the shard key is stored on
+func (s *Sharded) Locate(key string) string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.ring.locate(key)
+}
+
+// Shards returns the shards by name
+func (s *Sharded) Shards() map[string]CacheServer {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	shards := make(map[string]CacheServer, len(s.shards))
+	for name, server := range s.shards {
+		shards[name] = server
+	}
+	return shards
+}
+
+// AddShard adds server to the ring under name, taking over about a 1/n share
+// of the keys from the other shards
+func (s *Sharded) AddShard(name string, server CacheServer) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.previous = append(s.previous, s.ring)
+	s.shards[name] = server
+	delete(s.retired, name)
+	s.ring = newHashRing(s.shards, s.nodes)
+}
+
+// RemoveShard takes the shard called name off the ring, its keys are spread
+// over the other shards. The server is still read from and closed by
+// Rebalance or Close. It reports whether the shard existed.
+func (s *Sharded) RemoveShard(name string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	server, ok := s.shards[name]
+	if !ok || len(s.shards) == 1 {
+		return false
+	}
+	s.previous = append(s.previous, s.ring)
+	s.retired[name] = server
+	delete(s.shards, name)
+	s.ring = newHashRing(s.shards, s.nodes)
+	return true
+}
+
+// Rebalance moves every string value that is not on the shard the ring puts
+// it on to that shard, keeping its expiration, and then closes the removed
+// shards. Other kinds of values, such as lists and sets, cannot be moved and
+// are left where they are. It returns how many keys were moved.
+func (s *Sharded) Rebalance(ctx context.Context) (int64, error) {
+	s.mutex.RLock()
+	servers := s.all()
+	s.mutex.RUnlock()
+
+	var moved int64
+	for _, named := range servers {
+		var cursor uint64
+		for {
+			keys, next, err := named.server.Scan(ctx, cursor, "*", 100)
+			if err != nil {
+				return moved, err
+			}
+			for _, key := range keys {
+				owner := s.shard(key)
+				if owner == named.server {
+					continue
+				}
+				ok, err := moveKey(ctx, key, named.server, owner)
+				if err != nil {
+					return moved, err
+				}
+				if ok {
+					moved++
+				}
+			}
+			if next == 0 {
+				break
+			}
+			cursor = next
+		}
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.previous = nil
+	var errs []error
+	for name, server := range s.retired {
+		errs = append(errs, server.Close())
+		delete(s.retired, name)
+	}
+	return moved, errors.Join(errs...)
+}
+
+// moveKey moves the string value at key from one server to another unless
+// the destination has a newer one
+func moveKey(ctx context.Context, key string, from, to CacheServer) (bool, error) {
+	value, err := from.Get(ctx, key)
+	switch {
+	case errors.Is(err, redis.Nil) || err != nil && isWrongType(err):
+		return false, nil
+	case err != nil:
+		return false, err
+	}
+	ttl, err := from.TTL(ctx, key)
+	switch {
+	case errors.Is(err, redis.Nil):
+		// Expired in the meantime
+		return false, nil
+	case err != nil:
+		return false, err
+	case ttl == NoExpiration:
+		ttl = 0
+	}
+	stored, err := to.SetNX(ctx, key, value, ttl)
+	if err != nil {
+		return false, err
+	}
+	if _, err := from.Delete(ctx, key); err != nil {
+		return false, err
+	}
+	return stored, nil
+}
+
+func isWrongType(err error) bool {
+	return errors.Is(err, ErrWrongType) || strings.HasPrefix(err.Error(), "WRONGTYPE")
+}
+
+type namedServer struct {
+	name   string
+	server CacheServer
+}
+
+// all returns the shards on the ring and the removed ones, ordered by name
+func (s *Sharded) all() []namedServer {
+	servers := make([]namedServer, 0, len(s.shards)+len(s.retired))
+	for name, server := range s.shards {
+		servers = append(servers, namedServer{name, server})
+	}
+	for name, server := range s.retired {
+		servers = append(servers, namedServer{name, server})
+	}
+	slices.SortFunc(servers, func(a, b namedServer) int { return strings.Compare(a.name, b.name) })
+	return servers
+}
+
+// shard returns the server owning key
+func (s *Sharded) shard(key string) CacheServer {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.shards[s.ring.locate(key)]
+}
+
+// formerShards returns the servers previous rings put key on, newest first,
+// other than its current one
+func (s *Sharded) formerShards(key string) []CacheServer {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	if len(s.previous) == 0 {
+		return nil
+	}
+	current := s.ring.locate(key)
+	var former []CacheServer
+	for i := len(s.previous) - 1; i >= 0; i-- {
+		name := s.previous[i].locate(key)
+		if name == current {
+			continue
+		}
+		server, ok := s.shards[name]
+		if !ok {
+			server = s.retired[name]
+		}
+		if server != nil && !slices.Contains(former, server) {
+			former = append(former, server)
+		}
+	}
+	return former
+}
+
+// relocate looks for key on the shards it was on before the last ring
+// changes and moves it to its shard when found
+func (s *Sharded) relocate(ctx context.Context, key string) (string, error) {
+	former := s.formerShards(key)
+	if len(former) == 0 {
+		return "", redis.Nil
+	}
+	for _, former := range former {
+		if _, err := moveKey(ctx, key, former, s.shard(key)); err != nil {
+			return "", err
+		}
+	}
+	return s.shard(key).Get(ctx, key)
+}
+
+// group returns the server owning every one of keys, or ErrCrossShard
+func (s *Sharded) group(keys ...string) (CacheServer, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	name := s.ring.locate("")
+	for i, key := range keys {
+		owner := s.ring.locate(key)
+		if i > 0 && owner != name {
+			return nil, ErrCrossShard
+		}
+		name = owner
+	}
+	return s.shards[name], nil
+}
+
+// split groups the positions of keys by the server owning them
+func (s *Sharded) split(keys []string) map[CacheServer][]int {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	groups := make(map[CacheServer][]int)
+	for i, key := range keys {
+		server := s.shards[s.ring.locate(key)]
+		groups[server] = append(groups[server], i)
+	}
+	return groups
+}
+
+// fanOut runs fn for every group on its own goroutine and joins the errors
+func fanOut[T any](groups map[CacheServer]T, fn func(server CacheServer, group T) error) error {
+	var wg sync.WaitGroup
+	errs := make(chan error, len(groups))
+	for server, group := range groups {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- fn(server, group)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	var joined []error
+	for err := range errs {
+		joined = append(joined, err)
+	}
+	return errors.Join(joined...)
+}
+
+func pick(keys []string, positions []int) []string {
+	picked := make([]string, len(positions))
+	for i, position := range positions {
+		picked[i] = keys[position]
+	}
+	return picked
+}
+
+// Get finds keys moved by a change of the shards on their former shard
+func (s *Sharded) Get(ctx context.Context, key string) (string, error) {
+	value, err := s.shard(key).Get(ctx, key)
+	if errors.Is(err, redis.Nil) {
+		return s.relocate(ctx, key)
+	}
+	return value, err
+}
+
+func (s *Sharded) Delete(ctx context.Context, keys ...string) (int64, error) {
+	return s.deleteWith(ctx, keys, CacheServer.Delete)
+}
+
+// Unlink deletes keys with the Unlink of each shard, or its Delete
+func (s *Sharded) Unlink(ctx context.Context, keys ...string) (int64, error) {
+	return s.deleteWith(ctx, keys, func(server CacheServer, ctx context.Context, keys ...string) (int64, error) {
+		if u, ok := server.(unlinker); ok {
+			return u.Unlink(ctx, keys...)
+		}
+		return server.Delete(ctx, keys...)
+	})
+}
+
+func (s *Sharded) deleteWith(ctx context.Context, keys []string, del func(CacheServer, context.Context, ...string) (int64, error)) (int64, error) {
+	var mutex sync.Mutex
+	var deleted int64
+	err := fanOut(s.split(keys), func(server CacheServer, positions []int) error {
+		n, err := del(server, ctx, pick(keys, positions)...)
+		mutex.Lock()
+		deleted += n
+		mutex.Unlock()
+		return err
+	})
+	// Keys not moved yet must not come back after a Rebalance
+	for _, key := range keys {
+		for _, former := range s.formerShards(key) {
+			n, _ := del(former, ctx, key)
+			deleted += n
+		}
+	}
+	return deleted, err
+}
+
+// Scan visits the shards one after another, ordered by name. The top 16 bits
+// of the cursor hold the position of the shard, so a scan may skip or repeat
+// keys when the shards change while it runs.
+func (s *Sharded) Scan(ctx context.Context, cursor uint64, match string, count int64) ([]string, uint64, error) {
+	s.mutex.RLock()
+	servers := s.all()
+	s.mutex.RUnlock()
+
+	position := int(cursor >> shardCursorBits)
+	if position >= len(servers) {
+		return nil, 0, nil
+	}
+	keys, next, err := servers[position].server.Scan(ctx, cursor&(1<<shardCursorBits-1), match, count)
+	if err != nil {
+		return nil, 0, err
+	}
+	if next == 0 {
+		position++
+		if position == len(servers) {
+			return keys, 0, nil
+		}
+	}
+	return keys, uint64(position)<<shardCursorBits | next, nil
+}
+
+func (s *Sharded) MGet(ctx context.Context, keys ...string) ([]interface{}, error) {
+	values := make([]interface{}, len(keys))
+	err := fanOut(s.split(keys), func(server CacheServer, positions []int) error {
+		found, err := server.MGet(ctx, pick(keys, positions)...)
+		if err != nil {
+			return err
+		}
+		for i, position := range positions {
+			values[position] = found[i]
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i, value := range values {
+		if value != nil {
+			continue
+		}
+		if relocated, err := s.relocate(ctx, keys[i]); err == nil {
+			values[i] = relocated
+		}
+	}
+	return values, nil
+}
+
+func (s *Sharded) MSet(ctx context.Context, values map[string]interface{}, expiration time.Duration) error {
+	groups := make(map[CacheServer]map[string]interface{})
+	for key, value := range values {
+		server := s.shard(key)
+		if groups[server] == nil {
+			groups[server] = make(map[string]interface{})
+		}
+		groups[server][key] = value
+	}
+	return fanOut(groups, func(server CacheServer, values map[string]interface{}) error {
+		return server.MSet(ctx, values, expiration)
+	})
+}
+
+func (s *Sharded) PFCount(ctx context.Context, keys ...string) (int64, error) {
+	server, err := s.group(keys...)
+	if err != nil {
+		return 0, err
+	}
+	return server.PFCount(ctx, keys...)
+}
+
+func (s *Sharded) PFMerge(ctx context.Context, dest string, keys ...string) error {
+	server, err := s.group(append([]string{dest}, keys...)...)
+	if err != nil {
+		return err
+	}
+	return server.PFMerge(ctx, dest, keys...)
+}
+
+func (s *Sharded) BitOp(ctx context.Context, op, dest string, keys ...string) (int64, error) {
+	server, err := s.group(append([]string{dest}, keys...)...)
+	if err != nil {
+		return 0, err
+	}
+	return server.BitOp(ctx, op, dest, keys...)
+}
+
+func (s *Sharded) BLPop(ctx context.Context, timeout time.Duration, keys ...string) ([]string, error) {
+	server, err := s.group(keys...)
+	if err != nil {
+		return nil, err
+	}
+	return server.BLPop(ctx, timeout, keys...)
+}
+
+func (s *Sharded) BRPop(ctx context.Context, timeout time.Duration, keys ...string) ([]string, error) {
+	server, err := s.group(keys...)
+	if err != nil {
+		return nil, err
+	}
+	return server.BRPop(ctx, timeout, keys...)
+}
+
+func (s *Sharded) LMove(ctx context.Context, source, destination, from, to string) (string, error) {
+	server, err := s.group(source, destination)
+	if err != nil {
+		return "", err
+	}
+	return server.LMove(ctx, source, destination, from, to)
+}
+
+func (s *Sharded) BLMove(ctx context.Context, source, destination, from, to string, timeout time.Duration) (string, error) {
+	server, err := s.group(source, destination)
+	if err != nil {
+		return "", err
+	}
+	return server.BLMove(ctx, source, destination, from, to, timeout)
+}
+
+func (s *Sharded) ZMoveToList(ctx context.Context, source, destination string, max float64, count int64) (int64, error) {
+	server, err := s.group(source, destination)
+	if err != nil {
+		return 0, err
+	}
+	return server.ZMoveToList(ctx, source, destination, max, count)
+}
+
+// Pipeline sends the ops of each shard in one round trip to that shard, all
+// shards at once. A Delete of keys on several shards is split up.
+func (s *Sharded) Pipeline(ctx context.Context, ops []*PipelineOp) error {
+	groups := make(map[CacheServer][]*PipelineOp)
+	type part struct {
+		op    *PipelineOp
+		parts []*PipelineOp
+	}
+	var split []part
+	for _, op := range ops {
+		if server, err := s.group(op.Keys...); err == nil {
+			groups[server] = append(groups[server], op)
+			continue
+		}
+		if op.Command != PipelineDelete {
+			op.Err = ErrCrossShard
+			continue
+		}
+		deletes := part{op: op}
+		for server, positions := range s.split(op.Keys) {
+			forwarded := *op
+			forwarded.Keys = pick(op.Keys, positions)
+			groups[server] = append(groups[server], &forwarded)
+			deletes.parts = append(deletes.parts, &forwarded)
+		}
+		split = append(split, deletes)
+	}
+	err := fanOut(groups, func(server CacheServer, ops []*PipelineOp) error {
+		return server.Pipeline(ctx, ops)
+	})
+	for _, deletes := range split {
+		var deleted int64
+		var errs []error
+		for _, forwarded := range deletes.parts {
+			n, _ := forwarded.Result.(int64)
+			deleted += n
+			errs = append(errs, forwarded.Err)
+		}
+		deletes.op.Result, deletes.op.Err = deleted, errors.Join(errs...)
+	}
+	return err
+}
+
+// Watch runs the transaction on the shard of keys, which must all be on one
+// shard together with the keys of the writes fn returns
+func (s *Sharded) Watch(ctx context.Context, fn TxFunc, keys ...string) error {
+	server, err := s.group(keys...)
+	if err != nil {
+		return err
+	}
+	return server.Watch(ctx, func(tx TxReader) ([]*PipelineOp, error) {
+		ops, err := fn(tx)
+		for _, op := range ops {
+			if owner, groupErr := s.group(op.Keys...); groupErr != nil || owner != server {
+				return nil, ErrCrossShard
+			}
+		}
+		return ops, err
+	}, keys...)
+}
+
+// SubscribeKeyEvents reports the events of every shard
+func (s *Sharded) SubscribeKeyEvents(ctx context.Context, handler func(KeyEvent)) (io.Closer, error) {
+	var subscriptions closers
+	for _, server := range s.Shards() {
+		subscription, err := server.SubscribeKeyEvents(ctx, handler)
+		if err != nil {
+			_ = subscriptions.Close()
+			return nil, err
+		}
+		subscriptions = append(subscriptions, subscription)
+	}
+	return subscriptions, nil
+}
+
+// Ping checks that every shard answers
+func (s *Sharded) Ping(ctx context.Context) error {
+	return fanOut(s.servers(), func(server CacheServer, _ struct{}) error {
+		return Ping(ctx, NewCache(server))
+	})
+}
+
+func (s *Sharded) servers() map[CacheServer]struct{} {
+	servers := make(map[CacheServer]struct{})
+	for _, server := range s.Shards() {
+		servers[server] = struct{}{}
+	}
+	return servers
+}
+
+// Close closes every shard, including removed ones not closed by Rebalance
+func (s *Sharded) Close() error {
+	s.mutex.RLock()
+	servers := s.all()
+	s.mutex.RUnlock()
+	var errs []error
+	for _, named := range servers {
+		errs = append(errs, named.server.Close())
+	}
+	return errors.Join(errs...)
+}
+
+func (s *Sharded) Incr(ctx context.Context, key string) (int64, error) {
+	return s.shard(key).Incr(ctx, key)
+}
+
+func (s *Sharded) Decr(ctx context.Context, key string) (int64, error) {
+	return s.shard(key).Decr(ctx, key)
+}
+
+func (s *Sharded) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	return s.shard(key).Set(ctx, key, value, expiration)
+}
+
+func (s *Sharded) Remember(ctx context.Context, key string, expiration time.Duration, value func() interface{}) (interface{}, error) {
+	return s.shard(key).Remember(ctx, key, expiration, value)
+}
+
+func (s *Sharded) GetDel(ctx context.Context, key string) (string, error) {
+	return s.shard(key).GetDel(ctx, key)
+}
+
+func (s *Sharded) GetSet(ctx context.Context, key string, value interface{}, expiration time.Duration) (string, error) {
+	return s.shard(key).GetSet(ctx, key, value, expiration)
+}
+
+func (s *Sharded) Pop(ctx context.Context, key string) (string, error) {
+	return s.shard(key).Pop(ctx, key)
+}
+
+func (s *Sharded) Push(ctx context.Context, key string, values ...interface{}) error {
+	return s.shard(key).Push(ctx, key, values...)
+}
+
+func (s *Sharded) List(ctx context.Context, key string) ([]string, error) {
+	return s.shard(key).List(ctx, key)
+}
+
+func (s *Sharded) SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) (bool, error) {
+	return s.shard(key).SetNX(ctx, key, value, expiration)
+}
+
+func (s *Sharded) DecrBy(ctx context.Context, key string, decrement int64) (int64, error) {
+	return s.shard(key).DecrBy(ctx, key, decrement)
+}
+
+func (s *Sharded) IncrBy(ctx context.Context, key string, delta int64, expiration time.Duration) (int64, error) {
+	return s.shard(key).IncrBy(ctx, key, delta, expiration)
+}
+
+func (s *Sharded) IncrByFloat(ctx context.Context, key string, delta float64, expiration time.Duration) (float64, error) {
+	return s.shard(key).IncrByFloat(ctx, key, delta, expiration)
+}
+
+func (s *Sharded) Expire(ctx context.Context, key string, expiration time.Duration) (bool, error) {
+	return s.shard(key).Expire(ctx, key, expiration)
+}
+
+func (s *Sharded) Persist(ctx context.Context, key string) (bool, error) {
+	return s.shard(key).Persist(ctx, key)
+}
+
+func (s *Sharded) ExpireXX(ctx context.Context, key string, expiration time.Duration) (bool, error) {
+	return s.shard(key).ExpireXX(ctx, key, expiration)
+}
+
+func (s *Sharded) Exists(ctx context.Context, key string) (bool, error) {
+	return s.shard(key).Exists(ctx, key)
+}
+
+func (s *Sharded) TTL(ctx context.Context, key string) (time.Duration, error) {
+	return s.shard(key).TTL(ctx, key)
+}
+
+func (s *Sharded) SAdd(ctx context.Context, key string, members ...interface{}) (int64, error) {
+	return s.shard(key).SAdd(ctx, key, members...)
+}
+
+func (s *Sharded) SMembers(ctx context.Context, key string) ([]string, error) {
+	return s.shard(key).SMembers(ctx, key)
+}
+
+func (s *Sharded) SRem(ctx context.Context, key string, members ...interface{}) (int64, error) {
+	return s.shard(key).SRem(ctx, key, members...)
+}
+
+func (s *Sharded) SIsMember(ctx context.Context, key string, member interface{}) (bool, error) {
+	return s.shard(key).SIsMember(ctx, key, member)
+}
+
+func (s *Sharded) SCard(ctx context.Context, key string) (int64, error) {
+	return s.shard(key).SCard(ctx, key)
+}
+
+func (s *Sharded) ZRem(ctx context.Context, key string, members ...interface{}) (int64, error) {
+	return s.shard(key).ZRem(ctx, key, members...)
+}
+
+func (s *Sharded) CompareAndDelete(ctx context.Context, key string, expected string) (bool, error) {
+	return s.shard(key).CompareAndDelete(ctx, key, expected)
+}
+
+func (s *Sharded) CompareAndExpire(ctx context.Context, key string, expected string, expiration time.Duration) (bool, error) {
+	return s.shard(key).CompareAndExpire(ctx, key, expected, expiration)
+}
+
+func (s *Sharded) CompareAndSwap(ctx context.Context, key string, expected string, value interface{}, expiration time.Duration) (bool, error) {
+	return s.shard(key).CompareAndSwap(ctx, key, expected, value, expiration)
+}
+
+func (s *Sharded) RateLimiter(ctx context.Context, key string, value int, expiration time.Duration) (int64, error) {
+	return s.shard(key).RateLimiter(ctx, key, value, expiration)
+}
+
+func (s *Sharded) CountRateLimiter(ctx context.Context, key string, value int, decrement int, expiration time.Duration) (int64, error) {
+	return s.shard(key).CountRateLimiter(ctx, key, value, decrement, expiration)
+}
+
+func (s *Sharded) TokenBucket(ctx context.Context, key string, rate float64, burst int64) (bool, int64, error) {
+	return s.shard(key).TokenBucket(ctx, key, rate, burst)
+}
+
+func (s *Sharded) GCRA(ctx context.Context, key string, maxBurst, count int64, period time.Duration, quantity int64) (GCRAResult, error) {
+	return s.shard(key).GCRA(ctx, key, maxBurst, count, period, quantity)
+}
+
+func (s *Sharded) AcquireSemaphore(ctx context.Context, key string, holder string, limit int64, ttl time.Duration) (bool, error) {
+	return s.shard(key).AcquireSemaphore(ctx, key, holder, limit, ttl)
+}
+
+func (s *Sharded) HIncrByMany(ctx context.Context, key string, increments map[string]int64) error {
+	return s.shard(key).HIncrByMany(ctx, key, increments)
+}
+
+func (s *Sharded) HGetAll(ctx context.Context, key string) (map[string]string, error) {
+	return s.shard(key).HGetAll(ctx, key)
+}
+
+func (s *Sharded) HSet(ctx context.Context, key string, values map[string]interface{}) (int64, error) {
+	return s.shard(key).HSet(ctx, key, values)
+}
+
+func (s *Sharded) HGet(ctx context.Context, key, field string) (string, error) {
+	return s.shard(key).HGet(ctx, key, field)
+}
+
+func (s *Sharded) HDel(ctx context.Context, key string, fields ...string) (int64, error) {
+	return s.shard(key).HDel(ctx, key, fields...)
+}
+
+func (s *Sharded) HIncrBy(ctx context.Context, key, field string, delta int64) (int64, error) {
+	return s.shard(key).HIncrBy(ctx, key, field, delta)
+}
+
+func (s *Sharded) ZAdd(ctx context.Context, key string, members ...redis.Z) (int64, error) {
+	return s.shard(key).ZAdd(ctx, key, members...)
+}
+
+func (s *Sharded) ZIncrBy(ctx context.Context, key string, increment float64, member string) (float64, error) {
+	return s.shard(key).ZIncrBy(ctx, key, increment, member)
+}
+
+func (s *Sharded) ZScore(ctx context.Context, key, member string) (float64, error) {
+	return s.shard(key).ZScore(ctx, key, member)
+}
+
+func (s *Sharded) ZRank(ctx context.Context, key, member string) (int64, error) {
+	return s.shard(key).ZRank(ctx, key, member)
+}
+
+func (s *Sharded) ZRevRank(ctx context.Context, key, member string) (int64, error) {
+	return s.shard(key).ZRevRank(ctx, key, member)
+}
+
+func (s *Sharded) ZRangeWithScores(ctx context.Context, key string, start, stop int64) ([]redis.Z, error) {
+	return s.shard(key).ZRangeWithScores(ctx, key, start, stop)
+}
+
+func (s *Sharded) ZRevRangeWithScores(ctx context.Context, key string, start, stop int64) ([]redis.Z, error) {
+	return s.shard(key).ZRevRangeWithScores(ctx, key, start, stop)
+}
+
+func (s *Sharded) PFAdd(ctx context.Context, key string, elements ...interface{}) (bool, error) {
+	return s.shard(key).PFAdd(ctx, key, elements...)
+}
+
+func (s *Sharded) SetBit(ctx context.Context, key string, offset int64, value int) (int64, error) {
+	return s.shard(key).SetBit(ctx, key, offset, value)
+}
+
+func (s *Sharded) GetBit(ctx context.Context, key string, offset int64) (int64, error) {
+	return s.shard(key).GetBit(ctx, key, offset)
+}
+
+func (s *Sharded) BitCount(ctx context.Context, key string) (int64, error) {
+	return s.shard(key).BitCount(ctx, key)
+}
+
+func (s *Sharded) XAdd(ctx context.Context, stream string, maxLen int64, values map[string]interface{}) (string, error) {
+	return s.shard(stream).XAdd(ctx, stream, maxLen, values)
+}
+
+func (s *Sharded) XLen(ctx context.Context, stream string) (int64, error) {
+	return s.shard(stream).XLen(ctx, stream)
+}
+
+func (s *Sharded) XGroupCreate(ctx context.Context, stream, group, start string) error {
+	return s.shard(stream).XGroupCreate(ctx, stream, group, start)
+}
+
+func (s *Sharded) XReadGroup(ctx context.Context, stream, group, consumer string, count int64, block time.Duration) ([]redis.XMessage, error) {
+	return s.shard(stream).XReadGroup(ctx, stream, group, consumer, count, block)
+}
+
+func (s *Sharded) XAck(ctx context.Context, stream, group string, ids ...string) (int64, error) {
+	return s.shard(stream).XAck(ctx, stream, group, ids...)
+}
+
+func (s *Sharded) XAutoClaim(ctx context.Context, stream, group, consumer string, minIdle time.Duration, start string, count int64) ([]redis.XMessage, string, error) {
+	return s.shard(stream).XAutoClaim(ctx, stream, group, consumer, minIdle, start, count)
+}
+
+func (s *Sharded) GeoAdd(ctx context.Context, key string, locations ...*redis.GeoLocation) (int64, error) {
+	return s.shard(key).GeoAdd(ctx, key, locations...)
+}
+
+func (s *Sharded) GeoPos(ctx context.Context, key string, members ...string) ([]*redis.GeoPos, error) {
+	return s.shard(key).GeoPos(ctx, key, members...)
+}
+
+func (s *Sharded) GeoSearch(ctx context.Context, key string, longitude, latitude, radius float64, unit string, count int) ([]redis.GeoLocation, error) {
+	return s.shard(key).GeoSearch(ctx, key, longitude, latitude, radius, unit, count)
+}
+
+func (s *Sharded) LRem(ctx context.Context, key string, count int64, value interface{}) (int64, error) {
+	return s.shard(key).LRem(ctx, key, count, value)
+}
+
+func (s *Sharded) LLen(ctx context.Context, key string) (int64, error) {
+	return s.shard(key).LLen(ctx, key)
+}
+
+func (s *Sharded) SetBits(ctx context.Context, key string, offsets ...int64) (int64, error) {
+	return s.shard(key).SetBits(ctx, key, offsets...)
+}
+
+func (s *Sharded) TestBits(ctx context.Context, key string, offsets ...int64) (bool, error) {
+	return s.shard(key).TestBits(ctx, key, offsets...)
+}
@@ -0,0 +1,173 @@
+package adapters
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// snapshotHeader starts every snapshot, followed by one record per key made
+// of the key and its entry as a Store holds it, both length prefixed
+var snapshotHeader = []byte("cacher-snapshot\x01")
+
+// maxSnapshotRecord bounds the length of a key or an entry read from a
+// snapshot, so a corrupt length does not allocate without limit
+const maxSnapshotRecord = 1 << 32
+
+// ErrCorruptSnapshot is returned when a snapshot given to Restore cannot be
+// decoded
+var ErrCorruptSnapshot = errors.New("corrupt memory snapshot")
+
+// Snapshot writes every key that has not expired to w, for Restore to load
+// them later, e.g. after a restart. Keys are copied one shard at a time, so
+// writes made while it runs may or may not be included.
+func (m *Memory) Snapshot(w io.Writer) error {
+	buffered := bufio.NewWriter(w)
+	if _, err := buffered.Write(snapshotHeader); err != nil {
+		return err
+	}
+	var record []byte
+	write := func(key string, data []byte) error {
+		record = binary.AppendUvarint(record[:0], uint64(len(key)))
+		record = append(record, key...)
+		record = binary.AppendUvarint(record, uint64(len(data)))
+		record = append(record, data...)
+		_, err := buffered.Write(record)
+		return err
+	}
+
+	now := time.Now()
+	if m.store != nil {
+		var err error
+		rangeErr := m.store.Range(context.Background(), func(key string, data []byte) bool {
+			if e, decodeErr := decodeEntry(data); decodeErr == nil && e != nil && !e.expired(now) {
+				err = write(key, data)
+			}
+			return err == nil
+		})
+		if err != nil {
+			return err
+		}
+		if rangeErr != nil {
+			return rangeErr
+		}
+		return buffered.Flush()
+	}
+
+	for _, shard := range m.shards {
+		// Encode under the lock and write after it, w may be slow
+		shard.mutex.Lock()
+		entries := make(map[string][]byte, len(shard.items))
+		for key, e := range shard.items {
+			if !e.expired(now) {
+				entries[key] = encodeEntry(e)
+			}
+		}
+		shard.mutex.Unlock()
+		for key, data := range entries {
+			if err := write(key, data); err != nil {
+				return err
+			}
+		}
+	}
+	return buffered.Flush()
+}
+
+// Restore adds the keys of a snapshot written by Snapshot, replacing keys of
+// the same name and skipping those that expired in the meantime. Keys read
+// before a corrupt part of the snapshot are kept.
+func (m *Memory) Restore(r io.Reader) error {
+	buffered := bufio.NewReader(r)
+	header := make([]byte, len(snapshotHeader))
+	if _, err := io.ReadFull(buffered, header); err != nil || !bytes.Equal(header, snapshotHeader) {
+		return ErrCorruptSnapshot
+	}
+
+	ctx := context.Background()
+	now := time.Now()
+	for {
+		key, err := readSnapshotRecord(buffered)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		data, err := readSnapshotRecord(buffered)
+		if err != nil {
+			return ErrCorruptSnapshot
+		}
+		e, err := decodeEntry(data)
+		if err != nil || e == nil {
+			return ErrCorruptSnapshot
+		}
+		if e.expired(now) {
+			continue
+		}
+		err = m.update(ctx, string(key), func(s *memoryShard, _ *memoryEntry) error {
+			s.items[string(key)] = e
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// readSnapshotRecord reads one length prefixed part of a record, returning
+// io.EOF at the end of the snapshot
+func readSnapshotRecord(r *bufio.Reader) ([]byte, error) {
+	size, err := binary.ReadUvarint(r)
+	if errors.Is(err, io.EOF) {
+		return nil, io.EOF
+	}
+	if err != nil || size > maxSnapshotRecord {
+		return nil, ErrCorruptSnapshot
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, ErrCorruptSnapshot
+	}
+	return data, nil
+}
+
+// SaveSnapshot writes a snapshot to the file at path. It is written next to
+// it first and renamed, so a crash leaves the previous snapshot intact.
+func (m *Memory) SaveSnapshot(path string) error {
+	file, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	if err := m.Snapshot(file); err != nil {
+		_ = file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		_ = file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), path)
+}
+
+// LoadSnapshot restores the snapshot in the file at path, if there is one
+func (m *Memory) LoadSnapshot(path string) error {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return m.Restore(file)
+}
@@ -0,0 +1,223 @@
+package adapters
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DefaultSQLTable is the table OpenSQL keeps entries in
+const DefaultSQLTable = "cache_entries"
+
+// SQLDialect holds the SQL a SQLStore runs on one database engine
+type SQLDialect struct {
+	name string
+	// schema creates the table and its expiry index, %[1]s is the table
+	schema []string
+	// key is the quoted key column, which is a reserved word in MySQL
+	key string
+	// lock is appended to the SELECT of an update to lock the row
+	lock string
+	// insert starts an INSERT that does nothing when the key exists, end
+	// finishes it
+	insert, insertEnd string
+	// numbered placeholders ($1) instead of ?
+	numbered bool
+}
+
+var (
+	Postgres = SQLDialect{
+		name: "postgres",
+		schema: []string{
+			`CREATE TABLE IF NOT EXISTS %[1]s (key TEXT PRIMARY KEY, value BYTEA NOT NULL, expires_at BIGINT)`,
+			`CREATE INDEX IF NOT EXISTS %[1]s_expires_at ON %[1]s (expires_at)`,
+		},
+		key:       "key",
+		lock:      " FOR UPDATE",
+		insert:    "INSERT INTO",
+		insertEnd: " ON CONFLICT (key) DO NOTHING",
+		numbered:  true,
+	}
+	MySQL = SQLDialect{
+		name: "mysql",
+		schema: []string{
+			"CREATE TABLE IF NOT EXISTS %[1]s (`key` VARBINARY(512) PRIMARY KEY, value LONGBLOB NOT NULL, expires_at BIGINT NULL, INDEX %[1]s_expires_at (expires_at))",
+		},
+		key:    "`key`",
+		lock:   " FOR UPDATE",
+		insert: "INSERT IGNORE INTO",
+	}
+	// SQLite locks the whole database for writes, so concurrent updates fail
+	// with SQLITE_BUSY unless the pool is limited to one connection
+	SQLite = SQLDialect{
+		name: "sqlite",
+		schema: []string{
+			`CREATE TABLE IF NOT EXISTS %[1]s (key TEXT PRIMARY KEY, value BLOB NOT NULL, expires_at INTEGER)`,
+			`CREATE INDEX IF NOT EXISTS %[1]s_expires_at ON %[1]s (expires_at)`,
+		},
+		key:       "key",
+		insert:    "INSERT INTO",
+		insertEnd: " ON CONFLICT (key) DO NOTHING",
+	}
+)
+
+// bind replaces the ? placeholders of query with $1, $2... for dialects that
+// number them
+func (d SQLDialect) bind(query string) string {
+	if !d.numbered {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			fmt.Fprintf(&b, "$%d", n)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// SQLStore is a Store over a table of a SQL database with a row per key:
+// (key, value, expires_at), where expires_at is in unix milliseconds and
+// NULL for keys that never expire
+type SQLStore struct {
+	DB      *sql.DB
+	Dialect SQLDialect
+	Table   string
+
+	get, insert, update, remove, all, expired string
+}
+
+// NewSQLStore creates a Store over table of db, creating the table if needed
+func NewSQLStore(ctx context.Context, db *sql.DB, dialect SQLDialect, table string) (*SQLStore, error) {
+	for _, statement := range dialect.schema {
+		if _, err := db.ExecContext(ctx, fmt.Sprintf(statement, table)); err != nil {
+			return nil, err
+		}
+	}
+
+	key := dialect.key
+	return &SQLStore{
+		DB:      db,
+		Dialect: dialect,
+		Table:   table,
+		get:     dialect.bind(fmt.Sprintf("SELECT value FROM %s WHERE %s = ?%s", table, key, dialect.lock)),
+		insert:  dialect.bind(fmt.Sprintf("%s %s (%s, value, expires_at) VALUES (?, ?, ?)%s", dialect.insert, table, key, dialect.insertEnd)),
+		update:  dialect.bind(fmt.Sprintf("UPDATE %s SET value = ?, expires_at = ? WHERE %s = ?", table, key)),
+		remove:  dialect.bind(fmt.Sprintf("DELETE FROM %s WHERE %s = ?", table, key)),
+		all:     fmt.Sprintf("SELECT %s, value FROM %s", key, table),
+		expired: dialect.bind(fmt.Sprintf("DELETE FROM %s WHERE expires_at IS NOT NULL AND expires_at <= ?", table)),
+	}, nil
+}
+
+// OpenSQL returns a CacheServer keeping its entries in the cache_entries
+// table of db, which is created if needed. Expired rows are deleted in the
+// background, see NewStoreServer.
+func OpenSQL(ctx context.Context, db *sql.DB, dialect SQLDialect, opts ...MemoryOption) (*Memory, error) {
+	store, err := NewSQLStore(ctx, db, dialect, DefaultSQLTable)
+	if err != nil {
+		return nil, err
+	}
+	return NewStoreServer(store, opts...), nil
+}
+
+func (s *SQLStore) Name() string {
+	return s.Dialect.name
+}
+
+// errInsertConflict means another transaction inserted the key first
+var errInsertConflict = errors.New("key inserted concurrently")
+
+// Update locks the row of key for the transaction. A missing key is inserted
+// only if no concurrent transaction inserted it first, otherwise fn runs
+// again on the row that won, which keeps SetNX atomic. Conflicts are retried
+// after a backoff, up to storeConflicts.MaxAttempts times before failing with
+// ErrStoreConflict.
+func (s *SQLStore) Update(ctx context.Context, key string, fn func(current []byte) ([]byte, bool)) error {
+	return retryConflicts(ctx, func() error {
+		return s.updateOnce(ctx, key, fn)
+	}, func(err error) bool {
+		return errors.Is(err, errInsertConflict)
+	})
+}
+
+func (s *SQLStore) updateOnce(ctx context.Context, key string, fn func(current []byte) ([]byte, bool)) error {
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var current []byte
+	err = tx.QueryRowContext(ctx, s.get, key).Scan(&current)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	exists := err == nil
+
+	next, write := fn(current)
+	switch {
+	case !write:
+		return tx.Commit()
+	case next == nil:
+		_, err = tx.ExecContext(ctx, s.remove, key)
+	case exists:
+		_, err = tx.ExecContext(ctx, s.update, next, sqlExpiresAt(next), key)
+	default:
+		var result sql.Result
+		if result, err = tx.ExecContext(ctx, s.insert, key, next, sqlExpiresAt(next)); err == nil {
+			if inserted, _ := result.RowsAffected(); inserted == 0 {
+				return errInsertConflict
+			}
+		}
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *SQLStore) Range(ctx context.Context, fn func(key string, data []byte) bool) error {
+	rows, err := s.DB.QueryContext(ctx, s.all)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var key string
+		var data []byte
+		if err := rows.Scan(&key, &data); err != nil {
+			return err
+		}
+		if !fn(key, data) {
+			return nil
+		}
+	}
+	return rows.Err()
+}
+
+// DeleteExpired removes every expired row with a single statement
+func (s *SQLStore) DeleteExpired(ctx context.Context, now time.Time) error {
+	_, err := s.DB.ExecContext(ctx, s.expired, now.UnixMilli())
+	return err
+}
+
+// Close does nothing, the database is owned by the caller
+func (s *SQLStore) Close() error {
+	return nil
+}
+
+// sqlExpiresAt returns the expires_at column of an encoded entry
+func sqlExpiresAt(data []byte) interface{} {
+	expiresAt := entryExpiresAt(data)
+	if expiresAt.IsZero() {
+		return nil
+	}
+	return expiresAt.UnixMilli()
+}
@@ -0,0 +1,432 @@
+package adapters
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"math"
+	"sort"
+	"time"
+)
+
+// DefaultStoreCleanupInterval is how often NewStoreServer removes expired
+// entries unless WithCleanupInterval is given
+const DefaultStoreCleanupInterval = time.Minute
+
+// ErrCorruptEntry is returned when an entry read from a Store cannot be decoded
+var ErrCorruptEntry = errors.New("corrupt entry in store")
+
+// ErrStoreConflict is returned when an update of a Store kept losing the race
+// for its key to concurrent writers
+var ErrStoreConflict = errors.New("too many concurrent updates of the same key")
+
+// ErrNotCounter is returned by CounterStore.Add for a key holding something
+// else than a counter, which is then updated in a transaction instead
+var ErrNotCounter = errors.New("not a store counter")
+
+// storeConflicts is how stores retry a conditional write that lost the race
+// for its key
+var storeConflicts = RetryPolicy{MaxAttempts: 32, BaseDelay: time.Millisecond, MaxDelay: 100 * time.Millisecond}
+
+// Store is a persistent key-value store a Memory can keep its entries in, so
+// they outlive the process. Update must pass the current value of key, nil
+// if there is none, to fn and apply its result in one transaction: next is
+// written when write is true, and the key is deleted when next is nil too.
+// fn may run more than once if the store retries a conflicting transaction.
+type Store interface {
+	Name() string
+	Update(ctx context.Context, key string, fn func(current []byte) (next []byte, write bool)) error
+	// Range calls fn for every key until it returns false. data is only valid
+	// during the call.
+	Range(ctx context.Context, fn func(key string, data []byte) bool) error
+	Close() error
+}
+
+// BatchStore is a Store that can write many entries in one batch, which MSet
+// uses instead of one transaction per key
+type BatchStore interface {
+	Store
+	PutMany(ctx context.Context, entries map[string][]byte) error
+}
+
+// ExpiringStore is a Store that can delete every expired entry at once, which
+// the janitor uses instead of ranging over all entries
+type ExpiringStore interface {
+	Store
+	DeleteExpired(ctx context.Context, now time.Time) error
+}
+
+// CounterStore is a Store that can add to an integer in place, which the
+// counters of NewStoreServer use instead of a transaction. Add creates a
+// missing or expired key expiring at expiresAt, zero for never, and fails
+// with ErrNotCounter when key holds another value.
+type CounterStore interface {
+	Store
+	Add(ctx context.Context, key string, delta int64, expiresAt time.Time) (int64, error)
+}
+
+// retryConflicts runs update again while it fails with an error conflict
+// reports, backing off between attempts, until storeConflicts.MaxAttempts
+// attempts failed or ctx is done
+func retryConflicts(ctx context.Context, update func() error, conflict func(error) bool) error {
+	for attempt := 1; ; attempt++ {
+		err := update()
+		if err == nil || !conflict(err) {
+			return err
+		}
+		if attempt == storeConflicts.MaxAttempts {
+			return ErrStoreConflict
+		}
+		timer := time.NewTimer(storeConflicts.backoff(attempt - 1))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// NewStoreServer creates a CacheServer keeping its entries in store, with the
+// same semantics as the in-memory one. Expired entries are never returned and
+// are removed by a janitor every DefaultStoreCleanupInterval.
+func NewStoreServer(store Store, opts ...MemoryOption) *Memory {
+	opts = append([]MemoryOption{WithCleanupInterval(DefaultStoreCleanupInterval)}, opts...)
+	// The store is set before NewMemory starts the janitor
+	opts = append(opts, func(m *Memory) {
+		m.store = store
+	})
+	return NewMemory(opts...)
+}
+
+// updateStore runs fn inside a transaction of the store. fn works on a shard
+// holding only key, which is compared with the stored entry afterwards to
+// decide what to write back.
+func (m *Memory) updateStore(ctx context.Context, key string, fn func(s *memoryShard, e *memoryEntry) error) error {
+	var err error
+	expired := false
+	storeErr := m.store.Update(ctx, key, func(current []byte) ([]byte, bool) {
+		s := &memoryShard{items: make(map[string]*memoryEntry, 1)}
+		e, decodeErr := decodeEntry(current)
+		if decodeErr != nil {
+			err = decodeErr
+			return nil, false
+		}
+		expired = e != nil && e.expired(time.Now())
+		if expired {
+			e = nil
+		}
+		if e != nil {
+			s.items[key] = e
+		}
+
+		// fn may store an entry and still return an error, e.g. redis.Nil
+		// from GetSet, so its changes are kept either way
+		err = fn(s, e)
+		next, exists := s.items[key]
+		if !exists {
+			return nil, current != nil
+		}
+		encoded := encodeEntry(next)
+		return encoded, !bytes.Equal(encoded, current)
+	})
+	if storeErr != nil {
+		return storeErr
+	}
+	if expired {
+		m.emit(KeyExpired, key)
+	}
+	return err
+}
+
+func (m *Memory) putMany(ctx context.Context, batch BatchStore, values map[string]interface{}, expiration time.Duration) error {
+	entries := make(map[string][]byte, len(values))
+	for key, value := range values {
+		str, err := formatValue(value)
+		if err != nil {
+			return err
+		}
+		e := &memoryEntry{kind: memoryString, value: str}
+		if expiration > 0 {
+			e.expiresAt = time.Now().Add(expiration)
+		}
+		entries[key] = encodeEntry(e)
+	}
+	return batch.PutMany(ctx, entries)
+}
+
+func (m *Memory) scanStore(ctx context.Context, match string) ([]string, uint64, error) {
+	now := time.Now()
+	keys := []string{}
+	err := m.store.Range(ctx, func(key string, data []byte) bool {
+		if match != "" && !MatchPattern(match, key) {
+			return true
+		}
+		if e, err := decodeEntry(data); err == nil && e != nil && !e.expired(now) {
+			keys = append(keys, key)
+		}
+		return true
+	})
+	return keys, 0, err
+}
+
+func (m *Memory) deleteExpiredFromStore(now time.Time) {
+	ctx := context.Background()
+	if expiring, ok := m.store.(ExpiringStore); ok {
+		_ = expiring.DeleteExpired(ctx, now)
+		return
+	}
+	var expired []string
+	_ = m.store.Range(ctx, func(key string, data []byte) bool {
+		if e, err := decodeEntry(data); err == nil && e != nil && e.expired(now) {
+			expired = append(expired, key)
+		}
+		return true
+	})
+	// updateStore treats expired entries as missing, so an entry that was
+	// rewritten in the meantime survives
+	for _, key := range expired {
+		_ = m.update(ctx, key, func(s *memoryShard, e *memoryEntry) error {
+			return nil
+		})
+	}
+}
+
+const storeEntryVersion = 1
+
+// sortedKeys returns the keys of a map in order, so an entry always encodes to
+// the same bytes and unchanged entries are not written back
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// encodeEntry serializes e as a version byte, the kind, the expiration in
+// unix nanoseconds and the kind specific data, with length prefixed strings
+func encodeEntry(e *memoryEntry) []byte {
+	buf := []byte{storeEntryVersion, byte(e.kind)}
+	var expiresAt int64
+	if !e.expiresAt.IsZero() {
+		expiresAt = e.expiresAt.UnixNano()
+	}
+	buf = binary.AppendVarint(buf, expiresAt)
+
+	appendString := func(str string) {
+		buf = binary.AppendUvarint(buf, uint64(len(str)))
+		buf = append(buf, str...)
+	}
+	switch e.kind {
+	case memoryString:
+		buf = append(buf, e.value...)
+	case memoryList:
+		buf = binary.AppendUvarint(buf, uint64(len(e.list)))
+		for _, item := range e.list {
+			appendString(item)
+		}
+	case memorySet:
+		buf = binary.AppendUvarint(buf, uint64(len(e.set)))
+		for _, member := range sortedKeys(e.set) {
+			appendString(member)
+		}
+	case memoryZSet:
+		buf = binary.AppendUvarint(buf, uint64(len(e.zset)))
+		for _, member := range sortedKeys(e.zset) {
+			appendString(member)
+			buf = binary.BigEndian.AppendUint64(buf, math.Float64bits(e.zset[member]))
+		}
+	case memoryHash:
+		buf = binary.AppendUvarint(buf, uint64(len(e.hash)))
+		for _, field := range sortedKeys(e.hash) {
+			appendString(field)
+			appendString(e.hash[field])
+		}
+	case memoryStream:
+		appendID := func(id streamID) {
+			buf = binary.AppendUvarint(buf, id.ms)
+			buf = binary.AppendUvarint(buf, id.seq)
+		}
+		appendID(e.stream.last)
+		buf = binary.AppendUvarint(buf, uint64(len(e.stream.entries)))
+		for _, entry := range e.stream.entries {
+			appendID(entry.id)
+			buf = binary.AppendUvarint(buf, uint64(len(entry.fields)))
+			for _, field := range sortedKeys(entry.fields) {
+				appendString(field)
+				appendString(entry.fields[field])
+			}
+		}
+		buf = binary.AppendUvarint(buf, uint64(len(e.stream.groups)))
+		for _, name := range sortedKeys(e.stream.groups) {
+			group := e.stream.groups[name]
+			appendString(name)
+			appendID(group.lastDelivered)
+			ids := make([]streamID, 0, len(group.pending))
+			for id := range group.pending {
+				ids = append(ids, id)
+			}
+			sort.Slice(ids, func(i, j int) bool { return ids[i].less(ids[j]) })
+			buf = binary.AppendUvarint(buf, uint64(len(ids)))
+			for _, id := range ids {
+				pending := group.pending[id]
+				appendID(id)
+				appendString(pending.consumer)
+				buf = binary.AppendVarint(buf, pending.deliveredAt.UnixNano())
+				buf = binary.AppendVarint(buf, pending.deliveries)
+			}
+		}
+	}
+	return buf
+}
+
+// entryExpiresAt returns the expiration of an encoded entry, zero if it never
+// expires, for stores that expire keys natively
+func entryExpiresAt(data []byte) time.Time {
+	if len(data) < 2 {
+		return time.Time{}
+	}
+	expiresAt, n := binary.Varint(data[2:])
+	if n <= 0 || expiresAt == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, expiresAt)
+}
+
+// decodeEntry reverses encodeEntry, returning nil for a nil data
+func decodeEntry(data []byte) (*memoryEntry, error) {
+	if data == nil {
+		return nil, nil
+	}
+	if len(data) < 2 || data[0] != storeEntryVersion {
+		return nil, ErrCorruptEntry
+	}
+	e := &memoryEntry{kind: memoryKind(data[1])}
+	data = data[2:]
+
+	expiresAt, n := binary.Varint(data)
+	if n <= 0 {
+		return nil, ErrCorruptEntry
+	}
+	data = data[n:]
+	if expiresAt != 0 {
+		e.expiresAt = time.Unix(0, expiresAt)
+	}
+
+	corrupt := false
+	readCount := func() int {
+		count, n := binary.Uvarint(data)
+		if n <= 0 || count > uint64(len(data)) {
+			corrupt = true
+			return 0
+		}
+		data = data[n:]
+		return int(count)
+	}
+	readString := func() string {
+		size := readCount()
+		if corrupt {
+			return ""
+		}
+		str := string(data[:size])
+		data = data[size:]
+		return str
+	}
+	readNumber := func() uint64 {
+		n, size := binary.Uvarint(data)
+		if size <= 0 {
+			corrupt = true
+			return 0
+		}
+		data = data[size:]
+		return n
+	}
+	readSigned := func() int64 {
+		n, size := binary.Varint(data)
+		if size <= 0 {
+			corrupt = true
+			return 0
+		}
+		data = data[size:]
+		return n
+	}
+	readID := func() streamID {
+		return streamID{ms: readNumber(), seq: readNumber()}
+	}
+
+	switch e.kind {
+	case memoryString:
+		e.value = string(data)
+	case memoryList:
+		count := readCount()
+		e.list = make([]string, 0, count)
+		for i := 0; i < count && !corrupt; i++ {
+			e.list = append(e.list, readString())
+		}
+	case memorySet:
+		count := readCount()
+		e.set = make(map[string]struct{}, count)
+		for i := 0; i < count && !corrupt; i++ {
+			e.set[readString()] = struct{}{}
+		}
+	case memoryZSet:
+		count := readCount()
+		e.zset = make(map[string]float64, count)
+		for i := 0; i < count && !corrupt; i++ {
+			member := readString()
+			if corrupt || len(data) < 8 {
+				corrupt = true
+				break
+			}
+			e.zset[member] = math.Float64frombits(binary.BigEndian.Uint64(data))
+			data = data[8:]
+		}
+	case memoryHash:
+		count := readCount()
+		e.hash = make(map[string]string, count)
+		for i := 0; i < count && !corrupt; i++ {
+			field := readString()
+			e.hash[field] = readString()
+		}
+	case memoryStream:
+		e.stream = &streamLog{last: readID()}
+		count := readCount()
+		e.stream.entries = make([]streamEntry, 0, count)
+		for i := 0; i < count && !corrupt; i++ {
+			entry := streamEntry{id: readID()}
+			fields := readCount()
+			entry.fields = make(map[string]string, fields)
+			for j := 0; j < fields && !corrupt; j++ {
+				field := readString()
+				entry.fields[field] = readString()
+			}
+			e.stream.entries = append(e.stream.entries, entry)
+		}
+		groups := readCount()
+		e.stream.groups = make(map[string]*streamGroup, groups)
+		for i := 0; i < groups && !corrupt; i++ {
+			name := readString()
+			group := &streamGroup{lastDelivered: readID()}
+			pending := readCount()
+			group.pending = make(map[streamID]*streamPending, pending)
+			for j := 0; j < pending && !corrupt; j++ {
+				id := readID()
+				group.pending[id] = &streamPending{
+					consumer:    readString(),
+					deliveredAt: time.Unix(0, readSigned()),
+					deliveries:  readSigned(),
+				}
+			}
+			e.stream.groups[name] = group
+		}
+	default:
+		return nil, ErrCorruptEntry
+	}
+	if corrupt {
+		return nil, ErrCorruptEntry
+	}
+	return e, nil
+}
@@ -0,0 +1,372 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNoGroup is returned when reading a stream through a consumer group that
+// does not exist
+var ErrNoGroup = errors.New("no such stream or consumer group")
+
+// ErrStreamID is returned for a malformed stream entry id
+var ErrStreamID = errors.New("invalid stream id")
+
+// defaultClaimCount is how many pending entries XAutoClaim looks at when no
+// count is given, like Redis
+const defaultClaimCount = 100
+
+// XAdd appends an entry with values to stream, creating it if needed, and
+// returns its id. A positive maxLen trims the stream to its newest maxLen
+// entries.
+func (r *RedisClient) XAdd(ctx context.Context, stream string, maxLen int64, values map[string]interface{}) (string, error) {
+	return r.Client.XAdd(ctx, &redis.XAddArgs{Stream: stream, MaxLen: maxLen, Values: values}).Result()
+}
+
+// XLen returns the number of entries of stream
+func (r *RedisClient) XLen(ctx context.Context, stream string) (int64, error) {
+	return r.Client.XLen(ctx, stream).Result()
+}
+
+// XGroupCreate creates the consumer group of stream, creating the stream too
+// if needed. The group starts after start, "$" for the last entry or "0" for
+// the whole stream. It does nothing when the group already exists.
+func (r *RedisClient) XGroupCreate(ctx context.Context, stream, group, start string) error {
+	err := r.Client.XGroupCreateMkStream(ctx, stream, group, start).Err()
+	if err != nil && strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return nil
+	}
+	return err
+}
+
+// XReadGroup delivers up to count entries of stream that no consumer of group
+// received yet to consumer, which must acknowledge them with XAck. A positive
+// block waits that long for new entries; none is no error.
+func (r *RedisClient) XReadGroup(ctx context.Context, stream, group, consumer string, count int64, block time.Duration) ([]redis.XMessage, error) {
+	if block <= 0 {
+		block = -1
+	}
+	streams, err := r.Client.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    group,
+		Consumer: consumer,
+		Streams:  []string{stream, ">"},
+		Count:    count,
+		Block:    block,
+	}).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "NOGROUP") {
+			return nil, ErrNoGroup
+		}
+		return nil, err
+	}
+	if len(streams) == 0 {
+		return nil, nil
+	}
+	return streams[0].Messages, nil
+}
+
+// XAck acknowledges entries delivered to group, removing them from its pending
+// entries, and returns how many were pending
+func (r *RedisClient) XAck(ctx context.Context, stream, group string, ids ...string) (int64, error) {
+	return r.Client.XAck(ctx, stream, group, ids...).Result()
+}
+
+// XAutoClaim transfers to consumer up to count entries of group, from start
+// on, that were delivered at least minIdle ago and never acknowledged. It
+// returns them with the start of the next call, "0-0" once every pending
+// entry was looked at.
+func (r *RedisClient) XAutoClaim(ctx context.Context, stream, group, consumer string, minIdle time.Duration, start string, count int64) ([]redis.XMessage, string, error) {
+	messages, next, err := r.Client.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+		Stream:   stream,
+		Group:    group,
+		Consumer: consumer,
+		MinIdle:  minIdle,
+		Start:    start,
+		Count:    count,
+	}).Result()
+	if err != nil && strings.HasPrefix(err.Error(), "NOGROUP") {
+		return nil, "", ErrNoGroup
+	}
+	return messages, next, err
+}
+
+// streamID is the id of a stream entry, its unix milliseconds and a sequence
+// number within them
+type streamID struct {
+	ms, seq uint64
+}
+
+func (id streamID) String() string {
+	return fmt.Sprintf("%d-%d", id.ms, id.seq)
+}
+
+func (id streamID) less(other streamID) bool {
+	return id.ms < other.ms || id.ms == other.ms && id.seq < other.seq
+}
+
+// parseStreamID parses "ms-seq" and "ms", "-" being the smallest id
+func parseStreamID(str string) (streamID, error) {
+	if str == "-" {
+		return streamID{}, nil
+	}
+	ms, seq, found := strings.Cut(str, "-")
+	var id streamID
+	var err error
+	if id.ms, err = strconv.ParseUint(ms, 10, 64); err != nil {
+		return id, ErrStreamID
+	}
+	if found {
+		if id.seq, err = strconv.ParseUint(seq, 10, 64); err != nil {
+			return id, ErrStreamID
+		}
+	}
+	return id, nil
+}
+
+type streamEntry struct {
+	id     streamID
+	fields map[string]string
+}
+
+func (e streamEntry) message() redis.XMessage {
+	values := make(map[string]interface{}, len(e.fields))
+	for field, value := range e.fields {
+		values[field] = value
+	}
+	return redis.XMessage{ID: e.id.String(), Values: values}
+}
+
+type streamPending struct {
+	consumer    string
+	deliveredAt time.Time
+	deliveries  int64
+}
+
+type streamGroup struct {
+	lastDelivered streamID
+	pending       map[streamID]*streamPending
+}
+
+// streamLog holds the entries of a stream in id order and its consumer
+// groups
+type streamLog struct {
+	entries []streamEntry
+	last    streamID
+	groups  map[string]*streamGroup
+}
+
+// find returns the index of the entry id, or where it would be
+func (s *streamLog) find(id streamID) (int, bool) {
+	i := sort.Search(len(s.entries), func(i int) bool {
+		return !s.entries[i].id.less(id)
+	})
+	return i, i < len(s.entries) && s.entries[i].id == id
+}
+
+// stream runs fn on the stream at key, which is nil when it does not exist
+func (m *Memory) stream(ctx context.Context, key string, fn func(s *memoryShard, stream *streamLog) error) error {
+	return m.update(ctx, key, func(s *memoryShard, e *memoryEntry) error {
+		if e == nil {
+			return fn(s, nil)
+		}
+		if e.kind != memoryStream {
+			return ErrWrongType
+		}
+		return fn(s, e.stream)
+	})
+}
+
+// XAdd appends an entry with values to stream, creating it if needed, and
+// returns its id. A positive maxLen trims the stream to its newest maxLen
+// entries.
+func (m *Memory) XAdd(ctx context.Context, stream string, maxLen int64, values map[string]interface{}) (string, error) {
+	fields := make(map[string]string, len(values))
+	for field, value := range values {
+		str, err := formatValue(value)
+		if err != nil {
+			return "", err
+		}
+		fields[field] = str
+	}
+
+	var id streamID
+	err := m.stream(ctx, stream, func(s *memoryShard, st *streamLog) error {
+		if st == nil {
+			st = &streamLog{groups: make(map[string]*streamGroup)}
+			s.items[stream] = &memoryEntry{kind: memoryStream, stream: st}
+		}
+		id = streamID{ms: uint64(time.Now().UnixMilli())}
+		if !st.last.less(id) {
+			id = streamID{ms: st.last.ms, seq: st.last.seq + 1}
+		}
+		st.last = id
+		st.entries = append(st.entries, streamEntry{id: id, fields: fields})
+		if maxLen > 0 && int64(len(st.entries)) > maxLen {
+			st.entries = append([]streamEntry(nil), st.entries[int64(len(st.entries))-maxLen:]...)
+		}
+		return nil
+	})
+	return id.String(), err
+}
+
+// XLen returns the number of entries of stream
+func (m *Memory) XLen(ctx context.Context, stream string) (int64, error) {
+	var n int64
+	err := m.stream(ctx, stream, func(s *memoryShard, st *streamLog) error {
+		n = 0
+		if st != nil {
+			n = int64(len(st.entries))
+		}
+		return nil
+	})
+	return n, err
+}
+
+// XGroupCreate creates the consumer group of stream, creating the stream too
+// if needed. The group starts after start, "$" for the last entry or "0" for
+// the whole stream. It does nothing when the group already exists.
+func (m *Memory) XGroupCreate(ctx context.Context, stream, group, start string) error {
+	return m.stream(ctx, stream, func(s *memoryShard, st *streamLog) error {
+		if st == nil {
+			st = &streamLog{groups: make(map[string]*streamGroup)}
+			s.items[stream] = &memoryEntry{kind: memoryStream, stream: st}
+		}
+		if _, exists := st.groups[group]; exists {
+			return nil
+		}
+		after := st.last
+		if start != "$" {
+			var err error
+			if after, err = parseStreamID(start); err != nil {
+				return err
+			}
+		}
+		st.groups[group] = &streamGroup{lastDelivered: after, pending: make(map[streamID]*streamPending)}
+		return nil
+	})
+}
+
+// XReadGroup delivers up to count entries of stream that no consumer of group
+// received yet to consumer, which must acknowledge them with XAck. A positive
+// block waits that long for new entries; none is no error.
+func (m *Memory) XReadGroup(ctx context.Context, stream, group, consumer string, count int64, block time.Duration) ([]redis.XMessage, error) {
+	var messages []redis.XMessage
+	read := func() (bool, error) {
+		err := m.stream(ctx, stream, func(s *memoryShard, st *streamLog) error {
+			messages = nil
+			if st == nil || st.groups[group] == nil {
+				return ErrNoGroup
+			}
+			g := st.groups[group]
+			i, found := st.find(g.lastDelivered)
+			if found {
+				i++
+			}
+			now := time.Now()
+			for ; i < len(st.entries) && (count <= 0 || int64(len(messages)) < count); i++ {
+				entry := st.entries[i]
+				g.lastDelivered = entry.id
+				g.pending[entry.id] = &streamPending{consumer: consumer, deliveredAt: now, deliveries: 1}
+				messages = append(messages, entry.message())
+			}
+			return nil
+		})
+		return len(messages) > 0, err
+	}
+	if err := m.block(ctx, block, read); err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+// XAck acknowledges entries delivered to group, removing them from its pending
+// entries, and returns how many were pending
+func (m *Memory) XAck(ctx context.Context, stream, group string, ids ...string) (int64, error) {
+	parsed := make([]streamID, len(ids))
+	for i, id := range ids {
+		var err error
+		if parsed[i], err = parseStreamID(id); err != nil {
+			return 0, err
+		}
+	}
+	var acked int64
+	err := m.stream(ctx, stream, func(s *memoryShard, st *streamLog) error {
+		acked = 0
+		if st == nil || st.groups[group] == nil {
+			return nil
+		}
+		for _, id := range parsed {
+			if _, ok := st.groups[group].pending[id]; ok {
+				delete(st.groups[group].pending, id)
+				acked++
+			}
+		}
+		return nil
+	})
+	return acked, err
+}
+
+// XAutoClaim transfers to consumer up to count entries of group, from start
+// on, that were delivered at least minIdle ago and never acknowledged. It
+// returns them with the start of the next call, "0-0" once every pending
+// entry was looked at. Pending entries trimmed from the stream are dropped.
+func (m *Memory) XAutoClaim(ctx context.Context, stream, group, consumer string, minIdle time.Duration, start string, count int64) ([]redis.XMessage, string, error) {
+	from, err := parseStreamID(start)
+	if err != nil {
+		return nil, "", err
+	}
+	if count <= 0 {
+		count = defaultClaimCount
+	}
+	var messages []redis.XMessage
+	next := streamID{}
+	err = m.stream(ctx, stream, func(s *memoryShard, st *streamLog) error {
+		messages, next = nil, streamID{}
+		if st == nil || st.groups[group] == nil {
+			return ErrNoGroup
+		}
+		g := st.groups[group]
+		ids := make([]streamID, 0, len(g.pending))
+		for id := range g.pending {
+			if !id.less(from) {
+				ids = append(ids, id)
+			}
+		}
+		sort.Slice(ids, func(i, j int) bool { return ids[i].less(ids[j]) })
+
+		now := time.Now()
+		for n, id := range ids {
+			if int64(n) == count {
+				next = id
+				break
+			}
+			pending := g.pending[id]
+			if now.Sub(pending.deliveredAt) < minIdle {
+				continue
+			}
+			i, found := st.find(id)
+			if !found {
+				delete(g.pending, id)
+				continue
+			}
+			pending.consumer = consumer
+			pending.deliveredAt = now
+			pending.deliveries++
+			messages = append(messages, st.entries[i].message())
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return messages, next.String(), nil
+}
@@ -0,0 +1,192 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+// Tiered is a two level Cache. Reads check the in-process Local tier first and
+// fall back to Remote, copying remote hits into Local. Writes go to both.
+type Tiered struct {
+	Local    CacheServer
+	Remote   Cache
+	LocalTTL time.Duration // Upper bound for how long entries live in Local
+	Bus      InvalidationBus
+
+	localHits    uint64
+	localMisses  uint64
+	remoteHits   uint64
+	remoteMisses uint64
+}
+
+// NewTiered creates a tiered cache with local in front of remote
+func NewTiered(local CacheServer, remote Cache, localTTL time.Duration) *Tiered {
+	return &Tiered{
+		Local:    local,
+		Remote:   remote,
+		LocalTTL: localTTL,
+	}
+}
+
+// UseBus subscribes to bus so changes made by other nodes evict the local copy,
+// and announces changes made through t on it
+func (t *Tiered) UseBus(bus InvalidationBus) error {
+	err := bus.Subscribe(func(keys []string) {
+		_, _ = t.Local.Delete(context.Background(), keys...)
+	})
+	if err != nil {
+		return err
+	}
+	t.Bus = bus
+	return nil
+}
+
+func (t *Tiered) Get(ctx context.Context, key string) (interface{}, error) {
+	value, _, err := t.Lookup(ctx, key)
+	return value, err
+}
+
+// Lookup is Get that also reports whether the value came from the local tier
+func (t *Tiered) Lookup(ctx context.Context, key string) (interface{}, bool, error) {
+	if value, err := t.Local.Get(ctx, key); err == nil {
+		atomic.AddUint64(&t.localHits, 1)
+		return value, true, nil
+	}
+	atomic.AddUint64(&t.localMisses, 1)
+
+	value, err := t.Remote.Get(ctx, key)
+	if err != nil {
+		atomic.AddUint64(&t.remoteMisses, 1)
+		return value, false, err
+	}
+	atomic.AddUint64(&t.remoteHits, 1)
+
+	// Write back so the next read is served locally
+	_ = t.Local.Set(ctx, key, value, t.localExpiration(0))
+	return value, false, nil
+}
+
+func (t *Tiered) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	if err := t.Remote.Set(ctx, key, value, expiration); err != nil {
+		return err
+	}
+	if err := t.Local.Set(ctx, key, value, t.localExpiration(expiration)); err != nil {
+		return err
+	}
+	return t.publish(ctx, key)
+}
+
+func (t *Tiered) Delete(ctx context.Context, keys ...string) error {
+	if _, err := t.Local.Delete(ctx, keys...); err != nil {
+		return err
+	}
+	if err := t.Remote.Delete(ctx, keys...); err != nil {
+		return err
+	}
+	return t.publish(ctx, keys...)
+}
+
+// Evict drops keys from the local tier of this and, through the bus, every
+// other instance, after they were changed on the remote tier directly
+func (t *Tiered) Evict(ctx context.Context, keys ...string) error {
+	if _, err := t.Local.Delete(ctx, keys...); err != nil {
+		return err
+	}
+	return t.publish(ctx, keys...)
+}
+
+func (t *Tiered) publish(ctx context.Context, keys ...string) error {
+	if t.Bus == nil {
+		return nil
+	}
+	return t.Bus.Publish(ctx, keys...)
+}
+
+func (t *Tiered) GetMany(ctx context.Context, keys ...string) (map[string]interface{}, error) {
+	local, err := t.Local.MGet(ctx, keys...)
+	if err != nil {
+		local = make([]interface{}, len(keys))
+	}
+	found := zipValues(keys, local)
+	atomic.AddUint64(&t.localHits, uint64(len(found)))
+	atomic.AddUint64(&t.localMisses, uint64(len(keys)-len(found)))
+	if len(found) == len(keys) {
+		return found, nil
+	}
+
+	var missing []string
+	for _, key := range keys {
+		if _, ok := found[key]; !ok {
+			missing = append(missing, key)
+		}
+	}
+	remote, err := t.Remote.GetMany(ctx, missing...)
+	if err != nil {
+		atomic.AddUint64(&t.remoteMisses, uint64(len(missing)))
+		return found, err
+	}
+	atomic.AddUint64(&t.remoteHits, uint64(len(remote)))
+	atomic.AddUint64(&t.remoteMisses, uint64(len(missing)-len(remote)))
+
+	// Write back so the next read is served locally
+	if len(remote) > 0 {
+		_ = t.Local.MSet(ctx, remote, t.localExpiration(0))
+	}
+	for key, value := range remote {
+		found[key] = value
+	}
+	return found, nil
+}
+
+func (t *Tiered) SetMany(ctx context.Context, values map[string]interface{}, expiration time.Duration) error {
+	if err := t.Remote.SetMany(ctx, values, expiration); err != nil {
+		return err
+	}
+	if err := t.Local.MSet(ctx, values, t.localExpiration(expiration)); err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	return t.publish(ctx, keys...)
+}
+
+// TierStatistics returns the hit and miss counters of each tier
+func (t *Tiered) TierStatistics() map[string]map[string]uint64 {
+	return map[string]map[string]uint64{
+		"local": {
+			"hits":   atomic.LoadUint64(&t.localHits),
+			"misses": atomic.LoadUint64(&t.localMisses),
+		},
+		"remote": {
+			"hits":   atomic.LoadUint64(&t.remoteHits),
+			"misses": atomic.LoadUint64(&t.remoteMisses),
+		},
+	}
+}
+
+// localExpiration caps expiration to LocalTTL so local copies never outlive
+// the remote entry by more than LocalTTL
+func (t *Tiered) localExpiration(expiration time.Duration) time.Duration {
+	if expiration > 0 && (t.LocalTTL <= 0 || expiration < t.LocalTTL) {
+		return expiration
+	}
+	if t.LocalTTL > 0 {
+		return t.LocalTTL
+	}
+	return 0
+}
+
+// Close stops listening for invalidations and closes both tiers
+func (t *Tiered) Close() error {
+	var errs []error
+	if t.Bus != nil {
+		errs = append(errs, t.Bus.Close())
+	}
+	errs = append(errs, t.Local.Close(), t.Remote.Close())
+	return errors.Join(errs...)
+}
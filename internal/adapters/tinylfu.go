@@ -0,0 +1,65 @@
+package adapters
+
+// frequencySketchMax is where the counters of a frequencySketch saturate
+const frequencySketchMax = 15
+
+// frequencySketch estimates how often keys were used with a count-min sketch
+// of four rows of counters. Every counter is halved once it counted ten times
+// as many uses as a row has counters, so keys that were popular long ago do
+// not keep their estimate.
+type frequencySketch struct {
+	rows    [4][]uint8
+	mask    uint64
+	uses    int
+	resetAt int
+}
+
+// newFrequencySketch creates a sketch for about width keys
+func newFrequencySketch(width int) *frequencySketch {
+	size := 64
+	for size < width {
+		size <<= 1
+	}
+	f := &frequencySketch{mask: uint64(size - 1), resetAt: 10 * size}
+	for i := range f.rows {
+		f.rows[i] = make([]uint8, size)
+	}
+	return f
+}
+
+// index returns the counter of row for hash, double hashing its halves
+func (f *frequencySketch) index(hash uint64, row int) uint64 {
+	return (hash + uint64(row)*(hash>>32|1)) & f.mask
+}
+
+// increment counts a use of the key with hash
+func (f *frequencySketch) increment(hash uint64) {
+	for row := range f.rows {
+		if counter := &f.rows[row][f.index(hash, row)]; *counter < frequencySketchMax {
+			*counter++
+		}
+	}
+	if f.uses++; f.uses >= f.resetAt {
+		f.reset()
+	}
+}
+
+// estimate returns how often the key with hash was used, or more when other
+// keys share its counters
+func (f *frequencySketch) estimate(hash uint64) uint8 {
+	estimate := uint8(frequencySketchMax)
+	for row := range f.rows {
+		estimate = min(estimate, f.rows[row][f.index(hash, row)])
+	}
+	return estimate
+}
+
+// reset halves every counter
+func (f *frequencySketch) reset() {
+	for row := range f.rows {
+		for i := range f.rows[row] {
+			f.rows[row][i] >>= 1
+		}
+	}
+	f.uses /= 2
+}
@@ -0,0 +1,139 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"sort"
+)
+
+// ErrTxFailed is returned by Watch when a watched key changed before the
+// transaction committed, so none of its writes were applied
+var ErrTxFailed = redis.TxFailedErr
+
+// TxReader reads keys inside a transaction started by Watch
+type TxReader interface {
+	Get(ctx context.Context, key string) (string, error)
+}
+
+// TxFunc reads the watched state of a transaction and returns the writes to
+// commit, none to commit nothing
+type TxFunc func(tx TxReader) ([]*PipelineOp, error)
+
+// Watch runs fn and commits the writes it returns with MULTI/EXEC, failing
+// with ErrTxFailed if one of keys changed after Watch started. Results and
+// errors of the writes are filled in like Pipeline does.
+func (r *RedisClient) Watch(ctx context.Context, fn TxFunc, keys ...string) error {
+	err := r.Client.Watch(ctx, func(tx *redis.Tx) error {
+		ops, err := fn(redisTxReader{tx})
+		if err != nil || len(ops) == 0 {
+			return err
+		}
+		var cmds []redis.Cmder
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			cmds = queueOps(ctx, pipe, ops)
+			return nil
+		})
+		if errors.Is(err, redis.TxFailedErr) {
+			return err
+		}
+		return opResults(ops, cmds, err)
+	}, keys...)
+	return err
+}
+
+type redisTxReader struct {
+	tx *redis.Tx
+}
+
+func (r redisTxReader) Get(ctx context.Context, key string) (string, error) {
+	return r.tx.Get(ctx, key).Result()
+}
+
+// memoryHeldKey is the context key of the shards a transaction of Memory
+// holds, so its writes do not lock them again
+type memoryHeldKey struct{}
+
+// Watch runs fn and applies the writes it returns, failing with ErrTxFailed if
+// one of keys changed after Watch started. The shards of the watched and
+// written keys are locked while the writes are applied, so other writers of
+// this process wait for the transaction; a Store shared by several processes
+// does not keep out those of others. Unlike Redis, a key that was written
+// with the value it had counts as unchanged.
+func (m *Memory) Watch(ctx context.Context, fn TxFunc, keys ...string) error {
+	watched := make([]string, len(keys))
+	for i, key := range keys {
+		snapshot, err := m.snapshot(ctx, key)
+		if err != nil {
+			return err
+		}
+		watched[i] = snapshot
+	}
+	ops, err := fn(m)
+	if err != nil || len(ops) == 0 {
+		return err
+	}
+
+	locked := append([]string{}, keys...)
+	for _, op := range ops {
+		locked = append(locked, op.Keys...)
+	}
+	ctx, unlock := m.lockShards(ctx, locked)
+	defer unlock()
+	for i, key := range keys {
+		snapshot, err := m.snapshot(ctx, key)
+		if err != nil {
+			return err
+		}
+		if snapshot != watched[i] {
+			return ErrTxFailed
+		}
+	}
+	RunPipeline(ctx, m, ops)
+	return nil
+}
+
+// snapshot returns the encoded entry of key, empty if it does not exist, to
+// tell whether it changed
+func (m *Memory) snapshot(ctx context.Context, key string) (string, error) {
+	var snapshot string
+	err := m.update(ctx, key, func(s *memoryShard, e *memoryEntry) error {
+		snapshot = ""
+		if e != nil {
+			snapshot = string(encodeEntry(e))
+		}
+		return nil
+	})
+	return snapshot, err
+}
+
+// lockShards locks the shards of keys in a fixed order, so transactions do
+// not deadlock, and returns a context telling update they are held
+func (m *Memory) lockShards(ctx context.Context, keys []string) (context.Context, func()) {
+	held, _ := ctx.Value(memoryHeldKey{}).(map[*memoryShard]bool)
+	indexes := make(map[int]bool)
+	for _, key := range keys {
+		if i := m.shardIndex(key); !held[m.shards[i]] {
+			indexes[i] = true
+		}
+	}
+	order := make([]int, 0, len(indexes))
+	for i := range indexes {
+		order = append(order, i)
+	}
+	sort.Ints(order)
+
+	locked := make(map[*memoryShard]bool, len(held)+len(order))
+	for s := range held {
+		locked[s] = true
+	}
+	for _, i := range order {
+		m.shards[i].mutex.Lock()
+		locked[m.shards[i]] = true
+	}
+	return context.WithValue(ctx, memoryHeldKey{}, locked), func() {
+		for _, i := range order {
+			m.shards[i].mutex.Unlock()
+		}
+	}
+}
@@ -0,0 +1,265 @@
+package adapters
+
+import (
+	"context"
+	"github.com/redis/go-redis/v9"
+	"sort"
+)
+
+// zMoveToListScript moves the members of KEYS[1] scored up to ARGV[1] to the
+// head of KEYS[2], ARGV[2] at most, lowest scores first
+var zMoveToListScript = DefaultScripts.Register("zmove_to_list", `
+local due = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, ARGV[2])
+if #due == 0 then
+	return 0
+end
+redis.call('ZREM', KEYS[1], unpack(due))
+redis.call('LPUSH', KEYS[2], unpack(due))
+return #due
+`)
+
+// ZAdd adds members to the sorted set stored at key, updating the scores of
+// existing ones, and returns how many were added
+func (r *RedisClient) ZAdd(ctx context.Context, key string, members ...redis.Z) (int64, error) {
+	return r.Client.ZAdd(ctx, key, members...).Result()
+}
+
+// ZIncrBy adds increment to the score of member, which starts at 0
+func (r *RedisClient) ZIncrBy(ctx context.Context, key string, increment float64, member string) (float64, error) {
+	return r.Client.ZIncrBy(ctx, key, increment, member).Result()
+}
+
+// ZScore returns the score of member, redis.Nil if it is not in the set
+func (r *RedisClient) ZScore(ctx context.Context, key, member string) (float64, error) {
+	return r.Client.ZScore(ctx, key, member).Result()
+}
+
+// ZRank returns the position of member by ascending score, redis.Nil if it is
+// not in the set
+func (r *RedisClient) ZRank(ctx context.Context, key, member string) (int64, error) {
+	return r.Client.ZRank(ctx, key, member).Result()
+}
+
+// ZRevRank returns the position of member by descending score
+func (r *RedisClient) ZRevRank(ctx context.Context, key, member string) (int64, error) {
+	return r.Client.ZRevRank(ctx, key, member).Result()
+}
+
+// ZRangeWithScores returns the members from position start to stop, both
+// included and negative ones counting from the end, by ascending score
+func (r *RedisClient) ZRangeWithScores(ctx context.Context, key string, start, stop int64) ([]redis.Z, error) {
+	return r.Client.ZRangeWithScores(ctx, key, start, stop).Result()
+}
+
+// ZRevRangeWithScores returns members like ZRangeWithScores by descending score
+func (r *RedisClient) ZRevRangeWithScores(ctx context.Context, key string, start, stop int64) ([]redis.Z, error) {
+	return r.Client.ZRevRangeWithScores(ctx, key, start, stop).Result()
+}
+
+// ZMoveToList removes up to count members scored at most max from the sorted
+// set source, lowest scores first, and pushes them in that order to the head
+// of the list destination, in one step. It returns how many were moved; a
+// count that is not positive moves them all.
+func (r *RedisClient) ZMoveToList(ctx context.Context, source, destination string, max float64, count int64) (int64, error) {
+	if count <= 0 {
+		count = -1
+	}
+	return zMoveToListScript.Run(ctx, r.Client, []string{source, destination}, max, count).Int64()
+}
+
+// ZAdd adds members to the sorted set stored at key, updating the scores of
+// existing ones, and returns how many were added
+func (m *Memory) ZAdd(ctx context.Context, key string, members ...redis.Z) (int64, error) {
+	scores := make(map[string]float64, len(members))
+	for _, member := range members {
+		str, err := formatValue(member.Member)
+		if err != nil {
+			return 0, err
+		}
+		scores[str] = member.Score
+	}
+
+	var added int64
+	err := m.update(ctx, key, func(s *memoryShard, e *memoryEntry) error {
+		added = 0
+		if e == nil {
+			e = &memoryEntry{kind: memoryZSet, zset: make(map[string]float64)}
+			s.items[key] = e
+		}
+		if e.kind != memoryZSet {
+			return ErrWrongType
+		}
+		for member, score := range scores {
+			if _, exists := e.zset[member]; !exists {
+				added++
+			}
+			e.zset[member] = score
+		}
+		return nil
+	})
+	return added, err
+}
+
+// ZIncrBy adds increment to the score of member, which starts at 0
+func (m *Memory) ZIncrBy(ctx context.Context, key string, increment float64, member string) (float64, error) {
+	var score float64
+	err := m.update(ctx, key, func(s *memoryShard, e *memoryEntry) error {
+		if e == nil {
+			e = &memoryEntry{kind: memoryZSet, zset: make(map[string]float64)}
+			s.items[key] = e
+		}
+		if e.kind != memoryZSet {
+			return ErrWrongType
+		}
+		score = e.zset[member] + increment
+		e.zset[member] = score
+		return nil
+	})
+	return score, err
+}
+
+// ZScore returns the score of member, redis.Nil if it is not in the set
+func (m *Memory) ZScore(ctx context.Context, key, member string) (float64, error) {
+	var score float64
+	err := m.zset(ctx, key, func(zset map[string]float64) error {
+		var exists bool
+		if score, exists = zset[member]; !exists {
+			return redis.Nil
+		}
+		return nil
+	})
+	return score, err
+}
+
+// ZRank returns the position of member by ascending score, redis.Nil if it is
+// not in the set
+func (m *Memory) ZRank(ctx context.Context, key, member string) (int64, error) {
+	return m.zrank(ctx, key, member, false)
+}
+
+// ZRevRank returns the position of member by descending score
+func (m *Memory) ZRevRank(ctx context.Context, key, member string) (int64, error) {
+	return m.zrank(ctx, key, member, true)
+}
+
+// ZRangeWithScores returns the members from position start to stop, both
+// included and negative ones counting from the end, by ascending score
+func (m *Memory) ZRangeWithScores(ctx context.Context, key string, start, stop int64) ([]redis.Z, error) {
+	return m.zrange(ctx, key, start, stop, false)
+}
+
+// ZRevRangeWithScores returns members like ZRangeWithScores by descending score
+func (m *Memory) ZRevRangeWithScores(ctx context.Context, key string, start, stop int64) ([]redis.Z, error) {
+	return m.zrange(ctx, key, start, stop, true)
+}
+
+func (m *Memory) zrank(ctx context.Context, key, member string, reverse bool) (int64, error) {
+	var rank int64
+	err := m.zset(ctx, key, func(zset map[string]float64) error {
+		if _, exists := zset[member]; !exists {
+			return redis.Nil
+		}
+		for i, z := range sortZSet(zset, reverse) {
+			if z.Member == member {
+				rank = int64(i)
+				break
+			}
+		}
+		return nil
+	})
+	return rank, err
+}
+
+func (m *Memory) zrange(ctx context.Context, key string, start, stop int64, reverse bool) ([]redis.Z, error) {
+	result := []redis.Z{}
+	err := m.zset(ctx, key, func(zset map[string]float64) error {
+		sorted := sortZSet(zset, reverse)
+		size := int64(len(sorted))
+		if start < 0 {
+			start = max(size+start, 0)
+		}
+		if stop < 0 {
+			stop += size
+		}
+		stop = min(stop, size-1)
+		if start <= stop {
+			result = sorted[start : stop+1]
+		}
+		return nil
+	})
+	return result, err
+}
+
+// zset runs fn on the members of the sorted set at key, which are empty when
+// the key does not exist
+func (m *Memory) zset(ctx context.Context, key string, fn func(zset map[string]float64) error) error {
+	return m.update(ctx, key, func(s *memoryShard, e *memoryEntry) error {
+		if e == nil {
+			return fn(nil)
+		}
+		if e.kind != memoryZSet {
+			return ErrWrongType
+		}
+		return fn(e.zset)
+	})
+}
+
+// sortZSet orders members by score, then by member, like Redis does
+func sortZSet(zset map[string]float64, reverse bool) []redis.Z {
+	sorted := make([]redis.Z, 0, len(zset))
+	for member, score := range zset {
+		sorted = append(sorted, redis.Z{Member: member, Score: score})
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if reverse {
+			a, b = b, a
+		}
+		if a.Score != b.Score {
+			return a.Score < b.Score
+		}
+		return a.Member.(string) < b.Member.(string)
+	})
+	return sorted
+}
+
+// ZMoveToList removes up to count members scored at most max from the sorted
+// set source, lowest scores first, and pushes them in that order to the head
+// of the list destination. Like LMove, keys in different shards are not
+// updated in one step.
+func (m *Memory) ZMoveToList(ctx context.Context, source, destination string, max float64, count int64) (int64, error) {
+	if err := m.update(ctx, destination, func(s *memoryShard, e *memoryEntry) error {
+		if e != nil && e.kind != memoryList {
+			return ErrWrongType
+		}
+		return nil
+	}); err != nil {
+		return 0, err
+	}
+
+	var due []interface{}
+	err := m.update(ctx, source, func(s *memoryShard, e *memoryEntry) error {
+		due = nil
+		if e == nil {
+			return nil
+		}
+		if e.kind != memoryZSet {
+			return ErrWrongType
+		}
+		for _, member := range sortZSet(e.zset, false) {
+			if member.Score > max || count > 0 && int64(len(due)) == count {
+				break
+			}
+			due = append(due, member.Member)
+			delete(e.zset, member.Member.(string))
+		}
+		if len(e.zset) == 0 {
+			delete(s.items, source)
+		}
+		return nil
+	})
+	if err != nil || len(due) == 0 {
+		return 0, err
+	}
+	return int64(len(due)), m.Push(ctx, destination, due...)
+}
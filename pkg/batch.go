@@ -0,0 +1,90 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"go.opentelemetry.io/otel/attribute"
+	"time"
+)
+
+// GetMany retrieves several keys in one round trip. Only keys that exist are
+// present in the result; hits and misses are recorded per key.
+func (c *cache) GetMany(ctx context.Context, keys ...string) (map[string]interface{}, error) {
+	if len(keys) == 0 {
+		return map[string]interface{}{}, nil
+	}
+
+	start := time.Now()
+	ctx, span := c.startSpan(ctx, "GetMany", attribute.StringSlice("cache.keys", keys))
+
+	values, err := c.Cache.GetMany(ctx, keys...)
+	defer endSpan(span, start, err)
+	latency := time.Since(start)
+	if err != nil {
+		for _, key := range keys {
+			c.failed(key)
+			c.fire(ctx, hookError, "get", key, latency, err)
+		}
+		return nil, fmt.Errorf("cache backend: %w", err)
+	}
+
+	for _, key := range keys {
+		if _, ok := values[key]; ok {
+			c.hit(key)
+			c.fire(ctx, hookHit, "get", key, latency, nil)
+		} else {
+			c.miss(key)
+			c.fire(ctx, hookMiss, "get", key, latency, nil)
+		}
+	}
+	span.SetAttributes(attribute.Int("cache.hits", len(values)))
+	return values, nil
+}
+
+// SetMany stores several values in one round trip using the default
+// expiration, failing with ErrNoDefaultTTL when none was configured
+func (c *cache) SetMany(ctx context.Context, values map[string]interface{}, opts ...SetOption) error {
+	return c.SetManyWithTTL(ctx, values, c.defaultTTL, opts...)
+}
+
+// SetManyWithTTL stores several values that expire after ttl in one round
+// trip. With WithTTLJitter the whole batch shares one randomized ttl.
+func (c *cache) SetManyWithTTL(ctx context.Context, values map[string]interface{}, ttl time.Duration, opts ...SetOption) (err error) {
+	if len(values) == 0 {
+		return nil
+	}
+	if ttl == NoDefaultTTL {
+		return ErrNoDefaultTTL
+	}
+
+	start := time.Now()
+	ctx, span := c.startSpan(ctx, "SetMany", attribute.Int("cache.count", len(values)))
+	defer func() { endSpan(span, start, err) }()
+
+	o := &setOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	values, err = c.withoutOversized(ctx, values)
+	if err != nil || len(values) == 0 {
+		return err
+	}
+	err = c.Cache.SetMany(ctx, values, c.jitter(ttl))
+	for key := range values {
+		if err != nil {
+			c.fire(ctx, hookError, "set", key, time.Since(start), err)
+		} else {
+			c.fire(ctx, hookSet, "set", key, time.Since(start), nil)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	for key := range values {
+		if err := c.tag(ctx, key, o.tags); err != nil {
+			return err
+		}
+	}
+	return nil
+}
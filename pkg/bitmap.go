@@ -0,0 +1,109 @@
+package pkg
+
+import (
+	"cacher/internal/adapters"
+	"context"
+	"time"
+)
+
+// bitmapDay is the layout of the day in the key of a Bitmap bucket
+const bitmapDay = "2006-01-02"
+
+// bitmapScratchTTL bounds how long the result of a range or retention query
+// can linger if the process dies before deleting it
+const bitmapScratchTTL = time.Minute
+
+// Bitmap tracks which numeric ids, such as user ids, were active on each day
+// with one bit per id in a bitmap per UTC day. A million ids take 125KB a day,
+// and counting the active ids of a day, a month or those active on two days
+// runs in the backend without reading the bitmaps back.
+type Bitmap struct {
+	server adapters.CacheServer
+	name   string
+}
+
+// NewBitmap creates the Bitmap called name over the backend of c
+func NewBitmap(c Cache, name string) (*Bitmap, error) {
+	configured, ok := c.(*cache)
+	if !ok {
+		return nil, ErrUnsupported
+	}
+	server, err := configured.server()
+	if err != nil {
+		return nil, err
+	}
+	return &Bitmap{server: server, name: name}, nil
+}
+
+// key returns the key of the bucket of the day of t
+func (b *Bitmap) key(t time.Time) string {
+	return "bitmap:" + b.name + ":" + t.UTC().Format(bitmapDay)
+}
+
+// days returns the keys of the buckets from the day of from to the day of to,
+// both included
+func (b *Bitmap) days(from, to time.Time) []string {
+	var keys []string
+	day := from.UTC().Truncate(24 * time.Hour)
+	for end := to.UTC(); !day.After(end); day = day.AddDate(0, 0, 1) {
+		keys = append(keys, b.key(day))
+	}
+	return keys
+}
+
+// Mark records id as active on the day of t
+func (b *Bitmap) Mark(ctx context.Context, id int64, t time.Time) error {
+	_, err := b.server.SetBit(ctx, b.key(t), id, 1)
+	return backendError(err)
+}
+
+// Active reports whether id was marked on the day of t
+func (b *Bitmap) Active(ctx context.Context, id int64, t time.Time) (bool, error) {
+	bit, err := b.server.GetBit(ctx, b.key(t), id)
+	return bit == 1, backendError(err)
+}
+
+// Count returns how many ids were active on the day of t, the daily active
+// users
+func (b *Bitmap) Count(ctx context.Context, t time.Time) (int64, error) {
+	count, err := b.server.BitCount(ctx, b.key(t))
+	return count, backendError(err)
+}
+
+// CountRange returns how many ids were active on at least one day from the
+// day of from to the day of to, e.g. the monthly active users over 30 days
+func (b *Bitmap) CountRange(ctx context.Context, from, to time.Time) (int64, error) {
+	days := b.days(from, to)
+	if len(days) == 0 {
+		return 0, nil
+	}
+	return b.combine(ctx, "OR", days)
+}
+
+// Retained returns how many ids active on the day of first were active on the
+// day of later too
+func (b *Bitmap) Retained(ctx context.Context, first, later time.Time) (int64, error) {
+	return b.combine(ctx, "AND", []string{b.key(first), b.key(later)})
+}
+
+// Delete removes the bucket of the day of t
+func (b *Bitmap) Delete(ctx context.Context, t time.Time) error {
+	_, err := b.server.Delete(ctx, b.key(t))
+	return backendError(err)
+}
+
+// combine counts the bits of op over the buckets keys in a scratch key,
+// deleted afterwards
+func (b *Bitmap) combine(ctx context.Context, op string, keys []string) (int64, error) {
+	scratch := "bitmap:" + b.name + ":scratch:" + randomToken()
+	defer b.server.Delete(context.WithoutCancel(ctx), scratch)
+
+	if _, err := b.server.BitOp(ctx, op, scratch, keys...); err != nil {
+		return 0, backendError(err)
+	}
+	if _, err := b.server.Expire(ctx, scratch, bitmapScratchTTL); err != nil {
+		return 0, backendError(err)
+	}
+	count, err := b.server.BitCount(ctx, scratch)
+	return count, backendError(err)
+}
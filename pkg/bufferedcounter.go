@@ -0,0 +1,130 @@
+package pkg
+
+import (
+	"cacher/internal/adapters"
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Counter counts locally and adds what it counted to the integer stored at a
+// key every interval, or as soon as threshold is reached, with one Increment.
+// High frequency counts such as request metrics then cost one round trip per
+// flush instead of one per count, at the price of the backend lagging behind
+// by up to an interval. Counts not flushed yet are lost if the process dies.
+type Counter struct {
+	c         Cache
+	key       string
+	threshold int64
+	opts      []CounterOption
+	logger    Logger
+	pending   atomic.Int64
+	flush     chan struct{}
+	stop      chan struct{}
+	stopped   sync.WaitGroup
+	once      sync.Once
+	mutex     sync.Mutex
+}
+
+// NewCounter creates a Counter adding to key every interval unless it is 0
+// or less, and once the pending count reaches threshold in either direction
+// unless it is 0. Without either, counts are only added by Flush and Close.
+// opts apply to every flush, e.g. WithCounterTTL.
+func NewCounter(c Cache, key string, interval time.Duration, threshold int64, opts ...CounterOption) *Counter {
+	counter := &Counter{
+		c:         c,
+		key:       key,
+		threshold: threshold,
+		opts:      opts,
+		logger:    adapters.NopLogger(),
+		flush:     make(chan struct{}, 1),
+		stop:      make(chan struct{}),
+	}
+	if configured, ok := c.(*cache); ok {
+		counter.logger = configured.logger
+	}
+	counter.stopped.Add(1)
+	go counter.run(interval)
+	return counter
+}
+
+// Add counts delta without waiting for the backend
+func (c *Counter) Add(delta int64) {
+	pending := c.pending.Add(delta)
+	if c.threshold > 0 && (pending >= c.threshold || pending <= -c.threshold) {
+		select {
+		case c.flush <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Increment counts one
+func (c *Counter) Increment() {
+	c.Add(1)
+}
+
+// Pending returns what was counted since the last flush
+func (c *Counter) Pending() int64 {
+	return c.pending.Load()
+}
+
+// Value returns the value at the key plus what is pending
+func (c *Counter) Value(ctx context.Context) (int64, error) {
+	// Hold off flushes so nothing is counted twice or missed
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	stored, err := c.c.Increment(ctx, c.key, 0, c.opts...)
+	if err != nil {
+		return 0, err
+	}
+	return stored + c.Pending(), nil
+}
+
+// Flush adds what is pending to the key now. When the backend fails the
+// count stays pending for the next flush.
+func (c *Counter) Flush(ctx context.Context) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	delta := c.pending.Swap(0)
+	if delta == 0 {
+		return nil
+	}
+	if _, err := c.c.Increment(ctx, c.key, delta, c.opts...); err != nil {
+		c.pending.Add(delta)
+		return err
+	}
+	return nil
+}
+
+// Close stops the periodic flushes and flushes what is pending
+func (c *Counter) Close(ctx context.Context) error {
+	c.once.Do(func() {
+		close(c.stop)
+	})
+	c.stopped.Wait()
+	return c.Flush(ctx)
+}
+
+func (c *Counter) run(interval time.Duration) {
+	defer c.stopped.Done()
+	// A nil channel never ticks, leaving flushes to the threshold
+	var ticks <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		ticks = ticker.C
+	}
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticks:
+		case <-c.flush:
+		}
+		if err := c.Flush(context.Background()); err != nil {
+			c.logger.Warn("cache backend error", "key", c.key, "error", err)
+		}
+	}
+}
@@ -1,99 +1,417 @@
 package pkg
 
 import (
+	"cacher/codec"
 	"cacher/internal/adapters"
 	"context"
 	"errors"
 	"fmt"
 	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/sync/singleflight"
+	"io"
+	"iter"
+	"math/rand/v2"
+	"sync"
 	"sync/atomic"
 	"time"
 )
 
+// KeepTTL keeps the current expiration of a key when it is overwritten. New
+// keys written with KeepTTL never expire.
+const KeepTTL = redis.KeepTTL
+
+// DefaultTTL is the expiration applied by Set and Wrap unless WithDefaultTTL is
+// given. It is NoDefaultTTL, so writes without an explicit expiration fail
+// with ErrNoDefaultTTL; choose Forever or KeepTTL to store them without one.
+// SetWithTTL and WrapTTL take an explicit expiration instead.
+var DefaultTTL time.Duration = NoDefaultTTL
+
+// ErrCacheMiss is returned by Get when the key does not exist. Any other error
+// means the backend could not be reached or failed.
+var ErrCacheMiss = errors.New("cache miss")
+
+// ErrInvalidEncryptionKey is returned by Open, and by every operation of a
+// cache created by NewCache, when a key given to WithEncryption is not 16, 24
+// or 32 bytes long
+var ErrInvalidEncryptionKey = errors.New("invalid encryption key")
+
+// ErrNotEncrypted is returned when a cache with WithEncryption reads a value
+// that was stored without encryption, see WithPlaintextReads
+var ErrNotEncrypted = adapters.ErrNotEncrypted
+
 type cache struct {
-	hitStats         statsMap
-	missStats        statsMap
-	hitLatency       uint64 // Stores the cumulative latency for hits
-	hitCount         uint64 // Tracks the total number of hits
-	statsTimer       *time.Ticker
-	statsTimerStop   chan bool
-	RecordStatistics bool
-	Cache            adapters.Cache
+	err                 error // Configuration error every operation fails with
+	hitStats            statsMap
+	missStats           statsMap
+	deleteStats         statsMap
+	errorStats          statsMap
+	oversizeStats       statsMap
+	latencies           latencyMap
+	hotReads            *topK
+	hotMisses           *topK
+	hitLatency          uint64 // Stores the cumulative latency for hits
+	hitCount            uint64 // Tracks the total number of hits
+	reporter            StatsReporter
+	statsTimerStop      chan bool
+	statsDone           chan struct{}
+	closeOnce           sync.Once
+	defaultTTL          time.Duration
+	ttlJitter           float64
+	negativeTTL         time.Duration
+	xfetchBeta          float64
+	slidingTTL          time.Duration
+	codec               codec.Codec
+	compression         adapters.Compression
+	compressAbove       int
+	keyring             *adapters.Keyring
+	maxValueSize        int
+	oversizePolicy      OversizePolicy
+	loads               singleflight.Group // Deduplicates concurrent loader calls per key
+	contextLoads        singleflight.Group // Like loads for loaders that fail, so Wrap never gets their errors
+	tracer              trace.Tracer
+	logger              Logger
+	refresher           *refresher
+	writeBehind         *writeBehind
+	loaders             loaders
+	sharedStats         *sharedStats
+	snapshots           *snapshots
+	hooks               hooks
+	keyFilter           *keyFilter
+	backend             string
+	flushRequiresPrefix bool
+	RecordStatistics    bool
+	Cache               adapters.Cache
 }
 
 type Cache interface {
 	Wrap(ctx context.Context, key string, value func() interface{}) interface{}
+	WrapTTL(ctx context.Context, key string, ttl time.Duration, value func() interface{}) interface{}
+	RememberForever(ctx context.Context, key string, value func() interface{}) interface{}
+	WrapContext(ctx context.Context, key string, load LoadFunc) (interface{}, error)
+	WrapTTLContext(ctx context.Context, key string, ttl time.Duration, load LoadFunc) (interface{}, error)
+	RememberForeverContext(ctx context.Context, key string, load LoadFunc) (interface{}, error)
+	WrapWithRefresh(ctx context.Context, key string, ttl time.Duration, value func() interface{}) interface{}
 	KeyStatistics(ctx context.Context, key string) (map[string]uint64, error)
 	Statistics(ctx context.Context) map[string]map[string]uint64
+	HotKeys(ctx context.Context, n int) HotKeys
+	SharedStatistics(ctx context.Context) (map[string]map[string]uint64, error)
 	Get(ctx context.Context, key string) (interface{}, error)
-	Set(ctx context.Context, key string, value interface{}) error
+	Has(ctx context.Context, key string) (bool, error)
+	Pull(ctx context.Context, key string) (interface{}, error)
+	GetSet(ctx context.Context, key string, value interface{}) (interface{}, error)
+	TTL(ctx context.Context, key string) (time.Duration, error)
+	GetWithTTL(ctx context.Context, key string) (interface{}, time.Duration, error)
+	Touch(ctx context.Context, key string, ttl time.Duration) error
+	RegisterLoader(pattern string, loader Loader)
+	Set(ctx context.Context, key string, value interface{}, opts ...SetOption) error
+	SetWithTTL(ctx context.Context, key string, value interface{}, ttl time.Duration, opts ...SetOption) error
+	SetForever(ctx context.Context, key string, value interface{}, opts ...SetOption) error
+	Update(ctx context.Context, key string, fn UpdateFunc) error
+	SetAsync(ctx context.Context, key string, value interface{}, ttl time.Duration) error
+	Increment(ctx context.Context, key string, delta int64, opts ...CounterOption) (int64, error)
+	Decrement(ctx context.Context, key string, delta int64, opts ...CounterOption) (int64, error)
+	IncrementFloat(ctx context.Context, key string, delta float64, opts ...CounterOption) (float64, error)
+	DecrementFloat(ctx context.Context, key string, delta float64, opts ...CounterOption) (float64, error)
+	Delete(ctx context.Context, key string) error
+	DeleteMany(ctx context.Context, keys ...string) error
+	GetMany(ctx context.Context, keys ...string) (map[string]interface{}, error)
+	SetMany(ctx context.Context, values map[string]interface{}, opts ...SetOption) error
+	SetManyWithTTL(ctx context.Context, values map[string]interface{}, ttl time.Duration, opts ...SetOption) error
+	Pipeline() *Pipeline
+	Txn(ctx context.Context, fn func(tx Tx) error, watchKeys ...string) error
+	InvalidateTag(ctx context.Context, tag string) error
+	KeyFilter() *Filter
+	Namespace(name string) *Namespace
+	Keys(ctx context.Context, pattern string) iter.Seq2[string, error]
+	Warm(ctx context.Context, source WarmSource, opts ...WarmOption) (WarmProgress, error)
+	DeleteByPattern(ctx context.Context, pattern string) (int64, error)
+	Flush(ctx context.Context) error
+	FlushPrefix(ctx context.Context) error
+	Allow(ctx context.Context, key string, rate float64, burst int64) (bool, error)
+	Throttle(ctx context.Context, key string, maxBurst, count int64, period time.Duration, quantity int64) (RateLimitResult, error)
+	Lock(ctx context.Context, name string, ttl time.Duration, opts ...LockOption) (*Lock, error)
+	TryLock(ctx context.Context, name string, ttl time.Duration, opts ...LockOption) (*Lock, error)
+	Elect(ctx context.Context, name string, ttl time.Duration, opts ...LockOption) (*Leadership, error)
+	Acquire(ctx context.Context, name string, max int64, ttl time.Duration) (*Permit, error)
+	TryAcquire(ctx context.Context, name string, max int64, ttl time.Duration) (*Permit, error)
 	AverageHitLatency(ctx context.Context) float64
+	TierStatistics(ctx context.Context) map[string]map[string]uint64
+	CircuitBreakerStatistics(ctx context.Context) map[string]uint64
+	FallbackStatistics(ctx context.Context) map[string]map[string]uint64
+	MigrationStatistics(ctx context.Context) map[string]uint64
+	EvictionStatistics(ctx context.Context) map[string]uint64
+	Snapshot(ctx context.Context, w io.Writer) error
+	Restore(ctx context.Context, r io.Reader) error
+	Export(ctx context.Context, w io.Writer, pattern string) (int64, error)
+	Import(ctx context.Context, r io.Reader) (int64, error)
+	CutoverMigration(ctx context.Context) error
+	RollbackMigration(ctx context.Context) error
+	Ping(ctx context.Context) error
+	Health(ctx context.Context) Health
+	OnHit(hook Hook)
+	OnMiss(hook Hook)
+	OnSet(hook Hook)
+	OnDelete(hook Hook)
+	OnError(hook Hook)
+	Close(ctx context.Context) error
 }
 
+// Wrap returns the cached value for key, computing and storing it with the
+// default expiration on a miss. Without a default expiration the computed
+// value is returned but not stored.
 func (c *cache) Wrap(ctx context.Context, key string, value func() interface{}) interface{} {
-	if cachedValue, err := c.Get(ctx, key); err == nil && cachedValue != nil {
+	return c.WrapTTL(ctx, key, c.defaultTTL, value)
+}
+
+// WrapTTL behaves like Wrap but stores computed values with the given expiration
+func (c *cache) WrapTTL(ctx context.Context, key string, ttl time.Duration, value func() interface{}) interface{} {
+	start := time.Now()
+	ctx, span := c.startSpan(ctx, "Wrap", attribute.String("cache.key", key))
+	defer endSpan(span, start, nil)
+
+	if c.xfetchBeta > 0 && ttl > 0 {
+		// Wrap returns ErrNotFound in place of the value
+		result, err := c.wrapXFetch(ctx, key, ttl, func(context.Context) (interface{}, error) {
+			return value(), nil
+		})
+		if err != nil {
+			return err
+		}
+		return result
+	}
+
+	cachedValue, err := c.Get(ctx, key)
+	if errors.Is(err, ErrNotFound) || err == nil && cachedValue != nil {
+		span.SetAttributes(attribute.Bool("cache.hit", true))
+		if err != nil {
+			return err
+		}
 		return cachedValue
 	}
+	span.SetAttributes(attribute.Bool("cache.hit", false))
+	if c.filtered(ctx, key) {
+		return ErrNotFound
+	}
 
-	// Simulate a cache miss
-	result := value()
-	_ = c.Set(ctx, key, result)
+	// Only one loader runs per key, concurrent callers share its result
+	result, _, _ := c.loads.Do(key, func() (interface{}, error) {
+		loadStart := time.Now()
+		result := value()
+		c.loaded(key, time.Since(loadStart))
+		_ = c.store(ctx, key, result, ttl)
+		return result, nil
+	})
 	return result
 }
 
+// Get retrieves the value stored at key, loading it with the loader
+// registered for key, if any, on a miss. It returns ErrCacheMiss when the key
+// does not exist, ErrNotFound when a loader reported that the value does not
+// exist and a wrapped backend error when the lookup itself failed.
 func (c *cache) Get(ctx context.Context, key string) (interface{}, error) {
 	start := time.Now() // Start tracking latency
+	ctx, span := c.startSpan(ctx, "Get", attribute.String("cache.key", key))
+
+	data, local, err := c.lookup(ctx, key)
+	defer endSpan(span, start, err)
+	span.SetAttributes(attribute.Bool("cache.hit", err == nil))
 
+	switch {
+	case errors.Is(err, redis.Nil):
+		c.recordMiss(ctx, key, start)
+		if value, ok, err := c.readThrough(ctx, key); ok {
+			return value, err
+		}
+		return nil, ErrCacheMiss
+	case err != nil:
+		return nil, c.recordFailure(ctx, key, start, err)
+	}
+
+	c.recordHit(ctx, key, start, data)
+	// A cached "not found" result counts as a hit
+	if data == tombstone {
+		return nil, ErrNotFound
+	}
+	if !local {
+		c.slide(ctx, key)
+	}
+	return data, nil
+}
+
+// lookup reads key from the driver, reporting whether the local tier served it
+func (c *cache) lookup(ctx context.Context, key string) (interface{}, bool, error) {
+	if tiered, ok := c.Cache.(*adapters.Tiered); ok {
+		return tiered.Lookup(ctx, key)
+	}
 	data, err := c.Cache.Get(ctx, key)
-	if data == nil && err != nil {
-		c.miss(key)
-	} else {
-		c.hit(key)
+	return data, false, err
+}
+
+// recordMiss records a lookup of key started at start that missed
+func (c *cache) recordMiss(ctx context.Context, key string, start time.Time) {
+	c.miss(key)
+	c.missLatencyOf(key, time.Since(start))
+	c.logger.Debug("cache miss", "key", key)
+	c.fire(ctx, hookMiss, "get", key, time.Since(start), nil)
+}
+
+// recordFailure records a lookup of key started at start that failed with
+// err and returns err wrapped as a backend error
+func (c *cache) recordFailure(ctx context.Context, key string, start time.Time, err error) error {
+	c.failed(key)
+	c.logger.Warn("cache backend error", "key", key, "backend", c.backend, "error", err)
+	c.fire(ctx, hookError, "get", key, time.Since(start), err)
+	return fmt.Errorf("cache backend: %w", err)
+}
 
-		// Update hit latency
-		latency := uint64(time.Since(start).Microseconds()) // Convert duration to microseconds
-		atomic.AddUint64(&c.hitLatency, latency)
-		atomic.AddUint64(&c.hitCount, 1)
+// recordHit records a lookup of key started at start that found data. A
+// cached "not found" result counts as a hit, but not for latencies and hooks.
+func (c *cache) recordHit(ctx context.Context, key string, start time.Time, data interface{}) {
+	c.hit(key)
+	c.logger.Debug("cache hit", "key", key)
+	if data == tombstone {
+		return
 	}
 
-	return data, err
+	// Update hit latency
+	elapsed := time.Since(start)
+	c.hitLatencyOf(key, elapsed)
+	c.fire(ctx, hookHit, "get", key, elapsed, nil)
+	latency := uint64(elapsed.Microseconds()) // Convert duration to microseconds
+	atomic.AddUint64(&c.hitLatency, latency)
+	atomic.AddUint64(&c.hitCount, 1)
 }
 
-func (c *cache) Set(ctx context.Context, key string, value interface{}) error {
-	return c.Cache.Set(ctx, key, value)
+// Set stores a value with the expiration given with WithDefaultTTL. It fails
+// with ErrNoDefaultTTL when none was configured, use SetWithTTL or SetForever
+// then.
+func (c *cache) Set(ctx context.Context, key string, value interface{}, opts ...SetOption) error {
+	return c.SetWithTTL(ctx, key, value, c.defaultTTL, opts...)
 }
 
+// SetWithTTL stores a value that expires after ttl. A zero ttl never expires.
+func (c *cache) SetWithTTL(ctx context.Context, key string, value interface{}, ttl time.Duration, opts ...SetOption) (err error) {
+	start := time.Now()
+	ctx, span := c.startSpan(ctx, "Set", attribute.String("cache.key", key))
+	defer func() { endSpan(span, start, err) }()
+
+	if ttl == NoDefaultTTL {
+		return ErrNoDefaultTTL
+	}
+	if skip, err := c.oversized(ctx, key, value); skip {
+		return err
+	}
+	o := &setOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	if err := c.Cache.Set(ctx, key, value, c.jitter(ttl)); err != nil {
+		c.fire(ctx, hookError, "set", key, time.Since(start), err)
+		return err
+	}
+	c.fire(ctx, hookSet, "set", key, time.Since(start), nil)
+	return c.tag(ctx, key, o.tags)
+}
+
+// jitter randomizes ttl by up to the fraction given with WithTTLJitter in
+// either direction. Keys that never expire are left alone.
+func (c *cache) jitter(ttl time.Duration) time.Duration {
+	if ttl <= 0 || c.ttlJitter <= 0 {
+		return ttl
+	}
+	delta := (rand.Float64()*2 - 1) * c.ttlJitter * float64(ttl)
+	return max(ttl+time.Duration(delta), time.Millisecond)
+}
+
+// Delete removes a key from the cache
+func (c *cache) Delete(ctx context.Context, key string) error {
+	return c.DeleteMany(ctx, key)
+}
+
+// DeleteMany removes all given keys from the cache in a single call
+func (c *cache) DeleteMany(ctx context.Context, keys ...string) (err error) {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	start := time.Now()
+	ctx, span := c.startSpan(ctx, "Delete", attribute.StringSlice("cache.keys", keys))
+	defer func() { endSpan(span, start, err) }()
+
+	backendKeys := keys
+	if c.xfetchBeta > 0 {
+		// With the metadata WrapTTL keeps next to them
+		backendKeys = make([]string, 0, 2*len(keys))
+		for _, key := range keys {
+			backendKeys = append(backendKeys, key, xfetchKey(key))
+		}
+	}
+	if err := c.Cache.Delete(ctx, backendKeys...); err != nil {
+		for _, key := range keys {
+			c.fire(ctx, hookError, "delete", key, time.Since(start), err)
+		}
+		return err
+	}
+	for _, key := range keys {
+		c.deleted(key)
+		c.fire(ctx, hookDelete, "delete", key, time.Since(start), nil)
+	}
+	return nil
+}
+
+// KeyStatistics returns the hits, misses, deletes and errors of key, its hit
+// ratio and the p50, p95 and p99 latencies in microseconds of hits, misses
+// and loader calls, e.g. "hit_p99_us", once any were recorded. Writes dropped
+// by WithMaxValueSize are counted as "oversized".
 func (c *cache) KeyStatistics(ctx context.Context, key string) (map[string]uint64, error) {
 	hitCount := c.hitStats.get(key)
 	missCount := c.missStats.get(key)
-	if hitCount == 0 && missCount == 0 {
+	deleteCount := c.deleteStats.get(key)
+	errorCount := c.errorStats.get(key)
+	oversizeCount := c.oversizeStats.get(key)
+	if hitCount == 0 && missCount == 0 && deleteCount == 0 && errorCount == 0 && oversizeCount == 0 {
 		return nil, errors.New("no statistics available for the given key")
 	}
 
-	return map[string]uint64{
-		"hits":   hitCount,
-		"misses": missCount,
-	}, nil
+	stats := map[string]uint64{
+		"hits":              hitCount,
+		"misses":            missCount,
+		"deletes":           deleteCount,
+		"errors":            errorCount,
+		"hit_ratio_percent": hitRatio(hitCount, missCount),
+	}
+	if oversizeCount > 0 {
+		stats["oversized"] = oversizeCount
+	}
+	c.latencies.percentiles(key, stats)
+	return stats, nil
 }
 
+// Statistics returns the statistics of every key as KeyStatistics does
 func (c *cache) Statistics(ctx context.Context) map[string]map[string]uint64 {
-	hits := c.hitStats.getAll()
-	misses := c.missStats.getAll()
-
 	stats := make(map[string]map[string]uint64)
-	for key, hitCount := range hits {
-		if stats[key] == nil {
-			stats[key] = map[string]uint64{}
-		}
-		stats[key]["hits"] = hitCount
-	}
-	for key, missCount := range misses {
-		if stats[key] == nil {
-			stats[key] = map[string]uint64{}
+	merge := func(name string, counts map[string]uint64) {
+		for key, count := range counts {
+			if stats[key] == nil {
+				stats[key] = map[string]uint64{}
+			}
+			stats[key][name] = count
 		}
-		stats[key]["misses"] = missCount
 	}
 
+	merge("hits", c.hitStats.getAll())
+	merge("misses", c.missStats.getAll())
+	merge("deletes", c.deleteStats.getAll())
+	merge("errors", c.errorStats.getAll())
+	merge("oversized", c.oversizeStats.getAll())
+
+	for key, counts := range stats {
+		counts["hit_ratio_percent"] = hitRatio(counts["hits"], counts["misses"])
+		c.latencies.percentiles(key, counts)
+	}
 	return stats
 }
 
@@ -107,37 +425,250 @@ func (c *cache) AverageHitLatency(ctx context.Context) float64 {
 	return float64(totalLatency) / float64(hitCount)
 }
 
-func NewCache(recordStatistics bool) Cache {
+// TierStatistics returns per tier hit and miss counters when the cache was
+// created WithLocalTier, and nil otherwise.
+func (c *cache) TierStatistics(ctx context.Context) map[string]map[string]uint64 {
+	if tiered, ok := c.Cache.(*adapters.Tiered); ok {
+		return tiered.TierStatistics()
+	}
+	return nil
+}
+
+// CircuitBreakerStatistics returns the state of the circuit breaker when the
+// cache was created WithCircuitBreaker, and nil otherwise. "open" and
+// "half_open" are 1 in the respective state, "failures" counts the current
+// run of consecutive errors, "trips" how often the breaker opened and
+// "rejected" the requests kept from the backend.
+func (c *cache) CircuitBreakerStatistics(ctx context.Context) map[string]uint64 {
+	if breaker, ok := adapters.FindBreaker(c.Cache); ok {
+		return breaker.BreakerStatistics()
+	}
+	return nil
+}
+
+// FallbackStatistics returns the reads and the health of every backend when
+// the cache was created WithFallback, and nil otherwise. Backends are keyed by
+// their position and name, e.g. "0:redis", and report "hits", "misses",
+// "skipped" reads that failed or were not attempted, and the state of their
+// circuit breaker as CircuitBreakerStatistics does.
+func (c *cache) FallbackStatistics(ctx context.Context) map[string]map[string]uint64 {
+	if chain, ok := adapters.FindFallbackChain(c.Cache); ok {
+		return chain.FallbackStatistics()
+	}
+	return nil
+}
+
+// MigrationStatistics returns how the reads compared between the old and the
+// new backend when the cache was created WithMigrationFrom, and nil otherwise.
+// "compared" reads were repeated on the other backend, which had the same
+// value for "matches", lacked "missing" entries and had a different value for
+// "mismatches". "backfilled" entries were copied to the new backend,
+// "other_errors" counts failures of the backend not serving reads and
+// "cutover" is 1 once the new backend serves them.
+func (c *cache) MigrationStatistics(ctx context.Context) map[string]uint64 {
+	if migrating, ok := adapters.FindMigrating(c.Cache); ok {
+		return migrating.MigrationStatistics()
+	}
+	return nil
+}
+
+// EvictionStatistics returns the evictions of the memory of NewMemoryCache
+// when it was bounded WithMemoryLimits, and nil otherwise. "evictions" counts
+// the keys evicted and "evicted_bytes" their size, "entries" and "bytes" what
+// the memory holds now.
+func (c *cache) EvictionStatistics(ctx context.Context) map[string]uint64 {
+	if memory, ok := c.memory(); ok {
+		return memory.EvictionStatistics()
+	}
+	return nil
+}
+
+// CutoverMigration serves reads from the new backend of WithMigrationFrom.
+// Writes still go to both backends until the old one is removed from the
+// configuration, so RollbackMigration can undo it.
+func (c *cache) CutoverMigration(ctx context.Context) error {
+	migrating, ok := adapters.FindMigrating(c.Cache)
+	if !ok {
+		return ErrUnsupported
+	}
+	migrating.Cutover()
+	return nil
+}
+
+// RollbackMigration serves reads from the old backend of WithMigrationFrom
+// again
+func (c *cache) RollbackMigration(ctx context.Context) error {
+	migrating, ok := adapters.FindMigrating(c.Cache)
+	if !ok {
+		return ErrUnsupported
+	}
+	migrating.Rollback()
+	return nil
+}
+
+// NewCache creates a cache configured by the given options. Without options it
+// connects to Redis on localhost:6379.
+func NewCache(opts ...Option) Cache {
+	return newCache(newOptions(opts))
+}
+
+// NewMemoryCache creates a cache backed by the in-process memory adapter
+// instead of Redis, useful for unit tests and small services.
+func NewMemoryCache(opts ...Option) Cache {
+	opts = append([]Option{withServer(newOptions(opts).memory())}, opts...)
+	return NewCache(opts...)
+}
+
+// NewNullCache creates a cache that stores nothing, see WithDisabled
+func NewNullCache(opts ...Option) Cache {
+	opts = append([]Option{WithDisabled(true)}, opts...)
+	return NewCache(opts...)
+}
+
+// NewBoltCache creates a cache persisted in the bbolt database file at path,
+// for single node services that want cached values to survive a restart
+// without running Redis
+func NewBoltCache(path string, opts ...Option) (Cache, error) {
+	server, err := adapters.OpenBolt(path)
+	if err != nil {
+		return nil, err
+	}
+	opts = append([]Option{withServer(server)}, opts...)
+	return NewCache(opts...), nil
+}
+
+// NewBadgerCache creates a cache persisted in the badger database in dir, a
+// faster alternative to NewBoltCache for write heavy workloads
+func NewBadgerCache(dir string, opts ...Option) (Cache, error) {
+	server, err := adapters.OpenBadger(dir)
+	if err != nil {
+		return nil, err
+	}
+	opts = append([]Option{withServer(server)}, opts...)
+	return NewCache(opts...), nil
+}
+
+// NewRistrettoCache creates an in-process cache bounded to maxBytes of
+// serialized entries, admitting and evicting keys by how often they are used
+func NewRistrettoCache(maxBytes int64, opts ...Option) (Cache, error) {
+	server, err := adapters.NewRistretto(maxBytes)
+	if err != nil {
+		return nil, err
+	}
+	opts = append([]Option{withServer(server)}, opts...)
+	return NewCache(opts...), nil
+}
 
-	redisClient := adapters.Redis(&adapters.RedisClient{Client: redis.NewClient(&redis.Options{
-		Addr: "localhost:6379",
-	})})
+func newCache(o *options) *cache {
+	var configErr error
+	if len(o.encryptionKeys) > 0 {
+		keyring, err := adapters.NewKeyring(o.encryptionKeys[0], o.encryptionKeys[1:]...)
+		if err != nil {
+			configErr = fmt.Errorf("%w: %w", ErrInvalidEncryptionKey, err)
+		} else {
+			keyring.AllowPlaintext = o.plaintextReads
+		}
+		o.keyring = keyring
+	}
+	var driver adapters.Cache = misconfigured{configErr}
+	if configErr == nil {
+		driver = o.driver()
+	}
 	c := &cache{
-		hitStats:         newStatsMap(),
-		missStats:        newStatsMap(),
-		statsTimer:       time.NewTicker(1 * time.Second),
-		statsTimerStop:   make(chan bool),
-		RecordStatistics: recordStatistics,
-		Cache:            adapters.NewCache(redisClient),
-	}
-
-	go func() {
-		for {
-			select {
-			case <-c.statsTimer.C:
-				fmt.Println("Periodic stats update:", c.Statistics(context.Background()))
-				fmt.Printf("Average Hit Latency: %.2fµs\n", c.AverageHitLatency(context.Background()))
-			case <-c.statsTimerStop:
-				fmt.Println("Ticker stopped")
-				c.statsTimer.Stop()
-				return
-			}
+		err:                 configErr,
+		hitStats:            newStatsMap(),
+		missStats:           newStatsMap(),
+		deleteStats:         newStatsMap(),
+		errorStats:          newStatsMap(),
+		oversizeStats:       newStatsMap(),
+		maxValueSize:        o.maxValueSize,
+		oversizePolicy:      o.oversizePolicy,
+		latencies:           newLatencyMap(),
+		reporter:            o.reporter,
+		statsTimerStop:      make(chan bool),
+		statsDone:           make(chan struct{}),
+		defaultTTL:          o.defaultTTL,
+		ttlJitter:           o.ttlJitter,
+		negativeTTL:         o.negativeTTL,
+		xfetchBeta:          o.xfetchBeta,
+		slidingTTL:          o.slidingTTL,
+		codec:               o.codec,
+		compression:         o.compression,
+		compressAbove:       o.compressThreshold,
+		keyring:             o.keyring,
+		flushRequiresPrefix: o.flushRequiresPrefix,
+		tracer:              o.tracerProvider.Tracer(tracerName),
+		logger:              o.logger,
+		RecordStatistics:    o.recordStatistics,
+		Cache:               driver,
+	}
+	c.backend = adapters.BackendName(c.Cache)
+	if o.hotKeys > 0 {
+		c.hotReads = newTopK(o.hotKeys)
+		c.hotMisses = newTopK(o.hotKeys)
+	}
+	if o.filterPattern != "" {
+		c.keyFilter = &keyFilter{pattern: o.filterPattern, items: o.filterItems, rate: o.filterRate}
+	}
+	c.snapshots = newSnapshots(c, o)
+	c.refresher = newRefresher(c, o)
+	c.writeBehind = newWriteBehind(c, o)
+	if o.sharedStatsName != "" {
+		c.sharedStats = &sharedStats{
+			cache:    c,
+			name:     o.sharedStatsName,
+			interval: max(o.sharedStatsInterval, time.Second),
+			flushed:  make(map[string]map[string]uint64),
+			stop:     make(chan struct{}),
+			done:     make(chan struct{}),
 		}
-	}()
+		go c.sharedStats.run()
+	}
+
+	if c.reporter != nil {
+		go c.reportStatistics(max(o.reportInterval, time.Millisecond))
+	} else {
+		close(c.statsDone)
+	}
 
 	return c
 }
 
+// Close stores queued SetAsync writes, saves the snapshot of WithSnapshotFile,
+// stops the statistics reporter after a final report and closes the
+// underlying adapter, including a client given with WithRedisClient. It
+// waits for the flush until ctx is done.
+func (c *cache) Close(ctx context.Context) error {
+	var err error
+	c.closeOnce.Do(func() {
+		c.refresher.close()
+		err = c.writeBehind.close(ctx)
+		if c.snapshots != nil {
+			if saveErr := c.snapshots.close(); saveErr != nil && err == nil {
+				err = saveErr
+			}
+		}
+		if c.sharedStats != nil {
+			if flushErr := c.sharedStats.close(ctx); flushErr != nil && err == nil {
+				err = flushErr
+			}
+		}
+		close(c.statsTimerStop)
+		select {
+		case <-c.statsDone:
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+
+		if closeErr := c.Cache.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	})
+	return err
+}
+
+// WrapType calls Wrap and asserts the result to T, panicking if the cached value
+// has a different type. Use TypedCache to decode values safely.
 func WrapType[T any](ctx context.Context, key string, cache Cache, value func() T) T {
 	result := cache.Wrap(ctx, key, func() interface{} {
 		return value()
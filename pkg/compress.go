@@ -0,0 +1,13 @@
+package pkg
+
+import "cacher/internal/adapters"
+
+// Compression names the algorithm WithCompression stores large values with
+type Compression = adapters.Compression
+
+const (
+	NoCompression = adapters.NoCompression
+	Gzip          = adapters.Gzip
+	Snappy        = adapters.Snappy
+	Zstd          = adapters.Zstd
+)
@@ -0,0 +1,73 @@
+package pkg
+
+import (
+	"cacher/config"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// NewFromConfig creates the cache described by cfg, see config.FromEnv and
+// config.FromFile. opts are applied last, so code can still override it.
+func NewFromConfig(cfg config.Config, opts ...Option) (Cache, error) {
+	var cfgOpts []Option
+	if cfg.DefaultTTL != nil {
+		cfgOpts = append(cfgOpts, WithDefaultTTL(time.Duration(*cfg.DefaultTTL)))
+	}
+	if cfg.Timeout > 0 {
+		cfgOpts = append(cfgOpts, WithTimeout(time.Duration(cfg.Timeout)))
+	}
+	if cfg.LocalTTL > 0 {
+		cfgOpts = append(cfgOpts, WithLocalTier(time.Duration(cfg.LocalTTL)))
+	}
+	if cfg.Disabled {
+		cfgOpts = append(cfgOpts, WithDisabled(true))
+	}
+	opts = append(cfgOpts, opts...)
+
+	if cfg.DSN != "" {
+		return Open(cfg.DSN, opts...)
+	}
+	return openURL(configURL(cfg), opts...)
+}
+
+// configURL turns the backend fields of cfg into the DSN of its driver
+func configURL(cfg config.Config) *url.URL {
+	u := &url.URL{Scheme: cfg.Backend, Host: cfg.Addr}
+	if u.Scheme == "" {
+		u.Scheme = "redis"
+	}
+	switch u.Scheme {
+	case "bolt", "badger":
+		u.Host, u.Path = "", cfg.Addr
+	case "redis", "rediss":
+		if cfg.DB != 0 {
+			u.Path = "/" + strconv.Itoa(cfg.DB)
+		}
+	}
+	if cfg.Username != "" || cfg.Password != "" {
+		u.User = url.UserPassword(cfg.Username, cfg.Password)
+	}
+
+	query := url.Values{}
+	set := func(name, value string) {
+		if value != "" {
+			query.Set(name, value)
+		}
+	}
+	set("prefix", cfg.Prefix)
+	set("tls_cert", cfg.TLSCert)
+	set("tls_key", cfg.TLSKey)
+	set("tls_ca", cfg.TLSCA)
+	if cfg.TLS {
+		set("tls", "true")
+	}
+	if cfg.PoolSize > 0 {
+		set("pool_size", strconv.Itoa(cfg.PoolSize))
+	}
+	if cfg.MaxEntries > 0 {
+		set("max", strconv.Itoa(cfg.MaxEntries))
+	}
+	u.RawQuery = query.Encode()
+	return u
+}
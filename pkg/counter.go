@@ -0,0 +1,80 @@
+package pkg
+
+import (
+	"cacher/internal/adapters"
+	"context"
+	"fmt"
+	"go.opentelemetry.io/otel/attribute"
+	"time"
+)
+
+// CounterOption configures how a counter is updated
+type CounterOption func(*counterOptions)
+
+type counterOptions struct {
+	ttl time.Duration
+}
+
+// WithCounterTTL makes a counter created by the update expire after ttl.
+// Updates of an existing counter leave its expiration unchanged, so a window
+// counter keeps the lifetime it was started with.
+func WithCounterTTL(ttl time.Duration) CounterOption {
+	return func(o *counterOptions) {
+		o.ttl = ttl
+	}
+}
+
+// Increment atomically adds delta to the integer stored at key and returns
+// the new value. A missing key starts at 0.
+func (c *cache) Increment(ctx context.Context, key string, delta int64, opts ...CounterOption) (int64, error) {
+	return counterUpdate(c, ctx, key, opts, func(server adapters.CacheServer, ttl time.Duration) (int64, error) {
+		return server.IncrBy(ctx, key, delta, ttl)
+	})
+}
+
+// Decrement atomically subtracts delta from the integer stored at key and
+// returns the new value. A missing key starts at 0.
+func (c *cache) Decrement(ctx context.Context, key string, delta int64, opts ...CounterOption) (int64, error) {
+	return c.Increment(ctx, key, -delta, opts...)
+}
+
+// IncrementFloat atomically adds delta to the number stored at key and
+// returns the new value. A missing key starts at 0.
+func (c *cache) IncrementFloat(ctx context.Context, key string, delta float64, opts ...CounterOption) (float64, error) {
+	return counterUpdate(c, ctx, key, opts, func(server adapters.CacheServer, ttl time.Duration) (float64, error) {
+		return server.IncrByFloat(ctx, key, delta, ttl)
+	})
+}
+
+// DecrementFloat atomically subtracts delta from the number stored at key and
+// returns the new value. A missing key starts at 0.
+func (c *cache) DecrementFloat(ctx context.Context, key string, delta float64, opts ...CounterOption) (float64, error) {
+	return c.IncrementFloat(ctx, key, -delta, opts...)
+}
+
+// counterUpdate runs update against the backend, recording it like a write of
+// key and dropping the stale copy from the local tier
+func counterUpdate[T int64 | float64](c *cache, ctx context.Context, key string, opts []CounterOption, update func(adapters.CacheServer, time.Duration) (T, error)) (result T, err error) {
+	start := time.Now()
+	ctx, span := c.startSpan(ctx, "Increment", attribute.String("cache.key", key))
+	defer func() { endSpan(span, start, err) }()
+
+	o := &counterOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	// Counters are stored as plain numbers for the backend to add to
+	server, err := c.unencryptedServer()
+	if err != nil {
+		return 0, err
+	}
+	result, err = update(server, c.jitter(o.ttl))
+	if err != nil {
+		c.failed(key)
+		c.fire(ctx, hookError, "increment", key, time.Since(start), err)
+		return 0, fmt.Errorf("cache backend: %w", err)
+	}
+	c.fire(ctx, hookSet, "increment", key, time.Since(start), nil)
+	return result, c.evictLocal(ctx, key)
+}
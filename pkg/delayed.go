@@ -0,0 +1,101 @@
+package pkg
+
+import (
+	"cacher/codec"
+	"cacher/internal/adapters"
+	"context"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+// DefaultDelayedPoll is how often Run moves due items to the ready queue
+// unless it is given another interval
+const DefaultDelayedPoll = time.Second
+
+// delayedBatch is the most items one step of Poll moves
+const delayedBatch = 100
+
+// DelayedQueue holds values of type T until the time they were scheduled for,
+// in a sorted set of the backend of a cache scored by that time. Poll, or Run
+// in the background, moves due items in one step to a ReliableQueue of the
+// same name, from which workers take them with Dequeue and Ack.
+type DelayedQueue[T any] struct {
+	server adapters.CacheServer
+	key    string
+	ready  *ReliableQueue[T]
+}
+
+// NewDelayedQueue creates the delayed queue called name over the backend of
+// c. Its ready items are leased for lease, see NewReliableQueue.
+func NewDelayedQueue[T any](c Cache, name string, lease time.Duration) (*DelayedQueue[T], error) {
+	ready, err := NewReliableQueue[T](c, name, lease)
+	if err != nil {
+		return nil, err
+	}
+	return &DelayedQueue[T]{server: ready.server, key: "delayed:" + name, ready: ready}, nil
+}
+
+// Ready returns the queue due items are moved to
+func (d *DelayedQueue[T]) Ready() *ReliableQueue[T] {
+	return d.ready
+}
+
+// Schedule adds value to be ready at at and returns the id its QueueItem will
+// have
+func (d *DelayedQueue[T]) Schedule(ctx context.Context, value T, at time.Time) (string, error) {
+	id := randomToken()
+	// Items are stored like those of the ready queue, so moving them needs
+	// no decoding
+	data, err := codec.JSON.Marshal(queueEnvelope[T]{ID: id, Value: value})
+	if err != nil {
+		return "", err
+	}
+	_, err = d.server.ZAdd(ctx, d.key, redis.Z{Member: string(data), Score: float64(at.UnixMilli())})
+	if err != nil {
+		return "", backendError(err)
+	}
+	return id, nil
+}
+
+// ScheduleAfter adds value to be ready once delay has passed
+func (d *DelayedQueue[T]) ScheduleAfter(ctx context.Context, value T, delay time.Duration) (string, error) {
+	return d.Schedule(ctx, value, time.Now().Add(delay))
+}
+
+// Poll moves every item that is due to the ready queue, oldest first, and
+// returns how many there were
+func (d *DelayedQueue[T]) Poll(ctx context.Context) (int64, error) {
+	var moved int64
+	now := float64(time.Now().UnixMilli())
+	for {
+		n, err := d.server.ZMoveToList(ctx, d.key, d.ready.key, now, delayedBatch)
+		moved += n
+		if err != nil {
+			return moved, backendError(err)
+		}
+		if n < delayedBatch {
+			return moved, nil
+		}
+	}
+}
+
+// Run polls every interval, DefaultDelayedPoll if it is not positive, until
+// ctx is done. Several processes may run it, each item is moved once.
+// Backend errors are logged and polling goes on.
+func (d *DelayedQueue[T]) Run(ctx context.Context, interval time.Duration) error {
+	if interval <= 0 {
+		interval = DefaultDelayedPoll
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := d.Poll(ctx); err != nil && ctx.Err() == nil {
+			d.ready.logger.Warn("cache backend error", "queue", d.key, "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package pkg
+
+import (
+	"cacher/internal/adapters"
+	"fmt"
+	"github.com/bradfitz/gomemcache/memcache"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func init() {
+	Register("redis", openRedis)
+	Register("rediss", openRedis)
+	Register("memcached", openMemcached)
+	Register("memory", openMemory)
+	Register("null", func(dsn *url.URL, opts ...Option) (Cache, error) {
+		if err := unsupportedParameters(dsn); err != nil {
+			return nil, err
+		}
+		return NewNullCache(opts...), nil
+	})
+	Register("bolt", func(dsn *url.URL, opts ...Option) (Cache, error) {
+		if err := unsupportedParameters(dsn); err != nil {
+			return nil, err
+		}
+		return NewBoltCache(dsnPath(dsn), opts...)
+	})
+	Register("badger", func(dsn *url.URL, opts ...Option) (Cache, error) {
+		if err := unsupportedParameters(dsn); err != nil {
+			return nil, err
+		}
+		return NewBadgerCache(dsnPath(dsn), opts...)
+	})
+}
+
+// dsnOptions removes the parameters every driver supports from the query of
+// dsn and returns them as options:
+//
+//	prefix  namespaces every key, see WithPrefix
+//	ttl     the default ttl as a duration like 10m, 0 stores keys forever
+func dsnOptions(dsn *url.URL) ([]Option, error) {
+	var opts []Option
+	if prefix, ok := dsnParameter(dsn, "prefix"); ok {
+		opts = append(opts, WithPrefix(prefix))
+	}
+	if value, ok := dsnParameter(dsn, "ttl"); ok {
+		ttl, err := time.ParseDuration(value)
+		if err != nil || ttl < 0 {
+			return nil, fmt.Errorf("cache dsn: invalid ttl %q", value)
+		}
+		opts = append(opts, WithDefaultTTL(ttl))
+	}
+	return opts, nil
+}
+
+// dsnParameter removes the parameter name from the query of dsn and returns
+// its value
+func dsnParameter(dsn *url.URL, name string) (string, bool) {
+	query := dsn.Query()
+	if !query.Has(name) {
+		return "", false
+	}
+	value := query.Get(name)
+	query.Del(name)
+	dsn.RawQuery = query.Encode()
+	return value, true
+}
+
+// dsnInt removes an integer parameter from dsn
+func dsnInt(dsn *url.URL, name string) (int, bool, error) {
+	value, ok := dsnParameter(dsn, name)
+	if !ok {
+		return 0, false, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, false, fmt.Errorf("cache dsn: invalid %s %q", name, value)
+	}
+	return n, true, nil
+}
+
+// dsnPath returns the file path of bolt:///var/cache.db or, for a relative
+// path, bolt://cache.db
+func dsnPath(dsn *url.URL) string {
+	return dsn.Host + dsn.Path
+}
+
+// unsupportedParameters fails for a DSN with query parameters its driver did
+// not consume
+func unsupportedParameters(dsn *url.URL) error {
+	if dsn.RawQuery == "" {
+		return nil
+	}
+	names := make([]string, 0)
+	for name := range dsn.Query() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return fmt.Errorf("cache dsn: unsupported parameters %s", strings.Join(names, ", "))
+}
+
+// openRedis connects to redis://[user:password@]host[:port][/db]. rediss://
+// or tls=true connect over TLS, with the optional tls_cert, tls_key
+// and tls_ca files, and pool_size sets the connections per node.
+func openRedis(dsn *url.URL, opts ...Option) (Cache, error) {
+	var dsnOpts []Option
+	if size, ok, err := dsnInt(dsn, "pool_size"); err != nil {
+		return nil, err
+	} else if ok {
+		dsnOpts = append(dsnOpts, WithRedisPoolSize(size))
+	}
+
+	useTLS := dsn.Scheme == "rediss"
+	if value, ok := dsnParameter(dsn, "tls"); ok {
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("cache dsn: invalid tls %q", value)
+		}
+		useTLS = useTLS || enabled
+	}
+	certFile, _ := dsnParameter(dsn, "tls_cert")
+	keyFile, _ := dsnParameter(dsn, "tls_key")
+	caFile, _ := dsnParameter(dsn, "tls_ca")
+	if useTLS || certFile != "" || keyFile != "" || caFile != "" {
+		config, err := TLSConfig(certFile, keyFile, caFile)
+		if err != nil {
+			return nil, err
+		}
+		dsnOpts = append(dsnOpts, WithRedisTLS(config))
+	}
+	if err := unsupportedParameters(dsn); err != nil {
+		return nil, err
+	}
+
+	if dsn.Host != "" {
+		addr := dsn.Host
+		if dsn.Port() == "" {
+			addr += ":6379"
+		}
+		dsnOpts = append(dsnOpts, WithRedisAddr(addr))
+	}
+	if password, ok := dsn.User.Password(); ok || dsn.User.Username() != "" {
+		dsnOpts = append(dsnOpts, WithRedisAuth(dsn.User.Username(), password))
+	}
+	if db := strings.Trim(dsn.Path, "/"); db != "" {
+		index, err := strconv.Atoi(db)
+		if err != nil {
+			return nil, fmt.Errorf("cache dsn: invalid redis database %q", db)
+		}
+		dsnOpts = append(dsnOpts, WithRedisDB(index))
+	}
+	return NewCache(append(dsnOpts, opts...)...), nil
+}
+
+// openMemcached connects to memcached://host1[:port][,host2[:port]...]. The
+// prefix is applied by the adapter, as WithPrefix does not apply to adapters.
+func openMemcached(dsn *url.URL, opts ...Option) (Cache, error) {
+	if err := unsupportedParameters(dsn); err != nil {
+		return nil, err
+	}
+	servers := strings.Split(dsn.Host, ",")
+	for i, server := range servers {
+		if !strings.Contains(server, ":") {
+			servers[i] = server + ":11211"
+		}
+	}
+	client := memcache.New(servers...)
+	prefix := newOptions(opts).prefix
+	opts = append([]Option{WithAdapter(adapters.NewMemcached(client, prefix))}, opts...)
+	return NewCache(opts...), nil
+}
+
+// openMemory creates an in-process cache, memory://?max=10000 bounds it to
+// about that many keys and max_bytes to about that many bytes, evicting them
+// as eviction=lru or tinylfu says
+func openMemory(dsn *url.URL, opts ...Option) (Cache, error) {
+	maxEntries, _, err := dsnInt(dsn, "max")
+	if err != nil {
+		return nil, err
+	}
+	maxBytes, _, err := dsnInt(dsn, "max_bytes")
+	if err != nil {
+		return nil, err
+	}
+	policy := LRU
+	if eviction, ok := dsnParameter(dsn, "eviction"); ok {
+		switch eviction {
+		case LRU.String():
+		case TinyLFU.String():
+			policy = TinyLFU
+		default:
+			return nil, fmt.Errorf("cache dsn: invalid eviction %q", eviction)
+		}
+	}
+	if err := unsupportedParameters(dsn); err != nil {
+		return nil, err
+	}
+	opts = append([]Option{WithMemoryLimits(maxEntries, int64(maxBytes)), WithEvictionPolicy(policy)}, opts...)
+	return NewMemoryCache(opts...), nil
+}
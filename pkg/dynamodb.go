@@ -0,0 +1,17 @@
+package pkg
+
+import (
+	"cacher/internal/adapters"
+)
+
+// DynamoDBClient is the part of *dynamodb.Client NewDynamoDBCache uses
+type DynamoDBClient = adapters.DynamoDBClient
+
+// NewDynamoDBCache creates a cache stored in a DynamoDB table, for serverless
+// deployments without a Redis to reach. The table needs a string partition key
+// named "key", and TTL enabled on the "expires_at" attribute so DynamoDB
+// deletes expired items.
+func NewDynamoDBCache(client DynamoDBClient, table string, opts ...Option) Cache {
+	opts = append([]Option{withServer(adapters.NewDynamoDB(client, table))}, opts...)
+	return NewCache(opts...)
+}
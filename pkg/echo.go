@@ -0,0 +1,32 @@
+package pkg
+
+import (
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"time"
+)
+
+// echoContextKey is the request context key of the echo context
+type echoContextKey struct{}
+
+// EchoMiddleware caches the responses of the handlers after it for ttl, or
+// the TTL of their route, with Handler. Routes are matched by echo.Context
+// Path, so WithRouteTTL takes patterns like "/users/:id". Errors of the
+// handlers are rendered by the error handler of echo before they are cached.
+func EchoMiddleware(c Cache, ttl time.Duration, opts ...MiddlewareOption) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		m := newMiddleware(c, ttl, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context().Value(echoContextKey{}).(echo.Context)
+			response := ctx.Response()
+			ctx.SetResponse(echo.NewResponse(w, ctx.Echo()))
+			if err := next(ctx); err != nil {
+				ctx.Error(err)
+			}
+			ctx.SetResponse(response)
+		}), opts)
+		return func(ctx echo.Context) error {
+			m.serve(ctx.Response(), ctx.Request(), ctx.Path(), echoContextKey{}, ctx)
+			return nil
+		}
+	}
+}
@@ -0,0 +1,14 @@
+package pkg
+
+import (
+	"cacher/internal/adapters"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// NewEtcdCache creates a cache stored in etcd under the "cacher/" prefix, for
+// services that already run next to an etcd cluster. client stays open when
+// the cache is closed.
+func NewEtcdCache(client *clientv3.Client, opts ...Option) Cache {
+	opts = append([]Option{withServer(adapters.NewEtcd(client, adapters.DefaultEtcdPrefix))}, opts...)
+	return NewCache(opts...)
+}
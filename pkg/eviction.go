@@ -0,0 +1,12 @@
+package pkg
+
+import "cacher/internal/adapters"
+
+// EvictionPolicy decides which keys the memory of NewMemoryCache evicts once
+// it reaches WithMemoryLimits
+type EvictionPolicy = adapters.EvictionPolicy
+
+const (
+	LRU     = adapters.LRU
+	TinyLFU = adapters.TinyLFU
+)
@@ -0,0 +1,42 @@
+package pkg
+
+import (
+	"cacher/internal/adapters"
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrCorruptExport is returned by Import for a stream Export did not write
+var ErrCorruptExport = adapters.ErrCorruptExport
+
+// Export writes the keys matching a glob pattern to w with their remaining
+// time to live, for Import to load them into another cache, e.g. to back a
+// cache up or to clone it into another environment or backend. Values are
+// written as stored, so the importing cache needs the same codec,
+// compression and encryption keys. Only string keys are exported, hashes,
+// lists and the like are skipped. It returns how many keys were written.
+func (c *cache) Export(ctx context.Context, w io.Writer, pattern string) (int64, error) {
+	server, err := c.server()
+	if err != nil {
+		return 0, err
+	}
+	exported, err := adapters.Export(ctx, server, w, pattern)
+	return exported, backendError(err)
+}
+
+// Import sets the keys of a stream written by Export, replacing keys of the
+// same name, and returns how many were set. Keys keep the time to live they
+// had left at the export and those without one never expire. The local tier
+// of NewTieredCache is not updated, its copies expire on their own.
+func (c *cache) Import(ctx context.Context, r io.Reader) (int64, error) {
+	server, err := c.server()
+	if err != nil {
+		return 0, err
+	}
+	imported, err := adapters.Import(ctx, server, r)
+	if errors.Is(err, ErrCorruptExport) {
+		return imported, err
+	}
+	return imported, backendError(err)
+}
@@ -0,0 +1,173 @@
+package pkg
+
+import (
+	"cacher/internal/adapters"
+	"context"
+	"errors"
+	"hash/fnv"
+	"math"
+	"sync"
+)
+
+// Filter is a Bloom filter kept in the backend of a cache: it tells for sure
+// that an item was never added, and that it probably was otherwise. Checking
+// it before loading a key keeps lookups of keys that do not exist, such as
+// the random IDs of a cache penetration attack, away from the database.
+// Filters use RedisBloom when the server has it and a Redis bitmap otherwise.
+// Items cannot be removed; rebuild the filter after Reset instead.
+type Filter struct {
+	server   adapters.CacheServer
+	bloom    *adapters.RedisClient
+	key      string
+	capacity int64
+	rate     float64
+	bits     uint64
+	hashes   int
+}
+
+// NewFilter creates the Filter called name sized for expectedItems with a
+// falsePositiveRate chance of reporting an item it was not given, e.g. 0.01.
+// A million items at 1% take 1.2MB.
+func NewFilter(ctx context.Context, c Cache, name string, expectedItems int64, falsePositiveRate float64) (*Filter, error) {
+	configured, ok := c.(*cache)
+	if !ok {
+		return nil, ErrUnsupported
+	}
+	server, err := configured.server()
+	if err != nil {
+		return nil, err
+	}
+	if expectedItems <= 0 || falsePositiveRate <= 0 || falsePositiveRate >= 1 {
+		return nil, errors.New("filter needs expected items and a false positive rate between 0 and 1")
+	}
+
+	// The optimal size and number of hashes of a Bloom filter
+	bits := math.Ceil(-float64(expectedItems) * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2))
+	f := &Filter{
+		server:   server,
+		key:      "filter:" + name,
+		capacity: expectedItems,
+		rate:     falsePositiveRate,
+		bits:     uint64(bits),
+		hashes:   max(int(math.Round(bits/float64(expectedItems)*math.Ln2)), 1),
+	}
+
+	backend, key := server, f.key
+	if prefixed, ok := backend.(*adapters.Prefixed); ok {
+		backend, key = prefixed.Server, prefixed.Key(f.key)
+	}
+	if client, ok := backend.(*adapters.RedisClient); ok {
+		supported, err := client.BloomReserve(ctx, key, falsePositiveRate, expectedItems)
+		if err != nil {
+			return nil, backendError(err)
+		}
+		if supported {
+			f.bloom, f.key = client, key
+		}
+	}
+	return f, nil
+}
+
+// Add adds items to the filter
+func (f *Filter) Add(ctx context.Context, items ...string) error {
+	if len(items) == 0 {
+		return nil
+	}
+	if f.bloom != nil {
+		return backendError(f.bloom.BloomAdd(ctx, f.key, items...))
+	}
+	offsets := make([]int64, 0, len(items)*f.hashes)
+	for _, item := range items {
+		offsets = append(offsets, f.offsets(item)...)
+	}
+	_, err := f.server.SetBits(ctx, f.key, offsets...)
+	return backendError(err)
+}
+
+// MightContain reports whether item may have been added. False is certain,
+// true is wrong at the false positive rate once the filter holds its
+// expected items, and more often past that.
+func (f *Filter) MightContain(ctx context.Context, item string) (bool, error) {
+	if f.bloom != nil {
+		found, err := f.bloom.BloomExists(ctx, f.key, item)
+		return found, backendError(err)
+	}
+	found, err := f.server.TestBits(ctx, f.key, f.offsets(item)...)
+	return found, backendError(err)
+}
+
+// Reset empties the filter
+func (f *Filter) Reset(ctx context.Context) error {
+	if f.bloom != nil {
+		if _, err := f.bloom.Delete(ctx, f.key); err != nil {
+			return backendError(err)
+		}
+		_, err := f.bloom.BloomReserve(ctx, f.key, f.rate, f.capacity)
+		return backendError(err)
+	}
+	_, err := f.server.Delete(ctx, f.key)
+	return backendError(err)
+}
+
+// offsets returns the bits of item, derived from two halves of a 128 bit FNV
+// hash as in Kirsch and Mitzenmacher
+func (f *Filter) offsets(item string) []int64 {
+	hash := fnv.New128a()
+	_, _ = hash.Write([]byte(item))
+	sum := hash.Sum(nil)
+	var h1, h2 uint64
+	for i := range 8 {
+		h1 = h1<<8 | uint64(sum[i])
+		h2 = h2<<8 | uint64(sum[8+i])
+	}
+	offsets := make([]int64, f.hashes)
+	for i := range offsets {
+		offsets[i] = int64((h1 + uint64(i)*h2) % f.bits)
+	}
+	return offsets
+}
+
+// keyFilter is the Filter Wrap checks before loading keys matching pattern,
+// created on first use so NewCache does not reach the backend
+type keyFilter struct {
+	pattern string
+	items   int64
+	rate    float64
+	once    sync.Once
+	filter  *Filter
+}
+
+// KeyFilter returns the Filter configured with WithKeyFilter, nil without one
+// or when it could not be created
+func (c *cache) KeyFilter() *Filter {
+	if c.keyFilter == nil {
+		return nil
+	}
+	c.keyFilter.once.Do(func() {
+		filter, err := NewFilter(context.Background(), c, "keys", c.keyFilter.items, c.keyFilter.rate)
+		if err != nil {
+			c.logger.Warn("key filter disabled", "error", err)
+			return
+		}
+		c.keyFilter.filter = filter
+	})
+	return c.keyFilter.filter
+}
+
+// filtered reports whether the key filter knows key does not exist. When the
+// filter cannot be checked the key is loaded.
+func (c *cache) filtered(ctx context.Context, key string) bool {
+	if c.keyFilter == nil || !adapters.MatchPattern(c.keyFilter.pattern, key) {
+		return false
+	}
+	filter := c.KeyFilter()
+	if filter == nil {
+		return false
+	}
+	found, err := filter.MightContain(ctx, key)
+	if err != nil {
+		c.logger.Warn("cache backend error", "key", key, "error", err)
+		return false
+	}
+	return !found
+}
@@ -0,0 +1,62 @@
+package pkg
+
+import (
+	"cacher/internal/adapters"
+	"context"
+	"errors"
+)
+
+// ErrNoPrefix is returned by FlushPrefix, and by Flush with
+// WithFlushRequiresPrefix, when the cache has no key prefix, to avoid wiping a
+// backend shared with other applications
+var ErrNoPrefix = errors.New("no key prefix configured")
+
+// flusher is a CacheServer that can delete all of its keys at once
+type flusher interface {
+	Flush(ctx context.Context) (int64, error)
+}
+
+// Flush deletes every entry of the cache: the keys under its prefix when
+// WithPrefix is set, with SCAN and UNLINK on Redis, and otherwise every key of
+// the backend. The local tier is cleared too.
+func (c *cache) Flush(ctx context.Context) error {
+	return c.flush(ctx, c.flushRequiresPrefix)
+}
+
+// FlushPrefix deletes every key under the prefix configured with WithPrefix,
+// leaving keys of other applications untouched
+func (c *cache) FlushPrefix(ctx context.Context) error {
+	return c.flush(ctx, true)
+}
+
+func (c *cache) flush(ctx context.Context, requirePrefix bool) error {
+	server, err := c.server()
+	if err != nil {
+		return err
+	}
+	// A Prefixed without a prefix only rewrites long keys, see WithMaxKeyLength
+	if prefixed, ok := server.(*adapters.Prefixed); ok && prefixed.Prefix == "" {
+		server = prefixed.Server
+	}
+	if _, prefixed := server.(*adapters.Prefixed); !prefixed && requirePrefix {
+		return ErrNoPrefix
+	}
+
+	if err := flushServer(ctx, server); err != nil {
+		return err
+	}
+	if tiered, ok := c.Cache.(*adapters.Tiered); ok {
+		return flushServer(ctx, tiered.Local)
+	}
+	return nil
+}
+
+func flushServer(ctx context.Context, server adapters.CacheServer) error {
+	var err error
+	if f, ok := server.(flusher); ok {
+		_, err = f.Flush(ctx)
+	} else {
+		_, err = adapters.DeleteMatching(ctx, server, "*")
+	}
+	return err
+}
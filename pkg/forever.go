@@ -0,0 +1,31 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+)
+
+// Forever stores a key without an expiration, clearing any it had
+const Forever time.Duration = 0
+
+// NoDefaultTTL means no default expiration is configured, so Set, SetMany,
+// GetSet and Wrap need one given with WithDefaultTTL
+const NoDefaultTTL time.Duration = math.MinInt64
+
+// ErrNoDefaultTTL is returned by writes relying on the default expiration
+// when WithDefaultTTL was not given
+var ErrNoDefaultTTL = errors.New("no default ttl configured, use SetWithTTL or SetForever")
+
+// SetForever stores a value that never expires, whatever the default
+// expiration is
+func (c *cache) SetForever(ctx context.Context, key string, value interface{}, opts ...SetOption) error {
+	return c.SetWithTTL(ctx, key, value, Forever, opts...)
+}
+
+// RememberForever behaves like Wrap but stores computed values without an
+// expiration
+func (c *cache) RememberForever(ctx context.Context, key string, value func() interface{}) interface{} {
+	return c.WrapTTL(ctx, key, Forever, value)
+}
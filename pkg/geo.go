@@ -0,0 +1,86 @@
+package pkg
+
+import (
+	"cacher/internal/adapters"
+	"context"
+	"github.com/redis/go-redis/v9"
+)
+
+// GeoIndex finds members, such as stores or drivers, near a position with a
+// geospatial index in the backend of a cache. Positions are kept with a
+// precision of about 0.6 meters and distances are in meters.
+type GeoIndex struct {
+	server adapters.CacheServer
+	key    string
+}
+
+// GeoPlace is a member of a GeoIndex found near a position, with its distance
+// to it in meters
+type GeoPlace struct {
+	Member    string
+	Latitude  float64
+	Longitude float64
+	Distance  float64
+}
+
+// NewGeoIndex creates the GeoIndex called name over the backend of c
+func NewGeoIndex(c Cache, name string) (*GeoIndex, error) {
+	configured, ok := c.(*cache)
+	if !ok {
+		return nil, ErrUnsupported
+	}
+	server, err := configured.unencryptedServer()
+	if err != nil {
+		return nil, err
+	}
+	return &GeoIndex{server: server, key: "geo:" + name}, nil
+}
+
+// AddLocation places member at a latitude and longitude, moving it if it was
+// already placed. Latitudes must be within 85.05 degrees of the equator.
+func (g *GeoIndex) AddLocation(ctx context.Context, member string, lat, lng float64) error {
+	_, err := g.server.GeoAdd(ctx, g.key, &redis.GeoLocation{Name: member, Latitude: lat, Longitude: lng})
+	return backendError(err)
+}
+
+// Location returns where member is placed, ErrCacheMiss if it is not
+func (g *GeoIndex) Location(ctx context.Context, member string) (lat, lng float64, err error) {
+	positions, err := g.server.GeoPos(ctx, g.key, member)
+	if err != nil {
+		return 0, 0, backendError(err)
+	}
+	if len(positions) == 0 || positions[0] == nil {
+		return 0, 0, ErrCacheMiss
+	}
+	return positions[0].Latitude, positions[0].Longitude, nil
+}
+
+// Nearby returns the members within radius meters of a position, nearest
+// first
+func (g *GeoIndex) Nearby(ctx context.Context, lat, lng, radius float64) ([]GeoPlace, error) {
+	return g.NearbyN(ctx, lat, lng, radius, 0)
+}
+
+// NearbyN returns like Nearby the n nearest members, all of them when n is not
+// positive
+func (g *GeoIndex) NearbyN(ctx context.Context, lat, lng, radius float64, n int) ([]GeoPlace, error) {
+	locations, err := g.server.GeoSearch(ctx, g.key, lng, lat, radius, "m", n)
+	if err != nil {
+		return nil, backendError(err)
+	}
+	places := make([]GeoPlace, len(locations))
+	for i, location := range locations {
+		places[i] = GeoPlace{Member: location.Name, Latitude: location.Latitude, Longitude: location.Longitude, Distance: location.Dist}
+	}
+	return places, nil
+}
+
+// Remove takes members out of the index
+func (g *GeoIndex) Remove(ctx context.Context, members ...string) error {
+	values := make([]interface{}, len(members))
+	for i, member := range members {
+		values[i] = member
+	}
+	_, err := g.server.ZRem(ctx, g.key, values...)
+	return backendError(err)
+}
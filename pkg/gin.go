@@ -0,0 +1,80 @@
+package pkg
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"time"
+)
+
+// ginContextKey is the request context key of the gin context
+type ginContextKey struct{}
+
+// GinMiddleware caches the responses of the handlers after it for ttl, or
+// the TTL of their route, with Handler. Routes are matched by
+// gin.Context.FullPath, so WithRouteTTL takes patterns like "/users/:id".
+func GinMiddleware(c Cache, ttl time.Duration, opts ...MiddlewareOption) gin.HandlerFunc {
+	m := newMiddleware(c, ttl, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context().Value(ginContextKey{}).(*gin.Context)
+		writer := ctx.Writer
+		ctx.Writer = &ginWriter{ResponseWriter: writer, w: w}
+		ctx.Next()
+		ctx.Writer = writer
+	}), opts)
+	return func(ctx *gin.Context) {
+		m.serve(ctx.Writer, ctx.Request, ctx.FullPath(), ginContextKey{}, ctx)
+		ctx.Abort()
+	}
+}
+
+// ginWriter sends what the handlers write to the Handler, which stores it
+// before it reaches the client
+type ginWriter struct {
+	gin.ResponseWriter
+	w      http.ResponseWriter
+	status int
+	size   int
+}
+
+func (g *ginWriter) Header() http.Header {
+	return g.w.Header()
+}
+
+func (g *ginWriter) WriteHeader(status int) {
+	if g.status == 0 {
+		g.status = status
+		g.w.WriteHeader(status)
+	}
+}
+
+func (g *ginWriter) WriteHeaderNow() {
+	g.WriteHeader(http.StatusOK)
+}
+
+func (g *ginWriter) Write(b []byte) (int, error) {
+	g.WriteHeaderNow()
+	n, err := g.w.Write(b)
+	g.size += n
+	return n, err
+}
+
+func (g *ginWriter) WriteString(s string) (int, error) {
+	return g.Write([]byte(s))
+}
+
+func (g *ginWriter) Status() int {
+	if g.status == 0 {
+		return http.StatusOK
+	}
+	return g.status
+}
+
+func (g *ginWriter) Size() int {
+	if g.status == 0 {
+		return -1
+	}
+	return g.size
+}
+
+func (g *ginWriter) Written() bool {
+	return g.status != 0
+}
@@ -0,0 +1,207 @@
+package pkg
+
+import (
+	"cacher/internal/adapters"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// GRPCCache caches the responses of unary gRPC calls, as a client or a server
+// interceptor. Only the methods given a TTL with WithMethodTTL are cached, so
+// list idempotent ones only. Responses are keyed by method and a hash of the
+// request, and errors are never cached.
+type GRPCCache struct {
+	responses *TypedCache[grpcResponse]
+	methods   []grpcMethodTTL
+	logger    Logger
+	mutex     sync.RWMutex
+	stats     map[string]*grpcStats
+}
+
+// GRPCOption configures a GRPCCache
+type GRPCOption func(*GRPCCache)
+
+// WithMethodTTL caches the methods matching the glob pattern for ttl, e.g.
+// "/catalog.Catalog/Get*". The first pattern a method matches applies.
+func WithMethodTTL(pattern string, ttl time.Duration) GRPCOption {
+	return func(g *GRPCCache) {
+		g.methods = append(g.methods, grpcMethodTTL{pattern: pattern, ttl: ttl})
+	}
+}
+
+type grpcMethodTTL struct {
+	pattern string
+	ttl     time.Duration
+}
+
+type grpcStats struct {
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+// grpcResponse is a marshaled response with its message type, so a server
+// can serve it without calling the handler
+type grpcResponse struct {
+	Type string `json:"type"`
+	Body []byte `json:"body"`
+}
+
+// NewGRPCCache creates a GRPCCache storing responses in c
+func NewGRPCCache(c Cache, opts ...GRPCOption) *GRPCCache {
+	g := &GRPCCache{
+		responses: NewTypedCache[grpcResponse](c),
+		logger:    adapters.NopLogger(),
+		stats:     make(map[string]*grpcStats),
+	}
+	if configured, ok := c.(*cache); ok {
+		g.logger = configured.logger
+	}
+	for _, opt := range opts {
+		opt(g)
+	}
+	return g
+}
+
+// UnaryServerInterceptor returns an interceptor answering cached methods from
+// the cache before the handler is called
+func (g *GRPCCache) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ttl, key, ok := g.lookup(info.FullMethod, req)
+		if !ok {
+			return handler(ctx, req)
+		}
+		if cached, err := g.responses.Get(ctx, key); err == nil {
+			if resp, err := cached.message(); err == nil {
+				g.record(info.FullMethod, true)
+				return resp, nil
+			}
+		}
+		g.record(info.FullMethod, false)
+		resp, err := handler(ctx, req)
+		if err == nil {
+			g.store(ctx, key, resp, ttl)
+		}
+		return resp, err
+	}
+}
+
+// UnaryClientInterceptor returns an interceptor answering cached methods from
+// the cache without a round trip to the server
+func (g *GRPCCache) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ttl, key, ok := g.lookup(method, req)
+		message, isMessage := reply.(proto.Message)
+		if !ok || !isMessage {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		if cached, err := g.responses.Get(ctx, key); err == nil {
+			if err := proto.Unmarshal(cached.Body, message); err == nil {
+				g.record(method, true)
+				return nil
+			}
+			proto.Reset(message)
+		}
+		g.record(method, false)
+		if err := invoker(ctx, method, req, reply, cc, opts...); err != nil {
+			return err
+		}
+		g.store(ctx, key, reply, ttl)
+		return nil
+	}
+}
+
+// Statistics returns the hits and misses of every cached method
+func (g *GRPCCache) Statistics() map[string]map[string]uint64 {
+	g.mutex.RLock()
+	defer g.mutex.RUnlock()
+	out := make(map[string]map[string]uint64, len(g.stats))
+	for method, stats := range g.stats {
+		out[method] = map[string]uint64{
+			"hits":   stats.hits.Load(),
+			"misses": stats.misses.Load(),
+		}
+	}
+	return out
+}
+
+// lookup returns the TTL of method and the key of req, false when the method
+// is not cached or req is not a protobuf message
+func (g *GRPCCache) lookup(method string, req interface{}) (time.Duration, string, bool) {
+	message, ok := req.(proto.Message)
+	if !ok {
+		return 0, "", false
+	}
+	for _, m := range g.methods {
+		if !adapters.MatchPattern(m.pattern, method) {
+			continue
+		}
+		if m.ttl <= 0 {
+			return 0, "", false
+		}
+		body, err := proto.MarshalOptions{Deterministic: true}.Marshal(message)
+		if err != nil {
+			return 0, "", false
+		}
+		sum := sha256.Sum256(body)
+		return m.ttl, "grpc:" + method + ":" + hex.EncodeToString(sum[:]), true
+	}
+	return 0, "", false
+}
+
+func (g *GRPCCache) store(ctx context.Context, key string, resp interface{}, ttl time.Duration) {
+	message, ok := resp.(proto.Message)
+	if !ok {
+		return
+	}
+	body, err := proto.Marshal(message)
+	if err == nil {
+		err = g.responses.SetWithTTL(ctx, key, grpcResponse{
+			Type: string(message.ProtoReflect().Descriptor().FullName()),
+			Body: body,
+		}, ttl)
+	}
+	if err != nil {
+		g.logger.Warn("cache backend error", "key", key, "error", err)
+	}
+}
+
+func (g *GRPCCache) record(method string, hit bool) {
+	g.mutex.RLock()
+	stats, ok := g.stats[method]
+	g.mutex.RUnlock()
+	if !ok {
+		g.mutex.Lock()
+		if stats, ok = g.stats[method]; !ok {
+			stats = &grpcStats{}
+			g.stats[method] = stats
+		}
+		g.mutex.Unlock()
+	}
+	if hit {
+		stats.hits.Add(1)
+	} else {
+		stats.misses.Add(1)
+	}
+}
+
+// message unmarshals r into a new message of its registered type
+func (r grpcResponse) message() (proto.Message, error) {
+	messageType, err := protoregistry.GlobalTypes.FindMessageByName(protoreflect.FullName(r.Type))
+	if err != nil {
+		return nil, fmt.Errorf("grpc response type %s: %w", r.Type, err)
+	}
+	message := messageType.New().Interface()
+	if err := proto.Unmarshal(r.Body, message); err != nil {
+		return nil, err
+	}
+	return message, nil
+}
@@ -0,0 +1,175 @@
+package pkg
+
+import (
+	"bytes"
+	"cacher/internal/adapters"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// DefaultHandlerBypassHeader is the request header that makes Handler skip
+// the cache, whatever its value
+const DefaultHandlerBypassHeader = "X-Cache-Bypass"
+
+// HandlerKeyFunc returns the key a response to r is cached under
+type HandlerKeyFunc func(r *http.Request) string
+
+// HandlerOption configures Handler
+type HandlerOption func(*handler)
+
+// WithHandlerBypassHeader sets the request header that bypasses the cache,
+// see DefaultHandlerBypassHeader. An empty name only honours Cache-Control.
+func WithHandlerBypassHeader(name string) HandlerOption {
+	return func(h *handler) {
+		h.bypassHeader = name
+	}
+}
+
+// WithStaleIfError keeps responses for staleIfError after their ttl and
+// serves them, marked STALE, while next fails with a 5xx status
+func WithStaleIfError(staleIfError time.Duration) HandlerOption {
+	return func(h *handler) {
+		h.staleIfError = staleIfError
+	}
+}
+
+type handler struct {
+	responses    *TypedCache[cachedResponse]
+	next         http.Handler
+	key          HandlerKeyFunc
+	ttl          func(r *http.Request) time.Duration
+	bypassHeader string
+	staleIfError time.Duration
+	logger       Logger
+}
+
+// Handler caches the full responses of next to GET and HEAD requests in c
+// for ttl, keyed by method and URL or by key when it is not nil. Requests
+// with the bypass header or Cache-Control no-store go straight to next, and
+// no-cache ones refresh the cached response. Only responses with a cacheable
+// status and without Set-Cookie or Cache-Control no-store or private are
+// stored. Responses carry an X-Cache header: HIT, MISS or STALE. A ttl of 0
+// turns the cache off.
+func Handler(c Cache, next http.Handler, key HandlerKeyFunc, ttl time.Duration, opts ...HandlerOption) http.Handler {
+	return newHandler(c, next, key, func(*http.Request) time.Duration { return ttl }, opts...)
+}
+
+// newHandler creates a handler that caches every response for the TTL ttl
+// returns for its request, passing through the requests it returns 0 for
+func newHandler(c Cache, next http.Handler, key HandlerKeyFunc, ttl func(r *http.Request) time.Duration, opts ...HandlerOption) *handler {
+	h := &handler{
+		responses:    NewTypedCache[cachedResponse](c),
+		next:         next,
+		key:          key,
+		ttl:          ttl,
+		bypassHeader: DefaultHandlerBypassHeader,
+		logger:       adapters.NopLogger(),
+	}
+	if h.key == nil {
+		h.key = func(r *http.Request) string {
+			return r.Method + " " + r.URL.String()
+		}
+	}
+	if configured, ok := c.(*cache); ok {
+		h.logger = configured.logger
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	control := cacheControl(r.Header)
+	bypass := h.bypassHeader != "" && r.Header.Get(h.bypassHeader) != ""
+	ttl := h.ttl(r)
+	if r.Method != http.MethodGet && r.Method != http.MethodHead || bypass || control.has("no-store") || ttl <= 0 {
+		h.next.ServeHTTP(w, r)
+		return
+	}
+	ctx := r.Context()
+	key := "page:" + h.key(r)
+
+	entry, err := h.responses.Get(ctx, key)
+	cached := err == nil && entry.matches(r.Header)
+	if cached && !control.has("no-cache") && time.Now().Before(entry.Expires) {
+		entry.write(w, "HIT")
+		return
+	}
+
+	recorder := &responseRecorder{header: http.Header{}}
+	h.next.ServeHTTP(recorder, r)
+	if recorder.status == 0 {
+		recorder.status = http.StatusOK
+	}
+	if cached && recorder.status >= http.StatusInternalServerError {
+		entry.write(w, "STALE")
+		return
+	}
+
+	response := cachedResponse{
+		Status: recorder.status,
+		Header: recorder.header,
+		Body:   recorder.body.Bytes(),
+	}
+	if storable(response) {
+		stored := response
+		stored.Header = response.Header.Clone()
+		stored.Vary = varyValues(response.Header, r.Header)
+		stored.Expires = time.Now().Add(ttl)
+		if err := h.responses.SetWithTTL(ctx, key, stored, ttl+h.staleIfError); err != nil {
+			h.logger.Warn("cache backend error", "key", key, "error", err)
+		}
+	}
+	response.write(w, "MISS")
+}
+
+// storable reports whether response may be shared with other clients
+func storable(response cachedResponse) bool {
+	control := cacheControl(response.Header)
+	return cacheableStatus(response.Status) &&
+		!control.has("no-store") && !control.has("private") &&
+		response.Header.Get("Set-Cookie") == "" && response.Header.Get("Vary") != "*"
+}
+
+// write sends r to w, marked with an X-Cache status unless it is empty
+func (r cachedResponse) write(w http.ResponseWriter, status string) {
+	header := w.Header()
+	for name, values := range r.Header {
+		header[name] = append([]string(nil), values...)
+	}
+	if status != "" {
+		header.Set("X-Cache", status)
+	}
+	if header.Get("Content-Length") == "" {
+		header.Set("Content-Length", strconv.Itoa(len(r.Body)))
+	}
+	w.WriteHeader(r.Status)
+	_, _ = w.Write(r.Body)
+}
+
+// responseRecorder buffers the response of a handler so it can be stored, or
+// dropped for a stale one
+type responseRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (r *responseRecorder) Header() http.Header {
+	return r.header
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.body.Write(b)
+}
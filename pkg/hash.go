@@ -0,0 +1,285 @@
+package pkg
+
+import (
+	"cacher/internal/adapters"
+	"context"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// HashCache stores values of the struct type T as hashes, one hash field per
+// struct field, so single fields can be read and updated without rewriting
+// the whole value. Fields are named by their `cache:"name"` tag, or by the
+// field name; a "-" tag skips the field. Strings, numbers and bools are
+// stored as text, other types with encoding.TextMarshaler or JSON. Hash
+// fields are written to the backend directly, so they are not compressed and
+// bypass the local tier; with WithEncryption every method fails with
+// ErrEncryptionUnsupported.
+type HashCache[T any] struct {
+	cache  *cache
+	fields []hashField
+	byName map[string]hashField
+}
+
+type hashField struct {
+	name  string
+	index []int
+}
+
+// NewHashCache wraps c so values are stored as hashes. It panics when T is not
+// a struct.
+func NewHashCache[T any](c Cache) *HashCache[T] {
+	typ := reflect.TypeFor[T]()
+	if typ.Kind() != reflect.Struct {
+		panic("cacher: HashCache needs a struct type, got " + typ.String())
+	}
+
+	h := &HashCache[T]{byName: make(map[string]hashField)}
+	h.cache, _ = c.(*cache)
+	for _, field := range reflect.VisibleFields(typ) {
+		if !field.IsExported() || field.Anonymous {
+			continue
+		}
+		name := field.Name
+		if tag, ok := field.Tag.Lookup("cache"); ok {
+			if tag == "-" {
+				continue
+			}
+			if tag != "" {
+				name = tag
+			}
+		}
+		f := hashField{name: name, index: field.Index}
+		h.fields = append(h.fields, f)
+		h.byName[name] = f
+	}
+	return h
+}
+
+// configured returns the cache behind h, which must be one built by this
+// package to reach its backend
+func (h *HashCache[T]) configured() (*cache, error) {
+	if h.cache == nil {
+		return nil, ErrUnsupported
+	}
+	return h.cache, nil
+}
+
+// Get reads every field of the hash at key into a T. It returns ErrCacheMiss
+// when the hash does not exist.
+func (h *HashCache[T]) Get(ctx context.Context, key string) (T, error) {
+	var value T
+	fields, err := h.hash(key, func(server adapters.CacheServer) (interface{}, error) {
+		return server.HGetAll(ctx, key)
+	})
+	if err != nil {
+		return value, err
+	}
+	stored := fields.(map[string]string)
+	if len(stored) == 0 {
+		return value, ErrCacheMiss
+	}
+
+	target := reflect.ValueOf(&value).Elem()
+	for name, text := range stored {
+		field, ok := h.byName[name]
+		if !ok {
+			continue
+		}
+		if err := decodeHashField(target.FieldByIndex(field.index), text); err != nil {
+			return value, fmt.Errorf("decode hash field %s: %w", name, err)
+		}
+	}
+	return value, nil
+}
+
+// Set stores every field of value in the hash at key with the default
+// expiration
+func (h *HashCache[T]) Set(ctx context.Context, key string, value T) error {
+	c, err := h.configured()
+	if err != nil {
+		return err
+	}
+	return h.SetWithTTL(ctx, key, value, c.defaultTTL)
+}
+
+// SetWithTTL stores every field of value in the hash at key and sets the
+// expiration of the hash. Forever removes any expiration, like it does for
+// Set.
+func (h *HashCache[T]) SetWithTTL(ctx context.Context, key string, value T, ttl time.Duration) error {
+	if ttl == NoDefaultTTL {
+		return ErrNoDefaultTTL
+	}
+	source := reflect.ValueOf(value)
+	fields := make(map[string]interface{}, len(h.fields))
+	for _, field := range h.fields {
+		text, err := encodeHashField(source.FieldByIndex(field.index))
+		if err != nil {
+			return fmt.Errorf("encode hash field %s: %w", field.name, err)
+		}
+		fields[field.name] = text
+	}
+	if err := h.write(ctx, key, fields); err != nil {
+		return err
+	}
+	_, err := h.hash(key, func(server adapters.CacheServer) (interface{}, error) {
+		if ttl <= 0 {
+			return server.Persist(ctx, key)
+		}
+		return server.Expire(ctx, key, h.cache.jitter(ttl))
+	})
+	return err
+}
+
+// SetFields updates some fields of the hash at key, leaving the others and
+// the expiration unchanged. Fields are named like in T.
+func (h *HashCache[T]) SetFields(ctx context.Context, key string, values map[string]interface{}) error {
+	fields := make(map[string]interface{}, len(values))
+	for name, value := range values {
+		if _, ok := h.byName[name]; !ok {
+			return fmt.Errorf("unknown hash field %s", name)
+		}
+		text, err := encodeHashField(reflect.ValueOf(value))
+		if err != nil {
+			return fmt.Errorf("encode hash field %s: %w", name, err)
+		}
+		fields[name] = text
+	}
+	return h.write(ctx, key, fields)
+}
+
+// GetField returns the stored text of one field of the hash at key, or
+// ErrCacheMiss when the field is not set
+func (h *HashCache[T]) GetField(ctx context.Context, key, field string) (string, error) {
+	value, err := h.hash(key, func(server adapters.CacheServer) (interface{}, error) {
+		return server.HGet(ctx, key, field)
+	})
+	if err != nil {
+		return "", err
+	}
+	return value.(string), nil
+}
+
+// IncrBy atomically adds delta to an integer field of the hash at key
+func (h *HashCache[T]) IncrBy(ctx context.Context, key, field string, delta int64) (int64, error) {
+	value, err := h.hash(key, func(server adapters.CacheServer) (interface{}, error) {
+		return server.HIncrBy(ctx, key, field, delta)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return value.(int64), nil
+}
+
+// DeleteFields removes fields from the hash at key
+func (h *HashCache[T]) DeleteFields(ctx context.Context, key string, fields ...string) error {
+	_, err := h.hash(key, func(server adapters.CacheServer) (interface{}, error) {
+		return server.HDel(ctx, key, fields...)
+	})
+	return err
+}
+
+// Delete removes the hash at key
+func (h *HashCache[T]) Delete(ctx context.Context, key string) error {
+	c, err := h.configured()
+	if err != nil {
+		return err
+	}
+	return c.Delete(ctx, key)
+}
+
+func (h *HashCache[T]) write(ctx context.Context, key string, fields map[string]interface{}) error {
+	_, err := h.hash(key, func(server adapters.CacheServer) (interface{}, error) {
+		return server.HSet(ctx, key, fields)
+	})
+	return err
+}
+
+// hash runs a hash command on the backend, mapping a missing key or field to
+// ErrCacheMiss and wrapping backend errors
+func (h *HashCache[T]) hash(key string, command func(server adapters.CacheServer) (interface{}, error)) (interface{}, error) {
+	c, err := h.configured()
+	if err != nil {
+		return nil, err
+	}
+	server, err := c.unencryptedServer()
+	if err != nil {
+		return nil, err
+	}
+	result, err := command(server)
+	switch {
+	case errors.Is(err, redis.Nil):
+		c.miss(key)
+		return nil, ErrCacheMiss
+	case err != nil:
+		c.failed(key)
+		return nil, fmt.Errorf("cache backend: %w", err)
+	}
+	return result, nil
+}
+
+var textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+
+func encodeHashField(v reflect.Value) (string, error) {
+	if v.Type().Implements(textMarshalerType) {
+		text, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(text), err
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits()), nil
+	}
+	data, err := json.Marshal(v.Interface())
+	return string(data), err
+}
+
+func decodeHashField(v reflect.Value, text string) error {
+	if unmarshaler, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return unmarshaler.UnmarshalText([]byte(text))
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(text)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(text)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(text, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(text, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(text, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	default:
+		return json.Unmarshal([]byte(text), v.Addr().Interface())
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package pkg
+
+import (
+	"cacher/internal/adapters"
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// Health describes whether the backend of a cache is reachable
+type Health struct {
+	Backend string        `json:"backend"`
+	Healthy bool          `json:"healthy"`
+	Latency time.Duration `json:"latency_ns"`
+	Breaker string        `json:"breaker,omitempty"`
+	Error   string        `json:"error,omitempty"`
+}
+
+// Ping checks that the backend answers, even while the circuit breaker is open
+func (c *cache) Ping(ctx context.Context) error {
+	return adapters.Ping(ctx, c.Cache)
+}
+
+// Health pings the backend and reports the round trip time together with the
+// state of the circuit breaker, if any
+func (c *cache) Health(ctx context.Context) Health {
+	start := time.Now()
+	err := c.Ping(ctx)
+	health := Health{
+		Backend: c.backend,
+		Healthy: err == nil,
+		Latency: time.Since(start),
+	}
+	if err != nil {
+		health.Error = err.Error()
+	}
+	if breaker, ok := adapters.FindBreaker(c.Cache); ok {
+		health.Breaker = breaker.State().String()
+	}
+	return health
+}
+
+// HealthHandler serves the Health of c as JSON, with status 200 when the
+// backend is reachable and 503 otherwise, e.g. mounted at /healthz for
+// Kubernetes readiness probes
+func HealthHandler(c Cache) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		health := c.Health(r.Context())
+
+		w.Header().Set("Content-Type", "application/json")
+		if !health.Healthy {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_ = json.NewEncoder(w).Encode(health)
+	})
+}
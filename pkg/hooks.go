@@ -0,0 +1,90 @@
+package pkg
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Event describes one cache operation passed to hooks
+type Event struct {
+	Operation string // "get", "set" or "delete"
+	Key       string
+	Latency   time.Duration
+	Backend   string
+	Err       error // set for OnError hooks
+}
+
+// Hook is called synchronously on the goroutine performing the operation, so
+// it must be quick and must not call back into the cache for the same key
+type Hook func(ctx context.Context, event Event)
+
+type hookKind int
+
+const (
+	hookHit hookKind = iota
+	hookMiss
+	hookSet
+	hookDelete
+	hookError
+	hookKinds
+)
+
+// registeredHooks is replaced as a whole on registration so firing hooks
+// needs no lock
+type registeredHooks [hookKinds][]Hook
+
+type hooks struct {
+	mutex   sync.Mutex
+	current atomic.Pointer[registeredHooks]
+}
+
+func (h *hooks) add(kind hookKind, hook Hook) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	next := &registeredHooks{}
+	if current := h.current.Load(); current != nil {
+		*next = *current
+	}
+	next[kind] = append(slices.Clone(next[kind]), hook)
+	h.current.Store(next)
+}
+
+func (c *cache) fire(ctx context.Context, kind hookKind, operation, key string, latency time.Duration, err error) {
+	registered := c.hooks.current.Load()
+	if registered == nil {
+		return
+	}
+	for _, hook := range registered[kind] {
+		hook(ctx, Event{Operation: operation, Key: key, Latency: latency, Backend: c.backend, Err: err})
+	}
+}
+
+// OnHit registers hook to run after every lookup that found its key
+func (c *cache) OnHit(hook Hook) {
+	c.hooks.add(hookHit, hook)
+}
+
+// OnMiss registers hook to run after every lookup of a missing key
+func (c *cache) OnMiss(hook Hook) {
+	c.hooks.add(hookMiss, hook)
+}
+
+// OnSet registers hook to run after every key written
+func (c *cache) OnSet(hook Hook) {
+	c.hooks.add(hookSet, hook)
+}
+
+// OnDelete registers hook to run after every key deleted
+func (c *cache) OnDelete(hook Hook) {
+	c.hooks.add(hookDelete, hook)
+}
+
+// OnError registers hook to run after every backend error, with Operation
+// telling which call failed
+func (c *cache) OnError(hook Hook) {
+	c.hooks.add(hookError, hook)
+}
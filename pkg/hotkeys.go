@@ -0,0 +1,137 @@
+package pkg
+
+import (
+	"container/heap"
+	"context"
+	"math/rand/v2"
+	"sort"
+	"sync"
+)
+
+// KeyCount is a key with an estimate of how often it was seen
+type KeyCount struct {
+	Key   string
+	Count uint64
+}
+
+// HotKeys lists the most frequently read and the most frequently missed keys,
+// most frequent first
+type HotKeys struct {
+	Reads  []KeyCount
+	Misses []KeyCount
+}
+
+// topKSample is how many reads one is taken for while another goroutine
+// holds the lock of a topK, counting for all of them
+const topKSample = 16
+
+// topK tracks the most frequent keys in bounded space with the Space-Saving
+// algorithm: when full, a new key replaces the least frequent one and
+// inherits its count, so counts are overestimated by at most that count.
+// Under contention reads are sampled, so hits on a hot key do not wait on
+// each other.
+type topK struct {
+	capacity int
+	mutex    sync.Mutex
+	index    map[string]*topKEntry
+	entries  topKHeap
+}
+
+type topKEntry struct {
+	key   string
+	count uint64
+	pos   int
+}
+
+// topKHeap is a min heap of entries ordered by count
+type topKHeap []*topKEntry
+
+func (h topKHeap) Len() int           { return len(h) }
+func (h topKHeap) Less(i, j int) bool { return h[i].count < h[j].count }
+func (h topKHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].pos = i
+	h[j].pos = j
+}
+func (h *topKHeap) Push(x any) {
+	entry := x.(*topKEntry)
+	entry.pos = len(*h)
+	*h = append(*h, entry)
+}
+func (h *topKHeap) Pop() any {
+	old := *h
+	entry := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return entry
+}
+
+func newTopK(capacity int) *topK {
+	return &topK{capacity: capacity, index: make(map[string]*topKEntry)}
+}
+
+func (t *topK) increment(key string) {
+	weight := uint64(1)
+	if !t.mutex.TryLock() {
+		if rand.IntN(topKSample) != 0 {
+			return
+		}
+		weight = topKSample
+		t.mutex.Lock()
+	}
+	defer t.mutex.Unlock()
+
+	if entry, exists := t.index[key]; exists {
+		entry.count += weight
+		heap.Fix(&t.entries, entry.pos)
+		return
+	}
+	if len(t.entries) < t.capacity {
+		entry := &topKEntry{key: key, count: weight}
+		t.index[key] = entry
+		heap.Push(&t.entries, entry)
+		return
+	}
+
+	// Replace the least frequent key
+	least := t.entries[0]
+	delete(t.index, least.key)
+	least.key = key
+	least.count += weight
+	t.index[key] = least
+	heap.Fix(&t.entries, 0)
+}
+
+// top returns the n most frequent keys, none for n <= 0
+func (t *topK) top(n int) []KeyCount {
+	if n <= 0 {
+		return []KeyCount{}
+	}
+	t.mutex.Lock()
+	counts := make([]KeyCount, len(t.entries))
+	for i, entry := range t.entries {
+		counts[i] = KeyCount{Key: entry.key, Count: entry.count}
+	}
+	t.mutex.Unlock()
+
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].Key < counts[j].Key
+	})
+	if n < len(counts) {
+		counts = counts[:n]
+	}
+	return counts
+}
+
+// HotKeys returns the n most frequently read and missed keys when the cache
+// was created WithHotKeys, and empty lists otherwise. Counts are estimates
+// that may be too high for keys that recently became hot, and are sampled
+// while many goroutines read at once.
+func (c *cache) HotKeys(ctx context.Context, n int) HotKeys {
+	if c.hotReads == nil {
+		return HotKeys{}
+	}
+	return HotKeys{Reads: c.hotReads.top(n), Misses: c.hotMisses.top(n)}
+}
@@ -0,0 +1,213 @@
+package pkg
+
+import (
+	"cacher/internal/adapters"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var (
+	ErrRequestInProgress = errors.New("a request with this idempotency key is in progress")
+	ErrIdempotencyLost   = errors.New("the idempotency key was taken over while the request ran")
+)
+
+// DefaultIdempotencyLease is how long a request holds its idempotency key
+// without renewing it before it is considered crashed
+const DefaultIdempotencyLease = 30 * time.Second
+
+// IdempotencyHeader is the request header carrying the idempotency key
+const IdempotencyHeader = "Idempotency-Key"
+
+const (
+	idempotencyPending = "pending:"
+	idempotencyDone    = "done:"
+)
+
+// Idempotency runs each request once per idempotency key and replays its
+// result to duplicates, e.g. for payment or webhook endpoints that clients
+// retry. A request marks its key in progress with SetNX and renews the mark
+// while it runs; when its process crashes the mark expires after the lease
+// and the next duplicate runs the request again.
+type Idempotency struct {
+	server   adapters.CacheServer
+	lease    time.Duration
+	wait     bool
+	interval time.Duration
+}
+
+// IdempotencyOption configures an Idempotency
+type IdempotencyOption func(*Idempotency)
+
+// WithIdempotencyLease sets how long a request in progress holds its key
+// between renewals, see DefaultIdempotencyLease
+func WithIdempotencyLease(lease time.Duration) IdempotencyOption {
+	return func(i *Idempotency) {
+		i.lease = lease
+	}
+}
+
+// WithIdempotencyWait makes duplicates of a request in progress wait for its
+// result, checking every interval, instead of failing with
+// ErrRequestInProgress
+func WithIdempotencyWait(interval time.Duration) IdempotencyOption {
+	return func(i *Idempotency) {
+		i.wait = true
+		i.interval = interval
+	}
+}
+
+// NewIdempotency creates an Idempotency over the backend of c
+func NewIdempotency(c Cache, opts ...IdempotencyOption) (*Idempotency, error) {
+	configured, ok := c.(*cache)
+	if !ok {
+		return nil, ErrUnsupported
+	}
+	server, err := configured.unencryptedServer()
+	if err != nil {
+		return nil, err
+	}
+	i := &Idempotency{server: server, lease: DefaultIdempotencyLease, interval: 50 * time.Millisecond}
+	for _, opt := range opts {
+		opt(i)
+	}
+	if i.lease <= 0 {
+		return nil, errors.New("idempotency lease must be positive")
+	}
+	return i, nil
+}
+
+func idempotencyKey(key string) string {
+	return "idempotency:" + key
+}
+
+// Do runs fn once for key and keeps its result for ttl, returning it with
+// replayed set for duplicates. When fn fails nothing is kept, so the request
+// can be retried. Duplicates of a request in progress get
+// ErrRequestInProgress, or wait with WithIdempotencyWait.
+func (i *Idempotency) Do(ctx context.Context, key string, ttl time.Duration, fn func(ctx context.Context) ([]byte, error)) (result []byte, replayed bool, err error) {
+	mark := idempotencyPending + randomToken()
+	for {
+		acquired, err := i.server.SetNX(ctx, idempotencyKey(key), mark, i.lease)
+		if err != nil {
+			return nil, false, backendError(err)
+		}
+		if acquired {
+			break
+		}
+		value, err := i.server.Get(ctx, idempotencyKey(key))
+		if errors.Is(err, redis.Nil) {
+			// The request finished without a result or its mark expired
+			continue
+		}
+		if err != nil {
+			return nil, false, backendError(err)
+		}
+		if stored, ok := strings.CutPrefix(value, idempotencyDone); ok {
+			return []byte(stored), true, nil
+		}
+		if !i.wait {
+			return nil, false, ErrRequestInProgress
+		}
+		select {
+		case <-ctx.Done():
+			return nil, false, ctx.Err()
+		case <-time.After(i.interval):
+		}
+	}
+
+	stop := make(chan struct{})
+	renewed := make(chan struct{})
+	go i.renew(key, mark, stop, renewed)
+	result, err = fn(ctx)
+	close(stop)
+	<-renewed
+
+	if err != nil {
+		_, _ = i.server.CompareAndDelete(context.WithoutCancel(ctx), idempotencyKey(key), mark)
+		return nil, false, err
+	}
+	swapped, swapErr := i.server.CompareAndSwap(ctx, idempotencyKey(key), mark, idempotencyDone+string(result), ttl)
+	if swapErr != nil {
+		return result, false, backendError(swapErr)
+	}
+	if !swapped {
+		return result, false, ErrIdempotencyLost
+	}
+	return result, false, nil
+}
+
+// renew extends the mark of a request in progress every third of the lease
+// until stop is closed
+func (i *Idempotency) renew(key, mark string, stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(i.lease / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			// Transient errors are retried on the next tick while the mark
+			// has not expired yet
+			renewed, err := i.server.CompareAndExpire(context.Background(), idempotencyKey(key), mark, i.lease)
+			if err == nil && !renewed {
+				return
+			}
+		}
+	}
+}
+
+// Handler runs next once per Idempotency-Key header and replays the stored
+// response, marked with an Idempotent-Replayed header, to duplicates for ttl.
+// Requests without the header pass through. Duplicates of a request in
+// progress get 409 Conflict, and 5xx responses are not kept so the request
+// can be retried.
+func (i *Idempotency) Handler(next http.Handler, ttl time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(IdempotencyHeader)
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		var failed *cachedResponse
+		data, replayed, err := i.Do(r.Context(), r.Method+" "+r.URL.Path+" "+key, ttl, func(ctx context.Context) ([]byte, error) {
+			recorder := &responseRecorder{header: http.Header{}}
+			next.ServeHTTP(recorder, r)
+			if recorder.status == 0 {
+				recorder.status = http.StatusOK
+			}
+			response := cachedResponse{Status: recorder.status, Header: recorder.header, Body: recorder.body.Bytes()}
+			if response.Status >= http.StatusInternalServerError {
+				failed = &response
+				return nil, errServerFailed
+			}
+			return json.Marshal(response)
+		})
+		switch {
+		case failed != nil:
+			failed.write(w, "")
+		case errors.Is(err, ErrRequestInProgress):
+			http.Error(w, err.Error(), http.StatusConflict)
+		case err != nil && data == nil:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		default:
+			var response cachedResponse
+			if err := json.Unmarshal(data, &response); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			if replayed {
+				w.Header().Set("Idempotent-Replayed", "true")
+			}
+			response.write(w, "")
+		}
+	})
+}
+
+// errServerFailed drops the key of a request that failed with a 5xx status
+var errServerFailed = errors.New("server error")
@@ -0,0 +1,60 @@
+package pkg
+
+import (
+	"cacher/internal/adapters"
+	"context"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+// NoExpiration is returned by TTL for keys that never expire
+const NoExpiration = adapters.NoExpiration
+
+// Has reports whether key exists, including cached ErrNotFound results,
+// without fetching its value
+func (c *cache) Has(ctx context.Context, key string) (bool, error) {
+	server, err := c.server()
+	if err != nil {
+		return false, err
+	}
+	return server.Exists(ctx, key)
+}
+
+// TTL returns the remaining lifetime of key, NoExpiration if it never expires
+// and ErrCacheMiss if it does not exist
+func (c *cache) TTL(ctx context.Context, key string) (time.Duration, error) {
+	server, err := c.server()
+	if err != nil {
+		return 0, err
+	}
+	ttl, err := server.TTL(ctx, key)
+	if errors.Is(err, redis.Nil) {
+		return 0, ErrCacheMiss
+	}
+	return ttl, err
+}
+
+// GetWithTTL retrieves the value stored at key together with its remaining
+// lifetime in one round trip, for callers refreshing values themselves. The
+// lifetime is NoExpiration if the key never expires. Unlike Get it returns
+// ErrCacheMiss without consulting loaders.
+func (c *cache) GetWithTTL(ctx context.Context, key string) (interface{}, time.Duration, error) {
+	server, err := c.server()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	start := time.Now()
+	value, ttl, err := adapters.GetWithTTL(ctx, server, key)
+	if err := c.lookedUp(ctx, "get", key, start, err); err != nil {
+		return nil, 0, err
+	}
+	if value, err = c.decode(key, value); err != nil {
+		return nil, 0, err
+	}
+	if value == tombstone {
+		return nil, 0, ErrNotFound
+	}
+	return value, ttl, nil
+}
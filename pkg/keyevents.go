@@ -0,0 +1,127 @@
+package pkg
+
+import (
+	"cacher/internal/adapters"
+	"context"
+	"io"
+	"sync"
+)
+
+// KeyEvent tells that a key of the cache expired or was deleted
+type KeyEvent = adapters.KeyEvent
+
+// The types of key events
+const (
+	KeyExpired = adapters.KeyExpired
+	KeyDeleted = adapters.KeyDeleted
+)
+
+// KeyEventHandler handles a key event. Handlers run one at a time on the
+// goroutine of the subscription, so a slow one delays the others.
+type KeyEventHandler func(event KeyEvent)
+
+type keyEventHandler struct {
+	event   string
+	pattern string
+	handle  KeyEventHandler
+}
+
+// KeyEvents subscribes to the keys of a cache that expire or are deleted and
+// calls the handlers registered for them, e.g. to warm a key again or count
+// expirations. Every event also drops the key from the local tier, if any.
+// Redis publishes events as keyspace notifications, see WithKeyEventsConfig;
+// the in-memory backend reports them itself.
+type KeyEvents struct {
+	c            *cache
+	subscription io.Closer
+	mutex        sync.RWMutex
+	handlers     []keyEventHandler
+}
+
+// KeyEventsOption configures NewKeyEvents
+type KeyEventsOption func(*keyEventsOptions)
+
+type keyEventsOptions struct {
+	configure bool
+}
+
+// WithKeyEventsConfig turns on the expired and generic keyspace notifications
+// of Redis with CONFIG SET, for servers that do not publish them yet
+func WithKeyEventsConfig() KeyEventsOption {
+	return func(o *keyEventsOptions) {
+		o.configure = true
+	}
+}
+
+// NewKeyEvents subscribes to the key events of the backend of c. Events are
+// delivered until Close.
+func NewKeyEvents(ctx context.Context, c Cache, opts ...KeyEventsOption) (*KeyEvents, error) {
+	configured, ok := c.(*cache)
+	if !ok {
+		return nil, ErrUnsupported
+	}
+	server, err := configured.server()
+	if err != nil {
+		return nil, err
+	}
+	o := &keyEventsOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	if o.configure {
+		backend := server
+		if prefixed, ok := backend.(*adapters.Prefixed); ok {
+			backend = prefixed.Server
+		}
+		if client, ok := backend.(*adapters.RedisClient); ok {
+			if err := client.EnableKeyEvents(ctx); err != nil {
+				return nil, backendError(err)
+			}
+		}
+	}
+
+	k := &KeyEvents{c: configured}
+	k.subscription, err = server.SubscribeKeyEvents(ctx, k.dispatch)
+	if err != nil {
+		return nil, backendError(err)
+	}
+	return k, nil
+}
+
+// OnExpired calls handler for every key matching the glob pattern that
+// expires
+func (k *KeyEvents) OnExpired(pattern string, handler KeyEventHandler) {
+	k.on(KeyExpired, pattern, handler)
+}
+
+// OnDeleted calls handler for every key matching the glob pattern that is
+// deleted
+func (k *KeyEvents) OnDeleted(pattern string, handler KeyEventHandler) {
+	k.on(KeyDeleted, pattern, handler)
+}
+
+func (k *KeyEvents) on(event, pattern string, handler KeyEventHandler) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+	k.handlers = append(k.handlers, keyEventHandler{event: event, pattern: pattern, handle: handler})
+}
+
+func (k *KeyEvents) dispatch(event KeyEvent) {
+	if err := k.c.evictLocal(context.Background(), event.Key); err != nil {
+		k.c.logger.Warn("cache backend error", "key", event.Key, "error", err)
+	}
+	k.mutex.RLock()
+	handlers := k.handlers
+	k.mutex.RUnlock()
+	for _, handler := range handlers {
+		if handler.event == event.Type && adapters.MatchPattern(handler.pattern, event.Key) {
+			handler.handle(event)
+		}
+	}
+}
+
+// Close stops the subscription
+func (k *KeyEvents) Close() error {
+	return k.subscription.Close()
+}
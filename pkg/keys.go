@@ -0,0 +1,67 @@
+package pkg
+
+import (
+	"cacher/internal/adapters"
+	"fmt"
+	"strings"
+)
+
+// DefaultMaxKeyLength is the longest key a KeyBuilder leaves readable, which
+// is also the limit of memcached
+const DefaultMaxKeyLength = 250
+
+// DefaultKeySeparator joins the parts of a key
+const DefaultKeySeparator = ":"
+
+// KeyBuilder builds cache keys from parts such as ids and user input. Parts
+// are escaped so they cannot contain the separator or bytes backends reject,
+// which keeps ("a:b", "c") and ("a", "b:c") apart, and keys longer than
+// MaxLength are replaced by a hash led by their readable start.
+type KeyBuilder struct {
+	Separator string
+	MaxLength int
+}
+
+// NewKeyBuilder returns a KeyBuilder joining parts with DefaultKeySeparator and
+// hashing keys longer than DefaultMaxKeyLength
+func NewKeyBuilder() KeyBuilder {
+	return KeyBuilder{Separator: DefaultKeySeparator, MaxLength: DefaultMaxKeyLength}
+}
+
+// Key joins parts, formatted with fmt.Sprint, into a key that is safe for any
+// backend, e.g. Key("user", 42, email)
+func (b KeyBuilder) Key(parts ...interface{}) string {
+	separator := b.Separator
+	if separator == "" {
+		separator = DefaultKeySeparator
+	}
+	escaped := make([]string, len(parts))
+	for i, part := range parts {
+		escaped[i] = escapeKeyPart(fmt.Sprint(part), separator)
+	}
+	return b.Safe(strings.Join(escaped, separator))
+}
+
+// Safe returns key unchanged if it is short enough and only holds printable
+// ASCII without spaces, and a hash of it otherwise
+func (b KeyBuilder) Safe(key string) string {
+	maxLength := b.MaxLength
+	if maxLength <= 0 {
+		maxLength = DefaultMaxKeyLength
+	}
+	return adapters.SafeKey(key, maxLength)
+}
+
+// escapeKeyPart percent-encodes %, unsafe bytes and bytes of the separator
+func escapeKeyPart(part, separator string) string {
+	var b strings.Builder
+	for i := 0; i < len(part); i++ {
+		c := part[i]
+		if c == '%' || c <= ' ' || c >= 0x7f || strings.IndexByte(separator, c) >= 0 {
+			fmt.Fprintf(&b, "%%%02X", c)
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
@@ -0,0 +1,184 @@
+package pkg
+
+import (
+	"math/bits"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// histogramSubBuckets splits every power of two into this many buckets,
+// bounding the error of a percentile to about 6%
+const histogramSubBuckets = 16
+
+// histogramGroups is how many groups of histogramSubBuckets buckets it takes
+// to cover every uint64, the exact values and one group per power of two
+const histogramGroups = 64 - 4 + 1
+
+// histogram is a sparse log-linear histogram of latencies in microseconds,
+// in the spirit of HDR histograms. Values below histogramSubBuckets are
+// exact, larger ones share a bucket with values of the same magnitude.
+// Buckets are atomic counters allocated a power of two at a time, so
+// recording never takes a lock.
+type histogram struct {
+	groups [histogramGroups]atomic.Pointer[histogramGroup]
+}
+
+type histogramGroup [histogramSubBuckets]atomic.Uint64
+
+func histogramBucket(value uint64) uint16 {
+	if value < histogramSubBuckets {
+		return uint16(value)
+	}
+	// Keep the top 5 bits, the leading 1 and 4 more
+	shift := bits.Len64(value) - 5
+	return uint16(shift*histogramSubBuckets + int(value>>shift))
+}
+
+// histogramValue returns the highest value falling into bucket
+func histogramValue(bucket uint16) uint64 {
+	if bucket < histogramSubBuckets {
+		return uint64(bucket)
+	}
+	shift := int(bucket)/histogramSubBuckets - 1
+	mantissa := uint64(bucket)%histogramSubBuckets + histogramSubBuckets
+	return (mantissa+1)<<shift - 1
+}
+
+func (h *histogram) record(latency time.Duration) {
+	bucket := histogramBucket(uint64(max(latency.Microseconds(), 0)))
+	slot := &h.groups[bucket/histogramSubBuckets]
+	group := slot.Load()
+	if group == nil {
+		group = new(histogramGroup)
+		if !slot.CompareAndSwap(nil, group) {
+			group = slot.Load()
+		}
+	}
+	group[bucket%histogramSubBuckets].Add(1)
+}
+
+// quantiles returns the values below which the given fractions of recorded
+// latencies fall, e.g. 0.99 for p99, or nil when none were recorded
+func (h *histogram) quantiles(fractions ...float64) []uint64 {
+	// Counts may go up while they are read, so work on a copy
+	var counts [histogramGroups * histogramSubBuckets]uint64
+	var total uint64
+	for i := range h.groups {
+		group := h.groups[i].Load()
+		if group == nil {
+			continue
+		}
+		for j := range group {
+			counts[i*histogramSubBuckets+j] = group[j].Load()
+			total += counts[i*histogramSubBuckets+j]
+		}
+	}
+	if total == 0 {
+		return nil
+	}
+
+	result := make([]uint64, len(fractions))
+	for i, fraction := range fractions {
+		rank := uint64(fraction*float64(total) + 0.5)
+		var seen uint64
+		for bucket, count := range counts[:] {
+			seen += count
+			if seen >= max(rank, 1) {
+				result[i] = histogramValue(uint16(bucket))
+				break
+			}
+		}
+	}
+	return result
+}
+
+// keyLatency holds the latency histograms of one key
+type keyLatency struct {
+	hit    histogram
+	miss   histogram
+	loader histogram
+}
+
+// latencyMap holds the histograms of every key, sharded like statsMap
+type latencyMap struct {
+	shards [statsShardCount]*latencyShard
+}
+
+type latencyShard struct {
+	data  map[string]*keyLatency
+	mutex sync.RWMutex
+}
+
+func newLatencyMap() latencyMap {
+	var lm latencyMap
+	for i := range lm.shards {
+		lm.shards[i] = &latencyShard{data: make(map[string]*keyLatency)}
+	}
+	return lm
+}
+
+func (lm *latencyMap) record(key string, latency time.Duration, pick func(*keyLatency) *histogram) {
+	shard := lm.shards[statsShardOf(key)]
+
+	shard.mutex.RLock()
+	entry, exists := shard.data[key]
+	shard.mutex.RUnlock()
+	if !exists {
+		shard.mutex.Lock()
+		if entry, exists = shard.data[key]; !exists {
+			entry = &keyLatency{}
+			shard.data[key] = entry
+		}
+		shard.mutex.Unlock()
+	}
+	pick(entry).record(latency)
+}
+
+// percentiles adds p50, p95 and p99 in microseconds of every latency recorded
+// for key to stats, e.g. "hit_p99_us"
+func (lm *latencyMap) percentiles(key string, stats map[string]uint64) {
+	shard := lm.shards[statsShardOf(key)]
+	shard.mutex.RLock()
+	entry, exists := shard.data[key]
+	shard.mutex.RUnlock()
+	if !exists {
+		return
+	}
+	for name, h := range map[string]*histogram{"hit": &entry.hit, "miss": &entry.miss, "loader": &entry.loader} {
+		q := h.quantiles(0.5, 0.95, 0.99)
+		if q == nil {
+			continue
+		}
+		stats[name+"_p50_us"] = q[0]
+		stats[name+"_p95_us"] = q[1]
+		stats[name+"_p99_us"] = q[2]
+	}
+}
+
+func (c *cache) hitLatencyOf(key string, latency time.Duration) {
+	if c.RecordStatistics {
+		c.latencies.record(key, latency, func(k *keyLatency) *histogram { return &k.hit })
+	}
+}
+
+func (c *cache) missLatencyOf(key string, latency time.Duration) {
+	if c.RecordStatistics {
+		c.latencies.record(key, latency, func(k *keyLatency) *histogram { return &k.miss })
+	}
+}
+
+// loaded records how long the loader of key took
+func (c *cache) loaded(key string, latency time.Duration) {
+	if c.RecordStatistics {
+		c.latencies.record(key, latency, func(k *keyLatency) *histogram { return &k.loader })
+	}
+}
+
+// hitRatio returns hits as a percentage of all lookups, rounded
+func hitRatio(hits, misses uint64) uint64 {
+	if hits+misses == 0 {
+		return 0
+	}
+	return (hits*100 + (hits+misses)/2) / (hits + misses)
+}
@@ -0,0 +1,56 @@
+package pkg
+
+import (
+	"context"
+	"time"
+)
+
+// Leadership is held by the one instance elected leader under a name. It is
+// renewed every third of its ttl until it is resigned or lost.
+type Leadership struct {
+	lock *Lock
+}
+
+// Elect campaigns for the leadership called name, waiting until this instance
+// is elected or ctx is done. Only one instance at a time holds a leadership,
+// so background jobs run once: start them when Elect returns and stop them
+// when Lost is closed. Candidates retry every 50ms, see WithRetryInterval.
+func (l *Locker) Elect(ctx context.Context, name string, ttl time.Duration, opts ...LockOption) (*Leadership, error) {
+	lock, err := l.Lock(ctx, leaderName(name), ttl, append(opts, WithAutoRenew())...)
+	if err != nil {
+		return nil, err
+	}
+	return &Leadership{lock: lock}, nil
+}
+
+func leaderName(name string) string {
+	return "leader:" + name
+}
+
+// Lost is closed when the leadership was taken over by another instance, or
+// could not be renewed until it expired. It is not closed by Resign.
+func (l *Leadership) Lost() <-chan struct{} {
+	return l.lock.Lost()
+}
+
+// Term returns the term of the leadership, higher for every new leader, to
+// fence off writes of former leaders
+func (l *Leadership) Term() int64 {
+	return l.lock.Token()
+}
+
+// Resign gives up the leadership so another candidate is elected without
+// waiting for it to expire
+func (l *Leadership) Resign(ctx context.Context) error {
+	return l.lock.Unlock(ctx)
+}
+
+// Elect campaigns for the leadership called name for ttl, waiting until this
+// instance is elected or ctx is done
+func (c *cache) Elect(ctx context.Context, name string, ttl time.Duration, opts ...LockOption) (*Leadership, error) {
+	locker, err := NewLocker(c)
+	if err != nil {
+		return nil, err
+	}
+	return locker.Elect(ctx, name, ttl, opts...)
+}
@@ -0,0 +1,116 @@
+package pkg
+
+import (
+	"cacher/internal/adapters"
+	"context"
+	"errors"
+	"github.com/redis/go-redis/v9"
+)
+
+// Leaderboard ranks members by score, highest first, in a sorted set of the
+// backend of a cache
+type Leaderboard struct {
+	server adapters.CacheServer
+	key    string
+}
+
+// LeaderboardEntry is a member of a Leaderboard with its score and rank,
+// which starts at 1 for the highest score
+type LeaderboardEntry struct {
+	Member string
+	Score  float64
+	Rank   int64
+}
+
+// NewLeaderboard creates the leaderboard called name over the backend of c
+func NewLeaderboard(c Cache, name string) (*Leaderboard, error) {
+	configured, ok := c.(*cache)
+	if !ok {
+		return nil, ErrUnsupported
+	}
+	server, err := configured.unencryptedServer()
+	if err != nil {
+		return nil, err
+	}
+	return &Leaderboard{server: server, key: "leaderboard:" + name}, nil
+}
+
+// AddScore adds delta to the score of member, which starts at 0, and returns
+// the new score
+func (l *Leaderboard) AddScore(ctx context.Context, member string, delta float64) (float64, error) {
+	score, err := l.server.ZIncrBy(ctx, l.key, delta, member)
+	return score, leaderboardError(err)
+}
+
+// SetScore replaces the score of member
+func (l *Leaderboard) SetScore(ctx context.Context, member string, score float64) error {
+	_, err := l.server.ZAdd(ctx, l.key, redis.Z{Member: member, Score: score})
+	return leaderboardError(err)
+}
+
+// Score returns the score of member, ErrCacheMiss if it is not ranked
+func (l *Leaderboard) Score(ctx context.Context, member string) (float64, error) {
+	score, err := l.server.ZScore(ctx, l.key, member)
+	return score, leaderboardError(err)
+}
+
+// Rank returns the rank of member, 1 for the highest score, or ErrCacheMiss
+// if it is not ranked
+func (l *Leaderboard) Rank(ctx context.Context, member string) (int64, error) {
+	rank, err := l.server.ZRevRank(ctx, l.key, member)
+	if err != nil {
+		return 0, leaderboardError(err)
+	}
+	return rank + 1, nil
+}
+
+// Top returns the n members with the highest scores
+func (l *Leaderboard) Top(ctx context.Context, n int64) ([]LeaderboardEntry, error) {
+	if n <= 0 {
+		return []LeaderboardEntry{}, nil
+	}
+	return l.entries(ctx, 0, n-1)
+}
+
+// Around returns member with up to n members ranked right above and below it,
+// or ErrCacheMiss if member is not ranked
+func (l *Leaderboard) Around(ctx context.Context, member string, n int64) ([]LeaderboardEntry, error) {
+	rank, err := l.server.ZRevRank(ctx, l.key, member)
+	if err != nil {
+		return nil, leaderboardError(err)
+	}
+	return l.entries(ctx, max(rank-n, 0), rank+max(n, 0))
+}
+
+// Remove takes members off the leaderboard
+func (l *Leaderboard) Remove(ctx context.Context, members ...string) error {
+	values := make([]interface{}, len(members))
+	for i, member := range members {
+		values[i] = member
+	}
+	_, err := l.server.ZRem(ctx, l.key, values...)
+	return leaderboardError(err)
+}
+
+// entries returns the members ranked from start to stop, counting from 0
+func (l *Leaderboard) entries(ctx context.Context, start, stop int64) ([]LeaderboardEntry, error) {
+	members, err := l.server.ZRevRangeWithScores(ctx, l.key, start, stop)
+	if err != nil {
+		return nil, leaderboardError(err)
+	}
+	entries := make([]LeaderboardEntry, len(members))
+	for i, z := range members {
+		member, _ := z.Member.(string)
+		entries[i] = LeaderboardEntry{Member: member, Score: z.Score, Rank: start + int64(i) + 1}
+	}
+	return entries, nil
+}
+
+// leaderboardError maps a member missing from the sorted set to ErrCacheMiss
+// and wraps backend errors
+func leaderboardError(err error) error {
+	if errors.Is(err, redis.Nil) {
+		return ErrCacheMiss
+	}
+	return backendError(err)
+}
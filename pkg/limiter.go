@@ -0,0 +1,51 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// RateLimitResult describes the state of a GCRA limit after a Throttle call,
+// with everything needed for RateLimit-* and Retry-After response headers
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int64         // Maximum number of requests in a burst
+	Remaining  int64         // Requests left before being limited
+	RetryAfter time.Duration // When a limited request may be retried, -1 if allowed
+	ResetAfter time.Duration // When the limit is back to its maximum
+}
+
+// Allow reports whether an action identified by key may proceed under a token
+// bucket that holds up to burst tokens and refills at rate tokens per second.
+// Refill and consumption happen atomically on the backend.
+func (c *cache) Allow(ctx context.Context, key string, rate float64, burst int64) (bool, error) {
+	if rate <= 0 || burst <= 0 {
+		return false, errors.New("rate and burst must be positive")
+	}
+	server, err := c.server()
+	if err != nil {
+		return false, err
+	}
+	allowed, _, err := server.TokenBucket(ctx, key, rate, burst)
+	return allowed, err
+}
+
+// Throttle applies a GCRA limit of count requests per period, allowing bursts
+// of up to maxBurst extra requests, to quantity requests identified by key. It
+// follows the semantics of redis-cell's CL.THROTTLE.
+func (c *cache) Throttle(ctx context.Context, key string, maxBurst, count int64, period time.Duration, quantity int64) (RateLimitResult, error) {
+	if count <= 0 || period <= 0 || maxBurst < 0 || quantity < 0 {
+		return RateLimitResult{}, errors.New("count and period must be positive, maxBurst and quantity not negative")
+	}
+	server, err := c.server()
+	if err != nil {
+		return RateLimitResult{}, err
+	}
+
+	result, err := server.GCRA(ctx, key, maxBurst, count, period, quantity)
+	if err != nil {
+		return RateLimitResult{}, err
+	}
+	return RateLimitResult(result), nil
+}
@@ -0,0 +1,75 @@
+package pkg
+
+import (
+	"cacher/internal/adapters"
+	"context"
+	"sync"
+	"time"
+)
+
+// Loader computes the value of key on a cache miss. Returning ErrNotFound
+// caches the miss for the negative ttl.
+type Loader func(ctx context.Context, key string) (interface{}, error)
+
+type patternLoader struct {
+	pattern string
+	load    Loader
+}
+
+// loaders holds the read-through loaders registered with RegisterLoader
+type loaders struct {
+	mutex   sync.RWMutex
+	entries []patternLoader
+}
+
+func (l *loaders) find(key string) (Loader, bool) {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	for _, entry := range l.entries {
+		if adapters.MatchPattern(entry.pattern, key) {
+			return entry.load, true
+		}
+	}
+	return nil, false
+}
+
+// RegisterLoader makes Get read-through for keys matching the glob pattern,
+// e.g. "user:*": on a miss loader runs once per key at a time and its result
+// is stored with the default expiration. The first registered pattern that
+// matches a key wins.
+func (c *cache) RegisterLoader(pattern string, loader Loader) {
+	c.loaders.mutex.Lock()
+	defer c.loaders.mutex.Unlock()
+	c.loaders.entries = append(c.loaders.entries, patternLoader{pattern: pattern, load: loader})
+}
+
+// readThrough runs the loader registered for key after a miss. ok is false
+// when no loader matches.
+func (c *cache) readThrough(ctx context.Context, key string) (interface{}, bool, error) {
+	loader, ok := c.loaders.find(key)
+	if !ok {
+		return nil, false, nil
+	}
+	if c.filtered(ctx, key) {
+		return nil, true, ErrNotFound
+	}
+
+	value, err, _ := c.contextLoads.Do(key, func() (interface{}, error) {
+		start := time.Now()
+		value, err := loader(ctx, key)
+		c.loaded(key, time.Since(start))
+		switch {
+		case isNotFound(err):
+			value = err
+		case err != nil:
+			return nil, err
+		}
+		// Like Wrap, the loaded value is returned even if storing it failed
+		_ = c.store(ctx, key, value, c.defaultTTL)
+		return value, nil
+	})
+	if isNotFound(value) {
+		return nil, true, ErrNotFound
+	}
+	return value, true, err
+}
@@ -0,0 +1,242 @@
+package pkg
+
+import (
+	"cacher/internal/adapters"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	ErrLockNotAcquired = errors.New("lock is held by someone else")
+	ErrLockNotHeld     = errors.New("lock is no longer held")
+)
+
+// LockOption configures how a lock is acquired and held
+type LockOption func(*lockOptions)
+
+type lockOptions struct {
+	autoRenew     bool
+	retryInterval time.Duration
+}
+
+// WithAutoRenew keeps extending the lock every third of its ttl until it is
+// released, so a holder that is alive never loses it
+func WithAutoRenew() LockOption {
+	return func(o *lockOptions) {
+		o.autoRenew = true
+	}
+}
+
+// WithRetryInterval sets how often Lock retries while the lock is taken,
+// defaulting to 50ms
+func WithRetryInterval(interval time.Duration) LockOption {
+	return func(o *lockOptions) {
+		o.retryInterval = interval
+	}
+}
+
+// Locker hands out distributed locks stored in the backend of a cache
+type Locker struct {
+	server adapters.CacheServer
+}
+
+// NewLocker creates a Locker over the backend of c
+func NewLocker(c Cache) (*Locker, error) {
+	configured, ok := c.(*cache)
+	if !ok {
+		return nil, ErrUnsupported
+	}
+	server, err := configured.server()
+	if err != nil {
+		return nil, err
+	}
+	return &Locker{server: server}, nil
+}
+
+// Lock is a held distributed lock
+type Lock struct {
+	server  adapters.CacheServer
+	key     string
+	token   string
+	fence   int64
+	ttl     time.Duration
+	lost    chan struct{}
+	stop    chan struct{}
+	stopped sync.WaitGroup
+	once    sync.Once
+}
+
+func lockKey(name string) string {
+	return "lock:" + name
+}
+
+// errLockTTL is returned for a ttl the backend cannot expire a lock after
+var errLockTTL = errors.New("lock ttl must be at least a millisecond")
+
+// TryLock acquires the lock called name for ttl, at least a millisecond,
+// returning ErrLockNotAcquired right away if it is already held
+func (l *Locker) TryLock(ctx context.Context, name string, ttl time.Duration, opts ...LockOption) (*Lock, error) {
+	if ttl < time.Millisecond {
+		return nil, errLockTTL
+	}
+	o := &lockOptions{retryInterval: 50 * time.Millisecond}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	token := randomToken()
+	acquired, err := l.server.SetNX(ctx, lockKey(name), token, ttl)
+	if err != nil {
+		return nil, err
+	}
+	if !acquired {
+		return nil, ErrLockNotAcquired
+	}
+
+	// Every acquisition gets a higher fencing token than the previous one
+	fence, err := l.server.Incr(ctx, lockKey(name)+":fence")
+	if err != nil {
+		_, _ = l.server.CompareAndDelete(ctx, lockKey(name), token)
+		return nil, err
+	}
+
+	lock := &Lock{
+		server: l.server,
+		key:    lockKey(name),
+		token:  token,
+		fence:  fence,
+		ttl:    ttl,
+		lost:   make(chan struct{}),
+		stop:   make(chan struct{}),
+	}
+	if o.autoRenew {
+		lock.stopped.Add(1)
+		go lock.watchdog()
+	}
+	return lock, nil
+}
+
+// Lock acquires the lock called name for ttl, at least a millisecond,
+// waiting until it is free or ctx is done
+func (l *Locker) Lock(ctx context.Context, name string, ttl time.Duration, opts ...LockOption) (*Lock, error) {
+	o := &lockOptions{retryInterval: 50 * time.Millisecond}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if ttl < time.Millisecond {
+		return nil, errLockTTL
+	}
+	if o.retryInterval <= 0 {
+		return nil, errors.New("lock retry interval must be positive")
+	}
+
+	ticker := time.NewTicker(o.retryInterval)
+	defer ticker.Stop()
+	for {
+		lock, err := l.TryLock(ctx, name, ttl, opts...)
+		if !errors.Is(err, ErrLockNotAcquired) {
+			return lock, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Token returns the fencing token of the lock. Tokens increase with every
+// acquisition, so storage guarded by the lock can reject stale holders.
+func (l *Lock) Token() int64 {
+	return l.fence
+}
+
+// Lost is closed when an auto renewed lock could not be extended because
+// someone else took it over, or the backend failed until it expired
+func (l *Lock) Lost() <-chan struct{} {
+	return l.lost
+}
+
+// Refresh extends the lock by its ttl
+func (l *Lock) Refresh(ctx context.Context) error {
+	renewed, err := l.server.CompareAndExpire(ctx, l.key, l.token, l.ttl)
+	if err != nil {
+		return err
+	}
+	if !renewed {
+		return ErrLockNotHeld
+	}
+	return nil
+}
+
+// Unlock releases the lock if it is still held by l
+func (l *Lock) Unlock(ctx context.Context) error {
+	l.once.Do(func() {
+		close(l.stop)
+	})
+	l.stopped.Wait()
+
+	released, err := l.server.CompareAndDelete(ctx, l.key, l.token)
+	if err != nil {
+		return err
+	}
+	if !released {
+		return ErrLockNotHeld
+	}
+	return nil
+}
+
+func (l *Lock) watchdog() {
+	defer l.stopped.Done()
+	ticker := time.NewTicker(l.ttl / 3)
+	defer ticker.Stop()
+	renewed := time.Now()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+			// Transient errors are retried on the next tick while the lock
+			// has not expired yet
+			err := l.Refresh(context.Background())
+			if err == nil {
+				renewed = time.Now()
+			}
+			if errors.Is(err, ErrLockNotHeld) || err != nil && time.Since(renewed) >= l.ttl {
+				close(l.lost)
+				return
+			}
+		}
+	}
+}
+
+// Lock acquires the distributed lock called name for ttl, waiting until it is
+// free or ctx is done
+func (c *cache) Lock(ctx context.Context, name string, ttl time.Duration, opts ...LockOption) (*Lock, error) {
+	locker, err := NewLocker(c)
+	if err != nil {
+		return nil, err
+	}
+	return locker.Lock(ctx, name, ttl, opts...)
+}
+
+// TryLock acquires the distributed lock called name for ttl, returning
+// ErrLockNotAcquired right away if it is already held
+func (c *cache) TryLock(ctx context.Context, name string, ttl time.Duration, opts ...LockOption) (*Lock, error) {
+	locker, err := NewLocker(c)
+	if err != nil {
+		return nil, err
+	}
+	return locker.TryLock(ctx, name, ttl, opts...)
+}
+
+func randomToken() string {
+	token := make([]byte, 16)
+	_, _ = rand.Read(token)
+	return hex.EncodeToString(token)
+}
@@ -0,0 +1,19 @@
+package pkg
+
+import "cacher/internal/adapters"
+
+// Logger receives log records made of a message and alternating key value
+// pairs, e.g. logger.Debug("cache hit", "key", key). *slog.Logger implements
+// it, so WithLogger(slog.Default()) routes records to the application log.
+type Logger = adapters.Logger
+
+// WithLogger sends hits and misses at debug level and backend errors at warn
+// level to logger. By default, or when logger is nil, nothing is logged.
+func WithLogger(logger Logger) Option {
+	return func(o *options) {
+		if logger == nil {
+			logger = adapters.NopLogger()
+		}
+		o.logger = logger
+	}
+}
@@ -0,0 +1,103 @@
+package pkg
+
+import (
+	"cacher/config"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Manager holds several named caches, e.g. "sessions" on one Redis database
+// and "pages" in memory, so code asks for a store by name instead of wiring
+// each cache by hand
+type Manager struct {
+	mutex       sync.RWMutex
+	stores      map[string]Cache
+	defaultName string
+}
+
+// NewManager opens every store of cfg with NewFromConfig, applying opts to all
+// of them. The default store is cfg.Default, or the only store if there is
+// just one.
+func NewManager(cfg config.Manager, opts ...Option) (*Manager, error) {
+	m := &Manager{stores: make(map[string]Cache, len(cfg.Stores)), defaultName: cfg.Default}
+	if m.defaultName == "" && len(cfg.Stores) == 1 {
+		for name := range cfg.Stores {
+			m.defaultName = name
+		}
+	}
+	if _, ok := cfg.Stores[m.defaultName]; !ok && m.defaultName != "" {
+		return nil, fmt.Errorf("default cache store %q is not configured", m.defaultName)
+	}
+
+	for name, storeConfig := range cfg.Stores {
+		store, err := NewFromConfig(storeConfig, opts...)
+		if err != nil {
+			_ = m.Close(context.Background())
+			return nil, fmt.Errorf("cache store %q: %w", name, err)
+		}
+		m.stores[name] = store
+	}
+	return m, nil
+}
+
+// Add registers an already created cache under name, replacing any store
+// with that name
+func (m *Manager) Add(name string, store Cache) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.stores == nil {
+		m.stores = make(map[string]Cache)
+	}
+	m.stores[name] = store
+}
+
+// Lookup returns the store called name and whether it exists
+func (m *Manager) Lookup(name string) (Cache, bool) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	store, ok := m.stores[name]
+	return store, ok
+}
+
+// Store returns the store called name. It panics if there is none, as asking
+// for a store that was never configured is a programming error.
+func (m *Manager) Store(name string) Cache {
+	store, ok := m.Lookup(name)
+	if !ok {
+		panic(fmt.Sprintf("cacher: cache store %q is not configured", name))
+	}
+	return store
+}
+
+// Default returns the default store
+func (m *Manager) Default() Cache {
+	return m.Store(m.defaultName)
+}
+
+// Names returns the names of the stores in order
+func (m *Manager) Names() []string {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	names := make([]string, 0, len(m.stores))
+	for name := range m.stores {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Close closes every store
+func (m *Manager) Close(ctx context.Context) error {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	var errs []error
+	for name, store := range m.stores {
+		if err := store.Close(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("cache store %q: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,95 @@
+package pkg
+
+import (
+	"cacher/internal/adapters"
+	"context"
+	"errors"
+)
+
+// ErrValueTooLarge is returned by writes of values over the size given with
+// WithMaxValueSize when the policy is RejectOversized
+var ErrValueTooLarge = errors.New("value exceeds the maximum cached size")
+
+// OversizePolicy decides what happens to values over the maximum size
+type OversizePolicy int
+
+const (
+	// RejectOversized fails the write with ErrValueTooLarge
+	RejectOversized OversizePolicy = iota
+	// LogOversized skips the write and logs a warning with the key and the size
+	LogOversized
+	// SkipOversized skips the write silently
+	SkipOversized
+)
+
+// valueSize returns the length of value as it is written to the backend,
+// before compression. Values that cannot be written count as 0, their write
+// fails anyway.
+func valueSize(value interface{}) int {
+	switch v := value.(type) {
+	case string:
+		return len(v)
+	case []byte:
+		return len(v)
+	}
+	formatted, err := adapters.FormatValue(value)
+	if err != nil {
+		return 0
+	}
+	return len(formatted)
+}
+
+// oversized reports whether value is over the maximum size and must not be
+// written, counting it in the statistics of key. The error is
+// ErrValueTooLarge under RejectOversized and nil when the write is skipped.
+func (c *cache) oversized(ctx context.Context, key string, value interface{}) (bool, error) {
+	// Measuring may marshal the value, so only when there is a limit
+	if c.maxValueSize <= 0 {
+		return false, nil
+	}
+	size := valueSize(value)
+	if size <= c.maxValueSize {
+		return false, nil
+	}
+
+	if c.RecordStatistics {
+		c.oversizeStats.increment(key)
+	}
+	switch c.oversizePolicy {
+	case RejectOversized:
+		return true, ErrValueTooLarge
+	case LogOversized:
+		// Not the value itself, it may hold secrets or personal data
+		c.logger.Warn("value too large to cache", "key", key, "size", size, "max", c.maxValueSize)
+	}
+	return true, nil
+}
+
+// withoutOversized returns values without the ones over the maximum size, or
+// ErrValueTooLarge under RejectOversized so nothing of the batch is written
+func (c *cache) withoutOversized(ctx context.Context, values map[string]interface{}) (map[string]interface{}, error) {
+	if c.maxValueSize <= 0 {
+		return values, nil
+	}
+
+	var kept map[string]interface{}
+	for key, value := range values {
+		skip, err := c.oversized(ctx, key, value)
+		if err != nil {
+			return nil, err
+		}
+		if skip && kept == nil {
+			kept = make(map[string]interface{}, len(values))
+			for k, v := range values {
+				kept[k] = v
+			}
+		}
+		if skip {
+			delete(kept, key)
+		}
+	}
+	if kept == nil {
+		return values, nil
+	}
+	return kept, nil
+}
@@ -0,0 +1,81 @@
+package pkg
+
+import (
+	"cacher/internal/adapters"
+	"context"
+	"net/http"
+	"time"
+)
+
+// MiddlewareOption configures GinMiddleware and EchoMiddleware
+type MiddlewareOption func(*middleware)
+
+// WithRouteTTL caches the responses of the routes matching the glob pattern
+// for ttl instead of the default, e.g. "/products/:id". Patterns match the
+// route a request was routed to, not its path, and the first one matching
+// applies. A ttl of 0 turns caching off for the routes.
+func WithRouteTTL(pattern string, ttl time.Duration) MiddlewareOption {
+	return func(m *middleware) {
+		m.routes = append(m.routes, routeTTL{pattern: pattern, ttl: ttl})
+	}
+}
+
+// WithMiddlewareKey sets the key of responses, see Handler
+func WithMiddlewareKey(key HandlerKeyFunc) MiddlewareOption {
+	return func(m *middleware) {
+		m.key = key
+	}
+}
+
+// WithHandlerOptions configures the Handler behind the middleware, e.g. with
+// WithStaleIfError
+func WithHandlerOptions(opts ...HandlerOption) MiddlewareOption {
+	return func(m *middleware) {
+		m.handlerOptions = append(m.handlerOptions, opts...)
+	}
+}
+
+type routeTTL struct {
+	pattern string
+	ttl     time.Duration
+}
+
+// middleware holds what framework middlewares share: a Handler whose next
+// handler resumes the chain of the framework, found in the request context
+type middleware struct {
+	ttl            time.Duration
+	routes         []routeTTL
+	key            HandlerKeyFunc
+	handlerOptions []HandlerOption
+	handler        *handler
+}
+
+// routeKey is the context key of the route a request was routed to
+type routeKey struct{}
+
+func newMiddleware(c Cache, ttl time.Duration, next http.Handler, opts []MiddlewareOption) *middleware {
+	m := &middleware{ttl: ttl}
+	for _, opt := range opts {
+		opt(m)
+	}
+	m.handler = newHandler(c, next, m.key, m.routeTTL, m.handlerOptions...)
+	return m
+}
+
+// routeTTL returns the TTL of the route of r
+func (m *middleware) routeTTL(r *http.Request) time.Duration {
+	route, _ := r.Context().Value(routeKey{}).(string)
+	for _, override := range m.routes {
+		if adapters.MatchPattern(override.pattern, route) {
+			return override.ttl
+		}
+	}
+	return m.ttl
+}
+
+// serve runs the handler for r, routed to route, with the framework context
+// stored under key for the next handler
+func (m *middleware) serve(w http.ResponseWriter, r *http.Request, route string, key, framework interface{}) {
+	ctx := context.WithValue(r.Context(), routeKey{}, route)
+	m.handler.ServeHTTP(w, r.WithContext(context.WithValue(ctx, key, framework)))
+}
@@ -0,0 +1,19 @@
+package pkg
+
+import (
+	"cacher/internal/adapters"
+	"context"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// NewMongoCache creates a cache stored in a MongoDB collection, for stacks
+// standardized on Mongo. A TTL index on the collection is created if needed,
+// and its client stays connected when the cache is closed.
+func NewMongoCache(ctx context.Context, collection *mongo.Collection, opts ...Option) (Cache, error) {
+	server, err := adapters.OpenMongo(ctx, collection)
+	if err != nil {
+		return nil, err
+	}
+	opts = append([]Option{withServer(server)}, opts...)
+	return NewCache(opts...), nil
+}
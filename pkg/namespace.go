@@ -0,0 +1,117 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"strconv"
+	"time"
+)
+
+// Namespace is a group of keys that can be invalidated at once. Keys are
+// stored under the current version of the namespace, kept in the backend,
+// so bumping the version makes every key of the namespace miss without
+// scanning or deleting them. Entries of old versions are left to expire, so a
+// namespace should be used with a default expiration.
+type Namespace struct {
+	cache *cache
+	name  string
+}
+
+func namespaceKey(name string) string {
+	return "ns:" + name
+}
+
+// Namespace returns the namespace called name. Namespaces need a CacheServer
+// backend to keep their version; with other adapters every call fails with
+// ErrUnsupported.
+func (c *cache) Namespace(name string) *Namespace {
+	return &Namespace{cache: c, name: name}
+}
+
+// Version returns the current version of the namespace, 0 until it is first
+// invalidated
+func (n *Namespace) Version(ctx context.Context) (int64, error) {
+	server, err := n.cache.server()
+	if err != nil {
+		return 0, err
+	}
+	value, err := server.Get(ctx, namespaceKey(n.name))
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(value, 10, 64)
+}
+
+// Key returns the key under which key of the namespace is currently stored
+func (n *Namespace) Key(ctx context.Context, key string) (string, error) {
+	version, err := n.Version(ctx)
+	if err != nil {
+		return "", err
+	}
+	return n.name + ":" + strconv.FormatInt(version, 10) + ":" + key, nil
+}
+
+// Invalidate bumps the version of the namespace, so every key stored in it
+// before misses
+func (n *Namespace) Invalidate(ctx context.Context) error {
+	server, err := n.cache.server()
+	if err != nil {
+		return err
+	}
+	_, err = server.IncrBy(ctx, namespaceKey(n.name), 1, 0)
+	return err
+}
+
+// Get retrieves the value stored at key in the namespace
+func (n *Namespace) Get(ctx context.Context, key string) (interface{}, error) {
+	stored, err := n.Key(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return n.cache.Get(ctx, stored)
+}
+
+// Set stores value at key in the namespace with the default expiration
+func (n *Namespace) Set(ctx context.Context, key string, value interface{}, opts ...SetOption) error {
+	return n.SetWithTTL(ctx, key, value, n.cache.defaultTTL, opts...)
+}
+
+// SetWithTTL stores value at key in the namespace with the given expiration
+func (n *Namespace) SetWithTTL(ctx context.Context, key string, value interface{}, ttl time.Duration, opts ...SetOption) error {
+	stored, err := n.Key(ctx, key)
+	if err != nil {
+		return err
+	}
+	return n.cache.SetWithTTL(ctx, stored, value, ttl, opts...)
+}
+
+// Delete removes key from the namespace
+func (n *Namespace) Delete(ctx context.Context, key string) error {
+	stored, err := n.Key(ctx, key)
+	if err != nil {
+		return err
+	}
+	return n.cache.Delete(ctx, stored)
+}
+
+// Wrap returns the cached value for key in the namespace, computing and
+// storing it with the default expiration on a miss
+func (n *Namespace) Wrap(ctx context.Context, key string, value func() interface{}) interface{} {
+	return n.WrapTTL(ctx, key, n.cache.defaultTTL, value)
+}
+
+// WrapTTL behaves like Wrap but stores computed values with the given
+// expiration. When the version cannot be read the value is computed and
+// returned without being stored.
+func (n *Namespace) WrapTTL(ctx context.Context, key string, ttl time.Duration, value func() interface{}) interface{} {
+	stored, err := n.Key(ctx, key)
+	if err != nil {
+		n.cache.logger.Warn("cache backend error", "namespace", n.name, "key", key, "backend", n.cache.backend, "error", err)
+		return value()
+	}
+	return n.cache.WrapTTL(ctx, stored, ttl, value)
+}
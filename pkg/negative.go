@@ -0,0 +1,37 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrNotFound is returned by a Wrap loader to report that the value does not
+// exist. Wrap then caches this result for the negative ttl, see
+// WithNegativeTTL, and returns ErrNotFound to every caller until it expires,
+// so lookups of missing IDs do not reach the database each time.
+var ErrNotFound = errors.New("not found")
+
+// DefaultNegativeTTL is how long ErrNotFound results are cached unless
+// WithNegativeTTL is given
+const DefaultNegativeTTL = 30 * time.Second
+
+// tombstone is stored in place of a value the loader did not find
+const tombstone = "\x00cacher:not-found"
+
+func isNotFound(result interface{}) bool {
+	err, ok := result.(error)
+	return ok && errors.Is(err, ErrNotFound)
+}
+
+// store writes a computed value with ttl, or a tombstone with the negative
+// ttl when the loader reported ErrNotFound
+func (c *cache) store(ctx context.Context, key string, result interface{}, ttl time.Duration) error {
+	if !isNotFound(result) {
+		return c.SetWithTTL(ctx, key, result, ttl)
+	}
+	if c.negativeTTL <= 0 {
+		return nil
+	}
+	return c.SetWithTTL(ctx, key, tombstone, c.negativeTTL)
+}
@@ -0,0 +1,813 @@
+package pkg
+
+import (
+	"cacher/codec"
+	"cacher/internal/adapters"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+	"os"
+	"strings"
+	"time"
+)
+
+// Option configures a cache created by NewCache
+type Option func(*options)
+
+type options struct {
+	redisAddr           string
+	redisClient         redis.UniversalClient
+	clusterAddrs        []string
+	shardAddrs          []string
+	replicaAddrs        []string
+	replicaPolicy       ReplicaPolicy
+	replicaMaxLag       time.Duration
+	sentinelMaster      string
+	sentinelAddrs       []string
+	username            string
+	password            string
+	db                  int
+	tlsConfig           *tls.Config
+	poolSize            int
+	adapter             adapters.Cache
+	server              adapters.CacheServer
+	prefix              string
+	maxKeyLength        int
+	flushRequiresPrefix bool
+	codec               codec.Codec
+	compression         adapters.Compression
+	compressThreshold   int
+	encryptionKeys      [][]byte
+	plaintextReads      bool
+	keyring             *adapters.Keyring
+	maxValueSize        int
+	oversizePolicy      OversizePolicy
+	defaultTTL          time.Duration
+	ttlJitter           float64
+	negativeTTL         time.Duration
+	filterPattern       string
+	filterItems         int64
+	filterRate          float64
+	xfetchBeta          float64
+	memoryMaxEntries    int
+	memoryMaxBytes      int64
+	onEvict             func(key string)
+	evictionPolicy      EvictionPolicy
+	snapshotPath        string
+	snapshotInterval    time.Duration
+	slidingTTL          time.Duration
+	localTTL            time.Duration
+	localMaxBytes       int64
+	disabled            bool
+	invalidation        string
+	timeout             time.Duration
+	retry               adapters.RetryPolicy
+	writeBuffer         int
+	writeWorkers        int
+	refreshWindow       time.Duration
+	refreshMinRate      float64
+	refreshWorkers      int
+	breakerThreshold    int
+	breakerCooldown     time.Duration
+	memoryFallback      bool
+	fallbacks           []adapters.Cache
+	migrateFrom         adapters.Cache
+	migrateCompareRate  float64
+	tracerProvider      trace.TracerProvider
+	logger              Logger
+	recordStatistics    bool
+	hotKeys             int
+	reporter            StatsReporter
+	reportInterval      time.Duration
+	sharedStatsName     string
+	sharedStatsInterval time.Duration
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		redisAddr:      "localhost:6379",
+		codec:          codec.JSON,
+		tracerProvider: noop.NewTracerProvider(),
+		logger:         adapters.NopLogger(),
+		defaultTTL:     DefaultTTL,
+		negativeTTL:    DefaultNegativeTTL,
+		refreshMinRate: 1,
+		refreshWorkers: 4,
+		writeBuffer:    1024,
+		writeWorkers:   1,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithRedisAddr sets the address of the Redis server, defaulting to localhost:6379
+func WithRedisAddr(addr string) Option {
+	return func(o *options) {
+		o.redisAddr = addr
+	}
+}
+
+// WithRedisCluster connects to a Redis Cluster through the given seed nodes
+func WithRedisCluster(addrs ...string) Option {
+	return func(o *options) {
+		o.clusterAddrs = addrs
+	}
+}
+
+// WithRedisShards spreads keys over independent Redis servers with a
+// consistent hash ring, for more memory and throughput than one server has
+// without running Redis Cluster. Each server is a shard named by its address,
+// so keep the addresses stable; keys sharing a {hash tag} stay together.
+func WithRedisShards(addrs ...string) Option {
+	return func(o *options) {
+		o.shardAddrs = addrs
+	}
+}
+
+// WithRedisReplicas reads from the Redis replicas at addrs and writes to the
+// primary set with WithRedisAddr. Reads fall back to the primary when a
+// replica fails or lags behind, see WithReplicaPolicy.
+func WithRedisReplicas(addrs ...string) Option {
+	return func(o *options) {
+		o.replicaAddrs = addrs
+	}
+}
+
+// WithReplicaPolicy sets how WithRedisReplicas picks the replica of a read,
+// RoundRobin by default, and skips replicas more than maxLag behind the
+// primary. A maxLag of 0 lets replicas lag any amount.
+func WithReplicaPolicy(policy ReplicaPolicy, maxLag time.Duration) Option {
+	return func(o *options) {
+		o.replicaPolicy = policy
+		o.replicaMaxLag = maxLag
+	}
+}
+
+// WithRedisSentinel connects to the master called masterName as reported by
+// the given sentinels. The client follows the master across failovers, so
+// callers need no changes when the primary is replaced.
+func WithRedisSentinel(masterName string, sentinelAddrs ...string) Option {
+	return func(o *options) {
+		o.sentinelMaster = masterName
+		o.sentinelAddrs = sentinelAddrs
+	}
+}
+
+// WithRedisAuth authenticates with username and password. Leave username
+// empty for servers that only use a password (requirepass).
+func WithRedisAuth(username, password string) Option {
+	return func(o *options) {
+		o.username = username
+		o.password = password
+	}
+}
+
+// WithRedisDB selects the database index. Redis Cluster only supports 0.
+func WithRedisDB(db int) Option {
+	return func(o *options) {
+		o.db = db
+	}
+}
+
+// WithRedisPoolSize sets the maximum number of connections per Redis node,
+// defaulting to ten per CPU
+func WithRedisPoolSize(size int) Option {
+	return func(o *options) {
+		o.poolSize = size
+	}
+}
+
+// WithRedisTLS connects over TLS, as required by most managed Redis services.
+// See TLSConfig to build a configuration from certificate files.
+func WithRedisTLS(config *tls.Config) Option {
+	return func(o *options) {
+		o.tlsConfig = config
+	}
+}
+
+// TLSConfig builds a TLS configuration from PEM files. certFile and keyFile
+// hold a client certificate for mutual TLS and caFile the authority that
+// signed the server certificate. Any of them may be empty.
+func TLSConfig(certFile, keyFile, caFile string) (*tls.Config, error) {
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+	if certFile != "" || keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, err
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", caFile)
+		}
+		config.RootCAs = pool
+	}
+	return config, nil
+}
+
+// WithRedisClient uses an already configured Redis client, which may be a
+// *redis.Client, *redis.ClusterClient or any other redis.UniversalClient
+func WithRedisClient(client redis.UniversalClient) Option {
+	return func(o *options) {
+		o.redisClient = client
+	}
+}
+
+// WithAdapter uses the given adapter instead of Redis
+func WithAdapter(adapter adapters.Cache) Option {
+	return func(o *options) {
+		o.adapter = adapter
+	}
+}
+
+// WithPrefix namespaces every key with prefix, e.g. "myapp:", so several
+// applications can share one Redis. It does not apply to WithAdapter.
+func WithPrefix(prefix string) Option {
+	return func(o *options) {
+		o.prefix = prefix
+	}
+}
+
+// WithMaxKeyLength makes keys safe for any input: keys longer than maxLength
+// or holding spaces, control characters or non-ASCII bytes are stored under
+// a hash of the key instead, see KeyBuilder.Safe. Like WithPrefix it does not
+// apply to WithAdapter; the memcached adapter always hashes such keys.
+func WithMaxKeyLength(maxLength int) Option {
+	return func(o *options) {
+		o.maxKeyLength = maxLength
+	}
+}
+
+// WithFlushRequiresPrefix makes Flush fail with ErrNoPrefix unless WithPrefix
+// is set, so a cache sharing its backend cannot wipe it by mistake
+func WithFlushRequiresPrefix() Option {
+	return func(o *options) {
+		o.flushRequiresPrefix = true
+	}
+}
+
+// withServer stores data in server instead of Redis
+func withServer(server adapters.CacheServer) Option {
+	return func(o *options) {
+		o.server = server
+	}
+}
+
+// WithMemoryLimits bounds the in-process memory of NewMemoryCache to about
+// maxEntries keys and maxBytes of keys and encoded values, evicting the least
+// recently used keys beyond them. Zero leaves a limit off. See
+// EvictionStatistics.
+func WithMemoryLimits(maxEntries int, maxBytes int64) Option {
+	return func(o *options) {
+		o.memoryMaxEntries = maxEntries
+		o.memoryMaxBytes = maxBytes
+	}
+}
+
+// WithEvictionPolicy chooses the keys the memory of NewMemoryCache evicts to
+// respect WithMemoryLimits, LRU by default. TinyLFU keeps frequently read keys
+// when many keys are read only once.
+func WithEvictionPolicy(policy EvictionPolicy) Option {
+	return func(o *options) {
+		o.evictionPolicy = policy
+	}
+}
+
+// WithOnEvict calls fn with every key the memory of NewMemoryCache evicts to
+// respect WithMemoryLimits. fn runs while part of the memory is locked and
+// must not use the cache.
+func WithOnEvict(fn func(key string)) Option {
+	return func(o *options) {
+		o.onEvict = fn
+	}
+}
+
+// WithSnapshotFile restores the memory of NewMemoryCache from the snapshot
+// file at path when the cache is created and saves it there every interval
+// and on Close, so a restarted process does not start cold. A zero interval
+// only saves on Close.
+func WithSnapshotFile(path string, interval time.Duration) Option {
+	return func(o *options) {
+		o.snapshotPath = path
+		o.snapshotInterval = interval
+	}
+}
+
+// WithDisabled turns caching off when disabled is true: reads always miss and
+// writes are dropped, so loaders run on every call. It lets an environment
+// switch caching off from its configuration without changing code paths.
+func WithDisabled(disabled bool) Option {
+	return func(o *options) {
+		o.disabled = disabled
+	}
+}
+
+// WithCodec sets the codec TypedCache uses to serialize values, defaulting to JSON
+func WithCodec(c codec.Codec) Option {
+	return func(o *options) {
+		o.codec = c
+	}
+}
+
+// WithCompression stores values longer than threshold bytes compressed with
+// compression. Compressed values carry a header naming the algorithm, so they
+// stay readable after the option changes.
+func WithCompression(compression Compression, threshold int) Option {
+	return func(o *options) {
+		o.compression = compression
+		o.compressThreshold = threshold
+	}
+}
+
+// WithEncryption encrypts every value with AES-GCM under key before it is
+// written. Values sealed with one of the previous keys stay readable, so a
+// key is rotated by passing the new key first and the old one after it.
+// Values are encrypted before they reach any backend, those of WithFallback
+// and WithMigrationFrom included. Data kept in hashes, sets, lists or streams
+// cannot be, nor can counters the backend adds to, so HashCache, NewSet,
+// NewStream, NewReliableQueue, NewIdempotency, NewLeaderboard, NewGeoIndex,
+// the user index of NewSessionStore, Increment and the counters built on it
+// fail with ErrEncryptionUnsupported.
+// Keys must be 16, 24 or 32 bytes long, otherwise Open fails and every
+// operation of the cache returns ErrInvalidEncryptionKey.
+func WithEncryption(key []byte, previous ...[]byte) Option {
+	return func(o *options) {
+		o.encryptionKeys = append([][]byte{key}, previous...)
+	}
+}
+
+// WithPlaintextReads lets a cache with WithEncryption read values stored
+// before it was enabled, which otherwise fail with ErrNotEncrypted. Anyone who
+// can write to the backend can then hand the cache values of their choosing,
+// so it is only meant for migrating a cache to encryption until the values
+// written without it expired.
+func WithPlaintextReads() Option {
+	return func(o *options) {
+		o.plaintextReads = true
+	}
+}
+
+// WithMaxValueSize stops values longer than size bytes, measured before
+// compression, from being cached. policy decides whether such writes fail
+// with ErrValueTooLarge, are logged or are skipped silently.
+func WithMaxValueSize(size int, policy OversizePolicy) Option {
+	return func(o *options) {
+		o.maxValueSize = size
+		o.oversizePolicy = policy
+	}
+}
+
+// WithDefaultTTL sets the expiration used by Set and Wrap. Pass Forever to
+// store without an expiration, or KeepTTL to also keep the expiration of keys
+// that are overwritten.
+func WithDefaultTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		o.defaultTTL = ttl
+	}
+}
+
+// WithNegativeTTL sets how long Wrap caches an ErrNotFound result of its
+// loader. Zero disables negative caching.
+func WithNegativeTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		o.negativeTTL = ttl
+	}
+}
+
+// WithKeyFilter makes Wrap check the keys matching the glob pattern against a
+// Bloom filter before loading them and return ErrNotFound for keys it was
+// never given, without calling the loader or caching anything. The filter is
+// sized for expectedItems at falsePositiveRate, see NewFilter; fill it with
+// the keys that exist through KeyFilter, e.g. on startup and on every insert.
+func WithKeyFilter(pattern string, expectedItems int64, falsePositiveRate float64) Option {
+	return func(o *options) {
+		o.filterPattern = pattern
+		o.filterItems = expectedItems
+		o.filterRate = falsePositiveRate
+	}
+}
+
+// WithEarlyExpiration lets Wrap recompute values before they expire using
+// the XFetch algorithm. Each hit recomputes with a probability that rises as
+// expiry nears, scaled by how long the loader took and by beta, where 1 is a
+// good default and larger values recompute earlier. It stores the loader's
+// compute time in an extra key next to each value.
+func WithEarlyExpiration(beta float64) Option {
+	return func(o *options) {
+		o.xfetchBeta = beta
+	}
+}
+
+// WithSlidingTTL makes every Get hit, including those of Wrap, reset the
+// expiration of the key to ttl, so values such as sessions expire only after
+// ttl without being read. Keys stored without an expiration keep none. Each
+// hit costs an extra round trip, except those served by the local tier.
+func WithSlidingTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		o.slidingTTL = ttl
+	}
+}
+
+// WithTTLJitter randomizes every expiration by up to fraction in either
+// direction, e.g. 0.1 turns a ttl of one hour into 54 to 66 minutes, so keys
+// written together do not all expire at once
+func WithTTLJitter(fraction float64) Option {
+	return func(o *options) {
+		o.ttlJitter = min(max(fraction, 0), 1)
+	}
+}
+
+// WithLocalTier puts an in-process memory tier in front of the adapter. Entries
+// are kept locally for at most ttl.
+func WithLocalTier(ttl time.Duration) Option {
+	return func(o *options) {
+		o.localTTL = ttl
+	}
+}
+
+// WithLocalTierSize bounds the local tier of WithLocalTier to maxBytes of
+// serialized entries, using ristretto so only frequently read keys are
+// admitted and the least valuable ones are evicted
+func WithLocalTierSize(maxBytes int64) Option {
+	return func(o *options) {
+		o.localMaxBytes = maxBytes
+	}
+}
+
+// WithInvalidation broadcasts writes and deletes on the given Redis Pub/Sub
+// channel so every instance sharing it evicts its local tier copy. It only
+// has an effect together with WithLocalTier. When the channel cannot be
+// subscribed to, the cache runs without its local tier and logs an error.
+func WithInvalidation(channel string) Option {
+	return func(o *options) {
+		o.invalidation = channel
+	}
+}
+
+// WithRefreshAhead configures WrapWithRefresh: entries read at least
+// minHitRate times per second are recomputed by one of workers goroutines
+// when they expire within window. A zero window refreshes entries in the last
+// tenth of their ttl. The defaults are one hit per second and four workers.
+func WithRefreshAhead(window time.Duration, minHitRate float64, workers int) Option {
+	return func(o *options) {
+		o.refreshWindow = window
+		o.refreshMinRate = minHitRate
+		o.refreshWorkers = workers
+	}
+}
+
+// WithWriteBehind sizes the buffer of SetAsync writes and the number of
+// workers storing them, defaulting to 1024 writes and one worker
+func WithWriteBehind(bufferSize, workers int) Option {
+	return func(o *options) {
+		o.writeBuffer = bufferSize
+		o.writeWorkers = workers
+	}
+}
+
+// WithTimeout bounds every backend call, so a slow Redis fails fast instead of
+// stalling the request path
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.timeout = timeout
+	}
+}
+
+// WithRetry retries backend calls failing with transient errors, such as
+// timeouts, dropped connections or a loading replica, up to maxAttempts calls
+// in total. Before each retry it waits a random delay of up to baseDelay,
+// doubling with every retry and capped at maxDelay. It replaces the retries
+// of the Redis client created by the cache.
+func WithRetry(maxAttempts int, baseDelay, maxDelay time.Duration) Option {
+	return func(o *options) {
+		o.retry = adapters.RetryPolicy{
+			MaxAttempts: maxAttempts,
+			BaseDelay:   baseDelay,
+			MaxDelay:    maxDelay,
+		}
+	}
+}
+
+// WithCircuitBreaker stops calling the backend after threshold consecutive
+// errors. While open, reads miss and Wrap calls its loader directly, unless
+// WithMemoryFallback is given. Every cooldown one request probes whether the
+// backend has recovered.
+func WithCircuitBreaker(threshold int, cooldown time.Duration) Option {
+	return func(o *options) {
+		o.breakerThreshold = threshold
+		o.breakerCooldown = cooldown
+	}
+}
+
+// WithMemoryFallback serves reads and writes from an in-process memory store
+// while the circuit breaker is open. It only has an effect together with
+// WithCircuitBreaker.
+func WithMemoryFallback() Option {
+	return func(o *options) {
+		o.memoryFallback = true
+	}
+}
+
+// WithFallback chains the backend configured by the other options with the
+// ones of backups, e.g. a NewBoltCache on local disk: reads fall through to
+// the next backend on a miss or an error, writes go to all of them, and a
+// backend failing repeatedly is skipped for a while. backups need the same
+// codec as the cache and are closed with it; values reach them compressed and
+// encrypted as configured for the cache.
+func WithFallback(backups ...Cache) Option {
+	return func(o *options) {
+		for _, backup := range backups {
+			if configured, ok := backup.(*cache); ok {
+				o.fallbacks = append(o.fallbacks, configured.Cache)
+			}
+		}
+	}
+}
+
+// WithMigrationFrom moves to the backend configured by the other options
+// from the one of old without downtime: reads are served by old while writes
+// go to both, until CutoverMigration. A compareRate fraction of the reads is
+// also made against the new backend, see MigrationStatistics. old needs the
+// same codec as the new cache and is closed with it. When the migration
+// enables WithEncryption, the values already in old need WithPlaintextReads.
+func WithMigrationFrom(old Cache, compareRate float64) Option {
+	return func(o *options) {
+		if configured, ok := old.(*cache); ok {
+			o.migrateFrom = configured.Cache
+			o.migrateCompareRate = compareRate
+		}
+	}
+}
+
+// WithTracerProvider creates OpenTelemetry spans for cache operations using tp,
+// e.g. otel.GetTracerProvider()
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(o *options) {
+		o.tracerProvider = tp
+	}
+}
+
+// WithStats enables recording of hit, miss and delete statistics
+func WithStats(enabled bool) Option {
+	return func(o *options) {
+		o.recordStatistics = enabled
+	}
+}
+
+// WithHotKeys tracks the most frequently read and missed keys for HotKeys,
+// keeping at most capacity candidates of each. A capacity of a few times the
+// number of keys asked for keeps estimates accurate.
+func WithHotKeys(capacity int) Option {
+	return func(o *options) {
+		o.hotKeys = capacity
+	}
+}
+
+// WithStatsReporter hands the statistics of the cache to reporter every
+// interval and once more on Close. Without it statistics are only available
+// through Statistics and the other accessors.
+func WithStatsReporter(reporter StatsReporter, interval time.Duration) Option {
+	return func(o *options) {
+		o.reporter = reporter
+		o.reportInterval = interval
+	}
+}
+
+// WithSharedStatistics records statistics and adds them every interval to
+// hashes named "stats:<name>:hits" and so on in the backend, using pipelined
+// HINCRBY, so SharedStatistics on any instance returns the totals of every
+// instance using the same name. The interval is at least one second.
+func WithSharedStatistics(name string, interval time.Duration) Option {
+	return func(o *options) {
+		o.recordStatistics = true
+		o.sharedStatsName = name
+		o.sharedStatsInterval = interval
+	}
+}
+
+// driver returns the configured adapter, building a Redis one if none was
+// given, and wraps it with timeouts, retries, a circuit breaker and a local
+// tier when requested
+func (o *options) driver() adapters.Cache {
+	if o.disabled {
+		return adapters.NewCache(adapters.NewNull())
+	}
+	driver := o.baseDriver()
+	if o.timeout > 0 || o.retry.MaxAttempts > 1 {
+		driver = adapters.NewRetrying(driver, o.timeout, o.retry)
+	}
+	if o.breakerThreshold > 0 {
+		var fallback adapters.CacheServer
+		if o.memoryFallback {
+			fallback = adapters.NewMemory()
+		}
+		driver = adapters.NewBreaker(driver, fallback, o.breakerThreshold, o.breakerCooldown)
+	}
+	if len(o.fallbacks) > 0 {
+		driver = adapters.NewFallbackChain(0, 0, append([]adapters.Cache{driver}, o.fallbacks...)...)
+	}
+	if o.migrateFrom != nil {
+		driver = adapters.NewMigrating(o.migrateFrom, driver, o.migrateCompareRate)
+	}
+	// Around every backend, fallbacks and the old one of a migration included,
+	// so none of them is written to in the clear
+	if o.keyring != nil {
+		driver = adapters.NewEncrypting(driver, o.keyring)
+	}
+	if o.compression != adapters.NoCompression {
+		driver = adapters.NewCompressing(driver, o.compression, o.compressThreshold)
+	}
+	if o.localTTL <= 0 {
+		return driver
+	}
+
+	tiered := adapters.NewTiered(o.localTier(), driver, o.localTTL)
+	if o.invalidation != "" {
+		bus := adapters.NewRedisInvalidationBus(o.client(), o.invalidation)
+		// Without a working bus the local tier would serve stale entries
+		// forever, so it is skipped entirely
+		if err := tiered.UseBus(bus); err != nil {
+			o.logger.Error("local tier disabled, invalidation bus unavailable", "channel", o.invalidation, "error", err)
+			return driver
+		}
+	}
+	return tiered
+}
+
+// misconfigured is the adapter of a cache whose options are invalid, failing
+// every operation with their error instead of crashing the process
+type misconfigured struct {
+	err error
+}
+
+func (m misconfigured) Get(ctx context.Context, key string) (interface{}, error) {
+	return nil, m.err
+}
+
+func (m misconfigured) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	return m.err
+}
+
+func (m misconfigured) Delete(ctx context.Context, keys ...string) error {
+	return m.err
+}
+
+func (m misconfigured) GetMany(ctx context.Context, keys ...string) (map[string]interface{}, error) {
+	return nil, m.err
+}
+
+func (m misconfigured) SetMany(ctx context.Context, values map[string]interface{}, expiration time.Duration) error {
+	return m.err
+}
+
+func (m misconfigured) Ping(ctx context.Context) error {
+	return m.err
+}
+
+func (m misconfigured) Close() error {
+	return nil
+}
+
+// localTier returns the in-process tier, a ristretto one when it is bounded
+func (o *options) localTier() adapters.CacheServer {
+	if o.localMaxBytes > 0 {
+		if local, err := adapters.NewRistretto(o.localMaxBytes); err == nil {
+			return local
+		}
+	}
+	return adapters.NewMemory()
+}
+
+// client returns the configured Redis client or creates one for the sentinels,
+// the cluster seed nodes or redisAddr
+func (o *options) client() redis.UniversalClient {
+	if o.redisClient != nil {
+		return o.redisClient
+	}
+
+	maxRetries := o.maxRetries()
+	switch {
+	case o.sentinelMaster != "":
+		o.redisClient = redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:    o.sentinelMaster,
+			SentinelAddrs: o.sentinelAddrs,
+			Username:      o.username,
+			Password:      o.password,
+			DB:            o.db,
+			TLSConfig:     o.tlsConfig,
+			MaxRetries:    maxRetries,
+			PoolSize:      o.poolSize,
+		})
+	case len(o.clusterAddrs) > 0:
+		o.redisClient = redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:      o.clusterAddrs,
+			Username:   o.username,
+			Password:   o.password,
+			TLSConfig:  o.tlsConfig,
+			MaxRetries: maxRetries,
+			PoolSize:   o.poolSize,
+		})
+	default:
+		o.redisClient = redis.NewClient(o.nodeOptions(o.redisAddr))
+	}
+	return o.redisClient
+}
+
+// maxRetries returns the MaxRetries of go-redis clients: WithRetry takes over
+// retrying, -1 disables the retries of go-redis
+func (o *options) maxRetries() int {
+	if o.retry.MaxAttempts > 1 {
+		return -1
+	}
+	return 0
+}
+
+// nodeOptions returns the options of a client for the single server at addr
+func (o *options) nodeOptions(addr string) *redis.Options {
+	return &redis.Options{
+		Addr:       addr,
+		Username:   o.username,
+		Password:   o.password,
+		DB:         o.db,
+		TLSConfig:  o.tlsConfig,
+		MaxRetries: o.maxRetries(),
+		PoolSize:   o.poolSize,
+	}
+}
+
+// sharded returns a Sharded over a client for every shard address
+func (o *options) sharded() *adapters.Sharded {
+	shards := make(map[string]adapters.CacheServer, len(o.shardAddrs))
+	for _, addr := range o.shardAddrs {
+		shards[addr] = &adapters.RedisClient{Client: redis.NewClient(o.nodeOptions(addr)), Logger: o.logger}
+	}
+	return adapters.NewSharded(0, shards)
+}
+
+// replicated returns a Replicated writing to primary and reading from a
+// client for every replica address
+func (o *options) replicated(primary adapters.CacheServer) *adapters.Replicated {
+	replicas := make([]adapters.CacheServer, len(o.replicaAddrs))
+	for i, addr := range o.replicaAddrs {
+		replicas[i] = &adapters.RedisClient{Client: redis.NewClient(o.nodeOptions(addr)), Logger: o.logger}
+	}
+	return adapters.NewReplicated(primary, replicas, o.replicaPolicy, o.replicaMaxLag, 0, o.prefix)
+}
+
+func (o *options) baseDriver() adapters.Cache {
+	if o.adapter != nil {
+		return o.adapter
+	}
+
+	server := o.server
+	switch {
+	case server != nil:
+	case len(o.shardAddrs) > 0:
+		server = o.sharded()
+	default:
+		server = &adapters.RedisClient{Client: o.client(), Logger: o.logger}
+		if len(o.replicaAddrs) > 0 {
+			server = o.replicated(server)
+		}
+	}
+	if o.prefix != "" || o.maxKeyLength > 0 {
+		prefixed := adapters.NewPrefixed(server, o.prefix)
+		prefixed.MaxKeyLength = o.maxKeyLength
+		server = prefixed
+	}
+	return adapters.NewCache(server)
+}
+
+// memory creates the in-process server of NewMemoryCache, bounded as given
+// with WithMemoryLimits
+func (o *options) memory() *adapters.Memory {
+	memoryOpts := []adapters.MemoryOption{adapters.WithEvictionPolicy(o.evictionPolicy)}
+	if o.memoryMaxEntries > 0 {
+		memoryOpts = append(memoryOpts, adapters.WithMaxEntries(o.memoryMaxEntries))
+	}
+	if o.memoryMaxBytes > 0 {
+		memoryOpts = append(memoryOpts, adapters.WithMaxBytes(o.memoryMaxBytes))
+	}
+	if o.onEvict != nil {
+		// Evicted keys carry the prefix of the server
+		onEvict, prefix := o.onEvict, o.prefix
+		memoryOpts = append(memoryOpts, adapters.WithOnEvict(func(key string) {
+			if key, ok := strings.CutPrefix(key, prefix); ok {
+				onEvict(key)
+			}
+		}))
+	}
+	return adapters.NewMemory(memoryOpts...)
+}
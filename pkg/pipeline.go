@@ -0,0 +1,320 @@
+package pkg
+
+import (
+	"cacher/internal/adapters"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	"time"
+)
+
+// Pipeline queues commands to run against the backend in one round trip with
+// Exec. Every command returns a result that holds its outcome once Exec
+// returned. Loaders, tags and the local tier are not consulted, written keys
+// are dropped from the local tier. A Pipeline is not safe for concurrent use.
+type Pipeline struct {
+	c   *cache
+	ops []pipelineOp
+}
+
+type pipelineOp struct {
+	op     *adapters.PipelineOp
+	get    *GetResult
+	status *StatusResult
+	count  *IntResult
+}
+
+// GetResult is the outcome of Pipeline.Get
+type GetResult struct {
+	value interface{}
+	err   error
+}
+
+// Result returns the value, ErrCacheMiss if the key does not exist and
+// ErrNotFound if a loader reported that the value does not exist
+func (r *GetResult) Result() (interface{}, error) {
+	return r.value, r.err
+}
+
+// StatusResult is the outcome of Pipeline.Set and Pipeline.Delete
+type StatusResult struct {
+	err error
+}
+
+func (r *StatusResult) Err() error {
+	return r.err
+}
+
+// IntResult is the outcome of Pipeline.Increment
+type IntResult struct {
+	value int64
+	err   error
+}
+
+// Result returns the new value of the counter
+func (r *IntResult) Result() (int64, error) {
+	return r.value, r.err
+}
+
+// errNotExecuted is the outcome of commands until Exec ran them
+var errNotExecuted = errors.New("pipeline not executed")
+
+// Pipeline returns an empty pipeline over c
+func (c *cache) Pipeline() *Pipeline {
+	return &Pipeline{c: c}
+}
+
+// Get queues reading key
+func (p *Pipeline) Get(key string) *GetResult {
+	result := &GetResult{err: errNotExecuted}
+	p.ops = append(p.ops, pipelineOp{
+		op:  &adapters.PipelineOp{Command: adapters.PipelineGet, Keys: []string{key}},
+		get: result,
+	})
+	return result
+}
+
+// Set queues storing value with the expiration given with WithDefaultTTL
+func (p *Pipeline) Set(key string, value interface{}) *StatusResult {
+	return p.SetWithTTL(key, value, p.c.defaultTTL)
+}
+
+// SetWithTTL queues storing value so it expires after ttl, never for 0
+func (p *Pipeline) SetWithTTL(key string, value interface{}, ttl time.Duration) *StatusResult {
+	result := &StatusResult{err: errNotExecuted}
+	p.ops = append(p.ops, pipelineOp{
+		op:     &adapters.PipelineOp{Command: adapters.PipelineSet, Keys: []string{key}, Value: value, Expiration: ttl},
+		status: result,
+	})
+	return result
+}
+
+// Delete queues removing keys
+func (p *Pipeline) Delete(keys ...string) *StatusResult {
+	if len(keys) == 0 {
+		return &StatusResult{}
+	}
+	result := &StatusResult{err: errNotExecuted}
+	p.ops = append(p.ops, pipelineOp{
+		op:     &adapters.PipelineOp{Command: adapters.PipelineDelete, Keys: keys},
+		status: result,
+	})
+	return result
+}
+
+// Increment queues adding delta to the integer stored at key. A missing key
+// starts at 0 and never expires.
+func (p *Pipeline) Increment(key string, delta int64) *IntResult {
+	result := &IntResult{err: errNotExecuted}
+	p.ops = append(p.ops, pipelineOp{
+		op:    &adapters.PipelineOp{Command: adapters.PipelineIncr, Keys: []string{key}, Delta: delta},
+		count: result,
+	})
+	return result
+}
+
+// Len returns the number of queued commands
+func (p *Pipeline) Len() int {
+	return len(p.ops)
+}
+
+// Exec runs the queued commands in order and empties the pipeline. It returns
+// the error of the first command that failed, misses excluded; the outcome of
+// each command is in its result. Backends that cannot batch commands, like
+// Memcached, run them one by one.
+func (p *Pipeline) Exec(ctx context.Context) (err error) {
+	ops := p.ops
+	p.ops = nil
+	if len(ops) == 0 {
+		return nil
+	}
+
+	c := p.c
+	start := time.Now()
+	ctx, span := c.startSpan(ctx, "Pipeline", attribute.Int("cache.count", len(ops)))
+	defer func() { endSpan(span, start, err) }()
+
+	server, err := c.server()
+	if err != nil {
+		return p.execEach(ctx, ops)
+	}
+
+	queued := c.preparePipelined(ctx, ops)
+	if err := server.Pipeline(ctx, queued); err != nil {
+		for _, op := range queued {
+			if op.Err == nil {
+				op.Err = err
+			}
+		}
+	}
+	return c.finishPipelined(ctx, ops, time.Since(start))
+}
+
+// preparePipelined prepares ops and returns those to send to the backend
+func (c *cache) preparePipelined(ctx context.Context, ops []pipelineOp) []*adapters.PipelineOp {
+	queued := make([]*adapters.PipelineOp, 0, len(ops))
+	for _, op := range ops {
+		if err := c.preparePipelinedOp(ctx, op.op); err != nil {
+			op.op.Err = err
+			continue
+		}
+		queued = append(queued, op.op)
+	}
+	return queued
+}
+
+// finishPipelined records ops once the backend ran them, drops written keys
+// from the local tier and returns the first error
+func (c *cache) finishPipelined(ctx context.Context, ops []pipelineOp, latency time.Duration) error {
+	var written []string
+	for _, op := range ops {
+		c.recordPipelined(ctx, op, latency)
+		if op.op.Command != adapters.PipelineGet && op.op.Err == nil {
+			written = append(written, op.op.Keys...)
+		}
+	}
+	if len(written) > 0 {
+		if err := c.evictLocal(ctx, written...); err != nil {
+			return err
+		}
+	}
+	return firstError(ops)
+}
+
+// preparePipelinedOp checks a write like SetWithTTL and encodes its value
+// like the wrappers of the cache do. It returns errSkipped for values not to
+// write.
+func (c *cache) preparePipelinedOp(ctx context.Context, op *adapters.PipelineOp) error {
+	if op.Command == adapters.PipelineIncr && c.keyring != nil {
+		return ErrEncryptionUnsupported
+	}
+	if op.Command != adapters.PipelineSet {
+		return nil
+	}
+	if op.Expiration == NoDefaultTTL {
+		return ErrNoDefaultTTL
+	}
+	if skip, err := c.oversized(ctx, op.Keys[0], op.Value); skip {
+		if err == nil {
+			err = errSkipped
+		}
+		return err
+	}
+	op.Expiration = c.jitter(op.Expiration)
+	value, err := c.encode(op.Keys[0], op.Value)
+	if err != nil {
+		return err
+	}
+	op.Value = value
+	return nil
+}
+
+// errSkipped marks a value that was not written because it is too large
+// under LogOversized or DropOversized, which is not an error of the write
+var errSkipped = errors.New("value skipped")
+
+// recordPipelined fills in the result of op and records it like the matching
+// method of the cache
+func (c *cache) recordPipelined(ctx context.Context, op pipelineOp, latency time.Duration) {
+	result, err := op.op.Result, op.op.Err
+	if errors.Is(err, errSkipped) {
+		op.op.Err, err = nil, nil
+	}
+	switch op.op.Command {
+	case adapters.PipelineGet:
+		key := op.op.Keys[0]
+		switch {
+		case errors.Is(err, redis.Nil):
+			c.miss(key)
+			c.fire(ctx, hookMiss, "get", key, latency, nil)
+			op.op.Err = nil
+			op.get.value, op.get.err = nil, ErrCacheMiss
+			return
+		case err != nil:
+			c.failed(key)
+			c.fire(ctx, hookError, "get", key, latency, err)
+			op.op.Err = fmt.Errorf("cache backend: %w", err)
+			op.get.value, op.get.err = nil, op.op.Err
+			return
+		}
+		c.hit(key)
+		c.fire(ctx, hookHit, "get", key, latency, nil)
+		stored, _ := result.(string)
+		value, err := c.decode(key, stored)
+		switch {
+		case err != nil:
+			op.op.Err = err
+			op.get.value, op.get.err = nil, err
+		case value == tombstone:
+			op.get.value, op.get.err = nil, ErrNotFound
+		default:
+			op.get.value, op.get.err = value, nil
+		}
+	case adapters.PipelineSet:
+		if err != nil {
+			c.fire(ctx, hookError, "set", op.op.Keys[0], latency, err)
+		} else {
+			c.fire(ctx, hookSet, "set", op.op.Keys[0], latency, nil)
+		}
+		op.status.err = err
+	case adapters.PipelineDelete:
+		for _, key := range op.op.Keys {
+			if err != nil {
+				c.fire(ctx, hookError, "delete", key, latency, err)
+			} else {
+				c.deleted(key)
+				c.fire(ctx, hookDelete, "delete", key, latency, nil)
+			}
+		}
+		op.status.err = err
+	case adapters.PipelineIncr:
+		key := op.op.Keys[0]
+		if err != nil {
+			c.failed(key)
+			c.fire(ctx, hookError, "increment", key, latency, err)
+			op.op.Err = fmt.Errorf("cache backend: %w", err)
+			op.count.err = op.op.Err
+			return
+		}
+		c.fire(ctx, hookSet, "increment", key, latency, nil)
+		op.count.value, _ = result.(int64)
+		op.count.err = nil
+	}
+}
+
+// execEach runs ops through the methods of the cache, for backends without a
+// CacheServer
+func (p *Pipeline) execEach(ctx context.Context, ops []pipelineOp) error {
+	c := p.c
+	for _, op := range ops {
+		key := op.op.Keys[0]
+		switch op.op.Command {
+		case adapters.PipelineGet:
+			op.get.value, op.get.err = c.Get(ctx, key)
+			if op.get.err != nil && !errors.Is(op.get.err, ErrCacheMiss) && !errors.Is(op.get.err, ErrNotFound) {
+				op.op.Err = op.get.err
+			}
+		case adapters.PipelineSet:
+			op.status.err = c.SetWithTTL(ctx, key, op.op.Value, op.op.Expiration)
+			op.op.Err = op.status.err
+		case adapters.PipelineDelete:
+			op.status.err = c.DeleteMany(ctx, op.op.Keys...)
+			op.op.Err = op.status.err
+		case adapters.PipelineIncr:
+			op.count.value, op.count.err = c.Increment(ctx, key, op.op.Delta)
+			op.op.Err = op.count.err
+		}
+	}
+	return firstError(ops)
+}
+
+func firstError(ops []pipelineOp) error {
+	for _, op := range ops {
+		if op.op.Err != nil {
+			return op.op.Err
+		}
+	}
+	return nil
+}
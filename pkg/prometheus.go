@@ -0,0 +1,136 @@
+package pkg
+
+import (
+	"context"
+	"github.com/prometheus/client_golang/prometheus"
+	"strings"
+)
+
+type prometheusReporter struct {
+	keys           *prometheus.GaugeVec
+	hitRatio       *prometheus.GaugeVec
+	keyLatency     *prometheus.GaugeVec
+	latency        prometheus.Gauge
+	tiers          *prometheus.GaugeVec
+	circuitBreaker *prometheus.GaugeVec
+	fallback       *prometheus.GaugeVec
+	migration      *prometheus.GaugeVec
+	eviction       *prometheus.GaugeVec
+}
+
+// NewPrometheusReporter exports statistics as gauges registered with
+// registerer under namespace. Per key gauges are labeled with the key, so
+// only use it when the number of distinct keys is small.
+func NewPrometheusReporter(registerer prometheus.Registerer, namespace string) (StatsReporter, error) {
+	r := &prometheusReporter{
+		keys: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "key_operations",
+			Help:      "Hits, misses, deletes and errors per key.",
+		}, []string{"key", "result"}),
+		hitRatio: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "key_hit_ratio_percent",
+			Help:      "Hits as a percentage of lookups per key.",
+		}, []string{"key"}),
+		keyLatency: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "key_latency_microseconds",
+			Help:      "Latency percentiles of hits, misses and loader calls per key.",
+		}, []string{"key", "operation", "quantile"}),
+		latency: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "average_hit_latency_microseconds",
+			Help:      "Average latency of cache hits.",
+		}),
+		tiers: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "tier_operations",
+			Help:      "Hits and misses per cache tier.",
+		}, []string{"tier", "result"}),
+		circuitBreaker: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "circuit_breaker",
+			Help:      "State and counters of the circuit breaker.",
+		}, []string{"stat"}),
+		fallback: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "fallback_backend",
+			Help:      "Reads and circuit breaker state per backend of a fallback chain.",
+		}, []string{"backend", "stat"}),
+		migration: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "migration",
+			Help:      "Compared reads and errors of a backend migration.",
+		}, []string{"stat"}),
+		eviction: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "memory_eviction",
+			Help:      "Evicted and held keys and bytes of a bounded in-process memory.",
+		}, []string{"stat"}),
+	}
+
+	for _, collector := range []prometheus.Collector{r.keys, r.hitRatio, r.keyLatency, r.latency, r.tiers, r.circuitBreaker, r.fallback, r.migration, r.eviction} {
+		if err := registerer.Register(collector); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+func (r *prometheusReporter) Report(ctx context.Context, snapshot StatsSnapshot) {
+	for key, counts := range snapshot.Keys {
+		for name, count := range counts {
+			switch operation, quantile, isLatency := latencyStat(name); {
+			case name == "hit_ratio_percent":
+				r.hitRatio.WithLabelValues(key).Set(float64(count))
+			case isLatency:
+				r.keyLatency.WithLabelValues(key, operation, quantile).Set(float64(count))
+			default:
+				r.keys.WithLabelValues(key, name).Set(float64(count))
+			}
+		}
+	}
+	r.latency.Set(snapshot.AverageHitLatency)
+	for tier, counts := range snapshot.Tiers {
+		for result, count := range counts {
+			r.tiers.WithLabelValues(tier, result).Set(float64(count))
+		}
+	}
+	for stat, value := range snapshot.CircuitBreaker {
+		r.circuitBreaker.WithLabelValues(stat).Set(float64(value))
+	}
+	for backend, stats := range snapshot.Fallback {
+		for stat, value := range stats {
+			r.fallback.WithLabelValues(backend, stat).Set(float64(value))
+		}
+	}
+	for stat, value := range snapshot.Migration {
+		r.migration.WithLabelValues(stat).Set(float64(value))
+	}
+	for stat, value := range snapshot.Eviction {
+		r.eviction.WithLabelValues(stat).Set(float64(value))
+	}
+}
+
+// latencyStat splits a percentile statistic such as "hit_p99_us" into the
+// operation and the quantile label, "hit" and "0.99"
+func latencyStat(name string) (string, string, bool) {
+	name, ok := strings.CutSuffix(name, "_us")
+	if !ok {
+		return "", "", false
+	}
+	operation, percentile, ok := strings.Cut(name, "_p")
+	if !ok {
+		return "", "", false
+	}
+	switch percentile {
+	case "50":
+		return operation, "0.5", true
+	case "95":
+		return operation, "0.95", true
+	case "99":
+		return operation, "0.99", true
+	}
+	return "", "", false
+}
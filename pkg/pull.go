@@ -0,0 +1,130 @@
+package pkg
+
+import (
+	"cacher/internal/adapters"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+// Pull retrieves the value stored at key and deletes it in one atomic step,
+// so only one caller ever receives it. It returns ErrCacheMiss when the key
+// does not exist.
+func (c *cache) Pull(ctx context.Context, key string) (interface{}, error) {
+	server, err := c.server()
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	value, err := server.GetDel(ctx, key)
+	if err := c.lookedUp(ctx, "get", key, start, err); err != nil {
+		return nil, err
+	}
+	c.deleted(key)
+	c.fire(ctx, hookDelete, "delete", key, time.Since(start), nil)
+
+	if err := c.evictLocal(ctx, key); err != nil {
+		return nil, err
+	}
+	if value, err = c.decode(key, value); err != nil {
+		return nil, err
+	}
+	if value == tombstone {
+		return nil, ErrNotFound
+	}
+	return value, nil
+}
+
+// GetSet stores value at key with the default expiration and returns the
+// value it replaced in one atomic step. It returns ErrCacheMiss, after
+// storing value, when the key did not exist, and ErrNoDefaultTTL when no
+// default expiration was configured. Oversized values are always rejected
+// with ErrValueTooLarge, as GetSet cannot skip its write.
+func (c *cache) GetSet(ctx context.Context, key string, value interface{}) (interface{}, error) {
+	if c.defaultTTL == NoDefaultTTL {
+		return nil, ErrNoDefaultTTL
+	}
+	if skip, _ := c.oversized(ctx, key, value); skip {
+		return nil, ErrValueTooLarge
+	}
+	server, err := c.server()
+	if err != nil {
+		return nil, err
+	}
+
+	if value, err = c.encode(key, value); err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	previous, err := server.GetSet(ctx, key, value, c.jitter(c.defaultTTL))
+	if lookupErr := c.lookedUp(ctx, "set", key, start, err); lookupErr != nil && !errors.Is(err, redis.Nil) {
+		return nil, lookupErr
+	}
+	c.fire(ctx, hookSet, "set", key, time.Since(start), nil)
+
+	if err := c.evictLocal(ctx, key); err != nil {
+		return nil, err
+	}
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrCacheMiss
+	}
+	if previous, err = c.decode(key, previous); err != nil {
+		return nil, err
+	}
+	if previous == tombstone {
+		return nil, ErrNotFound
+	}
+	return previous, nil
+}
+
+// encode compresses and encrypts value like the driver does, for writes that
+// go to the backend directly
+func (c *cache) encode(key string, value interface{}) (interface{}, error) {
+	value, err := adapters.Compress(value, c.compression, c.compressAbove)
+	if err != nil || c.keyring == nil {
+		return value, err
+	}
+	return c.keyring.Encrypt(key, value)
+}
+
+// decode reverses encode for values read from the backend directly
+func (c *cache) decode(key, value string) (string, error) {
+	if c.keyring != nil {
+		var err error
+		if value, err = c.keyring.Decrypt(key, value); err != nil {
+			return "", err
+		}
+	}
+	return adapters.Decompress(value)
+}
+
+// lookedUp records the outcome of a backend read of key, returning
+// ErrCacheMiss for a missing key and a wrapped error for a failed read
+func (c *cache) lookedUp(ctx context.Context, operation, key string, start time.Time, err error) error {
+	switch {
+	case errors.Is(err, redis.Nil):
+		c.miss(key)
+		c.fire(ctx, hookMiss, operation, key, time.Since(start), nil)
+		return ErrCacheMiss
+	case err != nil:
+		c.failed(key)
+		c.fire(ctx, hookError, operation, key, time.Since(start), err)
+		return fmt.Errorf("cache backend: %w", err)
+	}
+	c.hit(key)
+	c.fire(ctx, hookHit, operation, key, time.Since(start), nil)
+	return nil
+}
+
+// evictLocal drops keys that were changed on the backend directly from the
+// local tier, if any
+func (c *cache) evictLocal(ctx context.Context, keys ...string) error {
+	if tiered, ok := c.Cache.(*adapters.Tiered); ok {
+		return tiered.Evict(ctx, keys...)
+	}
+	return nil
+}
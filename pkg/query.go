@@ -0,0 +1,200 @@
+package pkg
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// QueryDB runs queries, like *sql.DB, *sql.Tx, *sql.Conn and their sqlx
+// counterparts
+type QueryDB interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// selectDB is implemented by sqlx.DB and sqlx.Tx
+type selectDB interface {
+	SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+}
+
+// ExecHook returns the tables a write query changes beyond the one it
+// targets, e.g. the ones a trigger writes, or the tables of a statement that
+// is not detected
+type ExecHook func(query string, args []interface{}) []string
+
+// QueryCache is a second-level cache of the results of read queries, keyed by
+// their normalized SQL and a hash of their arguments. Cached results are
+// tagged with the tables the query reads, found in its FROM and JOIN clauses,
+// and Exec invalidates the tables a write targets, so results stay current as
+// long as every write goes through Exec.
+type QueryCache struct {
+	c     Cache
+	db    QueryDB
+	ttl   time.Duration
+	mutex sync.RWMutex
+	hooks []ExecHook
+}
+
+var (
+	// readTables finds the tables after FROM and JOIN
+	readTables = regexp.MustCompile("(?i)\\b(?:from|join)\\s+([`\"\\[]?[\\w.]+[`\"\\]]?)")
+	// writeTable finds the table of INSERT, UPDATE, DELETE, REPLACE, MERGE and
+	// TRUNCATE
+	writeTable = regexp.MustCompile("(?i)^\\s*(?:insert\\s+(?:or\\s+\\w+\\s+)?into|replace\\s+into|update(?:\\s+or\\s+\\w+)?|delete\\s+from|merge\\s+into|truncate(?:\\s+table)?)\\s+([`\"\\[]?[\\w.]+[`\"\\]]?)")
+	whitespace = regexp.MustCompile(`\s+`)
+)
+
+// NewQueryCache caches the results of read queries run on db in c for ttl
+func NewQueryCache(c Cache, db QueryDB, ttl time.Duration) *QueryCache {
+	return &QueryCache{c: c, db: db, ttl: ttl}
+}
+
+// Query returns the rows of a read query, each made by scan, from the cache
+// or else from the database. Rows are stored with the codec configured by
+// WithCodec, so T must round trip through it.
+func Query[T any](ctx context.Context, q *QueryCache, scan func(rows *sql.Rows) (T, error), query string, args ...interface{}) ([]T, error) {
+	results := NewTypedCache[[]T](q.c)
+	key := queryKey(query, args)
+	if cached, err := results.Get(ctx, key); err == nil {
+		return cached, nil
+	}
+
+	rows, err := q.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var scanned []T
+	for rows.Next() {
+		row, err := scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		scanned = append(scanned, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	q.store(ctx, key, query, scanned)
+	return scanned, nil
+}
+
+// Select fills dest, a pointer to a slice, with the rows of a read query like
+// sqlx SelectContext, from the cache or else from the database, which must be
+// an sqlx.DB or sqlx.Tx
+func (q *QueryCache) Select(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	db, ok := q.db.(selectDB)
+	if !ok {
+		return fmt.Errorf("select needs an sqlx database, got %T: %w", q.db, ErrUnsupported)
+	}
+	key := queryKey(query, args)
+	if data, err := q.c.Get(ctx, key); err == nil {
+		var raw []byte
+		switch v := data.(type) {
+		case string:
+			raw = []byte(v)
+		case []byte:
+			raw = v
+		}
+		if raw != nil && NewTypedCache[interface{}](q.c).codec.Unmarshal(raw, dest) == nil {
+			return nil
+		}
+	}
+
+	if err := db.SelectContext(ctx, dest, query, args...); err != nil {
+		return err
+	}
+	q.store(ctx, key, query, dest)
+	return nil
+}
+
+// Exec runs a write query and invalidates the cached results of the table it
+// targets and of the tables returned by the hooks. Results are invalidated
+// even when the query fails, as it may have changed rows before it did.
+func (q *QueryCache) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	result, err := q.db.ExecContext(ctx, query, args...)
+	var tables []string
+	if match := writeTable.FindStringSubmatch(query); match != nil {
+		tables = append(tables, match[1])
+	}
+	q.mutex.RLock()
+	for _, hook := range q.hooks {
+		tables = append(tables, hook(query, args)...)
+	}
+	q.mutex.RUnlock()
+	if invalidateErr := q.Invalidate(ctx, tables...); err == nil && invalidateErr != nil {
+		return result, invalidateErr
+	}
+	return result, err
+}
+
+// OnExec registers a hook naming more tables a write query changes
+func (q *QueryCache) OnExec(hook ExecHook) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	q.hooks = append(q.hooks, hook)
+}
+
+// Invalidate drops the cached results of every query reading the tables, for
+// writes that do not go through Exec
+func (q *QueryCache) Invalidate(ctx context.Context, tables ...string) error {
+	for _, table := range tables {
+		if err := q.c.InvalidateTag(ctx, tableTag(table)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// store caches the results of query, tagged with its tables, and logs errors
+// as the database already answered
+func (q *QueryCache) store(ctx context.Context, key, query string, results interface{}) {
+	tags := queryTags(query)
+	if len(tags) == 0 {
+		// A query reading no detectable table could never be invalidated
+		return
+	}
+	err := NewTypedCache[interface{}](q.c).SetWithTTL(ctx, key, results, q.ttl, WithTags(tags...))
+	if err != nil {
+		if configured, ok := q.c.(*cache); ok {
+			configured.logger.Warn("cache backend error", "key", key, "error", err)
+		}
+	}
+}
+
+// queryKey returns the key of the results of query with args, ignoring
+// differences in whitespace
+func queryKey(query string, args []interface{}) string {
+	hash := sha256.New()
+	hash.Write([]byte(normalizeQuery(query)))
+	for _, arg := range args {
+		_, _ = fmt.Fprintf(hash, "\x00%T:%v", arg, arg)
+	}
+	return "query:" + hex.EncodeToString(hash.Sum(nil))
+}
+
+func normalizeQuery(query string) string {
+	return strings.TrimRight(strings.TrimSpace(whitespace.ReplaceAllString(query, " ")), "; ")
+}
+
+// queryTags returns the tags of the tables query reads
+func queryTags(query string) []string {
+	var tags []string
+	for _, match := range readTables.FindAllStringSubmatch(query, -1) {
+		tags = append(tags, tableTag(match[1]))
+	}
+	return tags
+}
+
+// tableTag returns the tag of the results reading table, ignoring quoting
+// and case
+func tableTag(table string) string {
+	return "sql:" + strings.ToLower(strings.Trim(table, "`\"[]"))
+}
@@ -0,0 +1,198 @@
+package pkg
+
+import (
+	"cacher/codec"
+	"cacher/internal/adapters"
+	"context"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"strconv"
+	"time"
+)
+
+var (
+	// ErrQueueEmpty is returned by Dequeue when no item arrived in time
+	ErrQueueEmpty = errors.New("queue is empty")
+	// ErrNotLeased is returned by Ack for an item that was requeued after its
+	// lease expired, so it may be handled twice
+	ErrNotLeased = errors.New("queue item is no longer leased")
+)
+
+// DefaultQueueLease is how long a dequeued item may stay unacknowledged
+// before Requeue puts it back, unless NewReliableQueue is given another
+const DefaultQueueLease = time.Minute
+
+// ReliableQueue is a first-in first-out queue of values of type T in lists of
+// the backend of a cache. Dequeue moves an item to a processing list in one
+// step instead of removing it, so an item whose worker dies before Ack is not
+// lost: Requeue puts it back once its lease expires.
+type ReliableQueue[T any] struct {
+	server     adapters.CacheServer
+	logger     Logger
+	key        string
+	processing string
+	leases     string
+	lease      time.Duration
+}
+
+// QueueItem is a dequeued value, to be passed to Ack once handled
+type QueueItem[T any] struct {
+	ID    string
+	Value T
+	raw   string
+}
+
+type queueEnvelope[T any] struct {
+	ID    string `json:"id"`
+	Value T      `json:"value"`
+}
+
+// NewReliableQueue creates the queue called name over the backend of c, whose
+// items are leased for lease, DefaultQueueLease if it is not positive
+func NewReliableQueue[T any](c Cache, name string, lease time.Duration) (*ReliableQueue[T], error) {
+	configured, ok := c.(*cache)
+	if !ok {
+		return nil, ErrUnsupported
+	}
+	server, err := configured.unencryptedServer()
+	if err != nil {
+		return nil, err
+	}
+	if lease <= 0 {
+		lease = DefaultQueueLease
+	}
+	key := "queue:" + name
+	return &ReliableQueue[T]{
+		server:     server,
+		logger:     configured.logger,
+		key:        key,
+		processing: key + ":processing",
+		leases:     key + ":leases",
+		lease:      lease,
+	}, nil
+}
+
+// Enqueue adds values to the back of the queue
+func (q *ReliableQueue[T]) Enqueue(ctx context.Context, values ...T) error {
+	if len(values) == 0 {
+		return nil
+	}
+	items := make([]interface{}, len(values))
+	for i, value := range values {
+		data, err := codec.JSON.Marshal(queueEnvelope[T]{ID: randomToken(), Value: value})
+		if err != nil {
+			return err
+		}
+		items[i] = string(data)
+	}
+	return backendError(q.server.Push(ctx, q.key, items...))
+}
+
+// Dequeue takes the item at the front of the queue, waiting up to timeout for
+// one, or not at all when it is not positive. It returns ErrQueueEmpty when
+// there is none. The item is leased to the caller until Ack.
+func (q *ReliableQueue[T]) Dequeue(ctx context.Context, timeout time.Duration) (*QueueItem[T], error) {
+	var raw string
+	var err error
+	if timeout > 0 {
+		raw, err = q.server.BLMove(ctx, q.key, q.processing, "RIGHT", "LEFT", timeout)
+	} else {
+		raw, err = q.server.LMove(ctx, q.key, q.processing, "RIGHT", "LEFT")
+	}
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrQueueEmpty
+	}
+	if err != nil {
+		return nil, backendError(err)
+	}
+
+	var envelope queueEnvelope[T]
+	if err := codec.JSON.Unmarshal([]byte(raw), &envelope); err != nil {
+		// An item that cannot be decoded would be requeued forever
+		_, _ = q.server.LRem(ctx, q.processing, 1, raw)
+		return nil, &decodeError{err}
+	}
+	deadline := strconv.FormatInt(time.Now().Add(q.lease).UnixMilli(), 10)
+	if _, err := q.server.HSet(ctx, q.leases, map[string]interface{}{envelope.ID: deadline}); err != nil {
+		return nil, backendError(err)
+	}
+	return &QueueItem[T]{ID: envelope.ID, Value: envelope.Value, raw: raw}, nil
+}
+
+// Ack removes a handled item from the queue for good. It returns ErrNotLeased
+// if the item was requeued in the meantime.
+func (q *ReliableQueue[T]) Ack(ctx context.Context, item *QueueItem[T]) error {
+	removed, err := q.server.LRem(ctx, q.processing, 1, item.raw)
+	if err != nil {
+		return backendError(err)
+	}
+	if _, err := q.server.HDel(ctx, q.leases, item.ID); err != nil {
+		return backendError(err)
+	}
+	if removed == 0 {
+		return ErrNotLeased
+	}
+	return nil
+}
+
+// Requeue puts the items whose lease expired back at the end of the queue and
+// returns how many there were. It should run periodically, from any process.
+func (q *ReliableQueue[T]) Requeue(ctx context.Context) (int, error) {
+	processing, err := q.server.List(ctx, q.processing)
+	if err != nil {
+		return 0, backendError(err)
+	}
+	if len(processing) == 0 {
+		return 0, nil
+	}
+	leases, err := q.server.HGetAll(ctx, q.leases)
+	if err != nil {
+		return 0, backendError(err)
+	}
+
+	now := time.Now()
+	requeued := 0
+	for _, raw := range processing {
+		var envelope struct {
+			ID string `json:"id"`
+		}
+		_ = codec.JSON.Unmarshal([]byte(raw), &envelope)
+		deadline, leased := leases[envelope.ID]
+		if !leased {
+			// The worker died between taking the item and leasing it, or is
+			// about to lease it: start the lease now
+			lease := strconv.FormatInt(now.Add(q.lease).UnixMilli(), 10)
+			if _, err := q.server.HSet(ctx, q.leases, map[string]interface{}{envelope.ID: lease}); err != nil {
+				return requeued, backendError(err)
+			}
+			continue
+		}
+		if ms, _ := strconv.ParseInt(deadline, 10, 64); now.UnixMilli() < ms {
+			continue
+		}
+
+		// Only the caller that removes the item puts it back, so an Ack or
+		// another Requeue racing with this one cannot duplicate it
+		removed, err := q.server.LRem(ctx, q.processing, 1, raw)
+		if err != nil {
+			return requeued, backendError(err)
+		}
+		if removed == 0 {
+			continue
+		}
+		if _, err := q.server.HDel(ctx, q.leases, envelope.ID); err != nil {
+			return requeued, backendError(err)
+		}
+		if err := q.server.Push(ctx, q.key, raw); err != nil {
+			return requeued, backendError(err)
+		}
+		requeued++
+	}
+	return requeued, nil
+}
+
+// Len returns the number of items waiting in the queue, leased ones excluded
+func (q *ReliableQueue[T]) Len(ctx context.Context) (int64, error) {
+	n, err := q.server.LLen(ctx, q.key)
+	return n, backendError(err)
+}
@@ -0,0 +1,216 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// refresher recomputes hot entries registered with WrapWithRefresh shortly
+// before they expire, so callers never wait for the loader
+type refresher struct {
+	cache   *cache
+	window  time.Duration
+	minRate float64
+	workers int
+
+	mutex   sync.Mutex
+	entries map[string]*refreshEntry
+	queue   chan string
+	stop    chan struct{}
+	running sync.WaitGroup
+	start   sync.Once
+	closed  bool
+}
+
+type refreshEntry struct {
+	loader   func() interface{}
+	ttl      time.Duration
+	storedAt time.Time
+	hits     uint64
+	queued   bool
+}
+
+func newRefresher(c *cache, o *options) *refresher {
+	return &refresher{
+		cache:   c,
+		window:  o.refreshWindow,
+		minRate: o.refreshMinRate,
+		workers: max(o.refreshWorkers, 1),
+		entries: make(map[string]*refreshEntry),
+		queue:   make(chan string, 256),
+		stop:    make(chan struct{}),
+	}
+}
+
+// windowOf returns how long before expiry an entry with ttl is refreshed,
+// defaulting to a tenth of its ttl
+func (r *refresher) windowOf(ttl time.Duration) time.Duration {
+	if r.window > 0 && r.window < ttl {
+		return r.window
+	}
+	return ttl / 10
+}
+
+// register records the loader of key. stored tells whether the value was
+// just written, which restarts the hit rate measurement.
+func (r *refresher) register(key string, ttl time.Duration, loader func() interface{}, stored bool) {
+	r.start.Do(r.run)
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	entry, ok := r.entries[key]
+	if !ok {
+		entry = &refreshEntry{storedAt: time.Now()}
+		r.entries[key] = entry
+	}
+	entry.loader = loader
+	entry.ttl = ttl
+	if stored {
+		entry.storedAt = time.Now()
+		entry.hits = 0
+	} else {
+		entry.hits++
+	}
+}
+
+func (r *refresher) run() {
+	r.running.Add(r.workers + 1)
+	go r.scheduler()
+	for range r.workers {
+		go r.worker()
+	}
+}
+
+// scheduler queues hot entries that are about to expire and forgets cold ones
+// once they have expired
+func (r *refresher) scheduler() {
+	defer r.running.Done()
+
+	interval := 100 * time.Millisecond
+	if r.window > 0 {
+		interval = max(r.window/2, 10*time.Millisecond)
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+		}
+
+		r.mutex.Lock()
+		now := time.Now()
+		for key, entry := range r.entries {
+			age := now.Sub(entry.storedAt)
+			if age >= entry.ttl {
+				delete(r.entries, key)
+				continue
+			}
+			if entry.queued || entry.ttl-age > r.windowOf(entry.ttl) {
+				continue
+			}
+			if float64(entry.hits)/age.Seconds() < r.minRate {
+				continue
+			}
+			select {
+			case r.queue <- key:
+				entry.queued = true
+			default:
+			}
+		}
+		r.mutex.Unlock()
+	}
+}
+
+func (r *refresher) worker() {
+	defer r.running.Done()
+	for {
+		select {
+		case <-r.stop:
+			return
+		case key := <-r.queue:
+			r.refresh(key)
+		}
+	}
+}
+
+func (r *refresher) refresh(key string) {
+	r.mutex.Lock()
+	entry, ok := r.entries[key]
+	if !ok {
+		r.mutex.Unlock()
+		return
+	}
+	loader, ttl := entry.loader, entry.ttl
+	r.mutex.Unlock()
+
+	_, err, _ := r.cache.loads.Do(key, func() (interface{}, error) {
+		start := time.Now()
+		result := loader()
+		r.cache.loaded(key, time.Since(start))
+		return result, r.cache.store(context.Background(), key, result, ttl)
+	})
+
+	r.mutex.Lock()
+	entry.queued = false
+	// A failed refresh leaves the entry to expire, the next read reloads it
+	if err == nil {
+		entry.storedAt = time.Now()
+		entry.hits = 0
+	}
+	r.mutex.Unlock()
+}
+
+// close stops the scheduler and the workers
+func (r *refresher) close() {
+	r.mutex.Lock()
+	if r.closed {
+		r.mutex.Unlock()
+		return
+	}
+	r.closed = true
+	r.mutex.Unlock()
+
+	// Keep later registrations from starting the workers again
+	r.start.Do(func() {})
+	close(r.stop)
+	r.running.Wait()
+}
+
+// WrapWithRefresh behaves like WrapTTL and registers value as the loader of
+// key. While the key is read at least as often as configured with
+// WithRefreshAhead, a background worker recomputes it shortly before it
+// expires. A ttl of zero or KeepTTL never expires and is never refreshed.
+func (c *cache) WrapWithRefresh(ctx context.Context, key string, ttl time.Duration, value func() interface{}) interface{} {
+	if ttl <= 0 {
+		return c.WrapTTL(ctx, key, ttl, value)
+	}
+
+	cachedValue, err := c.Get(ctx, key)
+	if errors.Is(err, ErrNotFound) {
+		return err
+	}
+	if err == nil && cachedValue != nil {
+		c.refresher.register(key, ttl, value, false)
+		return cachedValue
+	}
+
+	result, _, _ := c.loads.Do(key, func() (interface{}, error) {
+		start := time.Now()
+		result := value()
+		c.loaded(key, time.Since(start))
+		if isNotFound(result) {
+			return result, c.store(ctx, key, result, ttl)
+		}
+		if err := c.SetWithTTL(ctx, key, result, ttl); err == nil {
+			c.refresher.register(key, ttl, value, true)
+		}
+		return result, nil
+	})
+	return result
+}
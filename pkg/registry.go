@@ -0,0 +1,109 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ErrUnknownDriver is returned by Open for a DSN whose scheme no driver was
+// registered for
+var ErrUnknownDriver = errors.New("unknown cache driver")
+
+// Driver creates a cache from a DSN. Parameters Open understands for every
+// driver, like prefix, are already removed from its query.
+type Driver func(dsn *url.URL, opts ...Option) (Cache, error)
+
+var (
+	driversMutex sync.RWMutex
+	drivers      = make(map[string]Driver)
+)
+
+// Register makes a driver available to Open for DSNs with the scheme name,
+// like database/sql drivers. It panics if driver is nil or name is taken.
+func Register(name string, driver Driver) {
+	driversMutex.Lock()
+	defer driversMutex.Unlock()
+	if driver == nil {
+		panic("cacher: Register driver is nil")
+	}
+	if _, taken := drivers[name]; taken {
+		panic("cacher: Register called twice for driver " + name)
+	}
+	drivers[name] = driver
+}
+
+// Drivers returns the names of the registered drivers in order
+func Drivers() []string {
+	driversMutex.RLock()
+	defer driversMutex.RUnlock()
+	names := make([]string, 0, len(drivers))
+	for name := range drivers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Open creates a cache from a DSN such as "redis://host:6379/0?prefix=app:",
+// picking the driver registered for its scheme. opts are applied after the
+// options taken from the DSN, so they take precedence.
+func Open(dsn string, opts ...Option) (Cache, error) {
+	u, err := parseDSN(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("cache dsn: %w", err)
+	}
+	return openURL(u, opts...)
+}
+
+// openURL opens a parsed DSN
+func openURL(u *url.URL, opts ...Option) (Cache, error) {
+	driversMutex.RLock()
+	driver, ok := drivers[u.Scheme]
+	driversMutex.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownDriver, u.Scheme)
+	}
+
+	common, err := dsnOptions(u)
+	if err != nil {
+		return nil, err
+	}
+	c, err := driver(u, append(common, opts...)...)
+	if configured, ok := c.(*cache); ok && err == nil && configured.err != nil {
+		_ = configured.Close(context.Background())
+		return nil, configured.err
+	}
+	return c, err
+}
+
+// parseDSN parses dsn as a URL, allowing a comma separated list of hosts as
+// in memcached://cache-1,cache-2. url.Parse rejects those, so the URL is
+// parsed with the first host and the list is put back afterwards.
+func parseDSN(dsn string) (*url.URL, error) {
+	scheme, rest, ok := strings.Cut(dsn, "://")
+	if !ok {
+		return url.Parse(dsn)
+	}
+	authority := rest
+	if end := strings.IndexAny(rest, "/?#"); end >= 0 {
+		authority = rest[:end]
+	}
+	hosts := authority[strings.LastIndex(authority, "@")+1:]
+	if !strings.Contains(hosts, ",") {
+		return url.Parse(dsn)
+	}
+
+	first, _, _ := strings.Cut(hosts, ",")
+	userinfo := authority[:len(authority)-len(hosts)]
+	u, err := url.Parse(scheme + "://" + userinfo + first + rest[len(authority):])
+	if err != nil {
+		return nil, err
+	}
+	u.Host = hosts
+	return u, nil
+}
@@ -0,0 +1,11 @@
+package pkg
+
+import "cacher/internal/adapters"
+
+// ReplicaPolicy decides which replica WithRedisReplicas reads from
+type ReplicaPolicy = adapters.ReplicaPolicy
+
+const (
+	RoundRobin    = adapters.RoundRobin
+	LowestLatency = adapters.LowestLatency
+)
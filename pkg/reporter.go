@@ -0,0 +1,133 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"time"
+)
+
+// StatsSnapshot is the state of a cache handed to a StatsReporter. Tiers,
+// CircuitBreaker, Fallback, Migration and Eviction are nil unless the cache
+// uses them.
+type StatsSnapshot struct {
+	Keys              map[string]map[string]uint64
+	AverageHitLatency float64 // microseconds
+	Tiers             map[string]map[string]uint64
+	CircuitBreaker    map[string]uint64
+	Fallback          map[string]map[string]uint64
+	Migration         map[string]uint64
+	Eviction          map[string]uint64
+}
+
+// StatsReporter publishes statistics of a cache, see WithStatsReporter
+type StatsReporter interface {
+	Report(ctx context.Context, snapshot StatsSnapshot)
+}
+
+type nopReporter struct{}
+
+func (nopReporter) Report(ctx context.Context, snapshot StatsSnapshot) {}
+
+// NopReporter discards statistics
+func NopReporter() StatsReporter {
+	return nopReporter{}
+}
+
+type writerReporter struct {
+	w io.Writer
+}
+
+// NewWriterReporter prints statistics to w in a human readable form
+func NewWriterReporter(w io.Writer) StatsReporter {
+	return &writerReporter{w: w}
+}
+
+// StdoutReporter prints statistics to standard output
+func StdoutReporter() StatsReporter {
+	return NewWriterReporter(os.Stdout)
+}
+
+func (r *writerReporter) Report(ctx context.Context, snapshot StatsSnapshot) {
+	fmt.Fprintln(r.w, "Periodic stats update:", snapshot.Keys)
+	fmt.Fprintf(r.w, "Average Hit Latency: %.2fµs\n", snapshot.AverageHitLatency)
+	if snapshot.Tiers != nil {
+		fmt.Fprintln(r.w, "Tier stats:", snapshot.Tiers)
+	}
+	if snapshot.CircuitBreaker != nil {
+		fmt.Fprintln(r.w, "Circuit breaker:", snapshot.CircuitBreaker)
+	}
+	if snapshot.Fallback != nil {
+		fmt.Fprintln(r.w, "Fallback backends:", snapshot.Fallback)
+	}
+	if snapshot.Migration != nil {
+		fmt.Fprintln(r.w, "Migration:", snapshot.Migration)
+	}
+	if snapshot.Eviction != nil {
+		fmt.Fprintln(r.w, "Eviction:", snapshot.Eviction)
+	}
+}
+
+type logReporter struct {
+	logger *slog.Logger
+}
+
+// NewLogReporter logs statistics as one info record per report
+func NewLogReporter(logger *slog.Logger) StatsReporter {
+	return &logReporter{logger: logger}
+}
+
+func (r *logReporter) Report(ctx context.Context, snapshot StatsSnapshot) {
+	attrs := []any{
+		slog.Any("keys", snapshot.Keys),
+		slog.Float64("average_hit_latency_us", snapshot.AverageHitLatency),
+	}
+	if snapshot.Tiers != nil {
+		attrs = append(attrs, slog.Any("tiers", snapshot.Tiers))
+	}
+	if snapshot.CircuitBreaker != nil {
+		attrs = append(attrs, slog.Any("circuit_breaker", snapshot.CircuitBreaker))
+	}
+	if snapshot.Fallback != nil {
+		attrs = append(attrs, slog.Any("fallback", snapshot.Fallback))
+	}
+	if snapshot.Migration != nil {
+		attrs = append(attrs, slog.Any("migration", snapshot.Migration))
+	}
+	if snapshot.Eviction != nil {
+		attrs = append(attrs, slog.Any("eviction", snapshot.Eviction))
+	}
+	r.logger.InfoContext(ctx, "cache statistics", attrs...)
+}
+
+func (c *cache) snapshot(ctx context.Context) StatsSnapshot {
+	return StatsSnapshot{
+		Keys:              c.Statistics(ctx),
+		AverageHitLatency: c.AverageHitLatency(ctx),
+		Tiers:             c.TierStatistics(ctx),
+		CircuitBreaker:    c.CircuitBreakerStatistics(ctx),
+		Fallback:          c.FallbackStatistics(ctx),
+		Migration:         c.MigrationStatistics(ctx),
+		Eviction:          c.EvictionStatistics(ctx),
+	}
+}
+
+// reportStatistics hands a snapshot to the reporter every interval and once
+// more when the cache is closed
+func (c *cache) reportStatistics(interval time.Duration) {
+	defer close(c.statsDone)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			c.reporter.Report(context.Background(), c.snapshot(context.Background()))
+		case <-c.statsTimerStop:
+			// Flush the stats collected since the last tick
+			c.reporter.Report(context.Background(), c.snapshot(context.Background()))
+			return
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package pkg
+
+import (
+	"cacher/internal/adapters"
+	"context"
+	"iter"
+)
+
+// scanCount is how many keys every SCAN call asks the backend for
+const scanCount = 100
+
+// Keys iterates over the keys matching a glob pattern, e.g. "user:*", with
+// SCAN so the backend is never blocked the way KEYS does. Keys are relative
+// to the prefix, and a key may be yielded twice if it is written during the
+// iteration. An error ends the iteration with an empty key.
+func (c *cache) Keys(ctx context.Context, pattern string) iter.Seq2[string, error] {
+	return func(yield func(string, error) bool) {
+		server, err := c.server()
+		if err != nil {
+			yield("", err)
+			return
+		}
+		var cursor uint64
+		for {
+			keys, next, err := server.Scan(ctx, cursor, pattern, scanCount)
+			if err != nil {
+				yield("", err)
+				return
+			}
+			for _, key := range keys {
+				if !yield(key, nil) {
+					return
+				}
+			}
+			if next == 0 {
+				return
+			}
+			cursor = next
+		}
+	}
+}
+
+// DeleteByPattern deletes every key matching a glob pattern, one SCAN page
+// per batch, and returns how many keys were removed. Matching keys of the
+// local tier are removed too.
+func (c *cache) DeleteByPattern(ctx context.Context, pattern string) (int64, error) {
+	server, err := c.server()
+	if err != nil {
+		return 0, err
+	}
+	deleted, err := adapters.DeleteMatching(ctx, server, pattern)
+	if err != nil {
+		return deleted, err
+	}
+	if tiered, ok := c.Cache.(*adapters.Tiered); ok {
+		_, err = adapters.DeleteMatching(ctx, tiered.Local, pattern)
+	}
+	return deleted, err
+}
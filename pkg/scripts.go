@@ -0,0 +1,42 @@
+package pkg
+
+import (
+	"cacher/internal/adapters"
+	"context"
+)
+
+// Script is a Lua script registered with RegisterScript
+type Script = adapters.Script
+
+// RegisterScript adds the Lua script called name to the scripts Redis backends
+// run, loading it on first use and again when Redis lost it. Register scripts
+// at init time; a name registered twice with different sources panics.
+func RegisterScript(name, source string) *Script {
+	return adapters.DefaultScripts.Register(name, source)
+}
+
+// RunScript runs the registered script called name on the Redis backend of c,
+// ErrUnsupported for other backends. keys get the prefix of the cache.
+func RunScript(ctx context.Context, c Cache, name string, keys []string, args ...interface{}) (interface{}, error) {
+	configured, ok := c.(*cache)
+	if !ok {
+		return nil, ErrUnsupported
+	}
+	server, err := configured.server()
+	if err != nil {
+		return nil, err
+	}
+	if prefixed, ok := server.(*adapters.Prefixed); ok {
+		prefixedKeys := make([]string, len(keys))
+		for i, key := range keys {
+			prefixedKeys[i] = prefixed.Key(key)
+		}
+		keys, server = prefixedKeys, prefixed.Server
+	}
+	client, ok := server.(*adapters.RedisClient)
+	if !ok {
+		return nil, ErrUnsupported
+	}
+	result, err := client.RunScript(ctx, name, keys, args...)
+	return result, backendError(err)
+}
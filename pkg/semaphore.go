@@ -0,0 +1,90 @@
+package pkg
+
+import (
+	"cacher/internal/adapters"
+	"context"
+	"errors"
+	"time"
+)
+
+var ErrSemaphoreFull = errors.New("all semaphore slots are taken")
+
+// Permit is a held slot of a distributed semaphore
+type Permit struct {
+	server adapters.CacheServer
+	key    string
+	holder string
+	limit  int64
+	ttl    time.Duration
+}
+
+func semaphoreKey(name string) string {
+	return "semaphore:" + name
+}
+
+// TryAcquire takes one of max slots of the semaphore called name for ttl,
+// returning ErrSemaphoreFull right away if none is free
+func (c *cache) TryAcquire(ctx context.Context, name string, max int64, ttl time.Duration) (*Permit, error) {
+	if max <= 0 || ttl <= 0 {
+		return nil, errors.New("semaphore size and ttl must be positive")
+	}
+	server, err := c.server()
+	if err != nil {
+		return nil, err
+	}
+
+	permit := &Permit{
+		server: server,
+		key:    semaphoreKey(name),
+		holder: randomToken(),
+		limit:  max,
+		ttl:    ttl,
+	}
+	acquired, err := server.AcquireSemaphore(ctx, permit.key, permit.holder, max, ttl)
+	if err != nil {
+		return nil, err
+	}
+	if !acquired {
+		return nil, ErrSemaphoreFull
+	}
+	return permit, nil
+}
+
+// Acquire takes one of max slots of the semaphore called name for ttl, waiting
+// until a slot is free or ctx is done. Slots of holders that crashed are freed
+// once their ttl elapses.
+func (c *cache) Acquire(ctx context.Context, name string, max int64, ttl time.Duration) (*Permit, error) {
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		permit, err := c.TryAcquire(ctx, name, max, ttl)
+		if !errors.Is(err, ErrSemaphoreFull) {
+			return permit, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Refresh extends the permit by its ttl, failing with ErrSemaphoreFull if it
+// already expired and its slot was given to someone else
+func (p *Permit) Refresh(ctx context.Context) error {
+	acquired, err := p.server.AcquireSemaphore(ctx, p.key, p.holder, p.limit, p.ttl)
+	if err != nil {
+		return err
+	}
+	if !acquired {
+		return ErrSemaphoreFull
+	}
+	return nil
+}
+
+// Release frees the slot held by the permit
+func (p *Permit) Release(ctx context.Context) error {
+	_, err := p.server.ZRem(ctx, p.key, p.holder)
+	return err
+}
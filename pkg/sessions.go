@@ -0,0 +1,264 @@
+package pkg
+
+import (
+	"cacher/codec"
+	"cacher/internal/adapters"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/gorilla/securecookie"
+	"github.com/gorilla/sessions"
+	"net/http"
+	"time"
+)
+
+// DefaultSessionMaxAge is how long sessions last without activity, the
+// default MaxAge of SessionStore, matching the cookie stores of gorilla
+const DefaultSessionMaxAge = 30 * 24 * time.Hour
+
+// SessionStore is a gorilla/sessions Store keeping session values in a cache
+// and only a signed, optionally encrypted, session ID in the cookie. Every
+// load of a session extends its expiration by its MaxAge, so sessions expire
+// after MaxAge without activity. Values are gob encoded, so register custom
+// types with gob.Register.
+type SessionStore struct {
+	Codecs  []securecookie.Codec
+	Options *sessions.Options
+	c       *cache
+	server  adapters.CacheServer
+	values  *TypedCache[map[interface{}]interface{}]
+	userKey interface{}
+}
+
+// SessionStoreOption configures a SessionStore
+type SessionStoreOption func(*SessionStore)
+
+// WithSessionUser indexes sessions by the user in their value at key, e.g.
+// "user_id", so UserSessions and DestroyUserSessions can find them
+func WithSessionUser(key interface{}) SessionStoreOption {
+	return func(s *SessionStore) {
+		s.userKey = key
+	}
+}
+
+// WithSessionOptions sets the cookie options of new sessions
+func WithSessionOptions(options sessions.Options) SessionStoreOption {
+	return func(s *SessionStore) {
+		s.Options = &options
+	}
+}
+
+// NewSessionStore creates a SessionStore for the backend of c. keyPairs are
+// the authentication and encryption keys of the cookie, as for
+// sessions.NewCookieStore.
+func NewSessionStore(c Cache, keyPairs [][]byte, opts ...SessionStoreOption) (*SessionStore, error) {
+	configured, ok := c.(*cache)
+	if !ok {
+		return nil, ErrUnsupported
+	}
+	server, err := configured.server()
+	if err != nil {
+		return nil, err
+	}
+	s := &SessionStore{
+		Codecs: securecookie.CodecsFromPairs(keyPairs...),
+		Options: &sessions.Options{
+			Path:     "/",
+			MaxAge:   int(DefaultSessionMaxAge / time.Second),
+			HttpOnly: true,
+		},
+		c:      configured,
+		server: server,
+		values: NewTypedCache[map[interface{}]interface{}](c, codec.Gob),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.userKey != nil {
+		// The index is a set of session IDs
+		if _, err := configured.unencryptedServer(); err != nil {
+			return nil, err
+		}
+	}
+	for _, cookieCodec := range s.Codecs {
+		if secure, ok := cookieCodec.(*securecookie.SecureCookie); ok {
+			// Sessions expire in the cache as they are used, not by cookie age
+			secure.MaxAge(0)
+		}
+	}
+	return s, nil
+}
+
+// Get returns the session name of r, cached in the request registry
+func (s *SessionStore) Get(r *http.Request, name string) (*sessions.Session, error) {
+	return sessions.GetRegistry(r).Get(s, name)
+}
+
+// New returns the session name of r, loading it from the cache when the
+// request carries its cookie. A session that cannot be loaded is returned
+// new, with the error when its cookie is invalid or the cache failed.
+func (s *SessionStore) New(r *http.Request, name string) (*sessions.Session, error) {
+	session := sessions.NewSession(s, name)
+	options := *s.Options
+	session.Options = &options
+	session.IsNew = true
+
+	cookie, err := r.Cookie(name)
+	if err != nil {
+		return session, nil
+	}
+	if err := securecookie.DecodeMulti(name, cookie.Value, &session.ID, s.Codecs...); err != nil {
+		return session, err
+	}
+	ctx := r.Context()
+	values, err := s.values.Get(ctx, sessionKey(session.ID))
+	if err != nil {
+		session.ID = ""
+		if isBackendError(err) {
+			return session, err
+		}
+		return session, nil
+	}
+	session.Values = values
+	session.IsNew = false
+	if _, err := s.server.Expire(ctx, sessionKey(session.ID), s.maxAge(session)); err != nil {
+		s.c.logger.Warn("cache backend error", "key", sessionKey(session.ID), "error", err)
+	}
+	return session, nil
+}
+
+// Save stores session and sets its cookie on w. A session with a negative
+// MaxAge is deleted along with its cookie.
+func (s *SessionStore) Save(r *http.Request, w http.ResponseWriter, session *sessions.Session) error {
+	ctx := r.Context()
+	if session.Options.MaxAge < 0 {
+		if session.ID != "" {
+			if err := s.destroy(ctx, session.ID, s.user(session.Values)); err != nil {
+				return err
+			}
+		}
+		http.SetCookie(w, sessions.NewCookie(session.Name(), "", session.Options))
+		return nil
+	}
+
+	if session.ID == "" {
+		session.ID = randomToken()
+	}
+	if err := s.values.SetWithTTL(ctx, sessionKey(session.ID), session.Values, s.maxAge(session)); err != nil {
+		return err
+	}
+	if user := s.user(session.Values); user != "" {
+		index, err := NewSet[string](s.c, userSessionsKey(user))
+		if err != nil {
+			return err
+		}
+		if _, err := index.Add(ctx, session.ID); err != nil {
+			return err
+		}
+	}
+	encoded, err := securecookie.EncodeMulti(session.Name(), session.ID, s.Codecs...)
+	if err != nil {
+		return err
+	}
+	http.SetCookie(w, sessions.NewCookie(session.Name(), encoded, session.Options))
+	return nil
+}
+
+// UserSessions returns the IDs of the live sessions of user, dropping the
+// expired ones from the index. It needs WithSessionUser.
+func (s *SessionStore) UserSessions(ctx context.Context, user string) ([]string, error) {
+	if s.userKey == nil {
+		return nil, errors.New("sessions are not indexed by user, see WithSessionUser")
+	}
+	index, err := NewSet[string](s.c, userSessionsKey(user))
+	if err != nil {
+		return nil, err
+	}
+	ids, err := index.Members(ctx)
+	if err != nil {
+		return nil, err
+	}
+	live := make([]string, 0, len(ids))
+	var expired []string
+	for _, id := range ids {
+		ok, err := s.c.Has(ctx, sessionKey(id))
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			live = append(live, id)
+		} else {
+			expired = append(expired, id)
+		}
+	}
+	if _, err := index.Remove(ctx, expired...); err != nil {
+		return nil, err
+	}
+	return live, nil
+}
+
+// DestroyUserSessions deletes every session of user, e.g. to sign them out
+// everywhere after a password change. It needs WithSessionUser.
+func (s *SessionStore) DestroyUserSessions(ctx context.Context, user string) error {
+	ids, err := s.UserSessions(ctx, user)
+	if err != nil {
+		return err
+	}
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = sessionKey(id)
+	}
+	if err := s.c.DeleteMany(ctx, keys...); err != nil {
+		return err
+	}
+	index, err := NewSet[string](s.c, userSessionsKey(user))
+	if err != nil {
+		return err
+	}
+	return index.Clear(ctx)
+}
+
+func (s *SessionStore) destroy(ctx context.Context, id, user string) error {
+	if err := s.c.Delete(ctx, sessionKey(id)); err != nil {
+		return err
+	}
+	if user == "" {
+		return nil
+	}
+	index, err := NewSet[string](s.c, userSessionsKey(user))
+	if err != nil {
+		return err
+	}
+	_, err = index.Remove(ctx, id)
+	return err
+}
+
+// user returns the user of a session, empty when it has none or sessions are
+// not indexed
+func (s *SessionStore) user(values map[interface{}]interface{}) string {
+	if s.userKey == nil {
+		return ""
+	}
+	user, ok := values[s.userKey]
+	if !ok || user == nil {
+		return ""
+	}
+	return fmt.Sprint(user)
+}
+
+// maxAge returns how long session is kept, DefaultSessionMaxAge for
+// sessions whose cookie only lasts as long as the browser
+func (s *SessionStore) maxAge(session *sessions.Session) time.Duration {
+	if session.Options.MaxAge == 0 {
+		return DefaultSessionMaxAge
+	}
+	return time.Duration(session.Options.MaxAge) * time.Second
+}
+
+func sessionKey(id string) string {
+	return "session:" + id
+}
+
+func userSessionsKey(user string) string {
+	return "sessions:" + user
+}
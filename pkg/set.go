@@ -0,0 +1,112 @@
+package pkg
+
+import (
+	"cacher/codec"
+	"cacher/internal/adapters"
+	"context"
+	"fmt"
+)
+
+// Set is a set of values of type T stored in the backend of a cache, with
+// members encoded as JSON so equal values are the same member. It suits
+// membership checks and indexes such as the keys sharing a tag.
+type Set[T any] struct {
+	server adapters.CacheServer
+	key    string
+}
+
+// NewSet creates the set stored at key in the backend of c
+func NewSet[T any](c Cache, key string) (*Set[T], error) {
+	configured, ok := c.(*cache)
+	if !ok {
+		return nil, ErrUnsupported
+	}
+	server, err := configured.unencryptedServer()
+	if err != nil {
+		return nil, err
+	}
+	return &Set[T]{server: server, key: key}, nil
+}
+
+// Add adds members to the set and returns how many were not in it yet
+func (s *Set[T]) Add(ctx context.Context, members ...T) (int64, error) {
+	if len(members) == 0 {
+		return 0, nil
+	}
+	encoded, err := s.encode(members)
+	if err != nil {
+		return 0, err
+	}
+	added, err := s.server.SAdd(ctx, s.key, encoded...)
+	return added, backendError(err)
+}
+
+// Remove removes members from the set and returns how many were in it
+func (s *Set[T]) Remove(ctx context.Context, members ...T) (int64, error) {
+	if len(members) == 0 {
+		return 0, nil
+	}
+	encoded, err := s.encode(members)
+	if err != nil {
+		return 0, err
+	}
+	removed, err := s.server.SRem(ctx, s.key, encoded...)
+	return removed, backendError(err)
+}
+
+// Contains reports whether member is in the set
+func (s *Set[T]) Contains(ctx context.Context, member T) (bool, error) {
+	encoded, err := s.encode([]T{member})
+	if err != nil {
+		return false, err
+	}
+	found, err := s.server.SIsMember(ctx, s.key, encoded[0])
+	return found, backendError(err)
+}
+
+// Members returns every member of the set, in no particular order
+func (s *Set[T]) Members(ctx context.Context) ([]T, error) {
+	stored, err := s.server.SMembers(ctx, s.key)
+	if err != nil {
+		return nil, backendError(err)
+	}
+	members := make([]T, len(stored))
+	for i, data := range stored {
+		if err := codec.JSON.Unmarshal([]byte(data), &members[i]); err != nil {
+			return nil, &decodeError{err}
+		}
+	}
+	return members, nil
+}
+
+// Len returns the number of members of the set
+func (s *Set[T]) Len(ctx context.Context) (int64, error) {
+	count, err := s.server.SCard(ctx, s.key)
+	return count, backendError(err)
+}
+
+// Clear removes every member of the set
+func (s *Set[T]) Clear(ctx context.Context) error {
+	_, err := s.server.Delete(ctx, s.key)
+	return backendError(err)
+}
+
+func (s *Set[T]) encode(members []T) ([]interface{}, error) {
+	encoded := make([]interface{}, len(members))
+	for i, member := range members {
+		data, err := codec.JSON.Marshal(member)
+		if err != nil {
+			return nil, err
+		}
+		encoded[i] = string(data)
+	}
+	return encoded, nil
+}
+
+// backendError wraps an error of the backend, leaving nil alone
+func backendError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return fmt.Errorf("cache backend: %w", err)
+}
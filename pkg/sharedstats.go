@@ -0,0 +1,113 @@
+package pkg
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// sharedStats periodically adds the statistics recorded since the previous
+// flush to hashes in the backend, one per counter, so every instance of a
+// service contributes to the same totals
+type sharedStats struct {
+	cache    *cache
+	name     string
+	interval time.Duration
+	mutex    sync.Mutex
+	flushed  map[string]map[string]uint64
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+func statsHash(name, counter string) string {
+	return "stats:" + name + ":" + counter
+}
+
+func (s *sharedStats) counters() map[string]*statsMap {
+	return map[string]*statsMap{
+		"hits":    &s.cache.hitStats,
+		"misses":  &s.cache.missStats,
+		"deletes": &s.cache.deleteStats,
+		"errors":  &s.cache.errorStats,
+	}
+}
+
+func (s *sharedStats) run() {
+	defer close(s.done)
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			_ = s.flush(context.Background())
+		}
+	}
+}
+
+// flush sends the increments since the last successful flush, one pipeline
+// per counter
+func (s *sharedStats) flush(ctx context.Context) error {
+	server, err := s.cache.server()
+	if err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for counter, stats := range s.counters() {
+		current := stats.getAll()
+		increments := make(map[string]int64)
+		for key, count := range current {
+			if delta := count - s.flushed[counter][key]; delta > 0 {
+				increments[key] = int64(delta)
+			}
+		}
+		if err := server.HIncrByMany(ctx, statsHash(s.name, counter), increments); err != nil {
+			return err
+		}
+		s.flushed[counter] = current
+	}
+	return nil
+}
+
+// close stops the periodic flush and flushes once more
+func (s *sharedStats) close(ctx context.Context) error {
+	close(s.stop)
+	<-s.done
+	return s.flush(ctx)
+}
+
+// SharedStatistics returns the statistics aggregated over every instance that
+// flushes to the same name given with WithSharedStatistics, in the format of
+// Statistics. Counts recorded since the last flush are not included.
+func (c *cache) SharedStatistics(ctx context.Context) (map[string]map[string]uint64, error) {
+	if c.sharedStats == nil {
+		return nil, ErrUnsupported
+	}
+	server, err := c.server()
+	if err != nil {
+		return nil, err
+	}
+
+	stats := make(map[string]map[string]uint64)
+	for counter := range c.sharedStats.counters() {
+		counts, err := server.HGetAll(ctx, statsHash(c.sharedStats.name, counter))
+		if err != nil {
+			return nil, err
+		}
+		for key, count := range counts {
+			parsed, err := strconv.ParseUint(count, 10, 64)
+			if err != nil {
+				continue
+			}
+			if stats[key] == nil {
+				stats[key] = map[string]uint64{}
+			}
+			stats[key][counter] = parsed
+		}
+	}
+	return stats, nil
+}
@@ -0,0 +1,106 @@
+package pkg
+
+import (
+	"cacher/internal/adapters"
+	"context"
+	"io"
+	"time"
+)
+
+// Snapshot writes every key of the memory of NewMemoryCache to w, for Restore
+// to load them into a cache, e.g. after a restart. It returns ErrUnsupported
+// for other backends.
+func (c *cache) Snapshot(ctx context.Context, w io.Writer) error {
+	memory, ok := c.memory()
+	if !ok {
+		return ErrUnsupported
+	}
+	return memory.Snapshot(w)
+}
+
+// Restore adds the keys of a snapshot written by Snapshot to the memory of
+// NewMemoryCache, replacing keys of the same name and skipping those that
+// expired since. It returns ErrUnsupported for other backends.
+func (c *cache) Restore(ctx context.Context, r io.Reader) error {
+	memory, ok := c.memory()
+	if !ok {
+		return ErrUnsupported
+	}
+	return memory.Restore(r)
+}
+
+// memory returns the in-process memory the cache stores its keys in
+func (c *cache) memory() (*adapters.Memory, bool) {
+	server, ok := adapters.Backend(c.Cache)
+	if !ok {
+		return nil, false
+	}
+	if prefixed, ok := server.(*adapters.Prefixed); ok {
+		server = prefixed.Server
+	}
+	memory, ok := server.(*adapters.Memory)
+	return memory, ok
+}
+
+// snapshots saves the memory of a cache to the file of WithSnapshotFile
+type snapshots struct {
+	memory *adapters.Memory
+	path   string
+	logger Logger
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+// newSnapshots restores the snapshot file of WithSnapshotFile, if any, and
+// starts saving it every interval. It returns nil without a snapshot file or
+// without a memory to save.
+func newSnapshots(c *cache, o *options) *snapshots {
+	if o.snapshotPath == "" {
+		return nil
+	}
+	memory, ok := c.memory()
+	if !ok {
+		c.logger.Warn("snapshot file needs an in-process memory", "path", o.snapshotPath, "backend", c.backend)
+		return nil
+	}
+	if err := memory.LoadSnapshot(o.snapshotPath); err != nil {
+		c.logger.Warn("snapshot not restored", "path", o.snapshotPath, "error", err)
+	}
+
+	s := &snapshots{
+		memory: memory,
+		path:   o.snapshotPath,
+		logger: c.logger,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	if o.snapshotInterval > 0 {
+		go s.run(o.snapshotInterval)
+	} else {
+		close(s.done)
+	}
+	return s
+}
+
+func (s *snapshots) run(interval time.Duration) {
+	defer close(s.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			if err := s.memory.SaveSnapshot(s.path); err != nil {
+				s.logger.Warn("snapshot not saved", "path", s.path, "error", err)
+			}
+		}
+	}
+}
+
+// close stops the periodic saves and saves once more
+func (s *snapshots) close() error {
+	close(s.stop)
+	<-s.done
+	return s.memory.SaveSnapshot(s.path)
+}
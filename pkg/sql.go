@@ -0,0 +1,28 @@
+package pkg
+
+import (
+	"cacher/internal/adapters"
+	"context"
+	"database/sql"
+)
+
+// SQLDialect selects the SQL NewSQLCache runs
+type SQLDialect = adapters.SQLDialect
+
+var (
+	Postgres = adapters.Postgres
+	MySQL    = adapters.MySQL
+	SQLite   = adapters.SQLite
+)
+
+// NewSQLCache creates a cache stored in the cache_entries table of db, which
+// is created if needed, for environments where only a relational database is
+// available. db stays open when the cache is closed.
+func NewSQLCache(ctx context.Context, db *sql.DB, dialect SQLDialect, opts ...Option) (Cache, error) {
+	server, err := adapters.OpenSQL(ctx, db, dialect)
+	if err != nil {
+		return nil, err
+	}
+	opts = append([]Option{withServer(server)}, opts...)
+	return NewCache(opts...), nil
+}
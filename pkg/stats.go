@@ -1,49 +1,79 @@
 package pkg
 
 import (
+	"hash/maphash"
 	"sync"
 	"sync/atomic"
 )
 
-type statsMap struct {
+// statsShardCount spreads counters over this many independently locked maps,
+// so goroutines recording different keys rarely contend
+const statsShardCount = 64
+
+var statsSeed = maphash.MakeSeed()
+
+func statsShardOf(key string) int {
+	return int(maphash.String(statsSeed, key) % statsShardCount)
+}
+
+type statsShard struct {
 	data  map[string]*uint64
 	mutex sync.RWMutex
 }
 
+type statsMap struct {
+	shards [statsShardCount]*statsShard
+}
+
 func newStatsMap() statsMap {
-	return statsMap{
-		data: make(map[string]*uint64),
+	var sm statsMap
+	for i := range sm.shards {
+		sm.shards[i] = &statsShard{data: make(map[string]*uint64)}
 	}
+	return sm
 }
 
+// increment adds one to the counter of key. Existing counters are updated
+// atomically under a read lock, only new keys take the write lock.
 func (sm *statsMap) increment(key string) {
-	sm.mutex.Lock()
-	defer sm.mutex.Unlock()
+	shard := sm.shards[statsShardOf(key)]
 
-	if _, exists := sm.data[key]; !exists {
-		var initial uint64 = 0
-		sm.data[key] = &initial
+	shard.mutex.RLock()
+	counter, exists := shard.data[key]
+	shard.mutex.RUnlock()
+	if exists {
+		atomic.AddUint64(counter, 1)
+		return
 	}
-	atomic.AddUint64(sm.data[key], 1)
+
+	shard.mutex.Lock()
+	if counter, exists = shard.data[key]; !exists {
+		counter = new(uint64)
+		shard.data[key] = counter
+	}
+	shard.mutex.Unlock()
+	atomic.AddUint64(counter, 1)
 }
 
 func (sm *statsMap) get(key string) uint64 {
-	sm.mutex.RLock()
-	defer sm.mutex.RUnlock()
+	shard := sm.shards[statsShardOf(key)]
+	shard.mutex.RLock()
+	defer shard.mutex.RUnlock()
 
-	if value, exists := sm.data[key]; exists {
+	if value, exists := shard.data[key]; exists {
 		return atomic.LoadUint64(value)
 	}
 	return 0
 }
 
 func (sm *statsMap) getAll() map[string]uint64 {
-	sm.mutex.RLock()
-	defer sm.mutex.RUnlock()
-
 	copy := make(map[string]uint64)
-	for key, value := range sm.data {
-		copy[key] = atomic.LoadUint64(value)
+	for _, shard := range sm.shards {
+		shard.mutex.RLock()
+		for key, value := range shard.data {
+			copy[key] = atomic.LoadUint64(value)
+		}
+		shard.mutex.RUnlock()
 	}
 	return copy
 }
@@ -52,10 +82,29 @@ func (c *cache) hit(key string) {
 	if c.RecordStatistics {
 		c.hitStats.increment(key)
 	}
+	if c.hotReads != nil {
+		c.hotReads.increment(key)
+	}
 }
 
 func (c *cache) miss(key string) {
 	if c.RecordStatistics {
 		c.missStats.increment(key)
 	}
+	if c.hotReads != nil {
+		c.hotReads.increment(key)
+		c.hotMisses.increment(key)
+	}
+}
+
+func (c *cache) deleted(key string) {
+	if c.RecordStatistics {
+		c.deleteStats.increment(key)
+	}
+}
+
+func (c *cache) failed(key string) {
+	if c.RecordStatistics {
+		c.errorStats.increment(key)
+	}
 }
@@ -0,0 +1,285 @@
+package pkg
+
+import (
+	"cacher/internal/adapters"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"sync"
+	"time"
+)
+
+// ErrConsumerRunning is returned by Run on a StreamConsumer that is already
+// running or was closed
+var ErrConsumerRunning = errors.New("stream consumer is running or closed")
+
+// Defaults of a StreamConsumer
+const (
+	DefaultStreamBatch      = 10
+	DefaultStreamBlock      = time.Second
+	DefaultStreamClaimAfter = time.Minute
+)
+
+// StreamMessage is an entry of a Stream
+type StreamMessage struct {
+	ID     string
+	Values map[string]string
+}
+
+// StreamHandler processes a message delivered to a StreamConsumer. A nil
+// error acknowledges the message; otherwise it stays pending and is delivered
+// again once it can be claimed.
+type StreamHandler func(ctx context.Context, message StreamMessage) error
+
+// StreamOption configures a StreamConsumer
+type StreamOption func(*streamOptions)
+
+type streamOptions struct {
+	batch      int64
+	block      time.Duration
+	claimAfter time.Duration
+	fromStart  bool
+}
+
+// WithStreamBatch sets how many messages a consumer reads at once, defaulting
+// to DefaultStreamBatch
+func WithStreamBatch(n int64) StreamOption {
+	return func(o *streamOptions) {
+		o.batch = n
+	}
+}
+
+// WithStreamBlock sets how long a read waits for new messages, defaulting to
+// DefaultStreamBlock. It must be positive, so an idle consumer does not poll
+// the backend in a loop. Close may wait that long for the consumer to stop.
+func WithStreamBlock(block time.Duration) StreamOption {
+	return func(o *streamOptions) {
+		o.block = block
+	}
+}
+
+// WithStreamClaimAfter sets how long a message stays unacknowledged, because
+// its handler failed or its consumer died, before another consumer of the
+// group claims it, defaulting to DefaultStreamClaimAfter. Zero disables
+// claiming.
+func WithStreamClaimAfter(idle time.Duration) StreamOption {
+	return func(o *streamOptions) {
+		o.claimAfter = idle
+	}
+}
+
+// WithStreamFromStart makes a group created by the consumer deliver the
+// messages already in the stream too, instead of only those added later
+func WithStreamFromStart() StreamOption {
+	return func(o *streamOptions) {
+		o.fromStart = true
+	}
+}
+
+// Stream is an append-only log of messages in the backend of a cache, read by
+// consumer groups: every group receives every message, and each message goes
+// to one consumer of the group
+type Stream struct {
+	server adapters.CacheServer
+	logger Logger
+	key    string
+	maxLen int64
+}
+
+// NewStream creates the Stream called name over the backend of c. A positive
+// maxLen keeps only its newest maxLen messages.
+func NewStream(c Cache, name string, maxLen int64) (*Stream, error) {
+	configured, ok := c.(*cache)
+	if !ok {
+		return nil, ErrUnsupported
+	}
+	server, err := configured.unencryptedServer()
+	if err != nil {
+		return nil, err
+	}
+	return &Stream{server: server, logger: configured.logger, key: "stream:" + name, maxLen: maxLen}, nil
+}
+
+// Publish appends a message with values to the stream and returns its id
+func (s *Stream) Publish(ctx context.Context, values map[string]interface{}) (string, error) {
+	id, err := s.server.XAdd(ctx, s.key, s.maxLen, values)
+	return id, backendError(err)
+}
+
+// Len returns the number of messages in the stream
+func (s *Stream) Len(ctx context.Context) (int64, error) {
+	n, err := s.server.XLen(ctx, s.key)
+	return n, backendError(err)
+}
+
+// StreamConsumer hands the messages of a Stream delivered to one consumer of
+// a group to a handler, one at a time
+type StreamConsumer struct {
+	stream   *Stream
+	group    string
+	consumer string
+	handler  StreamHandler
+	options  streamOptions
+
+	mutex   sync.Mutex
+	started bool
+	stop    chan struct{}
+	done    chan struct{}
+	once    sync.Once
+}
+
+// Consumer creates the group of the stream if needed and returns the consumer
+// called consumer in it. Consumers of a group must have distinct names; a
+// consumer restarted under the same name has its unacknowledged messages
+// claimed back like any other.
+func (s *Stream) Consumer(ctx context.Context, group, consumer string, handler StreamHandler, opts ...StreamOption) (*StreamConsumer, error) {
+	o := streamOptions{batch: DefaultStreamBatch, block: DefaultStreamBlock, claimAfter: DefaultStreamClaimAfter}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.block <= 0 {
+		return nil, errors.New("stream block must be positive")
+	}
+	start := "$"
+	if o.fromStart {
+		start = "0"
+	}
+	if err := s.server.XGroupCreate(ctx, s.key, group, start); err != nil {
+		return nil, backendError(err)
+	}
+	return &StreamConsumer{
+		stream:   s,
+		group:    group,
+		consumer: consumer,
+		handler:  handler,
+		options:  o,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}, nil
+}
+
+// Run reads and handles messages until ctx is done, returning its error, or
+// Close is called, returning nil. Messages idle in the group for the claim
+// delay are claimed before new ones are read. A backend error stops Run and
+// is returned.
+func (c *StreamConsumer) Run(ctx context.Context) error {
+	c.mutex.Lock()
+	select {
+	case <-c.stop:
+		c.mutex.Unlock()
+		return ErrConsumerRunning
+	default:
+	}
+	if c.started {
+		c.mutex.Unlock()
+		return ErrConsumerRunning
+	}
+	c.started = true
+	c.mutex.Unlock()
+	defer close(c.done)
+
+	// Reads are cancelled by Close too, handlers only by ctx
+	readCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-c.stop:
+			cancel()
+		case <-readCtx.Done():
+		}
+	}()
+
+	var lastClaim time.Time
+	for {
+		var messages []redis.XMessage
+		var err error
+		if c.options.claimAfter > 0 && time.Since(lastClaim) >= c.options.claimAfter {
+			messages, err = c.claim(readCtx)
+			// A full batch may leave more to claim on the next round
+			if int64(len(messages)) < c.options.batch {
+				lastClaim = time.Now()
+			}
+		}
+		if err == nil && len(messages) == 0 {
+			messages, err = c.stream.server.XReadGroup(readCtx, c.stream.key, c.group, c.consumer, c.options.batch, c.options.block)
+		}
+		if err != nil {
+			if c.stopped() {
+				return nil
+			}
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return ctxErr
+			}
+			return backendError(err)
+		}
+
+		for _, message := range messages {
+			c.handle(ctx, message)
+		}
+		if c.stopped() {
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+}
+
+// claim takes over up to a batch of the messages of the group that were idle
+// for the claim delay
+func (c *StreamConsumer) claim(ctx context.Context) ([]redis.XMessage, error) {
+	var claimed []redis.XMessage
+	start := "0-0"
+	for {
+		messages, next, err := c.stream.server.XAutoClaim(ctx, c.stream.key, c.group, c.consumer, c.options.claimAfter, start, c.options.batch)
+		if err != nil {
+			return nil, err
+		}
+		claimed = append(claimed, messages...)
+		if next == "0-0" || next == "" || int64(len(claimed)) >= c.options.batch {
+			return claimed, nil
+		}
+		start = next
+	}
+}
+
+// handle runs the handler on message and acknowledges it when it succeeds
+func (c *StreamConsumer) handle(ctx context.Context, message redis.XMessage) {
+	values := make(map[string]string, len(message.Values))
+	for field, value := range message.Values {
+		values[field] = fmt.Sprint(value)
+	}
+	if err := c.handler(ctx, StreamMessage{ID: message.ID, Values: values}); err != nil {
+		c.stream.logger.Warn("stream handler error", "stream", c.stream.key, "group", c.group, "id", message.ID, "error", err)
+		return
+	}
+	if _, err := c.stream.server.XAck(ctx, c.stream.key, c.group, message.ID); err != nil {
+		c.stream.logger.Warn("cache backend error", "stream", c.stream.key, "group", c.group, "id", message.ID, "error", err)
+	}
+}
+
+func (c *StreamConsumer) stopped() bool {
+	select {
+	case <-c.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// Close stops the consumer after the messages it already read are handled
+// and waits for Run to return. Messages whose handler failed are left for
+// other consumers of the group to claim.
+func (c *StreamConsumer) Close() error {
+	c.once.Do(func() {
+		close(c.stop)
+	})
+	c.mutex.Lock()
+	started := c.started
+	c.mutex.Unlock()
+	if started {
+		<-c.done
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package pkg
+
+import (
+	"cacher/internal/adapters"
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrUnsupported is returned when the backend of a cache does not provide the
+// primitives an operation needs
+var ErrUnsupported = errors.New("operation not supported by the cache backend")
+
+// ErrEncryptionUnsupported is returned with WithEncryption by the types that
+// keep data in hashes, sets, sorted sets, lists or streams, which the backend
+// has to read and cannot do encrypted. It wraps ErrUnsupported.
+var ErrEncryptionUnsupported = fmt.Errorf("%w with WithEncryption, the data would be stored unencrypted", ErrUnsupported)
+
+// SetOption configures a single Set call
+type SetOption func(*setOptions)
+
+type setOptions struct {
+	tags []string
+}
+
+// WithTags attaches tags to the stored key so it can be removed together with
+// every other key sharing a tag through InvalidateTag
+func WithTags(tags ...string) SetOption {
+	return func(o *setOptions) {
+		o.tags = append(o.tags, tags...)
+	}
+}
+
+func tagKey(tag string) string {
+	return "tag:" + tag
+}
+
+// server returns the CacheServer behind the cache, or ErrUnsupported when the
+// adapter does not expose one
+func (c *cache) server() (adapters.CacheServer, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	server, ok := adapters.Backend(c.Cache)
+	if !ok {
+		return nil, ErrUnsupported
+	}
+	return server, nil
+}
+
+// unencryptedServer returns the backend for data stored in its own
+// structures, failing with ErrEncryptionUnsupported rather than writing the
+// data in the clear when the cache encrypts its values
+func (c *cache) unencryptedServer() (adapters.CacheServer, error) {
+	if c.keyring != nil {
+		return nil, ErrEncryptionUnsupported
+	}
+	return c.server()
+}
+
+// tag records key as a member of every tag
+func (c *cache) tag(ctx context.Context, key string, tags []string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+	server, err := c.server()
+	if err != nil {
+		return err
+	}
+	for _, tag := range tags {
+		if _, err := server.SAdd(ctx, tagKey(tag), key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// InvalidateTag deletes every key stored with the given tag
+func (c *cache) InvalidateTag(ctx context.Context, tag string) error {
+	server, err := c.server()
+	if err != nil {
+		return err
+	}
+
+	keys, err := server.SMembers(ctx, tagKey(tag))
+	if err != nil {
+		return err
+	}
+	if err := c.DeleteMany(ctx, keys...); err != nil {
+		return err
+	}
+	return c.Cache.Delete(ctx, tagKey(tag))
+}
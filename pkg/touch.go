@@ -0,0 +1,44 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Touch resets the expiration of key to ttl without reading or rewriting its
+// value. It returns ErrCacheMiss when the key does not exist.
+func (c *cache) Touch(ctx context.Context, key string, ttl time.Duration) error {
+	if ttl <= 0 {
+		return errors.New("touch ttl must be positive")
+	}
+	server, err := c.server()
+	if err != nil {
+		return err
+	}
+	found, err := server.Expire(ctx, key, ttl)
+	if err != nil {
+		return fmt.Errorf("cache backend: %w", err)
+	}
+	if !found {
+		return ErrCacheMiss
+	}
+	return nil
+}
+
+// slide extends the expiration of key after a hit when WithSlidingTTL is
+// set, leaving keys without one alone. A failure is logged, the hit is still
+// served.
+func (c *cache) slide(ctx context.Context, key string) {
+	if c.slidingTTL <= 0 {
+		return
+	}
+	server, err := c.server()
+	if err == nil {
+		_, err = server.ExpireXX(ctx, key, c.slidingTTL)
+	}
+	if err != nil {
+		c.logger.Warn("cache backend error", "key", key, "error", err)
+	}
+}
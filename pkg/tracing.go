@@ -0,0 +1,34 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"time"
+)
+
+const tracerName = "cacher"
+
+// startSpan starts a client span for a cache operation as a child of the span
+// in ctx. Spans are no-ops unless WithTracerProvider was given.
+func (c *cache) startSpan(ctx context.Context, operation string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	attributes = append(attributes, attribute.String("cache.backend", c.backend))
+	return c.tracer.Start(ctx, "cache."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attributes...),
+	)
+}
+
+// endSpan records the latency and any backend error on span and ends it. A
+// missing key is not treated as an error.
+func endSpan(span trace.Span, start time.Time, err error) {
+	span.SetAttributes(attribute.Int64("cache.latency_us", time.Since(start).Microseconds()))
+	if err != nil && !errors.Is(err, redis.Nil) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
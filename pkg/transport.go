@@ -0,0 +1,293 @@
+package pkg
+
+import (
+	"bytes"
+	"cacher/internal/adapters"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultTransportKeepStale is how long Transport keeps a response with an
+// ETag or Last-Modified after it went stale, to revalidate it instead of
+// downloading it again
+const DefaultTransportKeepStale = time.Hour
+
+// Transport is an http.RoundTripper that caches GET responses in a Cache like
+// a private HTTP cache: it serves fresh responses by their Cache-Control
+// max-age or Expires, revalidates stale ones with If-None-Match and
+// If-Modified-Since, keeps one variant per URL under Vary, and never stores
+// responses marked no-store. Responses carry an X-Cache header, HIT when
+// they were served from the cache.
+type Transport struct {
+	responses     *TypedCache[cachedResponse]
+	base          http.RoundTripper
+	keepStale     time.Duration
+	logger        Logger
+	hits          atomic.Uint64
+	misses        atomic.Uint64
+	revalidations atomic.Uint64
+}
+
+// TransportOption configures a Transport
+type TransportOption func(*Transport)
+
+// WithTransportBase sets the RoundTripper that makes the requests, defaulting
+// to http.DefaultTransport
+func WithTransportBase(base http.RoundTripper) TransportOption {
+	return func(t *Transport) {
+		t.base = base
+	}
+}
+
+// WithTransportKeepStale sets how long stale responses that can be
+// revalidated are kept, see DefaultTransportKeepStale
+func WithTransportKeepStale(keepStale time.Duration) TransportOption {
+	return func(t *Transport) {
+		t.keepStale = keepStale
+	}
+}
+
+// cachedResponse is a response stored by Transport or Handler. Vary holds the
+// request headers the response varies on, with their values.
+type cachedResponse struct {
+	Status  int         `json:"status"`
+	Header  http.Header `json:"header"`
+	Body    []byte      `json:"body"`
+	Vary    http.Header `json:"vary,omitempty"`
+	Expires time.Time   `json:"expires"`
+}
+
+// NewTransport creates a Transport caching responses in c. Responses are
+// encoded with the codec configured by WithCodec.
+func NewTransport(c Cache, opts ...TransportOption) *Transport {
+	t := &Transport{
+		responses: NewTypedCache[cachedResponse](c),
+		base:      http.DefaultTransport,
+		keepStale: DefaultTransportKeepStale,
+		logger:    adapters.NopLogger(),
+	}
+	if configured, ok := c.(*cache); ok {
+		t.logger = configured.logger
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// RoundTrip serves req from the cache when it can and sends it otherwise
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	requestControl := cacheControl(req.Header)
+	if req.Method != http.MethodGet || req.Header.Get("Range") != "" || requestControl.has("no-store") {
+		return t.base.RoundTrip(req)
+	}
+	ctx := req.Context()
+	key := "http:" + req.URL.String()
+
+	entry, err := t.responses.Get(ctx, key)
+	cached := err == nil && entry.matches(req.Header)
+	revalidate := requestControl.has("no-cache") || requestControl.maxAge() == 0
+	if cached && !revalidate && time.Now().Before(entry.Expires) {
+		t.hits.Add(1)
+		return entry.response(req, "HIT"), nil
+	}
+
+	outgoing := req
+	if cached {
+		outgoing = req.Clone(ctx)
+		if etag := entry.Header.Get("ETag"); etag != "" {
+			outgoing.Header.Set("If-None-Match", etag)
+		}
+		if modified := entry.Header.Get("Last-Modified"); modified != "" {
+			outgoing.Header.Set("If-Modified-Since", modified)
+		}
+	}
+	resp, err := t.base.RoundTrip(outgoing)
+	if err != nil {
+		return nil, err
+	}
+
+	if cached && resp.StatusCode == http.StatusNotModified {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+		t.revalidations.Add(1)
+		for name, values := range resp.Header {
+			if name != "Content-Length" {
+				entry.Header[name] = values
+			}
+		}
+		t.store(ctx, key, entry)
+		return entry.response(req, "HIT"), nil
+	}
+
+	t.misses.Add(1)
+	resp.Header.Set("X-Cache", "MISS")
+	if !cacheableStatus(resp.StatusCode) || cacheControl(resp.Header).has("no-store") || resp.Header.Get("Vary") == "*" {
+		return resp, nil
+	}
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	t.store(ctx, key, cachedResponse{
+		Status: resp.StatusCode,
+		Header: resp.Header.Clone(),
+		Body:   body,
+		Vary:   varyValues(resp.Header, req.Header),
+	})
+	return resp, nil
+}
+
+// store writes entry for as long as it is fresh, or can be revalidated
+func (t *Transport) store(ctx context.Context, key string, entry cachedResponse) {
+	entry.Header.Del("X-Cache")
+	fresh := freshness(entry.Header, time.Now())
+	ttl := fresh
+	if entry.Header.Get("ETag") != "" || entry.Header.Get("Last-Modified") != "" {
+		ttl += t.keepStale
+	}
+	if ttl <= 0 {
+		return
+	}
+	entry.Expires = time.Now().Add(fresh)
+	if err := t.responses.SetWithTTL(ctx, key, entry, ttl); err != nil {
+		t.logger.Warn("cache backend error", "key", key, "error", err)
+	}
+}
+
+// Statistics returns how many responses were served from the cache, sent
+// over the network and revalidated
+func (t *Transport) Statistics() map[string]uint64 {
+	return map[string]uint64{
+		"hits":          t.hits.Load(),
+		"misses":        t.misses.Load(),
+		"revalidations": t.revalidations.Load(),
+	}
+}
+
+// matches reports whether the request headers a response varies on have the
+// values of the request it was stored for
+func (r cachedResponse) matches(header http.Header) bool {
+	for name, values := range r.Vary {
+		if strings.Join(header.Values(name), ", ") != strings.Join(values, ", ") {
+			return false
+		}
+	}
+	return true
+}
+
+// response builds the response of req from r, marked with an X-Cache status
+func (r cachedResponse) response(req *http.Request, status string) *http.Response {
+	header := r.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	header.Set("X-Cache", status)
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", r.Status, http.StatusText(r.Status)),
+		StatusCode:    r.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(r.Body)),
+		ContentLength: int64(len(r.Body)),
+		Request:       req,
+	}
+}
+
+// varyValues returns the request headers named by the Vary header of a
+// response, with their values
+func varyValues(response, request http.Header) http.Header {
+	var vary http.Header
+	for _, field := range response.Values("Vary") {
+		for _, name := range strings.Split(field, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				if vary == nil {
+					vary = http.Header{}
+				}
+				vary[http.CanonicalHeaderKey(name)] = request.Values(name)
+			}
+		}
+	}
+	return vary
+}
+
+// cacheableStatus reports whether responses with status may be cached without
+// explicit permission beyond freshness, following RFC 9110
+func cacheableStatus(status int) bool {
+	switch status {
+	case http.StatusOK, http.StatusNonAuthoritativeInfo, http.StatusNoContent,
+		http.StatusMultipleChoices, http.StatusMovedPermanently, http.StatusPermanentRedirect,
+		http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusGone,
+		http.StatusRequestURITooLong, http.StatusNotImplemented:
+		return true
+	}
+	return false
+}
+
+// directives are the directives of a Cache-Control header, keyed by lower
+// case name
+type directives map[string]string
+
+func cacheControl(header http.Header) directives {
+	parsed := directives{}
+	for _, field := range header.Values("Cache-Control") {
+		for _, directive := range strings.Split(field, ",") {
+			name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+			if name != "" {
+				parsed[strings.ToLower(name)] = strings.Trim(value, `"`)
+			}
+		}
+	}
+	return parsed
+}
+
+func (d directives) has(name string) bool {
+	_, ok := d[name]
+	return ok
+}
+
+// maxAge returns max-age in seconds, -1 when it is missing or invalid
+func (d directives) maxAge() int {
+	value, ok := d["max-age"]
+	if !ok {
+		return -1
+	}
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds < 0 {
+		return -1
+	}
+	return seconds
+}
+
+// freshness returns how long a response with header stays fresh from now, by
+// its max-age or else its Expires relative to its Date. Responses marked
+// no-cache are stale at once.
+func freshness(header http.Header, now time.Time) time.Duration {
+	control := cacheControl(header)
+	if control.has("no-cache") || control.has("no-store") {
+		return 0
+	}
+	if seconds := control.maxAge(); seconds >= 0 {
+		age, _ := strconv.Atoi(header.Get("Age"))
+		return time.Duration(max(seconds-age, 0)) * time.Second
+	}
+	expires, err := http.ParseTime(header.Get("Expires"))
+	if err != nil {
+		return 0
+	}
+	date, err := http.ParseTime(header.Get("Date"))
+	if err != nil {
+		date = now
+	}
+	return max(expires.Sub(date), 0)
+}
@@ -0,0 +1,121 @@
+package pkg
+
+import (
+	"cacher/internal/adapters"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	"math/rand/v2"
+	"time"
+)
+
+// ErrTxConflict is returned by Txn when the watched keys changed during every
+// attempt
+var ErrTxConflict = errors.New("transaction kept conflicting with other writes")
+
+// MaxTxAttempts is how often Txn runs a transaction whose watched keys changed
+// before it gives up
+const MaxTxAttempts = 10
+
+// Tx is the view of a transaction passed to the function of Txn. Get reads
+// the current value of a key; writes are queued and applied together when the
+// function returns, their results hold their outcome once Txn returned.
+type Tx interface {
+	// Get reads key like Cache.Get, without loaders and statistics
+	Get(ctx context.Context, key string) (interface{}, error)
+	Set(key string, value interface{}) *StatusResult
+	SetWithTTL(key string, value interface{}, ttl time.Duration) *StatusResult
+	Delete(keys ...string) *StatusResult
+	Increment(key string, delta int64) *IntResult
+}
+
+type tx struct {
+	c      *cache
+	reader adapters.TxReader
+	*Pipeline
+}
+
+func (t *tx) Get(ctx context.Context, key string) (interface{}, error) {
+	value, err := t.reader.Get(ctx, key)
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrCacheMiss
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cache backend: %w", err)
+	}
+	decoded, err := t.c.decode(key, value)
+	if err != nil {
+		return nil, err
+	}
+	if decoded == tombstone {
+		return nil, ErrNotFound
+	}
+	return decoded, nil
+}
+
+// Txn runs fn and applies the writes it queued on tx at once, unless one of
+// watchKeys changed since the attempt started: then fn runs again on the new
+// values, up to MaxTxAttempts times before Txn fails with ErrTxConflict. An
+// error from fn cancels the transaction and is returned as is. fn may run
+// several times, so it should have no other side effects.
+//
+// With Redis this is WATCH, MULTI and EXEC; on a cluster every key must be in
+// the same slot. The in-memory backend holds off other writes of the process
+// while a transaction commits.
+func (c *cache) Txn(ctx context.Context, fn func(tx Tx) error, watchKeys ...string) (err error) {
+	start := time.Now()
+	ctx, span := c.startSpan(ctx, "Txn", attribute.StringSlice("cache.keys", watchKeys))
+	defer func() { endSpan(span, start, err) }()
+
+	server, err := c.server()
+	if err != nil {
+		return err
+	}
+	for attempt := 1; ; attempt++ {
+		var ops []pipelineOp
+		var aborted error
+		err = server.Watch(ctx, func(reader adapters.TxReader) ([]*adapters.PipelineOp, error) {
+			ops, aborted = nil, nil
+			t := &tx{c: c, reader: reader, Pipeline: c.Pipeline()}
+			if aborted = fn(t); aborted != nil {
+				return nil, aborted
+			}
+			queued := c.preparePipelined(ctx, t.ops)
+			for _, op := range t.ops {
+				// A write that cannot be applied cancels all of them
+				if op.op.Err != nil && !errors.Is(op.op.Err, errSkipped) {
+					aborted = op.op.Err
+					return nil, aborted
+				}
+			}
+			ops = t.ops
+			return queued, nil
+		}, watchKeys...)
+		switch {
+		case aborted != nil:
+			return aborted
+		case errors.Is(err, adapters.ErrTxFailed):
+			if attempt == MaxTxAttempts {
+				return ErrTxConflict
+			}
+			// Writers racing for the same keys spread out before retrying
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(rand.N(time.Duration(attempt) * time.Millisecond)):
+			}
+			continue
+		case err != nil:
+			for _, op := range ops {
+				if op.op.Err == nil {
+					op.op.Err = err
+				}
+			}
+			_ = c.finishPipelined(ctx, ops, time.Since(start))
+			return fmt.Errorf("cache backend: %w", err)
+		}
+		return c.finishPipelined(ctx, ops, time.Since(start))
+	}
+}
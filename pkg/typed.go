@@ -0,0 +1,185 @@
+package pkg
+
+import (
+	"cacher/codec"
+	"context"
+	"errors"
+	"fmt"
+	"golang.org/x/sync/singleflight"
+	"time"
+)
+
+// TypedCache stores values of type T in a Cache, marshaling them with a codec
+// on write and unmarshaling them on read.
+type TypedCache[T any] struct {
+	cache        Cache
+	codec        codec.Codec
+	loads        singleflight.Group
+	contextLoads singleflight.Group // GetOrSet loaders, which fail unlike those of Wrap
+}
+
+// NewTypedCache wraps c so values are stored and loaded as T. Values are
+// serialized with the codec configured by WithCodec, or the given codec.
+func NewTypedCache[T any](c Cache, valueCodec ...codec.Codec) *TypedCache[T] {
+	t := &TypedCache[T]{cache: c, codec: codec.JSON}
+	if configured, ok := c.(*cache); ok && configured.codec != nil {
+		t.codec = configured.codec
+	}
+	if len(valueCodec) > 0 {
+		t.codec = valueCodec[0]
+	}
+	return t
+}
+
+// Get retrieves and decodes the value stored at key
+func (t *TypedCache[T]) Get(ctx context.Context, key string) (T, error) {
+	var zero T
+	data, err := t.cache.Get(ctx, key)
+	if err != nil {
+		return zero, err
+	}
+	return t.decode(data)
+}
+
+// Set encodes and stores value using the cache's default expiration
+func (t *TypedCache[T]) Set(ctx context.Context, key string, value T, opts ...SetOption) error {
+	data, err := t.codec.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return t.cache.Set(ctx, key, data, opts...)
+}
+
+// SetWithTTL encodes and stores value with the given expiration
+func (t *TypedCache[T]) SetWithTTL(ctx context.Context, key string, value T, ttl time.Duration, opts ...SetOption) error {
+	data, err := t.codec.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return t.cache.SetWithTTL(ctx, key, data, ttl, opts...)
+}
+
+// Wrap returns the cached value for key, computing and storing it on a miss.
+// Values that cannot be decoded as T are treated as a miss and overwritten,
+// cached "not found" results are returned as ErrNotFound.
+// When the backend fails the computed value is returned with the error and
+// nothing is stored.
+func (t *TypedCache[T]) Wrap(ctx context.Context, key string, value func() T) (T, error) {
+	cached, err := t.Get(ctx, key)
+	if err == nil || errors.Is(err, ErrNotFound) {
+		return cached, err
+	}
+	if isBackendError(err) {
+		return value(), err
+	}
+
+	result, err, _ := t.loads.Do(key, func() (interface{}, error) {
+		result := value()
+		return result, t.Set(ctx, key, result)
+	})
+	typed, _ := result.(T)
+	return typed, err
+}
+
+// WrapTTL behaves like Wrap but stores computed values with the given expiration
+func (t *TypedCache[T]) WrapTTL(ctx context.Context, key string, ttl time.Duration, value func() T) (T, error) {
+	cached, err := t.Get(ctx, key)
+	if err == nil || errors.Is(err, ErrNotFound) {
+		return cached, err
+	}
+	if isBackendError(err) {
+		return value(), err
+	}
+
+	result, err, _ := t.loads.Do(key, func() (interface{}, error) {
+		result := value()
+		return result, t.SetWithTTL(ctx, key, result, ttl)
+	})
+	typed, _ := result.(T)
+	return typed, err
+}
+
+// GetOrSet returns the value cached at key, running loader on a miss and
+// storing its result for ttl. Unlike Wrap, an error of loader is returned and
+// nothing is cached for it, except ErrNotFound which is cached for the
+// negative ttl, see WithNegativeTTL. Concurrent callers of a key share one
+// loader call, which keeps the values of the context of the first but runs
+// to completion when it is canceled; a caller whose context is done stops
+// waiting and returns its error.
+func (t *TypedCache[T]) GetOrSet(ctx context.Context, key string, ttl time.Duration, loader func(ctx context.Context) (T, error)) (T, error) {
+	var zero T
+	if err := ctx.Err(); err != nil {
+		return zero, err
+	}
+	cached, err := t.Get(ctx, key)
+	if err == nil || errors.Is(err, ErrNotFound) {
+		return cached, err
+	}
+	if isBackendError(err) {
+		value, loadErr := loader(ctx)
+		if loadErr != nil {
+			return zero, loadErr
+		}
+		return value, err
+	}
+
+	shared := context.WithoutCancel(ctx)
+	results := t.contextLoads.DoChan(key, func() (interface{}, error) {
+		value, err := loader(shared)
+		if errors.Is(err, ErrNotFound) {
+			if configured, ok := t.cache.(*cache); ok {
+				_ = configured.store(shared, key, ErrNotFound, ttl)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		return value, t.SetWithTTL(shared, key, value, ttl)
+	})
+	select {
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	case result := <-results:
+		typed, _ := result.Val.(T)
+		return typed, result.Err
+	}
+}
+
+func (t *TypedCache[T]) decode(data interface{}) (T, error) {
+	var parsed T
+	var raw []byte
+	switch v := data.(type) {
+	case string:
+		raw = []byte(v)
+	case []byte:
+		raw = v
+	default:
+		return parsed, &decodeError{fmt.Errorf("unexpected type %T", data)}
+	}
+
+	if err := t.codec.Unmarshal(raw, &parsed); err != nil {
+		var zero T
+		return zero, &decodeError{err}
+	}
+	return parsed, nil
+}
+
+// isBackendError reports whether err came from the backend rather than from a
+// missing key or an undecodable value
+func isBackendError(err error) bool {
+	var decodeErr *decodeError
+	return !errors.Is(err, ErrCacheMiss) && !errors.As(err, &decodeErr)
+}
+
+// decodeError is returned when a cached value cannot be decoded as T
+type decodeError struct {
+	err error
+}
+
+func (e *decodeError) Error() string {
+	return "decode cached value: " + e.err.Error()
+}
+
+func (e *decodeError) Unwrap() error {
+	return e.err
+}
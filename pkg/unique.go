@@ -0,0 +1,68 @@
+package pkg
+
+import (
+	"cacher/internal/adapters"
+	"context"
+)
+
+// UniqueCounter counts distinct ids, such as the visitors of a page per day,
+// with HyperLogLogs in the backend of a cache. Every counter takes about 12KB
+// whatever the number of ids, and counts are estimates with a standard error
+// of 0.81%.
+type UniqueCounter struct {
+	server adapters.CacheServer
+}
+
+// NewUniqueCounter creates a UniqueCounter over the backend of c
+func NewUniqueCounter(c Cache) (*UniqueCounter, error) {
+	configured, ok := c.(*cache)
+	if !ok {
+		return nil, ErrUnsupported
+	}
+	server, err := configured.server()
+	if err != nil {
+		return nil, err
+	}
+	return &UniqueCounter{server: server}, nil
+}
+
+func uniqueKey(name string) string {
+	return "unique:" + name
+}
+
+func uniqueKeys(names []string) []string {
+	keys := make([]string, len(names))
+	for i, name := range names {
+		keys[i] = uniqueKey(name)
+	}
+	return keys
+}
+
+// Track records ids in the counter called name and reports whether its count
+// changed
+func (u *UniqueCounter) Track(ctx context.Context, name string, ids ...string) (bool, error) {
+	elements := make([]interface{}, len(ids))
+	for i, id := range ids {
+		elements[i] = id
+	}
+	changed, err := u.server.PFAdd(ctx, uniqueKey(name), elements...)
+	return changed, backendError(err)
+}
+
+// Count estimates how many distinct ids were tracked in the counters called
+// names together, e.g. the unique visitors of a week from seven daily counters
+func (u *UniqueCounter) Count(ctx context.Context, names ...string) (int64, error) {
+	count, err := u.server.PFCount(ctx, uniqueKeys(names)...)
+	return count, backendError(err)
+}
+
+// Merge adds every id of the counters called names to the counter dest
+func (u *UniqueCounter) Merge(ctx context.Context, dest string, names ...string) error {
+	return backendError(u.server.PFMerge(ctx, uniqueKey(dest), uniqueKeys(names)...))
+}
+
+// Reset deletes the counter called name
+func (u *UniqueCounter) Reset(ctx context.Context, name string) error {
+	_, err := u.server.Delete(ctx, uniqueKey(name))
+	return backendError(err)
+}
@@ -0,0 +1,94 @@
+package pkg
+
+import (
+	"cacher/internal/adapters"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+// UpdateFunc computes the new value of a key from its current one, nil when
+// the key does not exist. Returning a nil value deletes the key, returning an
+// error aborts the update without writing.
+type UpdateFunc func(old []byte) ([]byte, error)
+
+// Update applies fn to the value stored at key as an optimistic transaction:
+// the result is only written if the key still holds the value fn was given,
+// otherwise fn runs again on the new value until the write succeeds or ctx is
+// done. Existing keys keep their expiration, new keys get the default one.
+func (c *cache) Update(ctx context.Context, key string, fn UpdateFunc) error {
+	server, err := c.server()
+	if err != nil {
+		return err
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		start := time.Now()
+		raw, err := server.Get(ctx, key)
+		exists := err == nil
+		if err != nil && !errors.Is(err, redis.Nil) {
+			c.failed(key)
+			return fmt.Errorf("cache backend: %w", err)
+		}
+
+		var old []byte
+		if exists {
+			decoded, err := c.decode(key, raw)
+			if err != nil {
+				return err
+			}
+			if decoded != tombstone {
+				old = []byte(decoded)
+			}
+		}
+		next, err := fn(old)
+		if err != nil {
+			return err
+		}
+		var value interface{}
+		if next != nil {
+			if skip, _ := c.oversized(ctx, key, next); skip {
+				return ErrValueTooLarge
+			}
+			if !exists && c.defaultTTL == NoDefaultTTL {
+				return ErrNoDefaultTTL
+			}
+			if value, err = c.encode(key, string(next)); err != nil {
+				return err
+			}
+		}
+
+		written, err := c.swap(ctx, server, key, raw, exists, value)
+		if err != nil {
+			c.failed(key)
+			return fmt.Errorf("cache backend: %w", err)
+		}
+		if written {
+			if next == nil {
+				c.fire(ctx, hookDelete, "update", key, time.Since(start), nil)
+			} else {
+				c.fire(ctx, hookSet, "update", key, time.Since(start), nil)
+			}
+			return c.evictLocal(ctx, key)
+		}
+	}
+}
+
+// swap replaces raw, the value key held, with the encoded value, deleting key
+// when value is nil. It reports false when key changed in the meantime.
+func (c *cache) swap(ctx context.Context, server adapters.CacheServer, key, raw string, exists bool, value interface{}) (bool, error) {
+	switch {
+	case value == nil && !exists:
+		return true, nil
+	case value == nil:
+		return server.CompareAndDelete(ctx, key, raw)
+	case exists:
+		return server.CompareAndSwap(ctx, key, raw, value, KeepTTL)
+	}
+	return server.SetNX(ctx, key, value, c.jitter(c.defaultTTL))
+}
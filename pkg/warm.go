@@ -0,0 +1,247 @@
+package pkg
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+	"sync"
+	"time"
+)
+
+// WarmEntry is a key and the value Warm stores at it
+type WarmEntry struct {
+	Key   string
+	Value interface{}
+}
+
+// WarmSource yields the entries Warm stores, ending the sequence with an
+// error if it cannot read them. See WarmFromSeq, WarmFromJSONLines and
+// WarmFromRows.
+type WarmSource interface {
+	Entries(ctx context.Context) iter.Seq2[WarmEntry, error]
+}
+
+type warmSourceFunc func(ctx context.Context) iter.Seq2[WarmEntry, error]
+
+func (f warmSourceFunc) Entries(ctx context.Context) iter.Seq2[WarmEntry, error] {
+	return f(ctx)
+}
+
+// WarmFromSeq warms the keys and values of seq, e.g. maps.All of a map
+func WarmFromSeq(seq iter.Seq2[string, interface{}]) WarmSource {
+	return warmSourceFunc(func(ctx context.Context) iter.Seq2[WarmEntry, error] {
+		return func(yield func(WarmEntry, error) bool) {
+			for key, value := range seq {
+				if !yield(WarmEntry{Key: key, Value: value}, nil) {
+					return
+				}
+			}
+		}
+	})
+}
+
+// WarmFromJSONLines warms the entries of r, one JSON object per line such as
+// {"key": "user:1", "value": "..."}. String values are stored as they are,
+// other values as their JSON text.
+func WarmFromJSONLines(r io.Reader) WarmSource {
+	return warmSourceFunc(func(ctx context.Context) iter.Seq2[WarmEntry, error] {
+		return func(yield func(WarmEntry, error) bool) {
+			scanner := bufio.NewScanner(r)
+			scanner.Buffer(nil, 64<<20)
+			for line := 1; scanner.Scan(); line++ {
+				if len(scanner.Bytes()) == 0 {
+					continue
+				}
+				var record struct {
+					Key   string          `json:"key"`
+					Value json.RawMessage `json:"value"`
+				}
+				if err := json.Unmarshal(scanner.Bytes(), &record); err != nil || record.Key == "" {
+					yield(WarmEntry{}, fmt.Errorf("warm line %d: not a key and a value", line))
+					return
+				}
+				entry := WarmEntry{Key: record.Key, Value: string(record.Value)}
+				var text string
+				if json.Unmarshal(record.Value, &text) == nil {
+					entry.Value = text
+				}
+				if !yield(entry, nil) {
+					return
+				}
+			}
+			if err := scanner.Err(); err != nil {
+				yield(WarmEntry{}, err)
+			}
+		}
+	})
+}
+
+// WarmFromRows warms the rows of a query selecting a key and a value column,
+// e.g. SELECT id, payload FROM products. rows are closed once read.
+func WarmFromRows(rows *sql.Rows) WarmSource {
+	return warmSourceFunc(func(ctx context.Context) iter.Seq2[WarmEntry, error] {
+		return func(yield func(WarmEntry, error) bool) {
+			defer rows.Close()
+			for rows.Next() {
+				var key string
+				var value []byte
+				if err := rows.Scan(&key, &value); err != nil {
+					yield(WarmEntry{}, err)
+					return
+				}
+				if !yield(WarmEntry{Key: key, Value: value}, nil) {
+					return
+				}
+			}
+			if err := rows.Err(); err != nil {
+				yield(WarmEntry{}, err)
+			}
+		}
+	})
+}
+
+// WarmProgress tells how far Warm got
+type WarmProgress struct {
+	Read    int64 // entries read from the source
+	Stored  int64 // entries stored in the cache
+	Elapsed time.Duration
+}
+
+// WarmOption configures Warm
+type WarmOption func(*warmOptions)
+
+type warmOptions struct {
+	ttl         time.Duration
+	batchSize   int
+	concurrency int
+	progress    func(WarmProgress)
+}
+
+// WithWarmTTL stores the warmed entries with ttl instead of the default
+// expiration
+func WithWarmTTL(ttl time.Duration) WarmOption {
+	return func(o *warmOptions) {
+		o.ttl = ttl
+	}
+}
+
+// WithWarmBatchSize stores size entries per SetMany round trip, 500 by
+// default
+func WithWarmBatchSize(size int) WarmOption {
+	return func(o *warmOptions) {
+		o.batchSize = size
+	}
+}
+
+// WithWarmConcurrency bounds the batches written at once, 4 by default
+func WithWarmConcurrency(concurrency int) WarmOption {
+	return func(o *warmOptions) {
+		o.concurrency = concurrency
+	}
+}
+
+// WithWarmProgress calls fn after every batch stored. Calls do not overlap.
+func WithWarmProgress(fn func(WarmProgress)) WarmOption {
+	return func(o *warmOptions) {
+		o.progress = fn
+	}
+}
+
+// Warm bulk loads the entries of source into the cache, e.g. to prime it
+// before a deploy takes traffic. Entries are stored with SetMany in batches,
+// several at once, with the default expiration unless WithWarmTTL is given.
+// Warm stops at the first error of the source or the backend and returns it
+// with the progress made so far; stored entries are not removed.
+func (c *cache) Warm(ctx context.Context, source WarmSource, opts ...WarmOption) (WarmProgress, error) {
+	o := &warmOptions{ttl: c.defaultTTL, batchSize: 500, concurrency: 4}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.ttl == NoDefaultTTL {
+		return WarmProgress{}, ErrNoDefaultTTL
+	}
+	o.batchSize, o.concurrency = max(o.batchSize, 1), max(o.concurrency, 1)
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	start := time.Now()
+	var mutex sync.Mutex
+	var progress WarmProgress
+	report := func(read, stored int64) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		progress.Read += read
+		progress.Stored += stored
+		progress.Elapsed = time.Since(start)
+		if stored > 0 && o.progress != nil {
+			o.progress(progress)
+		}
+	}
+
+	type batch struct {
+		values map[string]interface{}
+		count  int64
+	}
+	batches := make(chan batch)
+	var workers sync.WaitGroup
+	for range o.concurrency {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for b := range batches {
+				if err := c.SetManyWithTTL(ctx, b.values, o.ttl); err != nil {
+					cancel(err)
+					continue
+				}
+				report(0, b.count)
+			}
+		}()
+	}
+	send := func(b batch) bool {
+		select {
+		case batches <- b:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	next := batch{values: make(map[string]interface{}, o.batchSize)}
+	var sourceErr error
+	for entry, err := range source.Entries(ctx) {
+		if err != nil {
+			sourceErr = err
+			break
+		}
+		next.values[entry.Key] = entry.Value
+		next.count++
+		report(1, 0)
+		if next.count == int64(o.batchSize) {
+			if !send(next) {
+				break
+			}
+			next = batch{values: make(map[string]interface{}, o.batchSize)}
+		}
+	}
+	if sourceErr == nil && next.count > 0 {
+		send(next)
+	}
+	close(batches)
+	workers.Wait()
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	progress.Elapsed = time.Since(start)
+	if sourceErr != nil {
+		return progress, fmt.Errorf("warm source: %w", sourceErr)
+	}
+	if ctx.Err() != nil {
+		// The error of the failed batch, or of the caller's context
+		return progress, context.Cause(ctx)
+	}
+	return progress, nil
+}
@@ -0,0 +1,79 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"go.opentelemetry.io/otel/attribute"
+	"time"
+)
+
+// LoadFunc computes a value on a cache miss. It gets the context of the call
+// and its error is returned to the caller instead of being cached; returning
+// ErrNotFound caches the miss for the negative ttl. A loader shared by
+// concurrent callers keeps the values of the context but not its
+// cancellation, which would fail the other callers too.
+type LoadFunc func(ctx context.Context) (interface{}, error)
+
+// WrapContext behaves like Wrap with a loader that can fail or be canceled.
+// Errors of the loader are returned and nothing is stored for them.
+func (c *cache) WrapContext(ctx context.Context, key string, load LoadFunc) (interface{}, error) {
+	return c.WrapTTLContext(ctx, key, c.defaultTTL, load)
+}
+
+// WrapTTLContext behaves like WrapContext but stores computed values with the
+// given expiration. Concurrent callers of a key share one loader call, which
+// runs to completion for the others when the first is canceled; a caller
+// whose context is done stops waiting and returns its error.
+func (c *cache) WrapTTLContext(ctx context.Context, key string, ttl time.Duration, load LoadFunc) (result interface{}, err error) {
+	start := time.Now()
+	ctx, span := c.startSpan(ctx, "Wrap", attribute.String("cache.key", key))
+	defer func() { endSpan(span, start, err) }()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if c.xfetchBeta > 0 && ttl > 0 {
+		return c.wrapXFetch(ctx, key, ttl, load)
+	}
+
+	cachedValue, err := c.Get(ctx, key)
+	if errors.Is(err, ErrNotFound) || err == nil && cachedValue != nil {
+		span.SetAttributes(attribute.Bool("cache.hit", true))
+		return cachedValue, err
+	}
+	span.SetAttributes(attribute.Bool("cache.hit", false))
+	if c.filtered(ctx, key) {
+		return nil, ErrNotFound
+	}
+
+	shared := context.WithoutCancel(ctx)
+	results := c.contextLoads.DoChan(key, func() (interface{}, error) {
+		loadStart := time.Now()
+		result, err := load(shared)
+		c.loaded(key, time.Since(loadStart))
+		switch {
+		case isNotFound(err):
+			result = err
+		case err != nil:
+			return nil, err
+		}
+		// Like Wrap, the loaded value is returned even if storing it failed
+		_ = c.store(shared, key, result, ttl)
+		return result, nil
+	})
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case loaded := <-results:
+		if isNotFound(loaded.Val) {
+			return nil, ErrNotFound
+		}
+		return loaded.Val, loaded.Err
+	}
+}
+
+// RememberForeverContext behaves like WrapContext but stores computed values
+// without an expiration
+func (c *cache) RememberForeverContext(ctx context.Context, key string, load LoadFunc) (interface{}, error) {
+	return c.WrapTTLContext(ctx, key, Forever, load)
+}
@@ -0,0 +1,136 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrWriteBufferFull is returned by SetAsync when the write-behind buffer has
+// no room left, in which case the write is dropped
+var ErrWriteBufferFull = errors.New("write-behind buffer is full")
+
+// ErrClosed is returned when using a cache after Close
+var ErrClosed = errors.New("cache is closed")
+
+// writeBatchSize is the most writes a worker sends in one round trip
+const writeBatchSize = 100
+
+type pendingWrite struct {
+	key   string
+	value interface{}
+	ttl   time.Duration
+}
+
+// writeBehind buffers SetAsync writes and stores them in batches from
+// background workers
+type writeBehind struct {
+	cache   *cache
+	workers int
+	writes  chan pendingWrite
+	mutex   sync.RWMutex
+	closed  bool
+	start   sync.Once
+	running sync.WaitGroup
+}
+
+func newWriteBehind(c *cache, o *options) *writeBehind {
+	return &writeBehind{
+		cache:   c,
+		workers: max(o.writeWorkers, 1),
+		writes:  make(chan pendingWrite, max(o.writeBuffer, 1)),
+	}
+}
+
+func (w *writeBehind) enqueue(write pendingWrite) error {
+	w.start.Do(w.run)
+
+	w.mutex.RLock()
+	defer w.mutex.RUnlock()
+	if w.closed {
+		return ErrClosed
+	}
+
+	select {
+	case w.writes <- write:
+		return nil
+	default:
+		return ErrWriteBufferFull
+	}
+}
+
+func (w *writeBehind) run() {
+	w.running.Add(w.workers)
+	for range w.workers {
+		go w.worker()
+	}
+}
+
+// worker takes whatever is buffered, up to writeBatchSize writes, and stores
+// the last write of every key with one SetMany per ttl
+func (w *writeBehind) worker() {
+	defer w.running.Done()
+	for write := range w.writes {
+		latest := map[string]pendingWrite{write.key: write}
+	collect:
+		for n := 1; n < writeBatchSize; n++ {
+			select {
+			case write, ok := <-w.writes:
+				if !ok {
+					break collect
+				}
+				latest[write.key] = write
+			default:
+				break collect
+			}
+		}
+
+		batch := map[time.Duration]map[string]interface{}{}
+		for key, write := range latest {
+			if batch[write.ttl] == nil {
+				batch[write.ttl] = map[string]interface{}{}
+			}
+			batch[write.ttl][key] = write.value
+		}
+		for ttl, values := range batch {
+			_ = w.cache.SetManyWithTTL(context.Background(), values, ttl)
+		}
+	}
+}
+
+// close stores every buffered write and stops the workers, waiting for them
+// until ctx is done
+func (w *writeBehind) close(ctx context.Context) error {
+	w.mutex.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.writes)
+	}
+	w.mutex.Unlock()
+
+	w.start.Do(w.run)
+	done := make(chan struct{})
+	go func() {
+		w.running.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// SetAsync queues a write and returns immediately. Background workers store
+// queued writes in batches, so a later Get may still miss and a failed write
+// is dropped. It returns ErrWriteBufferFull when the buffer configured with
+// WithWriteBehind is full. Close stores all queued writes.
+func (c *cache) SetAsync(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	if skip, err := c.oversized(ctx, key, value); skip {
+		return err
+	}
+	return c.writeBehind.enqueue(pendingWrite{key: key, value: value, ttl: ttl})
+}
@@ -0,0 +1,86 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// xfetchKey holds how long the value at key took to compute and when it
+// expires, next to the value itself so its format stays unchanged
+func xfetchKey(key string) string {
+	return key + ":xfetch"
+}
+
+// wrapXFetch implements WrapTTL with probabilistic early expiration (XFetch):
+// every hit recomputes the value with a probability that grows as it
+// approaches expiry and with how long the loader took, so expensive values are
+// usually refreshed by a single caller before they expire.
+// Errors of load are returned and not cached, except ErrNotFound.
+func (c *cache) wrapXFetch(ctx context.Context, key string, ttl time.Duration, load LoadFunc) (interface{}, error) {
+	start := time.Now()
+	values, err := c.Cache.GetMany(ctx, key, xfetchKey(key))
+	cached, found := values[key]
+	switch {
+	case err != nil:
+		// The value is computed again like on a miss
+		_ = c.recordFailure(ctx, key, start, err)
+	case !found:
+		c.recordMiss(ctx, key, start)
+	default:
+		c.recordHit(ctx, key, start, cached)
+		if cached == tombstone {
+			return nil, ErrNotFound
+		}
+		delta, expiry, ok := parseXFetch(values[xfetchKey(key)])
+		if !ok || !c.recomputeEarly(delta, expiry) {
+			return cached, nil
+		}
+	}
+	if !found && c.filtered(ctx, key) {
+		return nil, ErrNotFound
+	}
+
+	result, err, _ := c.loads.Do(key, func() (interface{}, error) {
+		loadStart := time.Now()
+		result, err := load(ctx)
+		delta := time.Since(loadStart)
+		c.loaded(key, delta)
+		switch {
+		case isNotFound(err):
+			result = err
+		case err != nil:
+			return nil, err
+		}
+
+		if err := c.store(ctx, key, result, ttl); err == nil && !isNotFound(result) {
+			meta := fmt.Sprintf("%d %d", delta, time.Now().Add(ttl).UnixNano())
+			_ = c.Cache.Set(ctx, xfetchKey(key), meta, ttl)
+		}
+		return result, nil
+	})
+	if isNotFound(result) {
+		return nil, ErrNotFound
+	}
+	return result, err
+}
+
+// recomputeEarly decides whether a hit recomputes the value ahead of expiry
+func (c *cache) recomputeEarly(delta time.Duration, expiry time.Time) bool {
+	gap := float64(delta) * c.xfetchBeta * -math.Log(1-rand.Float64())
+	return !time.Now().Add(time.Duration(gap)).Before(expiry)
+}
+
+func parseXFetch(meta interface{}) (time.Duration, time.Time, bool) {
+	text, ok := meta.(string)
+	if !ok {
+		return 0, time.Time{}, false
+	}
+	var delta, expiry int64
+	if _, err := fmt.Sscanf(text, "%d %d", &delta, &expiry); err != nil {
+		return 0, time.Time{}, false
+	}
+	return time.Duration(delta), time.Unix(0, expiry), true
+}
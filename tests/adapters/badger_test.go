@@ -0,0 +1,62 @@
+package adapters
+
+import (
+	"cacher/internal/adapters"
+	"context"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"testing"
+	"time"
+)
+
+func openBadger(t *testing.T, dir string) *adapters.Memory {
+	t.Helper()
+	server, err := adapters.OpenBadger(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return server
+}
+
+func TestBadgerPersistence(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	server := openBadger(t, dir)
+	_ = server.MSet(ctx, map[string]interface{}{"a": "1", "b": 2}, 0)
+	_, _ = server.SAdd(ctx, "set", "x")
+	if err := server.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	server = openBadger(t, dir)
+	defer server.Close()
+	values, err := server.MGet(ctx, "a", "b", "missing")
+	if err != nil || values[0] != "1" || values[1] != "2" || values[2] != nil {
+		t.Errorf("want the batch after reopening, got %v (%v)", values, err)
+	}
+	if members, _ := server.SMembers(ctx, "set"); len(members) != 1 {
+		t.Errorf("want 1 member, got %v", members)
+	}
+}
+
+func TestBadgerExpiration(t *testing.T) {
+	server := openBadger(t, t.TempDir())
+	defer server.Close()
+	ctx := context.Background()
+
+	_ = server.Set(ctx, "short", "value", 20*time.Millisecond)
+	_ = server.MSet(ctx, map[string]interface{}{"batch": "value"}, 20*time.Millisecond)
+	time.Sleep(40 * time.Millisecond)
+	for _, key := range []string{"short", "batch"} {
+		if _, err := server.Get(ctx, key); !errors.Is(err, redis.Nil) {
+			t.Errorf("want %s expired, got %v", key, err)
+		}
+	}
+}
+
+func TestBadgerSemaphore(t *testing.T) {
+	server := openBadger(t, t.TempDir())
+	defer server.Close()
+	testSemaphore(t, server)
+}
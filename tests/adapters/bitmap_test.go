@@ -0,0 +1,121 @@
+package adapters
+
+import (
+	"cacher/internal/adapters"
+	"context"
+	"errors"
+	"testing"
+)
+
+func testBitmap(t *testing.T, server adapters.CacheServer) {
+	ctx := context.Background()
+	a, b, dest := "bits:"+t.Name()+":a", "bits:"+t.Name()+":b", "bits:"+t.Name()+":dest"
+	_, _ = server.Delete(ctx, a, b, dest)
+
+	if previous, err := server.SetBit(ctx, a, 7, 1); err != nil || previous != 0 {
+		t.Fatalf("want the previous bit 0, got %v (%v)", previous, err)
+	}
+	if previous, _ := server.SetBit(ctx, a, 7, 1); previous != 1 {
+		t.Errorf("want the previous bit 1, got %d", previous)
+	}
+	// Bits count from the most significant bit of the first byte
+	if value, _ := server.Get(ctx, a); value != "\x01" {
+		t.Errorf("want byte 0x01, got %q", value)
+	}
+	_, _ = server.SetBit(ctx, a, 100, 1)
+	if bit, _ := server.GetBit(ctx, a, 100); bit != 1 {
+		t.Errorf("want bit 100 set, got %d", bit)
+	}
+	if bit, _ := server.GetBit(ctx, a, 5000); bit != 0 {
+		t.Errorf("want 0 past the end, got %d", bit)
+	}
+	if count, err := server.BitCount(ctx, a); err != nil || count != 2 {
+		t.Errorf("want 2 bits set, got %v (%v)", count, err)
+	}
+
+	_, _ = server.SetBit(ctx, b, 7, 1)
+	_, _ = server.SetBit(ctx, b, 8, 1)
+	if size, err := server.BitOp(ctx, "AND", dest, a, b); err != nil || size != 13 {
+		t.Errorf("want the length of the longest source, got %v (%v)", size, err)
+	}
+	if count, _ := server.BitCount(ctx, dest); count != 1 {
+		t.Errorf("want 1 bit in the AND, got %d", count)
+	}
+	_, _ = server.BitOp(ctx, "OR", dest, a, b)
+	if count, _ := server.BitCount(ctx, dest); count != 3 {
+		t.Errorf("want 3 bits in the OR, got %d", count)
+	}
+	_, _ = server.BitOp(ctx, "XOR", dest, a, b)
+	if count, _ := server.BitCount(ctx, dest); count != 2 {
+		t.Errorf("want 2 bits in the XOR, got %d", count)
+	}
+	_, _ = server.BitOp(ctx, "NOT", dest, b)
+	if count, _ := server.BitCount(ctx, dest); count != 14 {
+		t.Errorf("want 14 bits in the NOT, got %d", count)
+	}
+	if _, err := server.BitOp(ctx, "NOT", dest, a, b); err == nil {
+		t.Error("want NOT over two keys to fail")
+	}
+
+	if count, _ := server.BitCount(ctx, "bits:missing"); count != 0 {
+		t.Errorf("want 0 for a missing key, got %d", count)
+	}
+}
+
+func TestMemoryBitmap(t *testing.T) {
+	testBitmap(t, adapters.NewMemory())
+
+	m := adapters.NewMemory()
+	ctx := context.Background()
+	_ = m.Push(ctx, "list", "x")
+	if _, err := m.SetBit(ctx, "list", 1, 1); !errors.Is(err, adapters.ErrWrongType) {
+		t.Errorf("want ErrWrongType setting a bit of a list, got %v", err)
+	}
+	if _, err := m.BitOp(ctx, "NAND", "dest", "a"); !errors.Is(err, adapters.ErrBitOp) {
+		t.Errorf("want ErrBitOp, got %v", err)
+	}
+	_ = m.Set(ctx, "dest", "x", 0)
+	if _, err := m.BitOp(ctx, "OR", "dest", "missing"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Get(ctx, "dest"); err == nil {
+		t.Error("want an empty result to delete dest")
+	}
+}
+
+func TestRedisBitmap(t *testing.T) {
+	testBitmap(t, &adapters.RedisClient{Client: redisOrSkip(t)})
+}
+
+func testBits(t *testing.T, server adapters.CacheServer) {
+	ctx := context.Background()
+	key := "bits:" + t.Name()
+	_, _ = server.Delete(ctx, key)
+
+	if found, err := server.TestBits(ctx, key, 3, 900); err != nil || found {
+		t.Errorf("want no bits in a missing key, got %v (%v)", found, err)
+	}
+	if set, err := server.SetBits(ctx, key, 3, 900, 3); err != nil || set != 2 {
+		t.Errorf("want 2 bits newly set, got %v (%v)", set, err)
+	}
+	if set, _ := server.SetBits(ctx, key, 3, 4); set != 1 {
+		t.Errorf("want 1 bit newly set, got %d", set)
+	}
+	if found, _ := server.TestBits(ctx, key, 3, 4, 900); !found {
+		t.Error("want every bit set")
+	}
+	if found, _ := server.TestBits(ctx, key, 3, 5); found {
+		t.Error("want bit 5 unset")
+	}
+	if count, _ := server.BitCount(ctx, key); count != 3 {
+		t.Errorf("want 3 bits set, got %d", count)
+	}
+}
+
+func TestMemoryBits(t *testing.T) {
+	testBits(t, adapters.NewMemory())
+}
+
+func TestRedisBits(t *testing.T) {
+	testBits(t, &adapters.RedisClient{Client: redisOrSkip(t)})
+}
@@ -0,0 +1,92 @@
+package adapters
+
+import (
+	"cacher/internal/adapters"
+	"context"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func openBolt(t *testing.T, path string, opts ...adapters.MemoryOption) *adapters.Memory {
+	t.Helper()
+	server, err := adapters.OpenBolt(path, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return server
+}
+
+func TestBoltPersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.db")
+	ctx := context.Background()
+
+	server := openBolt(t, path)
+	_ = server.Set(ctx, "key", "value", 0)
+	_ = server.Push(ctx, "list", "a", "b")
+	_, _ = server.SAdd(ctx, "set", "x", "y")
+	_ = server.HIncrByMany(ctx, "hash", map[string]int64{"field": 3})
+	_, _ = server.Incr(ctx, "counter")
+	if err := server.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	server = openBolt(t, path)
+	defer server.Close()
+	if v, err := server.Get(ctx, "key"); err != nil || v != "value" {
+		t.Errorf("want value after reopening, got %q (%v)", v, err)
+	}
+	if list, _ := server.List(ctx, "list"); len(list) != 2 || list[0] != "b" {
+		t.Errorf("want [b a], got %v", list)
+	}
+	if members, _ := server.SMembers(ctx, "set"); len(members) != 2 {
+		t.Errorf("want 2 members, got %v", members)
+	}
+	if hash, _ := server.HGetAll(ctx, "hash"); hash["field"] != "3" {
+		t.Errorf("want field 3, got %v", hash)
+	}
+	if v, _ := server.Incr(ctx, "counter"); v != 2 {
+		t.Errorf("want 2, got %d", v)
+	}
+	if deleted, _ := server.Delete(ctx, "key", "missing"); deleted != 1 {
+		t.Errorf("want 1 deleted key, got %d", deleted)
+	}
+	if keys, _, _ := server.Scan(ctx, 0, "*", 0); len(keys) != 4 {
+		t.Errorf("want 4 keys left, got %v", keys)
+	}
+}
+
+func TestBoltExpiration(t *testing.T) {
+	server := openBolt(t, filepath.Join(t.TempDir(), "cache.db"), adapters.WithCleanupInterval(10*time.Millisecond))
+	defer server.Close()
+	ctx := context.Background()
+
+	_ = server.Set(ctx, "short", "value", 20*time.Millisecond)
+	_ = server.Set(ctx, "long", "value", time.Hour)
+	if ttl, err := server.TTL(ctx, "long"); err != nil || ttl <= 0 {
+		t.Errorf("want a positive ttl, got %v (%v)", ttl, err)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if _, err := server.Get(ctx, "short"); !errors.Is(err, redis.Nil) {
+		t.Errorf("want the expired key gone, got %v", err)
+	}
+	keys, _, _ := server.Scan(ctx, 0, "", 0)
+	if len(keys) != 1 || keys[0] != "long" {
+		t.Errorf("want only long left, got %v", keys)
+	}
+}
+
+func TestBoltTokenBucket(t *testing.T) {
+	server := openBolt(t, filepath.Join(t.TempDir(), "cache.db"))
+	defer server.Close()
+	testTokenBucket(t, server)
+}
+
+func TestBoltSemaphore(t *testing.T) {
+	server := openBolt(t, filepath.Join(t.TempDir(), "cache.db"))
+	defer server.Close()
+	testSemaphore(t, server)
+}
@@ -0,0 +1,86 @@
+package adapters
+
+import (
+	"cacher/internal/adapters"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// flaky is a Cache that fails every call while down is set
+type flaky struct {
+	adapters.Cache
+	down bool
+}
+
+var errDown = errors.New("backend down")
+
+func (f *flaky) Get(ctx context.Context, key string) (interface{}, error) {
+	if f.down {
+		return nil, errDown
+	}
+	return f.Cache.Get(ctx, key)
+}
+
+func (f *flaky) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	if f.down {
+		return errDown
+	}
+	return f.Cache.Set(ctx, key, value, expiration)
+}
+
+func TestBreakerTripsAndRecovers(t *testing.T) {
+	backend := &flaky{Cache: adapters.NewCache(adapters.NewMemory())}
+	breaker := adapters.NewBreaker(backend, adapters.NewMemory(), 2, 20*time.Millisecond)
+	ctx := context.Background()
+
+	_ = backend.Set(ctx, "key", "old", 0)
+	backend.down = true
+	for range 2 {
+		if _, err := breaker.Get(ctx, "key"); !errors.Is(err, errDown) {
+			t.Fatalf("want backend error, got %v", err)
+		}
+	}
+	if breaker.State() != adapters.BreakerOpen {
+		t.Fatalf("want open breaker, got %s", breaker.State())
+	}
+
+	// While open the fallback serves requests
+	if err := breaker.Set(ctx, "key", "new", 0); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := breaker.Get(ctx, "key"); err != nil || v != "new" {
+		t.Errorf("want new from the fallback, got %v (%v)", v, err)
+	}
+
+	backend.down = false
+	time.Sleep(30 * time.Millisecond)
+	if v, err := breaker.Get(ctx, "key"); err != nil || v != "old" {
+		t.Fatalf("want probe to reach the backend, got %v (%v)", v, err)
+	}
+	if breaker.State() != adapters.BreakerClosed {
+		t.Fatalf("want closed breaker, got %s", breaker.State())
+	}
+
+	// The key written during the outage is not served stale
+	if _, err := breaker.Get(ctx, "key"); err == nil {
+		t.Errorf("want key changed during the outage to be dropped")
+	}
+
+	stats := breaker.BreakerStatistics()
+	if stats["trips"] != 1 || stats["rejected"] != 2 || stats["open"] != 0 {
+		t.Errorf("unexpected statistics %v", stats)
+	}
+}
+
+func TestBreakerWithoutFallback(t *testing.T) {
+	backend := &flaky{Cache: adapters.NewCache(adapters.NewMemory()), down: true}
+	breaker := adapters.NewBreaker(backend, nil, 1, time.Minute)
+	ctx := context.Background()
+
+	_, _ = breaker.Get(ctx, "key")
+	if _, err := breaker.Get(ctx, "key"); !errors.Is(err, adapters.ErrCircuitOpen) {
+		t.Errorf("want ErrCircuitOpen, got %v", err)
+	}
+}
@@ -0,0 +1,46 @@
+package adapters
+
+import (
+	"cacher/internal/adapters"
+	"context"
+	"github.com/redis/go-redis/v9"
+	"testing"
+	"time"
+)
+
+func testCompareAndSwap(t *testing.T, server adapters.CacheServer) {
+	ctx := context.Background()
+	key := "cas:" + t.Name()
+	_, _ = server.Delete(ctx, key)
+
+	if ok, err := server.CompareAndSwap(ctx, key, "", "1", 0); err != nil || ok {
+		t.Errorf("want a missing key not swapped, got %v (%v)", ok, err)
+	}
+	_ = server.Set(ctx, key, "1", time.Minute)
+	if ok, _ := server.CompareAndSwap(ctx, key, "2", "3", 0); ok {
+		t.Error("want a swap from the wrong value rejected")
+	}
+	if ok, err := server.CompareAndSwap(ctx, key, "1", "2", redis.KeepTTL); err != nil || !ok {
+		t.Fatalf("want the swap to succeed, got %v (%v)", ok, err)
+	}
+	if v, _ := server.Get(ctx, key); v != "2" {
+		t.Errorf("want 2, got %v", v)
+	}
+	if ttl, _ := server.TTL(ctx, key); ttl <= 0 || ttl > time.Minute {
+		t.Errorf("want the expiration kept, got %v", ttl)
+	}
+	if ok, _ := server.CompareAndSwap(ctx, key, "2", "3", 0); !ok {
+		t.Fatal("want the swap to succeed")
+	}
+	if ttl, _ := server.TTL(ctx, key); ttl >= 0 {
+		t.Errorf("want the expiration cleared, got %v", ttl)
+	}
+}
+
+func TestMemoryCompareAndSwap(t *testing.T) {
+	testCompareAndSwap(t, adapters.NewMemory())
+}
+
+func TestRedisCompareAndSwap(t *testing.T) {
+	testCompareAndSwap(t, &adapters.RedisClient{Client: redisOrSkip(t)})
+}
@@ -0,0 +1,54 @@
+package adapters
+
+import (
+	"cacher/internal/adapters"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestCompressingRoundTrip(t *testing.T) {
+	large := strings.Repeat(`{"name":"arash","tags":["a","b"]}`, 100)
+	for _, compression := range []adapters.Compression{adapters.Gzip, adapters.Snappy, adapters.Zstd} {
+		t.Run(compression.String(), func(t *testing.T) {
+			server := adapters.NewMemory()
+			c := adapters.NewCompressing(adapters.NewCache(server), compression, 64)
+			ctx := context.Background()
+
+			_ = c.Set(ctx, "large", large, 0)
+			_ = c.Set(ctx, "small", "value", 0)
+
+			stored, _ := server.Get(ctx, "large")
+			if len(stored) >= len(large) {
+				t.Errorf("want the large value stored compressed, got %d bytes", len(stored))
+			}
+			if stored, _ := server.Get(ctx, "small"); stored != "value" {
+				t.Errorf("want values under the threshold stored as is, got %q", stored)
+			}
+
+			if v, err := c.Get(ctx, "large"); err != nil || v != large {
+				t.Errorf("want the original value back, got %d bytes (%v)", len(v.(string)), err)
+			}
+			values, err := c.GetMany(ctx, "large", "small")
+			if err != nil || values["large"] != large || values["small"] != "value" {
+				t.Errorf("want both values back, got %v", err)
+			}
+		})
+	}
+}
+
+func TestDecompressWithoutCompression(t *testing.T) {
+	large := strings.Repeat("x", 1000)
+	compressed, err := adapters.Compress(large, adapters.Zstd, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A reader configured without compression still understands the header
+	c := adapters.NewCompressing(adapters.NewCache(adapters.NewMemory()), adapters.NoCompression, 0)
+	ctx := context.Background()
+	_ = c.Cache.Set(ctx, "key", compressed, 0)
+	if v, err := c.Get(ctx, "key"); err != nil || v != large {
+		t.Errorf("want the original value back, got %v", err)
+	}
+}
@@ -0,0 +1,321 @@
+package adapters
+
+import (
+	"cacher/internal/adapters"
+	"context"
+	"errors"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/redis/go-redis/v9"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeDynamoDB is an in-memory table honouring the conditions DynamoStore
+// writes with, returning at most two items per Scan page
+type fakeDynamoDB struct {
+	mutex     sync.Mutex
+	items     map[string]map[string]types.AttributeValue
+	conflicts int
+}
+
+func newFakeDynamoDB() *fakeDynamoDB {
+	return &fakeDynamoDB{items: make(map[string]map[string]types.AttributeValue)}
+}
+
+func (f *fakeDynamoDB) check(key string, condition *string, values map[string]types.AttributeValue) error {
+	current, exists := f.items[key]
+	ok := true
+	switch aws.ToString(condition) {
+	case "attribute_not_exists(#key)":
+		ok = !exists
+	case "#version = :version":
+		ok = exists && current["version"].(*types.AttributeValueMemberN).Value == values[":version"].(*types.AttributeValueMemberN).Value
+	case "attribute_exists(#key) AND attribute_not_exists(#version)":
+		_, versioned := current["version"]
+		ok = exists && !versioned
+	case "attribute_exists(#count) AND (attribute_not_exists(#expires_ms) OR #expires_ms > :now)":
+		_, counter := current["count"]
+		expiresAt, expiring := current["expires_ms"]
+		ok = exists && counter && (!expiring || number(expiresAt) > number(values[":now"]))
+	case "attribute_not_exists(#key) OR #expires_ms <= :now":
+		expiresAt, expiring := current["expires_ms"]
+		ok = !exists || expiring && number(expiresAt) <= number(values[":now"])
+	}
+	if !ok {
+		f.conflicts++
+		return &types.ConditionalCheckFailedException{Item: current}
+	}
+	return nil
+}
+
+func number(value types.AttributeValue) int64 {
+	n, _ := strconv.ParseInt(value.(*types.AttributeValueMemberN).Value, 10, 64)
+	return n
+}
+
+func (f *fakeDynamoDB) UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	key := params.Key["key"].(*types.AttributeValueMemberS).Value
+	if err := f.check(key, params.ConditionExpression, params.ExpressionAttributeValues); err != nil {
+		return nil, err
+	}
+	values := params.ExpressionAttributeValues
+	current := f.items[key]
+	version := int64(1)
+	if v, ok := current["version"]; ok {
+		version += number(v)
+	}
+	item := map[string]types.AttributeValue{"key": params.Key["key"], "version": &types.AttributeValueMemberN{Value: strconv.FormatInt(version, 10)}}
+	if strings.HasPrefix(aws.ToString(params.UpdateExpression), "ADD") {
+		for name, value := range current {
+			if name != "version" {
+				item[name] = value
+			}
+		}
+		item["count"] = &types.AttributeValueMemberN{Value: strconv.FormatInt(number(current["count"])+number(values[":delta"]), 10)}
+	} else {
+		item["count"] = values[":delta"]
+		if expiresAt, ok := values[":expires_ms"]; ok {
+			item["expires_at"], item["expires_ms"] = values[":expires_at"], expiresAt
+		}
+	}
+	f.items[key] = item
+	return &dynamodb.UpdateItemOutput{Attributes: map[string]types.AttributeValue{"count": item["count"], "version": item["version"]}}, nil
+}
+
+func (f *fakeDynamoDB) GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return &dynamodb.GetItemOutput{Item: f.items[params.Key["key"].(*types.AttributeValueMemberS).Value]}, nil
+}
+
+func (f *fakeDynamoDB) PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	key := params.Item["key"].(*types.AttributeValueMemberS).Value
+	if err := f.check(key, params.ConditionExpression, params.ExpressionAttributeValues); err != nil {
+		return nil, err
+	}
+	f.items[key] = params.Item
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func (f *fakeDynamoDB) DeleteItem(ctx context.Context, params *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	key := params.Key["key"].(*types.AttributeValueMemberS).Value
+	if err := f.check(key, params.ConditionExpression, params.ExpressionAttributeValues); err != nil {
+		return nil, err
+	}
+	delete(f.items, key)
+	return &dynamodb.DeleteItemOutput{}, nil
+}
+
+func (f *fakeDynamoDB) Scan(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	keys := make([]string, 0, len(f.items))
+	for key := range f.items {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	start := 0
+	if params.ExclusiveStartKey != nil {
+		last := params.ExclusiveStartKey["key"].(*types.AttributeValueMemberS).Value
+		start = sort.SearchStrings(keys, last) + 1
+	}
+	out := &dynamodb.ScanOutput{}
+	for i := start; i < len(keys) && len(out.Items) < 2; i++ {
+		out.Items = append(out.Items, f.items[keys[i]])
+	}
+	if start+2 < len(keys) {
+		out.LastEvaluatedKey = map[string]types.AttributeValue{"key": f.items[keys[start+1]]["key"]}
+	}
+	return out, nil
+}
+
+func openDynamoDB(t *testing.T) (*adapters.Memory, *fakeDynamoDB) {
+	t.Helper()
+	client := newFakeDynamoDB()
+	server := adapters.NewDynamoDB(client, "cache")
+	t.Cleanup(func() { _ = server.Close() })
+	return server, client
+}
+
+func TestDynamoDBSetGet(t *testing.T) {
+	server, client := openDynamoDB(t)
+	ctx := context.Background()
+
+	_ = server.Set(ctx, "key", "value", time.Hour)
+	if v, err := server.Get(ctx, "key"); err != nil || v != "value" {
+		t.Errorf("want value, got %q (%v)", v, err)
+	}
+	ttl, ok := client.items["key"]["expires_at"].(*types.AttributeValueMemberN)
+	if !ok {
+		t.Fatal("want the expires_at attribute set")
+	}
+	if seconds, _ := strconv.ParseInt(ttl.Value, 10, 64); seconds < time.Now().Add(time.Hour).Unix() {
+		t.Errorf("want expires_at an hour from now, got %d", seconds)
+	}
+	_ = server.Set(ctx, "forever", "value", 0)
+	if _, ok := client.items["forever"]["expires_at"]; ok {
+		t.Error("want no expires_at for a key without expiration")
+	}
+	if deleted, _ := server.Delete(ctx, "key", "forever"); deleted != 2 {
+		t.Errorf("want 2 deleted keys, got %d", deleted)
+	}
+}
+
+func TestDynamoDBCounters(t *testing.T) {
+	server, client := openDynamoDB(t)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = server.IncrBy(ctx, "counter", 1, 0)
+		}()
+	}
+	wg.Wait()
+	if v, _ := server.Get(ctx, "counter"); v != "20" {
+		t.Errorf("want 20, got %q", v)
+	}
+	if v, _ := server.DecrBy(ctx, "counter", 5); v != 15 {
+		t.Errorf("want 15, got %d", v)
+	}
+	if count, ok := client.items["counter"]["count"].(*types.AttributeValueMemberN); !ok || count.Value != "15" {
+		t.Errorf("want the counter kept as a number attribute, got %v", client.items["counter"])
+	}
+	t.Logf("%d conflicting writes retried", client.conflicts)
+
+	// Counters can be replaced by values and values counted transactionally
+	_ = server.Set(ctx, "counter", "100", 0)
+	if v, err := server.Incr(ctx, "counter"); err != nil || v != 101 {
+		t.Errorf("want 101 from a number stored as a value, got %d (%v)", v, err)
+	}
+	if _, err := server.Incr(ctx, "text"); err != nil {
+		t.Fatal(err)
+	}
+	_ = server.Set(ctx, "text", "value", 0)
+	if _, err := server.Incr(ctx, "text"); !errors.Is(err, adapters.ErrNotInteger) {
+		t.Errorf("want ErrNotInteger, got %v", err)
+	}
+}
+
+func TestDynamoDBCounterExpiration(t *testing.T) {
+	server, _ := openDynamoDB(t)
+	ctx := context.Background()
+
+	if v, err := server.IncrBy(ctx, "window", 3, 30*time.Millisecond); err != nil || v != 3 {
+		t.Fatalf("want 3, got %d (%v)", v, err)
+	}
+	if ttl, _ := server.TTL(ctx, "window"); ttl <= 0 || ttl > 30*time.Millisecond {
+		t.Errorf("want the counter to expire, got %s", ttl)
+	}
+	time.Sleep(40 * time.Millisecond)
+	// DynamoDB deletes the item late, the counter starts over regardless
+	if v, err := server.IncrBy(ctx, "window", 1, time.Minute); err != nil || v != 1 {
+		t.Errorf("want the expired counter replaced, got %d (%v)", v, err)
+	}
+}
+
+func TestDynamoDBUnversionedItem(t *testing.T) {
+	server, client := openDynamoDB(t)
+	ctx := context.Background()
+
+	_ = server.Set(ctx, "key", "value", 0)
+	// An item written by another tool carries no version
+	delete(client.items["key"], "version")
+	if err := server.Set(ctx, "key", "updated", 0); err != nil {
+		t.Fatalf("want an item without a version updated, got %v", err)
+	}
+	if v, err := server.Get(ctx, "key"); err != nil || v != "updated" {
+		t.Errorf("want updated, got %q (%v)", v, err)
+	}
+}
+
+// conflictingDynamoDB fails every conditional write
+type conflictingDynamoDB struct {
+	*fakeDynamoDB
+}
+
+func (c conflictingDynamoDB) PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.conflicts++
+	return nil, &types.ConditionalCheckFailedException{}
+}
+
+func TestDynamoDBConflictsBounded(t *testing.T) {
+	client := conflictingDynamoDB{newFakeDynamoDB()}
+	server := adapters.NewDynamoDB(client, "cache")
+	defer server.Close()
+
+	if err := server.Set(context.Background(), "key", "value", 0); !errors.Is(err, adapters.ErrStoreConflict) {
+		t.Errorf("want ErrStoreConflict, got %v", err)
+	}
+	if client.conflicts != 32 {
+		t.Errorf("want 32 attempts, got %d", client.conflicts)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	if err := server.Set(ctx, "key", "value", 0); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("want the retries to stop with the context, got %v", err)
+	}
+}
+
+func TestDynamoDBSetNX(t *testing.T) {
+	server, _ := openDynamoDB(t)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	won := 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if ok, err := server.SetNX(ctx, "lock", "holder", time.Minute); err == nil && ok {
+				mutex.Lock()
+				won++
+				mutex.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if won != 1 {
+		t.Errorf("want exactly one SetNX to win, got %d", won)
+	}
+}
+
+func TestDynamoDBExpiredItemsHidden(t *testing.T) {
+	server, _ := openDynamoDB(t)
+	ctx := context.Background()
+
+	_ = server.Set(ctx, "short", "value", 20*time.Millisecond)
+	_ = server.Set(ctx, "a", "value", 0)
+	_ = server.Set(ctx, "b", "value", 0)
+	_ = server.Set(ctx, "c", "value", 0)
+	time.Sleep(40 * time.Millisecond)
+
+	// DynamoDB deletes expired items late, until then they are skipped
+	if _, err := server.Get(ctx, "short"); !errors.Is(err, redis.Nil) {
+		t.Errorf("want the key expired, got %v", err)
+	}
+	keys, _, err := server.Scan(ctx, 0, "*", 0)
+	sort.Strings(keys)
+	if err != nil || len(keys) != 3 || keys[0] != "a" || keys[2] != "c" {
+		t.Errorf("want [a b c] across scan pages, got %v (%v)", keys, err)
+	}
+}
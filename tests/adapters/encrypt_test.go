@@ -0,0 +1,69 @@
+package adapters
+
+import (
+	"bytes"
+	"cacher/internal/adapters"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestEncryptingRoundTrip(t *testing.T) {
+	keys, err := adapters.NewKeyring(bytes.Repeat([]byte{1}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := adapters.NewMemory()
+	c := adapters.NewEncrypting(adapters.NewCache(server), keys)
+	ctx := context.Background()
+
+	_ = c.Set(ctx, "email", "arash@example.com", 0)
+	_ = c.SetMany(ctx, map[string]interface{}{"count": 42}, 0)
+
+	stored, _ := server.Get(ctx, "email")
+	if strings.Contains(stored, "arash") {
+		t.Errorf("want the value stored encrypted, got %q", stored)
+	}
+	if v, err := c.Get(ctx, "email"); err != nil || v != "arash@example.com" {
+		t.Errorf("want the plaintext back, got %v (%v)", v, err)
+	}
+	values, err := c.GetMany(ctx, "email", "count")
+	if err != nil || values["count"] != "42" {
+		t.Errorf("want every value decrypted, got %v (%v)", values, err)
+	}
+
+	// A sealed value copied to another key is rejected
+	_ = server.Set(ctx, "other", stored, 0)
+	if _, err := c.Get(ctx, "other"); !errors.Is(err, adapters.ErrDecrypt) {
+		t.Errorf("want ErrDecrypt for a moved value, got %v", err)
+	}
+}
+
+func TestKeyringRotation(t *testing.T) {
+	oldKey, newKey := bytes.Repeat([]byte{1}, 16), bytes.Repeat([]byte{2}, 16)
+	before, _ := adapters.NewKeyring(oldKey)
+	after, _ := adapters.NewKeyring(newKey, oldKey)
+	onlyNew, _ := adapters.NewKeyring(newKey)
+
+	sealed, err := before.Encrypt("key", "value")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, err := after.Decrypt("key", sealed); err != nil || v != "value" {
+		t.Errorf("want values sealed with the previous key readable, got %q (%v)", v, err)
+	}
+	if _, err := onlyNew.Decrypt("key", sealed); !errors.Is(err, adapters.ErrUnknownKey) {
+		t.Errorf("want ErrUnknownKey once the old key is dropped, got %v", err)
+	}
+	if _, err := after.Decrypt("key", "value"); !errors.Is(err, adapters.ErrNotEncrypted) {
+		t.Errorf("want ErrNotEncrypted for a plaintext value, got %v", err)
+	}
+	after.AllowPlaintext = true
+	if v, err := after.Decrypt("key", "value"); err != nil || v != "value" {
+		t.Errorf("want plaintext returned with AllowPlaintext, got %q (%v)", v, err)
+	}
+	if _, err := adapters.NewKeyring([]byte("short")); err == nil {
+		t.Error("want an error for an invalid key size")
+	}
+}
@@ -0,0 +1,128 @@
+package adapters
+
+import (
+	"cacher/internal/adapters"
+	"context"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+// etcdOrSkip connects to the etcd server in CACHER_ETCD_ADDR (default
+// localhost:2379) and returns a server under a prefix only the test uses
+func etcdOrSkip(t *testing.T) (*adapters.Memory, *clientv3.Client) {
+	t.Helper()
+	addr := os.Getenv("CACHER_ETCD_ADDR")
+	if addr == "" {
+		addr = "localhost:2379"
+	}
+
+	client, err := clientv3.New(clientv3.Config{Endpoints: []string{addr}, DialTimeout: time.Second})
+	if err != nil {
+		t.Skipf("etcd not available at %s: %v", addr, err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if _, err := client.Status(ctx, addr); err != nil {
+		_ = client.Close()
+		t.Skipf("etcd not available at %s: %v", addr, err)
+	}
+
+	prefix := "cacher-test/" + t.Name() + "/"
+	server := adapters.NewEtcd(client, prefix)
+	t.Cleanup(func() {
+		_, _ = client.Delete(context.Background(), prefix, clientv3.WithPrefix())
+		_ = server.Close()
+		_ = client.Close()
+	})
+	return server, client
+}
+
+func TestEtcdSetGet(t *testing.T) {
+	server, client := etcdOrSkip(t)
+	ctx := context.Background()
+
+	_ = server.Set(ctx, "key", "value", time.Hour)
+	if v, err := server.Get(ctx, "key"); err != nil || v != "value" {
+		t.Errorf("want value, got %q (%v)", v, err)
+	}
+	resp, err := client.Get(ctx, "cacher-test/"+t.Name()+"/key")
+	if err != nil || len(resp.Kvs) != 1 || resp.Kvs[0].Lease == 0 {
+		t.Errorf("want the key attached to a lease, got %v (%v)", resp, err)
+	}
+	keys, _, _ := server.Scan(ctx, 0, "*", 0)
+	if len(keys) != 1 || keys[0] != "key" {
+		t.Errorf("want [key], got %v", keys)
+	}
+	if deleted, _ := server.Delete(ctx, "key"); deleted != 1 {
+		t.Errorf("want 1 deleted key, got %d", deleted)
+	}
+}
+
+func TestEtcdSetNX(t *testing.T) {
+	server, _ := etcdOrSkip(t)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	won := 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if ok, err := server.SetNX(ctx, "lock", "holder", time.Minute); err == nil && ok {
+				mutex.Lock()
+				won++
+				mutex.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if won != 1 {
+		t.Errorf("want exactly one SetNX to win, got %d", won)
+	}
+}
+
+func TestEtcdCounters(t *testing.T) {
+	server, _ := etcdOrSkip(t)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = server.IncrBy(ctx, "counter", 1, time.Minute)
+		}()
+	}
+	wg.Wait()
+	if v, _ := server.Get(ctx, "counter"); v != "10" {
+		t.Errorf("want 10, got %q", v)
+	}
+}
+
+func TestEtcdLeaseExpiration(t *testing.T) {
+	server, client := etcdOrSkip(t)
+	ctx := context.Background()
+
+	_ = server.Set(ctx, "short", "value", 50*time.Millisecond)
+	time.Sleep(100 * time.Millisecond)
+	if _, err := server.Get(ctx, "short"); !errors.Is(err, redis.Nil) {
+		t.Errorf("want the key expired, got %v", err)
+	}
+
+	// etcd deletes the key once the lease runs out
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		resp, err := client.Get(ctx, "cacher-test/"+t.Name()+"/short")
+		if err == nil && len(resp.Kvs) == 0 {
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	t.Error("want the key deleted with its lease")
+}
@@ -0,0 +1,40 @@
+package adapters
+
+import (
+	"cacher/internal/adapters"
+	"context"
+	"testing"
+	"time"
+)
+
+func testExpireXX(t *testing.T, server adapters.CacheServer) {
+	ctx := context.Background()
+	key := "expirexx:" + t.Name()
+	_, _ = server.Delete(ctx, key, key+":forever")
+
+	if ok, err := server.ExpireXX(ctx, key, time.Hour); err != nil || ok {
+		t.Errorf("want a missing key left alone, got %v (%v)", ok, err)
+	}
+	_ = server.Set(ctx, key, "1", time.Minute)
+	if ok, err := server.ExpireXX(ctx, key, time.Hour); err != nil || !ok {
+		t.Fatalf("want the expiration updated, got %v (%v)", ok, err)
+	}
+	if ttl, _ := server.TTL(ctx, key); ttl <= time.Minute {
+		t.Errorf("want about an hour left, got %v", ttl)
+	}
+	_ = server.Set(ctx, key+":forever", "1", 0)
+	if ok, err := server.ExpireXX(ctx, key+":forever", time.Hour); err != nil || ok {
+		t.Errorf("want a key without an expiration left alone, got %v (%v)", ok, err)
+	}
+	if ttl, _ := server.TTL(ctx, key+":forever"); ttl >= 0 {
+		t.Errorf("want no expiration, got %v", ttl)
+	}
+}
+
+func TestMemoryExpireXX(t *testing.T) {
+	testExpireXX(t, adapters.NewMemory())
+}
+
+func TestRedisExpireXX(t *testing.T) {
+	testExpireXX(t, &adapters.RedisClient{Client: redisOrSkip(t)})
+}
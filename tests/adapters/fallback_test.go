@@ -0,0 +1,68 @@
+package adapters
+
+import (
+	"cacher/internal/adapters"
+	"context"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"testing"
+	"time"
+)
+
+func TestFallbackChain(t *testing.T) {
+	ctx := context.Background()
+	primary := &flaky{Cache: adapters.NewCache(adapters.NewMemory())}
+	secondary := adapters.NewCache(adapters.NewMemory())
+	chain := adapters.NewFallbackChain(2, 20*time.Millisecond, primary, secondary)
+
+	if err := chain.Set(ctx, "key", "v1", 0); err != nil {
+		t.Fatal(err)
+	}
+	if value, _ := secondary.Get(ctx, "key"); value != "v1" {
+		t.Errorf("want writes replicated, got %v", value)
+	}
+	_ = secondary.Set(ctx, "only-secondary", "value", 0)
+	if value, err := chain.Get(ctx, "only-secondary"); err != nil || value != "value" {
+		t.Errorf("want a miss to fall through, got %v (%v)", value, err)
+	}
+	if _, err := chain.Get(ctx, "missing"); !errors.Is(err, redis.Nil) {
+		t.Errorf("want a miss, got %v", err)
+	}
+
+	primary.down = true
+	if err := chain.Set(ctx, "key", "v2", 0); err != nil {
+		t.Errorf("want a write taken by the secondary, got %v", err)
+	}
+	for range 3 {
+		if value, err := chain.Get(ctx, "key"); err != nil || value != "v2" {
+			t.Errorf("want the secondary to serve an outage, got %v (%v)", value, err)
+		}
+	}
+	if value, err := chain.Get(ctx, "only-secondary"); err != nil || value != "value" {
+		t.Errorf("want errors to fall through, got %v (%v)", value, err)
+	}
+	stats := chain.FallbackStatistics()
+	if stats["0:custom"]["open"] != 1 || stats["0:custom"]["skipped"] != 4 || stats["1:memory"]["hits"] != 5 {
+		t.Errorf("want the primary skipped while down, got %v", stats)
+	}
+
+	// The primary comes back without the write it missed
+	primary.down = false
+	time.Sleep(30 * time.Millisecond)
+	if value, err := chain.Get(ctx, "key"); err != nil || value != "v2" {
+		t.Errorf("want no stale value after recovery, got %v (%v)", value, err)
+	}
+	if value, _ := chain.GetMany(ctx, "key", "only-secondary", "missing"); len(value) != 2 {
+		t.Errorf("want 2 values found across the chain, got %v", value)
+	}
+
+	primary.down = true
+	down := &flaky{Cache: adapters.NewCache(adapters.NewMemory()), down: true}
+	broken := adapters.NewFallbackChain(1, time.Minute, primary, down)
+	if err := broken.Set(ctx, "key", "value", 0); !errors.Is(err, errDown) {
+		t.Errorf("want an error when no backend took the write, got %v", err)
+	}
+	if err := broken.Ping(ctx); err == nil {
+		t.Error("want Ping to fail with every backend down")
+	}
+}
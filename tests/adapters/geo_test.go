@@ -0,0 +1,67 @@
+package adapters
+
+import (
+	"cacher/internal/adapters"
+	"context"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"math"
+	"testing"
+)
+
+func testGeo(t *testing.T, server adapters.CacheServer) {
+	ctx := context.Background()
+	key := "geo:" + t.Name()
+	_, _ = server.Delete(ctx, key)
+
+	added, err := server.GeoAdd(ctx, key,
+		&redis.GeoLocation{Name: "Palermo", Longitude: 13.361389, Latitude: 38.115556},
+		&redis.GeoLocation{Name: "Catania", Longitude: 15.087269, Latitude: 37.502669},
+	)
+	if err != nil || added != 2 {
+		t.Fatalf("want 2 added, got %v (%v)", added, err)
+	}
+
+	positions, err := server.GeoPos(ctx, key, "Palermo", "Rome")
+	if err != nil || len(positions) != 2 || positions[1] != nil {
+		t.Fatalf("want a position and a nil, got %v (%v)", positions, err)
+	}
+	if math.Abs(positions[0].Longitude-13.361389) > 1e-5 || math.Abs(positions[0].Latitude-38.115556) > 1e-5 {
+		t.Errorf("want the position back, got %+v", positions[0])
+	}
+
+	// Palermo and Catania are 166.27km apart
+	found, err := server.GeoSearch(ctx, key, 15, 37, 200, "km", 0)
+	if err != nil || len(found) != 2 || found[0].Name != "Catania" || found[1].Name != "Palermo" {
+		t.Fatalf("want both cities nearest first, got %v (%v)", found, err)
+	}
+	if math.Abs(found[0].Dist-56.4413) > 0.01 {
+		t.Errorf("want Catania 56.44km away, got %v", found[0].Dist)
+	}
+	if found, _ := server.GeoSearch(ctx, key, 15, 37, 100, "km", 0); len(found) != 1 {
+		t.Errorf("want only Catania within 100km, got %v", found)
+	}
+	if found, _ := server.GeoSearch(ctx, key, 15, 37, 200, "km", 1); len(found) != 1 || found[0].Name != "Catania" {
+		t.Errorf("want the nearest city only, got %v", found)
+	}
+	if found, err := server.GeoSearch(ctx, "geo:missing", 15, 37, 200, "km", 0); err != nil || len(found) != 0 {
+		t.Errorf("want nothing in a missing index, got %v (%v)", found, err)
+	}
+}
+
+func TestMemoryGeo(t *testing.T) {
+	testGeo(t, adapters.NewMemory())
+
+	m := adapters.NewMemory()
+	ctx := context.Background()
+	if _, err := m.GeoAdd(ctx, "geo", &redis.GeoLocation{Name: "pole", Latitude: 90}); !errors.Is(err, adapters.ErrInvalidCoordinates) {
+		t.Errorf("want ErrInvalidCoordinates, got %v", err)
+	}
+	if _, err := m.GeoSearch(ctx, "geo", 0, 0, 1, "yd", 0); !errors.Is(err, adapters.ErrGeoUnit) {
+		t.Errorf("want ErrGeoUnit, got %v", err)
+	}
+}
+
+func TestRedisGeo(t *testing.T) {
+	testGeo(t, &adapters.RedisClient{Client: redisOrSkip(t)})
+}
@@ -0,0 +1,50 @@
+package adapters
+
+import (
+	"cacher/internal/adapters"
+	"context"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"testing"
+)
+
+func testHash(t *testing.T, server adapters.CacheServer) {
+	ctx := context.Background()
+	key := "hash:" + t.Name()
+	_, _ = server.Delete(ctx, key)
+
+	if added, err := server.HSet(ctx, key, map[string]interface{}{"name": "arash", "visits": 1}); err != nil || added != 2 {
+		t.Fatalf("want 2 fields added, got %v (%v)", added, err)
+	}
+	if added, _ := server.HSet(ctx, key, map[string]interface{}{"name": "ali"}); added != 0 {
+		t.Errorf("want an overwritten field not counted, got %v", added)
+	}
+	if v, err := server.HGet(ctx, key, "name"); err != nil || v != "ali" {
+		t.Errorf("want ali, got %v (%v)", v, err)
+	}
+	if _, err := server.HGet(ctx, key, "missing"); !errors.Is(err, redis.Nil) {
+		t.Errorf("want redis.Nil for a missing field, got %v", err)
+	}
+	if n, err := server.HIncrBy(ctx, key, "visits", 2); err != nil || n != 3 {
+		t.Errorf("want 3, got %v (%v)", n, err)
+	}
+	if _, err := server.HIncrBy(ctx, key, "name", 1); err == nil {
+		t.Error("want incrementing a text field to fail")
+	}
+
+	if removed, err := server.HDel(ctx, key, "name", "missing"); err != nil || removed != 1 {
+		t.Errorf("want 1 field removed, got %v (%v)", removed, err)
+	}
+	_, _ = server.HDel(ctx, key, "visits")
+	if exists, _ := server.Exists(ctx, key); exists {
+		t.Error("want a hash without fields deleted")
+	}
+}
+
+func TestMemoryHash(t *testing.T) {
+	testHash(t, adapters.NewMemory())
+}
+
+func TestRedisHash(t *testing.T) {
+	testHash(t, &adapters.RedisClient{Client: redisOrSkip(t)})
+}
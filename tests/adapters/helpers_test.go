@@ -0,0 +1,28 @@
+package adapters
+
+import (
+	"context"
+	"github.com/redis/go-redis/v9"
+	"os"
+	"testing"
+)
+
+// redisOrSkip connects to the Redis server in CACHER_REDIS_ADDR (default
+// localhost:6379) and skips the test when it is not reachable
+func redisOrSkip(t *testing.T) *redis.Client {
+	t.Helper()
+	addr := os.Getenv("CACHER_REDIS_ADDR")
+	if addr == "" {
+		addr = "localhost:6379"
+	}
+
+	client := redis.NewClient(&redis.Options{Addr: addr})
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		_ = client.Close()
+		t.Skipf("redis not available at %s: %v", addr, err)
+	}
+	t.Cleanup(func() {
+		_ = client.Close()
+	})
+	return client
+}
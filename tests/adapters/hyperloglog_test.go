@@ -0,0 +1,69 @@
+package adapters
+
+import (
+	"cacher/internal/adapters"
+	"context"
+	"fmt"
+	"math"
+	"testing"
+)
+
+func testHyperLogLog(t *testing.T, server adapters.CacheServer) {
+	ctx := context.Background()
+	a, b, union := "hll:"+t.Name()+":a", "hll:"+t.Name()+":b", "hll:"+t.Name()+":union"
+	_, _ = server.Delete(ctx, a, b, union)
+
+	if changed, err := server.PFAdd(ctx, a, "x"); err != nil || !changed {
+		t.Fatalf("want the first add to change the estimate, got %v (%v)", changed, err)
+	}
+	if changed, _ := server.PFAdd(ctx, a, "x"); changed {
+		t.Error("want a duplicate not to change the estimate")
+	}
+
+	for i := range 10000 {
+		_, _ = server.PFAdd(ctx, a, fmt.Sprintf("user:%d", i))
+		_, _ = server.PFAdd(ctx, b, fmt.Sprintf("user:%d", i+5000))
+	}
+	assertNear := func(what string, got, want int64) {
+		t.Helper()
+		if math.Abs(float64(got-want)) > float64(want)*0.03 {
+			t.Errorf("want %s near %d, got %d", what, want, got)
+		}
+	}
+	count, err := server.PFCount(ctx, a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertNear("count", count, 10001)
+	if err := server.PFMerge(ctx, union, a, b); err != nil {
+		t.Fatal(err)
+	}
+	count, _ = server.PFCount(ctx, union)
+	assertNear("merged count", count, 15001)
+
+	if count, _ := server.PFCount(ctx, "hll:missing"); count != 0 {
+		t.Errorf("want 0 for a missing key, got %d", count)
+	}
+	_ = server.Set(ctx, a, "text", 0)
+	if _, err := server.PFAdd(ctx, a, "x"); err == nil {
+		t.Error("want adding to a plain string to fail")
+	}
+}
+
+func TestMemoryHyperLogLog(t *testing.T) {
+	testHyperLogLog(t, adapters.NewMemory())
+
+	m := adapters.NewMemory()
+	ctx := context.Background()
+	for i := range 1000 {
+		_, _ = m.PFAdd(ctx, "a", i)
+		_, _ = m.PFAdd(ctx, "b", i+500)
+	}
+	if count, err := m.PFCount(ctx, "a", "b"); err != nil || math.Abs(float64(count-1500)) > 30 {
+		t.Errorf("want the union of both keys counted near 1500, got %v (%v)", count, err)
+	}
+}
+
+func TestRedisHyperLogLog(t *testing.T) {
+	testHyperLogLog(t, &adapters.RedisClient{Client: redisOrSkip(t)})
+}
@@ -0,0 +1,66 @@
+package adapters
+
+import (
+	"cacher/internal/adapters"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryKeyEvents(t *testing.T) {
+	m := adapters.NewMemory(adapters.WithCleanupInterval(10 * time.Millisecond))
+	defer m.Close()
+	ctx := context.Background()
+	events := make(chan adapters.KeyEvent, 10)
+	subscription, err := m.SubscribeKeyEvents(ctx, func(event adapters.KeyEvent) { events <- event })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_ = m.Set(ctx, "a", "1", 20*time.Millisecond)
+	_ = m.Set(ctx, "b", "1", 0)
+	_, _ = m.Delete(ctx, "b", "missing")
+	for _, want := range []adapters.KeyEvent{{Type: adapters.KeyDeleted, Key: "b"}, {Type: adapters.KeyExpired, Key: "a"}} {
+		select {
+		case event := <-events:
+			if event != want {
+				t.Errorf("want %v, got %v", want, event)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("no event %v", want)
+		}
+	}
+
+	_ = subscription.Close()
+	_ = m.Set(ctx, "c", "1", 0)
+	_, _ = m.Delete(ctx, "c")
+	select {
+	case event := <-events:
+		t.Errorf("want no events after Close, got %v", event)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestRedisKeyEvents(t *testing.T) {
+	client := redisOrSkip(t)
+	server := adapters.NewPrefixed(&adapters.RedisClient{Client: client}, "app:")
+	ctx := context.Background()
+	events := make(chan adapters.KeyEvent, 10)
+	subscription, err := server.SubscribeKeyEvents(ctx, func(event adapters.KeyEvent) { events <- event })
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer subscription.Close()
+
+	// Publish the notifications Redis would send
+	client.Publish(ctx, "__keyevent@0__:del", "other:a")
+	client.Publish(ctx, "__keyevent@0__:expired", "app:session")
+	select {
+	case event := <-events:
+		if want := (adapters.KeyEvent{Type: adapters.KeyExpired, Key: "session"}); event != want {
+			t.Errorf("want %v, got %v", want, event)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no event")
+	}
+}
@@ -0,0 +1,81 @@
+package adapters
+
+import (
+	"cacher/internal/adapters"
+	"context"
+	"testing"
+	"time"
+)
+
+func testTokenBucket(t *testing.T, server adapters.CacheServer) {
+	ctx := context.Background()
+	key := "bucket:" + t.Name()
+	_, _ = server.Delete(ctx, key)
+
+	for i := range 3 {
+		allowed, remaining, err := server.TokenBucket(ctx, key, 10, 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !allowed || remaining != int64(2-i) {
+			t.Errorf("request %d: want allowed with %d left, got %v with %d", i, 2-i, allowed, remaining)
+		}
+	}
+	if allowed, _, _ := server.TokenBucket(ctx, key, 10, 3); allowed {
+		t.Errorf("want empty bucket to reject")
+	}
+
+	time.Sleep(150 * time.Millisecond)
+	if allowed, _, _ := server.TokenBucket(ctx, key, 10, 3); !allowed {
+		t.Errorf("want bucket refilled")
+	}
+}
+
+func TestMemoryTokenBucket(t *testing.T) {
+	testTokenBucket(t, adapters.NewMemory())
+}
+
+func TestRedisTokenBucket(t *testing.T) {
+	testTokenBucket(t, &adapters.RedisClient{Client: redisOrSkip(t)})
+}
+
+func testGCRA(t *testing.T, server adapters.CacheServer) {
+	ctx := context.Background()
+	key := "gcra:" + t.Name()
+	_, _ = server.Delete(ctx, key)
+
+	// A quantity of 0 looks at the limit without taking from it
+	result, err := server.GCRA(ctx, key, 2, 10, time.Second, 0)
+	if err != nil || !result.Allowed || result.Remaining != 3 {
+		t.Errorf("want allowed with the whole burst left, got %+v (%v)", result, err)
+	}
+
+	for i := range 3 {
+		result, err := server.GCRA(ctx, key, 2, 10, time.Second, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !result.Allowed || result.Limit != 3 || result.Remaining != int64(2-i) {
+			t.Errorf("request %d: want allowed with %d left, got %+v", i, 2-i, result)
+		}
+	}
+
+	result, _ = server.GCRA(ctx, key, 2, 10, time.Second, 1)
+	if result.Allowed || result.Remaining != 0 {
+		t.Errorf("want limited, got %+v", result)
+	}
+	if result.RetryAfter <= 0 || result.RetryAfter > 100*time.Millisecond {
+		t.Errorf("want retry within 100ms, got %v", result.RetryAfter)
+	}
+	if result.ResetAfter <= 200*time.Millisecond || result.ResetAfter > 300*time.Millisecond {
+		t.Errorf("want reset within 300ms, got %v", result.ResetAfter)
+	}
+}
+
+func TestMemoryGCRA(t *testing.T) {
+	testGCRA(t, adapters.NewMemory())
+}
+
+func TestRedisGCRA(t *testing.T) {
+	testGCRA(t, &adapters.RedisClient{Client: redisOrSkip(t)})
+}
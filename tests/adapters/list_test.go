@@ -0,0 +1,102 @@
+package adapters
+
+import (
+	"cacher/internal/adapters"
+	"context"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"testing"
+	"time"
+)
+
+func testBlockingLists(t *testing.T, server adapters.CacheServer) {
+	ctx := context.Background()
+	a, b := "list:"+t.Name()+":a", "list:"+t.Name()+":b"
+	_, _ = server.Delete(ctx, a, b)
+
+	_ = server.Push(ctx, b, "1", "2", "3")
+	if popped, err := server.BLPop(ctx, time.Second, a, b); err != nil || len(popped) != 2 || popped[0] != b || popped[1] != "3" {
+		t.Errorf("want the head of b, got %v (%v)", popped, err)
+	}
+	if popped, err := server.BRPop(ctx, time.Second, a, b); err != nil || popped[1] != "1" {
+		t.Errorf("want the tail of b, got %v (%v)", popped, err)
+	}
+
+	// A pop waits for a push
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		_ = server.Push(ctx, a, "late")
+	}()
+	if popped, err := server.BLPop(ctx, 2*time.Second, a); err != nil || popped[1] != "late" {
+		t.Errorf("want the element pushed while waiting, got %v (%v)", popped, err)
+	}
+	if _, err := server.BRPop(ctx, time.Second, a); !errors.Is(err, redis.Nil) {
+		t.Errorf("want redis.Nil after the timeout, got %v", err)
+	}
+
+	// b holds 2, move it to the left of a
+	if moved, err := server.LMove(ctx, b, a, "RIGHT", "LEFT"); err != nil || moved != "2" {
+		t.Errorf("want 2 moved, got %q (%v)", moved, err)
+	}
+	if _, err := server.LMove(ctx, b, a, "RIGHT", "LEFT"); !errors.Is(err, redis.Nil) {
+		t.Errorf("want redis.Nil moving from an empty list, got %v", err)
+	}
+	if moved, err := server.BLMove(ctx, a, b, "LEFT", "RIGHT", time.Second); err != nil || moved != "2" {
+		t.Errorf("want 2 moved back, got %q (%v)", moved, err)
+	}
+	if _, err := server.BLMove(ctx, a, b, "LEFT", "RIGHT", time.Second); !errors.Is(err, redis.Nil) {
+		t.Errorf("want redis.Nil after the timeout, got %v", err)
+	}
+
+	_ = server.Push(ctx, a, "x", "y", "x", "z", "x")
+	if n, _ := server.LLen(ctx, a); n != 5 {
+		t.Errorf("want 5 elements, got %d", n)
+	}
+	if removed, err := server.LRem(ctx, a, -2, "x"); err != nil || removed != 2 {
+		t.Errorf("want 2 removed, got %v (%v)", removed, err)
+	}
+	// LPUSH reversed the elements to x z x y x, minus the last two x
+	if list, _ := server.List(ctx, a); len(list) != 3 || list[0] != "x" || list[1] != "z" || list[2] != "y" {
+		t.Errorf("want x z y left, got %v", list)
+	}
+	if removed, _ := server.LRem(ctx, a, 0, "x"); removed != 1 {
+		t.Errorf("want the last x removed, got %d", removed)
+	}
+}
+
+func TestMemoryBlockingLists(t *testing.T) {
+	testBlockingLists(t, adapters.NewMemory())
+
+	m := adapters.NewMemory()
+	ctx := context.Background()
+	if _, err := m.LMove(ctx, "a", "b", "UP", "LEFT"); !errors.Is(err, adapters.ErrListDirection) {
+		t.Errorf("want ErrListDirection, got %v", err)
+	}
+	_ = m.Push(ctx, "a", "1")
+	_ = m.Set(ctx, "text", "x", 0)
+	if _, err := m.LMove(ctx, "a", "text", "LEFT", "LEFT"); !errors.Is(err, adapters.ErrWrongType) {
+		t.Errorf("want ErrWrongType, got %v", err)
+	}
+	if n, _ := m.LLen(ctx, "a"); n != 1 {
+		t.Errorf("want the element kept when the move fails, got %d", n)
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := m.BLPop(canceled, 0, "empty"); !errors.Is(err, context.Canceled) {
+		t.Errorf("want a pop waiting forever to end with its context, got %v", err)
+	}
+}
+
+func TestPrefixedBlockingLists(t *testing.T) {
+	server := &adapters.Prefixed{Server: adapters.NewMemory(), Prefix: "app:"}
+	ctx := context.Background()
+	_ = server.Push(ctx, "jobs", "1")
+	if popped, err := server.BLPop(ctx, time.Second, "jobs"); err != nil || popped[0] != "jobs" {
+		t.Errorf("want the unprefixed key, got %v (%v)", popped, err)
+	}
+}
+
+func TestRedisBlockingLists(t *testing.T) {
+	testBlockingLists(t, &adapters.RedisClient{Client: redisOrSkip(t)})
+}
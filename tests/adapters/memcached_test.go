@@ -0,0 +1,141 @@
+package adapters
+
+import (
+	"bufio"
+	"cacher/internal/adapters"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/bradfitz/gomemcache/memcache"
+	"github.com/redis/go-redis/v9"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeMemcached serves the get, set, delete and version commands of the
+// memcached text protocol from a map, recording expirations
+type fakeMemcached struct {
+	mutex       sync.Mutex
+	items       map[string]string
+	expirations map[string]int64
+}
+
+func startFakeMemcached(t *testing.T) (*fakeMemcached, string) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+
+	f := &fakeMemcached{items: make(map[string]string), expirations: make(map[string]int64)}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+	return f, listener.Addr().String()
+}
+
+func (f *fakeMemcached) serve(conn net.Conn) {
+	defer conn.Close()
+	rw := bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn))
+	for {
+		line, err := rw.ReadString('\n')
+		if err != nil {
+			return
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		f.mutex.Lock()
+		switch fields[0] {
+		case "gets", "get":
+			for i, key := range fields[1:] {
+				if value, ok := f.items[key]; ok {
+					fmt.Fprintf(rw, "VALUE %s 0 %d %d\r\n%s\r\n", key, len(value), i+1, value)
+				}
+			}
+			rw.WriteString("END\r\n")
+		case "set":
+			size, _ := strconv.Atoi(fields[4])
+			data := make([]byte, size+2)
+			if _, err := io.ReadFull(rw, data); err != nil {
+				f.mutex.Unlock()
+				return
+			}
+			f.items[fields[1]] = string(data[:size])
+			f.expirations[fields[1]], _ = strconv.ParseInt(fields[3], 10, 64)
+			rw.WriteString("STORED\r\n")
+		case "delete":
+			if _, ok := f.items[fields[1]]; ok {
+				delete(f.items, fields[1])
+				rw.WriteString("DELETED\r\n")
+			} else {
+				rw.WriteString("NOT_FOUND\r\n")
+			}
+		case "version":
+			rw.WriteString("VERSION 1.6.0\r\n")
+		default:
+			rw.WriteString("ERROR\r\n")
+		}
+		f.mutex.Unlock()
+		if err := rw.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func TestMemcached(t *testing.T) {
+	server, addr := startFakeMemcached(t)
+	cache := adapters.NewMemcached(memcache.New(addr), "app:")
+	defer cache.Close()
+	ctx := context.Background()
+
+	if err := cache.Set(ctx, "key", 42, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := cache.Get(ctx, "key"); err != nil || v != "42" {
+		t.Errorf("want 42, got %v (%v)", v, err)
+	}
+	server.mutex.Lock()
+	if server.expirations["app:key"] != 60 {
+		t.Errorf("want app:key to expire in 60 seconds, got %d", server.expirations["app:key"])
+	}
+	server.mutex.Unlock()
+
+	_ = cache.SetMany(ctx, map[string]interface{}{"a": "1", "b": "2"}, 0)
+	values, err := cache.GetMany(ctx, "a", "b", "missing")
+	if err != nil || len(values) != 2 || values["a"] != "1" || values["b"] != "2" {
+		t.Errorf("want a and b, got %v (%v)", values, err)
+	}
+
+	unsafe := "query with spaces " + strings.Repeat("x", 300)
+	if err := cache.Set(ctx, unsafe, "found", 0); err != nil {
+		t.Fatalf("want long keys with spaces hashed, got %v", err)
+	}
+	if v, err := cache.Get(ctx, unsafe); err != nil || v != "found" {
+		t.Errorf("want found, got %v (%v)", v, err)
+	}
+
+	if err := cache.Delete(ctx, "key", "missing"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cache.Get(ctx, "key"); !errors.Is(err, redis.Nil) {
+		t.Errorf("want redis.Nil after delete, got %v", err)
+	}
+	if err := adapters.Ping(ctx, cache); err != nil {
+		t.Errorf("want ping to succeed, got %v", err)
+	}
+}
@@ -0,0 +1,36 @@
+package adapters
+
+import (
+	"cacher/internal/adapters"
+	"context"
+	"math/rand"
+	"strconv"
+	"testing"
+)
+
+// BenchmarkMemoryZipf reads keys of a Zipfian distribution through a memory
+// holding 1% of them, storing every miss, and reports the hit ratio of each
+// eviction policy
+func BenchmarkMemoryZipf(b *testing.B) {
+	const keys, capacity = 100000, 1000
+	for _, policy := range []adapters.EvictionPolicy{adapters.LRU, adapters.TinyLFU} {
+		b.Run(policy.String(), func(b *testing.B) {
+			m := adapters.NewMemory(adapters.WithMaxEntries(capacity), adapters.WithEvictionPolicy(policy))
+			defer m.Close()
+			ctx := context.Background()
+			zipf := rand.NewZipf(rand.New(rand.NewSource(1)), 1.01, 1, keys-1)
+
+			var hits int
+			b.ResetTimer()
+			for range b.N {
+				key := strconv.FormatUint(zipf.Uint64(), 10)
+				if _, err := m.Get(ctx, key); err == nil {
+					hits++
+					continue
+				}
+				_ = m.Set(ctx, key, "value", 0)
+			}
+			b.ReportMetric(100*float64(hits)/float64(b.N), "hit%")
+		})
+	}
+}
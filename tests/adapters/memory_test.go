@@ -0,0 +1,247 @@
+package adapters
+
+import (
+	"cacher/internal/adapters"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMemorySetGet(t *testing.T) {
+	m := adapters.NewMemory()
+	ctx := context.Background()
+
+	if _, err := m.Get(ctx, "missing"); !errors.Is(err, redis.Nil) {
+		t.Errorf("want redis.Nil, got %v", err)
+	}
+
+	if err := m.Set(ctx, "key", 42, 0); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := m.Get(ctx, "key"); err != nil || v != "42" {
+		t.Errorf("want 42, got %v (%v)", v, err)
+	}
+
+	if err := m.Set(ctx, "short", "value", 10*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if _, err := m.Get(ctx, "short"); !errors.Is(err, redis.Nil) {
+		t.Errorf("want expired key, got %v", err)
+	}
+}
+
+func TestMemoryCounters(t *testing.T) {
+	m := adapters.NewMemory()
+	ctx := context.Background()
+
+	if v, _ := m.Incr(ctx, "counter"); v != 1 {
+		t.Errorf("want 1, got %v", v)
+	}
+	if v, _ := m.DecrBy(ctx, "counter", 5); v != -4 {
+		t.Errorf("want -4, got %v", v)
+	}
+
+	_ = m.Set(ctx, "text", "abc", 0)
+	if _, err := m.Incr(ctx, "text"); !errors.Is(err, adapters.ErrNotInteger) {
+		t.Errorf("want ErrNotInteger, got %v", err)
+	}
+
+	if v, _ := m.CountRateLimiter(ctx, "limit", 3, 2, time.Minute); v != 1 {
+		t.Errorf("want 1, got %v", v)
+	}
+	if v, _ := m.CountRateLimiter(ctx, "limit", 3, 2, time.Minute); v != -1 {
+		t.Errorf("want -1, got %v", v)
+	}
+	if v, _ := m.Get(ctx, "limit"); v != "1" {
+		t.Errorf("want counter to stay at 1, got %v", v)
+	}
+}
+
+func TestMemoryList(t *testing.T) {
+	m := adapters.NewMemory()
+	ctx := context.Background()
+
+	_ = m.Push(ctx, "list", "a", "b", "c")
+	list, _ := m.List(ctx, "list")
+	if len(list) != 3 || list[0] != "c" || list[2] != "a" {
+		t.Errorf("want [c b a], got %v", list)
+	}
+
+	if v, _ := m.Pop(ctx, "list"); v != "c" {
+		t.Errorf("want c, got %v", v)
+	}
+	if _, err := m.Get(ctx, "list"); !errors.Is(err, adapters.ErrWrongType) {
+		t.Errorf("want ErrWrongType, got %v", err)
+	}
+}
+
+func TestMemoryRemember(t *testing.T) {
+	m := adapters.NewMemory()
+	ctx := context.Background()
+
+	calls := 0
+	loader := func() interface{} {
+		calls++
+		return "computed"
+	}
+
+	for range 3 {
+		v, err := m.Remember(ctx, "remember", time.Minute, loader)
+		if err != nil || v != "computed" {
+			t.Errorf("want computed, got %v (%v)", v, err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("want 1 loader call, got %v", calls)
+	}
+}
+
+func TestMemoryExistsTTL(t *testing.T) {
+	m := adapters.NewMemory()
+	ctx := context.Background()
+
+	_ = m.Set(ctx, "expiring", "value", time.Minute)
+	_ = m.Set(ctx, "forever", "value", 0)
+
+	if found, _ := m.Exists(ctx, "expiring"); !found {
+		t.Errorf("want expiring to exist")
+	}
+	if ttl, _ := m.TTL(ctx, "expiring"); ttl <= 59*time.Second || ttl > time.Minute {
+		t.Errorf("want about a minute left, got %s", ttl)
+	}
+	if ttl, _ := m.TTL(ctx, "forever"); ttl != adapters.NoExpiration {
+		t.Errorf("want NoExpiration, got %s", ttl)
+	}
+	if _, err := m.TTL(ctx, "missing"); !errors.Is(err, redis.Nil) {
+		t.Errorf("want redis.Nil, got %v", err)
+	}
+}
+
+func TestMemoryGetWithTTL(t *testing.T) {
+	m := adapters.NewMemory()
+	defer m.Close()
+	ctx := context.Background()
+
+	_ = m.Set(ctx, "expiring", "value", time.Minute)
+	_ = m.Set(ctx, "forever", "value", 0)
+	_, _ = m.HSet(ctx, "hash", map[string]interface{}{"field": "value"})
+
+	if value, ttl, err := adapters.GetWithTTL(ctx, m, "expiring"); err != nil || value != "value" || ttl <= 59*time.Second || ttl > time.Minute {
+		t.Errorf("want the value with about a minute left, got %q %s (%v)", value, ttl, err)
+	}
+	if _, ttl, _ := adapters.GetWithTTL(ctx, m, "forever"); ttl != adapters.NoExpiration {
+		t.Errorf("want NoExpiration, got %s", ttl)
+	}
+	if _, _, err := adapters.GetWithTTL(ctx, m, "missing"); !errors.Is(err, redis.Nil) {
+		t.Errorf("want redis.Nil, got %v", err)
+	}
+	if _, _, err := adapters.GetWithTTL(ctx, m, "hash"); !errors.Is(err, adapters.ErrWrongType) {
+		t.Errorf("want ErrWrongType, got %v", err)
+	}
+}
+
+func TestMemoryMaxEntries(t *testing.T) {
+	m := adapters.NewMemory(adapters.WithMaxEntries(64))
+	ctx := context.Background()
+
+	for i := 0; i < 1000; i++ {
+		_ = m.Set(ctx, fmt.Sprintf("key:%d", i), "value", 0)
+	}
+	keys, _, _ := m.Scan(ctx, 0, "*", 0)
+	if len(keys) == 0 || len(keys) > 64 {
+		t.Errorf("want at most 64 keys, got %d", len(keys))
+	}
+	if v, err := m.Get(ctx, "key:999"); err != nil || v != "value" {
+		t.Errorf("want the newest key kept, got %q (%v)", v, err)
+	}
+}
+
+func TestMemoryEvictsLeastRecentlyUsed(t *testing.T) {
+	m := adapters.NewMemory(adapters.WithMaxEntries(64))
+	ctx := context.Background()
+
+	_ = m.Set(ctx, "hot", "value", 0)
+	for i := 0; i < 1000; i++ {
+		_ = m.Set(ctx, fmt.Sprintf("key:%d", i), "value", 0)
+		if _, err := m.Get(ctx, "hot"); err != nil {
+			t.Fatalf("want the key read after every write kept, got %v after %d writes", err, i)
+		}
+	}
+	stats := m.EvictionStatistics()
+	if stats["entries"] > 64 || stats["entries"] != uint64(countKeys(t, m, "*")) {
+		t.Errorf("want at most 64 entries, got %v counted and %d scanned", stats["entries"], countKeys(t, m, "*"))
+	}
+	if stats["evictions"] != 1001-stats["entries"] {
+		t.Errorf("want every other key evicted, got %v", stats)
+	}
+}
+
+func TestMemoryTinyLFUKeepsHotKeys(t *testing.T) {
+	for _, policy := range []adapters.EvictionPolicy{adapters.LRU, adapters.TinyLFU} {
+		m := adapters.NewMemory(adapters.WithMaxEntries(1024), adapters.WithEvictionPolicy(policy))
+		ctx := context.Background()
+
+		for i := 0; i < 512; i++ {
+			for range 3 {
+				if _, err := m.Get(ctx, fmt.Sprintf("hot:%d", i)); err != nil {
+					_ = m.Set(ctx, fmt.Sprintf("hot:%d", i), "value", 0)
+				}
+			}
+		}
+		// A scan reads every other key once
+		for i := 0; i < 10000; i++ {
+			_ = m.Set(ctx, fmt.Sprintf("cold:%d", i), "value", 0)
+		}
+		kept := 0
+		for i := 0; i < 512; i++ {
+			if _, err := m.Get(ctx, fmt.Sprintf("hot:%d", i)); err == nil {
+				kept++
+			}
+		}
+		switch {
+		case policy == adapters.LRU && kept > 0:
+			t.Errorf("want LRU to evict the hot keys, kept %d", kept)
+		case policy == adapters.TinyLFU && kept < 450:
+			t.Errorf("want TinyLFU to keep the hot keys, kept %d of 512", kept)
+		}
+		if entries := m.EvictionStatistics()["entries"]; entries > 1024 {
+			t.Errorf("%s: want about 1024 entries, got %d", policy, entries)
+		}
+	}
+}
+
+func TestMemoryMaxBytes(t *testing.T) {
+	var evicted []string
+	m := adapters.NewMemory(adapters.WithMaxBytes(32*1024), adapters.WithOnEvict(func(key string) {
+		evicted = append(evicted, key)
+	}))
+	ctx := context.Background()
+
+	value := strings.Repeat("v", 100)
+	for i := 0; i < 1000; i++ {
+		_ = m.Set(ctx, fmt.Sprintf("key:%d", i), value, 0)
+	}
+	stats := m.EvictionStatistics()
+	if stats["bytes"] == 0 || stats["bytes"] > 32*1024 {
+		t.Errorf("want at most 32KiB held, got %d", stats["bytes"])
+	}
+	if stats["evictions"] == 0 || stats["evictions"] != uint64(len(evicted)) {
+		t.Errorf("want OnEvict called for every eviction, got %d calls and %v", len(evicted), stats)
+	}
+	if stats["evicted_bytes"] < stats["evictions"]*uint64(len(value)) {
+		t.Errorf("want the size of evicted keys counted, got %v", stats)
+	}
+	if _, err := m.Get(ctx, evicted[0]); !errors.Is(err, redis.Nil) {
+		t.Errorf("want %s evicted, got %v", evicted[0], err)
+	}
+
+	_, _ = m.Flush(ctx)
+	if stats := m.EvictionStatistics(); stats["bytes"] != 0 || stats["entries"] != 0 {
+		t.Errorf("want nothing held after a flush, got %v", stats)
+	}
+}
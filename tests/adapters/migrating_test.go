@@ -0,0 +1,52 @@
+package adapters
+
+import (
+	"cacher/internal/adapters"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMigratingDualWrites(t *testing.T) {
+	ctx := context.Background()
+	old, new := adapters.NewCache(adapters.NewMemory()), adapters.NewCache(adapters.NewMemory())
+	migrating := adapters.NewMigrating(old, new, 1)
+	migrating.BackfillTTL = time.Minute
+
+	_ = old.Set(ctx, "before", "old", 0)
+	if err := migrating.Set(ctx, "during", "value", 0); err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []adapters.Cache{old, new} {
+		if value, err := c.Get(ctx, "during"); err != nil || value != "value" {
+			t.Errorf("want writes on both backends, got %v (%v)", value, err)
+		}
+	}
+
+	if value, err := migrating.Get(ctx, "before"); err != nil || value != "old" {
+		t.Errorf("want reads from the old backend, got %v (%v)", value, err)
+	}
+	if value, _ := new.Get(ctx, "before"); value != "old" {
+		t.Errorf("want the missing entry backfilled, got %v", value)
+	}
+	_, _ = migrating.Get(ctx, "during")
+	_ = new.Set(ctx, "during", "changed", 0)
+	_, _ = migrating.GetMany(ctx, "during", "missing")
+
+	stats := migrating.MigrationStatistics()
+	if stats["compared"] != 3 || stats["matches"] != 1 || stats["missing"] != 1 || stats["mismatches"] != 1 || stats["backfilled"] != 1 {
+		t.Errorf("want 1 match, 1 missing and 1 mismatch in 3 compared reads, got %v", stats)
+	}
+
+	migrating.Cutover()
+	if value, _ := migrating.Get(ctx, "during"); value != "changed" {
+		t.Errorf("want reads from the new backend after Cutover, got %v", value)
+	}
+	if err := migrating.Delete(ctx, "during"); err != nil {
+		t.Fatal(err)
+	}
+	migrating.Rollback()
+	if _, err := migrating.Get(ctx, "during"); err == nil {
+		t.Error("want deletes on both backends")
+	}
+}
@@ -0,0 +1,106 @@
+package adapters
+
+import (
+	"cacher/internal/adapters"
+	"context"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// mongoOrSkip connects to the MongoDB server in CACHER_MONGO_URI (default
+// mongodb://localhost:27017) and returns a server over a collection only the
+// test uses
+func mongoOrSkip(t *testing.T) (*adapters.Memory, *mongo.Collection) {
+	t.Helper()
+	uri := os.Getenv("CACHER_MONGO_URI")
+	if uri == "" {
+		uri = "mongodb://localhost:27017"
+	}
+
+	client, err := mongo.Connect(options.Client().ApplyURI(uri).SetServerSelectionTimeout(time.Second))
+	if err != nil {
+		t.Skipf("mongodb not available at %s: %v", uri, err)
+	}
+	ctx := context.Background()
+	if err := client.Ping(ctx, nil); err != nil {
+		_ = client.Disconnect(ctx)
+		t.Skipf("mongodb not available at %s: %v", uri, err)
+	}
+
+	collection := client.Database("cacher_test").Collection(strings.ReplaceAll(t.Name(), "/", "_"))
+	server, err := adapters.OpenMongo(ctx, collection)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = server.Close()
+		_ = collection.Drop(ctx)
+		_ = client.Disconnect(ctx)
+	})
+	return server, collection
+}
+
+func TestMongoSetGet(t *testing.T) {
+	server, collection := mongoOrSkip(t)
+	ctx := context.Background()
+
+	_ = server.Set(ctx, "key", "value", time.Hour)
+	if v, err := server.Get(ctx, "key"); err != nil || v != "value" {
+		t.Errorf("want value, got %q (%v)", v, err)
+	}
+	var doc bson.M
+	if err := collection.FindOne(ctx, bson.D{{Key: "_id", Value: "key"}}).Decode(&doc); err != nil || doc["expires_at"] == nil {
+		t.Errorf("want expires_at set, got %v (%v)", doc, err)
+	}
+	if deleted, _ := server.Delete(ctx, "key"); deleted != 1 {
+		t.Errorf("want 1 deleted key, got %d", deleted)
+	}
+}
+
+func TestMongoSetNX(t *testing.T) {
+	server, _ := mongoOrSkip(t)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	won := 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if ok, err := server.SetNX(ctx, "lock", "holder", time.Minute); err == nil && ok {
+				mutex.Lock()
+				won++
+				mutex.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if won != 1 {
+		t.Errorf("want exactly one SetNX to win, got %d", won)
+	}
+}
+
+func TestMongoCounters(t *testing.T) {
+	server, _ := mongoOrSkip(t)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = server.IncrBy(ctx, "counter", 1, 0)
+		}()
+	}
+	wg.Wait()
+	if v, _ := server.Get(ctx, "counter"); v != "10" {
+		t.Errorf("want 10, got %q", v)
+	}
+}
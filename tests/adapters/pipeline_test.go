@@ -0,0 +1,70 @@
+package adapters
+
+import (
+	"cacher/internal/adapters"
+	"context"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"testing"
+)
+
+func testPipeline(t *testing.T, server adapters.CacheServer) {
+	ctx := context.Background()
+	if err := server.Push(ctx, "list", "x"); err != nil {
+		t.Fatal(err)
+	}
+	ops := []*adapters.PipelineOp{
+		{Command: adapters.PipelineSet, Keys: []string{"a"}, Value: "1"},
+		{Command: adapters.PipelineIncr, Keys: []string{"n"}, Delta: 3},
+		{Command: adapters.PipelineGet, Keys: []string{"a"}},
+		{Command: adapters.PipelineGet, Keys: []string{"missing"}},
+		{Command: adapters.PipelineIncr, Keys: []string{"list"}, Delta: 1},
+		{Command: adapters.PipelineDelete, Keys: []string{"a", "n", "list"}},
+	}
+	if err := server.Pipeline(ctx, ops); err != nil {
+		t.Fatal(err)
+	}
+	if ops[0].Err != nil {
+		t.Errorf("want the set to succeed, got %v", ops[0].Err)
+	}
+	if ops[1].Result != int64(3) {
+		t.Errorf("want 3, got %v (%v)", ops[1].Result, ops[1].Err)
+	}
+	if ops[2].Result != "1" {
+		t.Errorf("want 1, got %v (%v)", ops[2].Result, ops[2].Err)
+	}
+	if !errors.Is(ops[3].Err, redis.Nil) {
+		t.Errorf("want redis.Nil for a missing key, got %v", ops[3].Err)
+	}
+	if ops[4].Err == nil {
+		t.Error("want incrementing a list to fail")
+	}
+	if ops[5].Result != int64(3) {
+		t.Errorf("want 3 keys deleted, got %v (%v)", ops[5].Result, ops[5].Err)
+	}
+}
+
+func TestMemoryPipeline(t *testing.T) {
+	testPipeline(t, adapters.NewMemory())
+}
+
+func TestPrefixedPipeline(t *testing.T) {
+	shared := adapters.NewMemory()
+	testPipeline(t, adapters.NewPrefixed(shared, "app:"))
+
+	ctx := context.Background()
+	ops := []*adapters.PipelineOp{{Command: adapters.PipelineSet, Keys: []string{"a"}, Value: "1"}}
+	if err := adapters.NewPrefixed(shared, "app:").Pipeline(ctx, ops); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := shared.Get(ctx, "app:a"); v != "1" {
+		t.Errorf("want key stored under prefix, got %v", v)
+	}
+}
+
+func TestRedisPipeline(t *testing.T) {
+	client := redisOrSkip(t)
+	server := adapters.NewPrefixed(&adapters.RedisClient{Client: client}, "pipeline:")
+	_, _ = server.Delete(context.Background(), "a", "n", "missing", "list")
+	testPipeline(t, server)
+}
@@ -0,0 +1,131 @@
+package adapters
+
+import (
+	"cacher/internal/adapters"
+	"context"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"strings"
+	"testing"
+)
+
+func TestPrefixedFlush(t *testing.T) {
+	shared := adapters.NewMemory()
+	app := adapters.NewPrefixed(shared, "app:")
+	other := adapters.NewPrefixed(shared, "other:")
+	ctx := context.Background()
+
+	for _, key := range []string{"a", "b", "c"} {
+		_ = app.Set(ctx, key, "1", 0)
+		_ = other.Set(ctx, key, "1", 0)
+	}
+	if v, _ := shared.Get(ctx, "app:a"); v != "1" {
+		t.Errorf("want key stored under prefix, got %v", v)
+	}
+
+	deleted, err := app.Flush(ctx)
+	if err != nil || deleted != 3 {
+		t.Errorf("want 3 deleted, got %v (%v)", deleted, err)
+	}
+	if _, err := app.Get(ctx, "a"); !errors.Is(err, redis.Nil) {
+		t.Errorf("want app keys flushed, got %v", err)
+	}
+	if v, _ := other.Get(ctx, "a"); v != "1" {
+		t.Errorf("want other keys untouched, got %v", v)
+	}
+}
+
+func TestMemoryScanPattern(t *testing.T) {
+	m := adapters.NewMemory()
+	ctx := context.Background()
+	for _, key := range []string{"user:1", "user:2", "user:10", "order:1", "u*er"} {
+		_ = m.Set(ctx, key, "x", 0)
+	}
+
+	patterns := map[string]int{
+		"user:*":   3,
+		"user:?":   2,
+		"user:[1]": 1,
+		"u\\*er":   1,
+		"*":        5,
+	}
+	for pattern, want := range patterns {
+		var found int
+		var cursor uint64
+		for {
+			keys, next, _ := m.Scan(ctx, cursor, pattern, 10)
+			found += len(keys)
+			if next == 0 {
+				break
+			}
+			cursor = next
+		}
+		if found != want {
+			t.Errorf("%s: want %v keys, got %v", pattern, want, found)
+		}
+	}
+}
+
+func TestPrefixedMaxKeyLength(t *testing.T) {
+	shared := adapters.NewMemory()
+	app := adapters.NewPrefixed(shared, "app:")
+	app.MaxKeyLength = 100
+	ctx := context.Background()
+
+	long := strings.Repeat("k", 200)
+	if err := app.Set(ctx, long, "1", 0); err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for cursor := uint64(0); ; {
+		page, next, _ := shared.Scan(ctx, cursor, "*", 0)
+		keys = append(keys, page...)
+		if cursor = next; cursor == 0 {
+			break
+		}
+	}
+	if len(keys) != 1 || len(keys[0]) > 100 || !strings.HasPrefix(keys[0], "app:kkk") {
+		t.Errorf("want the key stored hashed under the prefix, got %v", keys)
+	}
+	if v, err := app.Get(ctx, long); err != nil || v != "1" {
+		t.Errorf("want the value back, got %v (%v)", v, err)
+	}
+	if deleted, err := app.Flush(ctx); err != nil || deleted != 1 {
+		t.Errorf("want hashed keys flushed, got %v (%v)", deleted, err)
+	}
+}
+
+func TestPrefixedFlushRedis(t *testing.T) {
+	client := redisOrSkip(t)
+	server := &adapters.RedisClient{Client: client}
+	app := adapters.NewPrefixed(server, "flushtest:app:")
+	other := adapters.NewPrefixed(server, "flushtest:other:")
+	ctx := context.Background()
+	defer other.Flush(ctx)
+
+	for _, key := range []string{"a", "b", "c"} {
+		_ = app.Set(ctx, key, "1", 0)
+		_ = other.Set(ctx, key, "1", 0)
+	}
+	deleted, err := app.Flush(ctx)
+	if err != nil || deleted != 3 {
+		t.Errorf("want 3 unlinked, got %v (%v)", deleted, err)
+	}
+	if v, err := other.Get(ctx, "a"); err != nil || v != "1" {
+		t.Errorf("want other prefixes untouched, got %v (%v)", v, err)
+	}
+}
+
+func TestMemoryFlush(t *testing.T) {
+	m := adapters.NewMemory()
+	ctx := context.Background()
+	for _, key := range []string{"a", "b", "c"} {
+		_ = m.Set(ctx, key, "1", 0)
+	}
+	if deleted, err := m.Flush(ctx); err != nil || deleted != 3 {
+		t.Errorf("want 3 deleted, got %v (%v)", deleted, err)
+	}
+	if _, err := m.Get(ctx, "a"); !errors.Is(err, redis.Nil) {
+		t.Errorf("want keys flushed, got %v", err)
+	}
+}
@@ -0,0 +1,163 @@
+package adapters
+
+import (
+	"cacher/internal/adapters"
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeReplica is a CacheServer copying the values of primary on every Get.
+// Once frozen it serves the copies it has, once down it fails.
+type fakeReplica struct {
+	adapters.CacheServer
+	primary adapters.CacheServer
+	delay   time.Duration
+	frozen  atomic.Bool
+	down    atomic.Bool
+}
+
+func newFakeReplica(primary adapters.CacheServer, delay time.Duration) *fakeReplica {
+	return &fakeReplica{CacheServer: adapters.NewMemory(), primary: primary, delay: delay}
+}
+
+func (f *fakeReplica) Get(ctx context.Context, key string) (string, error) {
+	time.Sleep(f.delay)
+	if f.down.Load() {
+		return "", errDown
+	}
+	if !f.frozen.Load() {
+		if value, err := f.primary.Get(ctx, key); err == nil {
+			_ = f.CacheServer.Set(ctx, key, value, 0)
+		}
+	}
+	return f.CacheServer.Get(ctx, key)
+}
+
+func TestReplicatedRoutesReads(t *testing.T) {
+	ctx := context.Background()
+	primary := adapters.NewMemory()
+	first, second := adapters.NewMemory(), adapters.NewMemory()
+	replicated := adapters.NewReplicated(primary, []adapters.CacheServer{first, second}, adapters.RoundRobin, 0, time.Hour, "")
+	defer replicated.Close()
+
+	_ = first.Set(ctx, "key", "first", 0)
+	_ = second.Set(ctx, "key", "second", 0)
+	seen := map[string]int{}
+	for range 10 {
+		value, err := replicated.Get(ctx, "key")
+		if err != nil {
+			t.Fatal(err)
+		}
+		seen[value]++
+	}
+	if seen["first"] != 5 || seen["second"] != 5 {
+		t.Errorf("want reads spread evenly over the replicas, got %v", seen)
+	}
+
+	if err := replicated.Set(ctx, "key", "primary", 0); err != nil {
+		t.Fatal(err)
+	}
+	if value, _ := primary.Get(ctx, "key"); value != "primary" {
+		t.Errorf("want the write on the primary, got %q", value)
+	}
+	if value, _ := first.Get(ctx, "key"); value != "first" {
+		t.Errorf("want the replicas left to replication, got %q", value)
+	}
+	if _, err := replicated.Get(ctx, "missing"); err == nil {
+		t.Error("want a miss")
+	}
+}
+
+func TestReplicatedFallback(t *testing.T) {
+	ctx := context.Background()
+	primary := adapters.NewMemory()
+	replica := newFakeReplica(primary, 0)
+	replicated := adapters.NewReplicated(primary, []adapters.CacheServer{replica}, adapters.RoundRobin, 0, 10*time.Millisecond, "")
+	defer replicated.Close()
+
+	_ = replicated.Set(ctx, "key", "value", 0)
+	replica.down.Store(true)
+	if value, err := replicated.Get(ctx, "key"); err != nil || value != "value" {
+		t.Errorf("want the primary to serve while the replica fails, got %q (%v)", value, err)
+	}
+	if replicated.Replicas()[0].Healthy {
+		t.Error("want the failing replica marked unhealthy")
+	}
+
+	replica.down.Store(false)
+	time.Sleep(30 * time.Millisecond)
+	if !replicated.Replicas()[0].Healthy {
+		t.Error("want the replica back after a successful check")
+	}
+}
+
+func TestReplicatedLagGuard(t *testing.T) {
+	ctx := context.Background()
+	primary := adapters.NewMemory()
+	replica := newFakeReplica(primary, 0)
+	replicated := adapters.NewReplicated(primary, []adapters.CacheServer{replica}, adapters.RoundRobin, 25*time.Millisecond, 10*time.Millisecond, "")
+	defer replicated.Close()
+
+	_ = replicated.Set(ctx, "key", "old", 0)
+	time.Sleep(30 * time.Millisecond)
+	if value, _ := replicated.Get(ctx, "key"); value != "old" {
+		t.Fatalf("want the replica in sync, got %q", value)
+	}
+
+	// Replication stops, the replica keeps serving what it had
+	replica.frozen.Store(true)
+	_ = replicated.Set(ctx, "key", "new", 0)
+	time.Sleep(80 * time.Millisecond)
+	status := replicated.Replicas()[0]
+	if status.Healthy || status.Lag < 25*time.Millisecond {
+		t.Errorf("want the lagging replica skipped, got %+v", status)
+	}
+	if value, _ := replicated.Get(ctx, "key"); value != "new" {
+		t.Errorf("want reads from the primary, got %q", value)
+	}
+
+	replica.frozen.Store(false)
+	time.Sleep(40 * time.Millisecond)
+	if status := replicated.Replicas()[0]; !status.Healthy {
+		t.Errorf("want the replica used again once it caught up, got %+v", status)
+	}
+}
+
+func TestReplicatedLowestLatency(t *testing.T) {
+	ctx := context.Background()
+	primary := adapters.NewMemory()
+	slow, fast := newFakeReplica(primary, 5*time.Millisecond), newFakeReplica(primary, 0)
+	replicated := adapters.NewReplicated(primary, []adapters.CacheServer{slow, fast}, adapters.LowestLatency, 0, 10*time.Millisecond, "")
+	defer replicated.Close()
+
+	_ = replicated.Set(ctx, "key", "old", 0)
+	time.Sleep(50 * time.Millisecond)
+	slow.frozen.Store(true)
+	_ = replicated.Set(ctx, "key", "new", 0)
+	for range 5 {
+		if value, err := replicated.Get(ctx, "key"); err != nil || value != "new" {
+			t.Fatalf("want reads from the fast replica, got %q (%v)", value, err)
+		}
+	}
+	if statuses := replicated.Replicas(); statuses[0].Latency <= statuses[1].Latency {
+		t.Errorf("want the slow replica measured slower, got %+v", statuses)
+	}
+}
+
+func TestReplicatedHeartbeatPrefix(t *testing.T) {
+	ctx := context.Background()
+	primary := adapters.NewMemory()
+	replica := newFakeReplica(primary, 0)
+	replicated := adapters.NewReplicated(primary, []adapters.CacheServer{replica}, adapters.RoundRobin, 0, 10*time.Millisecond, "app:")
+	defer replicated.Close()
+
+	time.Sleep(30 * time.Millisecond)
+	if exists, _ := primary.Exists(ctx, "app:cacher:heartbeat"); !exists {
+		t.Error("want the heartbeat under the prefix")
+	}
+	if exists, _ := primary.Exists(ctx, "cacher:heartbeat"); exists {
+		t.Error("want no heartbeat shared with other apps")
+	}
+}
@@ -0,0 +1,74 @@
+package adapters
+
+import (
+	"cacher/internal/adapters"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// failing is a Cache whose Get fails with err the first failures times and
+// blocks for delay on every call
+type failing struct {
+	adapters.Cache
+	err      error
+	failures int
+	delay    time.Duration
+	calls    int
+}
+
+func (f *failing) Get(ctx context.Context, key string) (interface{}, error) {
+	f.calls++
+	select {
+	case <-time.After(f.delay):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if f.calls <= f.failures {
+		return nil, f.err
+	}
+	return f.Cache.Get(ctx, key)
+}
+
+func TestRetryTransientErrors(t *testing.T) {
+	backend := &failing{Cache: adapters.NewCache(adapters.NewMemory()), err: errors.New("LOADING Redis is loading the dataset in memory"), failures: 2}
+	retrying := adapters.NewRetrying(backend, 0, adapters.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond})
+	ctx := context.Background()
+
+	_ = backend.Set(ctx, "key", "value", 0)
+	if v, err := retrying.Get(ctx, "key"); err != nil || v != "value" {
+		t.Errorf("want value after retries, got %v (%v)", v, err)
+	}
+	if backend.calls != 3 {
+		t.Errorf("want 3 attempts, got %d", backend.calls)
+	}
+}
+
+func TestRetrySkipsPermanentErrors(t *testing.T) {
+	backend := &failing{Cache: adapters.NewCache(adapters.NewMemory()), err: adapters.ErrWrongType, failures: 1}
+	retrying := adapters.NewRetrying(backend, 0, adapters.RetryPolicy{MaxAttempts: 3})
+
+	if _, err := retrying.Get(context.Background(), "key"); !errors.Is(err, adapters.ErrWrongType) {
+		t.Errorf("want ErrWrongType, got %v", err)
+	}
+	if backend.calls != 1 {
+		t.Errorf("want a single attempt, got %d", backend.calls)
+	}
+}
+
+func TestOperationTimeout(t *testing.T) {
+	backend := &failing{Cache: adapters.NewCache(adapters.NewMemory()), delay: time.Second}
+	retrying := adapters.NewRetrying(backend, 10*time.Millisecond, adapters.RetryPolicy{MaxAttempts: 2})
+
+	start := time.Now()
+	if _, err := retrying.Get(context.Background(), "key"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("want deadline exceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("want both attempts to time out quickly, took %s", elapsed)
+	}
+	if backend.calls != 2 {
+		t.Errorf("want 2 attempts, got %d", backend.calls)
+	}
+}
@@ -0,0 +1,88 @@
+package adapters
+
+import (
+	"cacher/internal/adapters"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"strings"
+	"testing"
+	"time"
+)
+
+func openRistretto(t *testing.T, maxCost int64) *adapters.Memory {
+	t.Helper()
+	server, err := adapters.NewRistretto(maxCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = server.Close() })
+	return server
+}
+
+func TestRistrettoSetGet(t *testing.T) {
+	server := openRistretto(t, 1<<20)
+	ctx := context.Background()
+
+	_ = server.Set(ctx, "key", "value", time.Minute)
+	if v, err := server.Get(ctx, "key"); err != nil || v != "value" {
+		t.Errorf("want value, got %q (%v)", v, err)
+	}
+	for range 3 {
+		_, _ = server.IncrBy(ctx, "counter", 2, 0)
+	}
+	if v, _ := server.Get(ctx, "counter"); v != "6" {
+		t.Errorf("want 6, got %q", v)
+	}
+	if ok, _ := server.SetNX(ctx, "key", "other", 0); ok {
+		t.Error("want SetNX to fail on an existing key")
+	}
+	if deleted, _ := server.Delete(ctx, "key", "counter"); deleted != 2 {
+		t.Errorf("want 2 deleted keys, got %d", deleted)
+	}
+}
+
+func TestRistrettoExpiration(t *testing.T) {
+	server := openRistretto(t, 1<<20)
+	ctx := context.Background()
+
+	_ = server.Set(ctx, "short", "value", 20*time.Millisecond)
+	time.Sleep(40 * time.Millisecond)
+	if _, err := server.Get(ctx, "short"); !errors.Is(err, redis.Nil) {
+		t.Errorf("want the key expired, got %v", err)
+	}
+}
+
+func TestRistrettoBoundedByCost(t *testing.T) {
+	server := openRistretto(t, 10_000)
+	ctx := context.Background()
+
+	value := strings.Repeat("x", 100)
+	for i := 0; i < 1000; i++ {
+		_ = server.Set(ctx, fmt.Sprintf("key:%d", i), value, 0)
+	}
+	keys, _, err := server.Scan(ctx, 0, "key:*", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) == 0 || len(keys) > 100 {
+		t.Errorf("want at most 100 entries of about 100 bytes, got %d", len(keys))
+	}
+}
+
+func TestTieredRistretto(t *testing.T) {
+	remote := adapters.NewMemory()
+	tiered := adapters.NewTiered(openRistretto(t, 1<<20), adapters.NewCache(remote), time.Minute)
+	ctx := context.Background()
+
+	_ = remote.Set(ctx, "key", "value", 0)
+	for range 2 {
+		if v, err := tiered.Get(ctx, "key"); err != nil || v != "value" {
+			t.Errorf("want value, got %v (%v)", v, err)
+		}
+	}
+	if stats := tiered.TierStatistics(); stats["local"]["hits"] != 1 {
+		t.Errorf("want the second read served locally, got %v", stats)
+	}
+}
@@ -0,0 +1,102 @@
+package adapters
+
+import (
+	"cacher/internal/adapters"
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestScriptsRegistry(t *testing.T) {
+	scripts := adapters.NewScripts()
+	echo := scripts.Register("echo", "return ARGV[1]")
+	if again := scripts.Register("echo", "return ARGV[1]"); again != echo {
+		t.Error("want registering the same script twice to return it")
+	}
+	if echo.Hash() != "098e0f0d1448c0a81dafe820f66d460eb09263da" {
+		t.Errorf("want the SHA1 of the source, got %s", echo.Hash())
+	}
+	if found, ok := scripts.Get("echo"); !ok || found != echo {
+		t.Error("want the script found by name")
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("want another source under the same name to panic")
+		}
+	}()
+	scripts.Register("echo", "return 1")
+}
+
+func TestRedisScripts(t *testing.T) {
+	client := redisOrSkip(t)
+	ctx := context.Background()
+	scripts := adapters.NewScripts()
+	scripts.Register("echo", "return ARGV[1]")
+
+	if v, err := scripts.Run(ctx, client, "echo", nil, "hello").Text(); err != nil || v != "hello" {
+		t.Fatalf("want hello, got %q (%v)", v, err)
+	}
+	// A flushed script cache is reloaded on NOSCRIPT
+	if err := client.ScriptFlush(ctx).Err(); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := scripts.Run(ctx, client, "echo", nil, "again").Text(); err != nil || v != "again" {
+		t.Errorf("want the script reloaded, got %q (%v)", v, err)
+	}
+	if err := scripts.Run(ctx, client, "missing", nil).Err(); !errors.Is(err, adapters.ErrUnknownScript) {
+		t.Errorf("want ErrUnknownScript, got %v", err)
+	}
+
+	server := &adapters.RedisClient{Client: client}
+	if err := server.LoadScripts(ctx); err != nil {
+		t.Fatal(err)
+	}
+	hash, _ := adapters.DefaultScripts.Get("rate_limiter")
+	if exists, _ := client.ScriptExists(ctx, hash.Hash()).Result(); len(exists) != 1 || !exists[0] {
+		t.Error("want the built in scripts loaded")
+	}
+}
+
+func testRateLimiterRace(t *testing.T, server adapters.CacheServer) {
+	ctx := context.Background()
+	key := "limit:" + t.Name()
+	_, _ = server.Delete(ctx, key)
+
+	var allowed atomic.Int64
+	var wg sync.WaitGroup
+	for range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if left, err := server.CountRateLimiter(ctx, key, 20, 1, time.Minute); err == nil && left >= 0 {
+				allowed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if allowed.Load() != 20 {
+		t.Errorf("want exactly 20 allowed, got %d", allowed.Load())
+	}
+	if ttl, _ := server.TTL(ctx, key); ttl <= 0 {
+		t.Errorf("want the counter to expire, got %v", ttl)
+	}
+
+	_, _ = server.Delete(ctx, key)
+	if left, err := server.RateLimiter(ctx, key, 3, time.Minute); err != nil || left != 2 {
+		t.Errorf("want 2 left, got %v (%v)", left, err)
+	}
+	if left, _ := server.RateLimiter(ctx, key, 3, time.Minute); left != 1 {
+		t.Errorf("want 1 left, got %d", left)
+	}
+}
+
+func TestMemoryRateLimiterRace(t *testing.T) {
+	testRateLimiterRace(t, adapters.NewMemory())
+}
+
+func TestRedisRateLimiterRace(t *testing.T) {
+	testRateLimiterRace(t, &adapters.RedisClient{Client: redisOrSkip(t)})
+}
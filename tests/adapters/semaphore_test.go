@@ -0,0 +1,44 @@
+package adapters
+
+import (
+	"cacher/internal/adapters"
+	"context"
+	"testing"
+	"time"
+)
+
+func testSemaphore(t *testing.T, server adapters.CacheServer) {
+	ctx := context.Background()
+	key := "semaphore:" + t.Name()
+	_, _ = server.Delete(ctx, key)
+
+	for _, holder := range []string{"a", "b"} {
+		if ok, err := server.AcquireSemaphore(ctx, key, holder, 2, time.Minute); err != nil || !ok {
+			t.Fatalf("want %s to acquire, got %v (%v)", holder, ok, err)
+		}
+	}
+	if ok, _ := server.AcquireSemaphore(ctx, key, "c", 2, time.Minute); ok {
+		t.Errorf("want full semaphore to reject")
+	}
+	if ok, _ := server.AcquireSemaphore(ctx, key, "a", 2, time.Minute); !ok {
+		t.Errorf("want existing holder to renew")
+	}
+
+	_, _ = server.ZRem(ctx, key, "a")
+	if ok, _ := server.AcquireSemaphore(ctx, key, "c", 2, 50*time.Millisecond); !ok {
+		t.Errorf("want released slot to be reused")
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if ok, _ := server.AcquireSemaphore(ctx, key, "d", 2, time.Minute); !ok {
+		t.Errorf("want expired slot to be reclaimed")
+	}
+}
+
+func TestMemorySemaphore(t *testing.T) {
+	testSemaphore(t, adapters.NewMemory())
+}
+
+func TestRedisSemaphore(t *testing.T) {
+	testSemaphore(t, &adapters.RedisClient{Client: redisOrSkip(t)})
+}
@@ -0,0 +1,39 @@
+package adapters
+
+import (
+	"cacher/internal/adapters"
+	"context"
+	"testing"
+)
+
+func testSet(t *testing.T, server adapters.CacheServer) {
+	ctx := context.Background()
+	key := "set:" + t.Name()
+	_, _ = server.Delete(ctx, key)
+
+	_, _ = server.SAdd(ctx, key, "a", "b", "c")
+	if found, err := server.SIsMember(ctx, key, "b"); err != nil || !found {
+		t.Errorf("want b in the set, got %v (%v)", found, err)
+	}
+	if found, _ := server.SIsMember(ctx, key, "d"); found {
+		t.Error("want d missing")
+	}
+	if removed, err := server.SRem(ctx, key, "a", "d"); err != nil || removed != 1 {
+		t.Errorf("want 1 removed, got %v (%v)", removed, err)
+	}
+	if count, err := server.SCard(ctx, key); err != nil || count != 2 {
+		t.Errorf("want 2 members, got %v (%v)", count, err)
+	}
+	_, _ = server.SRem(ctx, key, "b", "c")
+	if exists, _ := server.Exists(ctx, key); exists {
+		t.Error("want an empty set deleted")
+	}
+}
+
+func TestMemorySet(t *testing.T) {
+	testSet(t, adapters.NewMemory())
+}
+
+func TestRedisSet(t *testing.T) {
+	testSet(t, &adapters.RedisClient{Client: redisOrSkip(t)})
+}
@@ -0,0 +1,169 @@
+package adapters
+
+import (
+	"cacher/internal/adapters"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"testing"
+	"time"
+)
+
+func newSharded(names ...string) (*adapters.Sharded, map[string]*adapters.Memory) {
+	memories := make(map[string]*adapters.Memory)
+	shards := make(map[string]adapters.CacheServer)
+	for _, name := range names {
+		memories[name] = adapters.NewMemory()
+		shards[name] = memories[name]
+	}
+	return adapters.NewSharded(0, shards), memories
+}
+
+func TestShardedSpreadsKeys(t *testing.T) {
+	ctx := context.Background()
+	sharded, memories := newSharded("a", "b", "c")
+	for i := range 3000 {
+		if err := sharded.Set(ctx, fmt.Sprintf("key:%d", i), "value", 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name, memory := range memories {
+		if count := countKeys(t, memory, "*"); count < 800 || count > 1200 {
+			t.Errorf("want about a third of the keys on shard %s, got %d", name, count)
+		}
+	}
+
+	if value, err := sharded.Get(ctx, "key:42"); err != nil || value != "value" {
+		t.Errorf("want the value back, got %q (%v)", value, err)
+	}
+	if sharded.Locate("{user:1}:profile") != sharded.Locate("{user:1}:settings") {
+		t.Error("want keys sharing a hash tag on one shard")
+	}
+
+	if scanned := countKeys(t, sharded, "key:*"); scanned != 3000 {
+		t.Errorf("want every key scanned, got %d", scanned)
+	}
+}
+
+func countKeys(t *testing.T, server adapters.CacheServer, match string) int {
+	t.Helper()
+	var count int
+	var cursor uint64
+	for {
+		keys, next, err := server.Scan(context.Background(), cursor, match, 500)
+		if err != nil {
+			t.Fatal(err)
+		}
+		count += len(keys)
+		if next == 0 {
+			return count
+		}
+		cursor = next
+	}
+}
+
+func TestShardedMultiKey(t *testing.T) {
+	ctx := context.Background()
+	sharded, _ := newSharded("a", "b", "c")
+
+	values := map[string]interface{}{}
+	keys := []string{"missing"}
+	for i := range 20 {
+		values[fmt.Sprintf("k%d", i)] = fmt.Sprint(i)
+		keys = append(keys, fmt.Sprintf("k%d", i))
+	}
+	if err := sharded.MSet(ctx, values, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	found, err := sharded.MGet(ctx, keys...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found[0] != nil || found[1] != "0" || found[20] != "19" {
+		t.Errorf("want values in the order of the keys, got %v", found)
+	}
+	if deleted, err := sharded.Delete(ctx, keys...); err != nil || deleted != 20 {
+		t.Errorf("want 20 keys deleted, got %v (%v)", deleted, err)
+	}
+
+	ops := []*adapters.PipelineOp{
+		{Command: adapters.PipelineSet, Keys: []string{"p1"}, Value: "1"},
+		{Command: adapters.PipelineSet, Keys: []string{"p2"}, Value: "2"},
+		{Command: adapters.PipelineDelete, Keys: []string{"p1", "p2", "p3"}},
+	}
+	if err := sharded.Pipeline(ctx, ops[:2]); err != nil {
+		t.Fatal(err)
+	}
+	if err := sharded.Pipeline(ctx, ops[2:]); err != nil || ops[2].Result != int64(2) {
+		t.Errorf("want a Delete across shards split up, got %v (%v)", ops[2].Result, ops[2].Err)
+	}
+
+	a, b := "{list}:a", "b"
+	for sharded.Locate(b) == sharded.Locate(a) {
+		b += "b"
+	}
+	if _, err := sharded.LMove(ctx, a, b, "LEFT", "RIGHT"); !errors.Is(err, adapters.ErrCrossShard) {
+		t.Errorf("want ErrCrossShard, got %v", err)
+	}
+	_ = sharded.Push(ctx, a, "x")
+	if moved, err := sharded.LMove(ctx, a, "{list}:b", "LEFT", "RIGHT"); err != nil || moved != "x" {
+		t.Errorf("want keys with one hash tag moved, got %q (%v)", moved, err)
+	}
+}
+
+func TestShardedRebalance(t *testing.T) {
+	ctx := context.Background()
+	sharded, _ := newSharded("a", "b")
+	for i := range 1000 {
+		_ = sharded.Set(ctx, fmt.Sprintf("key:%d", i), fmt.Sprint(i), time.Hour)
+	}
+
+	sharded.AddShard("c", adapters.NewMemory())
+	// Keys the new shard took over are found, and moved, before a rebalance
+	if value, err := sharded.Get(ctx, "key:7"); err != nil || value != "7" {
+		t.Fatalf("want key:7 found after adding a shard, got %q (%v)", value, err)
+	}
+	notMoved := 0
+	for i := range 1000 {
+		key := fmt.Sprintf("key:%d", i)
+		if _, err := sharded.Shards()[sharded.Locate(key)].Get(ctx, key); errors.Is(err, redis.Nil) {
+			notMoved++
+		}
+	}
+	if notMoved < 200 || notMoved > 450 {
+		t.Errorf("want about a third of the keys to move, %d must", notMoved)
+	}
+
+	moved, err := sharded.Rebalance(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if moved != int64(notMoved) {
+		t.Errorf("want %d keys moved, got %d", notMoved, moved)
+	}
+	for i := range 1000 {
+		key := fmt.Sprintf("key:%d", i)
+		if value, err := sharded.Shards()[sharded.Locate(key)].Get(ctx, key); err != nil || value != fmt.Sprint(i) {
+			t.Fatalf("want %s on its shard after Rebalance, got %q (%v)", key, value, err)
+		}
+	}
+	if ttl, _ := sharded.TTL(ctx, "key:7"); ttl <= 0 || ttl > time.Hour {
+		t.Errorf("want the expiration kept, got %s", ttl)
+	}
+
+	if !sharded.RemoveShard("a") {
+		t.Fatal("want shard a removed")
+	}
+	if _, err := sharded.Rebalance(ctx); err != nil {
+		t.Fatal(err)
+	}
+	for i := range 1000 {
+		if _, err := sharded.Get(ctx, fmt.Sprintf("key:%d", i)); err != nil {
+			t.Fatalf("want key:%d kept after removing a shard, got %v", i, err)
+		}
+	}
+	if len(sharded.Shards()) != 2 {
+		t.Errorf("want 2 shards left, got %d", len(sharded.Shards()))
+	}
+}
@@ -0,0 +1,81 @@
+package adapters
+
+import (
+	"bytes"
+	"cacher/internal/adapters"
+	"context"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMemorySnapshotRestore(t *testing.T) {
+	m := adapters.NewMemory()
+	ctx := context.Background()
+	_ = m.Set(ctx, "string", "value", time.Minute)
+	_ = m.Set(ctx, "expiring", "value", 20*time.Millisecond)
+	_, _ = m.HSet(ctx, "hash", map[string]interface{}{"field": "value"})
+	_ = m.Push(ctx, "list", "a", "b")
+	_, _ = m.XAdd(ctx, "stream", 0, map[string]interface{}{"field": "value"})
+
+	var snapshot bytes.Buffer
+	if err := m.Snapshot(&snapshot); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(30 * time.Millisecond)
+
+	restored := adapters.NewMemory()
+	_ = restored.Set(ctx, "string", "replaced", 0)
+	_ = restored.Set(ctx, "other", "kept", 0)
+	if err := restored.Restore(&snapshot); err != nil {
+		t.Fatal(err)
+	}
+	if value, _ := restored.Get(ctx, "string"); value != "value" {
+		t.Errorf("want the snapshot to replace string, got %q", value)
+	}
+	if ttl, _ := restored.TTL(ctx, "string"); ttl <= 0 || ttl > time.Minute {
+		t.Errorf("want the expiration kept, got %s", ttl)
+	}
+	if _, err := restored.Get(ctx, "expiring"); !errors.Is(err, redis.Nil) {
+		t.Errorf("want keys that expired since skipped, got %v", err)
+	}
+	if value, _ := restored.HGet(ctx, "hash", "field"); value != "value" {
+		t.Errorf("want the hash restored, got %q", value)
+	}
+	if list, _ := restored.List(ctx, "list"); len(list) != 2 {
+		t.Errorf("want the list restored, got %v", list)
+	}
+	if length, _ := restored.XLen(ctx, "stream"); length != 1 {
+		t.Errorf("want the stream restored, got %d entries", length)
+	}
+	if value, _ := restored.Get(ctx, "other"); value != "kept" {
+		t.Errorf("want keys missing from the snapshot kept, got %q", value)
+	}
+
+	if err := restored.Restore(bytes.NewBufferString("not a snapshot")); !errors.Is(err, adapters.ErrCorruptSnapshot) {
+		t.Errorf("want ErrCorruptSnapshot, got %v", err)
+	}
+}
+
+func TestMemorySnapshotFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "memory.snapshot")
+	m := adapters.NewMemory()
+	ctx := context.Background()
+
+	if err := m.LoadSnapshot(path); err != nil {
+		t.Fatalf("want a missing snapshot ignored, got %v", err)
+	}
+	_ = m.Set(ctx, "key", "value", 0)
+	if err := m.SaveSnapshot(path); err != nil {
+		t.Fatal(err)
+	}
+	restored := adapters.NewMemory()
+	if err := restored.LoadSnapshot(path); err != nil {
+		t.Fatal(err)
+	}
+	if value, _ := restored.Get(ctx, "key"); value != "value" {
+		t.Errorf("want key restored, got %q", value)
+	}
+}
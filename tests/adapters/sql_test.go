@@ -0,0 +1,96 @@
+package adapters
+
+import (
+	"cacher/internal/adapters"
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	_ "modernc.org/sqlite"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func openSQLite(t *testing.T, opts ...adapters.MemoryOption) (*adapters.Memory, *sql.DB) {
+	t.Helper()
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "cache.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = db.Close() })
+
+	server, err := adapters.OpenSQL(context.Background(), db, adapters.SQLite, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = server.Close() })
+	return server, db
+}
+
+func TestSQLSetGet(t *testing.T) {
+	server, db := openSQLite(t)
+	ctx := context.Background()
+
+	_ = server.Set(ctx, "key", "value", time.Hour)
+	if v, err := server.Get(ctx, "key"); err != nil || v != "value" {
+		t.Errorf("want value, got %q (%v)", v, err)
+	}
+	var expiresAt sql.NullInt64
+	if err := db.QueryRow("SELECT expires_at FROM cache_entries WHERE key = ?", "key").Scan(&expiresAt); err != nil || !expiresAt.Valid {
+		t.Errorf("want expires_at set, got %v (%v)", expiresAt, err)
+	}
+	if v, _ := server.IncrBy(ctx, "counter", 5, 0); v != 5 {
+		t.Errorf("want 5, got %d", v)
+	}
+	if deleted, _ := server.Delete(ctx, "key", "counter"); deleted != 2 {
+		t.Errorf("want 2 deleted keys, got %d", deleted)
+	}
+}
+
+func TestSQLSetNX(t *testing.T) {
+	server, _ := openSQLite(t)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	won := 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if ok, err := server.SetNX(ctx, "lock", "holder", time.Minute); err == nil && ok {
+				mutex.Lock()
+				won++
+				mutex.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if won != 1 {
+		t.Errorf("want exactly one SetNX to win, got %d", won)
+	}
+}
+
+func TestSQLExpiration(t *testing.T) {
+	server, db := openSQLite(t, adapters.WithCleanupInterval(10*time.Millisecond))
+	ctx := context.Background()
+
+	_ = server.Set(ctx, "short", "value", 20*time.Millisecond)
+	time.Sleep(60 * time.Millisecond)
+	if _, err := server.Get(ctx, "short"); !errors.Is(err, redis.Nil) {
+		t.Errorf("want the key expired, got %v", err)
+	}
+	var rows int
+	_ = db.QueryRow("SELECT COUNT(*) FROM cache_entries").Scan(&rows)
+	if rows != 0 {
+		t.Errorf("want the expired row deleted, got %d rows", rows)
+	}
+}
+
+func TestSQLTokenBucket(t *testing.T) {
+	server, _ := openSQLite(t)
+	testTokenBucket(t, server)
+}
@@ -0,0 +1,138 @@
+package adapters
+
+import (
+	"cacher/internal/adapters"
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func testStreams(t *testing.T, server adapters.CacheServer) {
+	ctx := context.Background()
+	stream := "stream:" + t.Name()
+	_, _ = server.Delete(ctx, stream)
+
+	if _, err := server.XReadGroup(ctx, stream, "workers", "a", 10, 0); err == nil {
+		t.Error("want reading through a missing group to fail")
+	}
+	if err := server.XGroupCreate(ctx, stream, "workers", "$"); err != nil {
+		t.Fatal(err)
+	}
+	if err := server.XGroupCreate(ctx, stream, "workers", "$"); err != nil {
+		t.Errorf("want creating an existing group to do nothing, got %v", err)
+	}
+
+	first, err := server.XAdd(ctx, stream, 0, map[string]interface{}{"n": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, _ := server.XAdd(ctx, stream, 0, map[string]interface{}{"n": 2})
+	if n, _ := server.XLen(ctx, stream); n != 2 {
+		t.Errorf("want 2 entries, got %d", n)
+	}
+
+	messages, err := server.XReadGroup(ctx, stream, "workers", "a", 1, 0)
+	if err != nil || len(messages) != 1 || messages[0].ID != first || messages[0].Values["n"] != "1" {
+		t.Fatalf("want the first entry, got %v (%v)", messages, err)
+	}
+	messages, _ = server.XReadGroup(ctx, stream, "workers", "b", 10, 0)
+	if len(messages) != 1 || messages[0].ID != second {
+		t.Fatalf("want the second entry for another consumer, got %v", messages)
+	}
+	if messages, err := server.XReadGroup(ctx, stream, "workers", "a", 10, 0); err != nil || len(messages) != 0 {
+		t.Errorf("want nothing left to read, got %v (%v)", messages, err)
+	}
+
+	if n, err := server.XAck(ctx, stream, "workers", second); err != nil || n != 1 {
+		t.Errorf("want 1 acknowledged, got %v (%v)", n, err)
+	}
+	if n, _ := server.XAck(ctx, stream, "workers", second); n != 0 {
+		t.Errorf("want an acknowledged entry not to be pending, got %d", n)
+	}
+
+	// The first entry is still pending for a and can be claimed by c
+	if claimed, _, _ := server.XAutoClaim(ctx, stream, "workers", "c", time.Hour, "0-0", 10); len(claimed) != 0 {
+		t.Errorf("want nothing idle for an hour, got %v", claimed)
+	}
+	time.Sleep(20 * time.Millisecond)
+	claimed, next, err := server.XAutoClaim(ctx, stream, "workers", "c", 10*time.Millisecond, "0-0", 10)
+	if err != nil || len(claimed) != 1 || claimed[0].ID != first || next != "0-0" {
+		t.Errorf("want the first entry claimed, got %v %q (%v)", claimed, next, err)
+	}
+
+	// A positive block waits for the next entry
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		_, _ = server.XAdd(ctx, stream, 0, map[string]interface{}{"n": 3})
+	}()
+	messages, err = server.XReadGroup(ctx, stream, "workers", "a", 10, 2*time.Second)
+	if err != nil || len(messages) != 1 || messages[0].Values["n"] != "3" {
+		t.Errorf("want the entry added while blocked, got %v (%v)", messages, err)
+	}
+	start := time.Now()
+	if messages, err := server.XReadGroup(ctx, stream, "workers", "a", 10, 50*time.Millisecond); err != nil || len(messages) != 0 {
+		t.Errorf("want nothing after blocking, got %v (%v)", messages, err)
+	}
+	if time.Since(start) < 40*time.Millisecond {
+		t.Error("want the read to block")
+	}
+
+	// A group created from 0 sees the whole stream
+	_ = server.XGroupCreate(ctx, stream, "audit", "0")
+	if messages, _ := server.XReadGroup(ctx, stream, "audit", "a", 0, 0); len(messages) != 3 {
+		t.Errorf("want every entry for a new group from 0, got %d", len(messages))
+	}
+
+	for range 5 {
+		_, _ = server.XAdd(ctx, stream, 4, map[string]interface{}{"n": 0})
+	}
+	if n, _ := server.XLen(ctx, stream); n != 4 {
+		t.Errorf("want the stream trimmed to 4, got %d", n)
+	}
+}
+
+func TestMemoryStreams(t *testing.T) {
+	testStreams(t, adapters.NewMemory())
+
+	m := adapters.NewMemory()
+	ctx := context.Background()
+	if _, err := m.XReadGroup(ctx, "missing", "g", "a", 1, 0); !errors.Is(err, adapters.ErrNoGroup) {
+		t.Errorf("want ErrNoGroup, got %v", err)
+	}
+	_ = m.Set(ctx, "text", "x", 0)
+	if _, err := m.XAdd(ctx, "text", 0, map[string]interface{}{"a": 1}); !errors.Is(err, adapters.ErrWrongType) {
+		t.Errorf("want ErrWrongType, got %v", err)
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	_ = m.XGroupCreate(ctx, "s", "g", "$")
+	if _, err := m.XReadGroup(canceled, "s", "g", "a", 1, time.Minute); !errors.Is(err, context.Canceled) {
+		t.Errorf("want a blocked read to end with its context, got %v", err)
+	}
+}
+
+func TestBoltStreams(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.db")
+	ctx := context.Background()
+
+	server := openBolt(t, path)
+	testStreams(t, server)
+	_ = server.XGroupCreate(ctx, "events", "g", "0")
+	id, _ := server.XAdd(ctx, "events", 0, map[string]interface{}{"kind": "signup"})
+	_, _ = server.XReadGroup(ctx, "events", "g", "a", 1, 0)
+	_ = server.Close()
+
+	server = openBolt(t, path)
+	defer server.Close()
+	claimed, _, err := server.XAutoClaim(ctx, "events", "g", "b", 0, "0-0", 10)
+	if err != nil || len(claimed) != 1 || claimed[0].ID != id || claimed[0].Values["kind"] != "signup" {
+		t.Errorf("want the pending entry kept across reopening, got %v (%v)", claimed, err)
+	}
+}
+
+func TestRedisStreams(t *testing.T) {
+	testStreams(t, &adapters.RedisClient{Client: redisOrSkip(t)})
+}
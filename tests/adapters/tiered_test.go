@@ -0,0 +1,58 @@
+package adapters
+
+import (
+	"cacher/internal/adapters"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTieredWriteBack(t *testing.T) {
+	remote := adapters.NewMemory()
+	tiered := adapters.NewTiered(adapters.NewMemory(), adapters.NewCache(remote), time.Minute)
+	ctx := context.Background()
+
+	_ = remote.Set(ctx, "key", "value", 0)
+	for range 2 {
+		if v, err := tiered.Get(ctx, "key"); err != nil || v != "value" {
+			t.Errorf("want value, got %v (%v)", v, err)
+		}
+	}
+
+	stats := tiered.TierStatistics()
+	if stats["local"]["hits"] != 1 || stats["remote"]["hits"] != 1 {
+		t.Errorf("want one hit per tier, got %v", stats)
+	}
+
+	_ = tiered.Delete(ctx, "key")
+	if _, err := tiered.Get(ctx, "key"); err == nil {
+		t.Errorf("want key deleted from both tiers")
+	}
+}
+
+func TestTieredInvalidation(t *testing.T) {
+	client := redisOrSkip(t)
+	ctx := context.Background()
+	remote := adapters.NewCache(&adapters.RedisClient{Client: client})
+
+	nodes := make([]*adapters.Tiered, 2)
+	for i := range nodes {
+		nodes[i] = adapters.NewTiered(adapters.NewMemory(), remote, time.Minute)
+		bus := adapters.NewRedisInvalidationBus(client, "test:invalidation")
+		if err := nodes[i].UseBus(bus); err != nil {
+			t.Fatal(err)
+		}
+		defer bus.Close()
+	}
+
+	_ = nodes[0].Set(ctx, "tiered:key", "old", time.Minute)
+	if v, _ := nodes[1].Get(ctx, "tiered:key"); v != "old" {
+		t.Fatalf("want old, got %v", v)
+	}
+
+	_ = nodes[0].Set(ctx, "tiered:key", "new", time.Minute)
+	time.Sleep(50 * time.Millisecond)
+	if v, _ := nodes[1].Get(ctx, "tiered:key"); v != "new" {
+		t.Errorf("want new after invalidation, got %v", v)
+	}
+}
@@ -0,0 +1,59 @@
+package adapters
+
+import (
+	"cacher/internal/adapters"
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func testWatch(t *testing.T, server adapters.CacheServer) {
+	ctx := context.Background()
+	if err := server.Set(ctx, "balance", "10", 0); err != nil {
+		t.Fatal(err)
+	}
+
+	incr := &adapters.PipelineOp{Command: adapters.PipelineIncr, Keys: []string{"balance"}, Delta: -3}
+	err := server.Watch(ctx, func(tx adapters.TxReader) ([]*adapters.PipelineOp, error) {
+		if v, err := tx.Get(ctx, "balance"); err != nil || v != "10" {
+			t.Errorf("want 10 inside the transaction, got %q (%v)", v, err)
+		}
+		return []*adapters.PipelineOp{incr}, nil
+	}, "balance")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if incr.Result != int64(7) {
+		t.Errorf("want 7, got %v (%v)", incr.Result, incr.Err)
+	}
+
+	set := &adapters.PipelineOp{Command: adapters.PipelineSet, Keys: []string{"balance"}, Value: "100"}
+	err = server.Watch(ctx, func(tx adapters.TxReader) ([]*adapters.PipelineOp, error) {
+		if err := server.Set(ctx, "balance", "8", 0); err != nil {
+			return nil, err
+		}
+		return []*adapters.PipelineOp{set}, nil
+	}, "balance")
+	if !errors.Is(err, adapters.ErrTxFailed) {
+		t.Errorf("want ErrTxFailed after a concurrent write, got %v", err)
+	}
+	if v, _ := server.Get(ctx, "balance"); v != "8" {
+		t.Errorf("want the write of the failed transaction dropped, got %q", v)
+	}
+}
+
+func TestMemoryWatch(t *testing.T) {
+	testWatch(t, adapters.NewPrefixed(adapters.NewMemory(), "app:"))
+}
+
+func TestBoltWatch(t *testing.T) {
+	server := openBolt(t, filepath.Join(t.TempDir(), "cache.db"))
+	defer server.Close()
+	testWatch(t, server)
+}
+
+func TestRedisWatch(t *testing.T) {
+	client := redisOrSkip(t)
+	testWatch(t, adapters.NewPrefixed(&adapters.RedisClient{Client: client}, "watch:"))
+}
@@ -0,0 +1,91 @@
+package adapters
+
+import (
+	"cacher/internal/adapters"
+	"context"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"testing"
+)
+
+func testSortedSet(t *testing.T, server adapters.CacheServer) {
+	ctx := context.Background()
+	key := "zset:" + t.Name()
+	_, _ = server.Delete(ctx, key)
+
+	added, err := server.ZAdd(ctx, key, redis.Z{Member: "b", Score: 2}, redis.Z{Member: "a", Score: 2}, redis.Z{Member: "c", Score: 1})
+	if err != nil || added != 3 {
+		t.Fatalf("want 3 members added, got %v (%v)", added, err)
+	}
+	if score, err := server.ZIncrBy(ctx, key, 5, "c"); err != nil || score != 6 {
+		t.Errorf("want 6, got %v (%v)", score, err)
+	}
+	if score, _ := server.ZScore(ctx, key, "a"); score != 2 {
+		t.Errorf("want 2, got %v", score)
+	}
+	if _, err := server.ZScore(ctx, key, "missing"); !errors.Is(err, redis.Nil) {
+		t.Errorf("want redis.Nil, got %v", err)
+	}
+
+	if rank, _ := server.ZRank(ctx, key, "b"); rank != 1 {
+		t.Errorf("want b ranked 1 ascending, ties by member, got %v", rank)
+	}
+	if rank, _ := server.ZRevRank(ctx, key, "c"); rank != 0 {
+		t.Errorf("want c ranked 0 descending, got %v", rank)
+	}
+	if _, err := server.ZRank(ctx, key, "missing"); !errors.Is(err, redis.Nil) {
+		t.Errorf("want redis.Nil, got %v", err)
+	}
+
+	ascending, _ := server.ZRangeWithScores(ctx, key, 0, -1)
+	if len(ascending) != 3 || ascending[0].Member != "a" || ascending[2].Member != "c" {
+		t.Errorf("want a, b, c, got %v", ascending)
+	}
+	descending, _ := server.ZRevRangeWithScores(ctx, key, -2, 10)
+	if len(descending) != 2 || descending[0].Member != "b" || descending[1].Member != "a" || descending[1].Score != 2 {
+		t.Errorf("want b, a, got %v", descending)
+	}
+	if empty, err := server.ZRangeWithScores(ctx, key+":missing", 0, -1); err != nil || len(empty) != 0 {
+		t.Errorf("want no members of a missing key, got %v (%v)", empty, err)
+	}
+}
+
+func TestMemorySortedSet(t *testing.T) {
+	testSortedSet(t, adapters.NewMemory())
+}
+
+func TestRedisSortedSet(t *testing.T) {
+	testSortedSet(t, &adapters.RedisClient{Client: redisOrSkip(t)})
+}
+
+func testZMoveToList(t *testing.T, server adapters.CacheServer) {
+	ctx := context.Background()
+	source, destination := "delayed:"+t.Name(), "ready:"+t.Name()
+	_, _ = server.Delete(ctx, source, destination)
+
+	_, _ = server.ZAdd(ctx, source, redis.Z{Member: "c", Score: 3}, redis.Z{Member: "a", Score: 1}, redis.Z{Member: "b", Score: 2}, redis.Z{Member: "later", Score: 10})
+	if moved, err := server.ZMoveToList(ctx, source, destination, 3, 2); err != nil || moved != 2 {
+		t.Fatalf("want 2 moved, got %v (%v)", moved, err)
+	}
+	if moved, _ := server.ZMoveToList(ctx, source, destination, 3, 0); moved != 1 {
+		t.Errorf("want the last due member moved, got %d", moved)
+	}
+	// Lowest scores are pushed first, so they are at the tail
+	if list, _ := server.List(ctx, destination); len(list) != 3 || list[0] != "c" || list[2] != "a" {
+		t.Errorf("want c b a, got %v", list)
+	}
+	if _, err := server.ZScore(ctx, source, "later"); err != nil {
+		t.Errorf("want the member not due kept, got %v", err)
+	}
+	if moved, _ := server.ZMoveToList(ctx, "delayed:missing", destination, 3, 0); moved != 0 {
+		t.Errorf("want nothing moved from a missing key, got %d", moved)
+	}
+}
+
+func TestMemoryZMoveToList(t *testing.T) {
+	testZMoveToList(t, adapters.NewMemory())
+}
+
+func TestRedisZMoveToList(t *testing.T) {
+	testZMoveToList(t, &adapters.RedisClient{Client: redisOrSkip(t)})
+}
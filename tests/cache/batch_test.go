@@ -0,0 +1,30 @@
+package cache
+
+import (
+	"cacher/pkg"
+	"context"
+	"testing"
+)
+
+func TestGetManySetMany(t *testing.T) {
+	c := pkg.NewMemoryCache(pkg.WithDefaultTTL(pkg.Forever), pkg.WithStats(true))
+	ctx := context.Background()
+
+	err := c.SetMany(ctx, map[string]interface{}{"a": "1", "b": "2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values, err := c.GetMany(ctx, "a", "b", "c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(values) != 2 || values["a"] != "1" || values["b"] != "2" {
+		t.Errorf("want a and b, got %v", values)
+	}
+
+	stats := c.Statistics(ctx)
+	if stats["a"]["hits"] != 1 || stats["c"]["misses"] != 1 {
+		t.Errorf("want per key hits and misses, got %v", stats)
+	}
+}
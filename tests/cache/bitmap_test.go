@@ -0,0 +1,54 @@
+package cache
+
+import (
+	"cacher/pkg"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestBitmap(t *testing.T) {
+	c := pkg.NewMemoryCache()
+	defer c.Close(context.Background())
+	active, err := pkg.NewBitmap(c, "logins")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	monday := time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)
+	tuesday := monday.AddDate(0, 0, 1)
+
+	for _, id := range []int64{1, 2, 3, 1000} {
+		_ = active.Mark(ctx, id, monday)
+	}
+	for _, id := range []int64{2, 3, 4} {
+		_ = active.Mark(ctx, id, tuesday.Add(12*time.Hour))
+	}
+
+	if ok, err := active.Active(ctx, 1000, monday.Add(time.Hour)); err != nil || !ok {
+		t.Errorf("want id 1000 active on monday, got %v (%v)", ok, err)
+	}
+	if ok, _ := active.Active(ctx, 1, tuesday); ok {
+		t.Error("want id 1 inactive on tuesday")
+	}
+	if n, err := active.Count(ctx, monday); err != nil || n != 4 {
+		t.Errorf("want 4 active on monday, got %v (%v)", n, err)
+	}
+	if n, err := active.CountRange(ctx, monday, tuesday); err != nil || n != 5 {
+		t.Errorf("want 5 active over both days, got %v (%v)", n, err)
+	}
+	if n, _ := active.CountRange(ctx, monday.AddDate(0, 0, -30), monday); n != 4 {
+		t.Errorf("want days without activity to count for nothing, got %d", n)
+	}
+	if n, err := active.Retained(ctx, monday, tuesday); err != nil || n != 2 {
+		t.Errorf("want 2 retained, got %v (%v)", n, err)
+	}
+	for key := range c.Keys(ctx, "bitmap:logins:scratch:*") {
+		t.Errorf("want scratch keys deleted, found %s", key)
+	}
+
+	_ = active.Delete(ctx, monday)
+	if n, _ := active.Count(ctx, monday); n != 0 {
+		t.Errorf("want monday deleted, got %d", n)
+	}
+}
@@ -0,0 +1,34 @@
+package cache
+
+import (
+	"cacher/pkg"
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestBoltCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.db")
+	ctx := context.Background()
+
+	c, err := pkg.NewBoltCache(path, pkg.WithDefaultTTL(pkg.Forever))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = c.Set(ctx, "key", "value")
+	if err := c.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err = pkg.NewBoltCache(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close(ctx)
+	if v, err := c.Get(ctx, "key"); err != nil || v != "value" {
+		t.Errorf("want the value to survive a restart, got %v (%v)", v, err)
+	}
+	if h := c.Health(ctx); h.Backend != "bbolt" {
+		t.Errorf("want backend bbolt, got %q", h.Backend)
+	}
+}
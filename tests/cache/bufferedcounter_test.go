@@ -0,0 +1,80 @@
+package cache
+
+import (
+	"cacher/pkg"
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCounterFlushesPeriodically(t *testing.T) {
+	c := pkg.NewMemoryCache()
+	defer c.Close(context.Background())
+	ctx := context.Background()
+	counter := pkg.NewCounter(c, "requests", 30*time.Millisecond, 0)
+	defer counter.Close(ctx)
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				counter.Increment()
+			}
+		}()
+	}
+	wg.Wait()
+	if value, err := counter.Value(ctx); err != nil || value != 1000 {
+		t.Errorf("want 1000 counted, got %d (%v)", value, err)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if pending := counter.Pending(); pending != 0 {
+		t.Errorf("want everything flushed, got %d pending", pending)
+	}
+	if stored, _ := c.Increment(ctx, "requests", 0); stored != 1000 {
+		t.Errorf("want 1000 stored, got %d", stored)
+	}
+}
+
+func TestCounterThreshold(t *testing.T) {
+	c := pkg.NewMemoryCache()
+	defer c.Close(context.Background())
+	ctx := context.Background()
+	counter := pkg.NewCounter(c, "bytes", time.Hour, 100)
+
+	counter.Add(99)
+	time.Sleep(20 * time.Millisecond)
+	if stored, _ := c.Increment(ctx, "bytes", 0); stored != 0 {
+		t.Errorf("want nothing flushed below the threshold, got %d", stored)
+	}
+	counter.Add(1)
+	time.Sleep(20 * time.Millisecond)
+	if stored, _ := c.Increment(ctx, "bytes", 0); stored != 100 {
+		t.Errorf("want the threshold to flush, got %d", stored)
+	}
+
+	counter.Add(-5)
+	if err := counter.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if stored, _ := c.Increment(ctx, "bytes", 0); stored != 95 {
+		t.Errorf("want Close to flush, got %d", stored)
+	}
+}
+
+func TestCounterThresholdOnly(t *testing.T) {
+	c := pkg.NewMemoryCache()
+	defer c.Close(context.Background())
+	ctx := context.Background()
+	counter := pkg.NewCounter(c, "bytes", 0, 10)
+	defer counter.Close(ctx)
+
+	counter.Add(10)
+	time.Sleep(20 * time.Millisecond)
+	if stored, _ := c.Increment(ctx, "bytes", 0); stored != 10 {
+		t.Errorf("want the threshold to flush without an interval, got %d", stored)
+	}
+}
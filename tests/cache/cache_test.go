@@ -3,13 +3,16 @@ package cache
 import (
 	"cacher/pkg"
 	"context"
+	"errors"
 	"fmt"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
 
 func TestWrap(t *testing.T) {
-	c := pkg.NewCache(true)
+	c := pkg.NewCache(pkg.WithDefaultTTL(pkg.Forever), pkg.WithStats(true))
 
 	value := "test"
 	loops := 100000
@@ -34,6 +37,100 @@ func TestWrap(t *testing.T) {
 	}
 }
 
+func TestWrapTTL(t *testing.T) {
+	c := pkg.NewMemoryCache()
+	ctx := context.Background()
+
+	calls := 0
+	loader := func() interface{} {
+		calls++
+		return "value"
+	}
+
+	c.WrapTTL(ctx, "ttl", 20*time.Millisecond, loader)
+	c.WrapTTL(ctx, "ttl", 20*time.Millisecond, loader)
+	if calls != 1 {
+		t.Errorf("want 1 loader call, got %v", calls)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	c.WrapTTL(ctx, "ttl", 20*time.Millisecond, loader)
+	if calls != 2 {
+		t.Errorf("want loader to run again after expiry, got %v calls", calls)
+	}
+}
+
+func TestWrapSingleflight(t *testing.T) {
+	c := pkg.NewMemoryCache(pkg.WithDefaultTTL(pkg.Forever))
+	ctx := context.Background()
+
+	var calls int32
+	var wg sync.WaitGroup
+	for range 100 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.Wrap(ctx, "popular", func() interface{} {
+				atomic.AddInt32(&calls, 1)
+				time.Sleep(50 * time.Millisecond)
+				return "value"
+			})
+		}()
+	}
+	wg.Wait()
+
+	if calls != 1 {
+		t.Errorf("want 1 loader call, got %v", calls)
+	}
+}
+
+func TestDelete(t *testing.T) {
+	c := pkg.NewMemoryCache(pkg.WithDefaultTTL(pkg.Forever), pkg.WithStats(true))
+	ctx := context.Background()
+
+	_ = c.Set(ctx, "a", "1")
+	_ = c.Set(ctx, "b", "2")
+	if err := c.DeleteMany(ctx, "a", "b"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Get(ctx, "a"); !errors.Is(err, pkg.ErrCacheMiss) {
+		t.Errorf("want ErrCacheMiss for deleted key, got %v", err)
+	}
+
+	stats, err := c.KeyStatistics(ctx, "b")
+	if err != nil || stats["deletes"] != 1 {
+		t.Errorf("want 1 delete, got %v (%v)", stats, err)
+	}
+}
+
+func TestBackendErrorIsNotMiss(t *testing.T) {
+	c := pkg.NewCache(pkg.WithRedisAddr("localhost:1"), pkg.WithStats(true))
+	ctx := context.Background()
+
+	_, err := c.Get(ctx, "key")
+	if err == nil || errors.Is(err, pkg.ErrCacheMiss) {
+		t.Errorf("want backend error, got %v", err)
+	}
+
+	stats, _ := c.KeyStatistics(ctx, "key")
+	if stats["errors"] != 1 || stats["misses"] != 0 {
+		t.Errorf("want 1 error and no misses, got %v", stats)
+	}
+}
+
+func TestClose(t *testing.T) {
+	c := pkg.NewMemoryCache()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if err := c.Close(ctx); err != nil {
+		t.Errorf("want nil, got %v", err)
+	}
+	if err := c.Close(ctx); err != nil {
+		t.Errorf("want second Close to be a no-op, got %v", err)
+	}
+}
+
 //
 //func TestWrapType(t *testing.T) {
 //	c := pkg.NewCache()
@@ -0,0 +1,29 @@
+package cache
+
+import (
+	"cacher/pkg"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestWithCompression(t *testing.T) {
+	c := pkg.NewMemoryCache(pkg.WithDefaultTTL(pkg.Forever), pkg.WithCompression(pkg.Gzip, 128))
+	defer c.Close(context.Background())
+	ctx := context.Background()
+
+	large := strings.Repeat("payload ", 200)
+	if err := c.Set(ctx, "key", large); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := c.Get(ctx, "key"); err != nil || v != large {
+		t.Errorf("want the original value from Get, got %v", err)
+	}
+	if v, err := c.GetSet(ctx, "key", "small"); err != nil || v != large {
+		t.Errorf("want the original value from GetSet, got %v", err)
+	}
+	_ = c.Set(ctx, "key", large)
+	if v, err := c.Pull(ctx, "key"); err != nil || v != large {
+		t.Errorf("want the original value from Pull, got %v", err)
+	}
+}
@@ -0,0 +1,51 @@
+package cache
+
+import (
+	"cacher/config"
+	"cacher/pkg"
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNewFromConfig(t *testing.T) {
+	ttl := config.Duration(time.Minute)
+	c, err := pkg.NewFromConfig(config.Config{Backend: "memory", MaxEntries: 100, DefaultTTL: &ttl, Prefix: "app:"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close(context.Background())
+	ctx := context.Background()
+
+	_ = c.Set(ctx, "key", "value")
+	if remaining, err := c.TTL(ctx, "key"); err != nil || remaining <= 59*time.Second {
+		t.Errorf("want the default ttl applied, got %s (%v)", remaining, err)
+	}
+}
+
+func TestNewFromConfigBackends(t *testing.T) {
+	c, err := pkg.NewFromConfig(config.Config{Backend: "bolt", Addr: filepath.Join(t.TempDir(), "cache.db")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h := c.Health(context.Background()); h.Backend != "bbolt" {
+		t.Errorf("want backend bbolt, got %q", h.Backend)
+	}
+	_ = c.Close(context.Background())
+
+	c, err = pkg.NewFromConfig(config.Config{DSN: "memory://", Disabled: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close(context.Background())
+	_ = c.SetForever(context.Background(), "key", "value")
+	if _, err := c.Get(context.Background(), "key"); !errors.Is(err, pkg.ErrCacheMiss) {
+		t.Errorf("want a disabled cache, got %v", err)
+	}
+
+	if _, err := pkg.NewFromConfig(config.Config{Backend: "redis", Addr: "localhost:6379", MaxEntries: 10}); err == nil {
+		t.Error("want an error for max_entries on redis")
+	}
+}
@@ -0,0 +1,151 @@
+package cache
+
+import (
+	"bufio"
+	"cacher/pkg"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"math/big"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRedis serves just enough RESP for a client to connect and ping, over
+// TLS when config is set. It returns the address and the commands received.
+func fakeRedis(t *testing.T, config *tls.Config) (string, func() []string) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config != nil {
+		listener = tls.NewListener(listener, config)
+	}
+	t.Cleanup(func() {
+		_ = listener.Close()
+	})
+
+	var mu sync.Mutex
+	var commands []string
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				for {
+					args, err := readCommand(r)
+					if err != nil {
+						return
+					}
+					mu.Lock()
+					commands = append(commands, strings.Join(args, " "))
+					mu.Unlock()
+					switch strings.ToUpper(args[0]) {
+					case "HELLO":
+						_, err = conn.Write([]byte("-ERR unknown command 'HELLO'\r\n"))
+					case "PING":
+						_, err = conn.Write([]byte("+PONG\r\n"))
+					default:
+						_, err = conn.Write([]byte("+OK\r\n"))
+					}
+					if err != nil {
+						return
+					}
+				}
+			}()
+		}
+	}()
+
+	return listener.Addr().String(), func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return slices.Clone(commands)
+	}
+}
+
+// readCommand reads one RESP array of bulk strings
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "*")))
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, n)
+	for i := range args {
+		if _, err := r.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		arg, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		args[i] = strings.TrimSuffix(arg, "\r\n")
+	}
+	return args, nil
+}
+
+func TestRedisAuthAndDB(t *testing.T) {
+	addr, commands := fakeRedis(t, nil)
+	c := pkg.NewCache(pkg.WithRedisAddr(addr), pkg.WithRedisAuth("app", "secret"), pkg.WithRedisDB(3))
+	defer c.Close(context.Background())
+
+	if err := c.Ping(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	received := commands()
+	for _, want := range []string{"auth app secret", "select 3"} {
+		if !slices.Contains(received, want) {
+			t.Errorf("want %q sent on connect, got %q", want, received)
+		}
+	}
+}
+
+func TestRedisTLS(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(cert)
+
+	addr, commands := fakeRedis(t, &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}})
+	c := pkg.NewCache(pkg.WithRedisAddr(addr), pkg.WithRedisTLS(&tls.Config{RootCAs: roots}))
+	defer c.Close(context.Background())
+
+	if err := c.Ping(context.Background()); err != nil {
+		t.Fatalf("want the client to connect over TLS, got %v", err)
+	}
+	if !slices.Contains(commands(), "ping") {
+		t.Errorf("want the ping received over TLS, got %q", commands())
+	}
+}
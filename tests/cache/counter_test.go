@@ -0,0 +1,95 @@
+package cache
+
+import (
+	"cacher/pkg"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestIncrement(t *testing.T) {
+	c := pkg.NewMemoryCache(pkg.WithDefaultTTL(pkg.Forever), pkg.WithLocalTier(time.Minute))
+	defer c.Close(context.Background())
+	ctx := context.Background()
+
+	if v, err := c.Increment(ctx, "visits", 5); err != nil || v != 5 {
+		t.Fatalf("want 5, got %d (%v)", v, err)
+	}
+	// Populate the local tier so the next update has to evict it
+	_, _ = c.Get(ctx, "visits")
+	if v, err := c.Decrement(ctx, "visits", 2); err != nil || v != 3 {
+		t.Fatalf("want 3, got %d (%v)", v, err)
+	}
+	if v, err := c.Get(ctx, "visits"); err != nil || v != "3" {
+		t.Errorf("want 3 after the update, got %v (%v)", v, err)
+	}
+
+	_ = c.Set(ctx, "name", "value")
+	if _, err := c.Increment(ctx, "name", 1); err == nil {
+		t.Error("want an error incrementing a non-numeric value")
+	}
+}
+
+func TestIncrementFloat(t *testing.T) {
+	c := pkg.NewMemoryCache()
+	defer c.Close(context.Background())
+	ctx := context.Background()
+
+	if v, err := c.IncrementFloat(ctx, "balance", 10.5); err != nil || v != 10.5 {
+		t.Fatalf("want 10.5, got %v (%v)", v, err)
+	}
+	if v, err := c.DecrementFloat(ctx, "balance", 0.25); err != nil || v != 10.25 {
+		t.Fatalf("want 10.25, got %v (%v)", v, err)
+	}
+	if v, err := c.Increment(ctx, "balance", 1); err == nil {
+		t.Errorf("want an error incrementing a float as an integer, got %d", v)
+	}
+}
+
+func TestIncrementTTL(t *testing.T) {
+	c := pkg.NewMemoryCache()
+	defer c.Close(context.Background())
+	ctx := context.Background()
+
+	if _, err := c.Increment(ctx, "window", 1, pkg.WithCounterTTL(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	first, err := c.TTL(ctx, "window")
+	if err != nil || first <= 0 || first > time.Minute {
+		t.Fatalf("want the counter to expire within a minute, got %v (%v)", first, err)
+	}
+
+	if _, err := c.Increment(ctx, "window", 1, pkg.WithCounterTTL(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if ttl, _ := c.TTL(ctx, "window"); ttl > time.Minute {
+		t.Errorf("want the expiration of an existing counter unchanged, got %v", ttl)
+	}
+
+	_, _ = c.Increment(ctx, "forever", 1)
+	if ttl, _ := c.TTL(ctx, "forever"); ttl != pkg.NoExpiration {
+		t.Errorf("want no expiration without a ttl, got %v", ttl)
+	}
+}
+
+func TestIncrementHooks(t *testing.T) {
+	c := pkg.NewMemoryCache(pkg.WithDefaultTTL(pkg.Forever), pkg.WithStats(true))
+	defer c.Close(context.Background())
+	ctx := context.Background()
+
+	var sets, errs int
+	c.OnSet(func(ctx context.Context, e pkg.Event) { sets++ })
+	c.OnError(func(ctx context.Context, e pkg.Event) { errs++ })
+
+	_, _ = c.Increment(ctx, "counter", 1)
+	_ = c.Set(ctx, "name", "value")
+	_, _ = c.Increment(ctx, "name", 1)
+
+	if sets != 2 || errs != 1 {
+		t.Errorf("want 2 sets and 1 error, got %d and %d", sets, errs)
+	}
+	stats, err := c.KeyStatistics(ctx, "name")
+	if err != nil || stats["errors"] != 1 {
+		t.Errorf("want the failed update counted, got %v (%v)", stats, err)
+	}
+}
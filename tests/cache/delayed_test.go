@@ -0,0 +1,72 @@
+package cache
+
+import (
+	"cacher/pkg"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDelayedQueue(t *testing.T) {
+	c := pkg.NewMemoryCache()
+	defer c.Close(context.Background())
+	ctx := context.Background()
+	warmups, err := pkg.NewDelayedQueue[string](c, "warmups", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	_, _ = warmups.Schedule(ctx, "later", now.Add(time.Hour))
+	second, _ := warmups.Schedule(ctx, "second", now.Add(-time.Second))
+	first, _ := warmups.Schedule(ctx, "first", now.Add(-time.Minute))
+
+	if n, err := warmups.Poll(ctx); err != nil || n != 2 {
+		t.Fatalf("want the 2 due items moved, got %v (%v)", n, err)
+	}
+	ready := warmups.Ready()
+	for _, want := range []string{first, second} {
+		item, err := ready.Dequeue(ctx, 0)
+		if err != nil || item.ID != want {
+			t.Fatalf("want due items oldest first, got %v (%v)", item, err)
+		}
+		if err := ready.Ack(ctx, item); err != nil {
+			t.Error(err)
+		}
+	}
+	if _, err := ready.Dequeue(ctx, 0); !errors.Is(err, pkg.ErrQueueEmpty) {
+		t.Errorf("want the item of the next hour still delayed, got %v", err)
+	}
+	if n, _ := warmups.Poll(ctx); n != 0 {
+		t.Errorf("want nothing due, got %d", n)
+	}
+}
+
+func TestDelayedQueueRun(t *testing.T) {
+	c := pkg.NewMemoryCache()
+	defer c.Close(context.Background())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	jobs, _ := pkg.NewDelayedQueue[int](c, "jobs", time.Minute)
+
+	// Many due items need more than one step of a poll
+	for i := range 250 {
+		_, _ = jobs.ScheduleAfter(ctx, i, 0)
+	}
+	_, _ = jobs.ScheduleAfter(ctx, 250, 50*time.Millisecond)
+
+	result := make(chan error, 1)
+	go func() { result <- jobs.Run(ctx, 10*time.Millisecond) }()
+	for i := 0; i <= 250; i++ {
+		item, err := jobs.Ready().Dequeue(ctx, 2*time.Second)
+		if err != nil {
+			t.Fatalf("want item %d, got %v", i, err)
+		}
+		_ = jobs.Ready().Ack(ctx, item)
+	}
+	cancel()
+	if err := <-result; !errors.Is(err, context.Canceled) {
+		t.Errorf("want Run to stop with its context, got %v", err)
+	}
+}
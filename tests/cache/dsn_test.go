@@ -0,0 +1,116 @@
+package cache
+
+import (
+	"cacher/pkg"
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOpenDefaultTTL(t *testing.T) {
+	c, err := pkg.Open("memory://?ttl=1m&max=100")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close(context.Background())
+	ctx := context.Background()
+
+	if err := c.Set(ctx, "key", "value"); err != nil {
+		t.Fatal(err)
+	}
+	if ttl, err := c.TTL(ctx, "key"); err != nil || ttl <= 59*time.Second || ttl > time.Minute {
+		t.Errorf("want about a minute left, got %s (%v)", ttl, err)
+	}
+}
+
+func TestOpenMaxBytes(t *testing.T) {
+	c, err := pkg.Open("memory://?ttl=1m&max_bytes=4096")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close(context.Background())
+	ctx := context.Background()
+
+	for i := range 1000 {
+		if err := c.Set(ctx, fmt.Sprintf("key:%d", i), strings.Repeat("x", 100)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The limit is split over the shards of the memory, so it holds only about as much
+	if stats := c.EvictionStatistics(ctx); stats["evictions"] == 0 || stats["bytes"] > 2*4096 {
+		t.Errorf("want the memory bounded to about 4096 bytes, got %v", stats)
+	}
+}
+
+func TestOpenTinyLFU(t *testing.T) {
+	c, err := pkg.Open("memory://?ttl=0&max=1024&eviction=tinylfu")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close(context.Background())
+	ctx := context.Background()
+
+	for i := range 512 {
+		for range 3 {
+			if _, err := c.Get(ctx, fmt.Sprintf("hot:%d", i)); err != nil {
+				_ = c.Set(ctx, fmt.Sprintf("hot:%d", i), "value")
+			}
+		}
+	}
+	for i := range 10000 {
+		_ = c.Set(ctx, fmt.Sprintf("cold:%d", i), "value")
+	}
+	kept := 0
+	for i := range 512 {
+		if _, err := c.Get(ctx, fmt.Sprintf("hot:%d", i)); err == nil {
+			kept++
+		}
+	}
+	if kept < 450 {
+		t.Errorf("want TinyLFU to keep the hot keys, kept %d of 512", kept)
+	}
+}
+
+func TestOpenBolt(t *testing.T) {
+	c, err := pkg.Open("bolt://" + filepath.Join(t.TempDir(), "cache.db") + "?ttl=0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close(context.Background())
+
+	if h := c.Health(context.Background()); h.Backend != "bbolt" {
+		t.Errorf("want backend bbolt, got %q", h.Backend)
+	}
+}
+
+func TestOpenParameters(t *testing.T) {
+	for _, dsn := range []string{
+		"rediss://localhost:6380/1?pool_size=20",
+		"redis://localhost?tls=true",
+		"memcached://cache-1:11211,cache-2?prefix=app:",
+	} {
+		c, err := pkg.Open(dsn)
+		if err != nil {
+			t.Errorf("%s: %v", dsn, err)
+			continue
+		}
+		_ = c.Close(context.Background())
+	}
+
+	for _, dsn := range []string{
+		"memory://?ttl=soon",
+		"memory://?max=-1",
+		"memory://?max_bytes=lots",
+		"memory://?eviction=random",
+		"redis://localhost?pool_size=many",
+		"redis://localhost?tls_ca=/nonexistent/ca.pem",
+		"memcached://localhost?timeout=1s",
+	} {
+		if _, err := pkg.Open(dsn); err == nil {
+			t.Errorf("%s: want an error", dsn)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package cache
+
+import (
+	"bytes"
+	"cacher/internal/adapters"
+	"cacher/pkg"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestWithEncryption(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 32)
+	c := pkg.NewMemoryCache(pkg.WithDefaultTTL(pkg.Forever), pkg.WithEncryption(key), pkg.WithCompression(pkg.Zstd, 64))
+	defer c.Close(context.Background())
+	ctx := context.Background()
+
+	large := strings.Repeat("personal data ", 100)
+	for _, value := range []string{"secret", large} {
+		if err := c.Set(ctx, "key", value); err != nil {
+			t.Fatal(err)
+		}
+		if v, err := c.Get(ctx, "key"); err != nil || v != value {
+			t.Errorf("want the plaintext from Get, got %v", err)
+		}
+		if v, err := c.Pull(ctx, "key"); err != nil || v != value {
+			t.Errorf("want the plaintext from Pull, got %v", err)
+		}
+	}
+}
+
+func TestWithEncryptionInvalidKey(t *testing.T) {
+	c := pkg.NewMemoryCache(pkg.WithDefaultTTL(pkg.Forever), pkg.WithEncryption([]byte("short")))
+	defer c.Close(context.Background())
+	ctx := context.Background()
+	if err := c.Set(ctx, "key", "secret"); !errors.Is(err, pkg.ErrInvalidEncryptionKey) {
+		t.Errorf("want ErrInvalidEncryptionKey from Set, got %v", err)
+	}
+	if _, err := c.Get(ctx, "key"); !errors.Is(err, pkg.ErrInvalidEncryptionKey) {
+		t.Errorf("want ErrInvalidEncryptionKey from Get, got %v", err)
+	}
+	if _, err := c.Increment(ctx, "counter", 1); !errors.Is(err, pkg.ErrInvalidEncryptionKey) {
+		t.Errorf("want ErrInvalidEncryptionKey from Increment, got %v", err)
+	}
+	if err := c.Ping(ctx); !errors.Is(err, pkg.ErrInvalidEncryptionKey) {
+		t.Errorf("want ErrInvalidEncryptionKey from Ping, got %v", err)
+	}
+
+	if _, err := pkg.Open("memory://", pkg.WithEncryption([]byte("short"))); !errors.Is(err, pkg.ErrInvalidEncryptionKey) {
+		t.Errorf("want Open to fail with ErrInvalidEncryptionKey, got %v", err)
+	}
+}
+
+func TestWithEncryptionRejectsPlaintext(t *testing.T) {
+	server := adapters.NewMemory()
+	ctx := context.Background()
+	_ = server.Set(ctx, "key", "forged", 0)
+
+	key := bytes.Repeat([]byte{7}, 32)
+	c := pkg.NewCache(pkg.WithDefaultTTL(pkg.Forever), pkg.WithAdapter(adapters.NewCache(server)), pkg.WithEncryption(key))
+	defer c.Close(ctx)
+	if _, err := c.Get(ctx, "key"); !errors.Is(err, pkg.ErrNotEncrypted) {
+		t.Errorf("want ErrNotEncrypted for a value stored in the clear, got %v", err)
+	}
+	if _, err := c.Pull(ctx, "key"); !errors.Is(err, pkg.ErrNotEncrypted) {
+		t.Errorf("want ErrNotEncrypted from Pull, got %v", err)
+	}
+
+	migrating := pkg.NewCache(pkg.WithDefaultTTL(pkg.Forever), pkg.WithAdapter(adapters.NewCache(server)), pkg.WithEncryption(key), pkg.WithPlaintextReads())
+	defer migrating.Close(ctx)
+	_ = server.Set(ctx, "key", "written before encryption", 0)
+	if v, err := migrating.Get(ctx, "key"); err != nil || v != "written before encryption" {
+		t.Errorf("want plaintext readable with WithPlaintextReads, got %v (%v)", v, err)
+	}
+}
+
+func TestWithEncryptionEveryBackend(t *testing.T) {
+	ctx := context.Background()
+	key := bytes.Repeat([]byte{7}, 32)
+	backup, old := adapters.NewMemory(), adapters.NewMemory()
+	c := pkg.NewMemoryCache(
+		pkg.WithDefaultTTL(pkg.Forever),
+		pkg.WithEncryption(key),
+		pkg.WithFallback(pkg.NewCache(pkg.WithAdapter(adapters.NewCache(backup)))),
+		pkg.WithMigrationFrom(pkg.NewCache(pkg.WithAdapter(adapters.NewCache(old))), 0),
+	)
+	defer c.Close(ctx)
+
+	if err := c.Set(ctx, "email", "arash@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	for name, server := range map[string]*adapters.Memory{"fallback": backup, "migration source": old} {
+		if stored, err := server.Get(ctx, "email"); err != nil || strings.Contains(stored, "arash") {
+			t.Errorf("want the value stored encrypted in the %s, got %q (%v)", name, stored, err)
+		}
+	}
+	if v, err := c.Get(ctx, "email"); err != nil || v != "arash@example.com" {
+		t.Errorf("want the plaintext back, got %v (%v)", v, err)
+	}
+}
+
+func TestWithEncryptionUnencryptableTypes(t *testing.T) {
+	c := pkg.NewMemoryCache(pkg.WithDefaultTTL(pkg.Forever), pkg.WithEncryption(bytes.Repeat([]byte{7}, 32)))
+	defer c.Close(context.Background())
+	ctx := context.Background()
+
+	if err := pkg.NewHashCache[profile](c).Set(ctx, "profile:1", profile{Name: "arash"}); !errors.Is(err, pkg.ErrEncryptionUnsupported) {
+		t.Errorf("want ErrEncryptionUnsupported from HashCache, got %v", err)
+	}
+	if _, err := pkg.NewSet[string](c, "members"); !errors.Is(err, pkg.ErrEncryptionUnsupported) {
+		t.Errorf("want ErrEncryptionUnsupported from NewSet, got %v", err)
+	}
+	if _, err := pkg.NewReliableQueue[string](c, "jobs", 0); !errors.Is(err, pkg.ErrUnsupported) {
+		t.Errorf("want an error wrapping ErrUnsupported from NewReliableQueue, got %v", err)
+	}
+	if _, err := c.Increment(ctx, "n", 1); !errors.Is(err, pkg.ErrEncryptionUnsupported) {
+		t.Errorf("want ErrEncryptionUnsupported from Increment, got %v", err)
+	}
+	if _, err := c.Get(ctx, "n"); !errors.Is(err, pkg.ErrCacheMiss) {
+		t.Errorf("want no counter stored in the clear, got %v", err)
+	}
+	pipeline := c.Pipeline()
+	count := pipeline.Increment("n", 1)
+	_ = pipeline.Exec(ctx)
+	if _, err := count.Result(); !errors.Is(err, pkg.ErrEncryptionUnsupported) {
+		t.Errorf("want ErrEncryptionUnsupported from a pipelined Increment, got %v", err)
+	}
+}
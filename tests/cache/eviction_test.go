@@ -0,0 +1,63 @@
+package cache
+
+import (
+	"cacher/pkg"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestMemoryLimits(t *testing.T) {
+	var evicted []string
+	c := pkg.NewMemoryCache(pkg.WithPrefix("app:"), pkg.WithDefaultTTL(pkg.Forever), pkg.WithMemoryLimits(64, 0), pkg.WithOnEvict(func(key string) {
+		evicted = append(evicted, key)
+	}))
+	defer c.Close(context.Background())
+	ctx := context.Background()
+
+	for i := 0; i < 1000; i++ {
+		if err := c.Set(ctx, fmt.Sprintf("key:%d", i), "value"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stats := c.EvictionStatistics(ctx)
+	if stats["entries"] > 64 || stats["evictions"] != 1000-stats["entries"] || stats["evictions"] != uint64(len(evicted)) {
+		t.Errorf("want OnEvict called for every key beyond 64, got %d calls and %v", len(evicted), stats)
+	}
+	if !strings.HasPrefix(evicted[0], "key:") {
+		t.Errorf("want evicted keys without the prefix, got %s", evicted[0])
+	}
+	if _, err := c.Get(ctx, evicted[0]); !errors.Is(err, pkg.ErrCacheMiss) {
+		t.Errorf("want %s evicted, got %v", evicted[0], err)
+	}
+}
+
+func TestMemoryLimitsOff(t *testing.T) {
+	c := pkg.NewMemoryCache()
+	defer c.Close(context.Background())
+	if stats := c.EvictionStatistics(context.Background()); stats != nil {
+		t.Errorf("want no eviction statistics without limits, got %v", stats)
+	}
+}
+
+func TestTinyLFU(t *testing.T) {
+	c := pkg.NewMemoryCache(pkg.WithDefaultTTL(pkg.Forever), pkg.WithMemoryLimits(256, 0), pkg.WithEvictionPolicy(pkg.TinyLFU))
+	defer c.Close(context.Background())
+	ctx := context.Background()
+
+	for range 3 {
+		_ = c.Set(ctx, "hot", "value")
+		_, _ = c.Get(ctx, "hot")
+	}
+	for i := 0; i < 2000; i++ {
+		_ = c.Set(ctx, fmt.Sprintf("once:%d", i), "value")
+	}
+	if _, err := c.Get(ctx, "hot"); err != nil {
+		t.Errorf("want the hot key kept past keys written once, got %v", err)
+	}
+	if stats := c.EvictionStatistics(ctx); stats["entries"] > 256 {
+		t.Errorf("want at most 256 entries, got %v", stats)
+	}
+}
@@ -0,0 +1,83 @@
+package cache
+
+import (
+	"bytes"
+	"cacher/pkg"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestExportImport(t *testing.T) {
+	source := pkg.NewMemoryCache(pkg.WithPrefix("app:"))
+	defer source.Close(context.Background())
+	target := pkg.NewMemoryCache(pkg.WithPrefix("copy:"))
+	defer target.Close(context.Background())
+	testExportImport(t, source, target)
+}
+
+func TestExportImportRedis(t *testing.T) {
+	source := pkg.NewMemoryCache(pkg.WithCompression(pkg.Gzip, 16))
+	defer source.Close(context.Background())
+	target := pkg.NewCache(pkg.WithRedisAddr(redisAddrOrSkip(t)), pkg.WithPrefix("import:"), pkg.WithCompression(pkg.Gzip, 16))
+	defer target.Close(context.Background())
+	_, _ = target.DeleteByPattern(context.Background(), "*")
+	testExportImport(t, source, target)
+}
+
+func testExportImport(t *testing.T, source, target pkg.Cache) {
+	ctx := context.Background()
+	_ = source.SetWithTTL(ctx, "user:1", "arash", time.Minute)
+	_ = source.SetForever(ctx, "user:2", string(bytes.Repeat([]byte("compressed "), 10)))
+	_ = source.SetForever(ctx, "order:1", "not exported")
+	profiles := pkg.NewHashCache[profile](source)
+	if err := profiles.SetWithTTL(ctx, "user:hash", profile{Name: "skipped"}, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	var stream bytes.Buffer
+	if exported, err := source.Export(ctx, &stream, "user:*"); err != nil || exported != 2 {
+		t.Fatalf("want the 2 string keys exported, got %d (%v)", exported, err)
+	}
+	if imported, err := target.Import(ctx, &stream); err != nil || imported != 2 {
+		t.Fatalf("want 2 keys imported, got %d (%v)", imported, err)
+	}
+
+	if value, err := target.Get(ctx, "user:1"); err != nil || value != "arash" {
+		t.Errorf("want user:1 imported, got %v (%v)", value, err)
+	}
+	if ttl, _ := target.TTL(ctx, "user:1"); ttl <= 0 || ttl > time.Minute {
+		t.Errorf("want the remaining ttl imported, got %s", ttl)
+	}
+	if value, err := target.Get(ctx, "user:2"); err != nil || value != string(bytes.Repeat([]byte("compressed "), 10)) {
+		t.Errorf("want the compressed value imported, got %v (%v)", value, err)
+	}
+	if ttl, _ := target.TTL(ctx, "user:2"); ttl != pkg.NoExpiration {
+		t.Errorf("want user:2 without expiration, got %s", ttl)
+	}
+	if _, err := target.Get(ctx, "order:1"); !errors.Is(err, pkg.ErrCacheMiss) {
+		t.Errorf("want keys outside the pattern left out, got %v", err)
+	}
+}
+
+func TestImportCorrupt(t *testing.T) {
+	c := pkg.NewMemoryCache()
+	defer c.Close(context.Background())
+	ctx := context.Background()
+	if _, err := c.Import(ctx, bytes.NewBufferString("not an export")); !errors.Is(err, pkg.ErrCorruptExport) {
+		t.Errorf("want ErrCorruptExport, got %v", err)
+	}
+
+	_ = c.SetForever(ctx, "kept", "value")
+	_ = c.SetForever(ctx, "truncated", "value")
+	var stream bytes.Buffer
+	if _, err := c.Export(ctx, &stream, "*"); err != nil {
+		t.Fatal(err)
+	}
+	_ = c.Flush(ctx)
+	imported, err := c.Import(ctx, bytes.NewReader(stream.Bytes()[:stream.Len()-2]))
+	if !errors.Is(err, pkg.ErrCorruptExport) || imported != 1 {
+		t.Errorf("want the record before the truncation imported and ErrCorruptExport, got %d (%v)", imported, err)
+	}
+}
@@ -0,0 +1,38 @@
+package cache
+
+import (
+	"cacher/pkg"
+	"context"
+	"testing"
+)
+
+func TestWithFallback(t *testing.T) {
+	ctx := context.Background()
+	// Nothing listens on port 1, so every call to Redis fails
+	c := pkg.NewCache(pkg.WithRedisAddr("127.0.0.1:1"), pkg.WithFallback(pkg.NewMemoryCache()))
+	defer c.Close(ctx)
+
+	if err := c.SetForever(ctx, "greeting", "hello"); err != nil {
+		t.Fatalf("want the write taken by the fallback, got %v", err)
+	}
+	if value, err := c.Get(ctx, "greeting"); err != nil || value != "hello" {
+		t.Errorf("want the fallback to serve, got %v (%v)", value, err)
+	}
+	if err := c.Ping(ctx); err != nil {
+		t.Errorf("want the cache available while one backend is, got %v", err)
+	}
+	stats := c.FallbackStatistics(ctx)
+	if stats["0:redis"]["skipped"] == 0 || stats["1:memory"]["hits"] != 1 {
+		t.Errorf("want reads served by the fallback, got %v", stats)
+	}
+}
+
+func TestWithFallbackCompressed(t *testing.T) {
+	ctx := context.Background()
+	c := pkg.NewCache(pkg.WithRedisAddr("127.0.0.1:1"), pkg.WithFallback(pkg.NewMemoryCache()), pkg.WithCompression(pkg.Gzip, 16))
+	defer c.Close(ctx)
+
+	if stats := c.FallbackStatistics(ctx); stats == nil {
+		t.Error("want the fallback statistics with compression, got none")
+	}
+}
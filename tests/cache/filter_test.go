@@ -0,0 +1,101 @@
+package cache
+
+import (
+	"cacher/pkg"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestFilter(t *testing.T) {
+	c := pkg.NewMemoryCache()
+	defer c.Close(context.Background())
+	ctx := context.Background()
+
+	filter, err := pkg.NewFilter(ctx, c, "emails", 1000, 0.01)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range 1000 {
+		if err := filter.Add(ctx, fmt.Sprintf("user%d@example.com", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := range 1000 {
+		if found, err := filter.MightContain(ctx, fmt.Sprintf("user%d@example.com", i)); err != nil || !found {
+			t.Fatalf("want no false negatives, got %v (%v)", found, err)
+		}
+	}
+	falsePositives := 0
+	for i := range 1000 {
+		if found, _ := filter.MightContain(ctx, fmt.Sprintf("other%d@example.com", i)); found {
+			falsePositives++
+		}
+	}
+	if falsePositives > 30 {
+		t.Errorf("want about 1%% false positives, got %d in 1000", falsePositives)
+	}
+
+	if err := filter.Reset(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if found, _ := filter.MightContain(ctx, "user1@example.com"); found {
+		t.Error("want the filter empty after Reset")
+	}
+	if _, err := pkg.NewFilter(ctx, c, "invalid", 1000, 1); err == nil {
+		t.Error("want an error for a false positive rate of 1")
+	}
+}
+
+func TestWrapKeyFilter(t *testing.T) {
+	c := pkg.NewMemoryCache(pkg.WithKeyFilter("user:*", 1000, 0.01))
+	defer c.Close(context.Background())
+	ctx := context.Background()
+	if err := c.KeyFilter().Add(ctx, "user:1"); err != nil {
+		t.Fatal(err)
+	}
+
+	loads := 0
+	load := func() interface{} {
+		loads++
+		return "value"
+	}
+	if result := c.Wrap(ctx, "user:2", load); !errors.Is(result.(error), pkg.ErrNotFound) || loads != 0 {
+		t.Errorf("want ErrNotFound without loading an unknown key, got %v after %d loads", result, loads)
+	}
+	if result := c.Wrap(ctx, "user:1", load); result != "value" || loads != 1 {
+		t.Errorf("want a known key loaded, got %v after %d loads", result, loads)
+	}
+	if result := c.Wrap(ctx, "post:1", load); result != "value" || loads != 2 {
+		t.Errorf("want keys outside the pattern loaded, got %v after %d loads", result, loads)
+	}
+
+	if _, err := c.WrapContext(ctx, "user:3", func(context.Context) (interface{}, error) {
+		loads++
+		return "value", nil
+	}); !errors.Is(err, pkg.ErrNotFound) || loads != 2 {
+		t.Errorf("want ErrNotFound from WrapContext, got %v", err)
+	}
+}
+
+func TestRedisFilter(t *testing.T) {
+	ctx := context.Background()
+	c := pkg.NewCache(pkg.WithRedisAddr(redisAddrOrSkip(t)), pkg.WithPrefix("filters:"))
+	defer c.Close(ctx)
+
+	filter, err := pkg.NewFilter(ctx, c, "redis", 100, 0.01)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = filter.Reset(ctx)
+	if err := filter.Add(ctx, "a", "b"); err != nil {
+		t.Fatal(err)
+	}
+	if found, err := filter.MightContain(ctx, "a"); err != nil || !found {
+		t.Errorf("want an added item found, got %v (%v)", found, err)
+	}
+	if found, _ := filter.MightContain(ctx, "c"); found {
+		t.Error("want an item never added missing")
+	}
+}
@@ -0,0 +1,55 @@
+package cache
+
+import (
+	"cacher/pkg"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestFlush(t *testing.T) {
+	c := pkg.NewMemoryCache(pkg.WithDefaultTTL(pkg.Forever), pkg.WithLocalTier(time.Minute))
+	defer c.Close(context.Background())
+	ctx := context.Background()
+
+	_ = c.Set(ctx, "a", "1")
+	_ = c.Set(ctx, "b", "2")
+	_, _ = c.Get(ctx, "a")
+
+	if err := c.FlushPrefix(ctx); !errors.Is(err, pkg.ErrNoPrefix) {
+		t.Errorf("want ErrNoPrefix from FlushPrefix without a prefix, got %v", err)
+	}
+	if err := c.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"a", "b"} {
+		if _, err := c.Get(ctx, key); !errors.Is(err, pkg.ErrCacheMiss) {
+			t.Errorf("want %s flushed from both tiers, got %v", key, err)
+		}
+	}
+}
+
+func TestFlushRequiresPrefix(t *testing.T) {
+	ctx := context.Background()
+
+	unprefixed := pkg.NewMemoryCache(pkg.WithDefaultTTL(pkg.Forever), pkg.WithFlushRequiresPrefix())
+	defer unprefixed.Close(ctx)
+	_ = unprefixed.Set(ctx, "a", "1")
+	if err := unprefixed.Flush(ctx); !errors.Is(err, pkg.ErrNoPrefix) {
+		t.Errorf("want ErrNoPrefix, got %v", err)
+	}
+	if _, err := unprefixed.Get(ctx, "a"); err != nil {
+		t.Errorf("want the key kept, got %v", err)
+	}
+
+	prefixed := pkg.NewMemoryCache(pkg.WithDefaultTTL(pkg.Forever), pkg.WithFlushRequiresPrefix(), pkg.WithPrefix("app:"))
+	defer prefixed.Close(ctx)
+	_ = prefixed.Set(ctx, "a", "1")
+	if err := prefixed.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := prefixed.Get(ctx, "a"); !errors.Is(err, pkg.ErrCacheMiss) {
+		t.Errorf("want the key flushed, got %v", err)
+	}
+}
@@ -0,0 +1,67 @@
+package cache
+
+import (
+	"cacher/pkg"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSetRequiresDefaultTTL(t *testing.T) {
+	c := pkg.NewMemoryCache()
+	defer c.Close(context.Background())
+	ctx := context.Background()
+
+	if err := c.Set(ctx, "key", "value"); !errors.Is(err, pkg.ErrNoDefaultTTL) {
+		t.Errorf("want ErrNoDefaultTTL from Set, got %v", err)
+	}
+	if err := c.SetMany(ctx, map[string]interface{}{"key": "value"}); !errors.Is(err, pkg.ErrNoDefaultTTL) {
+		t.Errorf("want ErrNoDefaultTTL from SetMany, got %v", err)
+	}
+	if _, err := c.Get(ctx, "key"); !errors.Is(err, pkg.ErrCacheMiss) {
+		t.Errorf("want nothing stored, got %v", err)
+	}
+
+	calls := 0
+	for i := 0; i < 2; i++ {
+		c.Wrap(ctx, "wrapped", func() interface{} {
+			calls++
+			return "value"
+		})
+	}
+	if calls != 2 {
+		t.Errorf("want Wrap to skip storing without a default ttl, loader ran %d times", calls)
+	}
+}
+
+func TestSetForever(t *testing.T) {
+	c := pkg.NewMemoryCache(pkg.WithDefaultTTL(time.Minute))
+	defer c.Close(context.Background())
+	ctx := context.Background()
+
+	_ = c.Set(ctx, "key", "value")
+	if ttl, _ := c.TTL(ctx, "key"); ttl <= 0 {
+		t.Fatalf("want the default ttl applied, got %v", ttl)
+	}
+	if err := c.SetForever(ctx, "key", "value"); err != nil {
+		t.Fatal(err)
+	}
+	if ttl, _ := c.TTL(ctx, "key"); ttl != pkg.NoExpiration {
+		t.Errorf("want the expiration cleared, got %v", ttl)
+	}
+
+	calls := 0
+	for i := 0; i < 2; i++ {
+		c.RememberForever(ctx, "remembered", func() interface{} {
+			calls++
+			return "value"
+		})
+	}
+	if calls != 1 {
+		t.Errorf("want the value remembered, loader ran %d times", calls)
+	}
+	if ttl, _ := c.TTL(ctx, "remembered"); ttl != pkg.NoExpiration {
+		t.Errorf("want no expiration, got %v", ttl)
+	}
+}
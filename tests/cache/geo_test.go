@@ -0,0 +1,51 @@
+package cache
+
+import (
+	"cacher/pkg"
+	"context"
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestGeoIndex(t *testing.T) {
+	c := pkg.NewMemoryCache()
+	defer c.Close(context.Background())
+	stores, err := pkg.NewGeoIndex(c, "stores")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	_ = stores.AddLocation(ctx, "louvre", 48.8606, 2.3376)
+	_ = stores.AddLocation(ctx, "eiffel", 48.8584, 2.2945)
+	_ = stores.AddLocation(ctx, "versailles", 48.8049, 2.1204)
+
+	lat, lng, err := stores.Location(ctx, "eiffel")
+	if err != nil || math.Abs(lat-48.8584) > 1e-5 || math.Abs(lng-2.2945) > 1e-5 {
+		t.Errorf("want the position of eiffel, got %v %v (%v)", lat, lng, err)
+	}
+	if _, _, err := stores.Location(ctx, "colosseum"); !errors.Is(err, pkg.ErrCacheMiss) {
+		t.Errorf("want ErrCacheMiss, got %v", err)
+	}
+
+	// From the Louvre, the Eiffel tower is about 3.2km away and Versailles 17km
+	places, err := stores.Nearby(ctx, 48.8606, 2.3376, 5000)
+	if err != nil || len(places) != 2 || places[0].Member != "louvre" || places[1].Member != "eiffel" {
+		t.Fatalf("want louvre then eiffel, got %v (%v)", places, err)
+	}
+	if places[1].Distance < 3000 || places[1].Distance > 3400 {
+		t.Errorf("want eiffel about 3.2km away, got %vm", places[1].Distance)
+	}
+	if places, _ := stores.NearbyN(ctx, 48.8606, 2.3376, 50000, 2); len(places) != 2 {
+		t.Errorf("want the 2 nearest, got %v", places)
+	}
+
+	if err := stores.AddLocation(ctx, "north pole", 90, 0); err == nil {
+		t.Error("want a latitude out of range to fail")
+	}
+	_ = stores.Remove(ctx, "louvre")
+	if places, _ := stores.Nearby(ctx, 48.8606, 2.3376, 5000); len(places) != 1 {
+		t.Errorf("want louvre removed, got %v", places)
+	}
+}
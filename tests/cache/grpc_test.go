@@ -0,0 +1,108 @@
+package cache
+
+import (
+	"cacher/pkg"
+	"context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/test/bufconn"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type countingHealth struct {
+	grpc_health_v1.UnimplementedHealthServer
+	calls atomic.Int64
+}
+
+func (h *countingHealth) Check(ctx context.Context, req *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
+	h.calls.Add(1)
+	return &grpc_health_v1.HealthCheckResponse{Status: grpc_health_v1.HealthCheckResponse_SERVING}, nil
+}
+
+func dialHealth(t *testing.T, serverOpts []grpc.ServerOption, dialOpts ...grpc.DialOption) (grpc_health_v1.HealthClient, *countingHealth) {
+	t.Helper()
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer(serverOpts...)
+	health := &countingHealth{}
+	grpc_health_v1.RegisterHealthServer(server, health)
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+
+	dialOpts = append(dialOpts,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}))
+	conn, err := grpc.Dial("bufnet", dialOpts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return grpc_health_v1.NewHealthClient(conn), health
+}
+
+func checkHealth(t *testing.T, client grpc_health_v1.HealthClient, service string) {
+	t.Helper()
+	resp, err := client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{Service: service})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Status != grpc_health_v1.HealthCheckResponse_SERVING {
+		t.Errorf("want SERVING, got %v", resp.Status)
+	}
+}
+
+func TestGRPCServerInterceptor(t *testing.T) {
+	c := pkg.NewMemoryCache()
+	defer c.Close(context.Background())
+	responses := pkg.NewGRPCCache(c, pkg.WithMethodTTL("/grpc.health.v1.Health/*", time.Minute))
+	client, health := dialHealth(t, []grpc.ServerOption{grpc.UnaryInterceptor(responses.UnaryServerInterceptor())})
+
+	checkHealth(t, client, "a")
+	checkHealth(t, client, "a")
+	checkHealth(t, client, "b")
+	if n := health.calls.Load(); n != 2 {
+		t.Errorf("want one call per distinct request, got %d", n)
+	}
+	stats := responses.Statistics()["/grpc.health.v1.Health/Check"]
+	if stats["hits"] != 1 || stats["misses"] != 2 {
+		t.Errorf("want 1 hit and 2 misses, got %v", stats)
+	}
+}
+
+func TestGRPCClientInterceptor(t *testing.T) {
+	c := pkg.NewMemoryCache()
+	defer c.Close(context.Background())
+	responses := pkg.NewGRPCCache(c,
+		pkg.WithMethodTTL("/grpc.health.v1.Health/Watch", time.Minute),
+		pkg.WithMethodTTL("/grpc.health.v1.Health/Check", 50*time.Millisecond))
+	client, health := dialHealth(t, nil, grpc.WithUnaryInterceptor(responses.UnaryClientInterceptor()))
+
+	checkHealth(t, client, "a")
+	checkHealth(t, client, "a")
+	if n := health.calls.Load(); n != 1 {
+		t.Errorf("want the second call answered by the cache, got %d calls", n)
+	}
+	time.Sleep(60 * time.Millisecond)
+	checkHealth(t, client, "a")
+	if n := health.calls.Load(); n != 2 {
+		t.Errorf("want the response expired after its method TTL, got %d calls", n)
+	}
+}
+
+func TestGRPCUncachedMethod(t *testing.T) {
+	c := pkg.NewMemoryCache()
+	defer c.Close(context.Background())
+	responses := pkg.NewGRPCCache(c, pkg.WithMethodTTL("/other.Service/*", time.Minute))
+	client, health := dialHealth(t, nil, grpc.WithUnaryInterceptor(responses.UnaryClientInterceptor()))
+
+	checkHealth(t, client, "a")
+	checkHealth(t, client, "a")
+	if n := health.calls.Load(); n != 2 {
+		t.Errorf("want methods without a TTL not cached, got %d calls", n)
+	}
+}
@@ -0,0 +1,88 @@
+package cache
+
+import (
+	"cacher/pkg"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHandler(t *testing.T) {
+	var requests atomic.Int64
+	var failing atomic.Bool
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := requests.Add(1)
+		if failing.Load() {
+			http.Error(w, "down", http.StatusServiceUnavailable)
+			return
+		}
+		if r.URL.Path == "/login" {
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "secret"})
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusOK)
+		_, _ = io.WriteString(w, "page "+r.URL.Path+" #"+strconv.FormatInt(n, 10))
+	})
+
+	c := pkg.NewMemoryCache()
+	defer c.Close(context.Background())
+	server := httptest.NewServer(pkg.Handler(c, next, nil, 50*time.Millisecond, pkg.WithStaleIfError(time.Minute)))
+	defer server.Close()
+
+	get := func(path string, header ...string) (*http.Response, string) {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodGet, server.URL+path, nil)
+		for i := 0; i+1 < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp, string(body)
+	}
+
+	resp, body := get("/home")
+	if resp.Header.Get("X-Cache") != "MISS" || body != "page /home #1" {
+		t.Errorf("want the first request served by the handler, got %s with %q", resp.Header.Get("X-Cache"), body)
+	}
+	resp, body = get("/home")
+	if resp.Header.Get("X-Cache") != "HIT" || body != "page /home #1" {
+		t.Errorf("want the response cached, got %s with %q", resp.Header.Get("X-Cache"), body)
+	}
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/plain" {
+		t.Errorf("want the status and headers cached, got %d with %v", resp.StatusCode, resp.Header)
+	}
+
+	if resp, _ = get("/home", pkg.DefaultHandlerBypassHeader, "1"); resp.Header.Get("X-Cache") != "" {
+		t.Errorf("want the bypass header to skip the cache, got %s", resp.Header.Get("X-Cache"))
+	}
+	if resp, body = get("/home", "Cache-Control", "no-cache"); body != "page /home #3" {
+		t.Errorf("want no-cache to refresh the response, got %q", body)
+	}
+	if _, body = get("/home"); body != "page /home #3" {
+		t.Errorf("want the refreshed response cached, got %q", body)
+	}
+
+	get("/login")
+	if resp, _ = get("/login"); resp.Header.Get("X-Cache") != "MISS" {
+		t.Errorf("want responses setting cookies not cached, got %s", resp.Header.Get("X-Cache"))
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	failing.Store(true)
+	resp, body = get("/home")
+	if resp.Header.Get("X-Cache") != "STALE" || body != "page /home #3" {
+		t.Errorf("want the stale response while the handler fails, got %s with %q", resp.Header.Get("X-Cache"), body)
+	}
+	if resp, _ = get("/other"); resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("want the error without a stale response, got %d", resp.StatusCode)
+	}
+}
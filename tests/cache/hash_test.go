@@ -0,0 +1,91 @@
+package cache
+
+import (
+	"cacher/pkg"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type profile struct {
+	Name    string    `cache:"name"`
+	Visits  int64     `cache:"visits"`
+	Score   float64   `cache:"score"`
+	Admin   bool      `cache:"admin"`
+	Tags    []string  `cache:"tags"`
+	Seen    time.Time `cache:"seen"`
+	Session string    `cache:"-"`
+}
+
+func TestHashCache(t *testing.T) {
+	c := pkg.NewMemoryCache(pkg.WithDefaultTTL(time.Minute))
+	defer c.Close(context.Background())
+	profiles := pkg.NewHashCache[profile](c)
+	ctx := context.Background()
+
+	if _, err := profiles.Get(ctx, "profile:1"); !errors.Is(err, pkg.ErrCacheMiss) {
+		t.Errorf("want ErrCacheMiss, got %v", err)
+	}
+
+	seen := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	stored := profile{Name: "arash", Visits: 1, Score: 1.5, Admin: true, Tags: []string{"a"}, Seen: seen, Session: "secret"}
+	if err := profiles.Set(ctx, "profile:1", stored); err != nil {
+		t.Fatal(err)
+	}
+	if ttl, _ := c.TTL(ctx, "profile:1"); ttl <= 0 {
+		t.Errorf("want the hash to expire, got %v", ttl)
+	}
+
+	if _, err := profiles.IncrBy(ctx, "profile:1", "visits", 4); err != nil {
+		t.Fatal(err)
+	}
+	if err := profiles.SetFields(ctx, "profile:1", map[string]interface{}{"name": "ali", "tags": []string{"b", "c"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := profiles.SetFields(ctx, "profile:1", map[string]interface{}{"unknown": 1}); err == nil {
+		t.Error("want unknown fields rejected")
+	}
+	if name, err := profiles.GetField(ctx, "profile:1", "name"); err != nil || name != "ali" {
+		t.Errorf("want ali, got %v (%v)", name, err)
+	}
+
+	loaded, err := profiles.Get(ctx, "profile:1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Name != "ali" || loaded.Visits != 5 || loaded.Score != 1.5 || !loaded.Admin ||
+		len(loaded.Tags) != 2 || !loaded.Seen.Equal(seen) || loaded.Session != "" {
+		t.Errorf("want the partially updated profile, got %+v", loaded)
+	}
+
+	if err := profiles.DeleteFields(ctx, "profile:1", "admin"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := profiles.GetField(ctx, "profile:1", "admin"); !errors.Is(err, pkg.ErrCacheMiss) {
+		t.Errorf("want ErrCacheMiss for a deleted field, got %v", err)
+	}
+	if err := profiles.Delete(ctx, "profile:1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := profiles.Get(ctx, "profile:1"); !errors.Is(err, pkg.ErrCacheMiss) {
+		t.Errorf("want ErrCacheMiss after Delete, got %v", err)
+	}
+}
+
+func TestHashCacheForever(t *testing.T) {
+	c := pkg.NewMemoryCache(pkg.WithDefaultTTL(time.Minute))
+	defer c.Close(context.Background())
+	profiles := pkg.NewHashCache[profile](c)
+	ctx := context.Background()
+
+	if err := profiles.Set(ctx, "profile:1", profile{Name: "arash"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := profiles.SetWithTTL(ctx, "profile:1", profile{Name: "ali"}, pkg.Forever); err != nil {
+		t.Fatal(err)
+	}
+	if ttl, _ := c.TTL(ctx, "profile:1"); ttl != pkg.NoExpiration {
+		t.Errorf("want Forever to remove the expiration, got %s", ttl)
+	}
+}
@@ -0,0 +1,58 @@
+package cache
+
+import (
+	"cacher/pkg"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHealth(t *testing.T) {
+	c := pkg.NewMemoryCache(pkg.WithCircuitBreaker(3, time.Second))
+	defer c.Close(context.Background())
+
+	health := c.Health(context.Background())
+	if !health.Healthy || health.Backend != "memory" || health.Breaker != "closed" {
+		t.Errorf("want healthy memory backend, got %+v", health)
+	}
+
+	recorder := httptest.NewRecorder()
+	pkg.HealthHandler(c).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if recorder.Code != http.StatusOK {
+		t.Errorf("want 200, got %d", recorder.Code)
+	}
+}
+
+func TestHealthUnreachable(t *testing.T) {
+	c := pkg.NewCache(pkg.WithRedisAddr("localhost:1"))
+	defer c.Close(context.Background())
+
+	if err := c.Ping(context.Background()); err == nil {
+		t.Errorf("want ping to fail")
+	}
+
+	recorder := httptest.NewRecorder()
+	pkg.HealthHandler(c).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Errorf("want 503, got %d", recorder.Code)
+	}
+}
+
+func TestHealthBreakerWrapped(t *testing.T) {
+	key := []byte("0123456789abcdef0123456789abcdef")
+	for name, opt := range map[string]pkg.Option{
+		"compression": pkg.WithCompression(pkg.Gzip, 16),
+		"encryption":  pkg.WithEncryption(key),
+	} {
+		c := pkg.NewMemoryCache(pkg.WithCircuitBreaker(3, time.Second), opt)
+		if stats := c.CircuitBreakerStatistics(context.Background()); stats == nil {
+			t.Errorf("%s: want the breaker statistics, got none", name)
+		}
+		if health := c.Health(context.Background()); health.Breaker != "closed" {
+			t.Errorf("%s: want the breaker state, got %+v", name, health)
+		}
+		c.Close(context.Background())
+	}
+}
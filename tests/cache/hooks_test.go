@@ -0,0 +1,58 @@
+package cache
+
+import (
+	"cacher/pkg"
+	"context"
+	"testing"
+)
+
+func TestHooks(t *testing.T) {
+	c := pkg.NewMemoryCache(pkg.WithDefaultTTL(pkg.Forever))
+	defer c.Close(context.Background())
+	ctx := context.Background()
+
+	var events []string
+	record := func(kind string) pkg.Hook {
+		return func(ctx context.Context, event pkg.Event) {
+			if event.Backend != "memory" {
+				t.Errorf("want memory backend, got %q", event.Backend)
+			}
+			events = append(events, kind+":"+event.Operation+":"+event.Key)
+		}
+	}
+	c.OnHit(record("hit"))
+	c.OnMiss(record("miss"))
+	c.OnSet(record("set"))
+	c.OnDelete(record("delete"))
+	c.OnError(record("error"))
+
+	_, _ = c.Get(ctx, "key")
+	_ = c.Set(ctx, "key", "value")
+	_, _ = c.Get(ctx, "key")
+	_ = c.Delete(ctx, "key")
+
+	want := []string{"miss:get:key", "set:set:key", "hit:get:key", "delete:delete:key"}
+	if len(events) != len(want) {
+		t.Fatalf("want %v, got %v", want, events)
+	}
+	for i := range want {
+		if events[i] != want[i] {
+			t.Errorf("want %v, got %v", want, events)
+			break
+		}
+	}
+}
+
+func TestErrorHook(t *testing.T) {
+	c := pkg.NewCache(pkg.WithRedisAddr("localhost:1"))
+	defer c.Close(context.Background())
+
+	var failed pkg.Event
+	c.OnError(func(ctx context.Context, event pkg.Event) {
+		failed = event
+	})
+	_, _ = c.Get(context.Background(), "key")
+	if failed.Err == nil || failed.Operation != "get" || failed.Backend != "redis" {
+		t.Errorf("want a failed get event, got %+v", failed)
+	}
+}
@@ -0,0 +1,44 @@
+package cache
+
+import (
+	"cacher/pkg"
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestHotKeys(t *testing.T) {
+	c := pkg.NewMemoryCache(pkg.WithDefaultTTL(pkg.Forever), pkg.WithHotKeys(10))
+	defer c.Close(context.Background())
+	ctx := context.Background()
+
+	_ = c.Set(ctx, "hot", "value")
+	for i := range 200 {
+		_, _ = c.Get(ctx, "hot")
+		_, _ = c.Get(ctx, fmt.Sprintf("cold:%d", i))
+		if i%2 == 0 {
+			_, _ = c.Get(ctx, "missing")
+		}
+	}
+
+	hot := c.HotKeys(ctx, 2)
+	if len(hot.Reads) != 2 || hot.Reads[0].Key != "hot" || hot.Reads[1].Key != "missing" {
+		t.Errorf("want hot and missing as the most read keys, got %v", hot.Reads)
+	}
+	if len(hot.Misses) == 0 || hot.Misses[0].Key != "missing" || hot.Misses[0].Count < 100 {
+		t.Errorf("want missing as the most missed key, got %v", hot.Misses)
+	}
+}
+
+func TestHotKeysNonPositive(t *testing.T) {
+	c := pkg.NewMemoryCache(pkg.WithDefaultTTL(pkg.Forever), pkg.WithHotKeys(10))
+	defer c.Close(context.Background())
+	ctx := context.Background()
+
+	_, _ = c.Get(ctx, "missing")
+	for _, n := range []int{0, -1} {
+		if hot := c.HotKeys(ctx, n); len(hot.Reads) != 0 || len(hot.Misses) != 0 {
+			t.Errorf("want no keys for n = %d, got %v", n, hot)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package cache
+
+import (
+	"cacher/pkg"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestIdempotencyDo(t *testing.T) {
+	c := pkg.NewMemoryCache()
+	defer c.Close(context.Background())
+	idempotency, err := pkg.NewIdempotency(c, pkg.WithIdempotencyLease(90*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	var runs atomic.Int64
+	release := make(chan struct{})
+	charge := func(ctx context.Context) ([]byte, error) {
+		runs.Add(1)
+		<-release
+		return []byte("charged"), nil
+	}
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if result, replayed, err := idempotency.Do(ctx, "payment-1", time.Minute, charge); err != nil || replayed || string(result) != "charged" {
+			t.Errorf("want the first request to run, got %q replayed %v (%v)", result, replayed, err)
+		}
+	}()
+	for runs.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	// Outlive the lease so renewals are needed
+	time.Sleep(150 * time.Millisecond)
+	if _, _, err := idempotency.Do(ctx, "payment-1", time.Minute, charge); !errors.Is(err, pkg.ErrRequestInProgress) {
+		t.Errorf("want ErrRequestInProgress for a duplicate, got %v", err)
+	}
+	close(release)
+	wg.Wait()
+
+	result, replayed, err := idempotency.Do(ctx, "payment-1", time.Minute, charge)
+	if err != nil || !replayed || string(result) != "charged" || runs.Load() != 1 {
+		t.Errorf("want the result replayed, got %q replayed %v after %d runs (%v)", result, replayed, runs.Load(), err)
+	}
+
+	failing := errors.New("card declined")
+	if _, _, err := idempotency.Do(ctx, "payment-2", time.Minute, func(ctx context.Context) ([]byte, error) {
+		return nil, failing
+	}); !errors.Is(err, failing) {
+		t.Fatalf("want the error of fn, got %v", err)
+	}
+	if result, replayed, err := idempotency.Do(ctx, "payment-2", time.Minute, func(ctx context.Context) ([]byte, error) {
+		return []byte("retried"), nil
+	}); err != nil || replayed || string(result) != "retried" {
+		t.Errorf("want a failed request retried, got %q replayed %v (%v)", result, replayed, err)
+	}
+}
+
+func TestIdempotencyCrashed(t *testing.T) {
+	c := pkg.NewMemoryCache()
+	defer c.Close(context.Background())
+	idempotency, err := pkg.NewIdempotency(c, pkg.WithIdempotencyWait(10*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	// A process that crashed holding the key leaves a mark that expires
+	if err := c.SetWithTTL(ctx, "idempotency:webhook-1", "pending:crashed", 100*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	result, replayed, err := idempotency.Do(ctx, "webhook-1", time.Minute, func(ctx context.Context) ([]byte, error) {
+		return []byte("handled"), nil
+	})
+	if err != nil || replayed || string(result) != "handled" {
+		t.Errorf("want the request run once the mark expired, got %q replayed %v (%v)", result, replayed, err)
+	}
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("want the duplicate to wait for the mark, waited %v", elapsed)
+	}
+}
+
+func TestIdempotencyHandler(t *testing.T) {
+	c := pkg.NewMemoryCache()
+	defer c.Close(context.Background())
+	idempotency, err := pkg.NewIdempotency(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var orders atomic.Int64
+	server := httptest.NewServer(idempotency.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			http.Error(w, "try again", http.StatusBadGateway)
+			return
+		}
+		n := orders.Add(1)
+		w.Header().Set("Location", "/orders/"+strconv.FormatInt(n, 10))
+		w.WriteHeader(http.StatusCreated)
+		_, _ = io.WriteString(w, "created")
+	}), time.Minute))
+	defer server.Close()
+
+	post := func(path, key string) *http.Response {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodPost, server.URL+path, strings.NewReader("{}"))
+		if key != "" {
+			req.Header.Set(pkg.IdempotencyHeader, key)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = resp.Body.Close()
+		return resp
+	}
+
+	first := post("/orders", "order-1")
+	replay := post("/orders", "order-1")
+	if replay.StatusCode != http.StatusCreated || replay.Header.Get("Location") != first.Header.Get("Location") || replay.Header.Get("Idempotent-Replayed") != "true" {
+		t.Errorf("want the response replayed, got %d at %s", replay.StatusCode, replay.Header.Get("Location"))
+	}
+	if first.Header.Get("Idempotent-Replayed") != "" {
+		t.Error("want the first response not marked as replayed")
+	}
+	post("/orders", "")
+	if n := orders.Load(); n != 2 {
+		t.Errorf("want requests without a key to pass through, got %d orders", n)
+	}
+
+	for range 2 {
+		if resp := post("/fail", "order-2"); resp.StatusCode != http.StatusBadGateway || resp.Header.Get("Idempotent-Replayed") != "" {
+			t.Errorf("want server errors not kept, got %d", resp.StatusCode)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package cache
+
+import (
+	"cacher/pkg"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHasAndTTL(t *testing.T) {
+	c := pkg.NewMemoryCache(pkg.WithPrefix("app:"))
+	defer c.Close(context.Background())
+	ctx := context.Background()
+
+	_ = c.SetWithTTL(ctx, "key", "value", time.Minute)
+	if found, err := c.Has(ctx, "key"); err != nil || !found {
+		t.Errorf("want key to exist, got %v (%v)", found, err)
+	}
+	if found, _ := c.Has(ctx, "missing"); found {
+		t.Errorf("want missing to not exist")
+	}
+	if ttl, err := c.TTL(ctx, "key"); err != nil || ttl <= 0 || ttl > time.Minute {
+		t.Errorf("want about a minute left, got %s (%v)", ttl, err)
+	}
+	if _, err := c.TTL(ctx, "missing"); !errors.Is(err, pkg.ErrCacheMiss) {
+		t.Errorf("want ErrCacheMiss, got %v", err)
+	}
+}
+
+func TestGetWithTTL(t *testing.T) {
+	c := pkg.NewMemoryCache(pkg.WithPrefix("app:"))
+	defer c.Close(context.Background())
+	testGetWithTTL(t, c)
+}
+
+func TestGetWithTTLRedis(t *testing.T) {
+	c := pkg.NewCache(pkg.WithRedisAddr(redisAddrOrSkip(t)), pkg.WithPrefix("getttl:"), pkg.WithCompression(pkg.Gzip, 16))
+	defer c.Close(context.Background())
+	testGetWithTTL(t, c)
+}
+
+func testGetWithTTL(t *testing.T, c pkg.Cache) {
+	ctx := context.Background()
+	_ = c.Delete(ctx, "missing")
+	value := strings.Repeat("value", 10)
+	_ = c.SetWithTTL(ctx, "key", value, time.Minute)
+	got, ttl, err := c.GetWithTTL(ctx, "key")
+	if err != nil || got != value || ttl <= 0 || ttl > time.Minute {
+		t.Errorf("want the value with about a minute left, got %v %s (%v)", got, ttl, err)
+	}
+	_ = c.SetForever(ctx, "key", "forever")
+	if got, ttl, err := c.GetWithTTL(ctx, "key"); err != nil || got != "forever" || ttl != pkg.NoExpiration {
+		t.Errorf("want NoExpiration, got %v %s (%v)", got, ttl, err)
+	}
+	if _, _, err := c.GetWithTTL(ctx, "missing"); !errors.Is(err, pkg.ErrCacheMiss) {
+		t.Errorf("want ErrCacheMiss, got %v", err)
+	}
+}
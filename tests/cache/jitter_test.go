@@ -0,0 +1,43 @@
+package cache
+
+import (
+	"cacher/internal/adapters"
+	"cacher/pkg"
+	"context"
+	"testing"
+	"time"
+)
+
+// recording is an adapter remembering the expiration of the last Set
+type recording struct {
+	adapters.Cache
+	expiration time.Duration
+}
+
+func (r *recording) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	r.expiration = expiration
+	return r.Cache.Set(ctx, key, value, expiration)
+}
+
+func TestTTLJitter(t *testing.T) {
+	adapter := &recording{Cache: adapters.NewCache(adapters.NewMemory())}
+	c := pkg.NewCache(pkg.WithAdapter(adapter), pkg.WithTTLJitter(0.1))
+	defer c.Close(context.Background())
+
+	seen := map[time.Duration]bool{}
+	for range 20 {
+		_ = c.SetWithTTL(context.Background(), "key", "value", time.Hour)
+		if adapter.expiration < 54*time.Minute || adapter.expiration > 66*time.Minute {
+			t.Fatalf("want ttl within 10%% of an hour, got %s", adapter.expiration)
+		}
+		seen[adapter.expiration] = true
+	}
+	if len(seen) < 2 {
+		t.Errorf("want randomized ttls, got %v", seen)
+	}
+
+	_ = c.SetWithTTL(context.Background(), "key", "value", 0)
+	if adapter.expiration != 0 {
+		t.Errorf("want keys without expiration left alone, got %s", adapter.expiration)
+	}
+}
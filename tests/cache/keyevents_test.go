@@ -0,0 +1,72 @@
+package cache
+
+import (
+	"cacher/pkg"
+	"context"
+	"testing"
+	"time"
+)
+
+func waitKeyEvent(t *testing.T, events chan pkg.KeyEvent, want pkg.KeyEvent) {
+	t.Helper()
+	select {
+	case event := <-events:
+		if event != want {
+			t.Errorf("want %v, got %v", want, event)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("no %s event for %s", want.Type, want.Key)
+	}
+}
+
+func testKeyEvents(t *testing.T, c pkg.Cache, opts ...pkg.KeyEventsOption) {
+	ctx := context.Background()
+	events, err := pkg.NewKeyEvents(ctx, c, opts...)
+	if err != nil {
+		t.Skipf("key events not available: %v", err)
+	}
+	defer events.Close()
+
+	expired := make(chan pkg.KeyEvent, 10)
+	deleted := make(chan pkg.KeyEvent, 10)
+	events.OnExpired("session:*", func(event pkg.KeyEvent) { expired <- event })
+	events.OnDeleted("*", func(event pkg.KeyEvent) { deleted <- event })
+
+	if err := c.SetWithTTL(ctx, "other", "1", 20*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SetWithTTL(ctx, "session:1", "1", 20*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SetForever(ctx, "user:1", "1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Delete(ctx, "user:1"); err != nil {
+		t.Fatal(err)
+	}
+	waitKeyEvent(t, deleted, pkg.KeyEvent{Type: pkg.KeyDeleted, Key: "user:1"})
+
+	time.Sleep(50 * time.Millisecond)
+	// Reading the keys makes backends that expire keys lazily notice
+	_, _ = c.Get(ctx, "other")
+	_, _ = c.Get(ctx, "session:1")
+	waitKeyEvent(t, expired, pkg.KeyEvent{Type: pkg.KeyExpired, Key: "session:1"})
+	select {
+	case event := <-expired:
+		t.Errorf("want keys outside the pattern ignored, got %v", event)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestKeyEventsMemory(t *testing.T) {
+	c := pkg.NewMemoryCache()
+	defer c.Close(context.Background())
+	testKeyEvents(t, c)
+}
+
+func TestKeyEventsRedis(t *testing.T) {
+	addr := redisAddrOrSkip(t)
+	c := pkg.NewCache(pkg.WithRedisAddr(addr), pkg.WithPrefix("keyevents:"))
+	defer c.Close(context.Background())
+	testKeyEvents(t, c, pkg.WithKeyEventsConfig())
+}
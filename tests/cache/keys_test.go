@@ -0,0 +1,60 @@
+package cache
+
+import (
+	"cacher/pkg"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestKeyBuilder(t *testing.T) {
+	b := pkg.NewKeyBuilder()
+
+	if key := b.Key("user", 42, "profile"); key != "user:42:profile" {
+		t.Errorf("want user:42:profile, got %q", key)
+	}
+	if b.Key("a:b", "c") == b.Key("a", "b:c") {
+		t.Error("want parts holding the separator to build distinct keys")
+	}
+	if key := b.Key("search", "hello world"); key != "search:hello%20world" {
+		t.Errorf("want spaces escaped, got %q", key)
+	}
+
+	long := b.Key("search", strings.Repeat("x", 300))
+	if len(long) > pkg.DefaultMaxKeyLength || !strings.HasPrefix(long, "search:xxx") {
+		t.Errorf("want a long key hashed behind its readable start, got %q", long)
+	}
+	if long != b.Key("search", strings.Repeat("x", 300)) {
+		t.Error("want hashing to be deterministic")
+	}
+	if long == b.Key("search", strings.Repeat("x", 301)) {
+		t.Error("want different long keys to hash differently")
+	}
+	if safe := b.Safe(long); safe != long {
+		t.Errorf("want a hashed key left unchanged, got %q", safe)
+	}
+}
+
+func TestMaxKeyLength(t *testing.T) {
+	c := pkg.NewMemoryCache(pkg.WithDefaultTTL(pkg.Forever), pkg.WithMaxKeyLength(64))
+	defer c.Close(context.Background())
+	ctx := context.Background()
+
+	keys := []string{strings.Repeat("k", 200), "with spaces\n", "short"}
+	for _, key := range keys {
+		if err := c.Set(ctx, key, "value of "+key); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, key := range keys {
+		if v, err := c.Get(ctx, key); err != nil || v != "value of "+key {
+			t.Errorf("want the value of %q, got %v (%v)", key, v, err)
+		}
+	}
+	if err := c.Delete(ctx, keys[0]); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := c.Has(ctx, keys[0]); ok {
+		t.Error("want the hashed key deleted")
+	}
+}
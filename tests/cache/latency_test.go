@@ -0,0 +1,39 @@
+package cache
+
+import (
+	"cacher/pkg"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestHitRatioAndPercentiles(t *testing.T) {
+	c := pkg.NewMemoryCache(pkg.WithDefaultTTL(pkg.Forever), pkg.WithStats(true))
+	defer c.Close(context.Background())
+	ctx := context.Background()
+
+	for range 4 {
+		c.Wrap(ctx, "key", func() interface{} {
+			time.Sleep(5 * time.Millisecond)
+			return "value"
+		})
+	}
+
+	stats, err := c.KeyStatistics(ctx, "key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats["hit_ratio_percent"] != 75 {
+		t.Errorf("want a hit ratio of 75%%, got %d", stats["hit_ratio_percent"])
+	}
+	// Percentiles are accurate to a few percent
+	if p50 := stats["loader_p50_us"]; p50 < 4500 || p50 > 20000 {
+		t.Errorf("want a loader p50 of about 5ms, got %dµs", p50)
+	}
+	if _, ok := stats["hit_p99_us"]; !ok {
+		t.Errorf("want hit percentiles, got %v", stats)
+	}
+	if c.Statistics(ctx)["key"]["miss_p50_us"] != stats["miss_p50_us"] {
+		t.Errorf("want Statistics to include percentiles")
+	}
+}
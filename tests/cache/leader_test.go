@@ -0,0 +1,80 @@
+package cache
+
+import (
+	"cacher/pkg"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestElect(t *testing.T) {
+	c := pkg.NewMemoryCache()
+	defer c.Close(context.Background())
+	ctx := context.Background()
+
+	leader, err := c.Elect(ctx, "reports", 60*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	elected := make(chan *pkg.Leadership)
+	go func() {
+		candidate, err := c.Elect(ctx, "reports", 60*time.Millisecond, pkg.WithRetryInterval(10*time.Millisecond))
+		if err != nil {
+			t.Error(err)
+		}
+		elected <- candidate
+	}()
+	select {
+	case <-elected:
+		t.Fatal("want one leader while the first renews its leadership")
+	case <-time.After(150 * time.Millisecond):
+	}
+
+	if err := leader.Resign(ctx); err != nil {
+		t.Fatal(err)
+	}
+	var next *pkg.Leadership
+	select {
+	case next = <-elected:
+	case <-time.After(time.Second):
+		t.Fatal("want the candidate elected after the leader resigned")
+	}
+	if next.Term() <= leader.Term() {
+		t.Errorf("want a higher term, got %d after %d", next.Term(), leader.Term())
+	}
+	if err := next.Resign(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestElectLost(t *testing.T) {
+	c := pkg.NewMemoryCache()
+	defer c.Close(context.Background())
+	ctx := context.Background()
+
+	leader, err := c.Elect(ctx, "cleanup", 60*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Another instance takes over, e.g. after a long pause of the leader
+	if err := c.Delete(ctx, "lock:leader:cleanup"); err != nil {
+		t.Fatal(err)
+	}
+	other, err := c.Elect(ctx, "cleanup", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-leader.Lost():
+	case <-time.After(time.Second):
+		t.Fatal("want the leadership lost")
+	}
+	if err := leader.Resign(ctx); !errors.Is(err, pkg.ErrLockNotHeld) {
+		t.Errorf("want ErrLockNotHeld resigning a lost leadership, got %v", err)
+	}
+	if err := other.Resign(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
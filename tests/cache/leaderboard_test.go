@@ -0,0 +1,55 @@
+package cache
+
+import (
+	"cacher/pkg"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestLeaderboard(t *testing.T) {
+	c := pkg.NewMemoryCache()
+	defer c.Close(context.Background())
+	board, err := pkg.NewLeaderboard(c, "game")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	for i := 1; i <= 10; i++ {
+		if err := board.SetScore(ctx, fmt.Sprintf("player%d", i), float64(i*10)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if score, err := board.AddScore(ctx, "player1", 95); err != nil || score != 105 {
+		t.Errorf("want 105, got %v (%v)", score, err)
+	}
+
+	top, err := board.Top(ctx, 3)
+	if err != nil || len(top) != 3 {
+		t.Fatalf("want 3 entries, got %v (%v)", top, err)
+	}
+	if top[0].Member != "player1" || top[0].Rank != 1 || top[1].Member != "player10" || top[2].Rank != 3 {
+		t.Errorf("want player1 then player10 on top, got %v", top)
+	}
+
+	if rank, err := board.Rank(ctx, "player5"); err != nil || rank != 7 {
+		t.Errorf("want player5 ranked 7, got %v (%v)", rank, err)
+	}
+	around, err := board.Around(ctx, "player5", 1)
+	if err != nil || len(around) != 3 || around[0].Member != "player6" || around[1].Member != "player5" || around[2].Member != "player4" {
+		t.Errorf("want player6, player5, player4, got %v (%v)", around, err)
+	}
+	if around, _ := board.Around(ctx, "player1", 2); len(around) != 3 || around[0].Rank != 1 {
+		t.Errorf("want the window clipped at the top, got %v", around)
+	}
+
+	_ = board.Remove(ctx, "player5")
+	if _, err := board.Rank(ctx, "player5"); !errors.Is(err, pkg.ErrCacheMiss) {
+		t.Errorf("want ErrCacheMiss for a removed member, got %v", err)
+	}
+	if _, err := board.Around(ctx, "player5", 1); !errors.Is(err, pkg.ErrCacheMiss) {
+		t.Errorf("want ErrCacheMiss, got %v", err)
+	}
+}
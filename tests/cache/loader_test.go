@@ -0,0 +1,39 @@
+package cache
+
+import (
+	"cacher/pkg"
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestRegisterLoader(t *testing.T) {
+	c := pkg.NewMemoryCache(pkg.WithDefaultTTL(pkg.Forever))
+	defer c.Close(context.Background())
+	ctx := context.Background()
+
+	loads := 0
+	c.RegisterLoader("user:*", func(ctx context.Context, key string) (interface{}, error) {
+		loads++
+		if key == "user:missing" {
+			return nil, pkg.ErrNotFound
+		}
+		return "loaded " + key, nil
+	})
+
+	for range 2 {
+		if v, err := c.Get(ctx, "user:1"); err != nil || v != "loaded user:1" {
+			t.Fatalf("want loaded user:1, got %v (%v)", v, err)
+		}
+	}
+	if loads != 1 {
+		t.Errorf("want the loaded value stored, loader ran %d times", loads)
+	}
+
+	if _, err := c.Get(ctx, "user:missing"); !errors.Is(err, pkg.ErrNotFound) {
+		t.Errorf("want ErrNotFound, got %v", err)
+	}
+	if _, err := c.Get(ctx, "order:1"); !errors.Is(err, pkg.ErrCacheMiss) {
+		t.Errorf("want keys without a loader to miss, got %v", err)
+	}
+}
@@ -0,0 +1,70 @@
+package cache
+
+import (
+	"cacher/pkg"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLock(t *testing.T) {
+	c := pkg.NewMemoryCache()
+	ctx := context.Background()
+
+	first, err := c.TryLock(ctx, "job", time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.TryLock(ctx, "job", time.Second); !errors.Is(err, pkg.ErrLockNotAcquired) {
+		t.Errorf("want ErrLockNotAcquired, got %v", err)
+	}
+
+	if err := first.Unlock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	second, err := c.Lock(ctx, "job", time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.Token() <= first.Token() {
+		t.Errorf("want increasing fencing tokens, got %v then %v", first.Token(), second.Token())
+	}
+	if err := first.Unlock(ctx); !errors.Is(err, pkg.ErrLockNotHeld) {
+		t.Errorf("want ErrLockNotHeld for stale holder, got %v", err)
+	}
+}
+
+func TestLockAutoRenew(t *testing.T) {
+	c := pkg.NewMemoryCache()
+	ctx := context.Background()
+
+	lock, err := c.TryLock(ctx, "renewed", 60*time.Millisecond, pkg.WithAutoRenew())
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(150 * time.Millisecond)
+
+	if _, err := c.TryLock(ctx, "renewed", time.Second); !errors.Is(err, pkg.ErrLockNotAcquired) {
+		t.Errorf("want renewed lock to still be held, got %v", err)
+	}
+	if err := lock.Unlock(ctx); err != nil {
+		t.Errorf("want unlock to succeed, got %v", err)
+	}
+}
+
+func TestLockTinyTTL(t *testing.T) {
+	c := pkg.NewMemoryCache()
+	ctx := context.Background()
+
+	if _, err := c.TryLock(ctx, "job", 2*time.Nanosecond, pkg.WithAutoRenew()); err == nil {
+		t.Error("want a ttl under a millisecond rejected")
+	}
+	locker, err := pkg.NewLocker(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := locker.Lock(ctx, "job", time.Second, pkg.WithRetryInterval(0)); err == nil {
+		t.Error("want a retry interval of 0 rejected")
+	}
+}
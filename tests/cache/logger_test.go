@@ -0,0 +1,51 @@
+package cache
+
+import (
+	"bytes"
+	"cacher/pkg"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithLogger(t *testing.T) {
+	var out bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&out, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	c := pkg.NewMemoryCache(pkg.WithDefaultTTL(pkg.Forever), pkg.WithLogger(logger))
+	defer c.Close(context.Background())
+	ctx := context.Background()
+
+	_, _ = c.Get(ctx, "key")
+	_ = c.Set(ctx, "key", "value")
+	_, _ = c.Get(ctx, "key")
+
+	logged := out.String()
+	if !strings.Contains(logged, `msg="cache miss" key=key`) || !strings.Contains(logged, `msg="cache hit" key=key`) {
+		t.Errorf("want hit and miss records, got %q", logged)
+	}
+}
+
+func TestLocalTierWithoutBusLogged(t *testing.T) {
+	var out bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&out, nil))
+	// Nothing listens on port 1, so the invalidation bus cannot subscribe
+	c := pkg.NewCache(pkg.WithRedisAddr("127.0.0.1:1"), pkg.WithLocalTier(time.Minute), pkg.WithInvalidation("invalidate"), pkg.WithLogger(logger))
+	defer c.Close(context.Background())
+
+	if !strings.Contains(out.String(), "local tier disabled") {
+		t.Errorf("want the dropped local tier logged, got %q", out.String())
+	}
+}
+
+func TestNilLogger(t *testing.T) {
+	c := pkg.NewMemoryCache(pkg.WithLogger(nil))
+	defer c.Close(context.Background())
+	ctx := context.Background()
+
+	if _, err := c.Get(ctx, "key"); !errors.Is(err, pkg.ErrCacheMiss) {
+		t.Errorf("want a miss without a logger, got %v", err)
+	}
+}
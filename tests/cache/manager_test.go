@@ -0,0 +1,68 @@
+package cache
+
+import (
+	"cacher/config"
+	"cacher/pkg"
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestManager(t *testing.T) {
+	forever := config.Duration(0)
+	m, err := pkg.NewManager(config.Manager{
+		Default: "pages",
+		Stores: map[string]config.Config{
+			"pages":    {Backend: "memory", DefaultTTL: &forever},
+			"sessions": {Backend: "memory", DefaultTTL: &forever},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close(context.Background())
+	ctx := context.Background()
+
+	_ = m.Store("sessions").Set(ctx, "key", "session")
+	_ = m.Default().Set(ctx, "key", "page")
+	if v, _ := m.Store("sessions").Get(ctx, "key"); v != "session" {
+		t.Errorf("want stores kept apart, got %v", v)
+	}
+	if names := m.Names(); !slices.Equal(names, []string{"pages", "sessions"}) {
+		t.Errorf("want pages and sessions, got %v", names)
+	}
+
+	m.Add("custom", pkg.NewNullCache())
+	if _, ok := m.Lookup("custom"); !ok {
+		t.Error("want the added store found")
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("want Store to panic for an unknown store")
+		}
+	}()
+	m.Store("missing")
+}
+
+func TestManagerFromFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.yaml")
+	_ = os.WriteFile(path, []byte("stores:\n  only:\n    backend: memory\n"), 0o600)
+	cfg, err := config.ManagerFromFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := pkg.NewManager(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close(context.Background())
+	if m.Default() != m.Store("only") {
+		t.Error("want the only store to be the default")
+	}
+
+	if _, err := pkg.NewManager(config.Manager{Default: "missing", Stores: cfg.Stores}); err == nil {
+		t.Error("want an error for an unknown default store")
+	}
+}
@@ -0,0 +1,87 @@
+package cache
+
+import (
+	"bytes"
+	"cacher/pkg"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestMaxValueSizeReject(t *testing.T) {
+	c := pkg.NewMemoryCache(pkg.WithDefaultTTL(pkg.Forever), pkg.WithStats(true), pkg.WithMaxValueSize(8, pkg.RejectOversized))
+	defer c.Close(context.Background())
+	ctx := context.Background()
+
+	if err := c.Set(ctx, "small", "value"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Set(ctx, "large", "a value over eight bytes"); !errors.Is(err, pkg.ErrValueTooLarge) {
+		t.Errorf("want ErrValueTooLarge, got %v", err)
+	}
+	values := map[string]interface{}{"a": "ok", "b": "another large value"}
+	if err := c.SetMany(ctx, values); !errors.Is(err, pkg.ErrValueTooLarge) {
+		t.Errorf("want ErrValueTooLarge for the batch, got %v", err)
+	}
+	if _, err := c.Get(ctx, "a"); !errors.Is(err, pkg.ErrCacheMiss) {
+		t.Errorf("want nothing of the batch stored, got %v", err)
+	}
+
+	stats, err := c.KeyStatistics(ctx, "large")
+	if err != nil || stats["oversized"] != 1 {
+		t.Errorf("want the rejection counted, got %v (%v)", stats, err)
+	}
+}
+
+func TestMaxValueSizeSkip(t *testing.T) {
+	var out bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&out, nil))
+	c := pkg.NewMemoryCache(pkg.WithDefaultTTL(pkg.Forever), pkg.WithLogger(logger), pkg.WithMaxValueSize(8, pkg.LogOversized))
+	defer c.Close(context.Background())
+	ctx := context.Background()
+
+	if err := c.Set(ctx, "large", strings.Repeat("x", 100)); err != nil {
+		t.Fatalf("want the write skipped without an error, got %v", err)
+	}
+	if _, err := c.Get(ctx, "large"); !errors.Is(err, pkg.ErrCacheMiss) {
+		t.Errorf("want the value not cached, got %v", err)
+	}
+	if !strings.Contains(out.String(), "value too large to cache") {
+		t.Errorf("want a warning logged, got %q", out.String())
+	}
+	if strings.Contains(out.String(), "xxxx") {
+		t.Errorf("want the value left out of the log, got %q", out.String())
+	}
+
+	if err := c.SetMany(ctx, map[string]interface{}{"a": "ok", "b": strings.Repeat("x", 100)}); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := c.Get(ctx, "a"); err != nil || v != "ok" {
+		t.Errorf("want small values of the batch stored, got %v (%v)", v, err)
+	}
+}
+
+// blob is written as its MarshalBinary output
+type blob int
+
+func (b blob) MarshalBinary() ([]byte, error) {
+	return bytes.Repeat([]byte("b"), int(b)), nil
+}
+
+func TestMaxValueSizeEncoded(t *testing.T) {
+	c := pkg.NewMemoryCache(pkg.WithDefaultTTL(pkg.Forever), pkg.WithMaxValueSize(8, pkg.RejectOversized))
+	defer c.Close(context.Background())
+	ctx := context.Background()
+
+	if err := c.Set(ctx, "small", blob(4)); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Set(ctx, "large", blob(100)); !errors.Is(err, pkg.ErrValueTooLarge) {
+		t.Errorf("want ErrValueTooLarge for a large encoded value, got %v", err)
+	}
+	if err := c.Set(ctx, "number", 1234567890123); !errors.Is(err, pkg.ErrValueTooLarge) {
+		t.Errorf("want numbers measured as written, got %v", err)
+	}
+}
@@ -0,0 +1,86 @@
+package cache
+
+import (
+	"cacher/pkg"
+	"context"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/labstack/echo/v4"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func serveMiddleware(t *testing.T, handler http.Handler, path string) (string, string) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	body, _ := io.ReadAll(recorder.Result().Body)
+	return recorder.Header().Get("X-Cache"), string(body)
+}
+
+func testMiddleware(t *testing.T, handler http.Handler, calls *int) {
+	for _, want := range []string{"MISS", "HIT"} {
+		if status, body := serveMiddleware(t, handler, "/products/1"); status != want || body != "product 1 #1" {
+			t.Errorf("want %s with the first response, got %s with %q", want, status, body)
+		}
+	}
+	serveMiddleware(t, handler, "/live/1")
+	if status, body := serveMiddleware(t, handler, "/live/1"); status != "" || body != "product 1 #3" {
+		t.Errorf("want a route with a TTL of 0 not cached, got %s with %q", status, body)
+	}
+	if status, _ := serveMiddleware(t, handler, "/fail"); status != "MISS" {
+		t.Errorf("want errors passed through, got %s", status)
+	}
+	if status, _ := serveMiddleware(t, handler, "/fail"); status != "MISS" {
+		t.Errorf("want errors not cached, got %s", status)
+	}
+	if *calls != 5 {
+		t.Errorf("want 5 handler calls, got %d", *calls)
+	}
+}
+
+func TestGinMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c := pkg.NewMemoryCache()
+	defer c.Close(context.Background())
+
+	calls := 0
+	router := gin.New()
+	router.Use(pkg.GinMiddleware(c, time.Minute, pkg.WithRouteTTL("/live/*", 0)))
+	product := func(ctx *gin.Context) {
+		calls++
+		ctx.String(http.StatusOK, "product %s #%d", ctx.Param("id"), calls)
+	}
+	router.GET("/products/:id", product)
+	router.GET("/live/:id", product)
+	router.GET("/fail", func(ctx *gin.Context) {
+		calls++
+		ctx.String(http.StatusInternalServerError, "failed")
+	})
+	testMiddleware(t, router, &calls)
+}
+
+func TestEchoMiddleware(t *testing.T) {
+	c := pkg.NewMemoryCache()
+	defer c.Close(context.Background())
+
+	calls := 0
+	e := echo.New()
+	e.Use(pkg.EchoMiddleware(c, time.Minute, pkg.WithRouteTTL("/live/*", 0)))
+	product := func(ctx echo.Context) error {
+		calls++
+		return ctx.String(http.StatusOK, "product "+ctx.Param("id")+" #"+strconv.Itoa(calls))
+	}
+	e.GET("/products/:id", product)
+	e.GET("/live/:id", product)
+	e.GET("/fail", func(ctx echo.Context) error {
+		calls++
+		return errors.New("failed")
+	})
+	testMiddleware(t, e, &calls)
+}
@@ -0,0 +1,59 @@
+package cache
+
+import (
+	"cacher/pkg"
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestMigrationFrom(t *testing.T) {
+	ctx := context.Background()
+	old := pkg.NewMemoryCache()
+	_ = old.SetForever(ctx, "user:1", "alice")
+
+	c := pkg.NewMemoryCache(pkg.WithMigrationFrom(old, 1))
+	defer c.Close(ctx)
+	if value, err := c.Get(ctx, "user:1"); err != nil || value != "alice" {
+		t.Fatalf("want entries of the old backend served, got %v (%v)", value, err)
+	}
+	if err := c.SetForever(ctx, "user:2", "bob"); err != nil {
+		t.Fatal(err)
+	}
+	if value, _ := old.Get(ctx, "user:2"); value != "bob" {
+		t.Errorf("want writes on the old backend too, got %v", value)
+	}
+	if stats := c.MigrationStatistics(ctx); stats["compared"] != 1 || stats["missing"] != 1 {
+		t.Errorf("want the read compared and found missing, got %v", stats)
+	}
+
+	if err := c.CutoverMigration(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if value, _ := c.Get(ctx, "user:2"); value != "bob" {
+		t.Errorf("want written entries served by the new backend, got %v", value)
+	}
+	if _, err := c.Get(ctx, "user:1"); !errors.Is(err, pkg.ErrCacheMiss) {
+		t.Errorf("want entries never written missing after the cutover, got %v", err)
+	}
+	if stats := c.MigrationStatistics(ctx); stats["cutover"] != 1 {
+		t.Errorf("want the cutover reported, got %v", stats)
+	}
+
+	plain := pkg.NewMemoryCache()
+	defer plain.Close(ctx)
+	if err := plain.CutoverMigration(ctx); !errors.Is(err, pkg.ErrUnsupported) {
+		t.Errorf("want ErrUnsupported without a migration, got %v", err)
+	}
+}
+
+func TestMigrationFromCompressed(t *testing.T) {
+	ctx := context.Background()
+	old := pkg.NewMemoryCache()
+	c := pkg.NewMemoryCache(pkg.WithMigrationFrom(old, 1), pkg.WithCompression(pkg.Gzip, 16))
+	defer c.Close(ctx)
+
+	if err := c.CutoverMigration(ctx); err != nil {
+		t.Errorf("want the migration found with compression, got %v", err)
+	}
+}
@@ -0,0 +1,48 @@
+package cache
+
+import (
+	"cacher/pkg"
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestNamespaceInvalidate(t *testing.T) {
+	c := pkg.NewMemoryCache(pkg.WithDefaultTTL(pkg.Forever))
+	defer c.Close(context.Background())
+	ctx := context.Background()
+
+	products := c.Namespace("products")
+	users := c.Namespace("users")
+	_ = products.Set(ctx, "1", "book")
+	_ = users.Set(ctx, "1", "alice")
+
+	if v, err := products.Get(ctx, "1"); err != nil || v != "book" {
+		t.Errorf("want book, got %v (%v)", v, err)
+	}
+	if err := products.Invalidate(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := products.Get(ctx, "1"); !errors.Is(err, pkg.ErrCacheMiss) {
+		t.Errorf("want a miss after Invalidate, got %v", err)
+	}
+	if v, err := users.Get(ctx, "1"); err != nil || v != "alice" {
+		t.Errorf("want other namespaces untouched, got %v (%v)", v, err)
+	}
+	if version, err := products.Version(ctx); err != nil || version != 1 {
+		t.Errorf("want version 1, got %v (%v)", version, err)
+	}
+
+	loads := 0
+	load := func() interface{} {
+		loads++
+		return "loaded"
+	}
+	products.Wrap(ctx, "2", load)
+	products.Wrap(ctx, "2", load)
+	_ = products.Invalidate(ctx)
+	products.Wrap(ctx, "2", load)
+	if loads != 2 {
+		t.Errorf("want the loader to run again only after Invalidate, ran %d times", loads)
+	}
+}
@@ -0,0 +1,39 @@
+package cache
+
+import (
+	"cacher/pkg"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNegativeCaching(t *testing.T) {
+	c := pkg.NewMemoryCache(pkg.WithNegativeTTL(50 * time.Millisecond))
+	defer c.Close(context.Background())
+	ctx := context.Background()
+
+	loads := 0
+	loader := func() interface{} {
+		loads++
+		return pkg.ErrNotFound
+	}
+
+	for range 3 {
+		if result := c.Wrap(ctx, "user:42", loader); result != pkg.ErrNotFound {
+			t.Fatalf("want ErrNotFound, got %v", result)
+		}
+	}
+	if loads != 1 {
+		t.Errorf("want the miss cached, loader ran %d times", loads)
+	}
+	if _, err := c.Get(ctx, "user:42"); !errors.Is(err, pkg.ErrNotFound) {
+		t.Errorf("want ErrNotFound from Get, got %v", err)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	c.Wrap(ctx, "user:42", loader)
+	if loads != 2 {
+		t.Errorf("want the loader to run again after the negative ttl, ran %d times", loads)
+	}
+}
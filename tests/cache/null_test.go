@@ -0,0 +1,44 @@
+package cache
+
+import (
+	"cacher/pkg"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDisabledCache(t *testing.T) {
+	c := pkg.NewMemoryCache(pkg.WithDefaultTTL(pkg.Forever), pkg.WithLocalTier(time.Minute), pkg.WithDisabled(true))
+	defer c.Close(context.Background())
+	ctx := context.Background()
+
+	if err := c.Set(ctx, "key", "value"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Get(ctx, "key"); !errors.Is(err, pkg.ErrCacheMiss) {
+		t.Errorf("want a miss after Set, got %v", err)
+	}
+
+	loads := 0
+	for range 2 {
+		if v := c.Wrap(ctx, "wrapped", func() interface{} {
+			loads++
+			return "loaded"
+		}); v != "loaded" {
+			t.Errorf("want loaded, got %v", v)
+		}
+	}
+	if loads != 2 {
+		t.Errorf("want the loader to run on every call, ran %d times", loads)
+	}
+}
+
+func TestNullCacheHealth(t *testing.T) {
+	c := pkg.NewNullCache()
+	defer c.Close(context.Background())
+
+	if health := c.Health(context.Background()); health.Backend != "null" || !health.Healthy {
+		t.Errorf("want a healthy null backend, got %+v", health)
+	}
+}
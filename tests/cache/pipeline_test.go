@@ -0,0 +1,148 @@
+package cache
+
+import (
+	"bytes"
+	"cacher/pkg"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testPipeline(t *testing.T, c pkg.Cache) {
+	ctx := context.Background()
+	long := strings.Repeat("compressible ", 20)
+	if err := c.SetForever(ctx, "old", "gone soon"); err != nil {
+		t.Fatal(err)
+	}
+
+	p := c.Pipeline()
+	setA := p.Set("a", "1")
+	setLong := p.SetWithTTL("long", long, time.Minute)
+	incr := p.Increment("n", 5)
+	incrAgain := p.Increment("n", 2)
+	getA := p.Get("a")
+	getLong := p.Get("long")
+	getMissing := p.Get("missing")
+	del := p.Delete("old")
+	getOld := p.Get("old")
+	if p.Len() != 9 {
+		t.Fatalf("want 9 queued commands, got %d", p.Len())
+	}
+	if _, err := getA.Result(); err == nil {
+		t.Error("want an error before Exec")
+	}
+	if err := p.Exec(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if p.Len() != 0 {
+		t.Errorf("want Exec to empty the pipeline, got %d commands", p.Len())
+	}
+
+	if setA.Err() != nil || setLong.Err() != nil || del.Err() != nil {
+		t.Errorf("want writes to succeed, got %v, %v and %v", setA.Err(), setLong.Err(), del.Err())
+	}
+	if n, err := incr.Result(); err != nil || n != 5 {
+		t.Errorf("want 5, got %d, %v", n, err)
+	}
+	if n, err := incrAgain.Result(); err != nil || n != 7 {
+		t.Errorf("want 7, got %d, %v", n, err)
+	}
+	if value, err := getA.Result(); err != nil || value != "1" {
+		t.Errorf("want 1, got %v, %v", value, err)
+	}
+	if value, err := getLong.Result(); err != nil || value != long {
+		t.Errorf("want the long value back, got %v, %v", value, err)
+	}
+	if _, err := getMissing.Result(); !errors.Is(err, pkg.ErrCacheMiss) {
+		t.Errorf("want ErrCacheMiss, got %v", err)
+	}
+	if _, err := getOld.Result(); !errors.Is(err, pkg.ErrCacheMiss) {
+		t.Errorf("want the deleted key to miss, got %v", err)
+	}
+
+	// Values written by the pipeline are read back by the cache
+	if value, err := c.Get(ctx, "long"); err != nil || value != long {
+		t.Errorf("want the long value from Get, got %v, %v", value, err)
+	}
+}
+
+func TestPipelineMemory(t *testing.T) {
+	c := pkg.NewMemoryCache(pkg.WithDefaultTTL(pkg.Forever), pkg.WithCompression(pkg.Zstd, 64))
+	defer c.Close(context.Background())
+	testPipeline(t, c)
+}
+
+func TestPipelineEncrypted(t *testing.T) {
+	key := bytes.Repeat([]byte("k"), 32)
+	c := pkg.NewMemoryCache(pkg.WithDefaultTTL(pkg.Forever), pkg.WithEncryption(key), pkg.WithCompression(pkg.Zstd, 64))
+	defer c.Close(context.Background())
+	ctx := context.Background()
+	long := strings.Repeat("compressible ", 20)
+
+	p := c.Pipeline()
+	set := p.Set("long", long)
+	get := p.Get("long")
+	if err := p.Exec(ctx); err != nil || set.Err() != nil {
+		t.Fatalf("want the write to succeed, got %v (%v)", set.Err(), err)
+	}
+	if value, err := get.Result(); err != nil || value != long {
+		t.Errorf("want the long value back, got %v, %v", value, err)
+	}
+	if value, err := c.Get(ctx, "long"); err != nil || value != long {
+		t.Errorf("want the long value from Get, got %v, %v", value, err)
+	}
+}
+
+func TestPipelineRedis(t *testing.T) {
+	addr := redisAddrOrSkip(t)
+	ctx := context.Background()
+	c := pkg.NewCache(pkg.WithRedisAddr(addr), pkg.WithPrefix("pipeline:"), pkg.WithDefaultTTL(time.Minute), pkg.WithCompression(pkg.Gzip, 64))
+	defer c.Close(ctx)
+	if err := c.DeleteMany(ctx, "a", "long", "n", "missing", "old"); err != nil {
+		t.Fatal(err)
+	}
+	testPipeline(t, c)
+}
+
+func TestPipelineRejectsWrites(t *testing.T) {
+	ctx := context.Background()
+	c := pkg.NewMemoryCache(pkg.WithMaxValueSize(4, pkg.RejectOversized))
+	defer c.Close(ctx)
+
+	p := c.Pipeline()
+	noTTL := p.Set("a", "1")
+	tooLarge := p.SetWithTTL("b", "too large", pkg.Forever)
+	small := p.SetWithTTL("c", "ok", pkg.Forever)
+	if err := p.Exec(ctx); !errors.Is(err, pkg.ErrNoDefaultTTL) {
+		t.Errorf("want ErrNoDefaultTTL first, got %v", err)
+	}
+	if !errors.Is(noTTL.Err(), pkg.ErrNoDefaultTTL) || !errors.Is(tooLarge.Err(), pkg.ErrValueTooLarge) || small.Err() != nil {
+		t.Errorf("want each write checked, got %v, %v and %v", noTTL.Err(), tooLarge.Err(), small.Err())
+	}
+	if value, err := c.Get(ctx, "c"); err != nil || value != "ok" {
+		t.Errorf("want the valid write stored, got %v, %v", value, err)
+	}
+}
+
+func TestPipelineEvictsLocalTier(t *testing.T) {
+	ctx := context.Background()
+	c := pkg.NewMemoryCache(pkg.WithDefaultTTL(pkg.Forever), pkg.WithLocalTier(time.Minute))
+	defer c.Close(ctx)
+	if err := c.Set(ctx, "a", "1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Get(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+
+	p := c.Pipeline()
+	p.Set("a", "2")
+	if err := p.Exec(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if value, err := c.Get(ctx, "a"); err != nil || value != "2" {
+		t.Errorf("want the new value, got %v, %v", value, err)
+	}
+}
@@ -0,0 +1,45 @@
+package cache
+
+import (
+	"cacher/pkg"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPull(t *testing.T) {
+	c := pkg.NewMemoryCache(pkg.WithDefaultTTL(pkg.Forever), pkg.WithLocalTier(time.Minute))
+	defer c.Close(context.Background())
+	ctx := context.Background()
+
+	_ = c.Set(ctx, "key", "value")
+	// Populate the local tier so Pull has to evict it
+	_, _ = c.Get(ctx, "key")
+
+	if v, err := c.Pull(ctx, "key"); err != nil || v != "value" {
+		t.Fatalf("want value, got %v (%v)", v, err)
+	}
+	if _, err := c.Get(ctx, "key"); !errors.Is(err, pkg.ErrCacheMiss) {
+		t.Errorf("want key gone from both tiers, got %v", err)
+	}
+	if _, err := c.Pull(ctx, "key"); !errors.Is(err, pkg.ErrCacheMiss) {
+		t.Errorf("want ErrCacheMiss, got %v", err)
+	}
+}
+
+func TestGetSet(t *testing.T) {
+	c := pkg.NewMemoryCache(pkg.WithDefaultTTL(pkg.Forever))
+	defer c.Close(context.Background())
+	ctx := context.Background()
+
+	if _, err := c.GetSet(ctx, "key", "first"); !errors.Is(err, pkg.ErrCacheMiss) {
+		t.Errorf("want ErrCacheMiss for a new key, got %v", err)
+	}
+	if v, err := c.GetSet(ctx, "key", "second"); err != nil || v != "first" {
+		t.Errorf("want first, got %v (%v)", v, err)
+	}
+	if v, _ := c.Get(ctx, "key"); v != "second" {
+		t.Errorf("want second stored, got %v", v)
+	}
+}
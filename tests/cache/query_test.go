@@ -0,0 +1,140 @@
+package cache
+
+import (
+	"cacher/pkg"
+	"context"
+	"database/sql"
+	_ "modernc.org/sqlite"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type product struct {
+	ID    int    `json:"id"`
+	Name  string `json:"name"`
+	Price int    `json:"price"`
+}
+
+func scanProduct(rows *sql.Rows) (product, error) {
+	var p product
+	err := rows.Scan(&p.ID, &p.Name, &p.Price)
+	return p, err
+}
+
+// countingDB counts the queries that reach the database
+type countingDB struct {
+	*sql.DB
+	queries int
+}
+
+func (d *countingDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	d.queries++
+	return d.DB.QueryContext(ctx, query, args...)
+}
+
+// SelectContext scans products like sqlx does
+func (d *countingDB) SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	rows, err := d.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	products := dest.(*[]product)
+	for rows.Next() {
+		p, err := scanProduct(rows)
+		if err != nil {
+			return err
+		}
+		*products = append(*products, p)
+	}
+	return rows.Err()
+}
+
+func openProducts(t *testing.T) *countingDB {
+	t.Helper()
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "shop.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = db.Close() })
+	for _, statement := range []string{
+		"CREATE TABLE products (id INTEGER PRIMARY KEY, name TEXT, price INTEGER)",
+		"INSERT INTO products (name, price) VALUES ('pen', 2), ('book', 12)",
+	} {
+		if _, err := db.Exec(statement); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return &countingDB{DB: db}
+}
+
+func TestQueryCache(t *testing.T) {
+	db := openProducts(t)
+	c := pkg.NewMemoryCache()
+	defer c.Close(context.Background())
+	q := pkg.NewQueryCache(c, db, time.Minute)
+	ctx := context.Background()
+
+	cheap := func(query string) []product {
+		t.Helper()
+		products, err := pkg.Query(ctx, q, scanProduct, query, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return products
+	}
+	if products := cheap("SELECT id, name, price FROM products WHERE price < ?"); len(products) != 1 || products[0].Name != "pen" {
+		t.Fatalf("want the pen, got %v", products)
+	}
+	if products := cheap("SELECT id, name, price\n\tFROM products   WHERE price < ?;"); len(products) != 1 || db.queries != 1 {
+		t.Errorf("want the same query with other whitespace cached, got %v after %d queries", products, db.queries)
+	}
+	if products, _ := pkg.Query(ctx, q, scanProduct, "SELECT id, name, price FROM products WHERE price < ?", 20); len(products) != 2 || db.queries != 2 {
+		t.Errorf("want other arguments queried, got %v after %d queries", products, db.queries)
+	}
+
+	if _, err := q.Exec(ctx, "UPDATE products SET price = 15 WHERE name = ?", "pen"); err != nil {
+		t.Fatal(err)
+	}
+	if products := cheap("SELECT id, name, price FROM products WHERE price < ?"); len(products) != 0 || db.queries != 3 {
+		t.Errorf("want the write to invalidate the results, got %v after %d queries", products, db.queries)
+	}
+}
+
+func TestQueryCacheHooks(t *testing.T) {
+	db := openProducts(t)
+	c := pkg.NewMemoryCache()
+	defer c.Close(context.Background())
+	q := pkg.NewQueryCache(c, db, time.Minute)
+	q.OnExec(func(query string, args []interface{}) []string {
+		return []string{"products"}
+	})
+	ctx := context.Background()
+
+	var products []product
+	if err := q.Select(ctx, &products, `SELECT p.id, p.name, p.price FROM "products" p ORDER BY p.id`); err != nil {
+		t.Fatal(err)
+	}
+	var cached []product
+	if err := q.Select(ctx, &cached, `SELECT p.id, p.name, p.price FROM "products" p ORDER BY p.id`); err != nil || len(cached) != 2 || db.queries != 1 {
+		t.Errorf("want the selection cached, got %v after %d queries (%v)", cached, db.queries, err)
+	}
+
+	if _, err := q.Exec(ctx, "CREATE TRIGGER noop AFTER INSERT ON products BEGIN SELECT 1; END"); err != nil {
+		t.Fatal(err)
+	}
+	var refreshed []product
+	if err := q.Select(ctx, &refreshed, `SELECT p.id, p.name, p.price FROM "products" p ORDER BY p.id`); err != nil || db.queries != 2 {
+		t.Errorf("want the hook to invalidate the results, got %d queries (%v)", db.queries, err)
+	}
+
+	if err := q.Invalidate(ctx, "PRODUCTS"); err != nil {
+		t.Fatal(err)
+	}
+	refreshed = nil
+	if err := q.Select(ctx, &refreshed, `SELECT p.id, p.name, p.price FROM "products" p ORDER BY p.id`); err != nil || db.queries != 3 {
+		t.Errorf("want explicit invalidation, got %d queries (%v)", db.queries, err)
+	}
+}
@@ -0,0 +1,74 @@
+package cache
+
+import (
+	"cacher/pkg"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type job struct {
+	Name string `json:"name"`
+}
+
+func TestReliableQueue(t *testing.T) {
+	c := pkg.NewMemoryCache()
+	defer c.Close(context.Background())
+	ctx := context.Background()
+	jobs, err := pkg.NewReliableQueue[job](c, "jobs", 50*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := jobs.Dequeue(ctx, 0); !errors.Is(err, pkg.ErrQueueEmpty) {
+		t.Errorf("want ErrQueueEmpty, got %v", err)
+	}
+	// Equal values are distinct items
+	_ = jobs.Enqueue(ctx, job{"a"}, job{"b"}, job{"b"})
+	if n, _ := jobs.Len(ctx); n != 3 {
+		t.Errorf("want 3 waiting, got %d", n)
+	}
+
+	first, err := jobs.Dequeue(ctx, time.Second)
+	if err != nil || first.Value.Name != "a" {
+		t.Fatalf("want a first, got %v (%v)", first, err)
+	}
+	if err := jobs.Ack(ctx, first); err != nil {
+		t.Errorf("want the ack to succeed, got %v", err)
+	}
+
+	// The worker of the second item dies: it goes back once its lease expires
+	second, _ := jobs.Dequeue(ctx, time.Second)
+	if n, _ := jobs.Requeue(ctx); n != 0 {
+		t.Errorf("want nothing requeued before the lease expires, got %d", n)
+	}
+	time.Sleep(60 * time.Millisecond)
+	if n, err := jobs.Requeue(ctx); err != nil || n != 1 {
+		t.Errorf("want 1 requeued, got %v (%v)", n, err)
+	}
+	if err := jobs.Ack(ctx, second); !errors.Is(err, pkg.ErrNotLeased) {
+		t.Errorf("want ErrNotLeased acking a requeued item, got %v", err)
+	}
+
+	third, _ := jobs.Dequeue(ctx, time.Second)
+	again, _ := jobs.Dequeue(ctx, time.Second)
+	if third.ID == second.ID || again.ID != second.ID {
+		t.Errorf("want the requeued item after the one waiting, got %v then %v", third.ID, again.ID)
+	}
+	_ = jobs.Ack(ctx, third)
+	_ = jobs.Ack(ctx, again)
+
+	// A waiting Dequeue gets an item enqueued later
+	go func() {
+		time.Sleep(30 * time.Millisecond)
+		_ = jobs.Enqueue(ctx, job{"late"})
+	}()
+	if item, err := jobs.Dequeue(ctx, time.Second); err != nil || item.Value.Name != "late" {
+		t.Errorf("want the late job, got %v (%v)", item, err)
+	}
+	start := time.Now()
+	if _, err := jobs.Dequeue(ctx, 50*time.Millisecond); !errors.Is(err, pkg.ErrQueueEmpty) || time.Since(start) < 40*time.Millisecond {
+		t.Errorf("want ErrQueueEmpty after waiting, got %v", err)
+	}
+}
@@ -0,0 +1,34 @@
+package cache
+
+import (
+	"cacher/pkg"
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWrapWithRefresh(t *testing.T) {
+	c := pkg.NewMemoryCache(pkg.WithStats(true), pkg.WithRefreshAhead(100*time.Millisecond, 1, 1))
+	defer c.Close(context.Background())
+	ctx := context.Background()
+
+	var loads atomic.Int32
+	loader := func() interface{} {
+		return loads.Add(1)
+	}
+
+	deadline := time.Now().Add(700 * time.Millisecond)
+	for time.Now().Before(deadline) {
+		c.WrapWithRefresh(ctx, "hot", 300*time.Millisecond, loader)
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if loads.Load() < 2 {
+		t.Errorf("want the hot key refreshed in the background, loaded %d times", loads.Load())
+	}
+	stats, _ := c.KeyStatistics(ctx, "hot")
+	if stats["misses"] != 1 {
+		t.Errorf("want only the first read to miss, got %v", stats)
+	}
+}
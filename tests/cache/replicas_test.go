@@ -0,0 +1,26 @@
+package cache
+
+import (
+	"cacher/pkg"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRedisReplicas(t *testing.T) {
+	ctx := context.Background()
+	addr := redisAddrOrSkip(t)
+	// The primary stands in for its own replica
+	c := pkg.NewCache(pkg.WithRedisAddr(addr), pkg.WithRedisReplicas(addr), pkg.WithReplicaPolicy(pkg.LowestLatency, time.Second), pkg.WithPrefix("replicas:"))
+	defer c.Close(ctx)
+
+	if err := c.SetWithTTL(ctx, "greeting", "hello", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if value, err := c.Get(ctx, "greeting"); err != nil || value != "hello" {
+		t.Errorf("want the value back, got %v (%v)", value, err)
+	}
+	if err := c.Ping(ctx); err != nil {
+		t.Error(err)
+	}
+}
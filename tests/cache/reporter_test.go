@@ -0,0 +1,48 @@
+package cache
+
+import (
+	"bytes"
+	"cacher/pkg"
+	"context"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriterReporter(t *testing.T) {
+	var out bytes.Buffer
+	c := pkg.NewMemoryCache(pkg.WithDefaultTTL(pkg.Forever), pkg.WithStats(true), pkg.WithStatsReporter(pkg.NewWriterReporter(&out), time.Hour))
+	ctx := context.Background()
+
+	_, _ = c.Get(ctx, "key")
+	// Close reports once more even though the interval has not elapsed
+	_ = c.Close(ctx)
+	if !strings.Contains(out.String(), "misses:1") {
+		t.Errorf("want the miss reported, got %q", out.String())
+	}
+}
+
+func TestPrometheusReporter(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	reporter, err := pkg.NewPrometheusReporter(registry, "cacher")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := pkg.NewMemoryCache(pkg.WithDefaultTTL(pkg.Forever), pkg.WithStats(true), pkg.WithStatsReporter(reporter, time.Hour))
+	ctx := context.Background()
+
+	_ = c.Set(ctx, "key", "value")
+	_, _ = c.Get(ctx, "key")
+	_ = c.Close(ctx)
+
+	expected := `
+# HELP cacher_key_operations Hits, misses, deletes and errors per key.
+# TYPE cacher_key_operations gauge
+cacher_key_operations{key="key",result="hits"} 1
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "cacher_key_operations"); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,67 @@
+package cache
+
+import (
+	"cacher/pkg"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestKeys(t *testing.T) {
+	c := pkg.NewMemoryCache(pkg.WithDefaultTTL(pkg.Forever), pkg.WithPrefix("app:"))
+	defer c.Close(context.Background())
+	ctx := context.Background()
+
+	for i := range 250 {
+		_ = c.Set(ctx, fmt.Sprintf("user:%d", i), i)
+	}
+	_ = c.Set(ctx, "order:1", 1)
+
+	var keys []string
+	for key, err := range c.Keys(ctx, "user:*") {
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	if len(keys) != 250 || keys[0] != "user:0" {
+		t.Errorf("want 250 user keys without the prefix, got %d starting with %v", len(keys), keys[:min(len(keys), 1)])
+	}
+
+	seen := 0
+	for range c.Keys(ctx, "*") {
+		if seen++; seen == 3 {
+			break
+		}
+	}
+	if seen != 3 {
+		t.Errorf("want iteration to stop on break, saw %d keys", seen)
+	}
+}
+
+func TestDeleteByPattern(t *testing.T) {
+	c := pkg.NewMemoryCache(pkg.WithDefaultTTL(pkg.Forever), pkg.WithLocalTier(time.Minute))
+	defer c.Close(context.Background())
+	ctx := context.Background()
+
+	for i := range 150 {
+		_ = c.Set(ctx, fmt.Sprintf("session:%d", i), i)
+	}
+	_ = c.Set(ctx, "user:1", "alice")
+	_, _ = c.Get(ctx, "session:1")
+
+	deleted, err := c.DeleteByPattern(ctx, "session:*")
+	if err != nil || deleted != 150 {
+		t.Errorf("want 150 deleted, got %d (%v)", deleted, err)
+	}
+	if _, err := c.Get(ctx, "session:1"); !errors.Is(err, pkg.ErrCacheMiss) {
+		t.Errorf("want the local tier cleared too, got %v", err)
+	}
+	if v, err := c.Get(ctx, "user:1"); err != nil || v != "alice" {
+		t.Errorf("want other keys kept, got %v (%v)", v, err)
+	}
+}
@@ -0,0 +1,53 @@
+package cache
+
+import (
+	"cacher/pkg"
+	"context"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"os"
+	"testing"
+)
+
+// redisAddrOrSkip returns the address of the Redis server in
+// CACHER_REDIS_ADDR (default localhost:6379) and skips the test when it is not
+// reachable
+func redisAddrOrSkip(t *testing.T) string {
+	t.Helper()
+	addr := os.Getenv("CACHER_REDIS_ADDR")
+	if addr == "" {
+		addr = "localhost:6379"
+	}
+	client := redis.NewClient(&redis.Options{Addr: addr})
+	defer client.Close()
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		t.Skipf("redis not available at %s: %v", addr, err)
+	}
+	return addr
+}
+
+var incrTwiceScript = pkg.RegisterScript("test_incr_twice", `
+redis.call('INCR', KEYS[1])
+return redis.call('INCR', KEYS[1])
+`)
+
+func TestRunScript(t *testing.T) {
+	ctx := context.Background()
+	memory := pkg.NewMemoryCache()
+	defer memory.Close(ctx)
+	if _, err := pkg.RunScript(ctx, memory, incrTwiceScript.Name(), []string{"n"}); !errors.Is(err, pkg.ErrUnsupported) {
+		t.Errorf("want ErrUnsupported without Redis, got %v", err)
+	}
+
+	c := pkg.NewCache(pkg.WithRedisAddr(redisAddrOrSkip(t)), pkg.WithPrefix("scripts:"))
+	defer c.Close(ctx)
+	_ = c.Delete(ctx, "n")
+	result, err := pkg.RunScript(ctx, c, "test_incr_twice", []string{"n"})
+	if err != nil || result != int64(2) {
+		t.Fatalf("want 2, got %v (%v)", result, err)
+	}
+	// The key was prefixed like the keys of the cache
+	if n, err := c.Increment(ctx, "n", 1); err != nil || n != 3 {
+		t.Errorf("want the script to update the prefixed key, got %v (%v)", n, err)
+	}
+}
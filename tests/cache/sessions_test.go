@@ -0,0 +1,142 @@
+package cache
+
+import (
+	"cacher/pkg"
+	"context"
+	"github.com/gorilla/sessions"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newSessionStore(t *testing.T, opts ...pkg.SessionStoreOption) *pkg.SessionStore {
+	t.Helper()
+	c := pkg.NewMemoryCache()
+	t.Cleanup(func() { _ = c.Close(context.Background()) })
+	store, err := pkg.NewSessionStore(c, [][]byte{[]byte("0123456789abcdef0123456789abcdef")}, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+// saveSession saves the session of the request with cookies and returns the
+// cookie the response sets
+func saveSession(t *testing.T, store sessions.Store, cookies []*http.Cookie, change func(s *sessions.Session)) (*sessions.Session, *http.Cookie) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+	session, err := store.Get(req, "app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	change(session)
+	recorder := httptest.NewRecorder()
+	if err := session.Save(req, recorder); err != nil {
+		t.Fatal(err)
+	}
+	response := recorder.Result().Cookies()
+	if len(response) != 1 {
+		t.Fatalf("want one cookie, got %v", response)
+	}
+	return session, response[0]
+}
+
+func TestSessionStore(t *testing.T) {
+	store := newSessionStore(t)
+
+	session, cookie := saveSession(t, store, nil, func(s *sessions.Session) {
+		if !s.IsNew {
+			t.Error("want a new session without a cookie")
+		}
+		s.Values["cart"] = 3
+	})
+	if cookie.Value == "" || cookie.Value == session.ID {
+		t.Errorf("want the signed session ID in the cookie, got %q", cookie.Value)
+	}
+
+	loaded, _ := saveSession(t, store, []*http.Cookie{cookie}, func(s *sessions.Session) {
+		if s.IsNew || s.Values["cart"] != 3 {
+			t.Errorf("want the stored session, got new %v with %v", s.IsNew, s.Values)
+		}
+		s.Options.MaxAge = -1
+	})
+	if loaded.ID != session.ID {
+		t.Errorf("want the same session, got %s and %s", session.ID, loaded.ID)
+	}
+
+	saveSession(t, store, []*http.Cookie{cookie}, func(s *sessions.Session) {
+		if !s.IsNew {
+			t.Error("want a deleted session to start over")
+		}
+	})
+
+	forged := &http.Cookie{Name: "app", Value: session.ID}
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(forged)
+	if s, err := store.New(req, "app"); err == nil || !s.IsNew {
+		t.Errorf("want an unsigned cookie rejected, got %v", err)
+	}
+}
+
+func TestSessionStoreSlidingExpiration(t *testing.T) {
+	store := newSessionStore(t, pkg.WithSessionOptions(sessions.Options{Path: "/", MaxAge: 1}))
+
+	_, cookie := saveSession(t, store, nil, func(s *sessions.Session) {
+		s.Values["step"] = "one"
+	})
+	for range 3 {
+		time.Sleep(600 * time.Millisecond)
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.AddCookie(cookie)
+		if s, err := store.New(req, "app"); err != nil || s.IsNew {
+			t.Fatalf("want each load to extend the session, got new %v (%v)", s.IsNew, err)
+		}
+	}
+	time.Sleep(1100 * time.Millisecond)
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(cookie)
+	if s, _ := store.New(req, "app"); !s.IsNew {
+		t.Error("want the session expired after MaxAge without activity")
+	}
+}
+
+func TestSessionStoreUserSessions(t *testing.T) {
+	store := newSessionStore(t, pkg.WithSessionUser("user_id"))
+	ctx := context.Background()
+
+	var cookies []*http.Cookie
+	for range 2 {
+		_, cookie := saveSession(t, store, nil, func(s *sessions.Session) {
+			s.Values["user_id"] = 42
+		})
+		cookies = append(cookies, cookie)
+	}
+	_, other := saveSession(t, store, nil, func(s *sessions.Session) {
+		s.Values["user_id"] = 7
+	})
+
+	if ids, err := store.UserSessions(ctx, "42"); err != nil || len(ids) != 2 {
+		t.Fatalf("want 2 sessions of the user, got %v (%v)", ids, err)
+	}
+	if err := store.DestroyUserSessions(ctx, "42"); err != nil {
+		t.Fatal(err)
+	}
+	for _, cookie := range append(cookies, other) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.AddCookie(cookie)
+		s, err := store.New(req, "app")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := s.Values["user_id"] == nil; s.IsNew != want {
+			t.Errorf("want only the sessions of the user destroyed, got new %v with %v", s.IsNew, s.Values)
+		}
+	}
+	if ids, _ := store.UserSessions(ctx, "42"); len(ids) != 0 {
+		t.Errorf("want no sessions left, got %v", ids)
+	}
+}
@@ -0,0 +1,46 @@
+package cache
+
+import (
+	"cacher/pkg"
+	"context"
+	"sort"
+	"testing"
+)
+
+func TestSet(t *testing.T) {
+	c := pkg.NewMemoryCache()
+	defer c.Close(context.Background())
+	users, err := pkg.NewSet[user](c, "online")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	alice, bob := user{ID: 1, Name: "alice"}, user{ID: 2, Name: "bob"}
+	if added, err := users.Add(ctx, alice, bob, alice); err != nil || added != 2 {
+		t.Errorf("want 2 added, got %v (%v)", added, err)
+	}
+	if found, err := users.Contains(ctx, user{ID: 1, Name: "alice"}); err != nil || !found {
+		t.Errorf("want an equal value to be a member, got %v (%v)", found, err)
+	}
+	if n, _ := users.Len(ctx); n != 2 {
+		t.Errorf("want 2 members, got %v", n)
+	}
+
+	members, err := users.Members(ctx)
+	sort.Slice(members, func(i, j int) bool { return members[i].ID < members[j].ID })
+	if err != nil || len(members) != 2 || members[0] != alice || members[1] != bob {
+		t.Errorf("want alice and bob, got %v (%v)", members, err)
+	}
+
+	if removed, _ := users.Remove(ctx, bob); removed != 1 {
+		t.Errorf("want bob removed, got %v", removed)
+	}
+	if found, _ := users.Contains(ctx, bob); found {
+		t.Error("want bob gone")
+	}
+	_ = users.Clear(ctx)
+	if n, _ := users.Len(ctx); n != 0 {
+		t.Errorf("want an empty set after Clear, got %v", n)
+	}
+}
@@ -0,0 +1,27 @@
+package cache
+
+import (
+	"cacher/pkg"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRedisShards(t *testing.T) {
+	ctx := context.Background()
+	c := pkg.NewCache(pkg.WithRedisShards(redisAddrOrSkip(t)), pkg.WithPrefix("shards:"))
+	defer c.Close(ctx)
+
+	if err := c.SetWithTTL(ctx, "greeting", "hello", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if value, err := c.Get(ctx, "greeting"); err != nil || value != "hello" {
+		t.Errorf("want the value back, got %v (%v)", value, err)
+	}
+	if err := c.Ping(ctx); err != nil {
+		t.Errorf("want every shard to answer, got %v", err)
+	}
+	if err := c.FlushPrefix(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
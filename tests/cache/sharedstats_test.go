@@ -0,0 +1,35 @@
+package cache
+
+import (
+	"cacher/internal/adapters"
+	"cacher/pkg"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSharedStatistics(t *testing.T) {
+	server := adapters.NewMemory()
+	ctx := context.Background()
+
+	for _, hits := range []int{1, 2} {
+		c := pkg.NewCache(pkg.WithDefaultTTL(pkg.Forever), pkg.WithAdapter(adapters.NewCache(server)), pkg.WithSharedStatistics("test", time.Minute))
+		_ = c.Set(ctx, "key", "value")
+		for range hits {
+			_, _ = c.Get(ctx, "key")
+		}
+		_, _ = c.Get(ctx, "missing")
+		// Close flushes the counters of this instance
+		_ = c.Close(ctx)
+	}
+
+	c := pkg.NewCache(pkg.WithDefaultTTL(pkg.Forever), pkg.WithAdapter(adapters.NewCache(server)), pkg.WithSharedStatistics("test", time.Minute))
+	defer c.Close(ctx)
+	stats, err := c.SharedStatistics(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats["key"]["hits"] != 3 || stats["missing"]["misses"] != 2 {
+		t.Errorf("want totals across instances, got %v", stats)
+	}
+}
@@ -0,0 +1,66 @@
+package cache
+
+import (
+	"bytes"
+	"cacher/pkg"
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSnapshotFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.snapshot")
+	ctx := context.Background()
+
+	c := pkg.NewMemoryCache(pkg.WithPrefix("app:"), pkg.WithSnapshotFile(path, 20*time.Millisecond))
+	_ = c.SetWithTTL(ctx, "key", "value", time.Minute)
+	time.Sleep(50 * time.Millisecond)
+
+	// A periodic snapshot is readable while the cache still runs
+	restarted := pkg.NewMemoryCache(pkg.WithPrefix("app:"), pkg.WithSnapshotFile(path, 0))
+	if value, err := restarted.Get(ctx, "key"); err != nil || value != "value" {
+		t.Errorf("want key restored from the periodic snapshot, got %v (%v)", value, err)
+	}
+	_ = restarted.Close(ctx)
+
+	_ = c.SetForever(ctx, "late", "value")
+	if err := c.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+	restarted = pkg.NewMemoryCache(pkg.WithPrefix("app:"), pkg.WithSnapshotFile(path, 0))
+	defer restarted.Close(ctx)
+	if value, err := restarted.Get(ctx, "late"); err != nil || value != "value" {
+		t.Errorf("want Close to save a snapshot, got %v (%v)", value, err)
+	}
+	if ttl, _ := restarted.TTL(ctx, "key"); ttl <= 0 || ttl > time.Minute {
+		t.Errorf("want the expiration restored, got %s", ttl)
+	}
+}
+
+func TestSnapshotRestore(t *testing.T) {
+	ctx := context.Background()
+	c := pkg.NewMemoryCache(pkg.WithDefaultTTL(pkg.Forever))
+	defer c.Close(ctx)
+	_ = c.Set(ctx, "key", "value")
+
+	var snapshot bytes.Buffer
+	if err := c.Snapshot(ctx, &snapshot); err != nil {
+		t.Fatal(err)
+	}
+	restored := pkg.NewMemoryCache()
+	defer restored.Close(ctx)
+	if err := restored.Restore(ctx, &snapshot); err != nil {
+		t.Fatal(err)
+	}
+	if value, err := restored.Get(ctx, "key"); err != nil || value != "value" {
+		t.Errorf("want key restored, got %v (%v)", value, err)
+	}
+
+	remote := pkg.NewCache(pkg.WithRedisAddr("127.0.0.1:1"))
+	defer remote.Close(ctx)
+	if err := remote.Snapshot(ctx, &snapshot); !errors.Is(err, pkg.ErrUnsupported) {
+		t.Errorf("want ErrUnsupported without a memory, got %v", err)
+	}
+}
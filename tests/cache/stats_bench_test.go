@@ -0,0 +1,59 @@
+package cache
+
+import (
+	"cacher/pkg"
+	"context"
+	"strconv"
+	"testing"
+)
+
+// BenchmarkStatsParallelHits measures recording hits on one hot key from
+// many goroutines
+func BenchmarkStatsParallelHits(b *testing.B) {
+	c := pkg.NewMemoryCache(pkg.WithDefaultTTL(pkg.Forever), pkg.WithStats(true))
+	defer c.Close(context.Background())
+	ctx := context.Background()
+	_ = c.Set(ctx, "key", "value")
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_, _ = c.Get(ctx, "key")
+		}
+	})
+}
+
+// BenchmarkStatsParallelKeys measures recording hits spread over many keys
+func BenchmarkStatsParallelKeys(b *testing.B) {
+	c := pkg.NewMemoryCache(pkg.WithDefaultTTL(pkg.Forever), pkg.WithStats(true))
+	defer c.Close(context.Background())
+	ctx := context.Background()
+
+	keys := make([]string, 1024)
+	for i := range keys {
+		keys[i] = "key:" + strconv.Itoa(i)
+		_ = c.Set(ctx, keys[i], "value")
+	}
+
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			_, _ = c.Get(ctx, keys[i%len(keys)])
+			i++
+		}
+	})
+}
+
+// BenchmarkHotKeysParallelHits measures recording hits on one hot key with
+// latencies and hot key tracking from many goroutines
+func BenchmarkHotKeysParallelHits(b *testing.B) {
+	c := pkg.NewMemoryCache(pkg.WithDefaultTTL(pkg.Forever), pkg.WithStats(true), pkg.WithHotKeys(64))
+	defer c.Close(context.Background())
+	ctx := context.Background()
+	_ = c.Set(ctx, "key", "value")
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_, _ = c.Get(ctx, "key")
+		}
+	})
+}
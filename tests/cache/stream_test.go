@@ -0,0 +1,123 @@
+package cache
+
+import (
+	"cacher/pkg"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStreamConsumer(t *testing.T) {
+	c := pkg.NewMemoryCache()
+	defer c.Close(context.Background())
+	ctx := context.Background()
+	events, err := pkg.NewStream(c, "events", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var mutex sync.Mutex
+	handled := map[string]string{}
+	failOnce := true
+	handler := func(ctx context.Context, message pkg.StreamMessage) error {
+		mutex.Lock()
+		defer mutex.Unlock()
+		if message.Values["user"] == "2" && failOnce {
+			failOnce = false
+			return errors.New("boom")
+		}
+		handled[message.ID] = message.Values["user"]
+		return nil
+	}
+	consumer, err := events.Consumer(ctx, "mailer", "a", handler,
+		pkg.WithStreamBlock(20*time.Millisecond), pkg.WithStreamClaimAfter(50*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, user := range []string{"1", "2", "3"} {
+		if _, err := events.Publish(ctx, map[string]interface{}{"user": user}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	result := make(chan error, 1)
+	go func() { result <- consumer.Run(ctx) }()
+
+	// The failed message is claimed again once idle for the claim delay
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		mutex.Lock()
+		n := len(handled)
+		mutex.Unlock()
+		if n == 3 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("want 3 messages handled, got %d", n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := consumer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-result; err != nil {
+		t.Errorf("want Run to return nil after Close, got %v", err)
+	}
+	if err := consumer.Run(ctx); !errors.Is(err, pkg.ErrConsumerRunning) {
+		t.Errorf("want ErrConsumerRunning after Close, got %v", err)
+	}
+	if n, _ := events.Len(ctx); n != 3 {
+		t.Errorf("want 3 messages in the stream, got %d", n)
+	}
+}
+
+func TestStreamConsumerGroups(t *testing.T) {
+	c := pkg.NewMemoryCache()
+	defer c.Close(context.Background())
+	ctx, cancel := context.WithCancel(context.Background())
+	events, _ := pkg.NewStream(c, "events", 0)
+	_, _ = events.Publish(ctx, map[string]interface{}{"before": "group"})
+
+	// Every group gets every message, from the start when asked to
+	counts := make(chan string, 10)
+	for _, group := range []string{"billing", "audit"} {
+		consumer, err := events.Consumer(ctx, group, "a", func(ctx context.Context, message pkg.StreamMessage) error {
+			counts <- group
+			return nil
+		}, pkg.WithStreamBlock(10*time.Millisecond), pkg.WithStreamFromStart())
+		if err != nil {
+			t.Fatal(err)
+		}
+		go func() { _ = consumer.Run(ctx) }()
+	}
+	_, _ = events.Publish(ctx, map[string]interface{}{"after": "group"})
+
+	seen := map[string]int{}
+	for range 4 {
+		select {
+		case group := <-counts:
+			seen[group]++
+		case <-time.After(2 * time.Second):
+			t.Fatalf("want 2 messages per group, got %v", seen)
+		}
+	}
+	if seen["billing"] != 2 || seen["audit"] != 2 {
+		t.Errorf("want 2 messages per group, got %v", seen)
+	}
+
+	if _, err := events.Consumer(ctx, "billing", "c", func(ctx context.Context, message pkg.StreamMessage) error {
+		return nil
+	}, pkg.WithStreamBlock(0)); err == nil {
+		t.Error("want a block of 0 rejected")
+	}
+	consumer, _ := events.Consumer(ctx, "billing", "b", func(ctx context.Context, message pkg.StreamMessage) error {
+		return nil
+	})
+	cancel()
+	if err := consumer.Run(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("want Run to return the context error, got %v", err)
+	}
+}
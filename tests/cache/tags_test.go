@@ -0,0 +1,30 @@
+package cache
+
+import (
+	"cacher/pkg"
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestInvalidateTag(t *testing.T) {
+	c := pkg.NewMemoryCache(pkg.WithDefaultTTL(pkg.Forever))
+	ctx := context.Background()
+
+	_ = c.Set(ctx, "user:42:profile", "profile", pkg.WithTags("user:42"))
+	_ = c.Set(ctx, "user:42:orders", "orders", pkg.WithTags("user:42", "orders"))
+	_ = c.Set(ctx, "user:7:profile", "profile", pkg.WithTags("user:7"))
+
+	if err := c.InvalidateTag(ctx, "user:42"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, key := range []string{"user:42:profile", "user:42:orders"} {
+		if _, err := c.Get(ctx, key); !errors.Is(err, pkg.ErrCacheMiss) {
+			t.Errorf("want %s invalidated, got %v", key, err)
+		}
+	}
+	if v, err := c.Get(ctx, "user:7:profile"); err != nil || v != "profile" {
+		t.Errorf("want untouched key, got %v (%v)", v, err)
+	}
+}
@@ -0,0 +1,78 @@
+package cache
+
+import (
+	"cacher/pkg"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTouch(t *testing.T) {
+	c := pkg.NewMemoryCache(pkg.WithPrefix("app:"))
+	defer c.Close(context.Background())
+	ctx := context.Background()
+
+	_ = c.SetWithTTL(ctx, "key", "value", time.Second)
+	if err := c.Touch(ctx, "key", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if ttl, _ := c.TTL(ctx, "key"); ttl <= time.Minute {
+		t.Errorf("want about an hour left, got %s", ttl)
+	}
+	if value, _ := c.Get(ctx, "key"); value != "value" {
+		t.Errorf("want the value kept, got %v", value)
+	}
+	if err := c.Touch(ctx, "missing", time.Hour); !errors.Is(err, pkg.ErrCacheMiss) {
+		t.Errorf("want ErrCacheMiss, got %v", err)
+	}
+	if err := c.Touch(ctx, "key", 0); err == nil {
+		t.Error("want an error for a non-positive ttl")
+	}
+}
+
+func TestSlidingTTL(t *testing.T) {
+	c := pkg.NewMemoryCache(pkg.WithSlidingTTL(80 * time.Millisecond))
+	defer c.Close(context.Background())
+	ctx := context.Background()
+
+	_ = c.SetWithTTL(ctx, "session", "user", 80*time.Millisecond)
+	for range 4 {
+		time.Sleep(40 * time.Millisecond)
+		if _, err := c.Get(ctx, "session"); err != nil {
+			t.Fatalf("want reads to keep the session alive, got %v", err)
+		}
+	}
+	time.Sleep(120 * time.Millisecond)
+	if _, err := c.Get(ctx, "session"); !errors.Is(err, pkg.ErrCacheMiss) {
+		t.Errorf("want the session expired once idle, got %v", err)
+	}
+}
+
+func TestSlidingTTLForever(t *testing.T) {
+	c := pkg.NewMemoryCache(pkg.WithSlidingTTL(time.Minute))
+	defer c.Close(context.Background())
+	ctx := context.Background()
+
+	_ = c.SetForever(ctx, "config", "value")
+	if _, err := c.Get(ctx, "config"); err != nil {
+		t.Fatal(err)
+	}
+	if ttl, err := c.TTL(ctx, "config"); err != nil || ttl != pkg.NoExpiration {
+		t.Errorf("want a forever key left without an expiration, got %s (%v)", ttl, err)
+	}
+}
+
+func TestSlidingTTLLocalHit(t *testing.T) {
+	c := pkg.NewMemoryCache(pkg.WithSlidingTTL(time.Hour), pkg.WithLocalTier(time.Minute))
+	defer c.Close(context.Background())
+	ctx := context.Background()
+
+	_ = c.SetWithTTL(ctx, "session", "user", time.Second)
+	if _, err := c.Get(ctx, "session"); err != nil {
+		t.Fatal(err)
+	}
+	if ttl, _ := c.TTL(ctx, "session"); ttl > time.Second {
+		t.Errorf("want a local tier hit not to slide the key, got %s left", ttl)
+	}
+}
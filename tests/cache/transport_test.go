@@ -0,0 +1,95 @@
+package cache
+
+import (
+	"cacher/pkg"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestTransport(t *testing.T) {
+	var requests atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		switch r.URL.Path {
+		case "/fresh":
+			w.Header().Set("Cache-Control", "max-age=60")
+		case "/etag":
+			w.Header().Set("Cache-Control", "no-cache")
+			w.Header().Set("ETag", `"v1"`)
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		case "/private":
+			w.Header().Set("Cache-Control", "no-store")
+		case "/vary":
+			w.Header().Set("Cache-Control", "max-age=60")
+			w.Header().Set("Vary", "Accept-Language")
+			_, _ = io.WriteString(w, r.Header.Get("Accept-Language")+" ")
+		}
+		_, _ = io.WriteString(w, "body of "+r.URL.Path)
+	}))
+	defer server.Close()
+
+	c := pkg.NewMemoryCache()
+	defer c.Close(context.Background())
+	transport := pkg.NewTransport(c)
+	client := &http.Client{Transport: transport}
+
+	get := func(path string, header ...string) (string, string) {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodGet, server.URL+path, nil)
+		for i := 0; i+1 < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return string(body), resp.Header.Get("X-Cache")
+	}
+
+	for _, want := range []string{"MISS", "HIT"} {
+		if body, status := get("/fresh"); body != "body of /fresh" || status != want {
+			t.Errorf("want %s with the body, got %s with %q", want, status, body)
+		}
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("want a fresh response served from the cache, got %d requests", n)
+	}
+
+	for _, want := range []string{"MISS", "HIT"} {
+		if body, status := get("/etag"); body != "body of /etag" || status != want {
+			t.Errorf("want %s with the body, got %s with %q", want, status, body)
+		}
+	}
+	if n := requests.Load(); n != 3 {
+		t.Errorf("want a stale response revalidated, got %d requests", n)
+	}
+
+	get("/private")
+	if _, status := get("/private"); status != "MISS" {
+		t.Errorf("want no-store responses not cached, got %s", status)
+	}
+
+	if body, _ := get("/vary", "Accept-Language", "en"); body != "en body of /vary" {
+		t.Errorf("want the English variant, got %q", body)
+	}
+	if body, status := get("/vary", "Accept-Language", "fr"); body != "fr body of /vary" || status != "MISS" {
+		t.Errorf("want another variant fetched, got %s with %q", status, body)
+	}
+	if body, status := get("/vary", "Accept-Language", "fr"); body != "fr body of /vary" || status != "HIT" {
+		t.Errorf("want the variant cached, got %s with %q", status, body)
+	}
+
+	stats := transport.Statistics()
+	if stats["hits"] != 2 || stats["revalidations"] != 1 || stats["misses"] != 6 {
+		t.Errorf("want 2 hits, 1 revalidation and 6 misses, got %v", stats)
+	}
+}
@@ -0,0 +1,142 @@
+package cache
+
+import (
+	"cacher/pkg"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// transfer moves amount from one counter to another if it holds enough
+func transfer(ctx context.Context, c pkg.Cache, from, to string, amount int) error {
+	return c.Txn(ctx, func(tx pkg.Tx) error {
+		balances := make([]int, 2)
+		for i, key := range []string{from, to} {
+			value, err := tx.Get(ctx, key)
+			if err != nil {
+				return err
+			}
+			if balances[i], err = strconv.Atoi(fmt.Sprint(value)); err != nil {
+				return err
+			}
+		}
+		if balances[0] < amount {
+			return errors.New("insufficient budget")
+		}
+		tx.SetWithTTL(from, balances[0]-amount, pkg.Forever)
+		tx.SetWithTTL(to, balances[1]+amount, pkg.Forever)
+		return nil
+	}, from, to)
+}
+
+func testTxn(t *testing.T, c pkg.Cache) {
+	ctx := context.Background()
+	if err := c.SetWithTTL(ctx, "budget:a", 100, pkg.Forever); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SetWithTTL(ctx, "budget:b", 0, pkg.Forever); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				err := transfer(ctx, c, "budget:a", "budget:b", 5)
+				if !errors.Is(err, pkg.ErrTxConflict) {
+					errs <- err
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	a, _ := c.Get(ctx, "budget:a")
+	b, _ := c.Get(ctx, "budget:b")
+	if fmt.Sprint(a) != "0" || fmt.Sprint(b) != "100" {
+		t.Errorf("want the whole budget moved, got %v and %v", a, b)
+	}
+	if err := transfer(ctx, c, "budget:a", "budget:b", 5); err == nil || err.Error() != "insufficient budget" {
+		t.Errorf("want the error of fn, got %v", err)
+	}
+}
+
+func testTxnRetries(t *testing.T, c pkg.Cache) {
+	ctx := context.Background()
+	if err := c.SetWithTTL(ctx, "watched", "1", pkg.Forever); err != nil {
+		t.Fatal(err)
+	}
+
+	attempts := 0
+	var incr *pkg.IntResult
+	err := c.Txn(ctx, func(tx pkg.Tx) error {
+		attempts++
+		if _, err := tx.Get(ctx, "watched"); err != nil {
+			return err
+		}
+		if attempts == 1 {
+			// A write from outside the transaction makes it run again
+			if err := c.SetWithTTL(ctx, "watched", "2", pkg.Forever); err != nil {
+				return err
+			}
+		}
+		incr = tx.Increment("runs", 1)
+		tx.Delete("watched")
+		return nil
+	}, "watched")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attempts != 2 {
+		t.Errorf("want 2 attempts, got %d", attempts)
+	}
+	if n, err := incr.Result(); err != nil || n != 1 {
+		t.Errorf("want the writes applied once, got %d, %v", n, err)
+	}
+	if _, err := c.Get(ctx, "watched"); !errors.Is(err, pkg.ErrCacheMiss) {
+		t.Errorf("want the watched key deleted, got %v", err)
+	}
+
+	attempts = 0
+	err = c.Txn(ctx, func(tx pkg.Tx) error {
+		attempts++
+		tx.Increment("runs", 1)
+		return c.SetWithTTL(ctx, "runs", strconv.Itoa(-attempts), pkg.Forever)
+	}, "runs")
+	if !errors.Is(err, pkg.ErrTxConflict) {
+		t.Errorf("want ErrTxConflict when every attempt conflicts, got %v", err)
+	}
+}
+
+func TestTxnMemory(t *testing.T) {
+	c := pkg.NewMemoryCache(pkg.WithLocalTier(time.Minute))
+	defer c.Close(context.Background())
+	testTxn(t, c)
+	testTxnRetries(t, c)
+}
+
+func TestTxnRedis(t *testing.T) {
+	addr := redisAddrOrSkip(t)
+	ctx := context.Background()
+	c := pkg.NewCache(pkg.WithRedisAddr(addr), pkg.WithPrefix("txn:"))
+	defer c.Close(ctx)
+	if err := c.DeleteMany(ctx, "budget:a", "budget:b", "watched", "runs"); err != nil {
+		t.Fatal(err)
+	}
+	testTxn(t, c)
+	testTxnRetries(t, c)
+}
@@ -0,0 +1,138 @@
+package cache
+
+import (
+	"cacher/pkg"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type user struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func TestTypedCache(t *testing.T) {
+	c := pkg.NewTypedCache[user](pkg.NewMemoryCache(pkg.WithDefaultTTL(pkg.Forever)))
+	ctx := context.Background()
+
+	calls := 0
+	loader := func() user {
+		calls++
+		return user{ID: 42, Name: "arash"}
+	}
+
+	for range 2 {
+		u, err := c.Wrap(ctx, "user:42", loader)
+		if err != nil || u.Name != "arash" {
+			t.Errorf("want arash, got %v (%v)", u, err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("want 1 loader call, got %v", calls)
+	}
+
+	if _, err := c.Get(ctx, "user:missing"); err == nil {
+		t.Errorf("want error for missing key")
+	}
+}
+
+func TestGetOrSet(t *testing.T) {
+	c := pkg.NewTypedCache[user](pkg.NewMemoryCache(pkg.WithDefaultTTL(pkg.Forever), pkg.WithNegativeTTL(time.Minute)))
+	ctx := context.Background()
+
+	failure := errors.New("database down")
+	calls := 0
+	failing := func(ctx context.Context) (user, error) {
+		calls++
+		return user{}, failure
+	}
+	for range 2 {
+		if _, err := c.GetOrSet(ctx, "user:1", time.Minute, failing); !errors.Is(err, failure) {
+			t.Errorf("want the loader error, got %v", err)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("want failed results not cached, got %d loader calls", calls)
+	}
+
+	loaded, err := c.GetOrSet(ctx, "user:1", time.Minute, func(ctx context.Context) (user, error) {
+		return user{ID: 1, Name: "arash"}, nil
+	})
+	if err != nil || loaded.Name != "arash" {
+		t.Errorf("want arash, got %v (%v)", loaded, err)
+	}
+	if cached, err := c.Get(ctx, "user:1"); err != nil || cached.Name != "arash" {
+		t.Errorf("want the loaded value cached, got %v (%v)", cached, err)
+	}
+
+	missing := func(ctx context.Context) (user, error) {
+		calls++
+		return user{}, pkg.ErrNotFound
+	}
+	calls = 0
+	for range 2 {
+		if _, err := c.GetOrSet(ctx, "user:2", time.Minute, missing); !errors.Is(err, pkg.ErrNotFound) {
+			t.Errorf("want ErrNotFound, got %v", err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("want ErrNotFound cached with negative caching, got %d loader calls", calls)
+	}
+}
+
+func TestGetOrSetCancel(t *testing.T) {
+	c := pkg.NewTypedCache[user](pkg.NewMemoryCache(pkg.WithDefaultTTL(pkg.Forever)))
+	ctx, cancel := context.WithCancel(context.Background())
+
+	release := make(chan struct{})
+	defer close(release)
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	_, err := c.GetOrSet(ctx, "user:slow", time.Minute, func(ctx context.Context) (user, error) {
+		<-release
+		return user{ID: 1}, nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("want context.Canceled, got %v", err)
+	}
+	if _, err := c.GetOrSet(ctx, "user:slow", time.Minute, nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("want a done context to fail before loading, got %v", err)
+	}
+}
+
+func TestGetOrSetSharedCancel(t *testing.T) {
+	c := pkg.NewTypedCache[user](pkg.NewMemoryCache(pkg.WithDefaultTTL(pkg.Forever)))
+	first, cancel := context.WithCancel(context.Background())
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	go func() {
+		_, _ = c.GetOrSet(first, "user:1", time.Minute, func(ctx context.Context) (user, error) {
+			close(started)
+			<-release
+			return user{ID: 1}, ctx.Err()
+		})
+	}()
+	<-started
+
+	waited := make(chan error, 1)
+	go func() {
+		_, err := c.GetOrSet(context.Background(), "user:1", time.Minute, func(ctx context.Context) (user, error) {
+			return user{ID: 2}, nil
+		})
+		waited <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	close(release)
+	if err := <-waited; err != nil {
+		t.Errorf("want the other caller unaffected by the first canceling, got %v", err)
+	}
+	if loaded, err := c.Get(context.Background(), "user:1"); err != nil || loaded.ID != 1 {
+		t.Errorf("want the shared value stored, got %v (%v)", loaded, err)
+	}
+}
@@ -0,0 +1,48 @@
+package cache
+
+import (
+	"cacher/pkg"
+	"context"
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestUniqueCounter(t *testing.T) {
+	c := pkg.NewMemoryCache()
+	defer c.Close(context.Background())
+	visitors, err := pkg.NewUniqueCounter(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	for i := range 100 {
+		_, _ = visitors.Track(ctx, "monday", fmt.Sprint(i))
+		_, _ = visitors.Track(ctx, "tuesday", fmt.Sprint(i+50))
+	}
+	if changed, _ := visitors.Track(ctx, "monday", "1"); changed {
+		t.Error("want a known id not to change the count")
+	}
+	// Counts are estimates, within a few percent of the real ones
+	near := func(n, want int64) bool {
+		return math.Abs(float64(n-want)) <= float64(want)*0.03
+	}
+	if n, err := visitors.Count(ctx, "monday"); err != nil || !near(n, 100) {
+		t.Errorf("want about 100, got %v (%v)", n, err)
+	}
+	if n, _ := visitors.Count(ctx, "monday", "tuesday"); !near(n, 150) {
+		t.Errorf("want about 150 over both days, got %v", n)
+	}
+
+	if err := visitors.Merge(ctx, "week", "monday", "tuesday"); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := visitors.Count(ctx, "week"); !near(n, 150) {
+		t.Errorf("want about 150 in the merged counter, got %v", n)
+	}
+	_ = visitors.Reset(ctx, "week")
+	if n, _ := visitors.Count(ctx, "week"); n != 0 {
+		t.Errorf("want 0 after Reset, got %v", n)
+	}
+}
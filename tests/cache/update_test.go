@@ -0,0 +1,55 @@
+package cache
+
+import (
+	"cacher/pkg"
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestUpdate(t *testing.T) {
+	c := pkg.NewMemoryCache(pkg.WithDefaultTTL(time.Minute), pkg.WithEncryption(make([]byte, 32)))
+	defer c.Close(context.Background())
+	ctx := context.Background()
+
+	increment := func(old []byte) ([]byte, error) {
+		n, _ := strconv.Atoi(string(old))
+		return []byte(strconv.Itoa(n + 1)), nil
+	}
+	var wg sync.WaitGroup
+	for range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := c.Update(ctx, "counter", increment); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if v, err := c.Get(ctx, "counter"); err != nil || v != "50" {
+		t.Errorf("want 50 after concurrent updates, got %v (%v)", v, err)
+	}
+
+	failure := errors.New("invalid")
+	if err := c.Update(ctx, "counter", func(old []byte) ([]byte, error) {
+		return nil, failure
+	}); !errors.Is(err, failure) {
+		t.Errorf("want the error of fn, got %v", err)
+	}
+	if v, _ := c.Get(ctx, "counter"); v != "50" {
+		t.Errorf("want a failed update to write nothing, got %v", v)
+	}
+
+	if err := c.Update(ctx, "counter", func(old []byte) ([]byte, error) {
+		return nil, nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Get(ctx, "counter"); !errors.Is(err, pkg.ErrCacheMiss) {
+		t.Errorf("want a nil value to delete the key, got %v", err)
+	}
+}
@@ -0,0 +1,116 @@
+package cache
+
+import (
+	"cacher/pkg"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWarmFromSeq(t *testing.T) {
+	c := pkg.NewMemoryCache()
+	defer c.Close(context.Background())
+	ctx := context.Background()
+
+	entries := func(yield func(string, interface{}) bool) {
+		for i := range 1050 {
+			if !yield(fmt.Sprintf("key:%d", i), i) {
+				return
+			}
+		}
+	}
+	var reports atomic.Int64
+	progress, err := c.Warm(ctx, pkg.WarmFromSeq(entries), pkg.WithWarmTTL(time.Minute), pkg.WithWarmBatchSize(100), pkg.WithWarmConcurrency(3),
+		pkg.WithWarmProgress(func(pkg.WarmProgress) {
+			reports.Add(1)
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if progress.Read != 1050 || progress.Stored != 1050 || reports.Load() != 11 {
+		t.Errorf("want 1050 entries stored in 11 batches, got %+v after %d reports", progress, reports.Load())
+	}
+	if value, err := c.Get(ctx, "key:1049"); err != nil || value != "1049" {
+		t.Errorf("want the last entry stored, got %v (%v)", value, err)
+	}
+	if ttl, _ := c.TTL(ctx, "key:0"); ttl <= 0 || ttl > time.Minute {
+		t.Errorf("want the warm ttl, got %s", ttl)
+	}
+
+	if _, err := c.Warm(ctx, pkg.WarmFromSeq(entries)); !errors.Is(err, pkg.ErrNoDefaultTTL) {
+		t.Errorf("want ErrNoDefaultTTL, got %v", err)
+	}
+}
+
+func TestWarmFromJSONLines(t *testing.T) {
+	c := pkg.NewMemoryCache(pkg.WithDefaultTTL(pkg.Forever))
+	defer c.Close(context.Background())
+	ctx := context.Background()
+
+	lines := `{"key": "user:1", "value": "ada"}
+
+{"key": "user:2", "value": {"name": "grace"}}
+`
+	if _, err := c.Warm(ctx, pkg.WarmFromJSONLines(strings.NewReader(lines))); err != nil {
+		t.Fatal(err)
+	}
+	if value, _ := c.Get(ctx, "user:1"); value != "ada" {
+		t.Errorf("want a string value stored as is, got %v", value)
+	}
+	if value, _ := c.Get(ctx, "user:2"); value != `{"name": "grace"}` {
+		t.Errorf("want other values stored as JSON, got %v", value)
+	}
+
+	progress, err := c.Warm(ctx, pkg.WarmFromJSONLines(strings.NewReader(lines+"user:3\n")))
+	if err == nil || !strings.Contains(err.Error(), "line 4") {
+		t.Errorf("want the bad line reported, got %v", err)
+	}
+	if progress.Read != 2 {
+		t.Errorf("want the entries before it read, got %+v", progress)
+	}
+}
+
+func TestWarmFromRows(t *testing.T) {
+	db := openProducts(t)
+	c := pkg.NewMemoryCache(pkg.WithDefaultTTL(pkg.Forever))
+	defer c.Close(context.Background())
+	ctx := context.Background()
+
+	rows, err := db.QueryContext(ctx, "SELECT 'product:' || id, name FROM products")
+	if err != nil {
+		t.Fatal(err)
+	}
+	progress, err := c.Warm(ctx, pkg.WarmFromRows(rows))
+	if err != nil || progress.Stored != 2 {
+		t.Fatalf("want both products stored, got %+v (%v)", progress, err)
+	}
+	if value, _ := c.Get(ctx, "product:2"); value != "book" {
+		t.Errorf("want product:2 stored, got %v", value)
+	}
+}
+
+func TestWarmStopsOnBackendError(t *testing.T) {
+	c := pkg.NewCache(pkg.WithRedisAddr("127.0.0.1:1"), pkg.WithDefaultTTL(time.Minute))
+	defer c.Close(context.Background())
+
+	var read int
+	entries := func(yield func(string, interface{}) bool) {
+		for i := 0; i < 100000; i++ {
+			read++
+			if !yield(fmt.Sprint(i), i) {
+				return
+			}
+		}
+	}
+	progress, err := c.Warm(context.Background(), pkg.WarmFromSeq(entries), pkg.WithWarmBatchSize(10), pkg.WithWarmConcurrency(1))
+	if err == nil || progress.Stored != 0 {
+		t.Errorf("want the backend error, got %+v (%v)", progress, err)
+	}
+	if read == 100000 {
+		t.Error("want the source no longer read after the error")
+	}
+}
@@ -0,0 +1,154 @@
+package cache
+
+import (
+	"cacher/pkg"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWrapContext(t *testing.T) {
+	c := pkg.NewMemoryCache(pkg.WithDefaultTTL(pkg.Forever))
+	defer c.Close(context.Background())
+	ctx := context.Background()
+
+	failure := errors.New("database down")
+	calls := 0
+	for range 2 {
+		_, err := c.WrapContext(ctx, "key", func(ctx context.Context) (interface{}, error) {
+			calls++
+			return nil, failure
+		})
+		if !errors.Is(err, failure) {
+			t.Errorf("want the loader error, got %v", err)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("want errors not cached, got %d loader calls", calls)
+	}
+	if _, err := c.Get(ctx, "key"); !errors.Is(err, pkg.ErrCacheMiss) {
+		t.Errorf("want nothing stored after a failure, got %v", err)
+	}
+
+	for range 2 {
+		v, err := c.WrapContext(ctx, "key", func(ctx context.Context) (interface{}, error) {
+			calls++
+			return "loaded", nil
+		})
+		if err != nil || v != "loaded" {
+			t.Errorf("want loaded, got %v (%v)", v, err)
+		}
+	}
+	if calls != 3 {
+		t.Errorf("want the value cached after a success, got %d loader calls", calls)
+	}
+
+	for range 2 {
+		_, err := c.RememberForeverContext(ctx, "missing", func(ctx context.Context) (interface{}, error) {
+			calls++
+			return nil, pkg.ErrNotFound
+		})
+		if !errors.Is(err, pkg.ErrNotFound) {
+			t.Errorf("want ErrNotFound, got %v", err)
+		}
+	}
+	if calls != 4 {
+		t.Errorf("want ErrNotFound cached for the negative ttl, got %d loader calls", calls)
+	}
+}
+
+func TestWrapContextCancel(t *testing.T) {
+	c := pkg.NewMemoryCache(pkg.WithDefaultTTL(pkg.Forever))
+	defer c.Close(context.Background())
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	release := make(chan struct{})
+	defer close(release)
+	_, err := c.WrapTTLContext(ctx, "slow", time.Minute, func(ctx context.Context) (interface{}, error) {
+		<-release
+		return "late", nil
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("want context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestWrapContextXFetch(t *testing.T) {
+	c := pkg.NewMemoryCache(pkg.WithDefaultTTL(time.Minute), pkg.WithEarlyExpiration(1))
+	defer c.Close(context.Background())
+	ctx := context.Background()
+
+	failure := errors.New("database down")
+	if _, err := c.WrapContext(ctx, "key", func(ctx context.Context) (interface{}, error) {
+		return nil, failure
+	}); !errors.Is(err, failure) {
+		t.Errorf("want the loader error, got %v", err)
+	}
+	if v, err := c.WrapContext(ctx, "key", func(ctx context.Context) (interface{}, error) {
+		return "loaded", nil
+	}); err != nil || v != "loaded" {
+		t.Errorf("want loaded, got %v (%v)", v, err)
+	}
+}
+
+func TestWrapContextSharedCancel(t *testing.T) {
+	c := pkg.NewMemoryCache(pkg.WithDefaultTTL(pkg.Forever))
+	defer c.Close(context.Background())
+	first, cancel := context.WithCancel(context.Background())
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	go func() {
+		_, _ = c.WrapContext(first, "shared", func(ctx context.Context) (interface{}, error) {
+			close(started)
+			<-release
+			return "loaded", ctx.Err()
+		})
+	}()
+	<-started
+
+	waited := make(chan error, 1)
+	go func() {
+		v, err := c.WrapContext(context.Background(), "shared", func(ctx context.Context) (interface{}, error) {
+			return "second", nil
+		})
+		if err == nil && v != "loaded" {
+			err = errors.New("want the shared value")
+		}
+		waited <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	close(release)
+	if err := <-waited; err != nil {
+		t.Errorf("want the other caller unaffected by the first canceling, got %v", err)
+	}
+}
+
+func TestWrapIgnoresContextLoaderErrors(t *testing.T) {
+	c := pkg.NewMemoryCache(pkg.WithDefaultTTL(pkg.Forever))
+	defer c.Close(context.Background())
+	ctx := context.Background()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	go func() {
+		_, _ = c.WrapContext(ctx, "key", func(ctx context.Context) (interface{}, error) {
+			close(started)
+			<-release
+			return nil, errors.New("database down")
+		})
+	}()
+	<-started
+	wrapped := make(chan interface{}, 1)
+	go func() {
+		wrapped <- c.Wrap(ctx, "key", func() interface{} { return "wrapped" })
+	}()
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	if v := <-wrapped; v != "wrapped" {
+		t.Errorf("want Wrap to run its own loader, got %v", v)
+	}
+}
@@ -0,0 +1,72 @@
+package cache
+
+import (
+	"cacher/internal/adapters"
+	"cacher/pkg"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestSetAsync(t *testing.T) {
+	server := adapters.NewMemory()
+	c := pkg.NewCache(pkg.WithAdapter(adapters.NewCache(server)))
+	ctx := context.Background()
+
+	for _, key := range []string{"a", "b", "c"} {
+		if err := c.SetAsync(ctx, key, key, time.Minute); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Close stores every queued write
+	_ = c.Close(ctx)
+	for _, key := range []string{"a", "b", "c"} {
+		if v, err := server.Get(ctx, key); err != nil || v != key {
+			t.Errorf("want %s stored, got %v (%v)", key, v, err)
+		}
+	}
+	if err := c.SetAsync(ctx, "d", "d", time.Minute); !errors.Is(err, pkg.ErrClosed) {
+		t.Errorf("want ErrClosed after Close, got %v", err)
+	}
+}
+
+func TestSetAsyncLastWriteWins(t *testing.T) {
+	server := adapters.NewMemory()
+	c := pkg.NewCache(pkg.WithAdapter(adapters.NewCache(server)), pkg.WithWriteBehind(1024, 1))
+	ctx := context.Background()
+
+	// Every first write has a ttl of its own, so a batched write lands
+	// in a different SetMany than the last write of its key
+	for i := range 20 {
+		key := fmt.Sprintf("key:%d", i)
+		_ = c.SetAsync(ctx, key, "first", time.Duration(i+1)*time.Minute)
+		_ = c.SetAsync(ctx, key, "last", time.Hour)
+	}
+	_ = c.Close(ctx)
+	for i := range 20 {
+		key := fmt.Sprintf("key:%d", i)
+		if v, err := server.Get(ctx, key); err != nil || v != "last" {
+			t.Errorf("want the last write of %s stored, got %v (%v)", key, v, err)
+		}
+		if ttl, _ := server.TTL(ctx, key); ttl <= 30*time.Minute {
+			t.Errorf("want the ttl of the last write of %s, got %s", key, ttl)
+		}
+	}
+}
+
+func TestSetAsyncBufferFull(t *testing.T) {
+	c := pkg.NewMemoryCache(pkg.WithWriteBehind(1, 1))
+	defer c.Close(context.Background())
+	ctx := context.Background()
+
+	var err error
+	for i := 0; i < 10000 && err == nil; i++ {
+		err = c.SetAsync(ctx, "key", i, time.Minute)
+	}
+	if !errors.Is(err, pkg.ErrWriteBufferFull) {
+		t.Errorf("want ErrWriteBufferFull, got %v", err)
+	}
+}
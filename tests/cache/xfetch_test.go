@@ -0,0 +1,78 @@
+package cache
+
+import (
+	"cacher/pkg"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestEarlyExpiration(t *testing.T) {
+	c := pkg.NewMemoryCache(pkg.WithEarlyExpiration(10))
+	defer c.Close(context.Background())
+	ctx := context.Background()
+
+	loads := 0
+	loader := func() interface{} {
+		loads++
+		time.Sleep(20 * time.Millisecond)
+		return "value"
+	}
+
+	// An expensive loader with a large beta is recomputed well before expiry
+	deadline := time.Now().Add(200 * time.Millisecond)
+	for time.Now().Before(deadline) {
+		if v := c.WrapTTL(ctx, "key", time.Second, loader); v != "value" {
+			t.Fatalf("want value, got %v", v)
+		}
+	}
+	if loads < 2 {
+		t.Errorf("want early recomputation, loaded %d times", loads)
+	}
+}
+
+func TestEarlyExpirationCheapLoader(t *testing.T) {
+	c := pkg.NewMemoryCache(pkg.WithEarlyExpiration(1))
+	defer c.Close(context.Background())
+	ctx := context.Background()
+
+	loads := 0
+	for range 100 {
+		c.WrapTTL(ctx, "key", time.Minute, func() interface{} {
+			loads++
+			return "value"
+		})
+	}
+	if loads != 1 {
+		t.Errorf("want a cheap loader far from expiry to run once, ran %d times", loads)
+	}
+}
+
+func TestEarlyExpirationBookkeeping(t *testing.T) {
+	c := pkg.NewMemoryCache(pkg.WithEarlyExpiration(1), pkg.WithStats(true))
+	defer c.Close(context.Background())
+	ctx := context.Background()
+
+	hits := 0
+	c.OnHit(func(ctx context.Context, event pkg.Event) { hits++ })
+	for range 3 {
+		c.WrapTTL(ctx, "key", time.Minute, func() interface{} { return "value" })
+	}
+	if hits != 2 {
+		t.Errorf("want the hooks to see 2 hits, got %d", hits)
+	}
+	stats, _ := c.KeyStatistics(ctx, "key")
+	if _, ok := stats["hit_p50_us"]; !ok || stats["hits"] != 2 {
+		t.Errorf("want the hit latencies recorded, got %v", stats)
+	}
+
+	if err := c.Delete(ctx, "key"); err != nil {
+		t.Fatal(err)
+	}
+	for key, err := range c.Keys(ctx, "*") {
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Errorf("want nothing left after Delete, got %s", key)
+	}
+}
@@ -0,0 +1,37 @@
+package codec
+
+import (
+	"cacher/codec"
+	"testing"
+)
+
+type payload struct {
+	ID    int
+	Name  string
+	Tags  []string
+	Score float64
+}
+
+func TestCodecsRoundTrip(t *testing.T) {
+	codecs := map[string]codec.Codec{
+		"json":    codec.JSON,
+		"gob":     codec.Gob,
+		"msgpack": codec.MsgPack,
+	}
+
+	in := payload{ID: 7, Name: "cache", Tags: []string{"a", "b"}, Score: 1.5}
+	for name, c := range codecs {
+		data, err := c.Marshal(in)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		var out payload
+		if err := c.Unmarshal(data, &out); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if out.ID != in.ID || out.Name != in.Name || len(out.Tags) != 2 || out.Score != in.Score {
+			t.Errorf("%s: want %v, got %v", name, in, out)
+		}
+	}
+}
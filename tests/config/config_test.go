@@ -0,0 +1,60 @@
+package config
+
+import (
+	"cacher/config"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv("CACHE_BACKEND", "redis")
+	t.Setenv("CACHE_ADDR", "cache:6379")
+	t.Setenv("CACHE_DB", "2")
+	t.Setenv("CACHE_DEFAULT_TTL", "10m")
+	t.Setenv("CACHE_TLS", "true")
+
+	c, err := config.FromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Backend != "redis" || c.Addr != "cache:6379" || c.DB != 2 || !c.TLS {
+		t.Errorf("want the environment applied, got %+v", c)
+	}
+	if c.DefaultTTL == nil || time.Duration(*c.DefaultTTL) != 10*time.Minute {
+		t.Errorf("want a default ttl of 10m, got %v", c.DefaultTTL)
+	}
+
+	t.Setenv("CACHE_DB", "two")
+	if _, err := config.FromEnv(); err == nil {
+		t.Error("want an error for an invalid CACHE_DB")
+	}
+}
+
+func TestFromFile(t *testing.T) {
+	dir := t.TempDir()
+	yamlPath := filepath.Join(dir, "cache.yaml")
+	_ = os.WriteFile(yamlPath, []byte("backend: memory\nmax_entries: 1000\ndefault_ttl: 0s\nlocal_ttl: 30s\n"), 0o600)
+	jsonPath := filepath.Join(dir, "cache.json")
+	_ = os.WriteFile(jsonPath, []byte(`{"backend": "memory", "max_entries": 1000, "default_ttl": "0s", "local_ttl": "30s"}`), 0o600)
+
+	for _, path := range []string{yamlPath, jsonPath} {
+		c, err := config.FromFile(path)
+		if err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		if c.Backend != "memory" || c.MaxEntries != 1000 || time.Duration(c.LocalTTL) != 30*time.Second {
+			t.Errorf("%s: want the file applied, got %+v", path, c)
+		}
+		if c.DefaultTTL == nil || *c.DefaultTTL != 0 {
+			t.Errorf("%s: want a default ttl of 0, got %v", path, c.DefaultTTL)
+		}
+	}
+
+	t.Setenv("CACHE_BACKEND", "null")
+	c, _ := config.FromFile(yamlPath)
+	if err := c.ApplyEnv(); err != nil || c.Backend != "null" || c.MaxEntries != 1000 {
+		t.Errorf("want the environment to override the file, got %+v (%v)", c, err)
+	}
+}